@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/catalog"
+	"github.com/aman-churiwal/api-gateway/internal/config"
+	"github.com/aman-churiwal/api-gateway/internal/discovery"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/aman-churiwal/api-gateway/internal/vault"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Config keys whose values are replaced when the effective config is printed
+var secretKeys = map[string]bool{
+	"secret":            true,
+	"password":          true,
+	"client_secret":     true,
+	"sts_client_secret": true,
+	"token":             true,
+	"api_key":           true,
+}
+
+const checkTimeout = 5 * time.Second
+
+// Handles `gateway validate`: parses and validates the config without connecting to anything
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	configPath := flags.String("config", defaultConfigPath(), configUsage)
+	flags.Parse(args)
+
+	if _, err := loadConfig(*configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", configSource(*configPath), err)
+		return 1
+	}
+
+	fmt.Printf("%s: configuration is valid\n", configSource(*configPath))
+	return 0
+}
+
+// Handles `gateway check-config`: validates the config, prints it with env
+// overrides applied and secrets masked, and checks every dependency is reachable
+func runCheckConfig(args []string) int {
+	flags := flag.NewFlagSet("check-config", flag.ExitOnError)
+	configPath := flags.String("config", defaultConfigPath(), configUsage)
+	quiet := flags.Bool("quiet", false, "skip printing the effective config")
+	flags.Parse(args)
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", configSource(*configPath), err)
+		return 1
+	}
+
+	if !*quiet {
+		effective, err := maskedConfig(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print config: %v\n", err)
+			return 1
+		}
+		fmt.Println(effective)
+	}
+
+	failures := 0
+	check := func(name string, err error) {
+		if err != nil {
+			failures++
+			fmt.Printf("FAIL  %s: %v\n", name, err)
+			return
+		}
+		fmt.Printf("OK    %s\n", name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if cfg.Vault != nil {
+		secrets := vault.NewSecrets(cfg.Vault)
+		err := secrets.Load(ctx)
+		check("vault "+cfg.Vault.Address, err)
+		if err == nil {
+			applyVaultSecrets(cfg, secrets)
+		}
+	}
+
+	if cfg.Catalog != nil {
+		check(cfg.Catalog.Backend+" catalog "+cfg.Catalog.Address, checkCatalog(ctx, cfg))
+	}
+
+	switch cfg.Storage.Backend {
+	case "memory":
+		fmt.Println("SKIP  redis and database: in-memory storage")
+	case "embedded":
+		fmt.Println("SKIP  redis: kept in the embedded store")
+		check(databaseName(cfg), checkDatabase(ctx, cfg))
+	default:
+		check("redis "+cfg.Redis.GetRedisAddr(), checkRedis(cfg))
+		check(databaseName(cfg), checkDatabase(ctx, cfg))
+	}
+
+	for _, svc := range cfg.Services {
+		if svc.Kubernetes != nil {
+			fmt.Printf("SKIP  %s: targets are discovered from Kubernetes\n", svc.Path)
+			continue
+		}
+		for _, target := range svc.AllTargets() {
+			if discovery.IsSRVTarget(target) {
+				fmt.Printf("SKIP  %s %s: resolved at runtime\n", svc.Path, target)
+				continue
+			}
+			check(svc.Path+" "+target, checkTarget(target))
+		}
+	}
+
+	if failures > 0 {
+		fmt.Printf("%d check(s) failed\n", failures)
+		return 1
+	}
+	return 0
+}
+
+// Names where the config was read from in messages
+func configSource(path string) string {
+	if path == "" {
+		return "environment"
+	}
+	return path
+}
+
+// Returns the config as indented JSON with secret values masked
+func maskedConfig(cfg *config.Config) (string, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return "", err
+	}
+	maskSecrets(tree)
+
+	masked, err := json.MarshalIndent(tree, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(masked), nil
+}
+
+func maskSecrets(node interface{}) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if s, ok := child.(string); ok && s != "" && secretKeys[key] {
+				value[key] = "********"
+				continue
+			}
+			maskSecrets(child)
+		}
+	case []interface{}:
+		for _, child := range value {
+			maskSecrets(child)
+		}
+	}
+}
+
+func checkCatalog(ctx context.Context, cfg *config.Config) error {
+	source, err := catalog.NewSource(cfg.Catalog)
+	if err != nil {
+		return err
+	}
+
+	snapshot, _, err := source.Load(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = cfg.WithCatalog(snapshot.Services, snapshot.Tiers)
+	return err
+}
+
+// Drops the Redis client's retry logs; the check reports the final error
+type quietRedisLogger struct{}
+
+func (quietRedisLogger) Printf(context.Context, string, ...interface{}) {}
+
+func checkRedis(cfg *config.Config) error {
+	goredis.SetLogger(quietRedisLogger{})
+
+	redis, err := storage.NewRedis(cfg.Redis.GetRedisAddr(), cfg.Redis.Password, cfg.Redis.DB)
+	if err != nil {
+		return err
+	}
+	return redis.Close()
+}
+
+// Names the database in check results
+func databaseName(cfg *config.Config) string {
+	if cfg.Database.Driver == "sqlite" {
+		return "sqlite " + cfg.Database.Path
+	}
+	return fmt.Sprintf("%s %s:%d", cfg.Database.Driver, cfg.Database.Host, cfg.Database.Port)
+}
+
+func checkDatabase(ctx context.Context, cfg *config.Config) error {
+	postgres, err := openDatabase(cfg, nil)
+	if err != nil {
+		return err
+	}
+	defer postgres.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	return postgres.Ping(ctx)
+}
+
+// Checks the target accepts TCP connections
+func checkTarget(target string) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return err
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(u.Hostname(), port), checkTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -2,16 +2,25 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/aman-churiwal/api-gateway/internal/config"
+	"github.com/aman-churiwal/api-gateway/internal/logging"
 	"github.com/aman-churiwal/api-gateway/internal/server"
 	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/aman-churiwal/api-gateway/internal/vault"
+	"github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
 )
 
@@ -19,17 +28,64 @@ func main() {
 	// Load env if it exists
 	godotenv.Load()
 
-	cfg, err := config.Load("config.json")
+	// Offline checks for CI and deploy pipelines
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "check-config":
+			os.Exit(runCheckConfig(os.Args[2:]))
+		}
+	}
+
+	configPath := flag.String("config", defaultConfigPath(), configUsage)
+	flag.Parse()
+
+	cfg, err := loadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	// Initialize Redis
-	redis, err := storage.NewRedis(
-		cfg.Redis.GetRedisAddr(),
-		cfg.Redis.Password,
-		cfg.Redis.DB,
-	)
+	// Also routes the standard log package through the structured logger
+	logger, err := logging.New(os.Stdout, cfg.Logging.Format, cfg.Logging.Level)
+	if err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+	slog.SetDefault(logger)
+
+	// Secrets from Vault take the place of those in the file and environment
+	var secrets *vault.Secrets
+	if cfg.Vault != nil {
+		secrets = loadVaultSecrets(cfg)
+	}
+
+	// Open the embedded store for single-node installs
+	var kv *storage.EmbeddedKV
+	if cfg.Storage.Backend == "embedded" {
+		kv, err = storage.NewEmbeddedKV(cfg.Storage.Path)
+		if err != nil {
+			log.Fatalf("Failed to open embedded store: %v", err)
+		}
+		defer kv.Close()
+		log.Printf("Opened embedded store at %s", cfg.Storage.Path)
+	}
+
+	// Initialize Redis. Closed before the embedded store, which keeps its data.
+	var redis *storage.RedisClient
+	if cfg.Storage.Backend == "memory" {
+		redis, err = storage.NewMemoryRedis()
+		log.Println("Using in-memory storage; data is lost on exit")
+	} else if kv != nil {
+		redis, err = storage.NewEmbeddedRedis(kv)
+	} else if secrets != nil && cfg.Vault.RedisPassword != "" {
+		redis, err = storage.NewRedisWithRotatingPassword(cfg.Redis.GetRedisAddr(), secretFunc(secrets, vault.RedisPassword), cfg.Redis.DB)
+	} else {
+		redis, err = storage.NewRedis(
+			cfg.Redis.GetRedisAddr(),
+			cfg.Redis.Password,
+			cfg.Redis.DB,
+		)
+	}
 
 	if err != nil {
 		log.Fatalf("Failed to connect to Redis: %v", err)
@@ -38,23 +94,13 @@ func main() {
 
 	log.Println("Connected to redis successfully")
 
-	// Connect to PostgreSQL
-	// dsn := "host=localhost user=gateway password=password dbname=gateway port=5433 sslmode=disable"
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.DBName,
-		cfg.Database.SSLMode,
-	)
-
-	postgres, err := storage.NewPostgres(dsn)
+	// Connect to the database
+	postgres, err := openDatabase(cfg, secrets)
 	if err != nil {
-		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+		log.Fatalf("Failed to connect to %s: %v", cfg.Database.Driver, err)
 	}
 	defer postgres.Close()
-	log.Println("Connected to PostgreSQL successfully")
+	log.Printf("Connected to %s successfully", cfg.Database.Driver)
 
 	// Run migrations
 	if err := postgres.AutoMigrate(); err != nil {
@@ -63,11 +109,20 @@ func main() {
 	log.Println("Database migrations completed")
 
 	// Create server
-	srv := server.New(cfg, redis, postgres)
+	srv := server.New(cfg, redis, postgres, kv)
+	srv.SetConfigLoader(func() (*config.Config, error) {
+		return loadConfig(*configPath)
+	})
+
+	if secrets != nil {
+		srv.WatchSecrets(secrets)
+		secrets.Start()
+		defer secrets.Stop()
+	}
 
 	go func() {
 		addr := ":" + cfg.Server.Port
-		if err := srv.Run(addr); err != nil {
+		if err := srv.Run(addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("Server failed to start: %v", err)
 		}
 	}()
@@ -76,14 +131,110 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeoutSeconds)*time.Second)
 	defer cancel()
 
+	// Deferred closes of Vault, the embedded store, Postgres and Redis run
+	// after this, once nothing is using them
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		log.Printf("Server forced to shutdown: %v", err)
 	}
 
 	log.Println("Server Exited")
 }
+
+// Reads the configured secrets from Vault into cfg
+func loadVaultSecrets(cfg *config.Config) *vault.Secrets {
+	secrets := vault.NewSecrets(cfg.Vault)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := secrets.Load(ctx); err != nil {
+		log.Fatalf("Failed to read secrets from Vault: %v", err)
+	}
+	applyVaultSecrets(cfg, secrets)
+
+	log.Printf("Loaded secrets from Vault at %s (auth: %s)", cfg.Vault.Address, cfg.Vault.Auth.Method)
+	return secrets
+}
+
+// Replaces the config's secrets with those read from Vault
+func applyVaultSecrets(cfg *config.Config, secrets *vault.Secrets) {
+	if secret, ok := secrets.Get(vault.JWTSecret); ok {
+		cfg.JWT.Secret = secret
+	}
+	if password, ok := secrets.Get(vault.DatabasePassword); ok {
+		cfg.Database.Password = password
+	}
+	if password, ok := secrets.Get(vault.RedisPassword); ok {
+		cfg.Redis.Password = password
+	}
+}
+
+// Returns a function reading the current value of a Vault secret
+func secretFunc(secrets *vault.Secrets, name string) func() string {
+	return func() string {
+		value, _ := secrets.Get(name)
+		return value
+	}
+}
+
+const configUsage = "config file (JSON, YAML or TOML); empty reads the whole config from the environment"
+
+// CONFIG_PATH, else config.json if there is one, else empty to run from the environment
+func defaultConfigPath() string {
+	if path := os.Getenv("CONFIG_PATH"); path != "" {
+		return path
+	}
+	if _, err := os.Stat("config.json"); errors.Is(err, os.ErrNotExist) {
+		return ""
+	}
+	return "config.json"
+}
+
+func loadConfig(path string) (*config.Config, error) {
+	if path == "" {
+		return config.LoadEnv()
+	}
+	return config.Load(path)
+}
+
+func postgresDSN(cfg *config.Config) string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Database.Host,
+		cfg.Database.Port,
+		cfg.Database.User,
+		cfg.Database.Password,
+		cfg.Database.DBName,
+		cfg.Database.SSLMode,
+	)
+}
+
+// Builds a go-sql-driver DSN; parseTime makes DATETIME columns scan into time.Time
+func mysqlDSN(cfg *config.Config) string {
+	dsn := mysql.NewConfig()
+	dsn.Net = "tcp"
+	dsn.Addr = net.JoinHostPort(cfg.Database.Host, strconv.Itoa(cfg.Database.Port))
+	dsn.User = cfg.Database.User
+	dsn.Passwd = cfg.Database.Password
+	dsn.DBName = cfg.Database.DBName
+	dsn.ParseTime = true
+	return dsn.FormatDSN()
+}
+
+// Opens the configured database. Only Postgres re-reads a rotating Vault
+// password; other drivers use the value read at startup.
+func openDatabase(cfg *config.Config, secrets *vault.Secrets) (*storage.Postgres, error) {
+	switch cfg.Database.Driver {
+	case "mysql":
+		return storage.NewMySQL(mysqlDSN(cfg))
+	case "sqlite":
+		return storage.NewSQLite(cfg.Database.Path)
+	}
+
+	if secrets != nil && cfg.Vault.DatabasePassword != "" {
+		return storage.NewPostgresWithRotatingPassword(postgresDSN(cfg), secretFunc(secrets, vault.DatabasePassword))
+	}
+	return storage.NewPostgres(postgresDSN(cfg))
+}
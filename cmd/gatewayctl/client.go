@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// What login saves so later commands are authenticated
+type session struct {
+	URL          string `json:"url"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+func sessionPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gatewayctl", "session.json"), nil
+}
+
+// Reads the saved session; a missing one is empty rather than an error
+func loadSession() (*session, error) {
+	path, err := sessionPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &session{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid session file %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Writes the session readable only by the current user, as it holds a token
+func saveSession(s *session) error {
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func removeSession() error {
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Talks to the gateway's management API
+type client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// Error responses from the gateway
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// Builds a client from flags, the environment and the saved session, in that order
+func newClient(baseURL string) (*client, error) {
+	s, err := loadSession()
+	if err != nil {
+		return nil, err
+	}
+
+	if baseURL == "" {
+		baseURL = os.Getenv("GATEWAYCTL_URL")
+	}
+	if baseURL == "" {
+		baseURL = s.URL
+	}
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	token := os.Getenv("GATEWAYCTL_TOKEN")
+	if token == "" && strings.TrimSuffix(baseURL, "/") == strings.TrimSuffix(s.URL, "/") {
+		token = s.Token
+	}
+
+	return &client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 5 * time.Minute}, // Exports can be large
+	}, nil
+}
+
+// Sends a request and returns the response for the caller to read and close.
+// Non-2xx responses are turned into an apiError.
+func (c *client) do(method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+	return resp, nil
+}
+
+func decodeError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		message = body.Error
+	}
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		message += "; run gatewayctl login"
+	}
+	return &apiError{StatusCode: resp.StatusCode, Message: message}
+}
+
+// Sends a request and decodes the JSON response into out
+func (c *client) call(method, path string, query url.Values, body, out interface{}) error {
+	resp, err := c.do(method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
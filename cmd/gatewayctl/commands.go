@@ -0,0 +1,416 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+)
+
+// Handles `gatewayctl keys list|create|rotate`
+func runKeys(baseURL string, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: gatewayctl keys list|create|rotate")
+		return 2
+	}
+	c, err := newClient(baseURL)
+	if err != nil {
+		return fail(err)
+	}
+
+	switch args[0] {
+	case "list":
+		return listKeys(c, args[1:])
+	case "create":
+		return createKey(c, args[1:])
+	case "rotate":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, "Usage: gatewayctl keys rotate <id>")
+			return 2
+		}
+		var result struct {
+			Key string `json:"key"`
+		}
+		if err := c.call(http.MethodPost, "/admin/keys/"+url.PathEscape(args[1])+"/rotate", nil, nil, &result); err != nil {
+			return fail(err)
+		}
+		fmt.Println(result.Key)
+		fmt.Fprintln(os.Stderr, "Save this key - it won't be shown again. The previous key no longer works")
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown keys command %q\n", args[0])
+		return 2
+	}
+}
+
+func listKeys(c *client, args []string) int {
+	flags := flag.NewFlagSet("keys list", flag.ExitOnError)
+	tier := flags.String("tier", "", "only keys of this tier")
+	owner := flags.String("owner", "", "only keys of this owner")
+	active := flags.String("active", "", "true or false to filter by state")
+	limit := flags.Int("limit", 100, "maximum keys to list")
+	asJSON := flags.Bool("json", false, "print the raw JSON response")
+	flags.Parse(args)
+
+	query := url.Values{"limit": {strconv.Itoa(*limit)}}
+	for name, value := range map[string]string{"tier": *tier, "owner": *owner, "active": *active} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+
+	var keys []models.APIKey
+	if err := c.call(http.MethodGet, "/admin/keys", query, nil, &keys); err != nil {
+		return fail(err)
+	}
+	if *asJSON {
+		return printJSON(keys)
+	}
+
+	w := newTable()
+	fmt.Fprintln(w, "ID\tPREFIX\tNAME\tTIER\tOWNER\tACTIVE\tLAST USED")
+	for _, key := range keys {
+		lastUsed := "never"
+		if key.LastUsedAt != nil {
+			lastUsed = key.LastUsedAt.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\t%s\n", key.ID, key.KeyPrefix, key.Name, key.Tier, key.Owner, key.IsActive, lastUsed)
+	}
+	w.Flush()
+	return 0
+}
+
+func createKey(c *client, args []string) int {
+	flags := flag.NewFlagSet("keys create", flag.ExitOnError)
+	name := flags.String("name", "", "key name (required)")
+	tier := flags.String("tier", "", "rate limit tier (required)")
+	owner := flags.String("owner", "", "owning person or system (required)")
+	email := flags.String("email", "", "owner contact email (required)")
+	team := flags.String("team", "", "owning team")
+	notes := flags.String("notes", "", "free-form notes")
+	tags := flags.String("tags", "", "comma-separated tags")
+	flags.Parse(args)
+
+	if *name == "" || *tier == "" || *owner == "" || *email == "" {
+		fmt.Fprintln(os.Stderr, "keys create: -name, -tier, -owner and -email are required")
+		return 2
+	}
+
+	body := map[string]interface{}{
+		"name":          *name,
+		"tier":          *tier,
+		"owner":         *owner,
+		"contact_email": *email,
+		"team":          *team,
+		"notes":         *notes,
+	}
+	if *tags != "" {
+		body["tags"] = strings.Split(*tags, ",")
+	}
+
+	var result struct {
+		Key string `json:"key"`
+	}
+	if err := c.call(http.MethodPost, "/admin/keys", nil, body, &result); err != nil {
+		return fail(err)
+	}
+	fmt.Println(result.Key)
+	fmt.Fprintln(os.Stderr, "Save this key - it won't be shown again")
+	return 0
+}
+
+// Handles `gatewayctl breakers [reset <service>]`
+func runBreakers(baseURL string, args []string) int {
+	c, err := newClient(baseURL)
+	if err != nil {
+		return fail(err)
+	}
+
+	if len(args) > 0 && args[0] == "reset" {
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, "Usage: gatewayctl breakers reset <service path>")
+			return 2
+		}
+		service := "/" + strings.TrimPrefix(args[1], "/")
+		if err := c.call(http.MethodPost, "/admin/circuit-breakers"+service, nil, nil, nil); err != nil {
+			return fail(err)
+		}
+		fmt.Printf("Circuit breaker of %s reset\n", service)
+		return 0
+	}
+
+	flags := flag.NewFlagSet("breakers", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the raw JSON response")
+	flags.Parse(args)
+
+	var breakers map[string]struct {
+		State           string    `json:"state"`
+		FailureCount    int       `json:"failure_count"`
+		LastStateChange time.Time `json:"last_state_change"`
+	}
+	if err := c.call(http.MethodGet, "/admin/circuit-breakers", nil, nil, &breakers); err != nil {
+		return fail(err)
+	}
+	if *asJSON {
+		return printJSON(breakers)
+	}
+
+	w := newTable()
+	fmt.Fprintln(w, "SERVICE\tSTATE\tFAILURES\tSINCE")
+	for _, path := range sortedKeys(breakers) {
+		b := breakers[path]
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", path, b.State, b.FailureCount, b.LastStateChange.Local().Format(time.DateTime))
+	}
+	w.Flush()
+	return 0
+}
+
+// Handles `gatewayctl health`
+func runHealth(baseURL string, args []string) int {
+	flags := flag.NewFlagSet("health", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the raw JSON response")
+	flags.Parse(args)
+
+	c, err := newClient(baseURL)
+	if err != nil {
+		return fail(err)
+	}
+
+	var services map[string]struct {
+		OverallHealth string `json:"overall_health"`
+		TargetStatus  []struct {
+			Target       string `json:"target"`
+			IsHealthy    bool   `json:"is_healthy"`
+			FailureCount int    `json:"failure_count"`
+		} `json:"target_status"`
+		EjectedTargets []struct {
+			Target string `json:"target"`
+		} `json:"ejected_targets"`
+	}
+	if err := c.call(http.MethodGet, "/admin/services/health", nil, nil, &services); err != nil {
+		return fail(err)
+	}
+	if *asJSON {
+		return printJSON(services)
+	}
+
+	w := newTable()
+	fmt.Fprintln(w, "SERVICE\tTARGET\tSTATUS\tFAILURES")
+	for _, path := range sortedKeys(services) {
+		svc := services[path]
+		ejected := make(map[string]bool, len(svc.EjectedTargets))
+		for _, target := range svc.EjectedTargets {
+			ejected[target.Target] = true
+		}
+
+		fmt.Fprintf(w, "%s\t\t%s\t\n", path, svc.OverallHealth)
+		for _, target := range svc.TargetStatus {
+			status := "healthy"
+			if !target.IsHealthy {
+				status = "unhealthy"
+			}
+			if ejected[target.Target] {
+				status += " (ejected)"
+			}
+			fmt.Fprintf(w, "\t%s\t%s\t%d\n", target.Target, status, target.FailureCount)
+		}
+	}
+	w.Flush()
+	return 0
+}
+
+// Handles `gatewayctl reload`
+func runReload(baseURL string) int {
+	c, err := newClient(baseURL)
+	if err != nil {
+		return fail(err)
+	}
+
+	var result struct {
+		Changed  bool `json:"changed"`
+		Services int  `json:"services"`
+		Tiers    int  `json:"tiers"`
+	}
+	if err := c.call(http.MethodPost, "/admin/config/reload", nil, nil, &result); err != nil {
+		return fail(err)
+	}
+	if !result.Changed {
+		fmt.Println("Config unchanged")
+		return 0
+	}
+	fmt.Printf("Config reloaded: %d services, %d tiers\n", result.Services, result.Tiers)
+	return 0
+}
+
+// Handles `gatewayctl logs`. With -follow it polls for logs newer than the
+// last one printed until interrupted.
+func runLogs(baseURL string, args []string) int {
+	flags := flag.NewFlagSet("logs", flag.ExitOnError)
+	since := flags.Duration("since", 15*time.Minute, "how far back to start")
+	status := flags.Int("status", 0, "only logs with this status code")
+	limit := flags.Int("limit", 100, "maximum logs per request (up to 1000)")
+	follow := flags.Bool("follow", false, "keep printing new logs")
+	interval := flags.Duration("interval", 2*time.Second, "poll interval with -follow")
+	asJSON := flags.Bool("json", false, "print one JSON object per line")
+	flags.Parse(args)
+
+	c, err := newClient(baseURL)
+	if err != nil {
+		return fail(err)
+	}
+
+	from := time.Now().Add(-*since)
+	seen := make(map[string]bool) // Logs printed at the from timestamp
+	for {
+		query := url.Values{
+			"from":  {from.UTC().Format(time.RFC3339Nano)},
+			"to":    {time.Now().UTC().Format(time.RFC3339Nano)},
+			"limit": {strconv.Itoa(*limit)},
+		}
+		if *status != 0 {
+			query.Set("status", strconv.Itoa(*status))
+		}
+
+		var page struct {
+			Logs []models.RequestLog `json:"logs"`
+		}
+		if err := c.call(http.MethodGet, "/admin/logs", query, nil, &page); err != nil {
+			return fail(err)
+		}
+
+		// Logs come newest first
+		slices.Reverse(page.Logs)
+		for _, entry := range page.Logs {
+			id := logID(entry)
+			if entry.Timestamp.Before(from) || seen[id] {
+				continue
+			}
+			if entry.Timestamp.After(from) {
+				from = entry.Timestamp
+				clear(seen)
+			}
+			seen[id] = true
+			printLog(entry, *asJSON)
+		}
+
+		if !*follow {
+			return 0
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// Identifies a log across polls; analytics stores without IDs fall back to its contents
+func logID(entry models.RequestLog) string {
+	if entry.ID != 0 {
+		return strconv.FormatUint(uint64(entry.ID), 10)
+	}
+	return fmt.Sprintf("%s %s %s %s %d", entry.Timestamp.Format(time.RFC3339Nano), entry.IPAddress, entry.Method, entry.Path, entry.StatusCode)
+}
+
+func printLog(entry models.RequestLog, asJSON bool) {
+	if asJSON {
+		printJSON(entry)
+		return
+	}
+
+	key := "-"
+	if entry.APIKeyID != nil {
+		key = entry.APIKeyID.String()
+	}
+	fmt.Printf("%s %d %s %s %dms %s key=%s\n", entry.Timestamp.Local().Format(time.DateTime), entry.StatusCode,
+		entry.Method, entry.Path, entry.ResponseTimeMs, entry.IPAddress, key)
+}
+
+// Admin API paths of the reports `gatewayctl analytics` exports
+var analyticsReports = map[string]string{
+	"summary":           "/admin/analytics",
+	"timeseries":        "/admin/analytics/timeseries",
+	"status-codes":      "/admin/analytics/status-codes",
+	"latency-histogram": "/admin/analytics/latency-histogram",
+	"top-keys":          "/admin/analytics/top-keys",
+	"operations":        "/admin/analytics/operations",
+	"countries":         "/admin/analytics/countries",
+	"versions":          "/admin/analytics/versions",
+	"logs":              "/admin/logs",
+	"usage":             "/admin/reports/usage",
+}
+
+// Handles `gatewayctl analytics <report>`
+func runAnalytics(baseURL string, args []string) int {
+	if len(args) == 0 || analyticsReports[args[0]] == "" {
+		fmt.Fprintf(os.Stderr, "Usage: gatewayctl analytics <report> [flags]\nReports: %s\n", strings.Join(sortedKeys(analyticsReports), ", "))
+		return 2
+	}
+	report := args[0]
+
+	flags := flag.NewFlagSet("analytics "+report, flag.ExitOnError)
+	from := flags.String("from", "", "start as RFC3339 or Unix seconds (default: 24h ago)")
+	to := flags.String("to", "", "end as RFC3339 or Unix seconds (default: now)")
+	format := flags.String("format", "json", "json or csv")
+	output := flags.String("o", "", "write to this file instead of standard output")
+	flags.Parse(args[1:])
+
+	if *format != "json" && *format != "csv" {
+		fmt.Fprintln(os.Stderr, "analytics: -format must be json or csv")
+		return 2
+	}
+
+	c, err := newClient(baseURL)
+	if err != nil {
+		return fail(err)
+	}
+
+	query := url.Values{}
+	if *from != "" {
+		query.Set("from", *from)
+	}
+	if *to != "" {
+		query.Set("to", *to)
+	}
+	if *format == "csv" {
+		query.Set("format", "csv")
+	}
+
+	resp, err := c.do(http.MethodGet, analyticsReports[report], query, nil)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fail(err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return fail(err)
+	}
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "Wrote %s report to %s\n", report, *output)
+	}
+	return 0
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,148 @@
+// gatewayctl administers a running gateway through its management API
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const usage = `Usage: gatewayctl [-url URL] <command> [flags]
+
+Commands:
+  login                  Sign in and save the session
+  logout                 Forget the saved session
+  keys list              List API keys
+  keys create            Create an API key
+  keys rotate <id>       Replace an API key's secret
+  breakers               Show circuit breaker states
+  breakers reset <path>  Close a service's circuit breaker
+  health                 Show backend target health
+  reload                 Re-read services and tiers from the gateway's config
+  logs                   Print request logs, optionally following new ones
+  analytics <report>     Export an analytics report as JSON or CSV
+
+The URL defaults to GATEWAYCTL_URL, then the logged-in gateway, then
+http://localhost:8080. GATEWAYCTL_TOKEN overrides the saved token.
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	baseURL := flag.String("url", "", "gateway management URL")
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var code int
+	switch args[0] {
+	case "login":
+		code = runLogin(*baseURL, args[1:])
+	case "logout":
+		code = runLogout()
+	case "keys":
+		code = runKeys(*baseURL, args[1:])
+	case "breakers":
+		code = runBreakers(*baseURL, args[1:])
+	case "health":
+		code = runHealth(*baseURL, args[1:])
+	case "reload":
+		code = runReload(*baseURL)
+	case "logs":
+		code = runLogs(*baseURL, args[1:])
+	case "analytics":
+		code = runAnalytics(*baseURL, args[1:])
+	case "help", "-h", "--help":
+		flag.Usage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", args[0])
+		flag.Usage()
+		code = 2
+	}
+	os.Exit(code)
+}
+
+// Handles `gatewayctl login`. The password is read from GATEWAYCTL_PASSWORD
+// or standard input so it stays out of shell history.
+func runLogin(baseURL string, args []string) int {
+	flags := flag.NewFlagSet("login", flag.ExitOnError)
+	email := flags.String("email", "", "account email")
+	flags.Parse(args)
+
+	if *email == "" {
+		fmt.Fprintln(os.Stderr, "login: -email is required")
+		return 2
+	}
+
+	password := os.Getenv("GATEWAYCTL_PASSWORD")
+	if password == "" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fail(fmt.Errorf("failed to read password: %w", err))
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	c, err := newClient(baseURL)
+	if err != nil {
+		return fail(err)
+	}
+	c.token = ""
+
+	var tokens struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	body := map[string]string{"email": *email, "password": password}
+	if err := c.call(http.MethodPost, "/auth/login", nil, body, &tokens); err != nil {
+		return fail(err)
+	}
+
+	if err := saveSession(&session{URL: c.baseURL, Token: tokens.Token, RefreshToken: tokens.RefreshToken}); err != nil {
+		return fail(fmt.Errorf("failed to save session: %w", err))
+	}
+	fmt.Printf("Logged in to %s as %s\n", c.baseURL, *email)
+	return 0
+}
+
+// Handles `gatewayctl logout`
+func runLogout() int {
+	if err := removeSession(); err != nil {
+		return fail(err)
+	}
+	fmt.Println("Logged out")
+	return 0
+}
+
+// Prints an error and returns the exit code for failed commands
+func fail(err error) int {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+		return 3
+	}
+	return 1
+}
+
+func printJSON(v interface{}) int {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fail(err)
+	}
+	return 0
+}
+
+func newTable() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+}
@@ -0,0 +1,38 @@
+package accesslog
+
+import (
+	"context"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+)
+
+// Where the analytics API reads request logs from
+type RequestLogWriter interface {
+	CreateBatch(ctx context.Context, logs []*models.RequestLog) error
+}
+
+// Stores entries in the request_logs table that backs the analytics API,
+// named after the analytics backend
+type AnalyticsSink struct {
+	backend string
+	store   RequestLogWriter
+}
+
+func NewAnalyticsSink(backend string, store RequestLogWriter) *AnalyticsSink {
+	return &AnalyticsSink{backend: backend, store: store}
+}
+
+func (s *AnalyticsSink) Name() string { return s.backend }
+func (s *AnalyticsSink) Type() string { return s.backend }
+
+func (s *AnalyticsSink) Write(ctx context.Context, entries []models.RequestLog) error {
+	logs := make([]*models.RequestLog, len(entries))
+	for i := range entries {
+		logs[i] = &entries[i]
+	}
+	return s.store.CreateBatch(ctx, logs)
+}
+
+func (s *AnalyticsSink) Close() error {
+	return nil
+}
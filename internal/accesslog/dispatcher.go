@@ -0,0 +1,282 @@
+package accesslog
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+)
+
+// Destination for request log entries. Write is only called from the sink's
+// own pipeline goroutine.
+type Sink interface {
+	Name() string
+	Type() string
+	Write(ctx context.Context, entries []models.RequestLog) error
+	Close() error
+}
+
+// Returned by sinks that stored part of a batch. The failed entries are
+// counted but not retried, since resending the batch would duplicate the rest.
+type PartialError struct {
+	Failed int
+	Err    error
+}
+
+func (e *PartialError) Error() string {
+	return e.Err.Error()
+}
+
+// What happens to entries when a sink's buffer is full
+const (
+	OverflowDrop  = "drop"  // Discard the entry; never slows requests down
+	OverflowBlock = "block" // Wait up to BlockTimeout for room, then discard
+)
+
+// Batching and backpressure settings for one sink
+type Options struct {
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+	Overflow      string
+	BlockTimeout  time.Duration
+	MaxRetries    int // Attempts per batch before it is discarded
+}
+
+// Delivery counters for one sink
+type Stats struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Queued    int    `json:"queued"`
+	Capacity  int    `json:"capacity"`
+	Written   int64  `json:"written"`
+	Dropped   int64  `json:"dropped"` // Discarded because the buffer was full
+	Failed    int64  `json:"failed"`  // Discarded after the sink rejected them
+	LastError string `json:"last_error,omitempty"`
+}
+
+type pipeline struct {
+	sink    Sink
+	opts    Options
+	queue   chan models.RequestLog
+	done    chan struct{}
+	written atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+
+	mu        sync.Mutex
+	lastError string
+}
+
+// Fans request log entries out to sinks, each with its own buffer and batching
+// so a slow sink cannot hold up the others
+type Dispatcher struct {
+	pipelines []*pipeline
+
+	mu     sync.RWMutex // Held for writing while queues are closed
+	closed bool
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// Starts delivering to a sink. Must be called before the first Log.
+func (d *Dispatcher) Add(sink Sink, opts Options) {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1000
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+	if opts.Overflow == "" {
+		opts.Overflow = OverflowDrop
+	}
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = 3
+	}
+
+	p := &pipeline{
+		sink:  sink,
+		opts:  opts,
+		queue: make(chan models.RequestLog, opts.BufferSize),
+		done:  make(chan struct{}),
+	}
+	d.pipelines = append(d.pipelines, p)
+
+	go p.run()
+
+	log.Printf("Access log sink %s (%s) started", sink.Name(), sink.Type())
+}
+
+// Reports whether any sinks were added
+func (d *Dispatcher) Enabled() bool {
+	return d != nil && len(d.pipelines) > 0
+}
+
+// Queues an entry on every sink, applying each sink's overflow policy
+func (d *Dispatcher) Log(entry models.RequestLog) {
+	if d == nil {
+		return
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return
+	}
+
+	for _, p := range d.pipelines {
+		p.enqueue(entry)
+	}
+}
+
+// Returns delivery counters for every sink
+func (d *Dispatcher) Stats() []Stats {
+	stats := make([]Stats, 0, len(d.pipelines))
+	for _, p := range d.pipelines {
+		p.mu.Lock()
+		lastError := p.lastError
+		p.mu.Unlock()
+
+		stats = append(stats, Stats{
+			Name:      p.sink.Name(),
+			Type:      p.sink.Type(),
+			Queued:    len(p.queue),
+			Capacity:  cap(p.queue),
+			Written:   p.written.Load(),
+			Dropped:   p.dropped.Load(),
+			Failed:    p.failed.Load(),
+			LastError: lastError,
+		})
+	}
+	return stats
+}
+
+// Stops accepting entries and flushes what is buffered, giving up when ctx ends
+func (d *Dispatcher) Close(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	for _, p := range d.pipelines {
+		close(p.queue)
+	}
+	d.mu.Unlock()
+
+	var errs []error
+	for _, p := range d.pipelines {
+		select {
+		case <-p.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := p.sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *pipeline) enqueue(entry models.RequestLog) {
+	select {
+	case p.queue <- entry:
+		return
+	default:
+	}
+
+	if p.opts.Overflow == OverflowBlock && p.opts.BlockTimeout > 0 {
+		timer := time.NewTimer(p.opts.BlockTimeout)
+		defer timer.Stop()
+
+		select {
+		case p.queue <- entry:
+			return
+		case <-timer.C:
+		}
+	}
+
+	p.dropped.Add(1)
+}
+
+func (p *pipeline) run() {
+	defer close(p.done)
+
+	batch := make([]models.RequestLog, 0, p.opts.BatchSize)
+	ticker := time.NewTicker(p.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case entry, ok := <-p.queue:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+
+			batch = append(batch, entry)
+			if len(batch) >= p.opts.BatchSize {
+				p.flush(batch)
+				batch = make([]models.RequestLog, 0, p.opts.BatchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				p.flush(batch)
+				batch = make([]models.RequestLog, 0, p.opts.BatchSize)
+			}
+		}
+	}
+}
+
+// Writes a batch, retrying with backoff. Entries keep queueing meanwhile, so a
+// struggling sink pushes back through its overflow policy.
+func (p *pipeline) flush(batch []models.RequestLog) {
+	if len(batch) == 0 {
+		return
+	}
+
+	backoff := time.Second
+	for attempt := 1; attempt <= p.opts.MaxRetries; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := p.sink.Write(ctx, batch)
+		cancel()
+
+		if err == nil {
+			p.written.Add(int64(len(batch)))
+			return
+		}
+
+		p.mu.Lock()
+		p.lastError = err.Error()
+		p.mu.Unlock()
+
+		var partial *PartialError
+		if errors.As(err, &partial) {
+			log.Printf("Access log sink %s rejected %d of %d entries: %v", p.sink.Name(), partial.Failed, len(batch), partial.Err)
+			p.written.Add(int64(len(batch) - partial.Failed))
+			p.failed.Add(int64(partial.Failed))
+			return
+		}
+
+		log.Printf("Access log sink %s failed to write %d entries (attempt %d/%d): %v", p.sink.Name(), len(batch), attempt, p.opts.MaxRetries, err)
+		if attempt < p.opts.MaxRetries {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	p.failed.Add(int64(len(batch)))
+}
@@ -0,0 +1,113 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+)
+
+// Indexes entries through the Elasticsearch/OpenSearch bulk API. A "{date}"
+// placeholder in the index name becomes the entry's UTC date (2006.01.02) for
+// daily indices.
+type ElasticsearchSink struct {
+	name     string
+	endpoint string
+	index    string
+	username string
+	password string
+	apiKey   string
+	client   *http.Client
+}
+
+func NewElasticsearchSink(name, url, index, username, password, apiKey string) *ElasticsearchSink {
+	return &ElasticsearchSink{
+		name:     name,
+		endpoint: strings.TrimRight(url, "/") + "/_bulk",
+		index:    index,
+		username: username,
+		password: password,
+		apiKey:   apiKey,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *ElasticsearchSink) Name() string { return s.name }
+func (s *ElasticsearchSink) Type() string { return "elasticsearch" }
+
+func (s *ElasticsearchSink) Write(ctx context.Context, entries []models.RequestLog) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, entry := range entries {
+		index := strings.ReplaceAll(s.index, "{date}", entry.Timestamp.UTC().Format("2006.01.02"))
+		if err := enc.Encode(map[string]map[string]string{"index": {"_index": index}}); err != nil {
+			return err
+		}
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+s.apiKey)
+	} else if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("bulk request responded with status %d: %s", resp.StatusCode, msg)
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || !result.Errors {
+		return nil
+	}
+
+	failed := 0
+	var firstError string
+	for _, item := range result.Items {
+		for _, action := range item {
+			if action.Error != nil {
+				if failed == 0 {
+					firstError = action.Error.Type + ": " + action.Error.Reason
+				}
+				failed++
+			}
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+	return &PartialError{Failed: failed, Err: fmt.Errorf("bulk indexing failed: %s", firstError)}
+}
+
+func (s *ElasticsearchSink) Close() error {
+	return nil
+}
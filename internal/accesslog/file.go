@@ -0,0 +1,117 @@
+package accesslog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+)
+
+// Appends entries as JSON lines to a file, rotating it by size. Rotated files
+// are renamed with a timestamp suffix and the oldest beyond maxBackups removed.
+type FileSink struct {
+	name       string
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+func NewFileSink(name, path string, maxSizeBytes int64, maxBackups int) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &FileSink{name: name, path: path, maxSize: maxSizeBytes, maxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) Name() string { return s.name }
+func (s *FileSink) Type() string { return "file" }
+
+func (s *FileSink) Write(ctx context.Context, entries []models.RequestLog) error {
+	w := bufio.NewWriter(s.file)
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		line = append(line, '\n')
+
+		if s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			if err := s.rotate(); err != nil {
+				return err
+			}
+			w.Reset(s.file)
+		}
+
+		n, err := w.Write(line)
+		s.size += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+
+	backup := fmt.Sprintf("%s.%s", s.path, time.Now().UTC().Format("20060102T150405.000"))
+	if err := os.Rename(s.path, backup); err != nil {
+		return err
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+
+	if s.maxBackups > 0 {
+		backups, err := filepath.Glob(s.path + ".*")
+		if err != nil {
+			return err
+		}
+		// Timestamp suffixes sort chronologically
+		sort.Strings(backups)
+		for len(backups) > s.maxBackups {
+			os.Remove(backups[0])
+			backups = backups[1:]
+		}
+	}
+	return nil
+}
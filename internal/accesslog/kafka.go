@@ -0,0 +1,101 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+)
+
+// Produces entries to a Kafka topic through a Kafka REST Proxy (v2 API).
+// Records are keyed by API key so a consumer's requests stay in one partition.
+type KafkaSink struct {
+	name     string
+	endpoint string
+	client   *http.Client
+}
+
+func NewKafkaSink(name, restURL, topic string) *KafkaSink {
+	return &KafkaSink{
+		name:     name,
+		endpoint: strings.TrimRight(restURL, "/") + "/topics/" + topic,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *KafkaSink) Name() string { return s.name }
+func (s *KafkaSink) Type() string { return "kafka" }
+
+type kafkaRecord struct {
+	Key   string            `json:"key,omitempty"`
+	Value models.RequestLog `json:"value"`
+}
+
+func (s *KafkaSink) Write(ctx context.Context, entries []models.RequestLog) error {
+	records := make([]kafkaRecord, len(entries))
+	for i, entry := range entries {
+		records[i].Value = entry
+		if entry.APIKeyID != nil {
+			records[i].Key = entry.APIKeyID.String()
+		}
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{"records": records})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("kafka rest proxy responded with status %d: %s", resp.StatusCode, body)
+	}
+
+	// The proxy reports per-record failures in a successful response
+	var result struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil
+	}
+
+	failed := 0
+	var firstError string
+	for _, offset := range result.Offsets {
+		if offset.ErrorCode != nil {
+			if failed == 0 {
+				firstError = offset.Error
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return &PartialError{Failed: failed, Err: fmt.Errorf("kafka rejected records: %s", firstError)}
+	}
+	return nil
+}
+
+func (s *KafkaSink) Close() error {
+	return nil
+}
@@ -0,0 +1,38 @@
+package accesslog
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Decides which requests are logged. Errors and slow requests are usually
+// kept in full while only a share of fast successful requests is.
+type Sampler struct {
+	Rate            float64       // Share of other requests logged
+	ClientErrorRate float64       // Share of 4xx responses logged
+	ServerErrorRate float64       // Share of 5xx responses logged
+	Slow            time.Duration // Requests at least this slow are always logged; 0 disables
+}
+
+// Returns the share of requests like this one that are logged
+func (s *Sampler) SampleRate(statusCode int, latency time.Duration) float64 {
+	if s == nil {
+		return 1
+	}
+
+	switch {
+	case s.Slow > 0 && latency >= s.Slow:
+		return 1
+	case statusCode >= 500:
+		return s.ServerErrorRate
+	case statusCode >= 400:
+		return s.ClientErrorRate
+	default:
+		return s.Rate
+	}
+}
+
+// Reports whether a request with the given sample rate is logged
+func Keep(rate float64) bool {
+	return rate >= 1 || rand.Float64() < rate
+}
@@ -0,0 +1,290 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/livemetrics"
+	"github.com/google/uuid"
+)
+
+// Metrics watched per service
+const (
+	MetricErrorRate  = "error_rate"
+	MetricP95Latency = "p95_latency_ms"
+	MetricRPS        = "rps"
+)
+
+// Resolved alerts kept for GET /admin/alerts
+const historySize = 100
+
+// When alerts fire. Deviation checks compare against an exponentially
+// weighted baseline and only start once it has warmed up.
+type Rules struct {
+	Interval           time.Duration
+	Window             int     // Seconds of live metrics per evaluation
+	MinRequests        int64   // Error rate and latency are ignored for quieter windows
+	WarmupSamples      int     // Evaluations before deviation checks apply
+	BaselineAlpha      float64 // EWMA weight of the newest sample
+	DeviationMultiple  float64 // Fire when a metric exceeds baseline by this factor (RPS also when below it)
+	ErrorRateThreshold float64 // Percent of 5xx that always fires; 0 disables
+	MinErrorRate       float64 // Percent below which error rate deviations are ignored
+	P95ThresholdMs     float64 // p95 latency that always fires; 0 disables
+}
+
+// A detected anomaly on one service metric
+type Alert struct {
+	ID         string     `json:"id"`
+	Service    string     `json:"service"`
+	Metric     string     `json:"metric"`
+	Reason     string     `json:"reason"` // "threshold" or "deviation"
+	Message    string     `json:"message"`
+	Value      float64    `json:"value"`
+	Baseline   float64    `json:"baseline"`
+	Threshold  float64    `json:"threshold"`
+	FiredAt    time.Time  `json:"fired_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Rolling baseline for one service metric
+type Baseline struct {
+	Value   float64 `json:"value"`
+	Samples int     `json:"samples"`
+}
+
+// Receives alerts as they fire and resolve
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert, resolved bool) error
+}
+
+type alertKey struct {
+	service string
+	metric  string
+}
+
+// Periodically checks live traffic per service against thresholds and
+// rolling baselines, firing and resolving alerts through notifiers
+type Monitor struct {
+	collector *livemetrics.Collector
+	rules     Rules
+	notifiers []Notifier
+
+	mu        sync.Mutex
+	baselines map[alertKey]*Baseline
+	active    map[alertKey]*Alert
+	history   []Alert
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+func NewMonitor(collector *livemetrics.Collector, rules Rules, notifiers []Notifier) *Monitor {
+	return &Monitor{
+		collector: collector,
+		rules:     rules,
+		notifiers: notifiers,
+		baselines: make(map[alertKey]*Baseline),
+		active:    make(map[alertKey]*Alert),
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// Starts evaluating on the configured interval
+func (m *Monitor) Start() {
+	go func() {
+		ticker := time.NewTicker(m.rules.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.Evaluate(time.Now())
+			case <-m.stopChan:
+				return
+			}
+		}
+	}()
+
+	log.Printf("Traffic alerting started (interval: %v, window: %ds)", m.rules.Interval, m.rules.Window)
+}
+
+func (m *Monitor) Stop() {
+	m.stopOnce.Do(func() { close(m.stopChan) })
+}
+
+// Firing alerts, oldest first
+func (m *Monitor) Active() []Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	alerts := make([]Alert, 0, len(m.active))
+	for _, alert := range m.active {
+		alerts = append(alerts, *alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].FiredAt.Before(alerts[j].FiredAt) })
+	return alerts
+}
+
+// Recently resolved alerts, newest first
+func (m *Monitor) History() []Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	history := make([]Alert, len(m.history))
+	for i, alert := range m.history {
+		history[len(m.history)-1-i] = alert
+	}
+	return history
+}
+
+// Current baselines per service and metric
+func (m *Monitor) Baselines() map[string]map[string]Baseline {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	baselines := make(map[string]map[string]Baseline)
+	for key, baseline := range m.baselines {
+		if baselines[key.service] == nil {
+			baselines[key.service] = make(map[string]Baseline)
+		}
+		baselines[key.service][key.metric] = *baseline
+	}
+	return baselines
+}
+
+// Runs one evaluation of every service
+func (m *Monitor) Evaluate(now time.Time) {
+	_, services := m.collector.Snapshot(m.rules.Window)
+
+	var fired, resolved []Alert
+
+	m.mu.Lock()
+	for service, stats := range services {
+		busy := stats.Requests >= m.rules.MinRequests
+
+		checks := []struct {
+			metric    string
+			value     float64
+			threshold float64
+			floor     float64 // Deviations below this are noise
+			applies   bool
+			drops     bool // A fall below baseline is also anomalous
+		}{
+			{MetricErrorRate, stats.ErrorRate, m.rules.ErrorRateThreshold, m.rules.MinErrorRate, busy, false},
+			{MetricP95Latency, stats.P95Ms, m.rules.P95ThresholdMs, 0, busy, false},
+			{MetricRPS, stats.RPS, 0, float64(m.rules.MinRequests) / float64(m.rules.Window), true, true},
+		}
+
+		for _, check := range checks {
+			key := alertKey{service: service, metric: check.metric}
+			baseline := m.baselines[key]
+			if baseline == nil {
+				baseline = &Baseline{}
+				m.baselines[key] = baseline
+			}
+
+			reason, threshold := "", 0.0
+			if check.applies {
+				reason, threshold = m.check(check.value, baseline, check.threshold, check.floor, check.drops)
+			}
+
+			alert := m.active[key]
+			switch {
+			case reason != "" && alert == nil:
+				alert = &Alert{
+					ID:        uuid.New().String(),
+					Service:   service,
+					Metric:    check.metric,
+					Reason:    reason,
+					Value:     check.value,
+					Baseline:  baseline.Value,
+					Threshold: threshold,
+					FiredAt:   now,
+				}
+				alert.LastSeenAt = now
+				alert.Message = describe(alert)
+				m.active[key] = alert
+				fired = append(fired, *alert)
+			case reason != "":
+				alert.Value = check.value
+				alert.LastSeenAt = now
+			case alert != nil:
+				resolvedAt := now
+				alert.ResolvedAt = &resolvedAt
+				alert.Value = check.value
+				delete(m.active, key)
+				m.history = append(m.history, *alert)
+				if len(m.history) > historySize {
+					m.history = m.history[len(m.history)-historySize:]
+				}
+				resolved = append(resolved, *alert)
+			}
+
+			// Anomalous samples would teach the baseline to accept the anomaly
+			if reason == "" && check.applies {
+				if baseline.Samples == 0 {
+					baseline.Value = check.value
+				} else {
+					baseline.Value += m.rules.BaselineAlpha * (check.value - baseline.Value)
+				}
+				baseline.Samples++
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	if len(fired) > 0 || len(resolved) > 0 {
+		go m.notify(fired, resolved)
+	}
+}
+
+// Returns why value is anomalous and the limit it crossed, or "" when normal
+func (m *Monitor) check(value float64, baseline *Baseline, threshold, floor float64, drops bool) (string, float64) {
+	if threshold > 0 && value > threshold {
+		return "threshold", threshold
+	}
+
+	if baseline.Samples < m.rules.WarmupSamples || m.rules.DeviationMultiple <= 1 {
+		return "", 0
+	}
+
+	if limit := baseline.Value * m.rules.DeviationMultiple; value > limit && value > floor {
+		return "deviation", limit
+	}
+	if limit := baseline.Value / m.rules.DeviationMultiple; drops && baseline.Value > floor && value < limit {
+		return "deviation", limit
+	}
+	return "", 0
+}
+
+func (m *Monitor) notify(fired, resolved []Alert) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, notifier := range m.notifiers {
+		for _, alert := range fired {
+			if err := notifier.Notify(ctx, alert, false); err != nil {
+				log.Printf("Failed to send alert %s for %s: %v", alert.Metric, alert.Service, err)
+			}
+		}
+		for _, alert := range resolved {
+			if err := notifier.Notify(ctx, alert, true); err != nil {
+				log.Printf("Failed to send alert resolution %s for %s: %v", alert.Metric, alert.Service, err)
+			}
+		}
+	}
+}
+
+func describe(alert *Alert) string {
+	switch alert.Reason {
+	case "threshold":
+		return fmt.Sprintf("%s %s is %.2f, above the %.2f threshold", alert.Service, alert.Metric, alert.Value, alert.Threshold)
+	default:
+		return fmt.Sprintf("%s %s is %.2f against a baseline of %.2f", alert.Service, alert.Metric, alert.Value, alert.Baseline)
+	}
+}
@@ -0,0 +1,129 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/webhook"
+)
+
+// Publishes alerts as gateway webhook events
+type WebhookNotifier struct {
+	webhooks *webhook.Dispatcher
+}
+
+func NewWebhookNotifier(webhooks *webhook.Dispatcher) *WebhookNotifier {
+	return &WebhookNotifier{webhooks: webhooks}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert Alert, resolved bool) error {
+	event := webhook.EventAlertFired
+	if resolved {
+		event = webhook.EventAlertResolved
+	}
+
+	n.webhooks.Dispatch(event, map[string]interface{}{
+		"alert_id":    alert.ID,
+		"service":     alert.Service,
+		"metric":      alert.Metric,
+		"reason":      alert.Reason,
+		"message":     alert.Message,
+		"value":       alert.Value,
+		"baseline":    alert.Baseline,
+		"threshold":   alert.Threshold,
+		"fired_at":    alert.FiredAt,
+		"resolved_at": alert.ResolvedAt,
+	})
+	return nil
+}
+
+// Posts alerts to a Slack incoming webhook
+type SlackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewSlackNotifier(url string) *SlackNotifier {
+	return &SlackNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, alert Alert, resolved bool) error {
+	payload, err := json.Marshal(map[string]string{"text": summary(alert, resolved)})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("slack responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Emails alerts through an SMTP relay
+type EmailNotifier struct {
+	addr     string
+	host     string
+	from     string
+	to       []string
+	username string
+	password string
+}
+
+func NewEmailNotifier(host string, port int, from string, to []string, username, password string) *EmailNotifier {
+	return &EmailNotifier{
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		host:     host,
+		from:     from,
+		to:       to,
+		username: username,
+		password: password,
+	}
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, alert Alert, resolved bool) error {
+	var auth smtp.Auth
+	if n.username != "" {
+		auth = smtp.PlainAuth("", n.username, n.password, n.host)
+	}
+
+	subject := summary(alert, resolved)
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n\r\nService: %s\r\nMetric: %s\r\nValue: %.2f\r\nBaseline: %.2f\r\nFired at: %s\r\n",
+		alert.Message, alert.Service, alert.Metric, alert.Value, alert.Baseline, alert.FiredAt.UTC().Format(time.RFC3339))
+	if alert.ResolvedAt != nil {
+		fmt.Fprintf(&msg, "Resolved at: %s\r\n", alert.ResolvedAt.UTC().Format(time.RFC3339))
+	}
+
+	return smtp.SendMail(n.addr, auth, n.from, n.to, []byte(msg.String()))
+}
+
+func summary(alert Alert, resolved bool) string {
+	if resolved {
+		return fmt.Sprintf("[resolved] %s %s back to normal", alert.Service, alert.Metric)
+	}
+	return "[alert] " + alert.Message
+}
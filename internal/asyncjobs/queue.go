@@ -0,0 +1,355 @@
+package asyncjobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/aman-churiwal/api-gateway/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis stream jobs are queued on, and the consumer group of all replicas
+const (
+	streamKey = "gateway:async:queue"
+	groupName = "workers"
+)
+
+// Job states
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded" // The backend answered, whatever its status code
+	StatusFailed    = "failed"    // The backend couldn't be reached
+)
+
+var (
+	ErrNotFound           = errors.New("job not found")
+	ErrCallbackNotAllowed = errors.New("callback host is not allowed")
+)
+
+// A request accepted for background forwarding, and its outcome. Polled at
+// the status URL and sent to the callback URL when it completes.
+type Job struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	Service     string     `json:"service"`
+	Method      string     `json:"method"`
+	Path        string     `json:"path"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	StatusCode int         `json:"status_code,omitempty"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	Error      string      `json:"error,omitempty"`
+
+	APIKeyID    string `json:"-"` // Only this consumer may poll the job
+	CallbackURL string `json:"-"`
+}
+
+// The request to forward, kept apart from the job so polling never returns
+// credentials the client sent
+type Request struct {
+	RawQuery string      `json:"query,omitempty"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body,omitempty"`
+}
+
+// Sends a job's request to its service and returns the backend's response
+type Forwarder func(ctx context.Context, job *Job, req *Request) (int, http.Header, []byte, error)
+
+type Config struct {
+	Workers        int           // Per replica. Default: 4
+	ResultTTL      time.Duration // How long jobs can be polled. Default: 24h
+	Timeout        time.Duration // Per backend request. Default: 5m
+	CallbackHosts  []string      // Hosts clients may name in callback URLs; empty disables callbacks
+	CallbackSecret string        // Signs callbacks like webhooks when set
+}
+
+// Queues requests on a Redis stream shared by all replicas, whose workers
+// forward them and store the results. Jobs a replica was running when it
+// stopped stay pending in the group and are not retried.
+type Queue struct {
+	redis   *storage.RedisClient
+	cfg     Config
+	forward Forwarder
+	client  *http.Client
+
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewQueue(redis *storage.RedisClient, cfg Config, forward Forwarder) *Queue {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.ResultTTL <= 0 {
+		cfg.ResultTTL = 24 * time.Hour
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Minute
+	}
+	return &Queue{
+		redis:    redis,
+		cfg:      cfg,
+		forward:  forward,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		stopChan: make(chan struct{}),
+	}
+}
+
+func jobKey(id string) string {
+	return "gateway:async:job:" + id
+}
+
+func requestKey(id string) string {
+	return "gateway:async:request:" + id
+}
+
+// Checks that a client-supplied callback URL may be called
+func (q *Queue) CheckCallback(callbackURL string) error {
+	u, err := url.Parse(callbackURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("invalid callback URL: %s", callbackURL)
+	}
+	if !slices.Contains(q.cfg.CallbackHosts, u.Hostname()) {
+		return ErrCallbackNotAllowed
+	}
+	return nil
+}
+
+// Stores a job and its request and queues it for a worker
+func (q *Queue) Enqueue(ctx context.Context, job *Job, req *Request) error {
+	job.ID = uuid.New().String()
+	job.Status = StatusQueued
+	job.CreatedAt = time.Now().UTC()
+
+	if err := q.save(ctx, job); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if err := q.redis.Set(ctx, requestKey(job.ID), payload, q.cfg.ResultTTL); err != nil {
+		return err
+	}
+
+	_, err = q.redis.XAdd(ctx, streamKey, map[string]interface{}{"id": job.ID})
+	return err
+}
+
+// Stored jobs carry the fields hidden from clients
+type storedJob struct {
+	Job
+	APIKeyID    string `json:"api_key_id,omitempty"`
+	CallbackURL string `json:"callback_url,omitempty"`
+}
+
+func (q *Queue) save(ctx context.Context, job *Job) error {
+	payload, err := json.Marshal(storedJob{Job: *job, APIKeyID: job.APIKeyID, CallbackURL: job.CallbackURL})
+	if err != nil {
+		return err
+	}
+	return q.redis.Set(ctx, jobKey(job.ID), payload, q.cfg.ResultTTL)
+}
+
+// Returns a job by ID
+func (q *Queue) Get(ctx context.Context, id string) (*Job, error) {
+	payload, err := q.redis.Get(ctx, jobKey(id))
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stored storedJob
+	if err := json.Unmarshal([]byte(payload), &stored); err != nil {
+		return nil, err
+	}
+	job := stored.Job
+	job.APIKeyID, job.CallbackURL = stored.APIKeyID, stored.CallbackURL
+	return &job, nil
+}
+
+// Starts the workers unless they are running
+func (q *Queue) Start() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := q.redis.XGroupCreate(ctx, streamKey, groupName); err != nil {
+		return fmt.Errorf("failed to create async job group: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	for i := range q.cfg.Workers {
+		consumer := fmt.Sprintf("%s-%d-%d", host, os.Getpid(), i)
+		q.wg.Add(1)
+		go q.work(consumer)
+	}
+	q.running = true
+	log.Printf("Async job queue started with %d workers", q.cfg.Workers)
+	return nil
+}
+
+// Stops the workers after their current job
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.running {
+		close(q.stopChan)
+		q.running = false
+	}
+	q.wg.Wait()
+}
+
+func (q *Queue) work(consumer string) {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.stopChan:
+			return
+		default:
+		}
+
+		messages, err := q.redis.XReadGroup(context.Background(), streamKey, groupName, consumer, 1, 2*time.Second)
+		if err != nil {
+			log.Printf("Failed to read async jobs: %v", err)
+			select {
+			case <-time.After(time.Second):
+			case <-q.stopChan:
+				return
+			}
+			continue
+		}
+
+		for _, message := range messages {
+			if id, ok := message.Values["id"].(string); ok {
+				q.run(id)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			if err := q.redis.XAckDel(ctx, streamKey, groupName, message.ID); err != nil {
+				log.Printf("Failed to acknowledge async job %s: %v", message.ID, err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Forwards a job's request and records the outcome
+func (q *Queue) run(id string) {
+	ctx := context.Background()
+	job, err := q.Get(ctx, id)
+	if err != nil {
+		log.Printf("Failed to load async job %s: %v", id, err)
+		return
+	}
+	payload, err := q.redis.GetDel(ctx, requestKey(id))
+	if err != nil {
+		log.Printf("Failed to load request of async job %s: %v", id, err)
+		return
+	}
+	var req Request
+	if err := json.Unmarshal([]byte(payload), &req); err != nil {
+		log.Printf("Failed to decode request of async job %s: %v", id, err)
+		return
+	}
+
+	job.Status = StatusRunning
+	if err := q.save(ctx, job); err != nil {
+		log.Printf("Failed to update async job %s: %v", id, err)
+	}
+
+	forwardCtx, cancel := context.WithTimeout(ctx, q.cfg.Timeout)
+	statusCode, header, body, err := q.forward(forwardCtx, job, &req)
+	cancel()
+
+	completedAt := time.Now().UTC()
+	job.CompletedAt = &completedAt
+	// Errors with a status code, such as 5xx, are answers too
+	if err != nil && statusCode == 0 {
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = StatusSucceeded
+		job.StatusCode = statusCode
+		job.Headers = header
+		job.Body = string(body)
+	}
+	if err := q.save(ctx, job); err != nil {
+		log.Printf("Failed to store result of async job %s: %v", id, err)
+	}
+
+	if job.CallbackURL != "" {
+		q.callback(job)
+	}
+}
+
+// Posts the finished job to its callback URL, retrying with backoff
+func (q *Queue) callback(job *Job) {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return
+	}
+
+	backoff := time.Second
+	for attempt := 1; attempt <= 3; attempt++ {
+		err = q.sendCallback(job, payload)
+		if err == nil {
+			return
+		}
+		log.Printf("Callback of async job %s to %s failed (attempt %d/3): %v", job.ID, job.CallbackURL, attempt, err)
+		if attempt < 3 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+func (q *Queue) sendCallback(job *Job, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.CallbackURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gateway-Event", "async_job."+job.Status)
+	req.Header.Set("X-Gateway-Delivery", job.ID)
+	if q.cfg.CallbackSecret != "" {
+		req.Header.Set("X-Gateway-Signature", "sha256="+webhook.Sign(q.cfg.CallbackSecret, payload))
+	}
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
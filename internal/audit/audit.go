@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// A change made by an admin operation. Before is nil for creations and After
+// is nil for deletions.
+type Change struct {
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Before       interface{}
+	After        interface{}
+}
+
+// Collects the changes made while handling one admin request
+type Collector struct {
+	mu      sync.Mutex
+	changes []Change
+}
+
+type contextKey struct{}
+
+// Returns a context that collects changes recorded with Record
+func NewContext(ctx context.Context) (context.Context, *Collector) {
+	collector := &Collector{}
+	return context.WithValue(ctx, contextKey{}, collector), collector
+}
+
+// Returns the recorded changes
+func (c *Collector) Changes() []Change {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Change(nil), c.changes...)
+}
+
+// Records a change against the admin request being handled in ctx. Changes
+// made outside an admin request, e.g. by background jobs, are not recorded.
+func Record(ctx context.Context, action, resourceType, resourceID string, before, after interface{}) {
+	collector, ok := ctx.Value(contextKey{}).(*Collector)
+	if !ok {
+		return
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	collector.changes = append(collector.changes, Change{
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Before:       before,
+		After:        after,
+	})
+}
+
+// Returns the fields that differ between the JSON forms of before and after,
+// each as {"before": ..., "after": ...}. Fields hidden from JSON are never included.
+func Diff(before, after interface{}) map[string]interface{} {
+	b := toMap(before)
+	a := toMap(after)
+
+	diff := make(map[string]interface{})
+	for field, value := range b {
+		if other, exists := a[field]; !exists || !reflect.DeepEqual(value, other) {
+			diff[field] = map[string]interface{}{"before": value, "after": a[field]}
+		}
+	}
+	for field, value := range a {
+		if _, exists := b[field]; !exists {
+			diff[field] = map[string]interface{}{"before": nil, "after": value}
+		}
+	}
+
+	return diff
+}
+
+func toMap(v interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return m
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return m
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		// Not a JSON object; diff it as a single value
+		var value interface{}
+		json.Unmarshal(data, &value)
+		m["value"] = value
+	}
+	return m
+}
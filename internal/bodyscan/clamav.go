@@ -0,0 +1,94 @@
+package bodyscan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Size of chunks streamed to clamd
+const clamChunkSize = 32 * 1024
+
+// Scans bodies with a clamd daemon using the INSTREAM command
+type ClamAV struct {
+	address string // host:port of clamd
+}
+
+func NewClamAV(address string) *ClamAV {
+	if address == "" {
+		address = "localhost:3310"
+	}
+	return &ClamAV{address: address}
+}
+
+func (c *ClamAV) Name() string {
+	return "clamav"
+}
+
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) (Verdict, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(30 * time.Second))
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Verdict{}, err
+	}
+
+	// Stream the body as length-prefixed chunks, terminated by a zero-length chunk
+	buf := make([]byte, clamChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return Verdict{}, err
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return Verdict{}, err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return Verdict{}, readErr
+		}
+	}
+
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return Verdict{}, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && err != io.EOF {
+		return Verdict{}, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	reply = strings.TrimRight(reply, "\x00\n")
+
+	// Replies look like "stream: OK" or "stream: Eicar-Test-Signature FOUND"
+	switch {
+	case strings.HasSuffix(reply, "OK"):
+		return Verdict{Clean: true}, nil
+	case strings.HasSuffix(reply, "FOUND"):
+		signature := strings.TrimSuffix(strings.TrimPrefix(reply, "stream: "), " FOUND")
+		return Verdict{Clean: false, Reason: signature}, nil
+	default:
+		return Verdict{}, fmt.Errorf("unexpected clamd reply: %s", reply)
+	}
+}
@@ -0,0 +1,21 @@
+package bodyscan
+
+import (
+	"context"
+	"io"
+)
+
+// Result of scanning a request body
+type Verdict struct {
+	Clean  bool
+	Reason string // Signature or policy name when not clean
+}
+
+// Inspects request bodies as they stream through the gateway (antivirus, DLP, ...)
+type Scanner interface {
+	// Reads the body from r until EOF and returns the verdict
+	Scan(ctx context.Context, r io.Reader) (Verdict, error)
+
+	// Returns the scanner name
+	Name() string
+}
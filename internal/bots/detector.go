@@ -0,0 +1,126 @@
+package bots
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/aman-churiwal/api-gateway/internal/ratelimit"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+)
+
+// Matches common crawler, scraper and headless browser user agents
+var DefaultUserAgentPattern = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|scrap|curl|wget|python-requests|python-urllib|httpclient|libwww|headless|phantomjs|selenium|puppeteer|playwright`)
+
+// Signals a request can raise
+const (
+	SignalUserAgent      = "user_agent"      // Missing, or matches a bot pattern
+	SignalMissingHeaders = "missing_headers" // Lacks a header every browser sends
+	SignalHoneypot       = "honeypot"        // Requested a honeypot path
+	SignalBurst          = "burst"           // Faster than max_requests_per_second
+	SignalFlagged        = "flagged"         // Raised a behavioral signal earlier
+)
+
+// Heuristics applied to one service's requests
+type Options struct {
+	UserAgents      []*regexp.Regexp
+	AllowUserAgents []*regexp.Regexp // Exempt from the user agent and header checks
+	RequiredHeaders []string
+	HoneypotPaths   []string
+	MaxPerSecond    int           // 0 disables the burst check
+	FlagDuration    time.Duration // How long honeypot and burst signals stick to a client
+}
+
+// Outcome of checking a request
+type Verdict struct {
+	Bot     bool
+	Signals []string
+}
+
+// Classifies a service's requests as human or bot. Behavioral signals stick
+// to the client in Redis, so they hold across replicas.
+type Detector struct {
+	service string
+	opts    Options
+	redis   *storage.RedisClient
+	burst   ratelimit.Limiter
+}
+
+func NewDetector(service string, opts Options, redis *storage.RedisClient, newLimiter ratelimit.Factory) *Detector {
+	if len(opts.UserAgents) == 0 {
+		opts.UserAgents = []*regexp.Regexp{DefaultUserAgentPattern}
+	}
+	if opts.FlagDuration <= 0 {
+		opts.FlagDuration = time.Hour
+	}
+
+	d := &Detector{service: service, opts: opts, redis: redis}
+	if opts.MaxPerSecond > 0 {
+		d.burst = newLimiter("fixed_window", opts.MaxPerSecond, time.Second)
+	}
+	return d
+}
+
+// Checks a request from client, an API key ID or client IP. Redis errors
+// drop the behavioral signals rather than failing the request.
+func (d *Detector) Check(ctx context.Context, r *http.Request, client string) Verdict {
+	var signals []string
+
+	ua := r.UserAgent()
+	if !matchesAny(d.opts.AllowUserAgents, ua) {
+		if ua == "" || matchesAny(d.opts.UserAgents, ua) {
+			signals = append(signals, SignalUserAgent)
+		}
+		for _, name := range d.opts.RequiredHeaders {
+			if r.Header.Get(name) == "" {
+				signals = append(signals, SignalMissingHeaders)
+				break
+			}
+		}
+	}
+
+	flagKey := "bots:" + d.service + ":flagged:" + client
+	behavioral := false
+	if d.isHoneypot(r.URL.Path) {
+		signals = append(signals, SignalHoneypot)
+		behavioral = true
+	}
+	if d.burst != nil {
+		allowed, err := d.burst.Allow(ctx, "bots:"+d.service+":burst:"+client)
+		if err == nil && !allowed {
+			signals = append(signals, SignalBurst)
+			behavioral = true
+		}
+	}
+
+	if behavioral {
+		if err := d.redis.Set(ctx, flagKey, strings.Join(signals, ","), d.opts.FlagDuration); err != nil {
+			logging.FromContext(ctx).Warn("Failed to flag bot", "service", d.service, "error", err)
+		}
+	} else if flagged, err := d.redis.Get(ctx, flagKey); err == nil && flagged != "" {
+		signals = append(signals, SignalFlagged)
+	}
+
+	return Verdict{Bot: len(signals) > 0, Signals: signals}
+}
+
+func (d *Detector) isHoneypot(path string) bool {
+	for _, honeypot := range d.opts.HoneypotPaths {
+		if path == honeypot || strings.HasPrefix(path, strings.TrimSuffix(honeypot, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How one service's responses are cached
+type Policy struct {
+	TTL               time.Duration
+	VaryHeaders       []string // Request headers whose values get separate entries
+	QueryParams       []string // Params that select entries; nil keeps them all
+	HonorCacheControl bool
+	Paths             []PathRule
+	Coalesce          bool // Concurrent misses for a key share one backend call
+}
+
+// Overrides the policy under a path prefix
+type PathRule struct {
+	Prefix   string
+	TTL      time.Duration // Zero keeps the policy's
+	Disabled bool
+}
+
+// Returns the TTL for responses under path, or false when the path isn't cached
+func (p *Policy) PathTTL(path string) (time.Duration, bool) {
+	var match *PathRule
+	for i, rule := range p.Paths {
+		if strings.HasPrefix(path, rule.Prefix) && (match == nil || len(rule.Prefix) > len(match.Prefix)) {
+			match = &p.Paths[i]
+		}
+	}
+
+	switch {
+	case match == nil:
+		return p.TTL, true
+	case match.Disabled:
+		return 0, false
+	case match.TTL > 0:
+		return match.TTL, true
+	}
+	return p.TTL, true
+}
+
+// Returns the cache key for a GET of path with the given query and request
+// headers. Query params are filtered and sorted, so their order doesn't matter.
+func (p *Policy) Key(path, rawQuery string, header http.Header) string {
+	query, _ := url.ParseQuery(rawQuery)
+	if p.QueryParams != nil {
+		kept := make(url.Values)
+		for _, name := range p.QueryParams {
+			if values, ok := query[name]; ok {
+				kept[name] = values
+			}
+		}
+		query = kept
+	}
+
+	variant := ""
+	if len(p.VaryHeaders) > 0 {
+		hash := sha256.New()
+		for _, name := range p.VaryHeaders {
+			hash.Write([]byte(name + ":" + strings.Join(header.Values(name), ",") + "\n"))
+		}
+		variant = hex.EncodeToString(hash.Sum(nil))[:16]
+	}
+
+	// Encode sorts by name
+	return key(http.MethodGet, path, query.Encode(), variant)
+}
+
+// Returns the TTL a response may be stored for, or false when it must not be
+// stored. With Cache-Control honored, s-maxage or max-age replace ttl.
+func (p *Policy) ResponseTTL(header http.Header, ttl time.Duration) (time.Duration, bool) {
+	if strings.TrimSpace(header.Get("Vary")) == "*" {
+		return 0, false
+	}
+	if !p.HonorCacheControl {
+		return ttl, true
+	}
+
+	directives := cacheControl(header)
+	if _, ok := directives["no-cache"]; ok {
+		return 0, false
+	}
+	for _, name := range []string{"s-maxage", "max-age"} {
+		if value, ok := directives[name]; ok {
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds <= 0 {
+				return 0, false
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	return ttl, true
+}
+
+// Reports whether the request asks to skip the cache: "store" when it may
+// not be stored either (no-store), "lookup" when a fresh response is wanted
+// (no-cache or max-age=0). Empty when the cache may be used.
+func (p *Policy) RequestBypass(header http.Header) string {
+	if !p.HonorCacheControl {
+		return ""
+	}
+
+	directives := cacheControl(header)
+	if _, ok := directives["no-store"]; ok {
+		return "store"
+	}
+	if _, ok := directives["no-cache"]; ok || directives["max-age"] == "0" {
+		return "lookup"
+	}
+	if header.Get("Pragma") == "no-cache" && header.Get("Cache-Control") == "" {
+		return "lookup"
+	}
+	return ""
+}
+
+// Parses Cache-Control into lowercase directive names and their values
+func cacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, line := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(line, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name != "" {
+				directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+			}
+		}
+	}
+	return directives
+}
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/redis/go-redis/v9"
+)
+
+// A cached backend response
+type Entry struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"stored_at"`
+}
+
+// Key-value backend the cache stores entries in
+type backend interface {
+	Get(ctx context.Context, key string) ([]byte, error) // Returns nil on a miss
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	DeleteMatching(ctx context.Context, pattern string) (int, error) // Redis glob syntax
+}
+
+// Stores backend responses in Redis or the embedded store
+type Store struct {
+	backend       backend
+	maxEntryBytes int // Larger bodies are not stored; 0 means no limit
+}
+
+func NewStore(redis *storage.RedisClient, maxEntryBytes int) *Store {
+	return &Store{backend: redisBackend{redis: redis}, maxEntryBytes: maxEntryBytes}
+}
+
+// Creates a store on the embedded single-node store
+func NewEmbeddedStore(kv *storage.EmbeddedKV, maxEntryBytes int) *Store {
+	return &Store{backend: kv, maxEntryBytes: maxEntryBytes}
+}
+
+type redisBackend struct {
+	redis *storage.RedisClient
+}
+
+func (r redisBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := r.redis.Get(ctx, key)
+	if err == redis.Nil || data == "" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(data), nil
+}
+
+func (r redisBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.redis.Set(ctx, key, value, ttl)
+}
+
+func (r redisBackend) DeleteMatching(ctx context.Context, pattern string) (int, error) {
+	return r.redis.DelMatching(ctx, pattern)
+}
+
+const keyPrefix = "cache:response:"
+
+// Keys read "cache:response:GET /path?query#variant" so they can be purged by path
+func key(method, path, query, variant string) string {
+	return keyPrefix + method + " " + path + "?" + query + "#" + variant
+}
+
+// Returns a cached response, or nil on a miss
+func (s *Store) Get(ctx context.Context, key string) (*Entry, error) {
+	data, err := s.backend.Get(ctx, key)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// Stores a response for ttl. Bodies over the entry size limit are skipped.
+func (s *Store) Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) error {
+	if s.maxEntryBytes > 0 && len(entry.Body) > s.maxEntryBytes {
+		return nil
+	}
+
+	entry.StoredAt = time.Now()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return s.backend.Set(ctx, key, data, ttl)
+}
+
+// Deletes one entry by its full cache key
+func (s *Store) Delete(ctx context.Context, key string) (int, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return 0, nil
+	}
+	return s.backend.DeleteMatching(ctx, escapeGlob(key))
+}
+
+// Deletes every entry for path, across query strings and variants
+func (s *Store) Purge(ctx context.Context, path string) (int, error) {
+	return s.backend.DeleteMatching(ctx, escapeGlob(keyPrefix+"GET "+path+"?")+"*")
+}
+
+// Deletes the entries whose path matches a glob pattern, e.g. /api/users/*.
+// * and ? are wildcards; query strings and variants are ignored.
+func (s *Store) PurgePattern(ctx context.Context, pattern string) (int, error) {
+	escaped := strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(pattern)
+	return s.backend.DeleteMatching(ctx, escapeGlob(keyPrefix+"GET ")+escaped+`\?*`)
+}
+
+// Escapes glob metacharacters so s matches only itself
+func escapeGlob(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`).Replace(s)
+}
+
+// Reports whether a response may be stored in a shared cache
+func Cacheable(statusCode int, header http.Header) bool {
+	if statusCode != http.StatusOK {
+		return false
+	}
+	if header.Get("Set-Cookie") != "" {
+		return false
+	}
+
+	cacheControl := header.Get("Cache-Control")
+	for _, directive := range []string{"no-store", "private"} {
+		if strings.Contains(cacheControl, directive) {
+			return false
+		}
+	}
+
+	return true
+}
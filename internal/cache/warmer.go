@@ -0,0 +1,288 @@
+package cache
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Maximum number of errors kept on a job
+const maxJobErrors = 50
+
+// Fetches a gateway path from its backend and returns the entry to cache with its key and TTL
+type Fetcher func(ctx context.Context, path, rawQuery string) (*Entry, string, time.Duration, error)
+
+// Tracks the progress of a cache warming run
+type Job struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"` // "scheduled", "running", "completed", "cancelled"
+	Sitemap     string     `json:"sitemap,omitempty"`
+	Total       int        `json:"total"`
+	Completed   int        `json:"completed"`
+	Failed      int        `json:"failed"`
+	Errors      []string   `json:"errors,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+
+	urls []string
+}
+
+// Preloads the response cache ahead of traffic spikes
+type Warmer struct {
+	mu          sync.RWMutex
+	store       *Store
+	fetch       Fetcher
+	jobs        map[string]*Job
+	concurrency int
+	ctx         context.Context
+	cancel      context.CancelFunc
+	client      *http.Client
+}
+
+func NewWarmer(store *Store, fetch Fetcher, concurrency int) *Warmer {
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Warmer{
+		store:       store,
+		fetch:       fetch,
+		jobs:        make(map[string]*Job),
+		concurrency: concurrency,
+		ctx:         ctx,
+		cancel:      cancel,
+		client:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Schedules a warming job for a list of URLs and/or a sitemap, starting at the given time
+func (w *Warmer) Submit(urls []string, sitemap string, at time.Time) (*Job, error) {
+	if len(urls) == 0 && sitemap == "" {
+		return nil, errors.New("at least one url or a sitemap is required")
+	}
+
+	now := time.Now()
+	if at.Before(now) {
+		at = now
+	}
+
+	job := &Job{
+		ID:          uuid.New().String(),
+		Status:      "scheduled",
+		Sitemap:     sitemap,
+		Total:       len(urls),
+		CreatedAt:   now,
+		ScheduledAt: at,
+		urls:        urls,
+	}
+
+	w.mu.Lock()
+	w.jobs[job.ID] = job
+	w.mu.Unlock()
+
+	go func() {
+		timer := time.NewTimer(time.Until(at))
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			w.run(job)
+		case <-w.ctx.Done():
+			w.finish(job, "cancelled")
+		}
+	}()
+
+	snapshot := w.snapshot(job)
+	return &snapshot, nil
+}
+
+// Returns a job by id
+func (w *Warmer) Get(id string) (*Job, bool) {
+	w.mu.RLock()
+	job, exists := w.jobs[id]
+	w.mu.RUnlock()
+
+	if !exists {
+		return nil, false
+	}
+
+	snapshot := w.snapshot(job)
+	return &snapshot, true
+}
+
+// Returns all jobs, newest first
+func (w *Warmer) List() []Job {
+	w.mu.RLock()
+	jobs := make([]*Job, 0, len(w.jobs))
+	for _, job := range w.jobs {
+		jobs = append(jobs, job)
+	}
+	w.mu.RUnlock()
+
+	snapshots := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		snapshots = append(snapshots, w.snapshot(job))
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+
+	return snapshots
+}
+
+// Cancels scheduled and running jobs
+func (w *Warmer) Stop() {
+	w.cancel()
+}
+
+func (w *Warmer) run(job *Job) {
+	now := time.Now()
+
+	w.mu.Lock()
+	job.Status = "running"
+	job.StartedAt = &now
+	w.mu.Unlock()
+
+	urls := job.urls
+	if job.Sitemap != "" {
+		sitemapURLs, err := w.loadSitemap(job.Sitemap)
+		if err != nil {
+			w.recordError(job, fmt.Sprintf("sitemap: %v", err))
+		}
+		urls = append(urls, sitemapURLs...)
+
+		w.mu.Lock()
+		job.Total = len(urls)
+		w.mu.Unlock()
+	}
+
+	sem := make(chan struct{}, w.concurrency)
+	var wg sync.WaitGroup
+
+	for _, rawURL := range urls {
+		if w.ctx.Err() != nil {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(rawURL string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			w.warm(job, rawURL)
+		}(rawURL)
+	}
+
+	wg.Wait()
+
+	if w.ctx.Err() != nil {
+		w.finish(job, "cancelled")
+		return
+	}
+	w.finish(job, "completed")
+}
+
+// Fetches a single URL and stores it in the cache
+func (w *Warmer) warm(job *Job, rawURL string) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		w.recordError(job, fmt.Sprintf("%s: %v", rawURL, err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(w.ctx, 30*time.Second)
+	defer cancel()
+
+	entry, key, ttl, err := w.fetch(ctx, parsed.Path, parsed.RawQuery)
+	if err == nil {
+		err = w.store.Set(ctx, key, entry, ttl)
+	}
+	if err != nil {
+		w.recordError(job, fmt.Sprintf("%s: %v", rawURL, err))
+		return
+	}
+
+	w.mu.Lock()
+	job.Completed++
+	w.mu.Unlock()
+}
+
+// Sitemap document (https://www.sitemaps.org/protocol.html)
+type urlSet struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+}
+
+// Reads the page URLs listed in a sitemap
+func (w *Warmer) loadSitemap(sitemapURL string) ([]string, error) {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodGet, sitemapURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var set urlSet
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(set.URLs))
+	for _, u := range set.URLs {
+		urls = append(urls, u.Loc)
+	}
+	return urls, nil
+}
+
+func (w *Warmer) recordError(job *Job, message string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	job.Failed++
+	if len(job.Errors) < maxJobErrors {
+		job.Errors = append(job.Errors, message)
+	}
+}
+
+func (w *Warmer) finish(job *Job, status string) {
+	now := time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	job.Status = status
+	job.FinishedAt = &now
+}
+
+// Returns a copy of a job that is safe to read without the lock
+func (w *Warmer) snapshot(job *Job) Job {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	snapshot := *job
+	snapshot.Errors = append([]string(nil), job.Errors...)
+	snapshot.urls = nil
+	return snapshot
+}
@@ -0,0 +1,101 @@
+package capture
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Replaces redacted values
+const Redacted = "[REDACTED]"
+
+// Redacted unless configured otherwise; configured names are added to these
+var (
+	DefaultHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+	DefaultFields  = []string{"password", "secret", "token", "access_token", "refresh_token", "api_key", "card_number", "cvv"}
+)
+
+// Candidate card numbers: 13 to 19 digits, optionally grouped by spaces or dashes
+var cardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+// Masks credentials, sensitive fields and card numbers in captured traffic
+type Redactor struct {
+	headers   map[string]bool
+	jsonField *regexp.Regexp
+	formField *regexp.Regexp
+}
+
+func NewRedactor(headers, fields []string) *Redactor {
+	r := &Redactor{headers: make(map[string]bool)}
+	for _, name := range append(append([]string{}, DefaultHeaders...), headers...) {
+		r.headers[http.CanonicalHeaderKey(name)] = true
+	}
+
+	quoted := make([]string, 0, len(DefaultFields)+len(fields))
+	for _, field := range append(append([]string{}, DefaultFields...), fields...) {
+		quoted = append(quoted, regexp.QuoteMeta(field))
+	}
+	names := strings.Join(quoted, "|")
+
+	// Patterns rather than parsing, so truncated bodies are still redacted
+	r.jsonField = regexp.MustCompile(`(?i)("(?:` + names + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+	r.formField = regexp.MustCompile(`(?i)((?:^|&)(?:` + names + `)=)[^&]*`)
+	return r
+}
+
+// Renders headers one per line with sensitive values masked
+func (r *Redactor) Headers(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		for _, value := range header[name] {
+			if r.headers[http.CanonicalHeaderKey(name)] {
+				value = Redacted
+			}
+			b.WriteString(name)
+			b.WriteString(": ")
+			b.WriteString(value)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// Masks sensitive fields in JSON and form bodies, and card numbers anywhere
+func (r *Redactor) Body(body string) string {
+	body = r.jsonField.ReplaceAllString(body, `${1}"`+Redacted+`"`)
+	body = r.formField.ReplaceAllString(body, `${1}`+Redacted)
+	return cardPattern.ReplaceAllStringFunc(body, func(match string) string {
+		if luhn(match) {
+			return Redacted
+		}
+		return match
+	})
+}
+
+// Reports whether the digits in s pass the Luhn checksum used by card numbers
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
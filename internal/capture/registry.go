@@ -0,0 +1,135 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/audit"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+)
+
+// Redis hash of service path to capture expiry, shared by all replicas
+const redisKey = "gateway:body_capture"
+
+// Capture windows can't outlive this, so a forgotten capture stops on its own
+const MaxDuration = 24 * time.Hour
+
+// A service with body capture switched on
+type Session struct {
+	Service string    `json:"service"`
+	Until   time.Time `json:"until"`
+}
+
+// Tracks which services currently capture request and response bodies.
+// Capture is off unless switched on through the admin API, and always expires.
+type Registry struct {
+	mu       sync.RWMutex
+	until    map[string]time.Time
+	redis    *storage.RedisClient
+	hashSync *storage.HashSync
+}
+
+func NewRegistry(redis *storage.RedisClient, interval time.Duration) *Registry {
+	r := &Registry{
+		until: make(map[string]time.Time),
+		redis: redis,
+	}
+	r.hashSync = storage.NewHashSync(redis, redisKey, "body capture sessions", interval, r.apply)
+	return r
+}
+
+// Reports whether bodies should be captured for the service right now
+func (r *Registry) Enabled(service string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	until, exists := r.until[service]
+	return exists && time.Now().Before(until)
+}
+
+// Captures bodies for the service until the duration has passed
+func (r *Registry) Enable(ctx context.Context, service string, duration time.Duration) (time.Time, error) {
+	if duration <= 0 || duration > MaxDuration {
+		return time.Time{}, fmt.Errorf("capture duration must be between 1s and %v", MaxDuration)
+	}
+
+	until := time.Now().Add(duration).UTC()
+	if err := r.redis.HSet(ctx, redisKey, service, until.Format(time.RFC3339)); err != nil {
+		return time.Time{}, fmt.Errorf("failed to persist body capture: %w", err)
+	}
+
+	r.mu.Lock()
+	r.until[service] = until
+	r.mu.Unlock()
+
+	audit.Record(ctx, "body_capture.enable", "service", service, nil, map[string]time.Time{"until": until})
+
+	log.Printf("Body capture enabled for %s until %s", service, until.Format(time.RFC3339))
+	return until, nil
+}
+
+// Stops capturing bodies for the service
+func (r *Registry) Disable(ctx context.Context, service string) error {
+	if err := r.redis.HDel(ctx, redisKey, service); err != nil {
+		return fmt.Errorf("failed to persist body capture: %w", err)
+	}
+
+	r.mu.Lock()
+	delete(r.until, service)
+	r.mu.Unlock()
+
+	audit.Record(ctx, "body_capture.disable", "service", service, nil, nil)
+
+	log.Printf("Body capture disabled for %s", service)
+	return nil
+}
+
+// Returns services currently capturing, sorted by path
+func (r *Registry) Sessions() []Session {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	sessions := make([]Session, 0, len(r.until))
+	for service, until := range r.until {
+		if now.Before(until) {
+			sessions = append(sessions, Session{Service: service, Until: until})
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Service < sessions[j].Service })
+	return sessions
+}
+
+// Loads persisted sessions and keeps them in sync with other replicas
+func (r *Registry) Start() {
+	r.hashSync.Start()
+}
+
+func (r *Registry) Stop() {
+	r.hashSync.Stop()
+}
+
+// Replaces the sessions with those persisted in Redis, returning expired ones to delete
+func (r *Registry) apply(persisted map[string]string) []string {
+	now := time.Now()
+	until := make(map[string]time.Time, len(persisted))
+	var expired []string
+	for service, value := range persisted {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil || !now.Before(t) {
+			expired = append(expired, service)
+			continue
+		}
+		until[service] = t
+	}
+
+	r.mu.Lock()
+	r.until = until
+	r.mu.Unlock()
+
+	return expired
+}
@@ -0,0 +1,155 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/config"
+)
+
+// Services and rate limit tiers read from a catalog backend. Each service is
+// a JSON document under <prefix>/services/ and each tier one under
+// <prefix>/tiers/; other keys are ignored.
+type Snapshot struct {
+	Services []config.ServiceConfig
+	Tiers    []config.RateLimiterTier
+}
+
+// A key-value store holding the catalog
+type Source interface {
+	// Returns the current catalog and the version it was read at
+	Load(ctx context.Context) (*Snapshot, uint64, error)
+	// Blocks until the catalog changes after version, returning the new version.
+	// May return version unchanged when the backend times the wait out.
+	Wait(ctx context.Context, version uint64) (uint64, error)
+	Name() string
+}
+
+// Opens the source for a catalog backend
+func NewSource(cfg *config.CatalogConfig) (Source, error) {
+	switch cfg.Backend {
+	case "consul":
+		return NewConsul(cfg.Address, cfg.Prefix, cfg.Token), nil
+	case "etcd":
+		return NewEtcd(cfg.Address, cfg.Prefix, cfg.Username, cfg.Password), nil
+	default:
+		return nil, fmt.Errorf("unknown catalog backend: %s", cfg.Backend)
+	}
+}
+
+// Decodes a key under prefix into the snapshot
+func (s *Snapshot) add(prefix, key string, value []byte) error {
+	name, isService := strings.CutPrefix(key, prefix+"/services/")
+	if isService && name != "" {
+		var svc config.ServiceConfig
+		if err := json.Unmarshal(value, &svc); err != nil {
+			return fmt.Errorf("invalid service %s: %w", key, err)
+		}
+		s.Services = append(s.Services, svc)
+		return nil
+	}
+
+	name, isTier := strings.CutPrefix(key, prefix+"/tiers/")
+	if isTier && name != "" {
+		var tier config.RateLimiterTier
+		if err := json.Unmarshal(value, &tier); err != nil {
+			return fmt.Errorf("invalid tier %s: %w", key, err)
+		}
+		if tier.Name == "" {
+			tier.Name = name
+		}
+		s.Tiers = append(s.Tiers, tier)
+	}
+
+	return nil
+}
+
+// Reloads the catalog whenever the source reports a change
+type Watcher struct {
+	source   Source
+	version  uint64
+	apply    func(*Snapshot)
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	cancel   context.CancelFunc
+}
+
+// version is the one the current routing table was loaded at
+func NewWatcher(source Source, version uint64, apply func(*Snapshot)) *Watcher {
+	return &Watcher{
+		source:   source,
+		version:  version,
+		apply:    apply,
+		stopChan: make(chan struct{}),
+	}
+}
+
+func (w *Watcher) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.running {
+		return
+	}
+	w.running = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	go w.run(ctx)
+}
+
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.running {
+		w.cancel()
+		close(w.stopChan)
+		w.running = false
+	}
+}
+
+func (w *Watcher) run(ctx context.Context) {
+	backoff := time.Second
+
+	for {
+		version, err := w.source.Wait(ctx, w.version)
+		if err == nil && version != w.version {
+			err = w.reload(ctx)
+		}
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Failed to watch %s catalog, retrying in %s: %v", w.source.Name(), backoff, err)
+
+			select {
+			case <-time.After(backoff):
+			case <-w.stopChan:
+				return
+			}
+			backoff = min(backoff*2, time.Minute)
+			continue
+		}
+		backoff = time.Second
+	}
+}
+
+func (w *Watcher) reload(ctx context.Context) error {
+	snapshot, version, err := w.source.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	w.version = version
+	w.apply(snapshot)
+	return nil
+}
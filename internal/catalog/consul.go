@@ -0,0 +1,109 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How long a blocking query waits for a change before Consul answers anyway
+const consulWait = 5 * time.Minute
+
+// Catalog in Consul KV, watched with blocking queries
+type Consul struct {
+	address string
+	prefix  string
+	token   string
+	client  *http.Client
+}
+
+func NewConsul(address, prefix, token string) *Consul {
+	return &Consul{
+		address: strings.TrimRight(address, "/"),
+		prefix:  prefix,
+		token:   token,
+		client:  &http.Client{Timeout: consulWait + 30*time.Second},
+	}
+}
+
+func (c *Consul) Name() string {
+	return "consul"
+}
+
+type consulPair struct {
+	Key   string `json:"Key"`
+	Value []byte `json:"Value"` // Base64 in the response
+}
+
+func (c *Consul) Load(ctx context.Context) (*Snapshot, uint64, error) {
+	resp, index, err := c.get(ctx, url.Values{"recurse": {"true"}})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	snapshot := &Snapshot{}
+	if resp.StatusCode == http.StatusNotFound {
+		return snapshot, index, nil
+	}
+
+	var pairs []consulPair
+	if err := json.NewDecoder(resp.Body).Decode(&pairs); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode consul response: %w", err)
+	}
+	for _, pair := range pairs {
+		if err := snapshot.add(c.prefix, pair.Key, pair.Value); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return snapshot, index, nil
+}
+
+func (c *Consul) Wait(ctx context.Context, version uint64) (uint64, error) {
+	resp, index, err := c.get(ctx, url.Values{
+		"keys":  {"true"},
+		"index": {strconv.FormatUint(version, 10)},
+		"wait":  {consulWait.String()},
+	})
+	if err != nil {
+		return version, err
+	}
+	resp.Body.Close()
+
+	return index, nil
+}
+
+// Reads the prefix, returning the response and its X-Consul-Index
+func (c *Consul) get(ctx context.Context, query url.Values) (*http.Response, uint64, error) {
+	endpoint := fmt.Sprintf("%s/v1/kv/%s/?%s", c.address, c.prefix, query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to reach consul: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("consul returned status %d", resp.StatusCode)
+	}
+
+	index, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if err != nil {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("consul response has no valid X-Consul-Index")
+	}
+
+	return resp, index, nil
+}
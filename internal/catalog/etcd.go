@@ -0,0 +1,248 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Catalog in etcd, read and watched through its v3 JSON gateway
+type Etcd struct {
+	address  string
+	prefix   string
+	username string
+	password string
+	client   *http.Client // No timeout; watches are held open until the catalog changes
+
+	mu    sync.Mutex
+	token string
+}
+
+func NewEtcd(address, prefix, username, password string) *Etcd {
+	return &Etcd{
+		address:  strings.TrimRight(address, "/"),
+		prefix:   prefix,
+		username: username,
+		password: password,
+		client:   &http.Client{},
+	}
+}
+
+func (e *Etcd) Name() string {
+	return "etcd"
+}
+
+// Revisions are 64-bit integers, which the gateway encodes as strings
+type etcdHeader struct {
+	Revision string `json:"revision"`
+}
+
+func (h etcdHeader) revision() (uint64, error) {
+	revision, err := strconv.ParseUint(h.Revision, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("etcd response has no valid revision")
+	}
+	return revision, nil
+}
+
+type etcdRangeResponse struct {
+	Header etcdHeader `json:"header"`
+	Kvs    []struct {
+		Key   []byte `json:"key"`
+		Value []byte `json:"value"`
+	} `json:"kvs"`
+}
+
+type etcdWatchResponse struct {
+	Result struct {
+		Header          etcdHeader        `json:"header"`
+		Canceled        bool              `json:"canceled"`
+		CancelReason    string            `json:"cancel_reason"`
+		CompactRevision string            `json:"compact_revision"`
+		Events          []json.RawMessage `json:"events"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (e *Etcd) Load(ctx context.Context) (*Snapshot, uint64, error) {
+	key, rangeEnd := e.keyRange()
+
+	resp, err := e.post(ctx, "/v3/kv/range", map[string]interface{}{
+		"key":       key,
+		"range_end": rangeEnd,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	var result etcdRangeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode etcd response: %w", err)
+	}
+	revision, err := result.Header.revision()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	snapshot := &Snapshot{}
+	for _, kv := range result.Kvs {
+		if err := snapshot.add(e.prefix, string(kv.Key), kv.Value); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return snapshot, revision, nil
+}
+
+func (e *Etcd) Wait(ctx context.Context, version uint64) (uint64, error) {
+	key, rangeEnd := e.keyRange()
+
+	resp, err := e.post(ctx, "/v3/watch", map[string]interface{}{
+		"create_request": map[string]interface{}{
+			"key":            key,
+			"range_end":      rangeEnd,
+			"start_revision": strconv.FormatUint(version+1, 10),
+		},
+	})
+	if err != nil {
+		return version, err
+	}
+	defer resp.Body.Close()
+
+	// The stream opens with a created message, then one message per batch of changes
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var message etcdWatchResponse
+		if err := decoder.Decode(&message); err != nil {
+			return version, fmt.Errorf("etcd watch ended: %w", err)
+		}
+		if message.Error != nil {
+			return version, fmt.Errorf("etcd watch failed: %s", message.Error.Message)
+		}
+
+		result := message.Result
+		if result.CompactRevision != "" && result.CompactRevision != "0" {
+			// Changes since version were compacted away, so reload from scratch
+			return result.Header.revision()
+		}
+		if result.Canceled {
+			return version, fmt.Errorf("etcd watch canceled: %s", result.CancelReason)
+		}
+		if len(result.Events) > 0 {
+			return result.Header.revision()
+		}
+	}
+}
+
+// Returns the key and range_end covering everything under the prefix
+func (e *Etcd) keyRange() ([]byte, []byte) {
+	key := []byte(e.prefix + "/")
+	rangeEnd := bytes.Clone(key)
+	rangeEnd[len(rangeEnd)-1]++ // "/" + 1 = "0"
+	return key, rangeEnd
+}
+
+// Sends a gateway request, authenticating first when credentials are configured
+func (e *Etcd) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := e.authToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.send(ctx, path, payload, token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Simple tokens expire; get a fresh one and retry once
+	if resp.StatusCode == http.StatusUnauthorized && e.username != "" {
+		resp.Body.Close()
+		e.mu.Lock()
+		e.token = ""
+		e.mu.Unlock()
+
+		if token, err = e.authToken(ctx); err != nil {
+			return nil, err
+		}
+		if resp, err = e.send(ctx, path, payload, token); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("etcd returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	return resp, nil
+}
+
+func (e *Etcd) send(ctx context.Context, path string, payload []byte, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.address+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach etcd: %w", err)
+	}
+	return resp, nil
+}
+
+// Returns the cached auth token, authenticating if there is none
+func (e *Etcd) authToken(ctx context.Context) (string, error) {
+	if e.username == "" {
+		return "", nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.token != "" {
+		return e.token, nil
+	}
+
+	payload, err := json.Marshal(map[string]string{"name": e.username, "password": e.password})
+	if err != nil {
+		return "", err
+	}
+	resp, err := e.send(ctx, "/v3/auth/authenticate", payload, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("etcd authentication returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode etcd authentication response: %w", err)
+	}
+
+	e.token = result.Token
+	return e.token, nil
+}
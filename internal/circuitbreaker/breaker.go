@@ -24,12 +24,16 @@ type CircuitBreaker struct {
 	maxFailures     int           // Number of failures before opening
 	timeout         time.Duration // How long to stay open
 	halfOpenSuccess int           // Successes needed in half-open to close
+	onStateChange   func(from, to State)
 }
 
 type Config struct {
 	MaxFailures     int           // Default: 5
 	Timeout         time.Duration // Default: 30 seconds
 	HalfOpenSuccess int           // Default: 1
+
+	// Called with the lock held on every transition, so it must not block
+	OnStateChange func(from, to State)
 }
 
 func New(cfg Config) *CircuitBreaker {
@@ -48,6 +52,7 @@ func New(cfg Config) *CircuitBreaker {
 		maxFailures:     cfg.MaxFailures,
 		timeout:         cfg.Timeout,
 		halfOpenSuccess: cfg.HalfOpenSuccess,
+		onStateChange:   cfg.OnStateChange,
 		lastStateChange: time.Now(),
 	}
 }
@@ -120,8 +125,12 @@ func (cb *CircuitBreaker) onSuccess() {
 // Changes the circuit breaker state
 func (cb *CircuitBreaker) setState(newState State) {
 	if cb.state != newState {
+		from := cb.state
 		cb.state = newState
 		cb.lastStateChange = time.Now()
+		if cb.onStateChange != nil {
+			cb.onStateChange(from, newState)
+		}
 	}
 }
 
@@ -137,7 +146,7 @@ func (cb *CircuitBreaker) Reset() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
-	cb.state = StateClosed
+	cb.setState(StateClosed)
 	cb.failureCount = 0
 	cb.successCount = 0
 	cb.lastStateChange = time.Now()
@@ -1,24 +1,139 @@
 package config
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"net/netip"
 	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/routing"
+	"github.com/google/uuid"
 )
 
 type Config struct {
-	Server         ServerConfig      `json:"server"`
-	Redis          RedisConfig       `json:"redis"`
-	Database       DatabaseConfig    `json:"database"`
-	JWT            JWTConfig         `json:"jwt"`
-	Analytics      AnalyticsConfig   `json:"analytics"`
-	Services       []ServiceConfig   `json:"services"`
-	RateLimitTiers []RateLimiterTier `json:"rate_limit_tiers"`
+	Server         ServerConfig            `json:"server"`
+	Resources      ResourceConfig          `json:"resources"`
+	Logging        LoggingConfig           `json:"logging"`
+	Redis          RedisConfig             `json:"redis"`
+	Storage        StorageConfig           `json:"storage"`
+	Database       DatabaseConfig          `json:"database"`
+	JWT            JWTConfig               `json:"jwt"`
+	Auth           AuthConfig              `json:"auth"`
+	StaleKeys      StaleKeysConfig         `json:"stale_keys"`
+	KeyValidation  KeyValidationConfig     `json:"key_validation"`
+	Analytics      AnalyticsConfig         `json:"analytics"`
+	AccessLog      AccessLogConfig         `json:"access_log"`
+	Alerts         AlertsConfig            `json:"alerts"`
+	UsageReports   UsageReportsConfig      `json:"usage_reports"`
+	Portal         PortalConfig            `json:"portal"`
+	OAuth          OAuthConfig             `json:"oauth"`
+	Services       []ServiceConfig         `json:"services"`
+	RateLimitTiers []RateLimiterTier       `json:"rate_limit_tiers"`
+	DarkLaunch     []DarkLaunchRule        `json:"dark_launch,omitempty"`
+	TokenExchange  TokenExchange           `json:"token_exchange"`
+	Webhooks       []WebhookConfig         `json:"webhooks,omitempty"`
+	CORS           *CORSConfig             `json:"cors,omitempty"`
+	Messages       MessagesConfig          `json:"messages"`
+	PolicyBundles  map[string]PolicyBundle `json:"policy_bundles,omitempty"`
+	OIDC           *OIDCConfig             `json:"oidc,omitempty"`
+	Catalog        *CatalogConfig          `json:"catalog,omitempty"`
+	APICatalog     APICatalogConfig        `json:"api_catalog"`
+	Kubernetes     *KubernetesConfig       `json:"kubernetes,omitempty"`
+	Vault          *VaultConfig            `json:"vault,omitempty"`
+	Composites     []CompositeConfig       `json:"composites,omitempty"`
+	IPFilter       *IPFilterConfig         `json:"ip_filter,omitempty"` // Every route except fast-path services
+	GeoIP          *GeoIPConfig            `json:"geoip,omitempty"`     // Adds the client's country to request logs
+	RequestID      *RequestIDConfig        `json:"request_id,omitempty"`
+	AsyncQueue     AsyncQueueConfig        `json:"async_queue"` // Workers for services with async requests
 }
 
 type ServerConfig struct {
-	Port        string `json:"port"`
-	Environment string `json:"environment"` // Development or production
+	Port                  string   `json:"port"`
+	Environment           string   `json:"environment"`             // Development or production
+	MaxConcurrentRequests int      `json:"max_concurrent_requests"` // Default: 0 (unlimited)
+	QueueTimeoutMs        int      `json:"queue_timeout_ms"`        // Default: 100
+	PriorityPaths         []string `json:"priority_paths"`          // Added to /health, /readyz and /admin
+	TrustedProxies        []string `json:"trusted_proxies"`         // CIDRs or addresses whose X-Forwarded-For names the client. Default: none
+	Profile               string   `json:"profile"`                 // "standard" (default) or "edge" for memory-constrained devices
+
+	TLS   *TLSConfig           `json:"tls,omitempty"`   // Terminate HTTPS in the gateway
+	Admin *AdminListenerConfig `json:"admin,omitempty"` // Serve the management routes on their own listener
+
+	// Connection limits for both listeners. Negative timeouts disable them,
+	// which streaming and long-polling services may need for writes.
+	ReadHeaderTimeoutSeconds int `json:"read_header_timeout_seconds"` // Default: 10
+	ReadTimeoutSeconds       int `json:"read_timeout_seconds"`        // Whole request including body. Default: 30
+	WriteTimeoutSeconds      int `json:"write_timeout_seconds"`       // Default: 60
+	IdleTimeoutSeconds       int `json:"idle_timeout_seconds"`        // Keep-alive. Default: 120
+	MaxHeaderBytes           int `json:"max_header_bytes"`            // Default: 1 MiB
+	ShutdownTimeoutSeconds   int `json:"shutdown_timeout_seconds"`    // Wait for in-flight requests on shutdown. Default: 30
+}
+
+// Moves /admin, /auth, /health and /readyz off the proxy listener so the
+// management plane can be firewalled separately. At least one of port and
+// socket is required.
+type AdminListenerConfig struct {
+	Port   string `json:"port,omitempty"`
+	Socket string `json:"socket,omitempty"` // Unix socket path, created with mode 0660
+}
+
+// Certificates and protocol settings for serving HTTPS. With several
+// certificates the one matching the client's SNI server name is used,
+// falling back to the first.
+type TLSConfig struct {
+	Certificates []TLSCertificate `json:"certificates"`
+	MinVersion   string           `json:"min_version"`   // "1.0" to "1.3". Default: "1.2"
+	CipherSuites []string         `json:"cipher_suites"` // Go names such as TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Default: Go's; ignored for TLS 1.3
+
+	ClientAuth *ClientAuthConfig `json:"client_auth,omitempty"` // Client certificates on the proxy listener
+}
+
+// Verifies client certificates against a CA. A verified certificate stands in
+// for an API key: its identity is rate limited by tier and logged like a key.
+// With mode "optional" services can still require one through client_cert.
+type ClientAuthConfig struct {
+	Mode        string               `json:"mode"` // "optional" (default) or "require"
+	CAFile      string               `json:"ca_file"`
+	DefaultTier string               `json:"default_tier,omitempty"` // For unlisted identities. Default: "basic"
+	Identities  []CertIdentityConfig `json:"identities,omitempty"`
+}
+
+// Matched against the certificate's common name and DNS, URI and email SANs
+type CertIdentityConfig struct {
+	Subject string `json:"subject"`        // e.g. "partner-a.example.com" or "spiffe://example.com/partner-a"
+	Name    string `json:"name,omitempty"` // Default: the subject
+	Tier    string `json:"tier,omitempty"` // Default: default_tier
+}
+
+// Requires requests to a service to present a verified client certificate
+type ServiceClientCertConfig struct {
+	Required bool     `json:"required"`
+	Allowed  []string `json:"allowed,omitempty"` // Identity names, or "cert:" and the subject of unlisted certificates; empty allows any verified certificate
+}
+
+type TLSCertificate struct {
+	CertFile string `json:"cert_file"` // PEM, leaf first then intermediates
+	KeyFile  string `json:"key_file"`
+}
+
+// Buffer, cache and concurrency bounds. Unset values come from the server profile.
+type LoggingConfig struct {
+	Level  string `json:"level"`  // "debug", "info" (default), "warn" or "error"
+	Format string `json:"format"` // "json" (default) or "text"
+}
+
+type ResourceConfig struct {
+	LogBufferSize          int `json:"log_buffer_size"`          // standard: 1000, edge: 100
+	CacheMaxEntryBytes     int `json:"cache_max_entry_bytes"`    // standard: 1 MiB, edge: 64 KiB
+	CacheWarmWorkers       int `json:"cache_warm_workers"`       // standard: 4, edge: 1
+	HealthCheckConcurrency int `json:"health_check_concurrency"` // standard: 0 (one per target), edge: 2
+	WebhookBufferSize      int `json:"webhook_buffer_size"`      // standard: 1000, edge: 100
 }
 
 type RedisConfig struct {
@@ -28,6 +143,13 @@ type RedisConfig struct {
 	DB       int    `json:"db"`
 }
 
+// Where rate limit counters and cached responses live. The memory backend
+// also keeps the database in the process, so nothing survives a restart.
+type StorageConfig struct {
+	Backend string `json:"backend"` // "redis" (default), "embedded" for single-node installs without Redis, or "memory" for development and tests
+	Path    string `json:"path"`    // Embedded store file. Default: "gateway.db"
+}
+
 type DatabaseConfig struct {
 	Host     string `json:"host"`
 	Port     int    `json:"port"`
@@ -35,26 +157,595 @@ type DatabaseConfig struct {
 	Password string `json:"password"`
 	DBName   string `json:"dbname"`
 	SSLMode  string `json:"sslmode"`
+
+	Driver string `json:"driver"` // "postgres" (default), "mysql" or "sqlite"
+	Path   string `json:"path"`   // SQLite database file, or ":memory:". Default: "gateway.sqlite"
 }
 
 type JWTConfig struct {
-	Secret      string `json:"secret"`
-	ExpiryHours int    `json:"expiry_hours"`
+	Secret             string `json:"secret"`
+	ExpiryHours        int    `json:"expiry_hours"`
+	ExpiryMinutes      int    `json:"expiry_minutes"`       // Overrides expiry_hours for short-lived access tokens
+	RefreshExpiryHours int    `json:"refresh_expiry_hours"` // Default: 720 (30 days)
+	// Asymmetric signing keys; when set they replace the HS256 secret. Keep a
+	// rotated-out key listed until tokens signed with it have expired.
+	Keys         []JWTKeyConfig `json:"keys,omitempty"`
+	SigningKeyID string         `json:"signing_key_id"` // Default: first key
+}
+
+type JWTKeyConfig struct {
+	ID             string `json:"id"`
+	Algorithm      string `json:"algorithm"`        // "RS256" or "EdDSA"
+	PrivateKeyFile string `json:"private_key_file"` // PEM encoded private key
+}
+
+type AuthConfig struct {
+	Registration          string              `json:"registration"`            // "open" (default) or "invite": open until the first admin exists, invitation-only after
+	InvitationExpiryHours int                 `json:"invitation_expiry_hours"` // Default: 72
+	PasswordResetMinutes  int                 `json:"password_reset_minutes"`  // Reset token lifetime. Default: 60
+	LoginThrottle         LoginThrottleConfig `json:"login_throttle"`
+}
+
+// Backoff and lockout after failed password logins
+type LoginThrottleConfig struct {
+	Disabled           bool `json:"disabled"`
+	MaxAccountFailures int  `json:"max_account_failures"` // Failures that lock an account. Default: 5
+	MaxIPFailures      int  `json:"max_ip_failures"`      // Failures that lock out a client IP. Default: 20
+	WindowMinutes      int  `json:"window_minutes"`       // How long failures are counted. Default: 15
+	BaseDelaySeconds   int  `json:"base_delay_seconds"`   // Backoff after the first failure, doubling after each. Default: 1
+	MaxDelaySeconds    int  `json:"max_delay_seconds"`    // Default: 60
+	LockoutMinutes     int  `json:"lockout_minutes"`      // Default: 15
+}
+
+// Returns how long an invitation token stays valid
+func (a *AuthConfig) InvitationExpiry() time.Duration {
+	if a.InvitationExpiryHours <= 0 {
+		return 72 * time.Hour
+	}
+	return time.Duration(a.InvitationExpiryHours) * time.Hour
+}
+
+// Single sign-on for gateway admins through an OIDC identity provider
+type OIDCConfig struct {
+	Enabled      bool              `json:"enabled"`
+	Issuer       string            `json:"issuer"`
+	ClientID     string            `json:"client_id"`
+	ClientSecret string            `json:"client_secret"` // Prefer OIDC_CLIENT_SECRET
+	RedirectURL  string            `json:"redirect_url"`  // Must point at /auth/oidc/callback
+	Scopes       []string          `json:"scopes"`        // Default: openid, email, profile
+	GroupsClaim  string            `json:"groups_claim"`  // Default: "groups"
+	RoleMapping  map[string]string `json:"role_mapping"`  // IdP group to "admin" or "viewer"
+	DefaultRole  string            `json:"default_role"`  // Role for unmapped users; empty denies them
+}
+
+// Policy for flagging and expiring unused API keys
+type StaleKeysConfig struct {
+	Enabled              bool `json:"enabled"`                // Runs periodic sweeps; the report is always available
+	UnusedDays           int  `json:"unused_days"`            // Default: 90
+	GraceDays            int  `json:"grace_days"`             // Default: 14
+	AutoDeactivate       bool `json:"auto_deactivate"`        // Deactivate keys still unused after the grace period
+	CheckIntervalMinutes int  `json:"check_interval_minutes"` // Default: 60
+}
+
+// What API key validation does when the database can't be reached and the
+// cache misses. Keys admitted this way may since have been revoked on another
+// gateway instance, so fail_open trades safety for availability.
+type KeyValidationConfig struct {
+	Degradation         string `json:"degradation"`           // "fail_closed" (default), "stale_cache" or "fail_open"
+	StaleCacheMinutes   int    `json:"stale_cache_minutes"`   // How long a key's last cached copy stays usable. Default: 60
+	RecentlySeenMinutes int    `json:"recently_seen_minutes"` // fail_open: keys validated this recently are admitted. Default: 15
+	SigningSecret       string `json:"signing_secret"`        // Derives each key's HMAC request signing secret; signed requests are refused without it. Prefer KEY_SIGNING_SECRET
+}
+
+// Returns how long a password reset token stays valid
+func (a *AuthConfig) PasswordResetExpiry() time.Duration {
+	if a.PasswordResetMinutes <= 0 {
+		return time.Hour
+	}
+	return time.Duration(a.PasswordResetMinutes) * time.Minute
+}
+
+// Returns the access token lifetime
+func (j *JWTConfig) AccessExpiry() time.Duration {
+	if j.ExpiryMinutes > 0 {
+		return time.Duration(j.ExpiryMinutes) * time.Minute
+	}
+	return time.Duration(j.ExpiryHours) * time.Hour
+}
+
+// Returns the refresh token lifetime
+func (j *JWTConfig) RefreshExpiry() time.Duration {
+	if j.RefreshExpiryHours <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(j.RefreshExpiryHours) * time.Hour
 }
 
 type AnalyticsConfig struct {
-	Enabled          bool `json:"enabled"`
-	RetentionDays    int  `json:"retention_days"`
-	BatchSize        int  `json:"batch_size"`
-	FlushIntervalSec int  `json:"flush_interval_sec"`
+	Enabled          bool              `json:"enabled"`
+	RetentionDays    int               `json:"retention_days"`
+	BatchSize        int               `json:"batch_size"`
+	FlushIntervalSec int               `json:"flush_interval_sec"`
+	Backend          string            `json:"backend"` // "postgres" (default), "timescale" or "clickhouse"
+	ClickHouse       *ClickHouseConfig `json:"clickhouse,omitempty"`
+}
+
+// Request log storage in ClickHouse, reached over its HTTP interface
+type ClickHouseConfig struct {
+	URL      string `json:"url"`      // e.g. "http://localhost:8123"
+	Database string `json:"database"` // Default: "default"
+	Username string `json:"username,omitempty"`
+	Password string `json:"-"` // From CLICKHOUSE_PASSWORD
+}
+
+// Extra destinations for request logs, alongside the analytics table in PostgreSQL
+type AccessLogConfig struct {
+	Sinks    []AccessLogSink `json:"sinks,omitempty"`
+	Sampling *LogSampling    `json:"sampling,omitempty"`
+}
+
+// Logs a share of requests; each entry records its rate so analytics can
+// extrapolate. Rates are between 0 and 1.
+type LogSampling struct {
+	Rate            float64 `json:"rate"`              // Requests not matched below, e.g. 0.1 for fast 2xx
+	ClientErrorRate float64 `json:"client_error_rate"` // Default: 1
+	ServerErrorRate float64 `json:"server_error_rate"` // Default: 1
+	SlowMs          int     `json:"slow_ms"`           // Requests at least this slow are always logged; 0 disables
+}
+
+// Monthly usage per API key for invoicing; the report endpoint is always available
+type UsageReportsConfig struct {
+	Enabled         bool `json:"enabled"`          // Runs periodic rollups
+	IntervalMinutes int  `json:"interval_minutes"` // Default: 60
+}
+
+// The developer portal under /portal, where API consumers register and manage
+// their own keys. Developers sign in separately from admin users.
+type PortalConfig struct {
+	Enabled bool     `json:"enabled"`
+	Tiers   []string `json:"tiers"`    // Tiers developers may pick; the first is the default
+	MaxKeys int      `json:"max_keys"` // Per developer. Default: 5
+	LogDays int      `json:"log_days"` // How far back developers can see their request logs. Default: 7
+}
+
+// Client-credentials grant at POST /oauth/token, exchanging an API key for a
+// short-lived JWT. Tokens are signed with jwt.keys so backends can verify them
+// against /.well-known/jwks.json, and the gateway accepts them in place of the key.
+type OAuthConfig struct {
+	Enabled      bool   `json:"enabled"`
+	Issuer       string `json:"issuer"`        // Default: "api-gateway"
+	Audience     string `json:"audience"`      // Default: "api"
+	TokenMinutes int    `json:"token_minutes"` // Default: 15; a deactivated key's tokens keep working this long
+}
+
+// Secrets read from HashiCorp Vault instead of the config file or environment.
+// Each secret is a path#field reference; KV version 2 paths include data/,
+// e.g. secret/data/gateway#jwt_secret.
+type VaultConfig struct {
+	Address          string    `json:"address"` // Overridden by VAULT_ADDR
+	Namespace        string    `json:"namespace,omitempty"`
+	Auth             VaultAuth `json:"auth"`
+	RefreshMinutes   int       `json:"refresh_minutes"` // Default: 15
+	JWTSecret        string    `json:"jwt_secret,omitempty"`
+	DatabasePassword string    `json:"database_password,omitempty"`
+	RedisPassword    string    `json:"redis_password,omitempty"`
+}
+
+type VaultAuth struct {
+	Method    string `json:"method"`               // token, kubernetes or approle
+	Mount     string `json:"mount,omitempty"`      // Default: the method name
+	Token     string `json:"-"`                    // From VAULT_TOKEN
+	Role      string `json:"role,omitempty"`       // kubernetes
+	TokenFile string `json:"token_file,omitempty"` // kubernetes; default: the pod's service account token
+	RoleID    string `json:"role_id,omitempty"`    // approle
+	SecretID  string `json:"-"`                    // From VAULT_SECRET_ID
+}
+
+// Kubernetes API access for target discovery. Defaults to the pod's service account.
+type KubernetesConfig struct {
+	APIServer          string `json:"api_server,omitempty"`
+	TokenFile          string `json:"token_file,omitempty"`
+	CAFile             string `json:"ca_file,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+// Services and tiers shared by gateway instances through Consul KV or etcd.
+// When the catalog holds any services or tiers they replace the file's, and
+// changes are applied as they are written.
+type CatalogConfig struct {
+	Backend  string `json:"backend"`            // consul or etcd
+	Address  string `json:"address"`            // e.g. http://localhost:8500
+	Prefix   string `json:"prefix"`             // Default: gateway
+	Token    string `json:"token,omitempty"`    // Consul ACL token
+	Username string `json:"username,omitempty"` // etcd
+	Password string `json:"password,omitempty"` // etcd
+}
+
+// GET route answered by calling several service endpoints in parallel and
+// merging their JSON responses into one object, keyed by part
+type CompositeConfig struct {
+	Path      string          `json:"path"`           // Gateway path; ":name" segments are passed on to parts
+	Auth      string          `json:"auth,omitempty"` // "optional" (default) or "api_key"
+	TimeoutMs int             `json:"timeout_ms"`     // For all parts together. Default: 5000
+	Parts     []CompositePart `json:"parts"`
+}
+
+// One backend call of a composite route
+type CompositePart struct {
+	Key          string `json:"key"`                     // Field of the merged response
+	Path         string `json:"path"`                    // Gateway path of a service endpoint, e.g. /api/users/:id
+	ForwardQuery bool   `json:"forward_query,omitempty"` // Pass the composite request's query string on
+	Optional     bool   `json:"optional,omitempty"`      // A failure becomes null instead of failing the route
+}
+
+// Workers forwarding queued async requests, which clients poll at
+// GET /async/jobs/:id. They run only when a service enables async.
+type AsyncQueueConfig struct {
+	Workers          int      `json:"workers"`                  // Per replica. Default: 4
+	ResultTTLSeconds int      `json:"result_ttl_seconds"`       // Default: 86400
+	TimeoutSeconds   int      `json:"timeout_seconds"`          // Per backend request. Default: 300
+	CallbackHosts    []string `json:"callback_hosts,omitempty"` // Hosts callback URLs may name; empty disables callbacks
+	CallbackSecret   string   `json:"-"`                        // From ASYNC_CALLBACK_SECRET; signs callbacks like webhooks
+}
+
+// Queues a service's matching requests and answers 202 with a status URL
+// instead of waiting for the backend
+type ServiceAsyncConfig struct {
+	Enabled        bool     `json:"enabled"`
+	Paths          []string `json:"paths,omitempty"`           // Path prefixes (empty: all)
+	Methods        []string `json:"methods,omitempty"`         // Default: POST, PUT, PATCH and DELETE
+	MaxBodyBytes   int64    `json:"max_body_bytes,omitempty"`  // Default: 1 MiB
+	CallbackHeader string   `json:"callback_header,omitempty"` // Default: X-Callback-URL
+}
+
+// Lists the services the gateway fronts at GET /admin/catalog
+type APICatalogConfig struct {
+	Public bool `json:"public"` // Also serve it without auth at GET /catalog, minus internal services
+}
+
+// Anomaly detection on live traffic per service
+type AlertsConfig struct {
+	Enabled            bool        `json:"enabled"`
+	IntervalSeconds    int         `json:"interval_seconds"`     // Default: 30
+	WindowSeconds      int         `json:"window_seconds"`       // Default: 30, at most 59
+	MinRequests        int         `json:"min_requests"`         // Default: 20 per window before error rate and latency count
+	WarmupIntervals    int         `json:"warmup_intervals"`     // Default: 10 before deviation alerts
+	BaselineAlpha      float64     `json:"baseline_alpha"`       // Default: 0.05
+	DeviationMultiple  float64     `json:"deviation_multiple"`   // Default: 3
+	ErrorRateThreshold float64     `json:"error_rate_threshold"` // Percent, default: 10
+	MinErrorRate       float64     `json:"min_error_rate"`       // Percent, default: 1
+	P95ThresholdMs     float64     `json:"p95_threshold_ms"`     // Default: 0 (deviation only)
+	Webhooks           bool        `json:"webhooks"`             // Dispatch alert.fired and alert.resolved events
+	SlackWebhookURL    string      `json:"-"`                    // From ALERTS_SLACK_WEBHOOK_URL
+	Email              *AlertEmail `json:"email,omitempty"`
+}
+
+type AlertEmail struct {
+	SMTPHost string   `json:"smtp_host"`
+	SMTPPort int      `json:"smtp_port"` // Default: 587
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"-"` // From ALERTS_SMTP_PASSWORD
+}
+
+type AccessLogSink struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // "file", "kafka" or "elasticsearch"
+
+	// file
+	Path       string `json:"path,omitempty"`
+	MaxSizeMB  int    `json:"max_size_mb,omitempty"` // Default: 100
+	MaxBackups int    `json:"max_backups,omitempty"` // Default: 5
+
+	// kafka, produced through a Kafka REST Proxy
+	RestURL string `json:"rest_url,omitempty"`
+	Topic   string `json:"topic,omitempty"`
+
+	// elasticsearch, also works with OpenSearch
+	URL      string `json:"url,omitempty"`
+	Index    string `json:"index,omitempty"` // "{date}" is replaced with the entry date
+	Username string `json:"username,omitempty"`
+	Password string `json:"-"` // From ACCESS_LOG_ES_PASSWORD
+	APIKey   string `json:"-"` // From ACCESS_LOG_ES_API_KEY
+
+	BatchSize        int    `json:"batch_size,omitempty"`         // Default: 500
+	FlushIntervalSec int    `json:"flush_interval_sec,omitempty"` // Default: 5
+	BufferSize       int    `json:"buffer_size,omitempty"`        // Default: resources.log_buffer_size
+	Overflow         string `json:"overflow,omitempty"`           // "drop" (default) or "block"
+	BlockTimeoutMs   int    `json:"block_timeout_ms,omitempty"`   // Default: 50
 }
 
 type ServiceConfig struct {
-	Path           string                `json:"path"`
-	Targets        []string              `json:"targets"`
-	LoadBalancer   string                `json:"load_balancer"` // "round-robin", "random", "least_connections"
+	Path           string                   `json:"path"`
+	Targets        []string                 `json:"targets"`
+	LoadBalancer   string                   `json:"load_balancer"` // "round-robin", "random", "least_connections"
+	CircuitBreaker *CircuitBreakerConfig    `json:"circuit_breaker,omitempty"`
+	HealthCheck    *HealthCheckConfig       `json:"health_check,omitempty"`
+	Outliers       *OutlierDetectionConfig  `json:"outlier_detection,omitempty"`
+	LongLived      *LongLivedConfig         `json:"long_lived,omitempty"`
+	DeadLetter     *DeadLetterConfig        `json:"dead_letter,omitempty"`
+	TokenExchange  *ServiceTokenExchange    `json:"token_exchange,omitempty"`
+	Cache          *CacheConfig             `json:"cache,omitempty"`
+	BodyScan       *BodyScanConfig          `json:"body_scan,omitempty"`
+	UpstreamLimit  *UpstreamLimitConfig     `json:"upstream_limit,omitempty"`
+	Policies       []string                 `json:"policies,omitempty"` // Policy bundles applied in order; fields set on the service win
+	Auth           string                   `json:"auth,omitempty"`     // "optional" (default) or "api_key"
+	RateLimit      *ServiceRateLimit        `json:"rate_limit,omitempty"`
+	TimeoutSeconds int                      `json:"timeout_seconds,omitempty"`
+	Transforms     *TransformConfig         `json:"transforms,omitempty"`
+	ForwardAuth    *ForwardAuthConfig       `json:"forward_auth,omitempty"`
+	Introspection  *IntrospectionConfig     `json:"introspection,omitempty"`
+	FastPath       bool                     `json:"fast_path,omitempty"` // Serve outside the middleware chain, global IP lists aside; see HasRequestPolicies
+	BodyCapture    *BodyCaptureConfig       `json:"body_capture,omitempty"`
+	Kubernetes     *KubernetesTargets       `json:"kubernetes,omitempty"` // Discovers targets instead of listing them
+	SRV            *SRVConfig               `json:"srv,omitempty"`        // Resolves srv:// and srv+https:// targets
+	Transport      *TransportConfig         `json:"transport,omitempty"`
+	Canary         *CanaryConfig            `json:"canary,omitempty"`
+	BlueGreen      *BlueGreenConfig         `json:"blue_green,omitempty"`  // Replaces targets
+	Experiments    []ExperimentConfig       `json:"experiments,omitempty"` // The first matching experiment routes the request
+	Mocks          []MockConfig             `json:"mocks,omitempty"`       // Answered by the gateway; targets are optional when set
+	Idempotency    *IdempotencyConfig       `json:"idempotency,omitempty"`
+	GraphQL        *GraphQLConfig           `json:"graphql,omitempty"`
+	IPFilter       *IPFilterConfig          `json:"ip_filter,omitempty"`
+	Geo            *GeoRulesConfig          `json:"geo,omitempty"` // Requires geoip.database
+	Bots           *BotDetectionConfig      `json:"bots,omitempty"`
+	Compression    *CompressionConfig       `json:"compression,omitempty"`
+	CORS           *CORSPolicyConfig        `json:"cors,omitempty"`         // Unset fields inherit from the global CORS policy
+	Routes         []RouteConfig            `json:"routes,omitempty"`       // When set, only matching paths are proxied
+	StripPrefix    bool                     `json:"strip_prefix,omitempty"` // Forward /users/1 of service /users as /1
+	Rewrite        *PrefixRewrite           `json:"rewrite,omitempty"`      // Or replace a leading part of the path
+	Versions       *VersioningConfig        `json:"versions,omitempty"`
+	ClientCert     *ServiceClientCertConfig `json:"client_cert,omitempty"` // Requires server.tls.client_auth
+	Async          *ServiceAsyncConfig      `json:"async,omitempty"`
+
+	// Shown in the API catalog
+	Description string `json:"description,omitempty"`
+	OpenAPI     string `json:"openapi,omitempty"`  // URL or file path of the service's OpenAPI spec
+	Internal    bool   `json:"internal,omitempty"` // Left out of the public catalog
+}
+
+// Sends a share of a service's traffic to a second group of targets. The
+// share can be changed at runtime through /admin/canaries.
+type CanaryConfig struct {
+	Targets      []string            `json:"targets"`
+	Percent      float64             `json:"percent"` // 0-100
+	AutoRollback *CanaryAutoRollback `json:"auto_rollback,omitempty"`
+}
+
+// Stops canary traffic when the canary fails noticeably more than the stable targets
+type CanaryAutoRollback struct {
+	Enabled            bool    `json:"enabled"`
+	ErrorRateThreshold float64 `json:"error_rate_threshold"` // Percentage points of 5xx responses above the stable rate. Default: 5
+	WindowSeconds      int     `json:"window_seconds"`       // Default: 60
+	MinRequests        int     `json:"min_requests"`         // Canary requests in the window before judging. Default: 20
+}
+
+// Two target sets of which only the active one gets traffic. The other stays
+// health-checked, and POST /admin/services/<path>/switch flips between them.
+type BlueGreenConfig struct {
+	Blue   []string `json:"blue"`
+	Green  []string `json:"green"`
+	Active string   `json:"active"` // Set live at startup: "blue" (default) or "green"
+}
+
+// A/B test that sends matching requests to its own targets. Requests match on
+// a header or cookie, or by percentage of consumers, who keep their variant.
+// The variant is recorded in the request log as "<name>/<variant>", and
+// requests of a service with experiments that match none as "control".
+type ExperimentConfig struct {
+	Name       string   `json:"name"`
+	Variant    string   `json:"variant"` // Default: "b"
+	Targets    []string `json:"targets"`
+	Header     string   `json:"header,omitempty"`
+	Cookie     string   `json:"cookie,omitempty"`
+	Value      string   `json:"value,omitempty"`      // Header or cookie value to match; empty matches any value
+	Percentage float64  `json:"percentage,omitempty"` // Share of consumers by API key, or client IP, 0-100
+}
+
+// API versions of a service, each optionally served by its own targets. The
+// version is read from the path segment after the service path (/users/v2/...)
+// or, when header is set, from that header. Requests naming no version get
+// the default, and responses of deprecated versions carry Deprecation and
+// Sunset headers.
+type VersioningConfig struct {
+	Header   string             `json:"header,omitempty"`  // e.g. "API-Version"
+	Default  string             `json:"default,omitempty"` // Default: none, served by the service targets
+	Versions []APIVersionConfig `json:"versions"`
+}
+
+type APIVersionConfig struct {
+	Name       string   `json:"name"`              // e.g. "v1"
+	Targets    []string `json:"targets,omitempty"` // Default: the service targets
+	Deprecated bool     `json:"deprecated,omitempty"`
+	Sunset     string   `json:"sunset,omitempty"` // RFC 3339 time the version goes away
+	Link       string   `json:"link,omitempty"`   // Migration guide, sent as a deprecation Link
+}
+
+// Path pattern of a service, set instead of proxying everything under its
+// path. The template (or name) is recorded in logs and metrics in place of
+// the raw path. Routes are tried by priority, then in the order listed.
+type RouteConfig struct {
+	Template string   `json:"template,omitempty"` // e.g. /users/{id}/orders, {id:[0-9]+} or {rest...}
+	Regex    string   `json:"regex,omitempty"`    // Matched against the whole path; named groups become parameters
+	Name     string   `json:"name,omitempty"`     // Default: the template or regex
+	Priority int      `json:"priority,omitempty"` // Higher first
+	Methods  []string `json:"methods,omitempty"`  // Empty matches every method
+}
+
+// Canned response for a route of a service whose backend doesn't exist yet.
+// Headers and body are text/templates with the same data as admin stubs,
+// e.g. {{.Query.id}} or {{.JSON.name}}.
+type MockConfig struct {
+	Method    string            `json:"method,omitempty"` // Empty matches any method
+	Path      string            `json:"path"`             // Full request path, or a prefix when it ends in "*"
+	Status    int               `json:"status"`           // Default: 200
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      string            `json:"body"`
+	LatencyMs int               `json:"latency_ms,omitempty"` // Delay before answering
+}
+
+// Returns the service's listed targets, blue-green sets, canary and experiment targets
+func (s *ServiceConfig) AllTargets() []string {
+	targets := slices.Clone(s.Targets)
+	if bg := s.BlueGreen; bg != nil {
+		targets = append(targets, bg.Blue...)
+		targets = append(targets, bg.Green...)
+	}
+	if cn := s.Canary; cn != nil {
+		targets = append(targets, cn.Targets...)
+	}
+	for _, exp := range s.Experiments {
+		targets = append(targets, exp.Targets...)
+	}
+	if v := s.Versions; v != nil {
+		for _, version := range v.Versions {
+			targets = append(targets, version.Targets...)
+		}
+	}
+	return targets
+}
+
+// Protocol and connection pooling toward a service's backends
+type TransportConfig struct {
+	Protocol               string `json:"protocol"`                  // "auto" (default: HTTP/2 when TLS negotiates it), "http1", "http2" or "h2c" (HTTP/2 without TLS)
+	MaxIdleConnsPerHost    int    `json:"max_idle_conns_per_host"`   // Default: 32
+	MaxConnsPerHost        int    `json:"max_conns_per_host"`        // Default: 0 (unlimited)
+	IdleConnTimeoutSeconds int    `json:"idle_conn_timeout_seconds"` // Default: 90
+	PingIntervalSeconds    int    `json:"ping_interval_seconds"`     // HTTP/2 pings on idle connections to find dead ones. Default: 0 (off)
+
+	DialTimeoutSeconds           int  `json:"dial_timeout_seconds"`            // Default: 30
+	TLSHandshakeTimeoutSeconds   int  `json:"tls_handshake_timeout_seconds"`   // Default: 10
+	ResponseHeaderTimeoutSeconds int  `json:"response_header_timeout_seconds"` // Default: 0 (none besides the service timeout)
+	DisableKeepAlives            bool `json:"disable_keep_alives"`             // One connection per request
+
+	TLS *UpstreamTLSConfig `json:"tls,omitempty"` // For https targets
+}
+
+// How the gateway verifies and authenticates to a service's https backends
+type UpstreamTLSConfig struct {
+	CAFile             string `json:"ca_file,omitempty"`              // PEM bundle trusted instead of the system roots
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"` // For development only
+	ServerName         string `json:"server_name,omitempty"`          // SNI and verified name. Default: the target host
+	CertFile           string `json:"cert_file,omitempty"`            // Client certificate for mutual TLS, with key_file
+	KeyFile            string `json:"key_file,omitempty"`
+}
+
+// DNS SRV resolution for srv://name targets, which become http://host:port
+// targets (https for srv+https://name)
+type SRVConfig struct {
+	RefreshSeconds int    `json:"refresh_seconds"`    // Default: 30
+	Resolver       string `json:"resolver,omitempty"` // DNS server host:port, e.g. Consul's 127.0.0.1:8600
+}
+
+// Targets taken from the ready endpoints of Kubernetes EndpointSlices
+type KubernetesTargets struct {
+	Namespace     string `json:"namespace"`                // Default: default
+	Service       string `json:"service,omitempty"`        // Watches the service's EndpointSlices
+	LabelSelector string `json:"label_selector,omitempty"` // Or any EndpointSlices matching this selector
+	Port          string `json:"port,omitempty"`           // Port name or number; default: each slice's first
+	Scheme        string `json:"scheme,omitempty"`         // Default: http
+}
+
+// Reports whether the service needs per-request middleware, which fast-path services cannot have
+func (s *ServiceConfig) HasRequestPolicies() bool {
+	return s.Auth == "api_key" || s.RateLimit != nil || s.TimeoutSeconds > 0 || s.Transforms != nil || len(s.Experiments) > 0 || len(s.Mocks) > 0 ||
+		(s.ForwardAuth != nil && s.ForwardAuth.Enabled) ||
+		(s.Introspection != nil && s.Introspection.Enabled) ||
+		(s.TokenExchange != nil && s.TokenExchange.Enabled) ||
+		(s.Cache != nil && s.Cache.Enabled) ||
+		(s.BodyScan != nil && s.BodyScan.Enabled) ||
+		(s.DeadLetter != nil && s.DeadLetter.Enabled) ||
+		(s.UpstreamLimit != nil && s.UpstreamLimit.Enabled) ||
+		(s.Idempotency != nil && s.Idempotency.Enabled) ||
+		(s.GraphQL != nil && s.GraphQL.Enabled) ||
+		s.IPFilter != nil || s.Geo != nil ||
+		(s.Bots != nil && s.Bots.Enabled) ||
+		(s.Compression != nil && s.Compression.Enabled) ||
+		len(s.Routes) > 0 || s.StripPrefix || s.Rewrite != nil || s.Versions != nil ||
+		(s.ClientCert != nil && s.ClientCert.Required) ||
+		(s.Async != nil && s.Async.Enabled)
+}
+
+// Named set of service policies shared by every service that references it
+type PolicyBundle struct {
+	Auth           string                `json:"auth,omitempty"`
+	RateLimit      *ServiceRateLimit     `json:"rate_limit,omitempty"`
+	TimeoutSeconds int                   `json:"timeout_seconds,omitempty"`
+	Transforms     *TransformConfig      `json:"transforms,omitempty"`
 	CircuitBreaker *CircuitBreakerConfig `json:"circuit_breaker,omitempty"`
-	HealthCheck    *HealthCheckConfig    `json:"health_check,omitempty"`
+	UpstreamLimit  *UpstreamLimitConfig  `json:"upstream_limit,omitempty"`
+	ForwardAuth    *ForwardAuthConfig    `json:"forward_auth,omitempty"`
+	Introspection  *IntrospectionConfig  `json:"introspection,omitempty"`
+}
+
+// End-user JWT validation on a service's routes
+type ForwardAuthConfig struct {
+	Enabled       bool              `json:"enabled"`
+	Issuer        string            `json:"issuer"`
+	Audience      string            `json:"audience"`
+	JWKSURL       string            `json:"jwks_url"`
+	Algorithms    []string          `json:"algorithms,omitempty"` // Default: RS256, RS384, RS512, PS256, ES256, ES384, EdDSA
+	Header        string            `json:"header,omitempty"`     // Default: "Authorization" with the Bearer scheme
+	Optional      bool              `json:"optional"`             // Let requests without a token through
+	ClaimHeaders  map[string]string `json:"claim_headers"`        // Claim to backend header, e.g. "sub": "X-User-ID"
+	LeewaySeconds int               `json:"leeway_seconds"`
+}
+
+// Opaque consumer token validation at an OAuth2 introspection endpoint (RFC 7662)
+type IntrospectionConfig struct {
+	Enabled       bool              `json:"enabled"`
+	URL           string            `json:"url"`
+	ClientID      string            `json:"client_id"`
+	ClientSecret  string            `json:"client_secret"`
+	Header        string            `json:"header,omitempty"`         // Default: "Authorization" with the Bearer scheme
+	Optional      bool              `json:"optional"`                 // Let requests without a token through
+	ConsumerClaim string            `json:"consumer_claim,omitempty"` // Field identifying the consumer. Default: "sub", then "client_id"
+	ClaimHeaders  map[string]string `json:"claim_headers"`            // Response field to backend header, e.g. "scope": "X-Scopes"
+	CacheSeconds  int               `json:"cache_seconds"`            // Default: 60; never past the token's exp
+}
+
+// Per-consumer limit for one service, applied on top of the tier limit
+type ServiceRateLimit struct {
+	RequestsPerMinute int    `json:"requests_per_minute"`
+	Algorithm         string `json:"algorithm"` // Default: "sliding_window"
+}
+
+// Rewrites applied to proxied requests and responses
+type TransformConfig struct {
+	Request  HeaderTransform `json:"request"`
+	Response HeaderTransform `json:"response"`
+
+	Paths []PathRewrite   `json:"paths,omitempty"` // The first rule matching the request path applies
+	Query *QueryTransform `json:"query,omitempty"`
+}
+
+// Header changes, applied as renames, removals, sets and then additions
+type HeaderTransform struct {
+	SetHeaders    map[string]string `json:"set_headers,omitempty"`
+	RemoveHeaders []string          `json:"remove_headers,omitempty"`
+
+	AddHeaders    map[string]string `json:"add_headers,omitempty"`    // Appended to any values already present
+	RenameHeaders map[string]string `json:"rename_headers,omitempty"` // Old name to new name
+}
+
+// Rewrites the path sent to the backend, e.g. "^/api/users/(\\d+)$" to "/v2/users/$1"
+type PathRewrite struct {
+	Match   string `json:"match"`   // Regular expression on the full request path
+	Replace string `json:"replace"` // May refer to capture groups as $1 or ${name}
+}
+
+// Replaces a leading part of the path sent to the backend, e.g. "/users" to
+// "/v1". Path rewrites in transforms take precedence when one matches.
+type PrefixRewrite struct {
+	From string `json:"from"` // Default: the service path
+	To   string `json:"to"`
+}
+
+// Query parameter changes on requests, applied as removals and then additions
+type QueryTransform struct {
+	Add    map[string]string `json:"add,omitempty"` // Replaces existing values
+	Remove []string          `json:"remove,omitempty"`
 }
 
 type CircuitBreakerConfig struct {
@@ -63,6 +754,19 @@ type CircuitBreakerConfig struct {
 	HalfOpenSuccess int `json:"half_open_success"` // Default: 1
 }
 
+// Ejects targets that fail or slow down under live traffic, for a time that
+// doubles with each ejection in a row, whatever their health checks say
+type OutlierDetectionConfig struct {
+	Enabled             bool    `json:"enabled"`
+	ConsecutiveErrors   int     `json:"consecutive_errors"`    // 5xx responses in a row. Default: 5
+	LatencyFactor       float64 `json:"latency_factor"`        // Times the pool's median average latency, e.g. 3. Default: 0 (off)
+	MinRequests         int     `json:"min_requests"`          // Per target and interval for latency comparison. Default: 20
+	IntervalSeconds     int     `json:"interval_seconds"`      // Default: 10
+	BaseEjectionSeconds int     `json:"base_ejection_seconds"` // Default: 30
+	MaxEjectionSeconds  int     `json:"max_ejection_seconds"`  // Default: 300
+	MaxEjectionPercent  float64 `json:"max_ejection_percent"`  // Default: 50
+}
+
 type HealthCheckConfig struct {
 	Endpoint        string `json:"endpoint"`         // Default: "/health"
 	IntervalSeconds int    `json:"interval_seconds"` // Default: 10
@@ -70,34 +774,340 @@ type HealthCheckConfig struct {
 	MaxFailures     int    `json:"max_failures"`     // Default: 3
 }
 
+type LongLivedConfig struct {
+	MaxPerKey     int      `json:"max_per_key"`     // Default: 0 (unlimited)
+	LongPollPaths []string `json:"long_poll_paths"` // Path prefixes treated as long-polls
+}
+
+// Client addresses let through or kept out, as CIDRs or single addresses.
+// Deny entries win; with any allow entries, other addresses are rejected.
+// Rules added through /admin/ip-filter extend these lists.
+type IPFilterConfig struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// Compresses responses the backend sent uncompressed, for clients that accept it
+type CompressionConfig struct {
+	Enabled      bool     `json:"enabled"`
+	Algorithms   []string `json:"algorithms,omitempty"`    // "br" and "gzip", preferred first. Default: both, br first
+	MinBytes     int      `json:"min_bytes"`               // Smaller responses are sent as is. Default: 1024
+	ContentTypes []string `json:"content_types,omitempty"` // Media types, with * as in text/* or application/*+json. Default: text, JSON, JavaScript, XML and SVG
+}
+
+// Flags suspected bots from their headers and behavior, then tags, throttles
+// or blocks them. The verdict is recorded in each request log.
+type BotDetectionConfig struct {
+	Enabled              bool     `json:"enabled"`
+	Action               string   `json:"action"`                        // "tag" (default) forwards X-Gateway-Bot to the backend, "throttle" or "block"
+	UserAgentPatterns    []string `json:"user_agent_patterns,omitempty"` // Regexps. Default: common crawler, scraper and headless browser names
+	AllowUserAgents      []string `json:"allow_user_agents,omitempty"`   // Regexps exempt from the user agent and header checks, e.g. Googlebot
+	RequiredHeaders      []string `json:"required_headers,omitempty"`    // Headers every browser sends, e.g. Accept-Language
+	HoneypotPaths        []string `json:"honeypot_paths,omitempty"`      // Links only crawlers follow; clients requesting one are flagged
+	MaxRequestsPerSecond int      `json:"max_requests_per_second"`       // Faster clients are flagged. Default: 0 (off)
+	FlagSeconds          int      `json:"flag_seconds"`                  // How long flagged clients stay bots. Default: 3600
+	ThrottleRPM          int      `json:"throttle_rpm"`                  // Requests per minute left to bots under throttle. Default: 10
+}
+
+// Which clients may set their own request id and W3C traceparent. Without
+// trusted_networks every client is trusted. Untrusted clients' ids are
+// replaced, or rejected with 400 under reject_untrusted.
+type RequestIDConfig struct {
+	Header          string   `json:"header"`           // Default: X-Request-ID
+	TrustedNetworks []string `json:"trusted_networks"` // CIDRs or addresses
+	RejectUntrusted bool     `json:"reject_untrusted"`
+	MaxLength       int      `json:"max_length"` // Longer ids are replaced. Default: 128
+}
+
+// Country lookups for access rules and analytics
+type GeoIPConfig struct {
+	Database string `json:"database"` // MaxMind DB file, e.g. GeoLite2-Country.mmdb
+}
+
+// Countries let through or kept out, as ISO 3166-1 alpha-2 codes. Deny
+// entries win; with any allow entries, other countries are rejected.
+type GeoRulesConfig struct {
+	AllowCountries []string `json:"allow_countries,omitempty"`
+	DenyCountries  []string `json:"deny_countries,omitempty"`
+	AllowUnknown   bool     `json:"allow_unknown"` // Let addresses without a country, such as private ranges, past allow_countries
+}
+
+// Parses a GraphQL service's requests to enforce limits before they reach the
+// backend, and logs each request under its operation name
+type GraphQLConfig struct {
+	Enabled       bool  `json:"enabled"`
+	MaxDepth      int   `json:"max_depth"`               // Default: 15
+	MaxComplexity int   `json:"max_complexity"`          // Fields selected, counting fragments where spread. Default: 1000
+	Introspection *bool `json:"introspection,omitempty"` // Default: allowed outside production
+}
+
+// Replays the stored response to POST and PATCH retries carrying the same
+// Idempotency-Key, so backends don't see duplicate writes
+type IdempotencyConfig struct {
+	Enabled    bool `json:"enabled"`
+	TTLSeconds int  `json:"ttl_seconds"` // How long responses are kept for replay. Default: 86400
+	Required   bool `json:"required"`    // Reject POST and PATCH requests without a key
+}
+
+// Honors RateLimit/Retry-After headers sent by the backend
+type UpstreamLimitConfig struct {
+	Enabled           bool   `json:"enabled"`
+	Mode              string `json:"mode"`                // "reject" (default) or "queue"
+	MaxWaitMs         int    `json:"max_wait_ms"`         // Longest a queued request waits. Default: 5000
+	MaxBackoffSeconds int    `json:"max_backoff_seconds"` // Caps backend-announced backoff. Default: 300
+}
+
+type DeadLetterConfig struct {
+	Enabled      bool     `json:"enabled"`
+	MaxBodyBytes int64    `json:"max_body_bytes"` // Default: 65536
+	Headers      []string `json:"headers"`        // Default: Content-Type, User-Agent
+}
+
+// Gateway wide token exchange settings
+type TokenExchange struct {
+	Issuer          string `json:"issuer"`      // Default: "api-gateway"
+	Secret          string `json:"secret"`      // Signs backend tokens; required, and must differ from the JWT secret
+	TTLSeconds      int    `json:"ttl_seconds"` // Default: 300
+	STSURL          string `json:"sts_url"`     // External RFC 8693 STS endpoint
+	STSClientID     string `json:"sts_client_id"`
+	STSClientSecret string `json:"sts_client_secret"`
+}
+
+// Per-service token exchange settings
+type ServiceTokenExchange struct {
+	Enabled  bool   `json:"enabled"`
+	Audience string `json:"audience"` // Default: service path
+	Header   string `json:"header"`   // Default: "Authorization"
+	Mode     string `json:"mode"`     // "local" (default) or "sts"
+}
+
+type CORSConfig struct {
+	CORSPolicyConfig
+	Routes []CORSRouteConfig `json:"routes"` // Route-level overrides, longest prefix wins
+}
+
+type CORSPolicyConfig struct {
+	AllowedOrigins   []string `json:"allowed_origins"` // "*", or origins with at most one * such as https://*.example.com
+	AllowedMethods   []string `json:"allowed_methods"`
+	AllowedHeaders   []string `json:"allowed_headers"`
+	ExposeHeaders    []string `json:"expose_headers"`
+	AllowCredentials *bool    `json:"allow_credentials"`
+	MaxAgeSeconds    int      `json:"max_age_seconds"` // Preflight cache duration
+}
+
+// Unset fields inherit from the global CORS policy
+type CORSRouteConfig struct {
+	PathPrefix string `json:"path_prefix"`
+	CORSPolicyConfig
+}
+
+// User-facing gateway messages as Go text/templates, keyed by locale then message key
+type MessagesConfig struct {
+	DefaultLocale string                       `json:"default_locale"` // Default: "en"
+	Templates     map[string]map[string]string `json:"templates"`
+}
+
+type WebhookConfig struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"` // Signs payloads (X-Gateway-Signature)
+	Events []string `json:"events"` // Event types or categories like "circuit_breaker.*"; empty subscribes to all but user.password_reset_requested, which must be listed
+}
+
+type CacheConfig struct {
+	Enabled    bool `json:"enabled"`
+	TTLSeconds int  `json:"ttl_seconds"` // Default: 60
+
+	VaryHeaders       []string        `json:"vary_headers,omitempty"`        // Request headers whose values get separate entries, e.g. Accept-Language
+	QueryParams       []string        `json:"query_params,omitempty"`        // Only these query params select entries; default: all of them
+	HonorCacheControl bool            `json:"honor_cache_control,omitempty"` // Take the TTL from max-age and let clients bypass with no-cache
+	Paths             []CachePathRule `json:"paths,omitempty"`               // Overrides for path prefixes; the longest match wins
+	Coalesce          bool            `json:"coalesce,omitempty"`            // Concurrent misses for the same entry wait for one backend call
+}
+
+type CachePathRule struct {
+	Prefix     string `json:"prefix"`
+	TTLSeconds int    `json:"ttl_seconds,omitempty"` // Default: the service's
+	Disabled   bool   `json:"disabled,omitempty"`    // Never cache under the prefix
+}
+
+// Returns the configured cache TTL
+func (c *CacheConfig) TTL() time.Duration {
+	if c.TTLSeconds <= 0 {
+		return time.Minute
+	}
+	return time.Duration(c.TTLSeconds) * time.Second
+}
+
+type BodyScanConfig struct {
+	Enabled        bool     `json:"enabled"`
+	Scanner        string   `json:"scanner"`         // "clamav"
+	Address        string   `json:"address"`         // Default: "localhost:3310"
+	Paths          []string `json:"paths"`           // Path prefixes to scan (empty: all)
+	MaxBytes       int64    `json:"max_bytes"`       // Default: 10MB
+	TimeoutMs      int      `json:"timeout_ms"`      // Default: 2000
+	RejectOversize bool     `json:"reject_oversize"` // Default: false (pass unscanned)
+	FailOpen       bool     `json:"fail_open"`       // Default: false
+}
+
+// Limits for debug body capture, which is switched on at runtime through
+// PUT /admin/body-capture. Listed names are redacted on top of the defaults.
+type BodyCaptureConfig struct {
+	MaxBytes      int      `json:"max_bytes"`      // Per body, default: 4096
+	RedactHeaders []string `json:"redact_headers"` // Always: Authorization, Cookie, X-Api-Key
+	RedactFields  []string `json:"redact_fields"`  // Always: password, secret, token, card_number, ...
+}
+
 type RateLimiterTier struct {
-	Name              string `json:"name"`
-	RequestsPerMinute int    `json:"requests_per_minute"`
-	RequestsPerHour   int    `json:"requests_per_hour"`
-	Algorithm         string `json:"algorithm"`
+	Name              string            `json:"name"`
+	RequestsPerMinute int               `json:"requests_per_minute"`
+	RequestsPerHour   int               `json:"requests_per_hour"`
+	Algorithm         string            `json:"algorithm"`
+	Buckets           []RateLimitBucket `json:"buckets,omitempty"` // Services not listed share one bucket
+}
+
+// Groups services that get their own rate limit allowance per API key
+type RateLimitBucket struct {
+	Name              string   `json:"name"`
+	Services          []string `json:"services"`
+	RequestsPerMinute int      `json:"requests_per_minute"` // Default: tier limit
+}
+
+// Returns the bucket a request path is partitioned into, or nil for the shared bucket
+func (t *RateLimiterTier) BucketFor(path string) *RateLimitBucket {
+	for i := range t.Buckets {
+		for _, svc := range t.Buckets[i].Services {
+			if path == svc || strings.HasPrefix(path, svc+"/") {
+				return &t.Buckets[i]
+			}
+		}
+	}
+	return nil
+}
+
+type DarkLaunchRule struct {
+	Header     string   `json:"header"`
+	Value      string   `json:"value"`      // Default: "true"
+	Tiers      []string `json:"tiers"`      // Empty applies to all tiers
+	Services   []string `json:"services"`   // Empty applies to all services
+	Orgs       []string `json:"orgs"`       // Organization IDs. Empty applies to all organizations
+	Percentage *float64 `json:"percentage"` // 0 sends the header to no one. Default: 100
 }
 
+// Reads a JSON, YAML or TOML config file, detected by extension
 func Load(path string) (*Config, error) {
 	file, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	file, err = toJSON(path, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
 	var config Config
 	if err := json.Unmarshal(file, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	applyEnvOverrides(&config)
+	return finishLoad(&config)
+}
+
+// Builds the config from environment variables alone, for container
+// deployments without a config file. Services come from SERVICES as JSON.
+func LoadEnv() (*Config, error) {
+	config := Config{
+		Server:   ServerConfig{Port: "8080"},
+		Redis:    RedisConfig{Port: 6379},
+		Database: DatabaseConfig{SSLMode: "disable"},
+	}
+
+	return finishLoad(&config)
+}
+
+func finishLoad(config *Config) (*Config, error) {
+	if err := applyEnvOverrides(config); err != nil {
+		return nil, fmt.Errorf("invalid environment: %w", err)
+	}
+
+	if err := applyPolicyBundles(config); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
 
-	if err := validate(&config); err != nil {
+	if err := validate(config); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	return &config, nil
+	return config, nil
+}
+
+// Returns a copy of the config serving a catalog's services and tiers, checked
+// like a config file. The file's are kept for whichever the catalog has none of.
+func (c *Config) WithCatalog(services []ServiceConfig, tiers []RateLimiterTier) (*Config, error) {
+	next := *c
+	if len(services) > 0 {
+		next.Services = services
+	}
+	if len(tiers) > 0 {
+		next.RateLimitTiers = tiers
+	}
+	// Bundles are applied in place, so never onto slices the current config shares
+	next.Services = append([]ServiceConfig(nil), next.Services...)
+
+	if err := applyPolicyBundles(&next); err != nil {
+		return nil, err
+	}
+	if err := validate(&next); err != nil {
+		return nil, err
+	}
+
+	return &next, nil
+}
+
+// Fills unset service fields from the policy bundles each service references
+func applyPolicyBundles(cfg *Config) error {
+	for i := range cfg.Services {
+		svc := &cfg.Services[i]
+
+		// Later bundles take precedence over earlier ones, so walk them in reverse
+		for j := len(svc.Policies) - 1; j >= 0; j-- {
+			name := svc.Policies[j]
+			bundle, exists := cfg.PolicyBundles[name]
+			if !exists {
+				return fmt.Errorf("service %s: unknown policy bundle: %s", svc.Path, name)
+			}
+
+			if svc.Auth == "" {
+				svc.Auth = bundle.Auth
+			}
+			if svc.RateLimit == nil {
+				svc.RateLimit = bundle.RateLimit
+			}
+			if svc.TimeoutSeconds == 0 {
+				svc.TimeoutSeconds = bundle.TimeoutSeconds
+			}
+			if svc.Transforms == nil {
+				svc.Transforms = bundle.Transforms
+			}
+			if svc.CircuitBreaker == nil {
+				svc.CircuitBreaker = bundle.CircuitBreaker
+			}
+			if svc.UpstreamLimit == nil {
+				svc.UpstreamLimit = bundle.UpstreamLimit
+			}
+			if svc.ForwardAuth == nil {
+				svc.ForwardAuth = bundle.ForwardAuth
+			}
+			if svc.Introspection == nil {
+				svc.Introspection = bundle.Introspection
+			}
+		}
+	}
+
+	return nil
 }
 
-func applyEnvOverrides(cfg *Config) {
+func applyEnvOverrides(cfg *Config) error {
 	// Server overrides
 	if port := os.Getenv("PORT"); port != "" {
 		cfg.Server.Port = port
@@ -105,6 +1115,39 @@ func applyEnvOverrides(cfg *Config) {
 	if env := os.Getenv("ENVIRONMENT"); env != "" {
 		cfg.Server.Environment = env
 	}
+	if profile := os.Getenv("GATEWAY_PROFILE"); profile != "" {
+		cfg.Server.Profile = profile
+	}
+	if port := os.Getenv("ADMIN_PORT"); port != "" {
+		if cfg.Server.Admin == nil {
+			cfg.Server.Admin = &AdminListenerConfig{}
+		}
+		cfg.Server.Admin.Port = port
+	}
+	for i := range cfg.AccessLog.Sinks {
+		if sink := &cfg.AccessLog.Sinks[i]; sink.Type == "elasticsearch" {
+			sink.Password = os.Getenv("ACCESS_LOG_ES_PASSWORD")
+			sink.APIKey = os.Getenv("ACCESS_LOG_ES_API_KEY")
+		}
+	}
+	if url := os.Getenv("ALERTS_SLACK_WEBHOOK_URL"); url != "" {
+		cfg.Alerts.SlackWebhookURL = url
+	}
+	if cfg.Alerts.Email != nil {
+		cfg.Alerts.Email.Password = os.Getenv("ALERTS_SMTP_PASSWORD")
+	}
+	cfg.AsyncQueue.CallbackSecret = os.Getenv("ASYNC_CALLBACK_SECRET")
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		cfg.Logging.Level = level
+	}
+	if format := os.Getenv("LOG_FORMAT"); format != "" {
+		cfg.Logging.Format = format
+	}
+
+	// Storage overrides
+	if backend := os.Getenv("STORAGE_BACKEND"); backend != "" {
+		cfg.Storage.Backend = backend
+	}
 
 	// Redis overrides
 	if redisHost := os.Getenv("REDIS_HOST"); redisHost != "" {
@@ -113,8 +1156,20 @@ func applyEnvOverrides(cfg *Config) {
 	if redisPassword := os.Getenv("REDIS_PASSWORD"); redisPassword != "" {
 		cfg.Redis.Password = redisPassword
 	}
+	if err := envInt("REDIS_PORT", &cfg.Redis.Port); err != nil {
+		return err
+	}
+	if err := envInt("REDIS_DB", &cfg.Redis.DB); err != nil {
+		return err
+	}
 
 	// Database overrides
+	if driver := os.Getenv("DB_DRIVER"); driver != "" {
+		cfg.Database.Driver = driver
+	}
+	if path := os.Getenv("DB_PATH"); path != "" {
+		cfg.Database.Path = path
+	}
 	if host := os.Getenv("DB_HOST"); host != "" {
 		cfg.Database.Host = host
 	}
@@ -127,27 +1182,205 @@ func applyEnvOverrides(cfg *Config) {
 	if dbname := os.Getenv("DB_NAME"); dbname != "" {
 		cfg.Database.DBName = dbname
 	}
+	if sslmode := os.Getenv("DB_SSLMODE"); sslmode != "" {
+		cfg.Database.SSLMode = sslmode
+	}
+	if err := envInt("DB_PORT", &cfg.Database.Port); err != nil {
+		return err
+	}
+	if cfg.Analytics.ClickHouse != nil {
+		cfg.Analytics.ClickHouse.Password = os.Getenv("CLICKHOUSE_PASSWORD")
+	}
 
 	// JWT overrides
 	if secret := os.Getenv("JWT_SECRET"); secret != "" {
 		cfg.JWT.Secret = secret
 	}
-}
 
-func validate(cfg *Config) error {
-	if cfg.Server.Port == "" {
-		return fmt.Errorf("server port is required")
+	if secret := os.Getenv("KEY_SIGNING_SECRET"); secret != "" {
+		cfg.KeyValidation.SigningSecret = secret
 	}
 
-	if cfg.Redis.Host == "" {
+	// Auth overrides
+	if mode := os.Getenv("REGISTRATION_MODE"); mode != "" {
+		cfg.Auth.Registration = mode
+	}
+
+	if secret := os.Getenv("OIDC_CLIENT_SECRET"); secret != "" && cfg.OIDC != nil {
+		cfg.OIDC.ClientSecret = secret
+	}
+
+	// Vault address and credentials
+	if cfg.Vault != nil {
+		if addr := os.Getenv("VAULT_ADDR"); addr != "" {
+			cfg.Vault.Address = addr
+		}
+		cfg.Vault.Auth.Token = os.Getenv("VAULT_TOKEN")
+		cfg.Vault.Auth.SecretID = os.Getenv("VAULT_SECRET_ID")
+	}
+
+	// Catalog credentials
+	if cfg.Catalog != nil {
+		if token := os.Getenv("CATALOG_TOKEN"); token != "" {
+			cfg.Catalog.Token = token
+		}
+		if password := os.Getenv("CATALOG_PASSWORD"); password != "" {
+			cfg.Catalog.Password = password
+		}
+	}
+
+	// Token exchange overrides
+	if secret := os.Getenv("TOKEN_EXCHANGE_SECRET"); secret != "" {
+		cfg.TokenExchange.Secret = secret
+	}
+	if secret := os.Getenv("STS_CLIENT_SECRET"); secret != "" {
+		cfg.TokenExchange.STSClientSecret = secret
+	}
+
+	// Routing, as JSON in the same shape as the config file. These replace the
+	// file's lists rather than merging into them.
+	if services := os.Getenv("SERVICES"); services != "" {
+		cfg.Services = nil
+		if err := json.Unmarshal([]byte(services), &cfg.Services); err != nil {
+			return fmt.Errorf("SERVICES is not valid JSON: %w", err)
+		}
+	}
+	if tiers := os.Getenv("RATE_LIMIT_TIERS"); tiers != "" {
+		cfg.RateLimitTiers = nil
+		if err := json.Unmarshal([]byte(tiers), &cfg.RateLimitTiers); err != nil {
+			return fmt.Errorf("RATE_LIMIT_TIERS is not valid JSON: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func envInt(name string, target *int) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%s must be a number: %s", name, value)
+	}
+	*target = n
+	return nil
+}
+
+func validate(cfg *Config) error {
+	if cfg.Server.Port == "" {
+		return fmt.Errorf("server port is required")
+	}
+
+	if err := applyProfile(cfg); err != nil {
+		return err
+	}
+
+	srv := &cfg.Server
+	if srv.ReadHeaderTimeoutSeconds == 0 {
+		srv.ReadHeaderTimeoutSeconds = 10
+	}
+	if srv.ReadTimeoutSeconds == 0 {
+		srv.ReadTimeoutSeconds = 30
+	}
+	if srv.WriteTimeoutSeconds == 0 {
+		srv.WriteTimeoutSeconds = 60
+	}
+	if srv.IdleTimeoutSeconds == 0 {
+		srv.IdleTimeoutSeconds = 120
+	}
+	if srv.MaxHeaderBytes <= 0 {
+		srv.MaxHeaderBytes = 1 << 20
+	}
+	if srv.ShutdownTimeoutSeconds <= 0 {
+		srv.ShutdownTimeoutSeconds = 30
+	}
+
+	if a := cfg.Server.Admin; a != nil {
+		if a.Port == "" && a.Socket == "" {
+			return fmt.Errorf("server admin listener requires a port or socket")
+		}
+		if a.Port == cfg.Server.Port {
+			return fmt.Errorf("server admin port must differ from the proxy port")
+		}
+	}
+
+	switch cfg.Logging.Level {
+	case "":
+		cfg.Logging.Level = "info"
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("unknown log level: %s", cfg.Logging.Level)
+	}
+	switch cfg.Logging.Format {
+	case "":
+		cfg.Logging.Format = "json"
+	case "json", "text":
+	default:
+		return fmt.Errorf("unknown log format: %s", cfg.Logging.Format)
+	}
+
+	switch cfg.Storage.Backend {
+	case "":
+		cfg.Storage.Backend = "redis"
+	case "redis":
+	case "embedded":
+		// Redis runs in the process, keeping its data in the embedded store
+		if cfg.Storage.Path == "" {
+			cfg.Storage.Path = "gateway.db"
+		}
+	case "memory":
+		// Redis and the database both run in the process; their settings are ignored
+		cfg.Database = DatabaseConfig{Driver: "sqlite", Path: ":memory:"}
+	default:
+		return fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+
+	if cfg.Redis.Host == "" && cfg.Storage.Backend == "redis" {
 		return fmt.Errorf("redis host is required")
 	}
 
-	if cfg.Database.Host == "" {
-		return fmt.Errorf("database host is required")
+	if err := validateDatabase(&cfg.Database); err != nil {
+		return err
 	}
-	if cfg.Database.DBName == "" {
-		return fmt.Errorf("database name is required")
+
+	switch cfg.Analytics.Backend {
+	case "":
+		cfg.Analytics.Backend = "postgres"
+	case "postgres":
+	case "timescale":
+		if cfg.Database.Driver != "postgres" {
+			return fmt.Errorf("analytics backend timescale requires the postgres database driver")
+		}
+	case "clickhouse":
+		if cfg.Analytics.ClickHouse == nil || cfg.Analytics.ClickHouse.URL == "" {
+			return fmt.Errorf("analytics backend clickhouse requires analytics.clickhouse.url")
+		}
+	default:
+		return fmt.Errorf("unknown analytics backend: %s", cfg.Analytics.Backend)
+	}
+
+	if err := validateAccessLog(cfg); err != nil {
+		return err
+	}
+
+	if err := validateAlerts(&cfg.Alerts); err != nil {
+		return err
+	}
+
+	if c := cfg.Catalog; c != nil {
+		if c.Backend != "consul" && c.Backend != "etcd" {
+			return fmt.Errorf("unknown catalog backend: %s", c.Backend)
+		}
+		if c.Address == "" {
+			return fmt.Errorf("catalog address is required")
+		}
+		c.Prefix = strings.Trim(c.Prefix, "/")
+		if c.Prefix == "" {
+			c.Prefix = "gateway"
+		}
 	}
 
 	if len(cfg.Services) == 0 {
@@ -158,18 +1391,952 @@ func validate(cfg *Config) error {
 		if svc.Path == "" {
 			return fmt.Errorf("service %d: path is required", i)
 		}
-		if len(svc.Targets) == 0 {
+		for j, route := range svc.Routes {
+			if _, err := routing.NewRoute(route.Template, route.Regex, route.Name, route.Priority, route.Methods); err != nil {
+				return fmt.Errorf("service %d: route %d: %w", i, j, err)
+			}
+			if route.Template != "" && route.Template != svc.Path && !strings.HasPrefix(route.Template, strings.TrimSuffix(svc.Path, "/")+"/") {
+				return fmt.Errorf("service %d: route %d: template must start with the service path %s", i, j, svc.Path)
+			}
+		}
+		if cfg.APICatalog.Public && (svc.Path == "/catalog" || strings.HasPrefix(svc.Path, "/catalog/")) {
+			return fmt.Errorf("service %d: path %s is taken by the public API catalog", i, svc.Path)
+		}
+		if svc.Path == "/async" || strings.HasPrefix(svc.Path, "/async/") {
+			return fmt.Errorf("service %d: path %s is taken by async job status", i, svc.Path)
+		}
+		if as := svc.Async; as != nil && as.Enabled {
+			if as.MaxBodyBytes <= 0 {
+				as.MaxBodyBytes = 1 << 20
+			}
+			if as.CallbackHeader == "" {
+				as.CallbackHeader = "X-Callback-URL"
+			}
+			for j, method := range as.Methods {
+				as.Methods[j] = strings.ToUpper(method)
+			}
+		}
+		if k := svc.Kubernetes; k != nil {
+			if (k.Service == "") == (k.LabelSelector == "") {
+				return fmt.Errorf("service %d: kubernetes requires exactly one of service and label_selector", i)
+			}
+			if k.Namespace == "" {
+				k.Namespace = "default"
+			}
+			if k.Scheme == "" {
+				k.Scheme = "http"
+			}
+		} else if len(svc.Targets) == 0 && svc.BlueGreen == nil && len(svc.Mocks) == 0 {
 			return fmt.Errorf("service %d: at least one target is required", i)
 		}
+		if bg := svc.BlueGreen; bg != nil {
+			if len(bg.Blue) == 0 || len(bg.Green) == 0 {
+				return fmt.Errorf("service %d: blue_green requires blue and green targets", i)
+			}
+			if bg.Active == "" {
+				bg.Active = "blue"
+			}
+			if bg.Active != "blue" && bg.Active != "green" {
+				return fmt.Errorf("service %d: blue_green active must be blue or green", i)
+			}
+			if len(svc.Targets) > 0 || svc.Kubernetes != nil || svc.Canary != nil {
+				return fmt.Errorf("service %d: blue_green cannot be combined with targets, kubernetes or canary", i)
+			}
+		}
+		if srv := svc.SRV; srv != nil && srv.RefreshSeconds <= 0 {
+			srv.RefreshSeconds = 30
+		}
+		if t := svc.Transport; t != nil {
+			switch t.Protocol {
+			case "", "auto", "http1":
+			case "http2":
+				for _, target := range svc.AllTargets() {
+					if strings.HasPrefix(target, "http://") {
+						return fmt.Errorf("service %d: transport protocol http2 requires https targets; use h2c for %s", i, target)
+					}
+				}
+			case "h2c":
+				for _, target := range svc.AllTargets() {
+					if strings.HasPrefix(target, "https://") {
+						return fmt.Errorf("service %d: transport protocol h2c requires http targets; use http2 for %s", i, target)
+					}
+				}
+			default:
+				return fmt.Errorf("service %d: unknown transport protocol: %s", i, t.Protocol)
+			}
+			if t.MaxIdleConnsPerHost < 0 || t.MaxConnsPerHost < 0 || t.IdleConnTimeoutSeconds < 0 || t.DialTimeoutSeconds < 0 ||
+				t.TLSHandshakeTimeoutSeconds < 0 || t.ResponseHeaderTimeoutSeconds < 0 {
+				return fmt.Errorf("service %d: transport limits and timeouts must not be negative", i)
+			}
+		}
+		if bs := svc.BodyScan; bs != nil && bs.Enabled && bs.Scanner != "clamav" {
+			return fmt.Errorf("service %d: unknown body scanner: %s", i, bs.Scanner)
+		}
+		if od := svc.Outliers; od != nil && od.Enabled {
+			if od.LatencyFactor != 0 && od.LatencyFactor <= 1 {
+				return fmt.Errorf("service %d: outlier_detection latency_factor must be greater than 1", i)
+			}
+			if od.MaxEjectionPercent < 0 || od.MaxEjectionPercent > 100 {
+				return fmt.Errorf("service %d: outlier_detection max_ejection_percent must be between 0 and 100", i)
+			}
+		}
+		if cn := svc.Canary; cn != nil {
+			if len(cn.Targets) == 0 {
+				return fmt.Errorf("service %d: canary requires targets", i)
+			}
+			if cn.Percent < 0 || cn.Percent > 100 {
+				return fmt.Errorf("service %d: canary percent must be between 0 and 100", i)
+			}
+		}
+		names := make(map[string]bool)
+		for j := range svc.Experiments {
+			exp := &svc.Experiments[j]
+			if exp.Name == "" || names[exp.Name] {
+				return fmt.Errorf("service %d: experiment %d needs a unique name", i, j)
+			}
+			names[exp.Name] = true
+			if len(exp.Targets) == 0 {
+				return fmt.Errorf("service %d: experiment %s requires targets", i, exp.Name)
+			}
+			selectors := 0
+			for _, set := range []bool{exp.Header != "", exp.Cookie != "", exp.Percentage > 0} {
+				if set {
+					selectors++
+				}
+			}
+			if selectors != 1 {
+				return fmt.Errorf("service %d: experiment %s needs exactly one of header, cookie or percentage", i, exp.Name)
+			}
+			if exp.Percentage < 0 || exp.Percentage > 100 {
+				return fmt.Errorf("service %d: experiment %s percentage must be between 0 and 100", i, exp.Name)
+			}
+			if exp.Variant == "" {
+				exp.Variant = "b"
+			}
+		}
+		for j := range svc.Mocks {
+			mock := &svc.Mocks[j]
+			if !strings.HasPrefix(mock.Path, "/") {
+				return fmt.Errorf("service %d: mock %d path must start with /", i, j)
+			}
+			if mock.Status == 0 {
+				mock.Status = 200
+			}
+			if mock.Status < 100 || mock.Status > 599 {
+				return fmt.Errorf("service %d: mock %d has invalid status %d", i, j, mock.Status)
+			}
+			if mock.LatencyMs < 0 {
+				return fmt.Errorf("service %d: mock %d latency_ms must not be negative", i, j)
+			}
+		}
+		if tr := svc.Transforms; tr != nil {
+			for _, rule := range tr.Paths {
+				if _, err := regexp.Compile(rule.Match); err != nil {
+					return fmt.Errorf("service %d: invalid path rewrite %q: %w", i, rule.Match, err)
+				}
+			}
+		}
+		if rw := svc.Rewrite; rw != nil {
+			if svc.StripPrefix {
+				return fmt.Errorf("service %d: strip_prefix and rewrite cannot both be set", i)
+			}
+			if rw.From != "" && !strings.HasPrefix(rw.From, "/") {
+				return fmt.Errorf("service %d: rewrite from must start with /: %q", i, rw.From)
+			}
+			if rw.To != "" && !strings.HasPrefix(rw.To, "/") {
+				return fmt.Errorf("service %d: rewrite to must start with /: %q", i, rw.To)
+			}
+		}
+		if v := svc.Versions; v != nil {
+			if len(v.Versions) == 0 {
+				return fmt.Errorf("service %d: versions requires at least one version", i)
+			}
+			names := make(map[string]bool, len(v.Versions))
+			for j, version := range v.Versions {
+				if version.Name == "" || strings.Contains(version.Name, "/") {
+					return fmt.Errorf("service %d: version %d: name is required and may not contain /", i, j)
+				}
+				if names[version.Name] {
+					return fmt.Errorf("service %d: duplicate version %s", i, version.Name)
+				}
+				names[version.Name] = true
+				if version.Sunset != "" {
+					if _, err := time.Parse(time.RFC3339, version.Sunset); err != nil {
+						return fmt.Errorf("service %d: version %s: invalid sunset: %w", i, version.Name, err)
+					}
+				}
+			}
+			if v.Default != "" && !names[v.Default] {
+				return fmt.Errorf("service %d: default version %s is not defined", i, v.Default)
+			}
+		}
+		if cc := svc.ClientCert; cc != nil && cc.Required && (cfg.Server.TLS == nil || cfg.Server.TLS.ClientAuth == nil) {
+			return fmt.Errorf("service %d: client_cert requires server.tls.client_auth", i)
+		}
+		if t := svc.Transport; t != nil && t.TLS != nil && (t.TLS.CertFile == "") != (t.TLS.KeyFile == "") {
+			return fmt.Errorf("service %d: transport tls needs both cert_file and key_file", i)
+		}
+		if c := svc.Cache; c != nil {
+			for _, rule := range c.Paths {
+				if !strings.HasPrefix(rule.Prefix, "/") {
+					return fmt.Errorf("service %d: cache path prefix must start with /: %q", i, rule.Prefix)
+				}
+			}
+		}
+		if bc := svc.BodyCapture; bc != nil && bc.MaxBytes > 1<<20 {
+			return fmt.Errorf("service %d: body_capture max_bytes must be at most 1 MiB", i)
+		}
+		if svc.Auth != "" && svc.Auth != "optional" && svc.Auth != "api_key" {
+			return fmt.Errorf("service %d: unknown auth mode: %s", i, svc.Auth)
+		}
+		if rl := svc.RateLimit; rl != nil && rl.RequestsPerMinute <= 0 {
+			return fmt.Errorf("service %d: rate_limit requests_per_minute must be positive", i)
+		}
+		if err := validateIPFilter(svc.IPFilter); err != nil {
+			return fmt.Errorf("service %d: %w", i, err)
+		}
+		if comp := svc.Compression; comp != nil && comp.Enabled {
+			if len(comp.Algorithms) == 0 {
+				comp.Algorithms = []string{"br", "gzip"}
+			}
+			for _, algorithm := range comp.Algorithms {
+				if algorithm != "br" && algorithm != "gzip" {
+					return fmt.Errorf("service %d: unknown compression algorithm: %s", i, algorithm)
+				}
+			}
+			if comp.MinBytes < 0 {
+				return fmt.Errorf("service %d: compression min_bytes must not be negative", i)
+			}
+			if comp.MinBytes == 0 {
+				comp.MinBytes = 1024
+			}
+			if len(comp.ContentTypes) == 0 {
+				comp.ContentTypes = []string{"text/*", "application/json", "application/*+json", "application/javascript", "application/xml", "application/*+xml", "image/svg+xml"}
+			}
+		}
+		if bots := svc.Bots; bots != nil && bots.Enabled {
+			if bots.Action == "" {
+				bots.Action = "tag"
+			}
+			if bots.Action != "tag" && bots.Action != "throttle" && bots.Action != "block" {
+				return fmt.Errorf("service %d: unknown bot action: %s", i, bots.Action)
+			}
+			for _, pattern := range slices.Concat(bots.UserAgentPatterns, bots.AllowUserAgents) {
+				if _, err := regexp.Compile(pattern); err != nil {
+					return fmt.Errorf("service %d: invalid bot user agent pattern %q: %w", i, pattern, err)
+				}
+			}
+			for _, path := range bots.HoneypotPaths {
+				if !strings.HasPrefix(path, "/") {
+					return fmt.Errorf("service %d: honeypot path %q must start with /", i, path)
+				}
+			}
+			if bots.MaxRequestsPerSecond < 0 || bots.FlagSeconds < 0 || bots.ThrottleRPM < 0 {
+				return fmt.Errorf("service %d: bot limits must not be negative", i)
+			}
+			if bots.FlagSeconds == 0 {
+				bots.FlagSeconds = 3600
+			}
+			if bots.ThrottleRPM == 0 {
+				bots.ThrottleRPM = 10
+			}
+		}
+		if geo := svc.Geo; geo != nil {
+			if cfg.GeoIP == nil || cfg.GeoIP.Database == "" {
+				return fmt.Errorf("service %d: geo rules require geoip.database", i)
+			}
+			for _, codes := range [][]string{geo.AllowCountries, geo.DenyCountries} {
+				for j, code := range codes {
+					if len(code) != 2 {
+						return fmt.Errorf("service %d: invalid country code %q", i, code)
+					}
+					codes[j] = strings.ToUpper(code)
+				}
+			}
+		}
+		if gql := svc.GraphQL; gql != nil {
+			if gql.MaxDepth < 0 || gql.MaxComplexity < 0 {
+				return fmt.Errorf("service %d: graphql limits must not be negative", i)
+			}
+			if gql.MaxDepth == 0 {
+				gql.MaxDepth = 15
+			}
+			if gql.MaxComplexity == 0 {
+				gql.MaxComplexity = 1000
+			}
+			if gql.Introspection == nil {
+				allowed := cfg.Server.Environment != "production"
+				gql.Introspection = &allowed
+			}
+		}
+		if id := svc.Idempotency; id != nil && id.TTLSeconds < 0 {
+			return fmt.Errorf("service %d: idempotency ttl_seconds must not be negative", i)
+		}
+		if ul := svc.UpstreamLimit; ul != nil && ul.Enabled && ul.Mode != "" && ul.Mode != "reject" && ul.Mode != "queue" {
+			return fmt.Errorf("service %d: unknown upstream limit mode: %s", i, ul.Mode)
+		}
+		if svc.FastPath && svc.HasRequestPolicies() {
+			return fmt.Errorf("service %d: fast_path cannot be combined with auth, limits, transforms, experiments, mocks, caching, idempotency, graphql, ip or geo filters, bot detection, compression, routes, path rewrites, versions, client certificates, async requests, body scanning, dead letters or token exchange", i)
+		}
+		if fa := svc.ForwardAuth; fa != nil && fa.Enabled && (fa.Issuer == "" || fa.JWKSURL == "") {
+			return fmt.Errorf("service %d: forward_auth requires issuer and jwks_url", i)
+		}
+		if in := svc.Introspection; in != nil && in.Enabled {
+			if in.URL == "" {
+				return fmt.Errorf("service %d: introspection requires url", i)
+			}
+			if in.CacheSeconds < 0 {
+				return fmt.Errorf("service %d: introspection cache_seconds must not be negative", i)
+			}
+			if in.CacheSeconds == 0 {
+				in.CacheSeconds = 60
+			}
+		}
+		if te := svc.TokenExchange; te != nil && te.Enabled && te.Mode == "sts" && cfg.TokenExchange.STSURL == "" {
+			return fmt.Errorf("service %d: token exchange mode sts requires token_exchange.sts_url", i)
+		}
+		// Backend tokens signed with the admin secret would be accepted by /admin
+		if te := svc.TokenExchange; te != nil && te.Enabled {
+			if cfg.TokenExchange.Secret == "" {
+				return fmt.Errorf("service %d: token exchange requires token_exchange.secret", i)
+			}
+			if cfg.TokenExchange.Secret == cfg.JWT.Secret {
+				return fmt.Errorf("service %d: token_exchange.secret must differ from jwt.secret", i)
+			}
+		}
+	}
+	if cfg.TokenExchange.Issuer == "" {
+		cfg.TokenExchange.Issuer = "api-gateway"
+	}
+
+	for i, rule := range cfg.DarkLaunch {
+		if rule.Header == "" {
+			return fmt.Errorf("dark launch rule %d: header is required", i)
+		}
+		if p := rule.Percentage; p != nil && (*p < 0 || *p > 100) {
+			return fmt.Errorf("dark launch rule %d: percentage must be between 0 and 100", i)
+		}
+		for j, org := range rule.Orgs {
+			id, err := uuid.Parse(org)
+			if err != nil {
+				return fmt.Errorf("dark launch rule %d: invalid organization ID %q", i, org)
+			}
+			rule.Orgs[j] = id.String()
+		}
+	}
+
+	for _, tier := range cfg.RateLimitTiers {
+		for i, bucket := range tier.Buckets {
+			if bucket.Name == "" {
+				return fmt.Errorf("rate limit tier %s: bucket %d: name is required", tier.Name, i)
+			}
+		}
+	}
+
+	if cfg.CORS != nil {
+		if err := validateCORSOrigins(cfg.CORS.AllowedOrigins); err != nil {
+			return fmt.Errorf("cors: %w", err)
+		}
+		for i, route := range cfg.CORS.Routes {
+			if route.PathPrefix == "" {
+				return fmt.Errorf("cors route %d: path_prefix is required", i)
+			}
+			if err := validateCORSOrigins(route.AllowedOrigins); err != nil {
+				return fmt.Errorf("cors route %d: %w", i, err)
+			}
+		}
+	}
+	for i, svc := range cfg.Services {
+		if svc.CORS != nil {
+			if err := validateCORSOrigins(svc.CORS.AllowedOrigins); err != nil {
+				return fmt.Errorf("service %d: cors: %w", i, err)
+			}
+		}
+	}
+
+	for i, hook := range cfg.Webhooks {
+		if hook.URL == "" {
+			return fmt.Errorf("webhook %d: url is required", i)
+		}
+	}
+
+	if err := validateComposites(cfg); err != nil {
+		return err
 	}
 
-	if cfg.JWT.Secret == "" {
-		return fmt.Errorf("JWT secret is required")
+	for _, entry := range cfg.Server.TrustedProxies {
+		if _, err := netip.ParsePrefix(entry); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(entry); err != nil {
+			return fmt.Errorf("server: invalid trusted proxy %q", entry)
+		}
+	}
+
+	if err := validateIPFilter(cfg.IPFilter); err != nil {
+		return err
+	}
+
+	if err := validateRequestID(cfg.RequestID); err != nil {
+		return err
+	}
+
+	if err := validateVault(cfg.Vault); err != nil {
+		return err
+	}
+
+	if err := validateTLS(cfg.Server.TLS); err != nil {
+		return err
+	}
+
+	if cfg.JWT.Secret == "" && len(cfg.JWT.Keys) == 0 && (cfg.Vault == nil || cfg.Vault.JWTSecret == "") {
+		return fmt.Errorf("JWT secret or signing keys are required")
+	}
+	for i, key := range cfg.JWT.Keys {
+		if key.ID == "" || key.PrivateKeyFile == "" {
+			return fmt.Errorf("jwt key %d: id and private_key_file are required", i)
+		}
+		if key.Algorithm != "RS256" && key.Algorithm != "EdDSA" {
+			return fmt.Errorf("jwt key %s: unsupported algorithm: %s", key.ID, key.Algorithm)
+		}
 	}
 	if cfg.JWT.ExpiryHours <= 0 {
 		cfg.JWT.ExpiryHours = 24 // Default to 24 hours
 	}
 
+	if o := cfg.OIDC; o != nil && o.Enabled {
+		if o.Issuer == "" || o.ClientID == "" || o.RedirectURL == "" {
+			return fmt.Errorf("oidc requires issuer, client_id and redirect_url")
+		}
+		for group, role := range o.RoleMapping {
+			if role != "admin" && role != "viewer" {
+				return fmt.Errorf("oidc role_mapping %s: unknown role: %s", group, role)
+			}
+		}
+		if o.DefaultRole != "" && o.DefaultRole != "admin" && o.DefaultRole != "viewer" {
+			return fmt.Errorf("oidc default_role: unknown role: %s", o.DefaultRole)
+		}
+	}
+
+	if cfg.StaleKeys.UnusedDays <= 0 {
+		cfg.StaleKeys.UnusedDays = 90
+	}
+	if cfg.StaleKeys.GraceDays < 0 {
+		return fmt.Errorf("stale_keys grace_days must not be negative")
+	}
+	if cfg.StaleKeys.GraceDays == 0 {
+		cfg.StaleKeys.GraceDays = 14
+	}
+	if cfg.StaleKeys.CheckIntervalMinutes <= 0 {
+		cfg.StaleKeys.CheckIntervalMinutes = 60
+	}
+
+	switch cfg.KeyValidation.Degradation {
+	case "":
+		cfg.KeyValidation.Degradation = "fail_closed"
+	case "fail_closed", "stale_cache", "fail_open":
+	default:
+		return fmt.Errorf("unknown key_validation degradation: %s", cfg.KeyValidation.Degradation)
+	}
+	if cfg.KeyValidation.StaleCacheMinutes <= 0 {
+		cfg.KeyValidation.StaleCacheMinutes = 60
+	}
+	if cfg.KeyValidation.RecentlySeenMinutes <= 0 {
+		cfg.KeyValidation.RecentlySeenMinutes = 15
+	}
+
+	if cfg.UsageReports.IntervalMinutes <= 0 {
+		cfg.UsageReports.IntervalMinutes = 60
+	}
+
+	if p := &cfg.Portal; p.Enabled {
+		if len(p.Tiers) == 0 {
+			return fmt.Errorf("portal requires at least one tier")
+		}
+		for _, name := range p.Tiers {
+			if !tierDefined(cfg, name) {
+				return fmt.Errorf("portal tiers: unknown rate limit tier: %s", name)
+			}
+		}
+		if p.MaxKeys <= 0 {
+			p.MaxKeys = 5
+		}
+		if p.LogDays <= 0 {
+			p.LogDays = 7
+		}
+	}
+
+	if o := &cfg.OAuth; o.Enabled {
+		if len(cfg.JWT.Keys) == 0 {
+			return fmt.Errorf("oauth requires jwt.keys so backends can verify tokens")
+		}
+		if o.Issuer == "" {
+			o.Issuer = "api-gateway"
+		}
+		if o.Audience == "" {
+			o.Audience = "api"
+		}
+		if o.TokenMinutes <= 0 {
+			o.TokenMinutes = 15
+		}
+	}
+
+	switch cfg.Auth.Registration {
+	case "":
+		cfg.Auth.Registration = "open"
+	case "open", "invite":
+	default:
+		return fmt.Errorf("unknown registration mode: %s", cfg.Auth.Registration)
+	}
+
+	lt := &cfg.Auth.LoginThrottle
+	if lt.MaxAccountFailures <= 0 {
+		lt.MaxAccountFailures = 5
+	}
+	if lt.MaxIPFailures <= 0 {
+		lt.MaxIPFailures = 20
+	}
+	if lt.WindowMinutes <= 0 {
+		lt.WindowMinutes = 15
+	}
+	if lt.BaseDelaySeconds <= 0 {
+		lt.BaseDelaySeconds = 1
+	}
+	if lt.MaxDelaySeconds <= 0 {
+		lt.MaxDelaySeconds = 60
+	}
+	if lt.LockoutMinutes <= 0 {
+		lt.LockoutMinutes = 15
+	}
+
+	return nil
+}
+
+// Reports whether a rate limit tier with the name is configured
+func tierDefined(cfg *Config, name string) bool {
+	for _, tier := range cfg.RateLimitTiers {
+		if tier.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks composite routes and fills their defaults
+func validateComposites(cfg *Config) error {
+	// Reports whether path is under a service, which routes it to the backend
+	underService := func(path string) bool {
+		return slices.ContainsFunc(cfg.Services, func(svc ServiceConfig) bool {
+			return path == svc.Path || strings.HasPrefix(path, svc.Path+"/")
+		})
+	}
+
+	paths := make(map[string]bool)
+	for i := range cfg.Composites {
+		comp := &cfg.Composites[i]
+		if !strings.HasPrefix(comp.Path, "/") {
+			return fmt.Errorf("composite %d: path must start with /", i)
+		}
+		if paths[comp.Path] {
+			return fmt.Errorf("composite %d: duplicate path %s", i, comp.Path)
+		}
+		paths[comp.Path] = true
+		if underService(comp.Path) {
+			return fmt.Errorf("composite %d: path %s is routed to a service", i, comp.Path)
+		}
+		if comp.Auth != "" && comp.Auth != "optional" && comp.Auth != "api_key" {
+			return fmt.Errorf("composite %d: unknown auth: %s", i, comp.Auth)
+		}
+		if comp.TimeoutMs < 0 {
+			return fmt.Errorf("composite %d: timeout_ms must not be negative", i)
+		}
+		if comp.TimeoutMs == 0 {
+			comp.TimeoutMs = 5000
+		}
+		if len(comp.Parts) == 0 {
+			return fmt.Errorf("composite %d: at least one part is required", i)
+		}
+
+		keys := make(map[string]bool)
+		for _, part := range comp.Parts {
+			if part.Key == "" || keys[part.Key] {
+				return fmt.Errorf("composite %d: parts need unique keys", i)
+			}
+			keys[part.Key] = true
+			if !underService(part.Path) {
+				return fmt.Errorf("composite %d: part %s path %q is not under a service", i, part.Key, part.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// Checks allowed origins: "*", or scheme://host[:port] with at most one *
+func validateCORSOrigins(origins []string) error {
+	for _, origin := range origins {
+		if origin == "*" {
+			continue
+		}
+		scheme, host, found := strings.Cut(origin, "://")
+		if !found || scheme == "" || host == "" || strings.Contains(host, "/") || strings.Count(origin, "*") > 1 {
+			return fmt.Errorf("invalid allowed origin %q", origin)
+		}
+	}
+	return nil
+}
+
+// Checks that allow and deny entries are CIDRs or addresses
+func validateIPFilter(f *IPFilterConfig) error {
+	if f == nil {
+		return nil
+	}
+	for _, entry := range slices.Concat(f.Allow, f.Deny) {
+		if _, err := netip.ParsePrefix(entry); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(entry); err != nil {
+			return fmt.Errorf("ip_filter: invalid entry %q", entry)
+		}
+	}
+	return nil
+}
+
+// Checks the trusted networks and fills the request id defaults
+func validateRequestID(r *RequestIDConfig) error {
+	if r == nil {
+		return nil
+	}
+	if r.Header == "" {
+		r.Header = "X-Request-ID"
+	}
+	if r.MaxLength == 0 {
+		r.MaxLength = 128
+	}
+	if r.MaxLength < 0 {
+		return fmt.Errorf("request_id: max_length cannot be negative")
+	}
+	if r.RejectUntrusted && len(r.TrustedNetworks) == 0 {
+		return fmt.Errorf("request_id: reject_untrusted requires trusted_networks")
+	}
+	for _, entry := range r.TrustedNetworks {
+		if _, err := netip.ParsePrefix(entry); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(entry); err != nil {
+			return fmt.Errorf("request_id: invalid trusted network %q", entry)
+		}
+	}
+	return nil
+}
+
+// Checks the Vault connection settings and fills their defaults
+func validateVault(v *VaultConfig) error {
+	if v == nil {
+		return nil
+	}
+
+	if v.Address == "" {
+		return fmt.Errorf("vault address is required")
+	}
+	switch v.Auth.Method {
+	case "token":
+		if v.Auth.Token == "" {
+			return fmt.Errorf("vault token auth requires VAULT_TOKEN")
+		}
+	case "kubernetes":
+		if v.Auth.Role == "" {
+			return fmt.Errorf("vault kubernetes auth requires a role")
+		}
+	case "approle":
+		if v.Auth.RoleID == "" || v.Auth.SecretID == "" {
+			return fmt.Errorf("vault approle auth requires role_id and VAULT_SECRET_ID")
+		}
+	default:
+		return fmt.Errorf("unknown vault auth method: %s", v.Auth.Method)
+	}
+	if v.RefreshMinutes <= 0 {
+		v.RefreshMinutes = 15
+	}
+
+	return nil
+}
+
+// Versions accepted for server.tls.min_version
+var TLSVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Checks the server TLS settings and fills their defaults
+func validateTLS(t *TLSConfig) error {
+	if t == nil {
+		return nil
+	}
+
+	if len(t.Certificates) == 0 {
+		return fmt.Errorf("server tls requires at least one certificate")
+	}
+	for i, cert := range t.Certificates {
+		if cert.CertFile == "" || cert.KeyFile == "" {
+			return fmt.Errorf("tls certificate %d: cert_file and key_file are required", i)
+		}
+	}
+	if t.MinVersion == "" {
+		t.MinVersion = "1.2"
+	}
+	if _, ok := TLSVersions[t.MinVersion]; !ok {
+		return fmt.Errorf("unknown tls min_version: %s", t.MinVersion)
+	}
+	for _, name := range t.CipherSuites {
+		if CipherSuiteID(name) == 0 {
+			return fmt.Errorf("unknown or insecure tls cipher suite: %s", name)
+		}
+	}
+	if ca := t.ClientAuth; ca != nil {
+		if ca.Mode == "" {
+			ca.Mode = "optional"
+		}
+		if ca.Mode != "optional" && ca.Mode != "require" {
+			return fmt.Errorf("unknown tls client_auth mode: %s", ca.Mode)
+		}
+		if ca.CAFile == "" {
+			return fmt.Errorf("tls client_auth requires ca_file")
+		}
+		if ca.DefaultTier == "" {
+			ca.DefaultTier = "basic"
+		}
+		for i, identity := range ca.Identities {
+			if identity.Subject == "" {
+				return fmt.Errorf("tls client_auth identity %d: subject is required", i)
+			}
+			if strings.HasPrefix(identity.Name, "cert:") || (identity.Name == "" && strings.HasPrefix(identity.Subject, "cert:")) {
+				return fmt.Errorf("tls client_auth identity %d: names starting with \"cert:\" are kept for unlisted certificates", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns the ID of a secure cipher suite by its Go name, or 0 if there is none
+func CipherSuiteID(name string) uint16 {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID
+		}
+	}
+	return 0
+}
+
+// Checks alerting rules and fills their defaults
+func validateAlerts(a *AlertsConfig) error {
+	if a.IntervalSeconds <= 0 {
+		a.IntervalSeconds = 30
+	}
+	if a.WindowSeconds <= 0 {
+		a.WindowSeconds = 30
+	}
+	if a.WindowSeconds > 59 {
+		return fmt.Errorf("alerts window_seconds must be at most 59")
+	}
+	if a.MinRequests <= 0 {
+		a.MinRequests = 20
+	}
+	if a.WarmupIntervals <= 0 {
+		a.WarmupIntervals = 10
+	}
+	if a.BaselineAlpha <= 0 {
+		a.BaselineAlpha = 0.05
+	}
+	if a.BaselineAlpha > 1 {
+		return fmt.Errorf("alerts baseline_alpha must be between 0 and 1")
+	}
+	if a.DeviationMultiple == 0 {
+		a.DeviationMultiple = 3
+	}
+	if a.DeviationMultiple <= 1 {
+		return fmt.Errorf("alerts deviation_multiple must be greater than 1")
+	}
+	if a.ErrorRateThreshold == 0 {
+		a.ErrorRateThreshold = 10
+	}
+	if a.MinErrorRate == 0 {
+		a.MinErrorRate = 1
+	}
+	if a.P95ThresholdMs < 0 {
+		return fmt.Errorf("alerts p95_threshold_ms must not be negative")
+	}
+
+	if e := a.Email; e != nil {
+		if e.SMTPHost == "" || e.From == "" || len(e.To) == 0 {
+			return fmt.Errorf("alerts email: smtp_host, from and to are required")
+		}
+		if e.SMTPPort <= 0 {
+			e.SMTPPort = 587
+		}
+	}
+	return nil
+}
+
+// Checks the database settings the driver needs, and fills their defaults
+func validateDatabase(db *DatabaseConfig) error {
+	switch db.Driver {
+	case "", "postgres":
+		db.Driver = "postgres"
+		if db.Port == 0 {
+			db.Port = 5432
+		}
+	case "mysql":
+		if db.Port == 0 {
+			db.Port = 3306
+		}
+	case "sqlite":
+		if db.Path == "" {
+			db.Path = "gateway.sqlite"
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown database driver: %s", db.Driver)
+	}
+
+	if db.Host == "" {
+		return fmt.Errorf("database host is required")
+	}
+	if db.DBName == "" {
+		return fmt.Errorf("database name is required")
+	}
+	return nil
+}
+
+// Checks access log sinks and sampling, and fills their defaults
+func validateAccessLog(cfg *Config) error {
+	if sampling := cfg.AccessLog.Sampling; sampling != nil {
+		if sampling.ClientErrorRate == 0 {
+			sampling.ClientErrorRate = 1
+		}
+		if sampling.ServerErrorRate == 0 {
+			sampling.ServerErrorRate = 1
+		}
+		for _, rate := range []float64{sampling.Rate, sampling.ClientErrorRate, sampling.ServerErrorRate} {
+			if rate <= 0 || rate > 1 {
+				return fmt.Errorf("access log sampling rates must be greater than 0 and at most 1")
+			}
+		}
+		if sampling.SlowMs < 0 {
+			return fmt.Errorf("access log sampling slow_ms must not be negative")
+		}
+	}
+
+	// The analytics table is always the sink named after its backend
+	names := map[string]bool{cfg.Analytics.Backend: true}
+	for i := range cfg.AccessLog.Sinks {
+		sink := &cfg.AccessLog.Sinks[i]
+		if sink.Name == "" {
+			sink.Name = sink.Type
+		}
+		if names[sink.Name] {
+			return fmt.Errorf("access log sink %d: duplicate name: %s", i, sink.Name)
+		}
+		names[sink.Name] = true
+
+		switch sink.Type {
+		case "file":
+			if sink.Path == "" {
+				return fmt.Errorf("access log sink %s: path is required", sink.Name)
+			}
+			if sink.MaxSizeMB <= 0 {
+				sink.MaxSizeMB = 100
+			}
+			if sink.MaxBackups <= 0 {
+				sink.MaxBackups = 5
+			}
+		case "kafka":
+			if sink.RestURL == "" || sink.Topic == "" {
+				return fmt.Errorf("access log sink %s: rest_url and topic are required", sink.Name)
+			}
+		case "elasticsearch":
+			if sink.URL == "" || sink.Index == "" {
+				return fmt.Errorf("access log sink %s: url and index are required", sink.Name)
+			}
+		default:
+			return fmt.Errorf("access log sink %d: unknown type: %s", i, sink.Type)
+		}
+
+		switch sink.Overflow {
+		case "":
+			sink.Overflow = "drop"
+		case "drop", "block":
+		default:
+			return fmt.Errorf("access log sink %s: unknown overflow policy: %s", sink.Name, sink.Overflow)
+		}
+		if sink.BatchSize <= 0 {
+			sink.BatchSize = 500
+		}
+		if sink.FlushIntervalSec <= 0 {
+			sink.FlushIntervalSec = 5
+		}
+		if sink.BufferSize <= 0 {
+			sink.BufferSize = cfg.Resources.LogBufferSize
+		}
+		if sink.BlockTimeoutMs <= 0 {
+			sink.BlockTimeoutMs = 50
+		}
+	}
+	return nil
+}
+
+// Fills resource bounds from the server profile. The edge profile also turns
+// off request analytics, which buffer and write every request to PostgreSQL.
+func applyProfile(cfg *Config) error {
+	defaults := ResourceConfig{
+		LogBufferSize:      1000,
+		CacheMaxEntryBytes: 1 << 20,
+		CacheWarmWorkers:   4,
+		WebhookBufferSize:  1000,
+	}
+
+	switch cfg.Server.Profile {
+	case "", "standard":
+		cfg.Server.Profile = "standard"
+	case "edge":
+		defaults = ResourceConfig{
+			LogBufferSize:          100,
+			CacheMaxEntryBytes:     64 << 10,
+			CacheWarmWorkers:       1,
+			HealthCheckConcurrency: 2,
+			WebhookBufferSize:      100,
+		}
+		cfg.Analytics.Enabled = false
+	default:
+		return fmt.Errorf("unknown server profile: %s", cfg.Server.Profile)
+	}
+
+	r := &cfg.Resources
+	if r.LogBufferSize <= 0 {
+		r.LogBufferSize = defaults.LogBufferSize
+	}
+	if r.CacheMaxEntryBytes <= 0 {
+		r.CacheMaxEntryBytes = defaults.CacheMaxEntryBytes
+	}
+	if r.CacheWarmWorkers <= 0 {
+		r.CacheWarmWorkers = defaults.CacheWarmWorkers
+	}
+	if r.HealthCheckConcurrency <= 0 {
+		r.HealthCheckConcurrency = defaults.HealthCheckConcurrency
+	}
+	if r.WebhookBufferSize <= 0 {
+		r.WebhookBufferSize = defaults.WebhookBufferSize
+	}
+
+	if cfg.Analytics.BatchSize <= 0 {
+		cfg.Analytics.BatchSize = 100
+	}
+	if cfg.Analytics.FlushIntervalSec <= 0 {
+		cfg.Analytics.FlushIntervalSec = 5
+	}
+
 	return nil
 }
 
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Converts a config file to JSON so every format shares the json field names
+// and validation. The format is chosen by file extension; anything other
+// than .yaml, .yml or .toml is read as JSON.
+func toJSON(path string, data []byte) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		converted, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		return converted, nil
+	case ".toml":
+		var document map[string]interface{}
+		if err := toml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("invalid TOML: %w", err)
+		}
+		return json.Marshal(document)
+	default:
+		return data, nil
+	}
+}
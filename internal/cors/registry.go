@@ -0,0 +1,194 @@
+package cors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/audit"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+)
+
+// Redis hash holding policy overrides shared by all replicas, keyed by service path
+const redisKey = "gateway:cors"
+
+var (
+	ErrNotFound      = errors.New("cors override not found")
+	ErrInvalidPolicy = errors.New("invalid cors policy")
+)
+
+// Cross-origin settings for a service. Unset fields keep the configured value.
+type Policy struct {
+	AllowedOrigins   []string `json:"allowed_origins,omitempty"` // "*", or origins with at most one * such as https://*.example.com
+	AllowedMethods   []string `json:"allowed_methods,omitempty"`
+	AllowedHeaders   []string `json:"allowed_headers,omitempty"`
+	ExposeHeaders    []string `json:"expose_headers,omitempty"`
+	AllowCredentials *bool    `json:"allow_credentials,omitempty"`
+	MaxAgeSeconds    int      `json:"max_age_seconds,omitempty"` // Preflight cache duration
+}
+
+// Checks origin patterns and normalizes methods
+func (p *Policy) Validate() error {
+	for _, origin := range p.AllowedOrigins {
+		if err := ValidateOrigin(origin); err != nil {
+			return err
+		}
+	}
+	for i, method := range p.AllowedMethods {
+		p.AllowedMethods[i] = strings.ToUpper(method)
+	}
+	if p.MaxAgeSeconds < 0 {
+		return fmt.Errorf("max_age_seconds must not be negative")
+	}
+	return nil
+}
+
+// Checks an allowed origin: "*", or scheme://host[:port] with at most one *
+func ValidateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	if strings.Count(origin, "*") > 1 {
+		return fmt.Errorf("origin %q has more than one wildcard", origin)
+	}
+	scheme, host, found := strings.Cut(origin, "://")
+	if !found || scheme == "" || host == "" || strings.Contains(host, "/") {
+		return fmt.Errorf("origin %q must look like https://example.com", origin)
+	}
+	return nil
+}
+
+// A policy set at runtime for a service, taking precedence over configuration
+type Override struct {
+	Service   string    `json:"service"` // Service path
+	Policy    Policy    `json:"policy"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Holds admin-managed overrides and keeps them in sync with other replicas
+type Registry struct {
+	mu        sync.RWMutex
+	overrides map[string]*Override
+	redis     *storage.RedisClient
+	hashSync  *storage.HashSync
+}
+
+func NewRegistry(redis *storage.RedisClient, interval time.Duration) *Registry {
+	r := &Registry{
+		overrides: make(map[string]*Override),
+		redis:     redis,
+	}
+	r.hashSync = storage.NewHashSync(redis, redisKey, "cors overrides", interval, r.apply)
+	return r
+}
+
+// Validates and stores a service's policy, replacing any earlier override
+func (r *Registry) Set(ctx context.Context, service string, policy Policy) (*Override, error) {
+	if !strings.HasPrefix(service, "/") {
+		return nil, fmt.Errorf("%w: service must be a service path", ErrInvalidPolicy)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+
+	override := &Override{Service: service, Policy: policy, UpdatedAt: time.Now()}
+	data, err := json.Marshal(override)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.redis.HSet(ctx, redisKey, service, data); err != nil {
+		return nil, fmt.Errorf("failed to persist cors override: %w", err)
+	}
+
+	r.mu.Lock()
+	previous := r.overrides[service]
+	r.overrides[service] = override
+	r.mu.Unlock()
+
+	audit.Record(ctx, "cors.update", "service", service, previous, override)
+
+	log.Printf("CORS policy for %s overridden", service)
+	return override, nil
+}
+
+// Drops a service's override, restoring its configured policy
+func (r *Registry) Remove(ctx context.Context, service string) error {
+	r.mu.Lock()
+	override, exists := r.overrides[service]
+	delete(r.overrides, service)
+	r.mu.Unlock()
+
+	if !exists {
+		return ErrNotFound
+	}
+	if err := r.redis.HDel(ctx, redisKey, service); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, "cors.delete", "service", service, override, nil)
+	return nil
+}
+
+// Returns all overrides by service path
+func (r *Registry) List() []*Override {
+	r.mu.RLock()
+	overrides := make([]*Override, 0, len(r.overrides))
+	for _, override := range r.overrides {
+		overrides = append(overrides, override)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].Service < overrides[j].Service
+	})
+	return overrides
+}
+
+// Returns the override of the longest service path containing path, or nil
+func (r *Registry) Lookup(path string) *Override {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var match *Override
+	for service, override := range r.overrides {
+		if (path == service || strings.HasPrefix(path, service+"/")) && (match == nil || len(service) > len(match.Service)) {
+			match = override
+		}
+	}
+	return match
+}
+
+// Loads persisted overrides and keeps them in sync with other replicas
+func (r *Registry) Start() {
+	r.hashSync.Start()
+}
+
+// Stops syncing overrides
+func (r *Registry) Stop() {
+	r.hashSync.Stop()
+}
+
+// Replaces the overrides with those persisted in Redis
+func (r *Registry) apply(persisted map[string]string) []string {
+	overrides := make(map[string]*Override, len(persisted))
+	for service, data := range persisted {
+		var override Override
+		if err := json.Unmarshal([]byte(data), &override); err != nil {
+			log.Printf("Skipping malformed cors override for %s: %v", service, err)
+			continue
+		}
+		overrides[service] = &override
+	}
+
+	r.mu.Lock()
+	r.overrides = overrides
+	r.mu.Unlock()
+
+	return nil
+}
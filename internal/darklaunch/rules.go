@@ -0,0 +1,114 @@
+package darklaunch
+
+import (
+	"hash/fnv"
+	"net/http"
+	"strings"
+)
+
+// A single header injection rule
+type Rule struct {
+	Header     string   // Header sent to the backend (e.g., "X-Feature-NewPricing")
+	Value      string   // Header value (default: "true")
+	Tiers      []string // Key tiers the rule applies to (empty: all tiers)
+	Services   []string // Service paths the rule applies to (empty: all services)
+	Orgs       []string // Organization IDs the rule applies to (empty: all organizations)
+	Percentage *float64 // Share of matching consumers that get the header, 0-100 (nil: 100)
+}
+
+// Describes the consumer a request is made on behalf of
+type Consumer struct {
+	ID   string // Stable identity used for bucketing (API key ID or client IP)
+	Tier string
+	Org  string // Organization ID of the key, empty for keys outside one
+}
+
+// Evaluates dark-launch rules against consumers
+type Engine struct {
+	rules []Rule
+}
+
+func NewEngine(rules []Rule) *Engine {
+	normalized := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Header == "" {
+			continue
+		}
+		rule.Header = http.CanonicalHeaderKey(rule.Header)
+		if rule.Value == "" {
+			rule.Value = "true"
+		}
+		if rule.Percentage == nil {
+			all := 100.0
+			rule.Percentage = &all
+		}
+		normalized = append(normalized, rule)
+	}
+
+	return &Engine{rules: normalized}
+}
+
+// Returns the headers that should be injected for a consumer calling a service
+func (e *Engine) Evaluate(servicePath string, consumer Consumer) map[string]string {
+	headers := make(map[string]string)
+
+	for _, rule := range e.rules {
+		if !matches(rule.Tiers, consumer.Tier) || !matches(rule.Orgs, consumer.Org) || !matchesService(rule.Services, servicePath) {
+			continue
+		}
+		if Bucket(rule.Header, consumer.ID) >= *rule.Percentage {
+			continue
+		}
+		headers[rule.Header] = rule.Value
+	}
+
+	return headers
+}
+
+// Returns all header names managed by the engine
+func (e *Engine) Headers() []string {
+	headers := make([]string, 0, len(e.rules))
+	for _, rule := range e.rules {
+		headers = append(headers, rule.Header)
+	}
+	return headers
+}
+
+// Returns the number of configured rules
+func (e *Engine) Len() int {
+	return len(e.rules)
+}
+
+// Maps a consumer to a stable bucket in [0, 100) for a given rule.
+// Salting with the header keeps buckets independent across rules.
+func Bucket(salt, consumerID string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(salt))
+	h.Write([]byte{0})
+	h.Write([]byte(consumerID))
+	return float64(h.Sum32()%10000) / 100
+}
+
+func matches(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesService(paths []string, path string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	for _, p := range paths {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
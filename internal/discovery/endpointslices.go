@@ -0,0 +1,265 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Label linking an EndpointSlice to the Service it belongs to
+const serviceNameLabel = "kubernetes.io/service-name"
+
+type endpointSlice struct {
+	Metadata struct {
+		Name            string `json:"name"`
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Endpoints []struct {
+		Addresses  []string `json:"addresses"`
+		Conditions struct {
+			Ready *bool `json:"ready"` // Unset means ready
+		} `json:"conditions"`
+	} `json:"endpoints"`
+	Ports []struct {
+		Name string `json:"name"`
+		Port *int   `json:"port"`
+	} `json:"ports"`
+}
+
+type endpointSliceList struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Items []endpointSlice `json:"items"`
+}
+
+type watchEvent struct {
+	Type   string          `json:"type"` // ADDED, MODIFIED, DELETED, BOOKMARK or ERROR
+	Object json.RawMessage `json:"object"`
+}
+
+// Turns the ready endpoints of a Service's EndpointSlices into proxy targets
+// and keeps them current with a watch on the API server
+type EndpointSliceSource struct {
+	client    *Kubernetes
+	namespace string
+	selector  string
+	port      string
+	scheme    string
+
+	slices   map[string]endpointSlice
+	targets  []string
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	cancel   context.CancelFunc
+}
+
+// Selects the EndpointSlices of service, or those matching labelSelector.
+// port is a port name or number; empty uses each slice's first port.
+func NewEndpointSliceSource(client *Kubernetes, namespace, service, labelSelector, port, scheme string) *EndpointSliceSource {
+	selector := labelSelector
+	if service != "" {
+		selector = serviceNameLabel + "=" + service
+	}
+
+	return &EndpointSliceSource{
+		client:    client,
+		namespace: namespace,
+		selector:  selector,
+		port:      port,
+		scheme:    scheme,
+		stopChan:  make(chan struct{}),
+	}
+}
+
+func (s *EndpointSliceSource) Watch(update func(targets []string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return
+	}
+	s.running = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	go s.run(ctx, update)
+}
+
+func (s *EndpointSliceSource) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		s.cancel()
+		close(s.stopChan)
+		s.running = false
+	}
+}
+
+func (s *EndpointSliceSource) run(ctx context.Context, update func(targets []string)) {
+	backoff := time.Second
+	resourceVersion := ""
+
+	for {
+		var err error
+		if resourceVersion == "" {
+			resourceVersion, err = s.list(ctx, update)
+		} else {
+			resourceVersion, err = s.watch(ctx, resourceVersion, update)
+		}
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			// The watch fell too far behind; start over from a fresh list
+			var status *statusError
+			if errors.As(err, &status) && status.code == http.StatusGone {
+				resourceVersion = ""
+				continue
+			}
+
+			log.Printf("Failed to watch endpoint slices in %s (%s), retrying in %s: %v", s.namespace, s.selector, backoff, err)
+			select {
+			case <-time.After(backoff):
+			case <-s.stopChan:
+				return
+			}
+			backoff = min(backoff*2, time.Minute)
+			continue
+		}
+		backoff = time.Second
+	}
+}
+
+func (s *EndpointSliceSource) path() string {
+	return fmt.Sprintf("/apis/discovery.k8s.io/v1/namespaces/%s/endpointslices", url.PathEscape(s.namespace))
+}
+
+// Reads every matching slice, returning the resource version to watch from
+func (s *EndpointSliceSource) list(ctx context.Context, update func(targets []string)) (string, error) {
+	resp, err := s.client.get(ctx, s.path(), url.Values{"labelSelector": {s.selector}})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var list endpointSliceList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return "", fmt.Errorf("failed to decode endpoint slices: %w", err)
+	}
+
+	s.slices = make(map[string]endpointSlice, len(list.Items))
+	for _, slice := range list.Items {
+		s.slices[slice.Metadata.Name] = slice
+	}
+	s.publish(update)
+
+	return list.Metadata.ResourceVersion, nil
+}
+
+// Applies changes until the watch ends, returning the last resource version seen
+func (s *EndpointSliceSource) watch(ctx context.Context, resourceVersion string, update func(targets []string)) (string, error) {
+	resp, err := s.client.get(ctx, s.path(), url.Values{
+		"labelSelector":       {s.selector},
+		"watch":               {"true"},
+		"resourceVersion":     {resourceVersion},
+		"allowWatchBookmarks": {"true"},
+	})
+	if err != nil {
+		return resourceVersion, err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event watchEvent
+		if err := decoder.Decode(&event); err != nil {
+			if ctx.Err() != nil {
+				return resourceVersion, ctx.Err()
+			}
+			// The API server closes watches after a few minutes; resume from here
+			return resourceVersion, nil
+		}
+
+		if event.Type == "ERROR" {
+			var status struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			}
+			json.Unmarshal(event.Object, &status)
+			return resourceVersion, &statusError{code: status.Code, message: status.Message}
+		}
+
+		var slice endpointSlice
+		if err := json.Unmarshal(event.Object, &slice); err != nil {
+			return resourceVersion, fmt.Errorf("failed to decode endpoint slice: %w", err)
+		}
+		resourceVersion = slice.Metadata.ResourceVersion
+
+		switch event.Type {
+		case "ADDED", "MODIFIED":
+			s.slices[slice.Metadata.Name] = slice
+		case "DELETED":
+			delete(s.slices, slice.Metadata.Name)
+		default:
+			continue
+		}
+		s.publish(update)
+	}
+}
+
+// Calls update if the ready endpoints changed
+func (s *EndpointSliceSource) publish(update func(targets []string)) {
+	targets := make([]string, 0)
+	for _, slice := range s.slices {
+		port, ok := s.slicePort(slice)
+		if !ok {
+			continue
+		}
+		for _, endpoint := range slice.Endpoints {
+			if ready := endpoint.Conditions.Ready; ready != nil && !*ready {
+				continue
+			}
+			for _, address := range endpoint.Addresses {
+				targets = append(targets, s.scheme+"://"+net.JoinHostPort(address, strconv.Itoa(port)))
+			}
+		}
+	}
+	slices.Sort(targets)
+	targets = slices.Compact(targets)
+
+	if s.targets != nil && slices.Equal(targets, s.targets) {
+		return
+	}
+	s.targets = targets
+
+	log.Printf("Discovered %d ready endpoints in %s (%s)", len(targets), s.namespace, s.selector)
+	update(targets)
+}
+
+// Returns the slice's port matching the configured name or number
+func (s *EndpointSliceSource) slicePort(slice endpointSlice) (int, bool) {
+	for _, port := range slice.Ports {
+		if port.Port == nil {
+			continue
+		}
+		if s.port == "" || s.port == port.Name || s.port == strconv.Itoa(*port.Port) {
+			return *port.Port, true
+		}
+	}
+	return 0, false
+}
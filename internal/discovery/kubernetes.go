@@ -0,0 +1,109 @@
+package discovery
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	defaultTokenFile  = serviceAccountDir + "/token"
+	defaultCAFile     = serviceAccountDir + "/ca.crt"
+)
+
+// Client for the parts of the Kubernetes API used for discovery
+type Kubernetes struct {
+	apiServer string
+	tokenFile string
+	client    *http.Client // No timeout; watches are held open
+}
+
+// Connects with the pod's service account unless an API server is given
+func NewKubernetes(apiServer, tokenFile, caFile string, insecureSkipVerify bool) (*Kubernetes, error) {
+	if apiServer == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, errors.New("not running in a cluster and no kubernetes api_server configured")
+		}
+		apiServer = "https://" + net.JoinHostPort(host, port)
+		if tokenFile == "" {
+			tokenFile = defaultTokenFile
+		}
+		if caFile == "" {
+			caFile = defaultCAFile
+		}
+	}
+	if _, err := url.Parse(apiServer); err != nil {
+		return nil, fmt.Errorf("invalid kubernetes api_server: %w", err)
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecureSkipVerify}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubernetes CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in kubernetes CA file %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &Kubernetes{
+		apiServer: strings.TrimRight(apiServer, "/"),
+		tokenFile: tokenFile,
+		client:    &http.Client{Transport: transport},
+	}, nil
+}
+
+// Sends a GET, returning the response when it is 200 OK
+func (k *Kubernetes) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.apiServer+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	// Projected service account tokens rotate, so read it for every request
+	if k.tokenFile != "" {
+		token, err := os.ReadFile(k.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubernetes token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach kubernetes api: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, &statusError{code: resp.StatusCode, message: strings.TrimSpace(string(message))}
+	}
+
+	return resp, nil
+}
+
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("kubernetes api returned status %d: %s", e.code, e.message)
+}
@@ -0,0 +1,162 @@
+package discovery
+
+import (
+	"context"
+	"log"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Target URL schemes resolved through SRV records, and the scheme of the targets they produce
+var srvSchemes = map[string]string{
+	"srv://":       "http",
+	"srv+https://": "https",
+}
+
+// Reports whether a target is resolved through DNS SRV records
+func IsSRVTarget(target string) bool {
+	_, _, ok := parseSRVTarget(target)
+	return ok
+}
+
+// Splits srv://name into the record name and the scheme for its targets
+func parseSRVTarget(target string) (name, scheme string, ok bool) {
+	for prefix, scheme := range srvSchemes {
+		if name, found := strings.CutPrefix(target, prefix); found && name != "" {
+			return strings.TrimRight(name, "/"), scheme, true
+		}
+	}
+	return "", "", false
+}
+
+// Resolves srv:// targets on an interval, passing other targets through.
+// Only the records with the lowest priority are used, so higher priorities
+// act as standbys; weights are left to the load balancer.
+type SRVSource struct {
+	static   []string
+	names    []string
+	schemes  map[string]string
+	interval time.Duration
+	resolver *net.Resolver
+
+	resolved map[string][]string // Last successful lookup per name
+	targets  []string
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+}
+
+// server is a DNS server address such as 127.0.0.1:8600; empty uses the system resolver
+func NewSRVSource(targets []string, interval time.Duration, server string) *SRVSource {
+	s := &SRVSource{
+		schemes:  make(map[string]string),
+		resolved: make(map[string][]string),
+		interval: interval,
+		resolver: net.DefaultResolver,
+		stopChan: make(chan struct{}),
+	}
+	for _, target := range targets {
+		if name, scheme, ok := parseSRVTarget(target); ok {
+			s.names = append(s.names, name)
+			s.schemes[name] = scheme
+		} else {
+			s.static = append(s.static, target)
+		}
+	}
+
+	if server != "" {
+		s.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	return s
+}
+
+// Returns the targets usable before the first resolution
+func (s *SRVSource) StaticTargets() []string {
+	return s.static
+}
+
+func (s *SRVSource) Watch(update func(targets []string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return
+	}
+	s.running = true
+
+	go func() {
+		s.resolve(update)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.resolve(update)
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+func (s *SRVSource) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		close(s.stopChan)
+		s.running = false
+	}
+}
+
+// Looks up every SRV name and calls update if the targets changed.
+// A name that fails to resolve keeps its previous targets.
+func (s *SRVSource) resolve(update func(targets []string)) {
+	targets := append([]string(nil), s.static...)
+
+	for _, name := range s.names {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, records, err := s.resolver.LookupSRV(ctx, "", "", name)
+		cancel()
+
+		if err != nil {
+			log.Printf("Failed to resolve SRV records for %s, keeping previous targets: %v", name, err)
+			targets = append(targets, s.resolved[name]...)
+			continue
+		}
+
+		var resolved []string
+		for _, record := range records {
+			if record.Priority != records[0].Priority {
+				break // Sorted by priority
+			}
+			host := strings.TrimSuffix(record.Target, ".")
+			resolved = append(resolved, s.schemes[name]+"://"+net.JoinHostPort(host, strconv.Itoa(int(record.Port))))
+		}
+		s.resolved[name] = resolved
+		targets = append(targets, resolved...)
+	}
+	slices.Sort(targets)
+	targets = slices.Compact(targets)
+
+	if s.targets != nil && slices.Equal(targets, s.targets) {
+		return
+	}
+	s.targets = targets
+
+	log.Printf("Resolved %d targets from SRV records %s", len(targets), strings.Join(s.names, ", "))
+	update(targets)
+}
@@ -0,0 +1,271 @@
+package faults
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/audit"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Redis hash holding fault rules shared by all replicas
+const redisKey = "gateway:faults"
+
+// Header a request must carry for fault rules to apply to it, unless the rule names another
+const DefaultHeader = "X-Gateway-Fault-Test"
+
+const (
+	DefaultTTL = time.Hour
+	MaxTTL     = 24 * time.Hour
+	MaxDelay   = 5 * time.Minute
+)
+
+var (
+	ErrNotFound    = errors.New("fault rule not found")
+	ErrInvalidRule = errors.New("invalid fault rule")
+)
+
+// Injects latency and errors into a share of a service's test requests
+type Rule struct {
+	ID           string    `json:"id"`
+	Service      string    `json:"service"` // Service path
+	Description  string    `json:"description,omitempty"`
+	Header       string    `json:"header"`          // Default: X-Gateway-Fault-Test
+	Value        string    `json:"value,omitempty"` // Empty matches any value of the header
+	DelayMs      int       `json:"delay_ms,omitempty"`
+	DelayPercent float64   `json:"delay_percent,omitempty"` // Share of test requests delayed, 0-100
+	ErrorStatus  int       `json:"error_status,omitempty"`  // Default: 503
+	ErrorPercent float64   `json:"error_percent,omitempty"` // Share of test requests failed, 0-100
+	Enabled      bool      `json:"enabled"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Reports whether the rule has passed its expiry
+func (r *Rule) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// Reports whether a request carries the rule's test header
+func (r *Rule) Matches(req *http.Request) bool {
+	value := req.Header.Get(r.Header)
+	return value != "" && (r.Value == "" || value == r.Value)
+}
+
+// Rolls the dice for a request: the latency to add and the status to fail
+// with, where zero means none
+func (r *Rule) Decide() (time.Duration, int) {
+	var delay time.Duration
+	if r.DelayMs > 0 && rand.Float64()*100 < r.DelayPercent {
+		delay = time.Duration(r.DelayMs) * time.Millisecond
+	}
+
+	status := 0
+	if rand.Float64()*100 < r.ErrorPercent {
+		status = r.ErrorStatus
+	}
+	return delay, status
+}
+
+func (r *Rule) validate() error {
+	if !strings.HasPrefix(r.Service, "/") {
+		return fmt.Errorf("service must be a service path")
+	}
+	if r.Header == "" {
+		r.Header = DefaultHeader
+	}
+	if r.DelayMs < 0 || time.Duration(r.DelayMs)*time.Millisecond > MaxDelay {
+		return fmt.Errorf("delay_ms must be between 0 and %d", MaxDelay.Milliseconds())
+	}
+	if r.DelayPercent < 0 || r.DelayPercent > 100 || r.ErrorPercent < 0 || r.ErrorPercent > 100 {
+		return fmt.Errorf("percentages must be between 0 and 100")
+	}
+	if r.ErrorStatus == 0 {
+		r.ErrorStatus = http.StatusServiceUnavailable
+	}
+	if r.ErrorStatus < 400 || r.ErrorStatus > 599 {
+		return fmt.Errorf("invalid error status: %d", r.ErrorStatus)
+	}
+	if r.DelayPercent == 0 && r.ErrorPercent == 0 {
+		return fmt.Errorf("rule injects nothing: set delay_percent or error_percent")
+	}
+	return nil
+}
+
+// Holds fault rules and keeps them in sync with other replicas
+type Registry struct {
+	mu       sync.RWMutex
+	rules    map[string]*Rule
+	redis    *storage.RedisClient
+	hashSync *storage.HashSync
+}
+
+func NewRegistry(redis *storage.RedisClient, interval time.Duration) *Registry {
+	r := &Registry{
+		rules: make(map[string]*Rule),
+		redis: redis,
+	}
+	r.hashSync = storage.NewHashSync(redis, redisKey, "fault rules", interval, r.apply)
+	return r
+}
+
+// Validates and stores a rule that expires after ttl (DefaultTTL when zero)
+func (r *Registry) Add(ctx context.Context, rule Rule, ttl time.Duration) (*Rule, error) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if ttl > MaxTTL {
+		return nil, fmt.Errorf("%w: ttl may not exceed %s", ErrInvalidRule, MaxTTL)
+	}
+	if err := rule.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+
+	rule.ID = uuid.New().String()
+	rule.CreatedAt = time.Now()
+	rule.ExpiresAt = rule.CreatedAt.Add(ttl)
+
+	if err := r.persist(ctx, &rule); err != nil {
+		return nil, err
+	}
+
+	audit.Record(ctx, "fault.create", "fault", rule.ID, nil, &rule)
+
+	log.Printf("Fault rule %s added for %s (enabled: %t, expires %s)", rule.ID, rule.Service, rule.Enabled, rule.ExpiresAt.Format(time.RFC3339))
+	return &rule, nil
+}
+
+// Switches a rule on or off without removing it
+func (r *Registry) SetEnabled(ctx context.Context, id string, enabled bool) (*Rule, error) {
+	r.mu.RLock()
+	existing, exists := r.rules[id]
+	r.mu.RUnlock()
+
+	if !exists || existing.Expired(time.Now()) {
+		return nil, ErrNotFound
+	}
+
+	rule := *existing
+	rule.Enabled = enabled
+	if err := r.persist(ctx, &rule); err != nil {
+		return nil, err
+	}
+
+	audit.Record(ctx, "fault.update", "fault", id, existing, &rule)
+	return &rule, nil
+}
+
+// Deletes a rule before it expires
+func (r *Registry) Remove(ctx context.Context, id string) error {
+	r.mu.Lock()
+	rule, exists := r.rules[id]
+	delete(r.rules, id)
+	r.mu.Unlock()
+
+	if !exists {
+		return ErrNotFound
+	}
+	if err := r.redis.HDel(ctx, redisKey, id); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, "fault.delete", "fault", id, rule, nil)
+	return nil
+}
+
+// Returns the live rules, newest first
+func (r *Registry) List() []*Rule {
+	now := time.Now()
+
+	r.mu.RLock()
+	rules := make([]*Rule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		if !rule.Expired(now) {
+			rules = append(rules, rule)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].CreatedAt.After(rules[j].CreatedAt)
+	})
+	return rules
+}
+
+// Returns the enabled live rules of a service that apply to a request
+func (r *Registry) Matching(service string, req *http.Request) []*Rule {
+	now := time.Now()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matching []*Rule
+	for _, rule := range r.rules {
+		if rule.Enabled && rule.Service == service && !rule.Expired(now) && rule.Matches(req) {
+			matching = append(matching, rule)
+		}
+	}
+	return matching
+}
+
+func (r *Registry) persist(ctx context.Context, rule *Rule) error {
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+	if err := r.redis.HSet(ctx, redisKey, rule.ID, data); err != nil {
+		return fmt.Errorf("failed to persist fault rule: %w", err)
+	}
+
+	r.mu.Lock()
+	r.rules[rule.ID] = rule
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Loads persisted rules and keeps them in sync with other replicas
+func (r *Registry) Start() {
+	r.hashSync.Start()
+}
+
+// Stops syncing rules
+func (r *Registry) Stop() {
+	r.hashSync.Stop()
+}
+
+// Replaces the rules with those persisted in Redis, returning expired ones to delete
+func (r *Registry) apply(persisted map[string]string) []string {
+	now := time.Now()
+	rules := make(map[string]*Rule, len(persisted))
+	var expired []string
+
+	for id, data := range persisted {
+		var rule Rule
+		if err := json.Unmarshal([]byte(data), &rule); err != nil {
+			log.Printf("Skipping malformed fault rule %s: %v", id, err)
+			continue
+		}
+		if rule.Expired(now) {
+			expired = append(expired, id)
+			continue
+		}
+		rules[id] = &rule
+	}
+
+	r.mu.Lock()
+	r.rules = rules
+	r.mu.Unlock()
+
+	return expired
+}
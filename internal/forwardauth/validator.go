@@ -0,0 +1,85 @@
+package forwardauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Returned when a consumer token fails validation
+var ErrInvalidToken = errors.New("invalid token")
+
+// Signing algorithms accepted when none are configured
+var DefaultAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384", "EdDSA"}
+
+// Settings for validating end-user tokens issued by an external identity provider
+type Config struct {
+	Issuer     string
+	Audience   string // Optional
+	JWKSURL    string
+	Algorithms []string      // Default: DefaultAlgorithms
+	Leeway     time.Duration // Clock skew tolerated on exp, nbf and iat
+}
+
+// Validates consumer JWTs against an issuer's published keys
+type Validator struct {
+	cfg  Config
+	keys *RemoteKeySet
+}
+
+func NewValidator(cfg Config) *Validator {
+	if len(cfg.Algorithms) == 0 {
+		cfg.Algorithms = DefaultAlgorithms
+	}
+
+	return &Validator{
+		cfg:  cfg,
+		keys: NewRemoteKeySet(cfg.JWKSURL),
+	}
+}
+
+// Verifies the token's signature, issuer, audience and lifetime and returns its claims
+func (v *Validator) Validate(ctx context.Context, rawToken string) (jwt.MapClaims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(v.cfg.Algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(v.cfg.Leeway),
+	}
+	if v.cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.cfg.Issuer))
+	}
+	if v.cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(v.cfg.Audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return claims, nil
+}
+
+// Returns a claim by name, following dots into nested objects (e.g. "realm_access.roles")
+func Claim(claims jwt.MapClaims, name string) (interface{}, bool) {
+	var current interface{} = map[string]interface{}(claims)
+	for _, part := range strings.Split(name, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = object[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,381 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"os"
+)
+
+// Marks the start of the metadata at the end of a MaxMind DB file
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// Data section types from the MaxMind DB format
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// Looks up countries in a MaxMind DB file, such as GeoLite2-Country or
+// GeoIP2-City. The whole file is held in memory.
+type Reader struct {
+	buf        []byte
+	data       []byte // Data section
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint // Node IPv4 lookups start at in an IPv6 tree
+}
+
+// Loads a MaxMind DB file
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return newReader(buf)
+}
+
+func newReader(buf []byte) (*Reader, error) {
+	start := bytes.LastIndex(buf, metadataMarker)
+	if start < 0 {
+		return nil, errors.New("not a MaxMind DB file")
+	}
+
+	meta := decoder{buf: buf[start+len(metadataMarker):]}
+	value, _, err := meta.decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata: %w", err)
+	}
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid metadata")
+	}
+
+	r := &Reader{buf: buf}
+	for name, dst := range map[string]*uint{"node_count": &r.nodeCount, "record_size": &r.recordSize, "ip_version": &r.ipVersion} {
+		n, ok := fields[name].(uint64)
+		if !ok {
+			return nil, fmt.Errorf("metadata is missing %s", name)
+		}
+		*dst = uint(n)
+	}
+	if r.recordSize != 24 && r.recordSize != 28 && r.recordSize != 32 {
+		return nil, fmt.Errorf("unsupported record size %d", r.recordSize)
+	}
+
+	treeSize := r.nodeCount * r.recordSize / 4
+	if treeSize+16 > uint(start) {
+		return nil, errors.New("search tree is larger than the file")
+	}
+	r.data = buf[treeSize+16 : start]
+
+	if r.ipVersion == 6 {
+		for i := 0; i < 96 && r.ipv4Start < r.nodeCount; i++ {
+			r.ipv4Start = r.record(r.ipv4Start, 0)
+		}
+	}
+	return r, nil
+}
+
+// Returns the ISO 3166-1 country code for addr, falling back to the country
+// the network is registered in. Empty when the database has no country.
+func (r *Reader) Country(addr netip.Addr) (string, error) {
+	offset, found, err := r.find(addr)
+	if err != nil || !found {
+		return "", err
+	}
+
+	d := decoder{buf: r.data}
+	for _, field := range []string{"country", "registered_country"} {
+		value, err := d.path(offset, field, "iso_code")
+		if err != nil {
+			return "", err
+		}
+		if code, ok := value.(string); ok && code != "" {
+			return code, nil
+		}
+	}
+	return "", nil
+}
+
+// Walks the search tree to the data record for addr
+func (r *Reader) find(addr netip.Addr) (uint, bool, error) {
+	addr = addr.Unmap()
+	if !addr.IsValid() {
+		return 0, false, nil
+	}
+	if addr.Is6() && r.ipVersion == 4 {
+		return 0, false, nil
+	}
+
+	node := uint(0)
+	if addr.Is4() && r.ipVersion == 6 {
+		node = r.ipv4Start
+	}
+
+	ip := addr.AsSlice()
+	for i := 0; i < len(ip)*8 && node < r.nodeCount; i++ {
+		bit := uint(ip[i/8]>>(7-i%8)) & 1
+		node = r.record(node, bit)
+	}
+
+	switch {
+	case node == r.nodeCount:
+		return 0, false, nil
+	case node < r.nodeCount:
+		return 0, false, errors.New("search tree is deeper than the address")
+	}
+
+	offset := node - r.nodeCount - 16
+	if offset >= uint(len(r.data)) {
+		return 0, false, errors.New("search tree points past the data section")
+	}
+	return offset, true, nil
+}
+
+// Reads the left (bit 0) or right (bit 1) record of a node
+func (r *Reader) record(node, bit uint) uint {
+	b := r.buf[node*r.recordSize/4:]
+	switch r.recordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:]))
+	}
+}
+
+// Decodes values from a data section
+type decoder struct {
+	buf []byte
+}
+
+// Follows map keys from the value at offset, returning nil when one is missing
+func (d *decoder) path(offset uint, keys ...string) (interface{}, error) {
+	for i, key := range keys {
+		typ, size, next, err := d.resolve(offset)
+		if err != nil {
+			return nil, err
+		}
+		if typ != typeMap {
+			return nil, nil
+		}
+
+		found := false
+		for j := uint(0); j < size; j++ {
+			name, valueOffset, err := d.decode(next, 0)
+			if err != nil {
+				return nil, err
+			}
+			if name == key {
+				offset, found = valueOffset, true
+				break
+			}
+			if next, err = d.skip(valueOffset); err != nil {
+				return nil, err
+			}
+		}
+		if !found {
+			return nil, nil
+		}
+		if i == len(keys)-1 {
+			value, _, err := d.decode(offset, 0)
+			return value, err
+		}
+	}
+	return nil, nil
+}
+
+// Reads the control bytes at offset, following a pointer if there is one
+func (d *decoder) resolve(offset uint) (int, uint, uint, error) {
+	typ, size, next, err := d.control(offset)
+	if err != nil || typ != typePointer {
+		return typ, size, next, err
+	}
+	return d.control(size)
+}
+
+// Reads a value's type and size, returning the offset of its payload. For
+// pointers the size is the target offset.
+func (d *decoder) control(offset uint) (int, uint, uint, error) {
+	if offset >= uint(len(d.buf)) {
+		return 0, 0, 0, errors.New("offset past the end of the data")
+	}
+	ctrl := d.buf[offset]
+	offset++
+
+	typ := int(ctrl >> 5)
+	if typ == typePointer {
+		n := uint(ctrl>>3)&0x3 + 1
+		if offset+n > uint(len(d.buf)) {
+			return 0, 0, 0, errors.New("truncated pointer")
+		}
+		var target uint
+		if n < 4 {
+			target = uint(ctrl & 0x7)
+		}
+		for _, b := range d.buf[offset : offset+n] {
+			target = target<<8 | uint(b)
+		}
+		target += []uint{0, 2048, 526336, 0}[n-1]
+		return typePointer, target, offset + n, nil
+	}
+
+	if typ == typeExtended {
+		if offset >= uint(len(d.buf)) {
+			return 0, 0, 0, errors.New("truncated type")
+		}
+		typ = 7 + int(d.buf[offset])
+		offset++
+	}
+
+	size := uint(ctrl & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		if offset+n > uint(len(d.buf)) {
+			return 0, 0, 0, errors.New("truncated size")
+		}
+		extra := uint(0)
+		for _, b := range d.buf[offset : offset+n] {
+			extra = extra<<8 | uint(b)
+		}
+		size = []uint{29, 285, 65821}[n-1] + extra
+		offset += n
+	}
+	return typ, size, offset, nil
+}
+
+// Decodes the value at offset and returns the offset after it
+func (d *decoder) decode(offset uint, depth int) (interface{}, uint, error) {
+	if depth > 32 {
+		return nil, 0, errors.New("data nested too deeply")
+	}
+
+	typ, size, next, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if typ == typePointer {
+		value, _, err := d.decode(size, depth+1)
+		return value, next, err
+	}
+
+	switch typ {
+	case typeMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			key, afterKey, err := d.decode(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, errors.New("map key is not a string")
+			}
+			if m[name], next, err = d.decode(afterKey, depth+1); err != nil {
+				return nil, 0, err
+			}
+		}
+		return m, next, nil
+	case typeArray:
+		a := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			var value interface{}
+			if value, next, err = d.decode(next, depth+1); err != nil {
+				return nil, 0, err
+			}
+			a = append(a, value)
+		}
+		return a, next, nil
+	case typeBool:
+		return size != 0, next, nil
+	case typeContainer, typeEndMarker:
+		return nil, next, nil
+	}
+
+	if next+size > uint(len(d.buf)) {
+		return nil, 0, errors.New("value past the end of the data")
+	}
+	payload := d.buf[next : next+size]
+	next += size
+
+	switch typ {
+	case typeString:
+		return string(payload), next, nil
+	case typeBytes:
+		return append([]byte(nil), payload...), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, errors.New("invalid double")
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(payload)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, errors.New("invalid float")
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(payload))), next, nil
+	case typeUint16, typeUint32, typeUint64, typeInt32:
+		if size > 8 {
+			return nil, 0, errors.New("invalid integer")
+		}
+		n := uint64(0)
+		for _, b := range payload {
+			n = n<<8 | uint64(b)
+		}
+		if typ == typeInt32 {
+			return int64(int32(n)), next, nil
+		}
+		return n, next, nil
+	case typeUint128:
+		return append([]byte(nil), payload...), next, nil
+	}
+	return nil, 0, fmt.Errorf("unknown data type %d", typ)
+}
+
+// Returns the offset after the value at offset without decoding it
+func (d *decoder) skip(offset uint) (uint, error) {
+	typ, size, next, err := d.control(offset)
+	if err != nil {
+		return 0, err
+	}
+
+	switch typ {
+	case typePointer, typeBool, typeContainer, typeEndMarker:
+		return next, nil
+	case typeMap, typeArray:
+		count := size
+		if typ == typeMap {
+			count *= 2
+		}
+		for i := uint(0); i < count; i++ {
+			if next, err = d.skip(next); err != nil {
+				return 0, err
+			}
+		}
+		return next, nil
+	}
+	return next + size, nil
+}
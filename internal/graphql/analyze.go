@@ -0,0 +1,367 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Returned by Analyze when a document has several operations and no name picks one
+var ErrOperationRequired = errors.New("operation name is required when the document has several operations")
+
+// What a request asks for, as far as limits and metrics need to know
+type Analysis struct {
+	OperationType string // "query", "mutation" or "subscription"
+	OperationName string // Empty for anonymous operations
+	Depth         int    // Deepest field nesting, with fragments expanded
+	Complexity    int    // Fields selected, with fragments expanded
+	Introspection bool   // Selects __schema or __type
+}
+
+type selection struct {
+	field    string      // Set for fields
+	spread   string      // Set for fragment spreads
+	children []selection // Field subselections and inline fragment selections
+}
+
+type operation struct {
+	kind       string
+	name       string
+	selections []selection
+}
+
+type document struct {
+	operations []operation
+	fragments  map[string][]selection
+}
+
+// Parses a query and measures the operation it runs: the one named
+// operationName, or the only one in the document
+func Analyze(query, operationName string) (*Analysis, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	doc, err := p.document()
+	if err != nil {
+		return nil, err
+	}
+
+	var op *operation
+	for i := range doc.operations {
+		if operationName == "" && len(doc.operations) == 1 || doc.operations[i].name == operationName {
+			op = &doc.operations[i]
+			break
+		}
+	}
+	if op == nil {
+		if operationName == "" {
+			return nil, ErrOperationRequired
+		}
+		return nil, fmt.Errorf("unknown operation %q", operationName)
+	}
+
+	m := &measurer{fragments: doc.fragments, depths: make(map[string]int), counts: make(map[string]int), visiting: make(map[string]bool)}
+	depth, complexity, err := m.measure(op.selections)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Analysis{
+		OperationType: op.kind,
+		OperationName: op.name,
+		Depth:         depth,
+		Complexity:    complexity,
+		Introspection: m.introspection,
+	}, nil
+}
+
+// Walks selections with fragments expanded. Fragment results are memoized,
+// so documents that reuse fragments heavily stay cheap to measure.
+type measurer struct {
+	fragments     map[string][]selection
+	depths        map[string]int
+	counts        map[string]int
+	visiting      map[string]bool
+	introspection bool
+}
+
+func (m *measurer) measure(selections []selection) (int, int, error) {
+	depth, complexity := 0, 0
+	for _, sel := range selections {
+		var d, c int
+		switch {
+		case sel.field != "":
+			if sel.field == "__schema" || sel.field == "__type" {
+				m.introspection = true
+			}
+			childDepth, childCount, err := m.measure(sel.children)
+			if err != nil {
+				return 0, 0, err
+			}
+			d, c = 1+childDepth, 1+childCount
+		case sel.spread != "":
+			var err error
+			if d, c, err = m.fragment(sel.spread); err != nil {
+				return 0, 0, err
+			}
+		default:
+			var err error
+			if d, c, err = m.measure(sel.children); err != nil {
+				return 0, 0, err
+			}
+		}
+		depth = max(depth, d)
+		complexity += c
+	}
+	return depth, complexity, nil
+}
+
+func (m *measurer) fragment(name string) (int, int, error) {
+	if depth, seen := m.depths[name]; seen {
+		return depth, m.counts[name], nil
+	}
+	selections, exists := m.fragments[name]
+	if !exists {
+		return 0, 0, fmt.Errorf("unknown fragment %q", name)
+	}
+	if m.visiting[name] {
+		return 0, 0, fmt.Errorf("fragment %q spreads itself", name)
+	}
+
+	m.visiting[name] = true
+	depth, complexity, err := m.measure(selections)
+	delete(m.visiting, name)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	m.depths[name], m.counts[name] = depth, complexity
+	return depth, complexity, nil
+}
+
+// Recursive descent over executable definitions. Arguments, variable
+// definitions and directives are checked for balance and otherwise skipped.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isPunct(value string) bool {
+	tok := p.peek()
+	return tok.kind == tokenPunct && tok.value == value
+}
+
+func (p *parser) expectPunct(value string) error {
+	tok := p.next()
+	if tok.kind != tokenPunct || tok.value != value {
+		return p.unexpected(tok, fmt.Sprintf("%q", value))
+	}
+	return nil
+}
+
+func (p *parser) expectName() (string, error) {
+	tok := p.next()
+	if tok.kind != tokenName {
+		return "", p.unexpected(tok, "a name")
+	}
+	return tok.value, nil
+}
+
+func (p *parser) unexpected(tok token, want string) error {
+	if tok.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of document, expected %s", want)
+	}
+	return fmt.Errorf("unexpected %q at %d, expected %s", tok.value, tok.pos, want)
+}
+
+func (p *parser) document() (*document, error) {
+	doc := &document{fragments: make(map[string][]selection)}
+	for p.peek().kind != tokenEOF {
+		if p.isPunct("{") {
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, operation{kind: "query", selections: selections})
+			continue
+		}
+
+		keyword, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		switch keyword {
+		case "query", "mutation", "subscription":
+			op := operation{kind: keyword}
+			if p.peek().kind == tokenName {
+				op.name = p.next().value
+			}
+			if p.isPunct("(") {
+				if err := p.skipBalanced("(", ")"); err != nil {
+					return nil, err
+				}
+			}
+			if err := p.directives(); err != nil {
+				return nil, err
+			}
+			if op.selections, err = p.selectionSet(); err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case "fragment":
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := doc.fragments[name]; exists {
+				return nil, fmt.Errorf("fragment %q is defined twice", name)
+			}
+			if on, err := p.expectName(); err != nil || on != "on" {
+				return nil, fmt.Errorf("fragment %q needs a type condition", name)
+			}
+			if _, err := p.expectName(); err != nil {
+				return nil, err
+			}
+			if err := p.directives(); err != nil {
+				return nil, err
+			}
+			if doc.fragments[name], err = p.selectionSet(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported definition %q", keyword)
+		}
+	}
+
+	if len(doc.operations) == 0 {
+		return nil, errors.New("document has no operations")
+	}
+	return doc, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+
+	var selections []selection
+	for !p.isPunct("}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	p.next()
+
+	if len(selections) == 0 {
+		return nil, errors.New("selection sets must not be empty")
+	}
+	return selections, nil
+}
+
+func (p *parser) selection() (selection, error) {
+	if p.isPunct("...") {
+		p.next()
+		if tok := p.peek(); tok.kind == tokenName && tok.value != "on" {
+			p.next()
+			return selection{spread: tok.value}, p.directives()
+		}
+		if p.peek().kind == tokenName {
+			p.next() // on
+			if _, err := p.expectName(); err != nil {
+				return selection{}, err
+			}
+		}
+		if err := p.directives(); err != nil {
+			return selection{}, err
+		}
+		children, err := p.selectionSet()
+		return selection{children: children}, err
+	}
+
+	name, err := p.expectName()
+	if err != nil {
+		return selection{}, err
+	}
+	if p.isPunct(":") {
+		p.next()
+		if name, err = p.expectName(); err != nil {
+			return selection{}, err
+		}
+	}
+	if p.isPunct("(") {
+		if err := p.skipBalanced("(", ")"); err != nil {
+			return selection{}, err
+		}
+	}
+	if err := p.directives(); err != nil {
+		return selection{}, err
+	}
+
+	sel := selection{field: name}
+	if p.isPunct("{") {
+		if sel.children, err = p.selectionSet(); err != nil {
+			return selection{}, err
+		}
+	}
+	return sel, nil
+}
+
+func (p *parser) directives() error {
+	for p.isPunct("@") {
+		p.next()
+		if _, err := p.expectName(); err != nil {
+			return err
+		}
+		if p.isPunct("(") {
+			if err := p.skipBalanced("(", ")"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Skips from an opening bracket to its match, checking nested brackets pair up
+func (p *parser) skipBalanced(open, close string) error {
+	if err := p.expectPunct(open); err != nil {
+		return err
+	}
+
+	closers := []string{close}
+	for len(closers) > 0 {
+		tok := p.next()
+		switch {
+		case tok.kind == tokenEOF:
+			return p.unexpected(tok, fmt.Sprintf("%q", closers[len(closers)-1]))
+		case tok.kind != tokenPunct:
+		case tok.value == "(":
+			closers = append(closers, ")")
+		case tok.value == "[":
+			closers = append(closers, "]")
+		case tok.value == "{":
+			closers = append(closers, "}")
+		case tok.value == ")" || tok.value == "]" || tok.value == "}":
+			if tok.value != closers[len(closers)-1] {
+				return p.unexpected(tok, fmt.Sprintf("%q", closers[len(closers)-1]))
+			}
+			closers = closers[:len(closers)-1]
+		}
+	}
+	return nil
+}
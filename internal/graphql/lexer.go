@@ -0,0 +1,95 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenNumber
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// Splits a GraphQL document into tokens, dropping whitespace, commas and comments
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(source) {
+		ch := source[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == ',':
+			i++
+		case ch == '#':
+			for i < len(source) && source[i] != '\n' && source[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(source[i:], "\uFEFF"):
+			i += len("\uFEFF")
+		case strings.HasPrefix(source[i:], "..."):
+			tokens = append(tokens, token{kind: tokenPunct, value: "...", pos: i})
+			i += 3
+		case strings.IndexByte("!$&():=@[]{}|", ch) >= 0:
+			tokens = append(tokens, token{kind: tokenPunct, value: string(ch), pos: i})
+			i++
+		case ch == '_' || isLetter(ch):
+			start := i
+			for i < len(source) && (source[i] == '_' || isLetter(source[i]) || isDigit(source[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenName, value: source[start:i], pos: start})
+		case ch == '-' || isDigit(ch):
+			start := i
+			i++
+			for i < len(source) && (isDigit(source[i]) || strings.IndexByte(".eE+-", source[i]) >= 0) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: source[start:i], pos: start})
+		case strings.HasPrefix(source[i:], `"""`):
+			end := strings.Index(strings.ReplaceAll(source[i+3:], `\"""`, "\x00\x00\x00\x00"), `"""`)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated block string at %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenString, value: source[i+3 : i+3+end], pos: i})
+			i += 3 + end + 3
+		case ch == '"':
+			start := i
+			i++
+			for i < len(source) && source[i] != '"' {
+				if source[i] == '\\' {
+					i++
+				}
+				if i < len(source) && (source[i] == '\n' || source[i] == '\r') {
+					return nil, fmt.Errorf("unterminated string at %d", start)
+				}
+				i++
+			}
+			if i >= len(source) {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, value: source[start+1 : i-1], pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", ch, i)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(source)}), nil
+}
+
+func isLetter(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
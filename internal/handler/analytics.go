@@ -1,10 +1,16 @@
 package handler
 
 import (
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/aman-churiwal/api-gateway/internal/models"
 	"github.com/aman-churiwal/api-gateway/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -19,6 +25,7 @@ func NewAnalyticsHandler(service *service.AnalyticsService) *AnalyticsHandler {
 }
 
 // Handles GET /admin/analytics
+// format=csv returns section,key,value rows
 func (h *AnalyticsHandler) GetSummary(c *gin.Context) {
 	// Parse time range
 	from, to, err := parseTimeRange(c)
@@ -34,9 +41,42 @@ func (h *AnalyticsHandler) GetSummary(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, summary)
+	if !wantsCSV(c) {
+		c.JSON(http.StatusOK, summary)
+		return
+	}
+
+	w := startCSV(c, "analytics-summary-"+from.UTC().Format("20060102")+".csv")
+	w.Write([]string{"section", "key", "value"})
+	for _, row := range [][2]string{
+		{"from", from.UTC().Format(time.RFC3339)},
+		{"to", to.UTC().Format(time.RFC3339)},
+		{"total_requests", strconv.FormatInt(summary.TotalRequests, 10)},
+		{"avg_response_time_ms", strconv.FormatFloat(summary.AvgResponseTime, 'f', 2, 64)},
+		{"p50_response_time_ms", strconv.Itoa(summary.P50ResponseTime)},
+		{"p95_response_time_ms", strconv.Itoa(summary.P95ResponseTime)},
+		{"p99_response_time_ms", strconv.Itoa(summary.P99ResponseTime)},
+		{"error_rate", strconv.FormatFloat(summary.ErrorRate, 'f', 2, 64)},
+		{"success_rate", strconv.FormatFloat(summary.SuccessRate, 'f', 2, 64)},
+		{"client_error_rate", strconv.FormatFloat(summary.ClientErrorRate, 'f', 2, 64)},
+		{"server_error_rate", strconv.FormatFloat(summary.ServerErrorRate, 'f', 2, 64)},
+	} {
+		w.Write([]string{"summary", row[0], row[1]})
+	}
+	for _, endpoint := range summary.TopEndpoints {
+		w.Write([]string{"top_endpoint", fmt.Sprint(endpoint["path"]), fmt.Sprint(endpoint["count"])})
+	}
+	if summary.Preflight != nil {
+		w.Write([]string{"preflight", "total_requests", strconv.FormatInt(summary.Preflight.TotalRequests, 10)})
+		for _, path := range summary.Preflight.TopPaths {
+			w.Write([]string{"preflight_path", fmt.Sprint(path["path"]), fmt.Sprint(path["count"])})
+		}
+	}
+	w.Flush()
 }
 
+// Handles GET /admin/analytics/timeseries
+// format=csv returns hour,count,avg_response_time_ms rows
 func (h *AnalyticsHandler) GetTimeSeries(c *gin.Context) {
 	// Parse time range
 	from, to, err := parseTimeRange(c)
@@ -52,7 +92,325 @@ func (h *AnalyticsHandler) GetTimeSeries(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, timeSeriesData)
+	if !wantsCSV(c) {
+		c.JSON(http.StatusOK, timeSeriesData)
+		return
+	}
+
+	w := startCSV(c, "analytics-timeseries-"+from.UTC().Format("20060102")+".csv")
+	w.Write([]string{"hour", "count", "avg_response_time_ms"})
+	for _, point := range timeSeriesData {
+		w.Write([]string{
+			formatTime(&point.Hour),
+			strconv.FormatInt(point.Count, 10),
+			strconv.FormatFloat(point.AvgResponseTime, 'f', 2, 64),
+		})
+	}
+	w.Flush()
+}
+
+// Handles GET /admin/analytics/status-codes
+func (h *AnalyticsHandler) GetStatusCodes(c *gin.Context) {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	distribution, err := h.service.GetStatusCodeDistribution(ctx, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, distribution)
+}
+
+// Handles GET /admin/analytics/latency-histogram
+// Accepts buckets=<ms>,<ms>,... with ascending upper bounds
+func (h *AnalyticsHandler) GetLatencyHistogram(c *gin.Context) {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bounds := service.DefaultLatencyBuckets
+	if bucketsStr := c.Query("buckets"); bucketsStr != "" {
+		bounds, err = parseBuckets(bucketsStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	histogram, err := h.service.GetLatencyHistogram(ctx, from, to, bounds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, histogram)
+}
+
+// Parses comma separated bucket bounds, which must be positive and ascending
+func parseBuckets(value string) ([]int, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) > 50 {
+		return nil, errors.New("at most 50 buckets are allowed")
+	}
+
+	bounds := make([]int, 0, len(parts))
+	for _, part := range parts {
+		bound, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || bound <= 0 {
+			return nil, errors.New("buckets must be positive integers in milliseconds")
+		}
+		if len(bounds) > 0 && bound <= bounds[len(bounds)-1] {
+			return nil, errors.New("buckets must be in ascending order")
+		}
+		bounds = append(bounds, bound)
+	}
+	return bounds, nil
+}
+
+// Handles GET /admin/analytics/top-keys
+// Accepts limit (default 10, max 100), sort=requests|errors|p95 and format=csv
+func (h *AnalyticsHandler) GetTopKeys(c *gin.Context) {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := 10
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	orderBy := c.DefaultQuery("sort", "requests")
+	if orderBy != "requests" && orderBy != "errors" && orderBy != "p95" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of requests, errors, p95"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	keys, err := h.service.GetTopKeys(ctx, from, to, orderBy, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !wantsCSV(c) {
+		c.JSON(http.StatusOK, gin.H{
+			"from": from,
+			"to":   to,
+			"sort": orderBy,
+			"keys": keys,
+		})
+		return
+	}
+
+	w := startCSV(c, "top-keys-"+from.UTC().Format("20060102")+".csv")
+	w.Write([]string{"api_key_id", "name", "tier", "owner", "requests", "error_rate", "client_error_rate", "server_error_rate", "avg_response_time_ms", "p95_response_time_ms"})
+	for _, key := range keys {
+		w.Write([]string{
+			key.APIKeyID.String(),
+			key.Name,
+			key.Tier,
+			key.Owner,
+			strconv.FormatInt(key.Requests, 10),
+			strconv.FormatFloat(key.ErrorRate, 'f', 2, 64),
+			strconv.FormatFloat(key.ClientErrorRate, 'f', 2, 64),
+			strconv.FormatFloat(key.ServerErrorRate, 'f', 2, 64),
+			strconv.FormatFloat(key.AvgResponseTime, 'f', 2, 64),
+			strconv.FormatFloat(key.P95ResponseTime, 'f', 2, 64),
+		})
+	}
+	w.Flush()
+}
+
+// Handles GET /admin/analytics/organizations
+// Usage rolled up per organization. Organization members only see their own.
+// Accepts format=csv
+func (h *AnalyticsHandler) GetOrganizationUsage(c *gin.Context) {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	orgs, err := h.service.GetOrganizationUsage(ctx, from, to, callerOrg(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !wantsCSV(c) {
+		c.JSON(http.StatusOK, gin.H{
+			"from":          from,
+			"to":            to,
+			"organizations": orgs,
+		})
+		return
+	}
+
+	w := startCSV(c, "organizations-"+from.UTC().Format("20060102")+".csv")
+	w.Write([]string{"org_id", "name", "api_keys", "requests", "error_rate", "client_error_rate", "server_error_rate", "bytes_in", "bytes_out"})
+	for _, org := range orgs {
+		w.Write([]string{
+			org.OrgID.String(),
+			org.Name,
+			strconv.Itoa(org.APIKeys),
+			strconv.FormatInt(org.Requests, 10),
+			strconv.FormatFloat(org.ErrorRate, 'f', 2, 64),
+			strconv.FormatFloat(org.ClientErrorRate, 'f', 2, 64),
+			strconv.FormatFloat(org.ServerErrorRate, 'f', 2, 64),
+			strconv.FormatInt(org.BytesIn, 10),
+			strconv.FormatInt(org.BytesOut, 10),
+		})
+	}
+	w.Flush()
+}
+
+// Handles GET /admin/analytics/countries
+// Traffic by client country. Accepts limit (default 10, max 100) and format=csv
+func (h *AnalyticsHandler) GetTopCountries(c *gin.Context) {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := 10
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	ctx := c.Request.Context()
+	countries, err := h.service.GetTopCountries(ctx, from, to, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !wantsCSV(c) {
+		c.JSON(http.StatusOK, gin.H{
+			"from":      from,
+			"to":        to,
+			"countries": countries,
+		})
+		return
+	}
+
+	w := startCSV(c, "countries-"+from.UTC().Format("20060102")+".csv")
+	w.Write([]string{"country", "requests", "error_rate", "client_error_rate", "server_error_rate", "avg_response_time_ms"})
+	for _, country := range countries {
+		w.Write([]string{
+			country.Country,
+			strconv.FormatInt(country.Requests, 10),
+			strconv.FormatFloat(country.ErrorRate, 'f', 2, 64),
+			strconv.FormatFloat(country.ClientErrorRate, 'f', 2, 64),
+			strconv.FormatFloat(country.ServerErrorRate, 'f', 2, 64),
+			strconv.FormatFloat(country.AvgResponseTime, 'f', 2, 64),
+		})
+	}
+	w.Flush()
+}
+
+// Handles GET /admin/analytics/versions
+// Traffic by API version of versioned services. Accepts format=csv
+func (h *AnalyticsHandler) GetVersionTraffic(c *gin.Context) {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	versions, err := h.service.GetVersionTraffic(c.Request.Context(), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !wantsCSV(c) {
+		c.JSON(http.StatusOK, gin.H{
+			"from":     from,
+			"to":       to,
+			"versions": versions,
+		})
+		return
+	}
+
+	w := startCSV(c, "versions-"+from.UTC().Format("20060102")+".csv")
+	w.Write([]string{"service", "version", "requests", "error_rate", "client_error_rate", "server_error_rate", "avg_response_time_ms"})
+	for _, version := range versions {
+		w.Write([]string{
+			version.Service,
+			version.Version,
+			strconv.FormatInt(version.Requests, 10),
+			strconv.FormatFloat(version.ErrorRate, 'f', 2, 64),
+			strconv.FormatFloat(version.ClientErrorRate, 'f', 2, 64),
+			strconv.FormatFloat(version.ServerErrorRate, 'f', 2, 64),
+			strconv.FormatFloat(version.AvgResponseTime, 'f', 2, 64),
+		})
+	}
+	w.Flush()
+}
+
+// Handles GET /admin/analytics/operations
+// Per-operation traffic of GraphQL services. Accepts limit (default 10, max 100) and format=csv
+func (h *AnalyticsHandler) GetTopOperations(c *gin.Context) {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := 10
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	ctx := c.Request.Context()
+	operations, err := h.service.GetTopOperations(ctx, from, to, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !wantsCSV(c) {
+		c.JSON(http.StatusOK, gin.H{
+			"from":       from,
+			"to":         to,
+			"operations": operations,
+		})
+		return
+	}
+
+	w := startCSV(c, "operations-"+from.UTC().Format("20060102")+".csv")
+	w.Write([]string{"path", "operation", "requests", "error_rate", "client_error_rate", "server_error_rate", "avg_response_time_ms"})
+	for _, op := range operations {
+		w.Write([]string{
+			op.Path,
+			op.Operation,
+			strconv.FormatInt(op.Requests, 10),
+			strconv.FormatFloat(op.ErrorRate, 'f', 2, 64),
+			strconv.FormatFloat(op.ClientErrorRate, 'f', 2, 64),
+			strconv.FormatFloat(op.ServerErrorRate, 'f', 2, 64),
+			strconv.FormatFloat(op.AvgResponseTime, 'f', 2, 64),
+		})
+	}
+	w.Flush()
 }
 
 // Handles GET /admin/analytics/keys/:id
@@ -72,6 +430,18 @@ func (h *AnalyticsHandler) GetAPIKeyStats(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
+	if orgID := callerOrg(c); orgID != nil {
+		owned, err := h.service.OrganizationOwnsKey(ctx, *orgID, apiKeyID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !owned {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+			return
+		}
+	}
+
 	stats, err := h.service.GetAPIKeyStats(ctx, apiKeyID, from, to)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -82,6 +452,7 @@ func (h *AnalyticsHandler) GetAPIKeyStats(c *gin.Context) {
 }
 
 // Handles GET /admin/logs
+// format=csv streams every matching log unless limit is given
 func (h *AnalyticsHandler) GetLogs(c *gin.Context) {
 	// Parse time range
 	from, to, err := parseTimeRange(c)
@@ -113,6 +484,14 @@ func (h *AnalyticsHandler) GetLogs(c *gin.Context) {
 		}
 	}
 
+	if wantsCSV(c) {
+		if c.Query("limit") == "" {
+			limit = 0
+		}
+		h.streamLogsCSV(c, from, to, statusCode, limit, offset)
+		return
+	}
+
 	ctx := c.Request.Context()
 	logs, err := h.service.GetLogs(ctx, from, to, statusCode, limit, offset)
 	if err != nil {
@@ -127,6 +506,64 @@ func (h *AnalyticsHandler) GetLogs(c *gin.Context) {
 	})
 }
 
+// Writes request logs as CSV while they are read from the database
+func (h *AnalyticsHandler) streamLogsCSV(c *gin.Context, from, to time.Time, statusCode *int, limit, offset int) {
+	header := []string{"timestamp", "api_key_id", "method", "path", "status_code", "response_time_ms", "ip_address", "user_agent", "backend_server", "is_preflight", "error_type", "error_message", "sample_rate"}
+
+	var w *csv.Writer
+	rows := 0
+	err := h.service.StreamLogs(c.Request.Context(), from, to, statusCode, limit, offset, func(log models.RequestLog) error {
+		// Headers wait for the first row so a failed query can still answer with JSON
+		if w == nil {
+			w = startCSV(c, "request-logs-"+from.UTC().Format("20060102")+".csv")
+			w.Write(header)
+		}
+
+		apiKeyID := ""
+		if log.APIKeyID != nil {
+			apiKeyID = log.APIKeyID.String()
+		}
+		w.Write([]string{
+			formatTime(&log.Timestamp),
+			apiKeyID,
+			log.Method,
+			log.Path,
+			strconv.Itoa(log.StatusCode),
+			strconv.Itoa(log.ResponseTimeMs),
+			log.IPAddress,
+			log.UserAgent,
+			log.BackendServer,
+			strconv.FormatBool(log.IsPreflight),
+			log.ErrorType,
+			log.ErrorMessage,
+			strconv.FormatFloat(log.SampleRate, 'g', -1, 64),
+		})
+
+		rows++
+		if rows%csvFlushRows == 0 {
+			w.Flush()
+			c.Writer.Flush()
+			return w.Error()
+		}
+		return nil
+	})
+
+	if w == nil {
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		w = startCSV(c, "request-logs-"+from.UTC().Format("20060102")+".csv")
+		w.Write(header)
+	}
+	w.Flush()
+
+	// The status is already sent, so a failure part way can only cut the file short
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("Request log export failed", "rows", rows, "error", err)
+	}
+}
+
 // Parses 'from' and 'to' query parameters
 func parseTimeRange(c *gin.Context) (time.Time, time.Time, error) {
 	// Default: last 24 hours
@@ -2,24 +2,56 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/repository"
 	"github.com/aman-churiwal/api-gateway/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type APIKeyHandler struct {
 	service *service.APIKeyService
+	orgs    *service.OrganizationService
 }
 
-func NewAPIKeyHandler(service *service.APIKeyService) *APIKeyHandler {
-	return &APIKeyHandler{service: service}
+func NewAPIKeyHandler(service *service.APIKeyService, orgs *service.OrganizationService) *APIKeyHandler {
+	return &APIKeyHandler{service: service, orgs: orgs}
+}
+
+// Answers 404 unless the key exists and the caller's organization owns it, so
+// organization members can't probe for other organizations' keys
+func (h *APIKeyHandler) ownedKey(c *gin.Context, id string) bool {
+	if callerOrg(c) == nil {
+		return true
+	}
+
+	apiKey, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if apiKey == nil || !canAccessOrg(c, apiKey.OrgID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return false
+	}
+	return true
 }
 
 func (h *APIKeyHandler) Create(c *gin.Context) {
 	var req struct {
-		Name      string `json:"name" binding:"required"`
-		CreatedBy string `json:"created_by"`
-		Tier      string `json:"tier" binding:"required"`
+		Name         string            `json:"name" binding:"required"`
+		CreatedBy    string            `json:"created_by"`
+		Tier         string            `json:"tier" binding:"required"`
+		Owner        string            `json:"owner" binding:"required"`
+		Team         string            `json:"team"`
+		ContactEmail string            `json:"contact_email" binding:"required,email"`
+		Notes        string            `json:"notes"`
+		Tags         []string          `json:"tags"`
+		Metadata     map[string]string `json:"metadata"`
+		OrgID        *uuid.UUID        `json:"org_id"` // Platform operators only; members create keys in their own organization
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -28,31 +60,122 @@ func (h *APIKeyHandler) Create(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
-	key, err := h.service.Create(ctx, req.Name, req.CreatedBy, req.Tier)
+	orgID := callerOrg(c)
+	if orgID == nil && req.OrgID != nil {
+		if _, err := h.orgs.Get(ctx, *req.OrgID); err != nil {
+			status := http.StatusInternalServerError
+			if err == service.ErrOrganizationNotFound {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		orgID = req.OrgID
+	}
+
+	owner := models.KeyOwner{Owner: req.Owner, Team: req.Team, ContactEmail: req.ContactEmail}
+	key, err := h.service.Create(ctx, req.Name, req.CreatedBy, req.Tier, owner, orgID, req.Notes, req.Tags, req.Metadata)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	resp := gin.H{
 		"key":     key,
 		"message": "Save this key - it won't be shown again",
-	})
+	}
+	if secret := h.service.SigningSecretForKey(key); secret != "" {
+		resp["signing_secret"] = secret
+	}
+	c.JSON(http.StatusCreated, resp)
 }
 
+// Handles GET /admin/keys
+// Supports filtering by tier, active, tag, created_by, owner, team, prefix, org_id, last_used_before and last_used_after,
+// sorting with sort=<column> and order=asc|desc, and pagination with limit and offset
 func (h *APIKeyHandler) List(c *gin.Context) {
+	filter := repository.APIKeyFilter{
+		Tier:      c.Query("tier"),
+		Tag:       c.Query("tag"),
+		CreatedBy: c.Query("created_by"),
+		Owner:     c.Query("owner"),
+		Team:      c.Query("team"),
+		Prefix:    c.Query("prefix"),
+		SortBy:    c.DefaultQuery("sort", "created_at"),
+		SortDesc:  c.DefaultQuery("order", "desc") == "desc",
+		Limit:     100,
+	}
+
+	if activeStr := c.Query("active"); activeStr != "" {
+		active, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "active must be true or false"})
+			return
+		}
+		filter.IsActive = &active
+	}
+
+	// Organization members only ever see their own organization's keys
+	filter.OrgID = callerOrg(c)
+	if orgStr := c.Query("org_id"); orgStr != "" && filter.OrgID == nil {
+		orgID, err := uuid.Parse(orgStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "org_id must be a UUID"})
+			return
+		}
+		filter.OrgID = &orgID
+	}
+
+	if beforeStr := c.Query("last_used_before"); beforeStr != "" {
+		before, err := time.Parse(time.RFC3339, beforeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "last_used_before must be an RFC3339 timestamp"})
+			return
+		}
+		filter.LastUsedBefore = &before
+	}
+
+	if afterStr := c.Query("last_used_after"); afterStr != "" {
+		after, err := time.Parse(time.RFC3339, afterStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "last_used_after must be an RFC3339 timestamp"})
+			return
+		}
+		filter.LastUsedAfter = &after
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 1000 {
+			filter.Limit = l
+		}
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			filter.Offset = o
+		}
+	}
+
 	ctx := c.Request.Context()
-	keys, err := h.service.List(ctx)
+	keys, total, err := h.service.ListFiltered(ctx, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Pagination details go in headers to keep the response a plain list
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.Header("X-Limit", strconv.Itoa(filter.Limit))
+	c.Header("X-Offset", strconv.Itoa(filter.Offset))
+
 	c.JSON(http.StatusOK, keys)
 }
 
 func (h *APIKeyHandler) Get(c *gin.Context) {
 	id := c.Param("id")
+	if !h.ownedKey(c, id) {
+		return
+	}
 
 	ctx := c.Request.Context()
 	apiKey, err := h.service.Get(ctx, id)
@@ -73,8 +196,11 @@ func (h *APIKeyHandler) Update(c *gin.Context) {
 	id := c.Param("id")
 
 	var req struct {
-		Tier     *string `json:"tier"`
-		IsActive *bool   `json:"is_active"`
+		Tier     *string            `json:"tier"`
+		IsActive *bool              `json:"is_active"`
+		Tags     *[]string          `json:"tags"`
+		Metadata *map[string]string `json:"metadata"`
+		Notes    *string            `json:"notes"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -90,12 +216,25 @@ func (h *APIKeyHandler) Update(c *gin.Context) {
 	if req.IsActive != nil {
 		updates["is_active"] = *req.IsActive
 	}
+	if req.Tags != nil {
+		updates["tags"] = models.StringList(*req.Tags)
+	}
+	if req.Metadata != nil {
+		updates["metadata"] = models.StringMap(*req.Metadata)
+	}
+	if req.Notes != nil {
+		updates["notes"] = *req.Notes
+	}
 
 	if len(updates) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
 		return
 	}
 
+	if !h.ownedKey(c, id) {
+		return
+	}
+
 	ctx := c.Request.Context()
 	if err := h.service.Update(ctx, id, updates); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -105,8 +244,99 @@ func (h *APIKeyHandler) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "API key updated successfully"})
 }
 
+// Handles POST /admin/keys/:id/rotate
+func (h *APIKeyHandler) Rotate(c *gin.Context) {
+	id := c.Param("id")
+	if !h.ownedKey(c, id) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	key, err := h.service.Rotate(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if key == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+
+	resp := gin.H{
+		"key":     key,
+		"message": "Save this key - it won't be shown again. The previous key no longer works",
+	}
+	if secret := h.service.SigningSecretForKey(key); secret != "" {
+		resp["signing_secret"] = secret
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Handles GET /admin/keys/unowned
+func (h *APIKeyHandler) Unowned(c *gin.Context) {
+	ctx := c.Request.Context()
+	keys, err := h.service.ListUnowned(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys":  keys,
+		"total": len(keys),
+	})
+}
+
+// Returns the key validation degradation policy and how often it triggered
+func (h *APIKeyHandler) Degradation(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.DegradationStats())
+}
+
+// Handles POST /admin/keys/:id/transfer
+func (h *APIKeyHandler) Transfer(c *gin.Context) {
+	var req struct {
+		Owner        string `json:"owner" binding:"required"`
+		Team         string `json:"team"`
+		ContactEmail string `json:"contact_email" binding:"required,email"`
+		Reason       string `json:"reason"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	to := models.KeyOwner{Owner: req.Owner, Team: req.Team, ContactEmail: req.ContactEmail}
+	by := c.GetString("email")
+	if by == "" {
+		by = "unknown"
+	}
+
+	if !h.ownedKey(c, c.Param("id")) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	apiKey, err := h.service.Transfer(ctx, c.Param("id"), to, by, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if apiKey == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, apiKey)
+}
+
 func (h *APIKeyHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
+	if !h.ownedKey(c, id) {
+		return
+	}
 
 	ctx := c.Request.Context()
 	if err := h.service.Delete(ctx, id); err != nil {
@@ -1,18 +1,24 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 
 	"github.com/aman-churiwal/api-gateway/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
 	service *service.AuthService
+	orgs    *service.OrganizationService
 }
 
-func NewAuthHandler(service *service.AuthService) *AuthHandler {
-	return &AuthHandler{service: service}
+func NewAuthHandler(service *service.AuthService, orgs *service.OrganizationService) *AuthHandler {
+	return &AuthHandler{service: service, orgs: orgs}
 }
 
 // handles POST /auth/register
@@ -21,6 +27,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Email    string `json:"email" binding:"required,email"`
 		Password string `json:"password" binding:"required,min=8"`
 		Name     string `json:"name" binding:"required"`
+		// Required once the first admin exists when registration is invite-only
+		InvitationToken string `json:"invitation_token"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -29,7 +37,12 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
-	if err := h.service.Register(ctx, req.Email, req.Password, req.Name); err != nil {
+	err := h.service.Register(ctx, req.Email, req.Password, req.Name, req.InvitationToken)
+	if errors.Is(err, service.ErrRegistrationClosed) || errors.Is(err, service.ErrInvalidInvitation) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -52,15 +65,173 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
-	token, err := h.service.Login(ctx, req.Email, req.Password)
+	tokens, err := h.service.Login(ctx, req.Email, req.Password, c.ClientIP())
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		c.Header("Retry-After", fmt.Sprintf("%d", int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"type":  "Bearer",
+		"token":         tokens.AccessToken,
+		"type":          "Bearer",
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// handles POST /auth/refresh
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tokens, err := h.service.Refresh(ctx, req.RefreshToken)
+	if err == service.ErrInvalidRefreshToken {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         tokens.AccessToken,
+		"type":          "Bearer",
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// handles POST /auth/logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	// Body is optional
+	_ = c.ShouldBindJSON(&req)
+
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.Logout(ctx, claims.(jwt.MapClaims), req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// handles POST /auth/password/forgot
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.ForgotPassword(ctx, req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Same response whether or not the account exists
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If the account exists, a reset token has been sent",
+	})
+}
+
+// handles POST /auth/password/reset
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required,min=8"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := h.service.ResetPassword(ctx, req.Token, req.Password)
+	if errors.Is(err, service.ErrInvalidResetToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset, please log in again"})
+}
+
+// handles POST /auth/password/change
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required,min=8"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tokens, err := h.service.ChangePassword(ctx, userID.(string), req.CurrentPassword, req.NewPassword)
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Other sessions were ended; these tokens replace the caller's
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Password changed",
+		"token":         tokens.AccessToken,
+		"type":          "Bearer",
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// handles GET /.well-known/jwks.json
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"keys": h.service.JWKs(),
 	})
 }
 
@@ -86,3 +257,74 @@ func (h *AuthHandler) Me(c *gin.Context) {
 
 	c.JSON(http.StatusOK, user)
 }
+
+// Handles POST /admin/invitations
+func (h *AuthHandler) CreateInvitation(c *gin.Context) {
+	var req struct {
+		Email string     `json:"email" binding:"omitempty,email"`
+		OrgID *uuid.UUID `json:"org_id"` // Platform operators only; members invite into their own organization
+	}
+
+	// Body is optional; an empty body issues an invitation for any address
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	orgID := callerOrg(c)
+	if orgID == nil && req.OrgID != nil {
+		if _, err := h.orgs.Get(ctx, *req.OrgID); err != nil {
+			status := http.StatusInternalServerError
+			if err == service.ErrOrganizationNotFound {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		orgID = req.OrgID
+	}
+
+	invitation, token, err := h.service.CreateInvitation(ctx, userID.(string), req.Email, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"invitation": invitation,
+		"token":      token, // Only returned once
+		"message":    "Share this token with the invitee; it cannot be retrieved again",
+	})
+}
+
+// Handles GET /admin/invitations
+func (h *AuthHandler) ListInvitations(c *gin.Context) {
+	ctx := c.Request.Context()
+	invitations, err := h.service.ListInvitations(ctx, c.Query("pending") == "true", callerOrg(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// Handles DELETE /admin/invitations/:id
+func (h *AuthHandler) RevokeInvitation(c *gin.Context) {
+	ctx := c.Request.Context()
+	if err := h.service.RevokeInvitation(ctx, c.Param("id"), callerOrg(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation revoked"})
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/capture"
+	"github.com/gin-gonic/gin"
+)
+
+// Capture window when the request doesn't give one
+const defaultCaptureMinutes = 15
+
+// Switches debug body capture on and off per service
+type BodyCaptureHandler struct {
+	registry *capture.Registry
+	services map[string]bool // Services that can capture; fast-path services can't
+}
+
+func NewBodyCaptureHandler(registry *capture.Registry, services []string) *BodyCaptureHandler {
+	h := &BodyCaptureHandler{registry: registry, services: make(map[string]bool)}
+	for _, service := range services {
+		h.services[service] = true
+	}
+	return h
+}
+
+type enableCaptureRequest struct {
+	DurationMinutes int `json:"duration_minutes"`
+}
+
+// Handles GET /admin/body-capture
+func (h *BodyCaptureHandler) List(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"sessions": h.registry.Sessions()})
+}
+
+// Handles PUT /admin/body-capture/*service
+func (h *BodyCaptureHandler) Enable(c *gin.Context) {
+	service := c.Param("service")
+	if !h.services[service] {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found or served on the fast path"})
+		return
+	}
+
+	var req enableCaptureRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.DurationMinutes == 0 {
+		req.DurationMinutes = defaultCaptureMinutes
+	}
+
+	until, err := h.registry.Enable(c.Request.Context(), service, time.Duration(req.DurationMinutes)*time.Minute)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"service": service,
+		"until":   until,
+	})
+}
+
+// Handles DELETE /admin/body-capture/*service
+func (h *BodyCaptureHandler) Disable(c *gin.Context) {
+	service := c.Param("service")
+	if !h.services[service] {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found or served on the fast path"})
+		return
+	}
+
+	if err := h.registry.Disable(c.Request.Context(), service); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Body capture disabled", "service": service})
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/cache"
+	"github.com/gin-gonic/gin"
+)
+
+// Handles response cache administration
+type CacheHandler struct {
+	store  *cache.Store
+	warmer *cache.Warmer
+}
+
+func NewCacheHandler(store *cache.Store, warmer *cache.Warmer) *CacheHandler {
+	return &CacheHandler{store: store, warmer: warmer}
+}
+
+// Handles POST /admin/cache/purge. Takes one of a full cache key, a path
+// (all its query strings and variants) or a glob pattern over paths.
+func (h *CacheHandler) Purge(c *gin.Context) {
+	var req struct {
+		Key     string `json:"key"`
+		Path    string `json:"path"`
+		Pattern string `json:"pattern"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var purged int
+	var err error
+	ctx := c.Request.Context()
+	switch {
+	case req.Key != "":
+		purged, err = h.store.Delete(ctx, req.Key)
+	case req.Path != "":
+		purged, err = h.store.Purge(ctx, req.Path)
+	case req.Pattern != "":
+		purged, err = h.store.PurgePattern(ctx, req.Pattern)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key, path or pattern is required"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"purged": purged})
+}
+
+// Handles POST /admin/cache/warm
+func (h *CacheHandler) Warm(c *gin.Context) {
+	var req struct {
+		URLs       []string   `json:"urls"`
+		Sitemap    string     `json:"sitemap"`
+		ScheduleAt *time.Time `json:"schedule_at"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	at := time.Now()
+	if req.ScheduleAt != nil {
+		at = *req.ScheduleAt
+	}
+
+	job, err := h.warmer.Submit(req.URLs, req.Sitemap, at)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// Handles GET /admin/cache/warm
+func (h *CacheHandler) ListJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, h.warmer.List())
+}
+
+// Handles GET /admin/cache/warm/:id
+func (h *CacheHandler) GetJob(c *gin.Context) {
+	job, exists := h.warmer.Get(c.Param("id"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Warming job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
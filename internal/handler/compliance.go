@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ComplianceHandler struct {
+	service *service.ComplianceService
+}
+
+func NewComplianceHandler(service *service.ComplianceService) *ComplianceHandler {
+	return &ComplianceHandler{service: service}
+}
+
+// Handles GET /admin/compliance/access-review
+// Accepts days=<n> (default 90) for the privileged action window. format=csv
+// exports one section at a time, chosen with section=users|api_keys|actions.
+func (h *ComplianceHandler) AccessReview(c *gin.Context) {
+	days := 90
+	if daysStr := c.Query("days"); daysStr != "" {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil || d <= 0 || d > 366 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be an integer between 1 and 366"})
+			return
+		}
+		days = d
+	}
+
+	format := c.DefaultQuery("format", "json")
+	section := c.Query("section")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+	if format == "csv" && section != "users" && section != "api_keys" && section != "actions" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "section must be one of users, api_keys, actions"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	since := time.Now().AddDate(0, 0, -days)
+	review, err := h.service.AccessReview(ctx, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, review)
+		return
+	}
+
+	w := startCSV(c, fmt.Sprintf("access-review-%s-%s.csv", section, review.GeneratedAt.Format("20060102")))
+	switch section {
+	case "users":
+		w.Write([]string{"id", "email", "name", "role", "auth_provider", "created_at", "last_login_at"})
+		for _, u := range review.Users {
+			w.Write([]string{u.ID, u.Email, u.Name, u.Role, u.AuthProvider, formatTime(&u.CreatedAt), formatTime(u.LastLoginAt)})
+		}
+	case "api_keys":
+		w.Write([]string{"id", "key_prefix", "name", "tier", "tags", "owner", "team", "contact_email", "created_by", "created_at", "last_used_at"})
+		for _, k := range review.APIKeys {
+			w.Write([]string{k.ID, k.KeyPrefix, k.Name, k.Tier, strings.Join(k.Tags, ";"), k.Owner, k.Team, k.ContactEmail, k.CreatedBy, formatTime(&k.CreatedAt), formatTime(k.LastUsedAt)})
+		}
+	case "actions":
+		w.Write([]string{"at", "actor", "ip", "action", "resource_type", "resource_id", "status"})
+		for _, a := range review.PrivilegedActions {
+			w.Write([]string{formatTime(&a.At), a.Actor, a.IP, a.Action, a.ResourceType, a.ResourceID, strconv.Itoa(a.Status)})
+		}
+	}
+	w.Flush()
+}
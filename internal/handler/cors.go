@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aman-churiwal/api-gateway/internal/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// Handles runtime CORS policy overrides
+type CORSHandler struct {
+	registry *cors.Registry
+}
+
+func NewCORSHandler(registry *cors.Registry) *CORSHandler {
+	return &CORSHandler{registry: registry}
+}
+
+// Handles GET /admin/cors
+func (h *CORSHandler) List(c *gin.Context) {
+	overrides := h.registry.List()
+	c.JSON(http.StatusOK, gin.H{
+		"overrides": overrides,
+		"total":     len(overrides),
+	})
+}
+
+// Handles PUT /admin/cors/<service path>. Fields left out keep the
+// configured policy; the override applies on every replica within seconds.
+func (h *CORSHandler) Set(c *gin.Context) {
+	var policy cors.Policy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	override, err := h.registry.Set(c.Request.Context(), c.Param("service"), policy)
+	if errors.Is(err, cors.ErrInvalidPolicy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, override)
+}
+
+// Handles DELETE /admin/cors/<service path>, restoring the configured policy
+func (h *CORSHandler) Delete(c *gin.Context) {
+	err := h.registry.Remove(c.Request.Context(), c.Param("service"))
+	if err == cors.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "CORS override deleted"})
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aman-churiwal/api-gateway/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type DeadLetterHandler struct {
+	service *service.DeadLetterService
+}
+
+func NewDeadLetterHandler(service *service.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{service: service}
+}
+
+// Handles GET /admin/dead-letters
+func (h *DeadLetterHandler) List(c *gin.Context) {
+	limit := 100
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 1000 {
+			limit = l
+		}
+	}
+
+	offset := 0
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	pendingOnly := c.Query("pending") == "true"
+
+	ctx := c.Request.Context()
+	deadLetters, err := h.service.List(ctx, c.Query("service"), pendingOnly, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dead_letters": deadLetters,
+		"limit":        limit,
+		"offset":       offset,
+	})
+}
+
+// Handles GET /admin/dead-letters/:id
+func (h *DeadLetterHandler) Get(c *gin.Context) {
+	id := c.Param("id")
+
+	ctx := c.Request.Context()
+	deadLetter, err := h.service.Get(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if deadLetter == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead letter not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetter)
+}
+
+// Handles POST /admin/dead-letters/:id/redrive
+func (h *DeadLetterHandler) Redrive(c *gin.Context) {
+	id := c.Param("id")
+
+	ctx := c.Request.Context()
+	deadLetter, err := h.service.Redrive(ctx, id)
+	if errors.Is(err, service.ErrDeadLetterNotRedrivable) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	if deadLetter == nil {
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead letter not found"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":       err.Error(),
+			"dead_letter": deadLetter,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Request redriven",
+		"dead_letter": deadLetter,
+	})
+}
+
+// Handles DELETE /admin/dead-letters/:id
+func (h *DeadLetterHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+
+	ctx := c.Request.Context()
+	if err := h.service.Delete(ctx, id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Dead letter deleted successfully"})
+}
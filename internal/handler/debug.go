@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handles runtime profiling and stats for diagnosing production issues
+type DebugHandler struct{}
+
+func NewDebugHandler() *DebugHandler {
+	return &DebugHandler{}
+}
+
+// Handles GET /admin/debug/runtime
+func (h *DebugHandler) Runtime(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	var lastGC *time.Time
+	if mem.LastGC > 0 {
+		t := time.Unix(0, int64(mem.LastGC))
+		lastGC = &t
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"go_version": runtime.Version(),
+		"goroutines": runtime.NumGoroutine(),
+		"num_cpu":    runtime.NumCPU(),
+		"gomaxprocs": runtime.GOMAXPROCS(0),
+		"memory": gin.H{
+			"alloc_bytes":       mem.Alloc,
+			"total_alloc_bytes": mem.TotalAlloc,
+			"sys_bytes":         mem.Sys,
+			"heap_inuse_bytes":  mem.HeapInuse,
+			"heap_idle_bytes":   mem.HeapIdle,
+			"heap_objects":      mem.HeapObjects,
+			"stack_inuse_bytes": mem.StackInuse,
+		},
+		"gc": gin.H{
+			"count":           mem.NumGC,
+			"last_run":        lastGC,
+			"last_pause_ms":   float64(mem.PauseNs[(mem.NumGC+255)%256]) / 1e6,
+			"total_pause_ms":  float64(mem.PauseTotalNs) / 1e6,
+			"next_heap_bytes": mem.NextGC,
+			"cpu_fraction":    mem.GCCPUFraction,
+		},
+	})
+}
+
+// Handles GET /admin/debug/pprof/, the index of available profiles
+func (h *DebugHandler) Index(c *gin.Context) {
+	pprof.Index(c.Writer, c.Request)
+}
+
+// Handles GET /admin/debug/pprof/:profile for heap, goroutine, allocs, block,
+// mutex and threadcreate, plus the cmdline, profile, symbol and trace endpoints
+func (h *DebugHandler) Profile(c *gin.Context) {
+	switch name := c.Param("profile"); name {
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+	}
+}
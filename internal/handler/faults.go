@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/faults"
+	"github.com/gin-gonic/gin"
+)
+
+// Handles admin-managed fault injection rules
+type FaultHandler struct {
+	registry *faults.Registry
+}
+
+func NewFaultHandler(registry *faults.Registry) *FaultHandler {
+	return &FaultHandler{registry: registry}
+}
+
+// Handles POST /admin/faults
+func (h *FaultHandler) Create(c *gin.Context) {
+	var req struct {
+		faults.Rule
+		TTLSeconds int `json:"ttl_seconds"` // Default: 3600
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.registry.Add(c.Request.Context(), req.Rule, time.Duration(req.TTLSeconds)*time.Second)
+	if errors.Is(err, faults.ErrInvalidRule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// Handles GET /admin/faults
+func (h *FaultHandler) List(c *gin.Context) {
+	rules := h.registry.List()
+	c.JSON(http.StatusOK, gin.H{
+		"rules":  rules,
+		"total":  len(rules),
+		"header": faults.DefaultHeader,
+	})
+}
+
+// Handles PUT /admin/faults/:id
+func (h *FaultHandler) Toggle(c *gin.Context) {
+	var req struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.registry.SetEnabled(c.Request.Context(), c.Param("id"), *req.Enabled)
+	if err == faults.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// Handles DELETE /admin/faults/:id
+func (h *FaultHandler) Delete(c *gin.Context) {
+	err := h.registry.Remove(c.Request.Context(), c.Param("id"))
+	if err == faults.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fault rule deleted"})
+}
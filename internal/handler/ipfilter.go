@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aman-churiwal/api-gateway/internal/ipfilter"
+	"github.com/gin-gonic/gin"
+)
+
+// Handles admin-managed IP allow and deny rules
+type IPFilterHandler struct {
+	registry *ipfilter.Registry
+}
+
+func NewIPFilterHandler(registry *ipfilter.Registry) *IPFilterHandler {
+	return &IPFilterHandler{registry: registry}
+}
+
+// Handles POST /admin/ip-filter. Rules take effect on every replica within
+// a few seconds.
+func (h *IPFilterHandler) Create(c *gin.Context) {
+	var rule ipfilter.Rule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.registry.Add(c.Request.Context(), rule)
+	if errors.Is(err, ipfilter.ErrInvalidRule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// Handles GET /admin/ip-filter
+func (h *IPFilterHandler) List(c *gin.Context) {
+	rules := h.registry.List()
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+		"total": len(rules),
+	})
+}
+
+// Handles DELETE /admin/ip-filter/:id
+func (h *IPFilterHandler) Delete(c *gin.Context) {
+	err := h.registry.Remove(c.Request.Context(), c.Param("id"))
+	if err == ipfilter.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "IP filter rule deleted"})
+}
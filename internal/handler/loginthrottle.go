@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aman-churiwal/api-gateway/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type LoginThrottleHandler struct {
+	throttle *service.LoginThrottle
+}
+
+func NewLoginThrottleHandler(throttle *service.LoginThrottle) *LoginThrottleHandler {
+	return &LoginThrottleHandler{throttle: throttle}
+}
+
+// Handles GET /admin/login-attempts
+// Accepts email, ip and limit (default 100, max 1000) query parameters
+func (h *LoginThrottleHandler) Attempts(c *gin.Context) {
+	limit := 100
+	if limitStr := c.Query("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n <= 0 || n > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+			return
+		}
+		limit = n
+	}
+
+	ctx := c.Request.Context()
+	attempts, err := h.throttle.Attempts(ctx, c.Query("email"), c.Query("ip"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attempts": attempts,
+		"total":    len(attempts),
+	})
+}
+
+// Handles DELETE /admin/login-lockouts/:email
+func (h *LoginThrottleHandler) Unlock(c *gin.Context) {
+	ctx := c.Request.Context()
+	if err := h.throttle.Unlock(ctx, c.Param("email")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlocked"})
+}
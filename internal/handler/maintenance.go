@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/maintenance"
+	"github.com/gin-gonic/gin"
+)
+
+// Handles admin-managed maintenance windows
+type MaintenanceHandler struct {
+	registry *maintenance.Registry
+}
+
+func NewMaintenanceHandler(registry *maintenance.Registry) *MaintenanceHandler {
+	return &MaintenanceHandler{registry: registry}
+}
+
+// Handles POST /admin/maintenance. Without starts_at the service goes into
+// maintenance immediately, and without ends_at it stays there until deleted.
+func (h *MaintenanceHandler) Create(c *gin.Context) {
+	var window maintenance.Window
+	if err := c.ShouldBindJSON(&window); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.registry.Add(c.Request.Context(), window)
+	if errors.Is(err, maintenance.ErrInvalidWindow) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// Handles GET /admin/maintenance
+func (h *MaintenanceHandler) List(c *gin.Context) {
+	now := time.Now()
+	windows := h.registry.List()
+
+	items := make([]gin.H, 0, len(windows))
+	for _, window := range windows {
+		items = append(items, gin.H{
+			"window": window,
+			"active": window.Active(now),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"windows": items,
+		"total":   len(items),
+	})
+}
+
+// Handles DELETE /admin/maintenance/:id
+func (h *MaintenanceHandler) Delete(c *gin.Context) {
+	err := h.registry.Remove(c.Request.Context(), c.Param("id"))
+	if err == maintenance.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Maintenance window deleted"})
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/aman-churiwal/api-gateway/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Serves the OAuth2 token endpoint, which answers in the RFC 6749 format
+type OAuthHandler struct {
+	service *service.OAuthService
+}
+
+func NewOAuthHandler(service *service.OAuthService) *OAuthHandler {
+	return &OAuthHandler{service: service}
+}
+
+// Answers with an RFC 6749 error response
+func oauthError(c *gin.Context, status int, code, description string) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, gin.H{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+// Handles POST /oauth/token
+// Form fields grant_type=client_credentials, with the API key ID as client_id
+// and the key as client_secret, sent in the form or with HTTP Basic
+func (h *OAuthHandler) Token(c *gin.Context) {
+	if c.PostForm("grant_type") != "client_credentials" {
+		oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "Only client_credentials is supported")
+		return
+	}
+
+	// Basic credentials are form-encoded first (RFC 6749 section 2.3.1)
+	clientID, clientSecret, basic := c.Request.BasicAuth()
+	if basic {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+
+	ctx := c.Request.Context()
+	token, err := h.service.IssueToken(ctx, clientID, clientSecret, c.ClientIP())
+	switch err {
+	case nil:
+	case service.ErrInvalidClient:
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		oauthError(c, http.StatusUnauthorized, "invalid_client", err.Error())
+		return
+	default:
+		oauthError(c, http.StatusServiceUnavailable, "temporarily_unavailable", "Client credentials could not be checked")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, token)
+}
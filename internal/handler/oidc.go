@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aman-churiwal/api-gateway/internal/oidc"
+	"github.com/aman-churiwal/api-gateway/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCHandler struct {
+	service *service.OIDCService
+}
+
+func NewOIDCHandler(service *service.OIDCService) *OIDCHandler {
+	return &OIDCHandler{service: service}
+}
+
+// handles GET /auth/oidc/login
+func (h *OIDCHandler) Login(c *gin.Context) {
+	ctx := c.Request.Context()
+	redirectURL, err := h.service.BeginLogin(ctx)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// handles GET /auth/oidc/callback
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if idpError := c.Query("error"); idpError != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":       idpError,
+			"description": c.Query("error_description"),
+		})
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "state and code are required"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tokens, err := h.service.CompleteLogin(ctx, state, code)
+	switch {
+	case errors.Is(err, service.ErrInvalidOIDCState), errors.Is(err, oidc.ErrInvalidIDToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrNoMappedRole), errors.Is(err, service.ErrExternalAccount):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         tokens.AccessToken,
+		"type":          "Bearer",
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
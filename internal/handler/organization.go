@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aman-churiwal/api-gateway/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type OrganizationHandler struct {
+	service   *service.OrganizationService
+	analytics *service.AnalyticsService
+}
+
+func NewOrganizationHandler(service *service.OrganizationService, analytics *service.AnalyticsService) *OrganizationHandler {
+	return &OrganizationHandler{service: service, analytics: analytics}
+}
+
+// Returns the organization the caller belongs to, or nil for platform
+// operators who see every organization
+func callerOrg(c *gin.Context) *uuid.UUID {
+	id, err := uuid.Parse(c.GetString("org_id"))
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// Whether the caller may see a resource in the given organization
+func canAccessOrg(c *gin.Context, orgID *uuid.UUID) bool {
+	caller := callerOrg(c)
+	return caller == nil || (orgID != nil && *orgID == *caller)
+}
+
+// Handles POST /admin/organizations
+func (h *OrganizationHandler) Create(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	org, err := h.service.Create(ctx, req.Name, c.GetString("email"))
+	switch err {
+	case nil:
+	case service.ErrOrganizationExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, org)
+}
+
+// Handles GET /admin/organizations
+func (h *OrganizationHandler) List(c *gin.Context) {
+	ctx := c.Request.Context()
+	orgs, err := h.service.List(ctx, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, orgs)
+}
+
+// Handles GET /admin/organizations/:id
+// Organization members may only look up their own organization
+func (h *OrganizationHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil || !canAccessOrg(c, &id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrOrganizationNotFound.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	orgs, err := h.service.List(ctx, &id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(orgs) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrOrganizationNotFound.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, orgs[0])
+}
+
+// Handles PUT /admin/organizations/:id/limits
+// Sets the requests per minute all the organization's keys may send together
+func (h *OrganizationHandler) SetLimits(c *gin.Context) {
+	var req struct {
+		RequestsPerMinute *int `json:"requests_per_minute" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrOrganizationNotFound.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	err = h.service.SetRequestLimit(ctx, id, *req.RequestsPerMinute)
+	switch err {
+	case nil:
+	case service.ErrOrganizationNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case service.ErrInvalidOrgLimit:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"org_id":              id,
+		"requests_per_minute": *req.RequestsPerMinute,
+	})
+}
+
+// Handles GET /admin/organizations/:id/usage
+// The organization's aggregate limit as it stands, with its traffic over the
+// time range broken down by key. Organization members may only see their own.
+func (h *OrganizationHandler) Usage(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil || !canAccessOrg(c, &id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrOrganizationNotFound.Error()})
+		return
+	}
+
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	usage, err := h.analytics.GetOrganizationUsage(ctx, from, to, &id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(usage) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrOrganizationNotFound.Error()})
+		return
+	}
+
+	keys, err := h.analytics.GetOrganizationKeyUsage(ctx, from, to, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":  from,
+		"to":    to,
+		"quota": h.service.Quota(ctx, id),
+		"usage": usage[0],
+		"keys":  keys,
+	})
+}
+
+// Handles POST /admin/organizations/:id/members
+func (h *OrganizationHandler) AddUser(c *gin.Context) {
+	var req struct {
+		UserID string `json:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.assign(c, req.UserID, "User not found", h.service.AddUser)
+}
+
+// Handles POST /admin/organizations/:id/keys
+func (h *OrganizationHandler) AddAPIKey(c *gin.Context) {
+	var req struct {
+		APIKeyID string `json:"api_key_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.assign(c, req.APIKeyID, "API key not found", h.service.AddAPIKey)
+}
+
+// Moves the resource with the given id into the organization from the path
+func (h *OrganizationHandler) assign(c *gin.Context, resourceID, notFound string,
+	add func(ctx context.Context, orgID, id uuid.UUID) (bool, error)) {
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrOrganizationNotFound.Error()})
+		return
+	}
+	id, err := uuid.Parse(resourceID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	found, err := add(c.Request.Context(), orgID, id)
+	if err == service.ErrOrganizationNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Moved into organization", "org_id": orgID})
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Serves the developer portal, where API consumers manage their own keys
+type PortalHandler struct {
+	service *service.DeveloperService
+	auth    *service.AuthService
+}
+
+func NewPortalHandler(service *service.DeveloperService, auth *service.AuthService) *PortalHandler {
+	return &PortalHandler{service: service, auth: auth}
+}
+
+// Returns the developer RequirePortalAuth signed in
+func portalDeveloper(c *gin.Context) *models.Developer {
+	developer, _ := c.MustGet("developer").(*models.Developer)
+	return developer
+}
+
+// Answers a DeveloperService error
+func portalError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrDeveloperKeyNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrTierNotOffered:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrDeveloperKeyLimit:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// Handles POST /portal/register
+func (h *PortalHandler) Register(c *gin.Context) {
+	var req struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required,min=8"`
+		Name     string `json:"name" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	developer, err := h.service.Register(ctx, req.Email, req.Password, req.Name)
+	if err == service.ErrDeveloperExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, developer)
+}
+
+// Handles POST /portal/login
+func (h *PortalHandler) Login(c *gin.Context) {
+	var req struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	token, expiresIn, err := h.service.Login(ctx, req.Email, req.Password, c.ClientIP())
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		c.Header("Retry-After", fmt.Sprintf("%d", int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if err == service.ErrInvalidCredentials {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"expires_in": expiresIn,
+	})
+}
+
+// Handles POST /portal/logout
+func (h *PortalHandler) Logout(c *gin.Context) {
+	ctx := c.Request.Context()
+	if err := h.auth.Logout(ctx, c.MustGet("claims").(jwt.MapClaims), ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// Handles GET /portal/me
+func (h *PortalHandler) Me(c *gin.Context) {
+	c.JSON(http.StatusOK, portalDeveloper(c))
+}
+
+// Handles GET /portal/tiers
+func (h *PortalHandler) Tiers(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.Tiers())
+}
+
+// Handles GET /portal/keys
+func (h *PortalHandler) ListKeys(c *gin.Context) {
+	ctx := c.Request.Context()
+	keys, err := h.service.ListKeys(ctx, portalDeveloper(c).ID)
+	if err != nil {
+		portalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// Handles POST /portal/keys
+// tier defaults to the first tier the portal offers
+func (h *PortalHandler) CreateKey(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+		Tier string `json:"tier"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	apiKey, key, err := h.service.CreateKey(ctx, portalDeveloper(c), req.Name, req.Tier)
+	if err != nil {
+		portalError(c, err)
+		return
+	}
+
+	resp := gin.H{
+		"api_key": apiKey,
+		"key":     key,
+		"message": "Save this key - it won't be shown again",
+	}
+	if secret := h.service.SigningSecret(key); secret != "" {
+		resp["signing_secret"] = secret
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Handles POST /portal/keys/:id/rotate
+func (h *PortalHandler) RotateKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	key, err := h.service.RotateKey(ctx, portalDeveloper(c).ID, c.Param("id"))
+	if err != nil {
+		portalError(c, err)
+		return
+	}
+
+	resp := gin.H{
+		"key":     key,
+		"message": "Save this key - it won't be shown again. The previous key no longer works",
+	}
+	if secret := h.service.SigningSecret(key); secret != "" {
+		resp["signing_secret"] = secret
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Handles GET /portal/keys/:id/usage
+// Traffic over from/to (default: last 24 hours) and the tier limit right now
+func (h *PortalHandler) KeyUsage(c *gin.Context) {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	usage, quota, err := h.service.KeyUsage(ctx, portalDeveloper(c).ID, c.Param("id"), from, to)
+	if err != nil {
+		portalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":  from,
+		"to":    to,
+		"usage": usage,
+		"quota": quota,
+	})
+}
+
+// Handles GET /portal/keys/:id/logs
+// Recent requests, newest first. Accepts limit (default 100, max 500) and offset
+func (h *PortalHandler) KeyLogs(c *gin.Context) {
+	limit := 100
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 500 {
+			limit = l
+		}
+	}
+
+	offset := 0
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	ctx := c.Request.Context()
+	logs, err := h.service.KeyLogs(ctx, portalDeveloper(c).ID, c.Param("id"), limit, offset)
+	if err != nil {
+		portalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":   logs,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ServiceAccountHandler struct {
+	service *service.ServiceAccountService
+}
+
+func NewServiceAccountHandler(service *service.ServiceAccountService) *ServiceAccountHandler {
+	return &ServiceAccountHandler{service: service}
+}
+
+// Handles POST /admin/service-accounts
+func (h *ServiceAccountHandler) Create(c *gin.Context) {
+	var req struct {
+		Name        string   `json:"name" binding:"required"`
+		Description string   `json:"description"`
+		Scopes      []string `json:"scopes" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	account, err := h.service.Create(ctx, req.Name, req.Description, c.GetString("email"), req.Scopes)
+	switch err {
+	case nil:
+	case service.ErrInvalidScope:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case service.ErrServiceAccountExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// Handles GET /admin/service-accounts
+func (h *ServiceAccountHandler) List(c *gin.Context) {
+	ctx := c.Request.Context()
+	accounts, err := h.service.List(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+// Handles DELETE /admin/service-accounts/:id
+func (h *ServiceAccountHandler) Deactivate(c *gin.Context) {
+	ctx := c.Request.Context()
+	err := h.service.Deactivate(ctx, c.Param("id"))
+	if err == service.ErrServiceAccountNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Service account deactivated and its tokens revoked"})
+}
+
+// Handles POST /admin/service-accounts/:id/tokens
+// Scopes default to everything granted to the account; expires_in_days of 0 never expires
+func (h *ServiceAccountHandler) IssueToken(c *gin.Context) {
+	var req struct {
+		Name          string   `json:"name" binding:"required"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days" binding:"min=0,max=3650"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	token, plain, err := h.service.IssueToken(ctx, c.Param("id"), req.Name, c.GetString("email"), req.Scopes, ttl)
+	switch err {
+	case nil:
+	case service.ErrServiceAccountNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case service.ErrInvalidScope, service.ErrScopeNotGranted, service.ErrServiceAccountInactive:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":      plain, // Only returned once
+		"token_info": token,
+		"message":    "Store this token securely; it cannot be retrieved again",
+	})
+}
+
+// Handles GET /admin/service-accounts/:id/tokens
+func (h *ServiceAccountHandler) ListTokens(c *gin.Context) {
+	ctx := c.Request.Context()
+	tokens, err := h.service.ListTokens(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Handles DELETE /admin/service-accounts/:id/tokens/:tokenId
+func (h *ServiceAccountHandler) RevokeToken(c *gin.Context) {
+	ctx := c.Request.Context()
+	err := h.service.RevokeToken(ctx, c.Param("id"), c.Param("tokenId"))
+	if err == service.ErrInvalidServiceToken {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found or already revoked"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type StaleKeyHandler struct {
+	service *service.StaleKeyService
+}
+
+func NewStaleKeyHandler(service *service.StaleKeyService) *StaleKeyHandler {
+	return &StaleKeyHandler{service: service}
+}
+
+// Handles GET /admin/keys/stale
+// Accepts days=<n> to report on a window other than the configured policy
+func (h *StaleKeyHandler) Report(c *gin.Context) {
+	var unusedFor time.Duration
+	if daysStr := c.Query("days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive integer"})
+			return
+		}
+		unusedFor = time.Duration(days) * 24 * time.Hour
+	}
+
+	ctx := c.Request.Context()
+	keys, err := h.service.Report(ctx, unusedFor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys":  keys,
+		"total": len(keys),
+	})
+}
+
+// Handles POST /admin/keys/stale/sweep
+func (h *StaleKeyHandler) Sweep(c *gin.Context) {
+	ctx := c.Request.Context()
+	flagged, deactivated, err := h.service.Sweep(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"flagged":     flagged,
+		"deactivated": deactivated,
+	})
+}
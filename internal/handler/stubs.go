@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/stubs"
+	"github.com/gin-gonic/gin"
+)
+
+// Handles admin-managed response stubs
+type StubHandler struct {
+	registry *stubs.Registry
+}
+
+func NewStubHandler(registry *stubs.Registry) *StubHandler {
+	return &StubHandler{registry: registry}
+}
+
+// Handles POST /admin/stubs
+func (h *StubHandler) Create(c *gin.Context) {
+	var req struct {
+		stubs.Stub
+		TTLSeconds int `json:"ttl_seconds"` // Default: 3600
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	stub, err := h.registry.Add(ctx, req.Stub, time.Duration(req.TTLSeconds)*time.Second)
+	if errors.Is(err, stubs.ErrInvalidStub) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, stub)
+}
+
+// Handles GET /admin/stubs
+func (h *StubHandler) List(c *gin.Context) {
+	stubs := h.registry.List()
+	c.JSON(http.StatusOK, gin.H{
+		"stubs": stubs,
+		"total": len(stubs),
+	})
+}
+
+// Handles DELETE /admin/stubs/:id
+func (h *StubHandler) Delete(c *gin.Context) {
+	ctx := c.Request.Context()
+	err := h.registry.Remove(ctx, c.Param("id"))
+	if err == stubs.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Stub deleted"})
+}
@@ -2,7 +2,9 @@ package handler
 
 import (
 	"net/http"
+	"strings"
 
+	"github.com/aman-churiwal/api-gateway/internal/audit"
 	"github.com/aman-churiwal/api-gateway/internal/proxy"
 	"github.com/gin-gonic/gin"
 )
@@ -49,7 +51,10 @@ func (h *SystemHandler) ResetCircuitBreaker(c *gin.Context) {
 		return
 	}
 
+	before := proxyInstance.CircuitBreakerState()
 	proxyInstance.ResetCircuitBreaker()
+	audit.Record(c.Request.Context(), "circuit_breaker.reset", "service", service,
+		gin.H{"state": before.String()}, gin.H{"state": proxyInstance.CircuitBreakerState().String()})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Circuit breaker reset successfully",
@@ -86,8 +91,133 @@ func (h *SystemHandler) ServiceHealthStatus(c *gin.Context) {
 			"healthy_targets": healthyTargets,
 			"all_targets":     allTargets,
 			"target_status":   statuses,
+			"ejected_targets": proxyInstance.EjectedTargets(),
 		}
 	}
 
 	c.JSON(http.StatusOK, healthStatuses)
 }
+
+// Returns normal and long-lived connection counts per service
+func (h *SystemHandler) ConnectionStatus(c *gin.Context) {
+	connections := make(map[string]interface{})
+
+	for path, proxyInstance := range h.proxies {
+		connections[path] = proxyInstance.ConnectionStats()
+	}
+
+	c.JSON(http.StatusOK, connections)
+}
+
+// Returns backend-requested throttling per service
+func (h *SystemHandler) UpstreamLimitStatus(c *gin.Context) {
+	limits := make(map[string]interface{})
+
+	for path, proxyInstance := range h.proxies {
+		limits[path] = proxyInstance.UpstreamLimitStats()
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+// Returns the canary split of every service that has one
+func (h *SystemHandler) CanaryStatus(c *gin.Context) {
+	canaries := make(map[string]interface{})
+
+	for path, proxyInstance := range h.proxies {
+		if status, ok := proxyInstance.CanaryStatus(); ok {
+			canaries[path] = status
+		}
+	}
+
+	c.JSON(http.StatusOK, canaries)
+}
+
+// Changes the share of a service's traffic sent to its canary. 0 rolls the
+// canary back and 100 sends it all traffic.
+func (h *SystemHandler) SetCanary(c *gin.Context) {
+	service := c.Param("service")
+
+	var req struct {
+		Percent *float64 `json:"percent" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	proxyInstance, exists := h.proxies[service]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	before, _ := proxyInstance.CanaryStatus()
+	if err := proxyInstance.SetCanaryPercent(*req.Percent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	after, _ := proxyInstance.CanaryStatus()
+	audit.Record(c.Request.Context(), "canary.update", "service", service,
+		gin.H{"percent": before.Percent}, gin.H{"percent": after.Percent})
+
+	c.JSON(http.StatusOK, after)
+}
+
+// Returns the target sets of every blue-green service
+func (h *SystemHandler) DeploymentStatus(c *gin.Context) {
+	deployments := make(map[string]interface{})
+
+	for path, proxyInstance := range h.proxies {
+		if status, ok := proxyInstance.BlueGreenStatus(); ok {
+			deployments[path] = status
+		}
+	}
+
+	c.JSON(http.StatusOK, deployments)
+}
+
+// Handles POST /admin/services/<path>/switch. Routes the service's traffic to
+// the set named in the body, or to the inactive set when none is given.
+func (h *SystemHandler) SwitchDeployment(c *gin.Context) {
+	service, found := strings.CutSuffix(c.Param("service"), "/switch")
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+
+	var req struct {
+		To string `json:"to"` // "blue" or "green"
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	proxyInstance, exists := h.proxies[service]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	previous, err := proxyInstance.SwitchTargets(req.To)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	status, _ := proxyInstance.BlueGreenStatus()
+	audit.Record(c.Request.Context(), "deployment.switch", "service", service,
+		gin.H{"active": previous}, gin.H{"active": status.Active})
+
+	c.JSON(http.StatusOK, gin.H{
+		"service":  service,
+		"previous": previous,
+		"active":   status.Active,
+	})
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aman-churiwal/api-gateway/internal/toggles"
+	"github.com/gin-gonic/gin"
+)
+
+// Handles runtime middleware toggles
+type ToggleHandler struct {
+	registry *toggles.Registry
+}
+
+func NewToggleHandler(registry *toggles.Registry) *ToggleHandler {
+	return &ToggleHandler{registry: registry}
+}
+
+// Handles GET /admin/middleware
+func (h *ToggleHandler) List(c *gin.Context) {
+	c.JSON(http.StatusOK, h.registry.States())
+}
+
+// Handles PUT /admin/middleware/:name/:action
+func (h *ToggleHandler) Set(c *gin.Context) {
+	name := c.Param("name")
+
+	var enabled bool
+	switch c.Param("action") {
+	case "enable":
+		enabled = true
+	case "disable":
+		enabled = false
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Action must be enable or disable"})
+		return
+	}
+
+	if !h.registry.Has(name) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":     "Unknown middleware",
+			"available": h.registry.Names(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.registry.Set(ctx, name, enabled); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"middleware": name,
+		"enabled":    enabled,
+	})
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type UsageReportHandler struct {
+	service *service.UsageReportService
+}
+
+func NewUsageReportHandler(service *service.UsageReportService) *UsageReportHandler {
+	return &UsageReportHandler{service: service}
+}
+
+// Reads month=YYYY-MM, defaulting to the current month
+func parseMonth(c *gin.Context) (time.Time, bool) {
+	monthStr := c.Query("month")
+	if monthStr == "" {
+		return service.MonthStart(time.Now()), true
+	}
+
+	month, err := time.Parse("2006-01", monthStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "month must be formatted as YYYY-MM"})
+		return time.Time{}, false
+	}
+	return month, true
+}
+
+// Handles GET /admin/reports/usage
+// Accepts month=YYYY-MM, api_key_id=<id>, org_id=<id>, group_by=organization
+// and format=csv. Organization members only see their own organization's keys.
+func (h *UsageReportHandler) Report(c *gin.Context) {
+	month, ok := parseMonth(c)
+	if !ok {
+		return
+	}
+
+	var apiKeyID *uuid.UUID
+	if idStr := c.Query("api_key_id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+			return
+		}
+		apiKeyID = &id
+	}
+
+	orgID := callerOrg(c)
+	if idStr := c.Query("org_id"); idStr != "" && orgID == nil {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+			return
+		}
+		orgID = &id
+	}
+
+	if c.Query("group_by") == "organization" {
+		h.reportByOrganization(c, month, orgID)
+		return
+	}
+
+	ctx := c.Request.Context()
+	reports, err := h.service.Report(ctx, month, apiKeyID, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !wantsCSV(c) {
+		c.JSON(http.StatusOK, gin.H{
+			"month":   month.Format("2006-01"),
+			"reports": reports,
+			"total":   len(reports),
+		})
+		return
+	}
+
+	w := startCSV(c, "usage-"+month.Format("2006-01")+".csv")
+	w.Write([]string{"month", "api_key_id", "name", "tier", "owner", "org_id", "requests", "client_errors", "server_errors", "bytes_in", "bytes_out", "final", "generated_at"})
+	for _, report := range reports {
+		w.Write([]string{
+			month.Format("2006-01"),
+			report.APIKeyID.String(),
+			report.Name,
+			report.Tier,
+			report.Owner,
+			formatUUID(report.OrgID),
+			strconv.FormatInt(report.Requests, 10),
+			strconv.FormatInt(report.ClientErrors, 10),
+			strconv.FormatInt(report.ServerErrors, 10),
+			strconv.FormatInt(report.BytesIn, 10),
+			strconv.FormatInt(report.BytesOut, 10),
+			strconv.FormatBool(report.Final),
+			formatTime(&report.GeneratedAt),
+		})
+	}
+	w.Flush()
+}
+
+// Writes a month's usage summed per organization
+func (h *UsageReportHandler) reportByOrganization(c *gin.Context, month time.Time, orgID *uuid.UUID) {
+	ctx := c.Request.Context()
+	reports, err := h.service.ReportByOrganization(ctx, month, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !wantsCSV(c) {
+		c.JSON(http.StatusOK, gin.H{
+			"month":         month.Format("2006-01"),
+			"organizations": reports,
+			"total":         len(reports),
+		})
+		return
+	}
+
+	w := startCSV(c, "usage-organizations-"+month.Format("2006-01")+".csv")
+	w.Write([]string{"month", "org_id", "api_keys", "requests", "client_errors", "server_errors", "bytes_in", "bytes_out", "final"})
+	for _, report := range reports {
+		w.Write([]string{
+			month.Format("2006-01"),
+			formatUUID(report.OrgID),
+			strconv.Itoa(report.APIKeys),
+			strconv.FormatInt(report.Requests, 10),
+			strconv.FormatInt(report.ClientErrors, 10),
+			strconv.FormatInt(report.ServerErrors, 10),
+			strconv.FormatInt(report.BytesIn, 10),
+			strconv.FormatInt(report.BytesOut, 10),
+			strconv.FormatBool(report.Final),
+		})
+	}
+	w.Flush()
+}
+
+// Handles POST /admin/reports/usage/rollup
+// Recomputes month=YYYY-MM (default: current) right away
+func (h *UsageReportHandler) Rollup(c *gin.Context) {
+	month, ok := parseMonth(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	keys, err := h.service.Rollup(ctx, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"month": month.Format("2006-01"),
+		"keys":  keys,
+	})
+}
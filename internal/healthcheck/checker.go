@@ -2,7 +2,7 @@ package healthcheck
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -18,8 +18,11 @@ type Checker struct {
 	interval       time.Duration
 	timeout        time.Duration
 	maxFailures    int
+	concurrency    int
+	client         *http.Client
 	stopChan       chan struct{}
 	running        bool
+	onChange       func(target string, healthy bool)
 }
 
 // Holds health checker configuration
@@ -29,6 +32,9 @@ type Config struct {
 	Interval    time.Duration // How often to check (default: 10s)
 	Timeout     time.Duration // Request timeout (default: 5s)
 	MaxFailures int           // Failures before marking unhealthy (default: 3)
+	Concurrency int           // Max targets checked at once (default: 0, all)
+	Transport   http.RoundTripper
+	OnChange    func(target string, healthy bool) // Called as targets turn healthy or unhealthy; must not block
 }
 
 func NewChecker(cfg *Config) *Checker {
@@ -53,6 +59,9 @@ func NewChecker(cfg *Config) *Checker {
 		interval:       cfg.Interval,
 		timeout:        cfg.Timeout,
 		maxFailures:    cfg.MaxFailures,
+		concurrency:    cfg.Concurrency,
+		onChange:       cfg.OnChange,
+		client:         &http.Client{Transport: cfg.Transport},
 		stopChan:       make(chan struct{}),
 	}
 
@@ -78,7 +87,7 @@ func (c *Checker) Start() {
 	c.running = true
 	c.mu.Unlock()
 
-	log.Printf("Starting health checks for %d targets (interval: %v)", len(c.targets), c.interval)
+	slog.Info("Starting health checks", "targets", len(c.GetAllTargets()), "interval", c.interval.String())
 
 	// Run initial check immediately
 	c.checkAll()
@@ -107,18 +116,51 @@ func (c *Checker) Stop() {
 	if c.running {
 		close(c.stopChan)
 		c.running = false
-		log.Printf("Health checker stopped")
+		slog.Info("Health checker stopped")
 	}
 }
 
+// Replaces the checked targets. Targets already known keep their status;
+// new ones start healthy like those configured up front.
+func (c *Checker) SetTargets(targets []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	statuses := make(map[string]*Status, len(targets))
+	for _, target := range targets {
+		if status, exists := c.healthStatus[target]; exists {
+			statuses[target] = status
+			continue
+		}
+		statuses[target] = &Status{
+			Target:    target,
+			IsHealthy: true,
+			LastCheck: time.Now(),
+		}
+	}
+
+	c.targets = append([]string(nil), targets...)
+	c.healthStatus = statuses
+	c.collectHealthyTargets()
+}
+
 // Performs health check on all targets
 func (c *Checker) checkAll() {
 	var wg sync.WaitGroup
 
-	for _, target := range c.targets {
+	targets := c.GetAllTargets()
+	concurrency := c.concurrency
+	if concurrency <= 0 {
+		concurrency = len(targets)
+	}
+	slots := make(chan struct{}, concurrency)
+
+	for _, target := range targets {
 		wg.Add(1)
+		slots <- struct{}{}
 		go func(t string) {
 			defer wg.Done()
+			defer func() { <-slots }()
 			c.checkTarget(t)
 		}(target)
 	}
@@ -139,7 +181,7 @@ func (c *Checker) checkTarget(target string) {
 		return
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.client.Do(req)
 	if err != nil {
 		c.recordFailure(target)
 		return
@@ -159,14 +201,20 @@ func (c *Checker) recordSuccess(target string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	status := c.healthStatus[target]
+	status, exists := c.healthStatus[target]
+	if !exists {
+		return // Removed while it was being checked
+	}
 	status.LastCheck = time.Now()
 	status.LastSuccess = time.Now()
 	status.FailureCount = 0
 
 	if !status.IsHealthy {
-		log.Printf("Target :%s is now healthy", target)
+		slog.Info("Target is now healthy", "backend_target", target)
 		status.IsHealthy = true
+		if c.onChange != nil {
+			c.onChange(target, true)
+		}
 	}
 }
 
@@ -175,14 +223,20 @@ func (c *Checker) recordFailure(target string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	status := c.healthStatus[target]
+	status, exists := c.healthStatus[target]
+	if !exists {
+		return
+	}
 	status.LastCheck = time.Now()
 	status.LastFailure = time.Now()
 	status.FailureCount++
 
 	if status.IsHealthy && status.FailureCount >= c.maxFailures {
-		log.Printf("Target %s is now unhealthy (failures: %d)", target, status.FailureCount)
+		slog.Warn("Target is now unhealthy", "backend_target", target, "failures", status.FailureCount)
 		status.IsHealthy = false
+		if c.onChange != nil {
+			c.onChange(target, false)
+		}
 	}
 }
 
@@ -191,6 +245,11 @@ func (c *Checker) updateHealthyTargets() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.collectHealthyTargets()
+}
+
+// Rebuilds the healthy target list; the caller holds the lock
+func (c *Checker) collectHealthyTargets() {
 	healthy := make([]string, 0)
 	for _, target := range c.targets {
 		if c.healthStatus[target].IsHealthy {
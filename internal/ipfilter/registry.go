@@ -0,0 +1,292 @@
+package ipfilter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/audit"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Redis hash holding admin-managed rules shared by all replicas
+const redisKey = "gateway:ipfilter"
+
+// Scope of rules that apply to every route
+const GlobalScope = "global"
+
+var (
+	ErrNotFound    = errors.New("ip filter rule not found")
+	ErrInvalidRule = errors.New("invalid ip filter rule")
+)
+
+// Parses a CIDR, or a bare address as a single-host prefix
+func ParsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Allow and deny prefixes from configuration
+type List struct {
+	Allow []netip.Prefix
+	Deny  []netip.Prefix
+}
+
+// Parses configured allow and deny entries
+func ParseList(allow, deny []string) (*List, error) {
+	list := &List{}
+	for _, entry := range allow {
+		prefix, err := ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow entry %q: %w", entry, err)
+		}
+		list.Allow = append(list.Allow, prefix)
+	}
+	for _, entry := range deny {
+		prefix, err := ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deny entry %q: %w", entry, err)
+		}
+		list.Deny = append(list.Deny, prefix)
+	}
+	return list, nil
+}
+
+// Returns the client address of a request served without gin. Like gin, it
+// walks X-Forwarded-For from the right only while the hops are trusted proxies.
+func ClientAddr(r *http.Request, trusted []netip.Prefix) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, _ := netip.ParseAddr(host)
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0 && contains(trusted, addr); i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop
+	}
+	return addr.Unmap()
+}
+
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// An admin-managed entry added to a scope's allow or deny list
+type Rule struct {
+	ID          string    `json:"id"`
+	Scope       string    `json:"scope"`  // "global" or a service path
+	Action      string    `json:"action"` // "allow" or "deny"
+	CIDR        string    `json:"cidr"`   // A bare address matches only itself
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	prefix netip.Prefix
+}
+
+func (r *Rule) validate() error {
+	if r.Scope == "" {
+		r.Scope = GlobalScope
+	}
+	if r.Scope != GlobalScope && !strings.HasPrefix(r.Scope, "/") {
+		return fmt.Errorf("scope must be %q or a service path", GlobalScope)
+	}
+	if r.Action != "allow" && r.Action != "deny" {
+		return fmt.Errorf("action must be allow or deny")
+	}
+
+	prefix, err := ParsePrefix(r.CIDR)
+	if err != nil {
+		return fmt.Errorf("invalid cidr: %v", err)
+	}
+	r.CIDR, r.prefix = prefix.String(), prefix
+	return nil
+}
+
+// Outcome of checking a client address against a scope
+type Decision struct {
+	Allowed bool
+	Reason  string // "denied" when a deny entry matched, "not_allowed" when no allow entry did
+	Match   string // The deny entry that matched
+}
+
+// Holds admin-managed rules and keeps them in sync with other replicas
+type Registry struct {
+	mu       sync.RWMutex
+	rules    map[string]*Rule
+	redis    *storage.RedisClient
+	hashSync *storage.HashSync
+}
+
+func NewRegistry(redis *storage.RedisClient, interval time.Duration) *Registry {
+	r := &Registry{
+		rules: make(map[string]*Rule),
+		redis: redis,
+	}
+	r.hashSync = storage.NewHashSync(redis, redisKey, "ip filter rules", interval, r.apply)
+	return r
+}
+
+// Validates and stores a rule
+func (r *Registry) Add(ctx context.Context, rule Rule) (*Rule, error) {
+	if err := rule.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+
+	rule.ID = uuid.New().String()
+	rule.CreatedAt = time.Now()
+
+	data, err := json.Marshal(&rule)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.redis.HSet(ctx, redisKey, rule.ID, data); err != nil {
+		return nil, fmt.Errorf("failed to persist ip filter rule: %w", err)
+	}
+
+	r.mu.Lock()
+	r.rules[rule.ID] = &rule
+	r.mu.Unlock()
+
+	audit.Record(ctx, "ip_filter.create", "ip_filter", rule.ID, nil, &rule)
+
+	log.Printf("IP filter rule %s added: %s %s on %s", rule.ID, rule.Action, rule.CIDR, rule.Scope)
+	return &rule, nil
+}
+
+// Deletes a rule
+func (r *Registry) Remove(ctx context.Context, id string) error {
+	r.mu.Lock()
+	rule, exists := r.rules[id]
+	delete(r.rules, id)
+	r.mu.Unlock()
+
+	if !exists {
+		return ErrNotFound
+	}
+	if err := r.redis.HDel(ctx, redisKey, id); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, "ip_filter.delete", "ip_filter", id, rule, nil)
+	return nil
+}
+
+// Returns all rules, grouped by scope then oldest first
+func (r *Registry) List() []*Rule {
+	r.mu.RLock()
+	rules := make([]*Rule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		rules = append(rules, rule)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Scope != rules[j].Scope {
+			return rules[i].Scope < rules[j].Scope
+		}
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+	return rules
+}
+
+// Checks a client address against a scope's configured list and its rules.
+// Deny entries win; when the scope has any allow entries the address must
+// match one of them.
+func (r *Registry) Decide(scope string, static *List, addr netip.Addr) Decision {
+	addr = addr.Unmap()
+	hasAllow, allowed := false, false
+
+	if static != nil {
+		for _, prefix := range static.Deny {
+			if prefix.Contains(addr) {
+				return Decision{Reason: "denied", Match: prefix.String()}
+			}
+		}
+		for _, prefix := range static.Allow {
+			hasAllow = true
+			allowed = allowed || prefix.Contains(addr)
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rule := range r.rules {
+		if rule.Scope != scope {
+			continue
+		}
+		if rule.Action == "deny" {
+			if rule.prefix.Contains(addr) {
+				return Decision{Reason: "denied", Match: rule.CIDR}
+			}
+			continue
+		}
+		hasAllow = true
+		allowed = allowed || rule.prefix.Contains(addr)
+	}
+
+	if hasAllow && !allowed {
+		return Decision{Reason: "not_allowed"}
+	}
+	return Decision{Allowed: true}
+}
+
+// Loads persisted rules and keeps them in sync with other replicas
+func (r *Registry) Start() {
+	r.hashSync.Start()
+}
+
+// Stops syncing rules
+func (r *Registry) Stop() {
+	r.hashSync.Stop()
+}
+
+// Replaces the rules with those persisted in Redis
+func (r *Registry) apply(persisted map[string]string) []string {
+	rules := make(map[string]*Rule, len(persisted))
+	for id, data := range persisted {
+		var rule Rule
+		if err := json.Unmarshal([]byte(data), &rule); err != nil {
+			log.Printf("Skipping malformed ip filter rule %s: %v", id, err)
+			continue
+		}
+		if err := rule.validate(); err != nil {
+			log.Printf("Skipping invalid ip filter rule %s: %v", id, err)
+			continue
+		}
+		rules[id] = &rule
+	}
+
+	r.mu.Lock()
+	r.rules = rules
+	r.mu.Unlock()
+
+	return nil
+}
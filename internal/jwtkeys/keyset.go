@@ -0,0 +1,183 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Settings for one signing key
+type KeyConfig struct {
+	ID             string
+	Algorithm      string // "RS256" or "EdDSA"
+	PrivateKeyFile string // PEM encoded PKCS#1 or PKCS#8 private key
+}
+
+// An asymmetric key identified by its key ID
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+}
+
+// Public key in JSON Web Key form
+type JWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// Signs tokens with one key and verifies tokens signed by any key in the set,
+// so a previous key keeps verifying while its tokens expire after a rotation
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// Loads the configured keys; signingID selects the key new tokens are signed with
+func Load(configs []KeyConfig, signingID string) (*KeySet, error) {
+	if len(configs) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+
+	set := &KeySet{keys: make(map[string]*Key, len(configs))}
+	for _, cfg := range configs {
+		key, err := loadKey(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", cfg.ID, err)
+		}
+		if _, exists := set.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate key id: %s", key.ID)
+		}
+		set.keys[key.ID] = key
+	}
+
+	if signingID == "" {
+		signingID = configs[0].ID
+	}
+	set.signing = set.keys[signingID]
+	if set.signing == nil {
+		return nil, fmt.Errorf("unknown signing key id: %s", signingID)
+	}
+
+	return set, nil
+}
+
+// Signs claims with the current signing key
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signing.Method, claims)
+	token.Header["kid"] = s.signing.ID
+	return token.SignedString(s.signing.Private)
+}
+
+// Resolves the verification key for a token by its kid header
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, exists := s.keys[kid]
+	if !exists {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Private.Public(), nil
+}
+
+// Returns the algorithms tokens may be signed with
+func (s *KeySet) Algorithms() []string {
+	seen := make(map[string]bool)
+	algorithms := make([]string, 0, len(s.keys))
+	for _, key := range s.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			algorithms = append(algorithms, alg)
+		}
+	}
+	return algorithms
+}
+
+// Returns the public keys for a JWKS document
+func (s *KeySet) JWKs() []JWK {
+	jwks := make([]JWK, 0, len(s.keys))
+	for _, key := range s.keys {
+		jwk := JWK{Kid: key.ID, Alg: key.Method.Alg(), Use: "sig"}
+
+		switch public := key.Private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+
+		jwks = append(jwks, jwk)
+	}
+	return jwks
+}
+
+func loadKey(cfg KeyConfig) (*Key, error) {
+	if cfg.ID == "" {
+		return nil, errors.New("id is required")
+	}
+
+	data, err := os.ReadFile(cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+
+	var private interface{}
+	if block.Type == "RSA PRIVATE KEY" {
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	key := &Key{ID: cfg.ID}
+	switch cfg.Algorithm {
+	case "RS256":
+		rsaKey, ok := private.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("RS256 requires an RSA private key")
+		}
+		key.Method = jwt.SigningMethodRS256
+		key.Private = rsaKey
+	case "EdDSA":
+		edKey, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("EdDSA requires an Ed25519 private key")
+		}
+		key.Method = jwt.SigningMethodEdDSA
+		key.Private = edKey
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", cfg.Algorithm)
+	}
+
+	return key, nil
+}
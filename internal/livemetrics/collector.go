@@ -0,0 +1,174 @@
+package livemetrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Seconds of history kept per service; snapshots can cover up to this much
+const MaxWindow = 60
+
+// Latency buckets grow by 25% from 1ms, reaching past a minute
+const (
+	latencyBuckets = 50
+	latencyGrowth  = 1.25
+)
+
+// Upper bound in milliseconds of each latency bucket
+var latencyBounds = func() [latencyBuckets]float64 {
+	var bounds [latencyBuckets]float64
+	bound := 1.0
+	for i := range bounds {
+		bounds[i] = bound
+		bound *= latencyGrowth
+	}
+	return bounds
+}()
+
+// Requests completed within one second
+type second struct {
+	unix         int64
+	requests     int64
+	clientErrors int64
+	serverErrors int64
+	latencies    [latencyBuckets + 1]int64 // Last bucket holds anything slower
+}
+
+// Per-second history for one service
+type ring struct {
+	seconds [MaxWindow]second
+}
+
+func (r *ring) slot(unix int64) *second {
+	s := &r.seconds[unix%MaxWindow]
+	if s.unix != unix {
+		*s = second{unix: unix}
+	}
+	return s
+}
+
+// Traffic figures over a recent window
+type Stats struct {
+	Requests        int64   `json:"requests"`
+	RPS             float64 `json:"rps"`
+	ErrorRate       float64 `json:"error_rate"` // Percent of requests answered with 5xx
+	ClientErrorRate float64 `json:"client_error_rate"`
+	P50Ms           float64 `json:"p50_ms"`
+	P95Ms           float64 `json:"p95_ms"`
+	P99Ms           float64 `json:"p99_ms"`
+}
+
+// Aggregates proxied requests in memory for live dashboards. Memory is fixed
+// per service; percentiles are accurate to one latency bucket (25%).
+type Collector struct {
+	mu       sync.Mutex
+	services map[string]*ring
+	now      func() time.Time
+}
+
+func NewCollector() *Collector {
+	return &Collector{
+		services: make(map[string]*ring),
+		now:      time.Now,
+	}
+}
+
+// Records a completed request to a service
+func (c *Collector) Record(service string, statusCode int, latency time.Duration) {
+	ms := float64(latency.Microseconds()) / 1000
+	bucket := sort.SearchFloat64s(latencyBounds[:], ms)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, exists := c.services[service]
+	if !exists {
+		r = &ring{}
+		c.services[service] = r
+	}
+
+	s := r.slot(c.now().Unix())
+	s.requests++
+	switch {
+	case statusCode >= 500:
+		s.serverErrors++
+	case statusCode >= 400:
+		s.clientErrors++
+	}
+	s.latencies[bucket]++
+}
+
+// Returns stats over the last window seconds, overall and per service. The
+// current, partial second is left out so rates don't dip at every tick.
+func (c *Collector) Snapshot(window int) (Stats, map[string]Stats) {
+	if window <= 0 || window > MaxWindow-1 {
+		window = MaxWindow - 1
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	end := c.now().Unix()
+	start := end - int64(window)
+
+	var total second
+	services := make(map[string]Stats, len(c.services))
+	for name, r := range c.services {
+		var sum second
+		for i := range r.seconds {
+			s := &r.seconds[i]
+			if s.unix < start || s.unix >= end {
+				continue
+			}
+			add(&sum, s)
+		}
+		add(&total, &sum)
+		services[name] = stats(&sum, window)
+	}
+
+	return stats(&total, window), services
+}
+
+func add(into, s *second) {
+	into.requests += s.requests
+	into.clientErrors += s.clientErrors
+	into.serverErrors += s.serverErrors
+	for i, n := range s.latencies {
+		into.latencies[i] += n
+	}
+}
+
+func stats(s *second, window int) Stats {
+	result := Stats{
+		Requests: s.requests,
+		RPS:      float64(s.requests) / float64(window),
+	}
+	if s.requests == 0 {
+		return result
+	}
+
+	result.ErrorRate = float64(s.serverErrors) / float64(s.requests) * 100
+	result.ClientErrorRate = float64(s.clientErrors) / float64(s.requests) * 100
+	result.P50Ms = percentile(s, 0.50)
+	result.P95Ms = percentile(s, 0.95)
+	result.P99Ms = percentile(s, 0.99)
+	return result
+}
+
+// Returns the upper bound of the bucket holding the given percentile
+func percentile(s *second, p float64) float64 {
+	rank := int64(math.Ceil(p * float64(s.requests)))
+	var seen int64
+	for i, n := range s.latencies {
+		seen += n
+		if seen >= rank {
+			if i == latencyBuckets {
+				return math.Round(latencyBounds[latencyBuckets-1]*latencyGrowth*100) / 100
+			}
+			return math.Round(latencyBounds[i]*100) / 100
+		}
+	}
+	return 0
+}
@@ -5,11 +5,13 @@ import "sync"
 type LeastConnections struct {
 	mu          sync.RWMutex
 	connections map[string]int
+	longLived   map[string]int // SSE, websockets and long-polls tracked separately
 }
 
 func NewLeastConnections() *LeastConnections {
 	return &LeastConnections{
 		connections: make(map[string]int),
+		longLived:   make(map[string]int),
 	}
 }
 
@@ -26,7 +28,7 @@ func (l *LeastConnections) Next(targets []string) string {
 	minConn := int(^uint(0) >> 1) // Max int
 
 	for _, target := range targets {
-		conn := l.connections[target]
+		conn := l.connections[target] + l.longLived[target]
 		if conn < minConn {
 			minConn = conn
 			selected = target
@@ -58,6 +60,23 @@ func (l *LeastConnections) Decrement(target string) {
 	}
 }
 
+// Increments the long-lived connection count for a target
+func (l *LeastConnections) IncrementLongLived(target string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.longLived[target]++
+}
+
+// Decrements the long-lived connection count for a target
+func (l *LeastConnections) DecrementLongLived(target string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.longLived[target] > 0 {
+		l.longLived[target]--
+	}
+}
+
 // Returns the strategy name
 func (l *LeastConnections) Name() string {
 	return "least_connections"
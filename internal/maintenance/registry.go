@@ -0,0 +1,235 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/audit"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Redis hash holding maintenance windows shared by all replicas
+const redisKey = "gateway:maintenance"
+
+var (
+	ErrNotFound      = errors.New("maintenance window not found")
+	ErrInvalidWindow = errors.New("invalid maintenance window")
+)
+
+// Response sent instead of proxying while a window is active
+type Response struct {
+	Status            int    `json:"status"`                        // Default: 503
+	Format            string `json:"format"`                        // "json" (default) or "html"
+	Body              string `json:"body,omitempty"`                // Default: the localized maintenance message
+	RetryAfterSeconds int    `json:"retry_after_seconds,omitempty"` // Default: until the window ends, when it has an end
+}
+
+// A period during which a service answers with the maintenance response
+type Window struct {
+	ID        string     `json:"id"`
+	Service   string     `json:"service"`           // Service path
+	Reason    string     `json:"reason,omitempty"`  // Shown to admins only
+	StartsAt  time.Time  `json:"starts_at"`         // Default: now
+	EndsAt    *time.Time `json:"ends_at,omitempty"` // Open-ended windows last until removed
+	Response  Response   `json:"response"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Reports whether the window is in effect at now
+func (w *Window) Active(now time.Time) bool {
+	return !now.Before(w.StartsAt) && !w.Ended(now)
+}
+
+// Reports whether the window has passed
+func (w *Window) Ended(now time.Time) bool {
+	return w.EndsAt != nil && !now.Before(*w.EndsAt)
+}
+
+// Seconds clients should wait before retrying, or 0 when unknown
+func (w *Window) RetryAfter(now time.Time) int {
+	if w.Response.RetryAfterSeconds > 0 {
+		return w.Response.RetryAfterSeconds
+	}
+	if w.EndsAt != nil {
+		return int(w.EndsAt.Sub(now).Round(time.Second).Seconds())
+	}
+	return 0
+}
+
+func (w *Window) validate(now time.Time) error {
+	if !strings.HasPrefix(w.Service, "/") {
+		return fmt.Errorf("service must be a service path")
+	}
+	if w.StartsAt.IsZero() {
+		w.StartsAt = now
+	}
+	if w.EndsAt != nil && !w.EndsAt.After(w.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	if w.Ended(now) {
+		return fmt.Errorf("window has already ended")
+	}
+
+	if w.Response.Status == 0 {
+		w.Response.Status = http.StatusServiceUnavailable
+	}
+	if w.Response.Status < 400 || w.Response.Status > 599 {
+		return fmt.Errorf("invalid response status: %d", w.Response.Status)
+	}
+	if w.Response.Format == "" {
+		w.Response.Format = "json"
+	}
+	if w.Response.Format != "json" && w.Response.Format != "html" {
+		return fmt.Errorf("unknown response format: %s", w.Response.Format)
+	}
+	if w.Response.Format == "json" && w.Response.Body != "" && !json.Valid([]byte(w.Response.Body)) {
+		return fmt.Errorf("body must be valid JSON for the json format")
+	}
+	if w.Response.RetryAfterSeconds < 0 {
+		return fmt.Errorf("retry_after_seconds must not be negative")
+	}
+	return nil
+}
+
+// Holds maintenance windows and keeps them in sync with other replicas
+type Registry struct {
+	mu       sync.RWMutex
+	windows  map[string]*Window
+	redis    *storage.RedisClient
+	hashSync *storage.HashSync
+}
+
+func NewRegistry(redis *storage.RedisClient, interval time.Duration) *Registry {
+	r := &Registry{
+		windows: make(map[string]*Window),
+		redis:   redis,
+	}
+	r.hashSync = storage.NewHashSync(redis, redisKey, "maintenance windows", interval, r.apply)
+	return r
+}
+
+// Validates and stores a window
+func (r *Registry) Add(ctx context.Context, window Window) (*Window, error) {
+	now := time.Now()
+	if err := window.validate(now); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWindow, err)
+	}
+
+	window.ID = uuid.New().String()
+	window.CreatedAt = now
+
+	data, err := json.Marshal(&window)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.redis.HSet(ctx, redisKey, window.ID, data); err != nil {
+		return nil, fmt.Errorf("failed to persist maintenance window: %w", err)
+	}
+
+	r.mu.Lock()
+	r.windows[window.ID] = &window
+	r.mu.Unlock()
+
+	audit.Record(ctx, "maintenance.create", "service", window.Service, nil, &window)
+
+	log.Printf("Maintenance window %s added for %s (starts %s)", window.ID, window.Service, window.StartsAt.Format(time.RFC3339))
+	return &window, nil
+}
+
+// Ends a window early or cancels a scheduled one
+func (r *Registry) Remove(ctx context.Context, id string) error {
+	r.mu.Lock()
+	window, exists := r.windows[id]
+	delete(r.windows, id)
+	r.mu.Unlock()
+
+	if !exists {
+		return ErrNotFound
+	}
+	if err := r.redis.HDel(ctx, redisKey, id); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, "maintenance.delete", "service", window.Service, window, nil)
+	return nil
+}
+
+// Returns the windows that haven't ended, soonest first
+func (r *Registry) List() []*Window {
+	now := time.Now()
+
+	r.mu.RLock()
+	windows := make([]*Window, 0, len(r.windows))
+	for _, window := range r.windows {
+		if !window.Ended(now) {
+			windows = append(windows, window)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].StartsAt.Before(windows[j].StartsAt)
+	})
+	return windows
+}
+
+// Returns the window in effect for a service, or nil
+func (r *Registry) Active(service string) *Window {
+	now := time.Now()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var active *Window
+	for _, window := range r.windows {
+		if window.Service == service && window.Active(now) && (active == nil || window.StartsAt.After(active.StartsAt)) {
+			active = window
+		}
+	}
+	return active
+}
+
+// Loads persisted windows and keeps them in sync with other replicas
+func (r *Registry) Start() {
+	r.hashSync.Start()
+}
+
+// Stops syncing windows
+func (r *Registry) Stop() {
+	r.hashSync.Stop()
+}
+
+// Replaces the windows with those persisted in Redis, returning ended ones to delete
+func (r *Registry) apply(persisted map[string]string) []string {
+	now := time.Now()
+	windows := make(map[string]*Window, len(persisted))
+	var ended []string
+
+	for id, data := range persisted {
+		var window Window
+		if err := json.Unmarshal([]byte(data), &window); err != nil {
+			log.Printf("Skipping malformed maintenance window %s: %v", id, err)
+			continue
+		}
+		if window.Ended(now) {
+			ended = append(ended, id)
+			continue
+		}
+		windows[id] = &window
+	}
+
+	r.mu.Lock()
+	r.windows = windows
+	r.mu.Unlock()
+
+	return ended
+}
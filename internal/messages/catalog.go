@@ -0,0 +1,180 @@
+package messages
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Message keys surfaced to end users
+const (
+	RateLimited        = "rate_limited"
+	InvalidAPIKey      = "invalid_api_key"
+	Maintenance        = "maintenance"
+	NoHealthyBackends  = "no_healthy_backends"
+	ServiceUnavailable = "service_unavailable"
+	AtCapacity         = "at_capacity"
+	UpstreamThrottled  = "upstream_throttled"
+	InvalidToken       = "invalid_token"
+	IPDenied           = "ip_denied"
+	CountryDenied      = "country_denied"
+	BotBlocked         = "bot_blocked"
+	UntrustedRequestID = "untrusted_request_id"
+	RouteNotFound      = "route_not_found"
+	UnsupportedVersion = "unsupported_version"
+	ClientCertRequired = "client_cert_required"
+)
+
+// Built-in English messages used when no override matches
+var defaults = map[string]string{
+	RateLimited:        "Rate limit exceeded",
+	InvalidAPIKey:      "Invalid API key",
+	Maintenance:        "Service is under maintenance",
+	NoHealthyBackends:  "No healthy backend servers available",
+	ServiceUnavailable: "Service temporarily unavailable",
+	AtCapacity:         "Gateway is at capacity, try again shortly",
+	UpstreamThrottled:  "Upstream service rate limit reached, retry in {{.RetryAfter}} seconds",
+	InvalidToken:       "Missing or invalid access token",
+	IPDenied:           "Access from this address is not allowed",
+	CountryDenied:      "Access from your country is not allowed",
+	BotBlocked:         "Automated access is not allowed",
+	UntrustedRequestID: "Requests from this network may not set {{.Header}} or traceparent",
+	RouteNotFound:      "No route matches this request",
+	UnsupportedVersion: "API version {{.Version}} is not supported",
+	ClientCertRequired: "A trusted client certificate is required",
+}
+
+// Context key the catalog is stored under
+const contextKey = "messages"
+
+// Holds message templates per locale
+type Catalog struct {
+	defaultLocale string
+	templates     map[string]map[string]*template.Template
+}
+
+var defaultCatalog, _ = NewCatalog("en", nil)
+
+// Parses operator-provided templates keyed by locale then message key
+func NewCatalog(defaultLocale string, overrides map[string]map[string]string) (*Catalog, error) {
+	if defaultLocale == "" {
+		defaultLocale = "en"
+	}
+
+	c := &Catalog{
+		defaultLocale: strings.ToLower(defaultLocale),
+		templates:     make(map[string]map[string]*template.Template),
+	}
+
+	// Built-in messages are the English baseline that overrides can replace
+	c.templates["en"] = make(map[string]*template.Template)
+	for key, text := range defaults {
+		c.templates["en"][key] = template.Must(template.New("en/" + key).Parse(text))
+	}
+
+	for locale, msgs := range overrides {
+		locale = strings.ToLower(locale)
+		if c.templates[locale] == nil {
+			c.templates[locale] = make(map[string]*template.Template)
+		}
+		for key, text := range msgs {
+			tmpl, err := template.New(locale + "/" + key).Option("missingkey=zero").Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("message %s (%s): %w", key, locale, err)
+			}
+			c.templates[locale][key] = tmpl
+		}
+	}
+
+	return c, nil
+}
+
+// Renders a message in the best locale for an Accept-Language header
+func (c *Catalog) Render(acceptLanguage, key string, data map[string]interface{}) (string, string) {
+	for _, locale := range append(preferredLocales(acceptLanguage), c.defaultLocale) {
+		tmpl := c.lookup(locale, key)
+		if tmpl == nil {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err == nil {
+			return buf.String(), locale
+		}
+	}
+
+	return defaults[key], "en"
+}
+
+// Finds a template for an exact locale or its base language
+func (c *Catalog) lookup(locale, key string) *template.Template {
+	if tmpl := c.templates[locale][key]; tmpl != nil {
+		return tmpl
+	}
+	if base, _, found := strings.Cut(locale, "-"); found {
+		return c.templates[base][key]
+	}
+	return nil
+}
+
+// Returns the locales of an Accept-Language header ordered by quality
+func preferredLocales(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+
+	var locales []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		locales = append(locales, weighted{locale: strings.ToLower(tag), q: q})
+	}
+
+	sort.SliceStable(locales, func(i, j int) bool {
+		return locales[i].q > locales[j].q
+	})
+
+	result := make([]string, 0, len(locales))
+	for _, l := range locales {
+		result = append(result, l.locale)
+	}
+	return result
+}
+
+// Makes the catalog available to handlers further down the chain
+func Middleware(catalog *Catalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(contextKey, catalog)
+		c.Next()
+	}
+}
+
+// Renders a message for the request's preferred language
+func Localize(c *gin.Context, key string, data map[string]interface{}) string {
+	catalog := defaultCatalog
+	if value, exists := c.Get(contextKey); exists {
+		catalog = value.(*Catalog)
+	}
+
+	message, locale := catalog.Render(c.GetHeader("Accept-Language"), key, data)
+	c.Header("Content-Language", locale)
+	return message
+}
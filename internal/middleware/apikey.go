@@ -1,18 +1,26 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
+	"github.com/aman-churiwal/api-gateway/internal/messages"
 	"github.com/aman-churiwal/api-gateway/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
-func APIKeyValidator(apiKeyService *service.APIKeyService) gin.HandlerFunc {
+// Identifies the consumer by its X-API-Key, or by an access token the gateway
+// issued for a key when oauth is set
+func APIKeyValidator(apiKeyService *service.APIKeyService, oauth *service.OAuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		apiKeyHeader := c.GetHeader("X-API-Key")
 
 		if apiKeyHeader == "" {
+			if oauth != nil {
+				clientToken(c, oauth)
+				return
+			}
 			c.Next()
 			return
 		}
@@ -24,9 +32,18 @@ func APIKeyValidator(apiKeyService *service.APIKeyService) gin.HandlerFunc {
 		ctx := c.Request.Context()
 		apiKey, err := apiKeyService.Validate(ctx, apiKeyHeader)
 
-		if err != nil || apiKey == nil {
+		// The key couldn't be checked and the degradation policy didn't admit it
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": messages.Localize(c, messages.ServiceUnavailable, nil),
+			})
+			c.Abort()
+			return
+		}
+
+		if apiKey == nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid API key",
+				"error": messages.Localize(c, messages.InvalidAPIKey, nil),
 			})
 			c.Abort()
 			return
@@ -35,8 +52,47 @@ func APIKeyValidator(apiKeyService *service.APIKeyService) gin.HandlerFunc {
 		c.Set("api_key", apiKey)
 		c.Set("api_key_id", apiKey.ID)
 		c.Set("api_key_tier", apiKey.Tier)
+		c.Set("auth_method", "api_key")
+
+		go apiKeyService.RecordUsage(context.Background(), apiKey, c.ClientIP())
+
+		c.Next()
+	}
+}
+
+// Accepts a gateway-issued access token in place of the API key. Bearer
+// tokens from other issuers are left to forward auth or introspection.
+func clientToken(c *gin.Context, oauth *service.OAuthService) {
+	token := consumerToken(c, "Authorization")
+	if token == "" || !oauth.Issued(token) {
+		c.Next()
+		return
+	}
+
+	apiKey, err := oauth.ValidateToken(token)
+	if err != nil {
+		rejectToken(c)
+		return
+	}
+
+	c.Set("api_key", apiKey)
+	c.Set("api_key_id", apiKey.ID)
+	c.Set("api_key_tier", apiKey.Tier)
+	c.Set("auth_method", "oauth")
+
+	c.Next()
+}
 
-		go apiKeyService.UpdateLastUsed(ctx, apiKey.ID)
+// Rejects requests that did not present a valid API key
+func RequireAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("api_key"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": messages.Localize(c, messages.InvalidAPIKey, nil),
+			})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
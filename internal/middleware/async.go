@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/aman-churiwal/api-gateway/internal/asyncjobs"
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// Which requests of a service are queued instead of proxied
+type AsyncOptions struct {
+	Service        string
+	Paths          []string // Path prefixes (empty: all)
+	Methods        []string // Empty: POST, PUT, PATCH and DELETE
+	MaxBodyBytes   int64
+	CallbackHeader string // Names the URL the result is posted to
+	StatusPath     string // Jobs are polled at StatusPath + "/" + ID
+}
+
+// Accepts matching requests with 202 and a status URL, and queues them for a
+// worker to forward. It belongs just before the proxy, after every policy.
+func AsyncRequests(queue *asyncjobs.Queue, opts AsyncOptions) gin.HandlerFunc {
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+
+	return func(c *gin.Context) {
+		if !slices.Contains(opts.Methods, c.Request.Method) || !scanPath(c.Request.URL.Path, opts.Paths) {
+			c.Next()
+			return
+		}
+
+		job := &asyncjobs.Job{
+			Service: opts.Service,
+			Method:  c.Request.Method,
+			Path:    c.Request.URL.Path,
+		}
+		if apiKeyID, exists := c.Get("api_key_id"); exists {
+			job.APIKeyID = fmt.Sprint(apiKeyID)
+		}
+		if callback := c.GetHeader(opts.CallbackHeader); callback != "" {
+			if err := queue.CheckCallback(callback); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			job.CallbackURL = callback
+		}
+
+		var body []byte
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			var err error
+			body, err = io.ReadAll(io.LimitReader(c.Request.Body, opts.MaxBodyBytes+1))
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+				return
+			}
+			if int64(len(body)) > opts.MaxBodyBytes {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body exceeds the async size limit"})
+				return
+			}
+		}
+
+		header := c.Request.Header.Clone()
+		header.Del(opts.CallbackHeader)
+		req := &asyncjobs.Request{RawQuery: c.Request.URL.RawQuery, Header: header, Body: body}
+		if err := queue.Enqueue(c.Request.Context(), job, req); err != nil {
+			logging.FromContext(c.Request.Context()).Error("Failed to queue async request", "error", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to queue request"})
+			return
+		}
+
+		statusURL := strings.TrimSuffix(opts.StatusPath, "/") + "/" + job.ID
+		c.Set("async_job", job.ID)
+		c.Header("Location", statusURL)
+		c.AbortWithStatusJSON(http.StatusAccepted, gin.H{
+			"id":         job.ID,
+			"status":     job.Status,
+			"status_url": statusURL,
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/aman-churiwal/api-gateway/internal/audit"
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// Writes an audit record for every mutating admin request. Changes recorded by
+// the handler become one record each, with a before/after diff; requests that
+// recorded nothing, including failed ones, get a record named after the route.
+// Must run after RequireAuth.
+func Audit(repo *repository.AuditLogRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		ctx, collector := audit.NewContext(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		base := models.AuditLog{
+			ActorID: c.GetString("user_id"),
+			Actor:   c.GetString("email"),
+			IP:      c.ClientIP(),
+			Method:  c.Request.Method,
+			Path:    c.Request.URL.Path,
+			Status:  c.Writer.Status(),
+		}
+
+		changes := collector.Changes()
+		if len(changes) == 0 {
+			changes = []audit.Change{{Action: strings.ToLower(c.Request.Method) + " " + c.FullPath()}}
+		}
+
+		// Record even if the admin disconnected
+		writeCtx := context.WithoutCancel(ctx)
+		for _, change := range changes {
+			entry := base
+			entry.Action = change.Action
+			entry.ResourceType = change.ResourceType
+			entry.ResourceID = change.ResourceID
+			entry.Changes = audit.Diff(change.Before, change.After)
+
+			if err := repo.Create(writeCtx, &entry); err != nil {
+				logging.FromContext(c.Request.Context()).Error("Failed to write audit record", "action", entry.Action, "error", err)
+			}
+		}
+	}
+}
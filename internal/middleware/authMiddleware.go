@@ -4,12 +4,16 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/aman-churiwal/api-gateway/internal/models"
 	"github.com/aman-churiwal/api-gateway/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// Validates JWT token and requires authentication
-func RequireAuth(authService *service.AuthService) gin.HandlerFunc {
+// Validates JWT token and requires authentication. When serviceAccounts is set,
+// service account tokens are also accepted, limited to the admin routes their
+// scopes cover.
+func RequireAuth(authService *service.AuthService, serviceAccounts *service.ServiceAccountService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Extract token from Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -33,6 +37,11 @@ func RequireAuth(authService *service.AuthService) gin.HandlerFunc {
 
 		tokenString := parts[1]
 
+		if serviceAccounts != nil && strings.HasPrefix(tokenString, service.ServiceTokenPrefix) {
+			authenticateServiceAccount(c, serviceAccounts, tokenString)
+			return
+		}
+
 		// Validate token
 		claims, err := authService.ValidateToken(tokenString)
 		if err != nil {
@@ -43,10 +52,174 @@ func RequireAuth(authService *service.AuthService) gin.HandlerFunc {
 			return
 		}
 
+		// Reject tokens revoked by logout
+		revoked, err := authService.IsRevoked(c.Request.Context(), claims)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Token revocation check failed",
+			})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Token has been revoked",
+			})
+			c.Abort()
+			return
+		}
+
 		// Store user info in context
 		c.Set("user_id", claims["user_id"])
 		c.Set("email", claims["email"])
 		c.Set("role", claims["role"])
+		if orgID, ok := claims["org_id"].(string); ok {
+			c.Set("org_id", orgID)
+		}
+		c.Set("claims", claims)
+
+		c.Next()
+	}
+}
+
+// Authenticates a service account token and enforces its scopes
+func authenticateServiceAccount(c *gin.Context, serviceAccounts *service.ServiceAccountService, token string) {
+	principal, err := serviceAccounts.Authenticate(c.Request.Context(), token)
+	if err == service.ErrInvalidServiceToken {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired token",
+		})
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Token validation failed",
+		})
+		c.Abort()
+		return
+	}
+
+	// Checked against the matched route so the scope agrees with what will run
+	if !principal.Allows(c.Request.Method, c.FullPath()) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Token scopes do not allow this operation",
+		})
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", principal.Account.ID.String())
+	c.Set("email", "service-account:"+principal.Account.Name)
+	c.Set("role", models.RoleServiceAccount)
+	c.Set("scopes", []string(principal.Token.Scopes))
+
+	c.Next()
+}
+
+// Limits viewers to read-only admin requests; admins may do anything. Service
+// accounts were already held to their token scopes by RequireAuth.
+func AdminAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.GetString("role") {
+		case models.RoleAdmin, models.RoleServiceAccount:
+			c.Next()
+			return
+		case models.RoleViewer:
+			if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Insufficient role for this operation",
+		})
+		c.Abort()
+	}
+}
+
+// Holds callers that belong to an organization to the admin routes that are
+// scoped to it; the rest of the admin API manages the whole gateway and is
+// left to platform operators. Handlers still filter what those routes return.
+func OrgScope(routes ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		allowed[route] = true
+	}
+
+	return func(c *gin.Context) {
+		if c.GetString("org_id") == "" || allowed[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Not available to organization members",
+		})
+		c.Abort()
+	}
+}
+
+// Authenticates developer portal tokens. Admin tokens are rejected here just
+// as portal tokens are by RequireAuth, so the two realms stay apart.
+func RequirePortalAuth(authService *service.AuthService, developers *service.DeveloperService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || tokenString == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Authorization header required. Use: Bearer <token>",
+			})
+			c.Abort()
+			return
+		}
+
+		claims, err := authService.ValidatePortalToken(tokenString)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or expired token",
+			})
+			c.Abort()
+			return
+		}
+
+		revoked, err := authService.IsRevoked(c.Request.Context(), claims)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Token revocation check failed",
+			})
+			c.Abort()
+			return
+		}
+
+		// Disabled developers lose access before their token expires
+		developerID, _ := claims["developer_id"].(string)
+		id, err := uuid.Parse(developerID)
+		if revoked || err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or expired token",
+			})
+			c.Abort()
+			return
+		}
+		developer, err := developers.Get(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Developer lookup failed",
+			})
+			c.Abort()
+			return
+		}
+		if developer == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or expired token",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("developer", developer)
+		c.Set("claims", claims)
 
 		c.Next()
 	}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aman-churiwal/api-gateway/internal/capture"
+	"github.com/gin-gonic/gin"
+)
+
+// Stores truncated, redacted request and response bodies on the request log
+// while body capture is switched on for the service
+func BodyCapture(registry *capture.Registry, service string, redactor *capture.Redactor, maxBytes int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !registry.Enabled(service) {
+			c.Next()
+			return
+		}
+
+		requestBody := ""
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			data, _ := io.ReadAll(io.LimitReader(c.Request.Body, int64(maxBytes)+1))
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(data), c.Request.Body), c.Request.Body}
+			requestBody = capturedBody(redactor, c.Request.Header, data, maxBytes)
+		}
+		c.Set("request_headers", redactor.Headers(c.Request.Header))
+		c.Set("request_body", requestBody)
+
+		writer := &captureWriter{ResponseWriter: c.Writer, maxBytes: maxBytes}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Set("response_body", capturedBody(redactor, writer.Header(), writer.body.Bytes(), maxBytes))
+	}
+}
+
+// Renders a captured body for the log, or notes why it was left out
+func capturedBody(redactor *capture.Redactor, header http.Header, data []byte, maxBytes int) string {
+	if len(data) == 0 {
+		return ""
+	}
+	if encoding := header.Get("Content-Encoding"); encoding != "" && encoding != "identity" {
+		return fmt.Sprintf("[%s encoded body omitted]", encoding)
+	}
+
+	truncated := len(data) > maxBytes
+	data = trimPartialRune(data, maxBytes)
+	if contentType := header.Get("Content-Type"); !textual(contentType) || !utf8.Valid(data) {
+		return fmt.Sprintf("[%s body omitted]", contentType)
+	}
+
+	body := redactor.Body(string(data))
+	if truncated {
+		body += "...[truncated]"
+	}
+	return body
+}
+
+// Reports whether a content type is text worth logging
+func textual(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return contentType == "" ||
+		strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "json") ||
+		strings.Contains(contentType, "xml") ||
+		strings.Contains(contentType, "x-www-form-urlencoded")
+}
+
+// Truncates to maxBytes, dropping a multi-byte character cut off at the end so
+// it isn't mistaken for binary
+func trimPartialRune(data []byte, maxBytes int) []byte {
+	if len(data) > maxBytes {
+		data = data[:maxBytes]
+	}
+	for i := 0; i < utf8.UTFMax-1 && len(data) > 0 && !utf8.Valid(data); i++ {
+		data = data[:len(data)-1]
+	}
+	return data
+}
+
+// Copies the start of the response body while passing it through
+type captureWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	maxBytes int
+}
+
+func (w *captureWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// Lets http.ResponseController reach the underlying writer
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Keeps one byte past the limit so truncation can be reported
+func (w *captureWriter) capture(data []byte) {
+	if remaining := w.maxBytes + 1 - w.body.Len(); remaining > 0 {
+		if len(data) > remaining {
+			data = data[:remaining]
+		}
+		w.body.Write(data)
+	}
+}
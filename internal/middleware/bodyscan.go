@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/bodyscan"
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// Budgets and policy for request body scanning
+type BodyScanOptions struct {
+	Paths          []string      // Path prefixes to scan (empty: all)
+	MaxBytes       int64         // Bodies above this size are not scanned
+	Timeout        time.Duration // Time budget for a scan
+	RejectOversize bool          // Reject bodies above MaxBytes instead of passing them unscanned
+	FailOpen       bool          // Forward the request when the scanner errors or times out
+}
+
+// Streams request bodies through a scanner and rejects flagged uploads before they reach backends
+func BodyScan(scanner bodyscan.Scanner, opts BodyScanOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody || !scanPath(c.Request.URL.Path, opts.Paths) {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > opts.MaxBytes {
+			if opts.RejectOversize {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+					"error": "Request body exceeds the scanning size limit",
+				})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), opts.Timeout)
+		defer cancel()
+
+		// The scanner consumes the body while a copy is kept for forwarding
+		var buffered bytes.Buffer
+		limited := io.LimitReader(c.Request.Body, opts.MaxBytes+1)
+		verdict, err := scanner.Scan(ctx, io.TeeReader(limited, &buffered))
+
+		// Drain anything the scanner did not read so the full body is forwarded
+		io.Copy(&buffered, limited)
+		oversize := int64(buffered.Len()) > opts.MaxBytes
+		c.Request.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(&buffered, c.Request.Body), c.Request.Body}
+
+		if oversize {
+			if opts.RejectOversize {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+					"error": "Request body exceeds the scanning size limit",
+				})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("Body scan failed", "scanner", scanner.Name(), "error", err)
+			if opts.FailOpen {
+				c.Next()
+				return
+			}
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Request body could not be scanned",
+			})
+			c.Abort()
+			return
+		}
+
+		if !verdict.Clean {
+			logging.FromContext(c.Request.Context()).Warn("Body rejected", "scanner", scanner.Name(), "reason", verdict.Reason)
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":  "Request body was rejected by content scanning",
+				"reason": verdict.Reason,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func scanPath(path string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
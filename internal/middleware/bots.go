@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/bots"
+	"github.com/aman-churiwal/api-gateway/internal/messages"
+	"github.com/aman-churiwal/api-gateway/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// Header telling the backend why the gateway suspects a bot
+const botHeader = "X-Gateway-Bot"
+
+// Classifies each request and sets "bot_verdict" and "bot_signals" for the
+// request log. Suspected bots are forwarded with X-Gateway-Bot under "tag",
+// held to throttleRPM under "throttle", or rejected under "block".
+func BotDetection(detector *bots.Detector, service, action string, newLimiter ratelimit.Factory, throttleRPM int) gin.HandlerFunc {
+	var throttle ratelimit.Limiter
+	if action == "throttle" {
+		throttle = newLimiter("sliding_window", throttleRPM, time.Minute)
+	}
+
+	return func(c *gin.Context) {
+		// Only the gateway may say a request is from a bot
+		c.Request.Header.Del(botHeader)
+
+		client := c.ClientIP()
+		if apiKeyID, exists := c.Get("api_key_id"); exists {
+			client = fmt.Sprintf("%v", apiKeyID)
+		}
+
+		verdict := detector.Check(c.Request.Context(), c.Request, client)
+		if !verdict.Bot {
+			c.Set("bot_verdict", "human")
+			c.Next()
+			return
+		}
+
+		signals := strings.Join(verdict.Signals, ",")
+		c.Set("bot_verdict", "bot")
+		c.Set("bot_signals", signals)
+
+		switch action {
+		case "block":
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": messages.Localize(c, messages.BotBlocked, nil),
+			})
+			return
+		case "throttle":
+			ctx := c.Request.Context()
+			key := "bots:" + service + ":throttle:" + client
+			if allowed, err := throttle.Allow(ctx, key); err == nil && !allowed {
+				resetTime, _ := throttle.Reset(ctx, key)
+				retryAfter := max(int(time.Until(resetTime).Seconds()), 0)
+
+				c.Header("Retry-After", fmt.Sprintf("%d", retryAfter))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+					"error": messages.Localize(c, messages.RateLimited, map[string]interface{}{
+						"Limit":      throttleRPM,
+						"RetryAfter": retryAfter,
+					}),
+					"retry_after": resetTime.Unix(),
+				})
+				return
+			}
+		}
+
+		c.Request.Header.Set(botHeader, signals)
+		c.Next()
+	}
+}
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/cache"
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/singleflight"
+)
+
+// Largest response body stored in the cache
+const maxCachedBodyBytes = 1 << 20
+
+// Serves GET responses from the cache and stores cacheable backend responses.
+// With coalescing, concurrent misses for the same key wait for the first one
+// and share its response when it is cacheable.
+func ResponseCache(store *cache.Store, policy *cache.Policy) gin.HandlerFunc {
+	var flights singleflight.Group
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		path := c.Request.URL.Path
+		ttl, ok := policy.PathTTL(path)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		bypass := policy.RequestBypass(c.Request.Header)
+		if bypass == "store" {
+			c.Header("X-Cache", "BYPASS")
+			c.Next()
+			return
+		}
+
+		key := policy.Key(path, c.Request.URL.RawQuery, c.Request.Header)
+		if variant := c.GetString("variant"); variant != "" && variant != "control" {
+			key += "@" + variant // A/B variants are served by other targets
+		}
+		if version := c.GetString("api_version"); version != "" {
+			key += "#" + version // Header-selected versions share paths
+		}
+
+		ctx := c.Request.Context()
+		if bypass == "" {
+			if entry, err := store.Get(ctx, key); err == nil && entry != nil {
+				for name, values := range entry.Header {
+					for _, value := range values {
+						c.Writer.Header().Add(name, value)
+					}
+				}
+				c.Header("X-Cache", "HIT")
+				c.Header("Age", strconv.Itoa(int(time.Since(entry.StoredAt).Seconds())))
+				c.Data(entry.StatusCode, entry.Header.Get("Content-Type"), entry.Body)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Header("X-Cache", "MISS")
+		forward := func() *cache.Entry {
+			recorder := &bodyRecorder{ResponseWriter: c.Writer}
+			c.Writer = recorder
+
+			c.Next()
+
+			if recorder.overflow || !cache.Cacheable(c.Writer.Status(), c.Writer.Header()) {
+				return nil
+			}
+			ttl, ok := policy.ResponseTTL(c.Writer.Header(), ttl)
+			if !ok {
+				return nil
+			}
+
+			header := c.Writer.Header().Clone()
+			header.Del("X-Cache")
+			header.Del("X-Request-ID")
+			entry := &cache.Entry{
+				StatusCode: c.Writer.Status(),
+				Header:     header,
+				Body:       recorder.body.Bytes(),
+			}
+
+			logger := logging.FromContext(c.Request.Context())
+			go func() {
+				if err := store.Set(context.Background(), key, entry, ttl); err != nil {
+					logger.Error("Failed to cache response", "path", path, "error", err)
+				}
+			}()
+			return entry
+		}
+
+		if !policy.Coalesce || bypass != "" {
+			forward()
+			return
+		}
+
+		led := false
+		shared, _, _ := flights.Do(key, func() (interface{}, error) {
+			led = true
+			return forward(), nil
+		})
+		if led {
+			return
+		}
+
+		entry := shared.(*cache.Entry)
+		if entry == nil {
+			// The first response can't be shared, so this request makes its own call
+			forward()
+			return
+		}
+		for name, values := range entry.Header {
+			for _, value := range values {
+				c.Writer.Header().Add(name, value)
+			}
+		}
+		c.Header("X-Cache", "COALESCED")
+		c.Data(entry.StatusCode, entry.Header.Get("Content-Type"), entry.Body)
+		c.Abort()
+	}
+}
+
+// Captures the response body for caching
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (r *bodyRecorder) Write(data []byte) (int, error) {
+	if !r.overflow {
+		if r.body.Len()+len(data) > maxCachedBodyBytes {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(data)
+		}
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// Lets http.ResponseController reach the underlying writer
+func (r *bodyRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
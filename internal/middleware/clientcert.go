@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"crypto/x509"
+	"net/http"
+	"slices"
+
+	"github.com/aman-churiwal/api-gateway/internal/messages"
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Known client certificate subject, mapped to a name and rate limit tier
+type CertIdentity struct {
+	Subject string
+	Name    string
+	Tier    string
+}
+
+// Namespace of the stable key IDs given to certificate identities
+var certKeyNamespace = uuid.MustParse("6f1c2b8e-3d4a-5e6f-8a9b-0c1d2e3f4a5b")
+
+// Prefixes the names of unlisted certificates, so a certificate whose common
+// name matches a listed identity's name can't pass as that identity
+const UnlistedCertPrefix = "cert:"
+
+// Turns a verified client certificate into an API-key-like principal when the
+// request carries no API key, so rate limits, logs and analytics treat it as
+// one. Sets "client_cert" to the identity name. Listed identities match the
+// common name or any DNS, URI or email SAN; others are named after their
+// common name, or first SAN, prefixed with "cert:" and given the default tier.
+func ClientCertificate(identities []CertIdentity, defaultTier string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+			c.Next()
+			return
+		}
+
+		name, tier := certIdentity(c.Request.TLS.VerifiedChains[0][0], identities)
+		if name == "" {
+			c.Next()
+			return
+		}
+		if tier == "" {
+			tier = defaultTier
+		}
+		c.Set("client_cert", name)
+
+		if _, exists := c.Get("api_key"); !exists {
+			apiKey := &models.APIKey{
+				ID:        uuid.NewSHA1(certKeyNamespace, []byte(name)),
+				KeyPrefix: "cert",
+				Name:      name,
+				Tier:      tier,
+				IsActive:  true,
+			}
+			c.Set("api_key", apiKey)
+			c.Set("api_key_id", apiKey.ID)
+			c.Set("api_key_tier", apiKey.Tier)
+			c.Set("auth_method", "client_cert")
+		}
+
+		c.Next()
+	}
+}
+
+// Returns the name and tier of a certificate's identity; the tier is empty
+// for unlisted ones
+func certIdentity(cert *x509.Certificate, identities []CertIdentity) (string, string) {
+	subjects := []string{cert.Subject.CommonName}
+	subjects = append(subjects, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		subjects = append(subjects, uri.String())
+	}
+	subjects = append(subjects, cert.EmailAddresses...)
+
+	for _, identity := range identities {
+		if slices.Contains(subjects, identity.Subject) {
+			name := identity.Name
+			if name == "" {
+				name = identity.Subject
+			}
+			return name, identity.Tier
+		}
+	}
+
+	for _, subject := range subjects {
+		if subject != "" {
+			return UnlistedCertPrefix + subject, ""
+		}
+	}
+	return "", ""
+}
+
+// Rejects requests without a verified client certificate, or whose identity
+// isn't allowed. Runs after ClientCertificate.
+func RequireClientCert(allowed []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.GetString("client_cert")
+		if name == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": messages.Localize(c, messages.ClientCertRequired, nil),
+			})
+			return
+		}
+		if len(allowed) > 0 && !slices.Contains(allowed, name) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":       messages.Localize(c, messages.ClientCertRequired, nil),
+				"client_cert": name,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// Brotli level 4 compresses better than gzip at about the same speed
+const brotliLevel = 4
+
+var (
+	gzipWriters   = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	brotliWriters = sync.Pool{New: func() any { return brotli.NewWriterLevel(io.Discard, brotliLevel) }}
+)
+
+// Response compression settings for a service
+type CompressionOptions struct {
+	Encodings    []string // "br" and "gzip", preferred first
+	MinBytes     int
+	ContentTypes []string // Media types; * matches within a part, as in text/* or application/*+json
+}
+
+// Compresses responses for clients that accept it, when the backend sent them
+// uncompressed, the content type is listed and the body reaches MinBytes.
+// Bodies are buffered only until MinBytes, so streaming still works.
+func Compression(opts CompressionOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), opts.Encodings)
+		if encoding == "" || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		w := &compressWriter{ResponseWriter: original, opts: opts, encoding: encoding}
+		c.Writer = w
+		defer func() {
+			w.close()
+			c.Writer = original
+		}()
+
+		c.Next()
+	}
+}
+
+// Picks the encoding the client weighs highest, breaking ties by preference
+func negotiateEncoding(acceptEncoding string, preferred []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		weight := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				weight = parsed
+			}
+		}
+		weights[strings.ToLower(strings.TrimSpace(name))] = weight
+	}
+
+	best, bestWeight := "", 0.0
+	for _, encoding := range preferred {
+		weight, listed := weights[encoding]
+		if !listed {
+			weight = weights["*"]
+		}
+		if weight > bestWeight {
+			best, bestWeight = encoding, weight
+		}
+	}
+	return best
+}
+
+// Holds back the start of the body until it can tell whether to compress
+type compressWriter struct {
+	gin.ResponseWriter
+	opts     CompressionOptions
+	encoding string
+	buf      []byte
+	decided  bool
+	encoder  interface {
+		io.WriteCloser
+		Flush() error
+	}
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.opts.MinBytes {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// Lets http.ResponseController reach the underlying writer
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Sets up compression if the response qualifies, then writes the held bytes
+func (w *compressWriter) decide() error {
+	w.decided = true
+	compress := w.compressible()
+	buf := w.buf
+	w.buf = nil
+
+	if compress {
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		header.Del("Accept-Ranges")
+		if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			header.Set("ETag", "W/"+etag)
+		}
+
+		switch w.encoding {
+		case "br":
+			bw := brotliWriters.Get().(*brotli.Writer)
+			bw.Reset(w.ResponseWriter)
+			w.encoder = bw
+		default:
+			gw := gzipWriters.Get().(*gzip.Writer)
+			gw.Reset(w.ResponseWriter)
+			w.encoder = gw
+		}
+		_, err := w.encoder.Write(buf)
+		return err
+	}
+
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *compressWriter) compressible() bool {
+	header := w.Header()
+	if !w.typeListed(header.Get("Content-Type")) {
+		return false
+	}
+	// Caches must keep compressed and uncompressed responses apart
+	if !strings.Contains(strings.ToLower(header.Get("Vary")), "accept-encoding") {
+		header.Add("Vary", "Accept-Encoding")
+	}
+
+	status := w.Status()
+	return !w.ResponseWriter.Written() &&
+		len(w.buf) >= w.opts.MinBytes &&
+		status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusPartialContent && status != http.StatusNotModified &&
+		header.Get("Content-Encoding") == "" &&
+		!strings.Contains(header.Get("Cache-Control"), "no-transform")
+}
+
+func (w *compressWriter) typeListed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, listed := range w.opts.ContentTypes {
+		if matched, _ := path.Match(listed, mediaType); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Writes whatever is still held back and finishes the compressed stream
+func (w *compressWriter) close() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.encoder == nil {
+		return
+	}
+
+	w.encoder.Close()
+	switch encoder := w.encoder.(type) {
+	case *brotli.Writer:
+		encoder.Reset(io.Discard)
+		brotliWriters.Put(encoder)
+	case *gzip.Writer:
+		encoder.Reset(io.Discard)
+		gzipWriters.Put(encoder)
+	}
+	w.encoder = nil
+}
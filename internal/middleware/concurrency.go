@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/messages"
+	"github.com/gin-gonic/gin"
+)
+
+// Caps in-flight requests while letting priority paths (health, admin) bypass the limit,
+// so health checks never fail merely because proxy traffic has saturated the gateway
+func ConcurrencyLimit(maxConcurrent int, queueTimeout time.Duration, priorityPaths []string) gin.HandlerFunc {
+	slots := make(chan struct{}, maxConcurrent)
+
+	return func(c *gin.Context) {
+		if isPriorityPath(c.Request.URL.Path, priorityPaths) {
+			c.Next()
+			return
+		}
+
+		timer := time.NewTimer(queueTimeout)
+		defer timer.Stop()
+
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		case <-timer.C:
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": messages.Localize(c, messages.AtCapacity, nil),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func isPriorityPath(path string, priorityPaths []string) bool {
+	for _, p := range priorityPaths {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Headers telling backends who the gateway authenticated
+const (
+	ConsumerIDHeader     = "X-Consumer-Id"
+	ConsumerTierHeader   = "X-Consumer-Tier"
+	ConsumerScopesHeader = "X-Consumer-Scopes"
+	AuthMethodHeader     = "X-Auth-Method"
+)
+
+var consumerHeaders = []string{ConsumerIDHeader, ConsumerTierHeader, ConsumerScopesHeader, AuthMethodHeader}
+
+// Removes client-supplied consumer identity headers, so only the gateway can set them
+func StripConsumerHeaders(header http.Header) {
+	for _, name := range consumerHeaders {
+		header.Del(name)
+	}
+}
+
+// Tells the backend who the caller is, after every authentication step has
+// run. X-Auth-Method is "api_key", "hmac", "client_cert", "oauth", "jwt",
+// "introspection" or "none"; scopes are space-separated as in OAuth. Values
+// sent by the client are always replaced.
+func ConsumerHeaders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.Request.Header
+		StripConsumerHeaders(header)
+
+		method := c.GetString("auth_method")
+		if method == "" {
+			header.Set(AuthMethodHeader, "none")
+			c.Next()
+			return
+		}
+		header.Set(AuthMethodHeader, method)
+
+		if apiKeyInterface, exists := c.Get("api_key"); exists && apiKeyInterface != nil {
+			apiKey := apiKeyInterface.(*models.APIKey)
+			header.Set(ConsumerIDHeader, apiKey.ID.String())
+			if apiKey.Tier != "" {
+				header.Set(ConsumerTierHeader, apiKey.Tier)
+			}
+		} else if consumer := c.GetString("consumer_id"); consumer != "" {
+			header.Set(ConsumerIDHeader, consumer)
+		}
+
+		if claims, ok := c.Get("consumer_claims"); ok {
+			if scopes := tokenScopes(claims.(jwt.MapClaims)); scopes != "" {
+				header.Set(ConsumerScopesHeader, scopes)
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// Records the consumer a service's token check identified. A caller already
+// identified by an API key keeps it as the consumer.
+func setTokenConsumer(c *gin.Context, consumer, method string, claims jwt.MapClaims) {
+	c.Set("consumer_claims", claims)
+	if _, exists := c.Get("api_key"); exists {
+		return
+	}
+
+	if consumer != "" {
+		c.Set("consumer_id", consumer)
+	}
+	c.Set("auth_method", method)
+}
+
+// Returns a token's scopes from "scope" (space-separated) or "scp" (a list)
+func tokenScopes(claims jwt.MapClaims) string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Join(strings.Fields(scope), " ")
+	}
+
+	list, _ := claims["scp"].([]interface{})
+	scopes := make([]string, 0, len(list))
+	for _, item := range list {
+		if scope, ok := item.(string); ok {
+			scopes = append(scopes, scope)
+		}
+	}
+	return strings.Join(scopes, " ")
+}
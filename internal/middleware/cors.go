@@ -1,16 +1,109 @@
 package middleware
 
-import "github.com/gin-gonic/gin"
+import (
+	"strconv"
+	"strings"
+	"time"
 
-func CORS() gin.HandlerFunc {
+	"github.com/aman-churiwal/api-gateway/internal/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// Cross-origin policy applied to a set of routes
+type CORSPolicy struct {
+	AllowedOrigins   []string // "*" allows any origin; https://*.example.com any subdomain
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           time.Duration // How long browsers may cache preflight results
+}
+
+// Overrides the default policy for routes under a path prefix
+type CORSRoute struct {
+	PathPrefix string
+	Policy     CORSPolicy
+}
+
+// Returns the policy used when no CORS configuration is provided
+func DefaultCORSPolicy() CORSPolicy {
+	return CORSPolicy{
+		AllowedOrigins:   []string{"*"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "X-API-Key", "X-API-Key-ID", "X-Timestamp", "X-Signature"},
+		ExposeHeaders:    []string{"Content-Length", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-RateLimit-Bucket"},
+		AllowCredentials: true,
+	}
+}
+
+// Overlays the fields set in o onto p
+func (p CORSPolicy) Merge(o cors.Policy) CORSPolicy {
+	if len(o.AllowedOrigins) > 0 {
+		p.AllowedOrigins = o.AllowedOrigins
+	}
+	if len(o.AllowedMethods) > 0 {
+		p.AllowedMethods = o.AllowedMethods
+	}
+	if len(o.AllowedHeaders) > 0 {
+		p.AllowedHeaders = o.AllowedHeaders
+	}
+	if len(o.ExposeHeaders) > 0 {
+		p.ExposeHeaders = o.ExposeHeaders
+	}
+	if o.AllowCredentials != nil {
+		p.AllowCredentials = *o.AllowCredentials
+	}
+	if o.MaxAgeSeconds > 0 {
+		p.MaxAge = time.Duration(o.MaxAgeSeconds) * time.Second
+	}
+	return p
+}
+
+func CORS(overrides *cors.Registry) gin.HandlerFunc {
+	return CORSWithRoutes(DefaultCORSPolicy(), nil, overrides)
+}
+
+// Applies the policy of the longest matching route prefix, falling back to the default policy.
+// A runtime override for the request's service is laid over it, unless a
+// longer route prefix matched. Preflights are answered by the gateway and
+// never reach backends.
+func CORSWithRoutes(defaultPolicy CORSPolicy, routes []CORSRoute, overrides *cors.Registry) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key")
-		c.Header("Access-Control-Expose-Headers", "Content-Length, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset")
-		c.Header("Access-Control-Allow-Credentials", "true")
+		policy := defaultPolicy
+		matched := ""
+		for _, route := range routes {
+			if strings.HasPrefix(c.Request.URL.Path, route.PathPrefix) && len(route.PathPrefix) > len(matched) {
+				policy = route.Policy
+				matched = route.PathPrefix
+			}
+		}
+		if overrides != nil {
+			if override := overrides.Lookup(c.Request.URL.Path); override != nil && len(override.Service) >= len(matched) {
+				policy = policy.Merge(override.Policy)
+			}
+		}
+
+		origin := c.GetHeader("Origin")
+		if allowOrigin := policy.allowOrigin(origin); allowOrigin != "" {
+			c.Header("Access-Control-Allow-Origin", allowOrigin)
+			if allowOrigin != "*" {
+				c.Writer.Header().Add("Vary", "Origin")
+			}
+			c.Header("Access-Control-Allow-Methods", strings.Join(policy.AllowedMethods, ", "))
+			c.Header("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+			c.Header("Access-Control-Expose-Headers", strings.Join(policy.ExposeHeaders, ", "))
+			if policy.AllowCredentials {
+				c.Header("Access-Control-Allow-Credentials", "true")
+			}
+		}
 
 		if c.Request.Method == "OPTIONS" {
+			if c.GetHeader("Access-Control-Request-Method") != "" {
+				c.Set("cors_preflight", true)
+				if policy.MaxAge > 0 {
+					c.Header("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+				}
+			}
 			c.AbortWithStatus(204)
 			return
 		}
@@ -18,3 +111,36 @@ func CORS() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// Returns the Access-Control-Allow-Origin value for a request origin, or "" if not allowed
+func (p CORSPolicy) allowOrigin(origin string) string {
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" {
+			// Credentialed requests can't use the wildcard, so echo the origin
+			if p.AllowCredentials && origin != "" {
+				return origin
+			}
+			return "*"
+		}
+		if origin != "" && matchOrigin(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// Compares an origin to an allowed one, where a * stands for at least one
+// character of the host, e.g. https://*.example.com
+func matchOrigin(allowed, origin string) bool {
+	prefix, suffix, wildcard := strings.Cut(strings.ToLower(allowed), "*")
+	if !wildcard {
+		return strings.EqualFold(allowed, origin)
+	}
+
+	origin = strings.ToLower(origin)
+	if len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+	middle := origin[len(prefix) : len(origin)-len(suffix)]
+	return !strings.ContainsAny(middle, "/:@")
+}
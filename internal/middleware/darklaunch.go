@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"github.com/aman-churiwal/api-gateway/internal/darklaunch"
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Injects dark-launch feature headers toward backends based on consumer attributes
+func DarkLaunch(engine *darklaunch.Engine) gin.HandlerFunc {
+	managedHeaders := engine.Headers()
+
+	return func(c *gin.Context) {
+		// Never trust client supplied values for gateway managed headers
+		for _, header := range managedHeaders {
+			c.Request.Header.Del(header)
+		}
+
+		consumer := darklaunch.Consumer{
+			ID:   c.ClientIP(),
+			Tier: "basic",
+		}
+		if apiKeyInterface, exists := c.Get("api_key"); exists && apiKeyInterface != nil {
+			apiKey := apiKeyInterface.(*models.APIKey)
+			consumer.ID = apiKey.ID.String()
+			consumer.Tier = apiKey.Tier
+			if apiKey.OrgID != nil {
+				consumer.Org = apiKey.OrgID.String()
+			}
+		}
+
+		for header, value := range engine.Evaluate(c.Request.URL.Path, consumer) {
+			c.Request.Header.Set(header, value)
+		}
+
+		c.Next()
+	}
+}
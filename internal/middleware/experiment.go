@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"github.com/aman-churiwal/api-gateway/internal/darklaunch"
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/proxy"
+	"github.com/gin-gonic/gin"
+)
+
+// A/B test whose matching requests go to the proxy target group named after it
+type Experiment struct {
+	Name       string
+	Variant    string
+	Header     string
+	Cookie     string
+	Value      string  // Empty matches any value
+	Percentage float64 // Share of consumers, 0-100
+}
+
+func (e *Experiment) matches(c *gin.Context) bool {
+	switch {
+	case e.Header != "":
+		value := c.GetHeader(e.Header)
+		return value != "" && (e.Value == "" || value == e.Value)
+	case e.Cookie != "":
+		value, err := c.Cookie(e.Cookie)
+		return err == nil && value != "" && (e.Value == "" || value == e.Value)
+	}
+
+	// Consumers stay in their bucket across requests, as for dark launches
+	consumer := c.ClientIP()
+	if apiKeyInterface, exists := c.Get("api_key"); exists && apiKeyInterface != nil {
+		consumer = apiKeyInterface.(*models.APIKey).ID.String()
+	}
+	return darklaunch.Bucket(e.Name, consumer) < e.Percentage
+}
+
+// Routes each request to the first experiment it matches and records its
+// variant for the request log as "<name>/<variant>", or "control"
+func Experiments(experiments []Experiment) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for i := range experiments {
+			exp := &experiments[i]
+			if exp.matches(c) {
+				proxy.RouteToGroup(c, exp.Name)
+				c.Set("variant", exp.Name+"/"+exp.Variant)
+				c.Next()
+				return
+			}
+		}
+
+		c.Set("variant", "control")
+		c.Next()
+	}
+}
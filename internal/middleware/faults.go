@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/faults"
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// Delays or fails test requests to a service according to its enabled fault
+// rules. Requests without a rule's test header are never affected.
+func FaultInjection(registry *faults.Registry, service string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules := registry.Matching(service, c.Request)
+		if len(rules) == 0 {
+			c.Next()
+			return
+		}
+
+		var delay time.Duration
+		status := 0
+		applied := make([]string, 0, len(rules))
+		for _, rule := range rules {
+			ruleDelay, ruleStatus := rule.Decide()
+			if ruleDelay == 0 && ruleStatus == 0 {
+				continue
+			}
+			applied = append(applied, rule.ID)
+			delay += ruleDelay
+			if status == 0 {
+				status = ruleStatus
+			}
+		}
+		if len(applied) == 0 {
+			c.Next()
+			return
+		}
+
+		c.Header("X-Gateway-Fault", strings.Join(applied, ","))
+		logging.FromContext(c.Request.Context()).Info("Injecting fault", "rules", applied, "delay_ms", delay.Milliseconds(), "status", status)
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-c.Request.Context().Done():
+				timer.Stop()
+				c.Abort()
+				return
+			}
+		}
+
+		if status != 0 {
+			c.AbortWithStatusJSON(status, gin.H{"error": "Fault injected: " + http.StatusText(status)})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aman-churiwal/api-gateway/internal/forwardauth"
+	"github.com/aman-churiwal/api-gateway/internal/messages"
+	"github.com/gin-gonic/gin"
+)
+
+// How consumer tokens are read and which claims reach the backend
+type ForwardAuthOptions struct {
+	Header       string            // Header carrying the token. Default: Authorization (Bearer scheme)
+	Optional     bool              // Let requests without a token through; present tokens must still be valid
+	ClaimHeaders map[string]string // Claim name (dots reach nested claims) to backend header
+}
+
+// Validates end-user JWTs on a service's routes and forwards selected claims
+// to the backend as headers. Claim headers sent by the client are always
+// removed so backends can trust them.
+func ForwardAuth(validator *forwardauth.Validator, opts ForwardAuthOptions) gin.HandlerFunc {
+	if opts.Header == "" {
+		opts.Header = "Authorization"
+	}
+
+	return func(c *gin.Context) {
+		for _, header := range opts.ClaimHeaders {
+			c.Request.Header.Del(header)
+		}
+
+		token := consumerToken(c, opts.Header)
+		if token == "" {
+			if opts.Optional {
+				c.Next()
+				return
+			}
+			rejectToken(c)
+			return
+		}
+
+		claims, err := validator.Validate(c.Request.Context(), token)
+		if err != nil {
+			rejectToken(c)
+			return
+		}
+
+		for claim, header := range opts.ClaimHeaders {
+			if value, ok := forwardauth.Claim(claims, claim); ok {
+				c.Request.Header.Set(header, claimHeaderValue(value))
+			}
+		}
+		subject, _ := claims.GetSubject()
+		setTokenConsumer(c, subject, "jwt", claims)
+
+		c.Next()
+	}
+}
+
+// Reads a consumer token from the header, which carries a Bearer credential
+// when it is Authorization and the bare token otherwise
+func consumerToken(c *gin.Context, header string) string {
+	token := c.GetHeader(header)
+	if !strings.EqualFold(header, "Authorization") {
+		return token
+	}
+
+	scheme, credentials, found := strings.Cut(token, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(credentials)
+}
+
+func rejectToken(c *gin.Context) {
+	c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"error": messages.Localize(c, messages.InvalidToken, nil),
+	})
+	c.Abort()
+}
+
+// Formats a claim as a header value: strings as-is, lists comma-separated, anything else as JSON
+func claimHeaderValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, claimHeaderValue(item))
+		}
+		return strings.Join(parts, ",")
+	case float64, bool:
+		return fmt.Sprint(v)
+	}
+
+	data, _ := json.Marshal(value)
+	return string(data)
+}
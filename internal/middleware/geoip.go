@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"slices"
+
+	"github.com/aman-churiwal/api-gateway/internal/geoip"
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/aman-churiwal/api-gateway/internal/messages"
+	"github.com/gin-gonic/gin"
+)
+
+// Looks up the client's country and sets "country" for geo rules and the
+// request log. Addresses without a country leave it empty.
+func GeoIP(reader *geoip.Reader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if addr, err := netip.ParseAddr(c.ClientIP()); err == nil {
+			country, err := reader.Country(addr)
+			if err != nil {
+				logging.FromContext(c.Request.Context()).Warn("GeoIP lookup failed", "client_ip", addr.String(), "error", err)
+			}
+			c.Set("country", country)
+		}
+		c.Next()
+	}
+}
+
+// Rejects clients from countries the service keeps out. Runs after GeoIP.
+func GeoFilter(allow, deny []string, allowUnknown bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		country := c.GetString("country")
+
+		reason := ""
+		switch {
+		case country != "" && slices.Contains(deny, country):
+			reason = "country_denied"
+		case len(allow) == 0:
+		case country == "" && !allowUnknown:
+			reason = "country_not_allowed"
+		case country != "" && !slices.Contains(allow, country):
+			reason = "country_not_allowed"
+		}
+
+		if reason == "" {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":   messages.Localize(c, messages.CountryDenied, nil),
+			"reason":  reason,
+			"country": country,
+		})
+	}
+}
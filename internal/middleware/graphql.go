@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aman-churiwal/api-gateway/internal/graphql"
+	"github.com/gin-gonic/gin"
+)
+
+// Largest GraphQL request body the gateway parses
+const maxGraphQLBodyBytes = 1 << 20
+
+// Limits enforced on a GraphQL service's requests
+type GraphQLLimits struct {
+	MaxDepth      int
+	MaxComplexity int
+	Introspection bool
+}
+
+type graphQLRequest struct {
+	Query         string `json:"query"`
+	OperationName string `json:"operationName"`
+}
+
+// Parses GraphQL requests, rejects those over the depth or complexity limits
+// or using disabled introspection, and sets "operation" for the request log.
+// Batched requests are checked one by one. Rejections use the GraphQL error format.
+func GraphQL(limits GraphQLLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var requests []graphQLRequest
+		switch c.Request.Method {
+		case http.MethodGet:
+			requests = []graphQLRequest{{Query: c.Query("query"), OperationName: c.Query("operationName")}}
+		case http.MethodPost:
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxGraphQLBodyBytes+1))
+			if err != nil {
+				graphQLError(c, http.StatusBadRequest, "Failed to read request body")
+				return
+			}
+			if len(body) > maxGraphQLBodyBytes {
+				graphQLError(c, http.StatusRequestEntityTooLarge, "Request body is too large")
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+			if strings.HasPrefix(c.ContentType(), "application/graphql") {
+				requests = []graphQLRequest{{Query: string(body), OperationName: c.Query("operationName")}}
+			} else if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+				err = json.Unmarshal(body, &requests)
+			} else {
+				requests = make([]graphQLRequest, 1)
+				err = json.Unmarshal(body, &requests[0])
+			}
+			if err != nil {
+				graphQLError(c, http.StatusBadRequest, "Request body must be a GraphQL request in JSON")
+				return
+			}
+		default:
+			c.Next()
+			return
+		}
+
+		operations := make([]string, 0, len(requests))
+		for _, req := range requests {
+			if req.Query == "" {
+				graphQLError(c, http.StatusBadRequest, "Request has no query")
+				return
+			}
+
+			analysis, err := graphql.Analyze(req.Query, req.OperationName)
+			if err != nil {
+				graphQLError(c, http.StatusBadRequest, "Invalid query: "+err.Error())
+				return
+			}
+			if analysis.Introspection && !limits.Introspection {
+				graphQLError(c, http.StatusForbidden, "Introspection is disabled")
+				return
+			}
+			if analysis.Depth > limits.MaxDepth {
+				graphQLError(c, http.StatusBadRequest, "Query depth "+strconv.Itoa(analysis.Depth)+" exceeds the limit of "+strconv.Itoa(limits.MaxDepth))
+				return
+			}
+			if analysis.Complexity > limits.MaxComplexity {
+				graphQLError(c, http.StatusBadRequest, "Query complexity "+strconv.Itoa(analysis.Complexity)+" exceeds the limit of "+strconv.Itoa(limits.MaxComplexity))
+				return
+			}
+
+			operation := analysis.OperationType
+			if analysis.OperationName != "" {
+				operation += " " + analysis.OperationName
+			}
+			operations = append(operations, operation)
+		}
+
+		c.Set("operation", strings.Join(operations, ", "))
+		c.Next()
+	}
+}
+
+func graphQLError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, gin.H{"errors": []gin.H{{"message": message}}})
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aman-churiwal/api-gateway/internal/config"
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/ratelimit"
+	"github.com/aman-churiwal/api-gateway/internal/routing"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Benchmarks for the middleware every proxied request passes through.
+// Rate limiting runs against in-memory Redis, so it measures the middleware
+// and client rather than the network.
+//
+//	go test ./internal/middleware -run '^$' -bench . -benchmem
+
+func init() {
+	gin.SetMode(gin.ReleaseMode)
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// Serves one request through the engine per iteration
+func benchmarkEngine(b *testing.B, engine *gin.Engine, path string) {
+	b.Helper()
+	engine.GET("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for b.Loop() {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("unexpected status %d", w.Code)
+		}
+	}
+}
+
+// Serves requests as the API key middleware would have identified them
+func withAPIKey(key *models.APIKey) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("api_key", key)
+		c.Set("api_key_id", key.ID)
+		c.Next()
+	}
+}
+
+func benchmarkLimiter(b *testing.B) (ratelimit.Factory, *config.Config) {
+	b.Helper()
+	redis, err := storage.NewMemoryRedis()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { redis.Close() })
+
+	cfg := &config.Config{RateLimitTiers: []config.RateLimiterTier{
+		{Name: "basic", RequestsPerMinute: 1 << 30, Algorithm: "sliding_window"},
+	}}
+	return ratelimit.RedisFactory(redis), cfg
+}
+
+func benchmarkTable(b *testing.B) *routing.Table {
+	b.Helper()
+	routes := make([]routing.Route, 0, 50)
+	for i := range 50 {
+		route, err := routing.NewRoute(fmt.Sprintf("/resource%d/{id}/items/{item}", i), "", "", 0, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		routes = append(routes, route)
+	}
+	return routing.NewTable(routes)
+}
+
+func BenchmarkRequestID(b *testing.B) {
+	engine := gin.New()
+	engine.Use(RequestID(DefaultRequestIDOptions()))
+	benchmarkEngine(b, engine, "/api/users")
+}
+
+func BenchmarkLogger(b *testing.B) {
+	engine := gin.New()
+	engine.Use(Logger())
+	benchmarkEngine(b, engine, "/api/users")
+}
+
+func BenchmarkRoutes(b *testing.B) {
+	engine := gin.New()
+	engine.Use(Routes(benchmarkTable(b)))
+	benchmarkEngine(b, engine, "/resource49/42/items/7")
+}
+
+func BenchmarkRateLimitWithTier(b *testing.B) {
+	newLimiter, cfg := benchmarkLimiter(b)
+
+	engine := gin.New()
+	engine.Use(withAPIKey(&models.APIKey{ID: uuid.New(), Tier: "basic"}))
+	engine.Use(RateLimitWithTier(newLimiter, cfg, nil))
+	benchmarkEngine(b, engine, "/api/users")
+}
+
+// The chain a proxied request passes through before reaching the backend
+func BenchmarkHotPath(b *testing.B) {
+	newLimiter, cfg := benchmarkLimiter(b)
+
+	engine := gin.New()
+	engine.Use(Recovery())
+	engine.Use(RequestID(DefaultRequestIDOptions()))
+	engine.Use(Logger())
+	engine.Use(withAPIKey(&models.APIKey{ID: uuid.New(), Tier: "basic"}))
+	engine.Use(RateLimitWithTier(newLimiter, cfg, nil))
+	engine.Use(Routes(benchmarkTable(b)))
+	benchmarkEngine(b, engine, "/resource49/42/items/7")
+}
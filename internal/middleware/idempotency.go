@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Largest request body accepted with an idempotency key. Responses
+	// larger than maxCachedBodyBytes are passed through without being stored.
+	maxIdempotentBodyBytes = 1 << 20
+
+	// How long a key stays locked unless renewed. The first request renews
+	// it while in flight, however long the backend takes, so a crashed
+	// gateway only holds the key this long.
+	idempotencyLockTTL = time.Minute
+)
+
+// Stored under an idempotency key: the request's fingerprint and, once the
+// backend has answered, its response
+type idempotencyRecord struct {
+	Fingerprint string      `json:"fingerprint"`
+	Completed   bool        `json:"completed"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// Replays the first response to POST and PATCH requests for retries with the
+// same Idempotency-Key. A key reused with a different request is rejected, as
+// is a retry while the first request is still in flight. Keys are scoped to
+// the service and the consumer. Server errors aren't stored, so they can be retried.
+func Idempotency(redisClient *storage.RedisClient, service string, ttl time.Duration, required bool) gin.HandlerFunc {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost && c.Request.Method != http.MethodPatch {
+			c.Next()
+			return
+		}
+
+		idempotencyKey := c.GetHeader("Idempotency-Key")
+		if idempotencyKey == "" {
+			if required {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key header is required"})
+				return
+			}
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > 255 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			var err error
+			body, err = io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBodyBytes+1))
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+				return
+			}
+			if len(body) > maxIdempotentBodyBytes {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large for an idempotent request"})
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		consumer := c.ClientIP()
+		if apiKeyInterface, exists := c.Get("api_key"); exists && apiKeyInterface != nil {
+			consumer = apiKeyInterface.(*models.APIKey).ID.String()
+		} else if id := c.GetString("consumer_id"); id != "" {
+			consumer = "consumer:" + id
+		}
+		key := "idempotency:" + service + ":" + consumer + ":" + idempotencyKey
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+		hash.Write(body)
+		fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+		ctx := c.Request.Context()
+		logger := logging.FromContext(ctx)
+
+		pending, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+		acquired, err := redisClient.SetNX(ctx, key, pending, idempotencyLockTTL)
+		if err != nil {
+			// Fail open: a Redis outage shouldn't take writes down with it
+			logger.Error("Idempotency check failed", "error", err)
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			var record idempotencyRecord
+			data, err := redisClient.Get(ctx, key)
+			if err == nil {
+				err = json.Unmarshal([]byte(data), &record)
+			}
+			if err == redis.Nil {
+				// The first request failed and released the key in between
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress"})
+				return
+			}
+			if err != nil {
+				logger.Error("Idempotency check failed", "error", err)
+				c.Next()
+				return
+			}
+
+			switch {
+			case record.Fingerprint != fingerprint:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+			case !record.Completed:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress"})
+			default:
+				for name, values := range record.Header {
+					if c.Writer.Header().Get(name) != "" {
+						continue // Set by the gateway for this request
+					}
+					for _, value := range values {
+						c.Writer.Header().Add(name, value)
+					}
+				}
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(record.Status, record.Header.Get("Content-Type"), record.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		release := holdIdempotencyLock(redisClient, key, logger)
+		c.Next()
+		release()
+
+		status := c.Writer.Status()
+		if status >= 500 || recorder.overflow {
+			if err := redisClient.Del(context.Background(), key); err != nil {
+				logger.Error("Failed to release idempotency key", "error", err)
+			}
+			return
+		}
+
+		header := c.Writer.Header().Clone()
+		header.Del("X-Request-ID")
+		data, err := json.Marshal(idempotencyRecord{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			Status:      status,
+			Header:      header,
+			Body:        recorder.body.Bytes(),
+		})
+		if err == nil {
+			err = redisClient.Set(context.Background(), key, data, ttl)
+		}
+		if err != nil {
+			logger.Error("Failed to store idempotent response", "error", err)
+		}
+	}
+}
+
+// Renews a key's lock until the returned function is called, which waits for
+// the last renewal so it can't outlast the stored response's TTL
+func holdIdempotencyLock(redisClient *storage.RedisClient, key string, logger *slog.Logger) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(idempotencyLockTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := redisClient.Expire(context.Background(), key, idempotencyLockTTL); err != nil {
+					logger.Error("Failed to renew idempotency key", "error", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+	}
+}
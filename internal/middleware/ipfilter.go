@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+
+	"github.com/aman-churiwal/api-gateway/internal/ipfilter"
+	"github.com/aman-churiwal/api-gateway/internal/messages"
+	"github.com/gin-gonic/gin"
+)
+
+// Rejects clients the scope's allow and deny lists keep out. Requests without
+// a client address only pass when the scope has no allow entries.
+func IPFilter(registry *ipfilter.Registry, scope string, static *ipfilter.List) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		addr, _ := netip.ParseAddr(c.ClientIP())
+
+		decision := registry.Decide(scope, static, addr)
+		if decision.Allowed {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":     messages.Localize(c, messages.IPDenied, nil),
+			"reason":    decision.Reason,
+			"scope":     scope,
+			"client_ip": c.ClientIP(),
+		})
+	}
+}
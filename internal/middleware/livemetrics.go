@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/livemetrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Feeds proxied requests into the live metrics collector. Admin and health
+// routes are not counted, and fast-path routes never reach gin.
+func LiveMetrics(collector *livemetrics.Collector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		if service := c.GetString("service"); service != "" {
+			collector.Record(service, c.Writer.Status(), time.Since(start))
+		}
+	}
+}
@@ -1,12 +1,17 @@
 package middleware
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/aman-churiwal/api-gateway/internal/logging"
 	"github.com/gin-gonic/gin"
 )
 
+// Logs one structured line per request. Server errors log at error level and
+// client errors at warn. service, route, api_version, backend_target and
+// error_type/error_message are set by proxied routes.
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -15,18 +20,30 @@ func Logger() gin.HandlerFunc {
 
 		c.Next()
 
-		latency := time.Since(start)
 		statusCode := c.Writer.Status()
 
-		requestID := c.GetString("request_id")
+		attrs := []any{
+			slog.String("method", method),
+			slog.String("path", path),
+			slog.Int("status", statusCode),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		for _, key := range []string{"api_key_id", "consumer_id", "client_cert", "service", "route", "api_version", "backend_target", "error_type", "error_message"} {
+			if value, exists := c.Get(key); exists {
+				attrs = append(attrs, slog.String(key, fmt.Sprint(value)))
+			}
+		}
 
-		log.Printf("[%s] %s %s - %d - %v - %s",
-			requestID,
-			method,
-			path,
-			statusCode,
-			latency,
-			c.ClientIP(),
-		)
+		level := slog.LevelInfo
+		switch {
+		case statusCode >= 500:
+			level = slog.LevelError
+		case statusCode >= 400:
+			level = slog.LevelWarn
+		}
+
+		logging.FromContext(c.Request.Context()).Log(c.Request.Context(), level, "request", attrs...)
 	}
 }
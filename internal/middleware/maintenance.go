@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"html"
+	"strconv"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/maintenance"
+	"github.com/aman-churiwal/api-gateway/internal/messages"
+	"github.com/gin-gonic/gin"
+)
+
+// Answers with the maintenance response while the service has an active
+// window, without reaching the backend
+func Maintenance(registry *maintenance.Registry, service string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		window := registry.Active(service)
+		if window == nil {
+			c.Next()
+			return
+		}
+
+		if retryAfter := window.RetryAfter(time.Now()); retryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+		}
+		c.Header("X-Gateway-Maintenance", window.ID)
+
+		resp := window.Response
+		body := resp.Body
+		if resp.Format == "html" {
+			if body == "" {
+				message := html.EscapeString(messages.Localize(c, messages.Maintenance, nil))
+				body = "<!DOCTYPE html><html><head><title>" + message + "</title></head><body><h1>" + message + "</h1></body></html>"
+			}
+			c.Data(resp.Status, "text/html; charset=utf-8", []byte(body))
+		} else if body != "" {
+			c.Data(resp.Status, "application/json; charset=utf-8", []byte(body))
+		} else {
+			c.JSON(resp.Status, gin.H{"error": messages.Localize(c, messages.Maintenance, nil)})
+		}
+		c.Abort()
+	}
+}
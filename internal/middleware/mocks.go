@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/aman-churiwal/api-gateway/internal/stubs"
+	"github.com/gin-gonic/gin"
+)
+
+// Configured response for a route of a service, rendered like an admin stub
+type Mock struct {
+	Stub    *stubs.Stub // Compiled
+	Latency time.Duration
+}
+
+// Answers requests with the first matching mock. Unmatched requests go on to
+// the backend, or get a 404 when the service has none.
+func Mocks(mocks []Mock, hasBackend bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var mock *Mock
+		for i := range mocks {
+			if mocks[i].Stub.Matches(c.Request) {
+				mock = &mocks[i]
+				break
+			}
+		}
+
+		if mock == nil {
+			if hasBackend {
+				c.Next()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "No mock matches this request"})
+			return
+		}
+
+		var body []byte
+		if mock.Stub.NeedsBody() && c.Request.Body != nil {
+			body, _ = io.ReadAll(io.LimitReader(c.Request.Body, maxStubBodyBytes))
+		}
+
+		status, header, out, err := mock.Stub.Render(c.Request, body)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("Mock failed to render", "path", mock.Stub.Path, "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Mock response could not be rendered"})
+			return
+		}
+
+		if mock.Latency > 0 {
+			timer := time.NewTimer(mock.Latency)
+			select {
+			case <-timer.C:
+			case <-c.Request.Context().Done():
+				timer.Stop()
+				c.Abort()
+				return
+			}
+		}
+
+		for name, values := range header {
+			for _, value := range values {
+				c.Writer.Header().Add(name, value)
+			}
+		}
+		c.Header("X-Gateway-Mock", "true")
+		c.Status(status)
+		c.Writer.Write(out)
+		c.Abort()
+	}
+}
@@ -3,21 +3,28 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/aman-churiwal/api-gateway/internal/config"
+	"github.com/aman-churiwal/api-gateway/internal/messages"
 	"github.com/aman-churiwal/api-gateway/internal/models"
 	"github.com/aman-churiwal/api-gateway/internal/ratelimit"
-	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/aman-churiwal/api-gateway/internal/service"
+	"github.com/aman-churiwal/api-gateway/internal/webhook"
 	"github.com/gin-gonic/gin"
 )
 
-func RateLimitWithTier(redis *storage.RedisClient, cfg *config.Config) gin.HandlerFunc {
+// Dispatches quota.exceeded the first time an API key is rejected in a window
+func RateLimitWithTier(newLimiter ratelimit.Factory, cfg *config.Config, webhooks *webhook.Dispatcher) gin.HandlerFunc {
+	var notified sync.Map // Rate limit key -> end of the window already reported
+
 	return func(c *gin.Context) {
 		var tier string
 		var limit int
 		var algorithm string
 		var key string
+		var tierConfig *config.RateLimiterTier
 
 		// Check if API key exists in context
 		apiKeyInterface, exists := c.Get("api_key")
@@ -28,7 +35,7 @@ func RateLimitWithTier(redis *storage.RedisClient, cfg *config.Config) gin.Handl
 			key = apiKey.ID.String() // Use API key ID as the rate limit key
 
 			// Find Tier Configuration
-			tierConfig := findTierConfig(cfg, tier)
+			tierConfig = FindTierConfig(cfg, tier)
 			if tierConfig != nil {
 				limit = tierConfig.RequestsPerMinute
 				algorithm = tierConfig.Algorithm
@@ -42,16 +49,29 @@ func RateLimitWithTier(redis *storage.RedisClient, cfg *config.Config) gin.Handl
 
 			// Use first tier as default
 			if len(cfg.RateLimitTiers) > 0 {
-				limit = cfg.RateLimitTiers[0].RequestsPerMinute
-				algorithm = cfg.RateLimitTiers[0].Algorithm
+				tierConfig = &cfg.RateLimitTiers[0]
+				limit = tierConfig.RequestsPerMinute
+				algorithm = tierConfig.Algorithm
 			} else {
 				limit = 60
 				algorithm = "fixed_window"
 			}
 		}
 
+		// Partition the allowance when the service has its own bucket
+		bucketName := "shared"
+		if tierConfig != nil {
+			if bucket := tierConfig.BucketFor(c.Request.URL.Path); bucket != nil {
+				bucketName = bucket.Name
+				key = key + ":" + bucket.Name
+				if bucket.RequestsPerMinute > 0 {
+					limit = bucket.RequestsPerMinute
+				}
+			}
+		}
+
 		// Create Rate Limiter based on algorithm
-		limiter := ratelimit.NewLimiter(redis, algorithm, limit, time.Minute)
+		limiter := newLimiter(algorithm, limit, time.Minute)
 
 		// Check Rate Limit
 		ctx := c.Request.Context()
@@ -75,6 +95,7 @@ func RateLimitWithTier(redis *storage.RedisClient, cfg *config.Config) gin.Handl
 		c.Header("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
 		c.Header("X-RateLimit-Reset", fmt.Sprintf("%d", resetTime.Unix()))
 		c.Header("X-RateLimit-Tier", tier)
+		c.Header("X-RateLimit-Bucket", bucketName)
 
 		if !allowed {
 			retryAfter := int(time.Until(resetTime).Seconds())
@@ -82,10 +103,151 @@ func RateLimitWithTier(redis *storage.RedisClient, cfg *config.Config) gin.Handl
 				retryAfter = 0
 			}
 
+			if apiKeyID, exists := c.Get("api_key_id"); exists {
+				if until, reported := notified.Load(key); !reported || time.Now().After(until.(time.Time)) {
+					notified.Store(key, resetTime)
+					webhooks.Dispatch(webhook.EventQuotaExceeded, map[string]interface{}{
+						"api_key_id": fmt.Sprint(apiKeyID),
+						"tier":       tier,
+						"bucket":     bucketName,
+						"limit":      limit,
+						"reset_at":   resetTime.UTC(),
+					})
+				}
+			}
+
 			c.Header("Retry-After", fmt.Sprintf("%d", retryAfter))
 			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":       "Rate limit exceeded",
+				"error": messages.Localize(c, messages.RateLimited, map[string]interface{}{
+					"Tier":       tier,
+					"Limit":      limit,
+					"RetryAfter": retryAfter,
+				}),
 				"tier":        tier,
+				"bucket":      bucketName,
+				"limit":       limit,
+				"retry_after": resetTime.Unix(),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Holds the keys of an organization to its aggregate limit, so one tenant's
+// keys together can't starve the others. Runs after the per-key limit, so
+// requests that limit rejects don't count against the organization.
+func OrgRateLimit(orgs *service.OrganizationService, webhooks *webhook.Dispatcher) gin.HandlerFunc {
+	var notified sync.Map // Organization -> end of the window already reported
+
+	return func(c *gin.Context) {
+		apiKeyInterface, exists := c.Get("api_key")
+		if !exists || apiKeyInterface == nil {
+			c.Next()
+			return
+		}
+		apiKey := apiKeyInterface.(*models.APIKey)
+		if apiKey.OrgID == nil {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		allowed, quota, err := orgs.AllowRequest(ctx, *apiKey.OrgID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Rate limit check failed",
+			})
+			c.Abort()
+			return
+		}
+		if quota.Limit == 0 {
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Org-Limit", fmt.Sprintf("%d", quota.Limit))
+		c.Header("X-RateLimit-Org-Remaining", fmt.Sprintf("%d", quota.Remaining))
+		c.Header("X-RateLimit-Org-Reset", fmt.Sprintf("%d", quota.ResetAt.Unix()))
+
+		if !allowed {
+			retryAfter := int(time.Until(quota.ResetAt).Seconds())
+			if retryAfter < 0 {
+				retryAfter = 0
+			}
+
+			orgID := apiKey.OrgID.String()
+			if until, reported := notified.Load(orgID); !reported || time.Now().After(until.(time.Time)) {
+				notified.Store(orgID, quota.ResetAt)
+				webhooks.Dispatch(webhook.EventQuotaExceeded, map[string]interface{}{
+					"org_id":     orgID,
+					"api_key_id": apiKey.ID.String(),
+					"scope":      "organization",
+					"limit":      quota.Limit,
+					"reset_at":   quota.ResetAt.UTC(),
+				})
+			}
+
+			c.Header("Retry-After", fmt.Sprintf("%d", retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": messages.Localize(c, messages.RateLimited, map[string]interface{}{
+					"Limit":      quota.Limit,
+					"RetryAfter": retryAfter,
+				}),
+				"scope":       "organization",
+				"limit":       quota.Limit,
+				"retry_after": quota.ResetAt.Unix(),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Applies a service's own per-consumer limit on top of the tier limit
+func ServiceRateLimit(newLimiter ratelimit.Factory, service string, limit int, algorithm string) gin.HandlerFunc {
+	if algorithm == "" {
+		algorithm = "sliding_window"
+	}
+	limiter := newLimiter(algorithm, limit, time.Minute)
+
+	return func(c *gin.Context) {
+		key := c.ClientIP()
+		if apiKeyID, exists := c.Get("api_key_id"); exists {
+			key = fmt.Sprintf("%v", apiKeyID)
+		} else if consumer := c.GetString("consumer_id"); consumer != "" {
+			key = "consumer:" + consumer
+		}
+		key = "service:" + service + ":" + key
+
+		ctx := c.Request.Context()
+		allowed, err := limiter.Allow(ctx, key)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Rate limit check failed",
+			})
+			c.Abort()
+			return
+		}
+
+		if !allowed {
+			resetTime, _ := limiter.Reset(ctx, key)
+			retryAfter := int(time.Until(resetTime).Seconds())
+			if retryAfter < 0 {
+				retryAfter = 0
+			}
+
+			c.Header("Retry-After", fmt.Sprintf("%d", retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": messages.Localize(c, messages.RateLimited, map[string]interface{}{
+					"Limit":      limit,
+					"RetryAfter": retryAfter,
+				}),
+				"service":     service,
 				"limit":       limit,
 				"retry_after": resetTime.Unix(),
 			})
@@ -97,7 +259,8 @@ func RateLimitWithTier(redis *storage.RedisClient, cfg *config.Config) gin.Handl
 	}
 }
 
-func findTierConfig(cfg *config.Config, tierName string) *config.RateLimiterTier {
+// Returns the configured rate limit tier with the given name
+func FindTierConfig(cfg *config.Config, tierName string) *config.RateLimiterTier {
 	for _, tier := range cfg.RateLimitTiers {
 		if tier.Name == tierName {
 			return &tier
@@ -1,8 +1,11 @@
 package middleware
 
 import (
-	"log"
+	"fmt"
 	"net/http"
+	"runtime/debug"
+
+	"github.com/aman-churiwal/api-gateway/internal/logging"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,8 +14,7 @@ func Recovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
-				requestID := c.GetString("request_id")
-				log.Printf("[%s] PANIC: %v", requestID, err)
+				logging.FromContext(c.Request.Context()).Error("Panic recovered", "panic", fmt.Sprint(err), "stack", string(debug.Stack()))
 
 				c.JSON(http.StatusInternalServerError, gin.H{
 					"error": "Internal Server Error",
@@ -1,20 +1,210 @@
 package middleware
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/aman-churiwal/api-gateway/internal/messages"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-func RequestID() gin.HandlerFunc {
+// W3C trace context headers
+const (
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+)
+
+// Controls which clients may supply their own request and trace ids
+type RequestIDOptions struct {
+	Header          string         // Default: X-Request-ID
+	TrustAll        bool           // Keep ids from every client
+	TrustedNetworks []netip.Prefix // Otherwise only these clients' ids are kept
+	RejectUntrusted bool           // Reject untrusted clients that send ids instead of replacing them
+	MaxLength       int            // Longer ids are replaced. Default: 128
+}
+
+// Accepts every client's request id, as the gateway always has
+func DefaultRequestIDOptions() RequestIDOptions {
+	return RequestIDOptions{Header: "X-Request-ID", TrustAll: true, MaxLength: 128}
+}
+
+// Assigns each request an id and a W3C trace context. Ids from trusted
+// clients are kept; others are replaced. Both are forwarded to backends, the
+// id is echoed in the response and added to the gateway's JSON error bodies.
+func RequestID(opts RequestIDOptions) gin.HandlerFunc {
+	if opts.Header == "" {
+		opts.Header = "X-Request-ID"
+	}
+	if opts.MaxLength <= 0 {
+		opts.MaxLength = 128
+	}
+
 	return func(c *gin.Context) {
-		requestID := c.GetHeader("X-Request-ID")
+		requestID := c.GetHeader(opts.Header)
+		traceparent := c.GetHeader(traceparentHeader)
 
-		if requestID == "" {
+		if !opts.trusted(c.ClientIP()) {
+			if opts.RejectUntrusted && (requestID != "" || traceparent != "") {
+				requestID = uuid.New().String()
+				c.Header(opts.Header, requestID)
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error":      messages.Localize(c, messages.UntrustedRequestID, map[string]interface{}{"Header": opts.Header}),
+					"request_id": requestID,
+				})
+				return
+			}
+			requestID, traceparent = "", ""
+		}
+
+		if !validRequestID(requestID, opts.MaxLength) {
 			requestID = uuid.New().String()
 		}
+		traceID, ok := parseTraceparent(traceparent)
+		if !ok {
+			traceID = randomHex(16)
+			traceparent = "00-" + traceID + "-" + randomHex(8) + "-00"
+			c.Request.Header.Del(tracestateHeader)
+		}
+
+		c.Request.Header.Set(opts.Header, requestID)
+		c.Request.Header.Set(traceparentHeader, traceparent)
 
 		c.Set("request_id", requestID)
-		c.Header("X-Request-ID", requestID)
+		c.Set("trace_id", traceID)
+		c.Header(opts.Header, requestID)
+		c.Writer = &requestIDWriter{ResponseWriter: c.Writer, requestID: requestID}
+
+		// Everything logged for this request carries its ids
+		logger := slog.Default().With("request_id", requestID, "trace_id", traceID)
+		c.Request = c.Request.WithContext(logging.WithContext(c.Request.Context(), logger))
 		c.Next()
 	}
 }
+
+func (o RequestIDOptions) trusted(clientIP string) bool {
+	if o.TrustAll {
+		return true
+	}
+	addr, err := netip.ParseAddr(clientIP)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range o.TrustedNetworks {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ids are limited to characters safe in headers and log lines
+func validRequestID(id string, maxLength int) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("-_.:/+=@", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the trace id of a version 00 traceparent
+func parseTraceparent(value string) (string, bool) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return "", false
+	}
+	for i, size := range []int{2, 32, 16, 2} {
+		if len(parts[i]) != size || !isLowerHex(parts[i]) {
+			return "", false
+		}
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+func isLowerHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Adds "request_id" to JSON error bodies the gateway writes itself. Backend
+// responses, marked by X-Backend-Server, pass through untouched.
+type requestIDWriter struct {
+	gin.ResponseWriter
+	requestID string
+	checked   bool
+}
+
+func (w *requestIDWriter) Write(data []byte) (int, error) {
+	if w.checked {
+		return w.ResponseWriter.Write(data)
+	}
+	w.checked = true
+
+	body, ok := w.withRequestID(data)
+	if !ok {
+		return w.ResponseWriter.Write(data)
+	}
+	if _, err := w.ResponseWriter.Write(body); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// Lets http.ResponseController reach the underlying writer, for write
+// deadlines on long-lived responses
+func (w *requestIDWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *requestIDWriter) withRequestID(data []byte) ([]byte, bool) {
+	header := w.Header()
+	if w.Status() < http.StatusBadRequest || w.Written() ||
+		header.Get("X-Backend-Server") != "" || header.Get("Content-Encoding") != "" || header.Get("Content-Length") != "" ||
+		!strings.HasPrefix(header.Get("Content-Type"), "application/json") {
+		return nil, false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return nil, false
+	}
+	if _, exists := fields["request_id"]; exists {
+		return nil, false
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	body := append([]byte(`{"request_id":`), strconv.Quote(w.requestID)...)
+	if len(fields) > 0 {
+		body = append(body, ',')
+	}
+	return append(body, trimmed[1:]...), true
+}
@@ -3,56 +3,22 @@ package middleware
 import (
 	"time"
 
+	"github.com/aman-churiwal/api-gateway/internal/accesslog"
 	"github.com/aman-churiwal/api-gateway/internal/models"
-	"github.com/aman-churiwal/api-gateway/internal/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-// Buffered channel for async logging
-var logChannel chan models.RequestLog
+// Receives entries for the configured access log sinks
+var accessLog *accesslog.Dispatcher
 
-// Initializes the request logger
-func InitRequestLogger(db *storage.Postgres, bufferSize int) {
-	logChannel = make(chan models.RequestLog, bufferSize)
-
-	// Start background worker to batch insert logs
-	go func() {
-		batch := make([]models.RequestLog, 0, 100)
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case log := <-logChannel:
-				batch = append(batch, log)
-
-				// Insert when batch is full
-				if len(batch) >= 100 {
-					insertBatch(db, batch)
-					batch = make([]models.RequestLog, 0, 100)
-				}
-			case <-ticker.C:
-				// Periodically insert remaining logs
-				if len(batch) > 0 {
-					insertBatch(db, batch)
-					batch = make([]models.RequestLog, 0, 100)
-				}
-			}
-		}
-	}()
-}
-
-// Inserts a batch of logs into the database
-func insertBatch(db *storage.Postgres, logs []models.RequestLog) {
-	if len(logs) == 0 {
-		return
-	}
+// Picks the requests that are logged; nil logs everything
+var logSampler *accesslog.Sampler
 
-	if err := db.DB.Create(&logs).Error; err != nil {
-		// Log error but dont block
-		println("Failed to insert request logs:", err.Error())
-	}
+// Initializes the request logger
+func InitRequestLogger(dispatcher *accesslog.Dispatcher, sampler *accesslog.Sampler) {
+	accessLog = dispatcher
+	logSampler = sampler
 }
 
 // Logs all HTTP requests
@@ -66,6 +32,16 @@ func RequestLogger() gin.HandlerFunc {
 		// Calculate duration
 		duration := time.Since(start)
 
+		// Failed proxy calls and captured bodies are always kept for debugging
+		sampleRate := 1.0
+		_, captured := c.Get("request_headers")
+		if c.GetString("error_type") == "" && !captured {
+			sampleRate = logSampler.SampleRate(c.Writer.Status(), duration)
+		}
+		if !accesslog.Keep(sampleRate) {
+			return
+		}
+
 		// Extract API key ID if present
 		var apiKeyID *uuid.UUID
 		if apiKeyInterface, exists := c.Get("api_key_id"); exists {
@@ -74,6 +50,15 @@ func RequestLogger() gin.HandlerFunc {
 			}
 		}
 
+		// Bandwidth for usage reports; unknown request lengths count as zero
+		var bytesIn, bytesOut int64
+		if c.Request.ContentLength > 0 {
+			bytesIn = c.Request.ContentLength
+		}
+		if size := c.Writer.Size(); size > 0 {
+			bytesOut = int64(size)
+		}
+
 		// Extract backend server if present
 		backendServer := c.GetHeader("X-Backend-Server")
 
@@ -81,22 +66,33 @@ func RequestLogger() gin.HandlerFunc {
 		logEntry := models.RequestLog{
 			Timestamp:      start,
 			APIKeyID:       apiKeyID,
+			ConsumerID:     c.GetString("consumer_id"),
 			Method:         c.Request.Method,
 			Path:           c.Request.URL.Path,
 			StatusCode:     c.Writer.Status(),
 			ResponseTimeMs: int(duration.Milliseconds()),
+			BytesIn:        bytesIn,
+			BytesOut:       bytesOut,
 			IPAddress:      c.ClientIP(),
 			UserAgent:      c.Request.UserAgent(),
 			BackendServer:  backendServer,
+			IsPreflight:    c.GetBool("cors_preflight"),
+			ErrorType:      c.GetString("error_type"),
+			ErrorMessage:   c.GetString("error_message"),
+			SampleRate:     sampleRate,
+			Variant:        c.GetString("variant"),
+			Operation:      c.GetString("operation"),
+			Country:        c.GetString("country"),
+			Route:          c.GetString("route"),
+			APIVersion:     c.GetString("api_version"),
+			BotVerdict:     c.GetString("bot_verdict"),
+			BotSignals:     c.GetString("bot_signals"),
+			RequestHeaders: c.GetString("request_headers"),
+			RequestBody:    c.GetString("request_body"),
+			ResponseBody:   c.GetString("response_body"),
 		}
 
-		// Send to channel for async processing
-		select {
-		case logChannel <- logEntry:
-			// Successfully queued
-		default:
-			// Channel full, skip logging to avoid blocking
-			println("Request log channel full, skipping log entry")
-		}
+		// Each sink buffers and batches in the background
+		accessLog.Log(logEntry)
 	}
 }
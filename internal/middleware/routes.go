@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/aman-churiwal/api-gateway/internal/messages"
+	"github.com/aman-churiwal/api-gateway/internal/routing"
+	"github.com/gin-gonic/gin"
+)
+
+// Matches the request against the service's routes, setting "route" to the
+// matched template for logs and metrics and "route_params" to its path
+// parameters. Requests matching no route get 404.
+func Routes(table *routing.Table) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route, params, ok := table.Match(c.Request.Method, c.Request.URL.Path)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"error": messages.Localize(c, messages.RouteNotFound, nil),
+			})
+			return
+		}
+
+		c.Set("route", route.Name)
+		c.Set("route_params", params)
+		c.Next()
+	}
+}
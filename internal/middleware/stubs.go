@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/aman-churiwal/api-gateway/internal/stubs"
+	"github.com/gin-gonic/gin"
+)
+
+// Bodies larger than this are only partially visible to stub matchers and templates
+const maxStubBodyBytes = 1 << 20
+
+// Answers requests that match an admin-defined stub instead of proxying them
+func Stubs(registry *stubs.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		candidates := registry.Candidates(c.Request)
+		if len(candidates) == 0 {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil && c.Request.Body != http.NoBody && needsBody(candidates) {
+			// Keep the body readable for the backend if no stub matches
+			var buffered bytes.Buffer
+			io.Copy(&buffered, io.LimitReader(c.Request.Body, maxStubBodyBytes))
+			body = buffered.Bytes()
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+		}
+
+		for _, stub := range candidates {
+			if !stub.MatchesBody(body) {
+				continue
+			}
+
+			status, header, out, err := stub.Render(c.Request, body)
+			if err != nil {
+				logging.FromContext(c.Request.Context()).Error("Stub failed to render", "stub_id", stub.ID, "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Stub response could not be rendered"})
+				c.Abort()
+				return
+			}
+
+			for name, values := range header {
+				for _, value := range values {
+					c.Writer.Header().Add(name, value)
+				}
+			}
+			c.Header("X-Gateway-Stub", stub.ID)
+			c.Status(status)
+			c.Writer.Write(out)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func needsBody(candidates []*stubs.Stub) bool {
+	for _, stub := range candidates {
+		if stub.NeedsBody() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/aman-churiwal/api-gateway/internal/toggles"
+	"github.com/gin-gonic/gin"
+)
+
+// Wraps a middleware so it can be switched off at runtime through the admin API
+func Toggleable(name string, registry *toggles.Registry, next gin.HandlerFunc) gin.HandlerFunc {
+	registry.Register(name)
+
+	return func(c *gin.Context) {
+		if !registry.Enabled(name) {
+			c.Next()
+			return
+		}
+
+		next(c)
+	}
+}
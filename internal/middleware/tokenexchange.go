@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/service"
+	"github.com/aman-churiwal/api-gateway/internal/tokenexchange"
+	"github.com/gin-gonic/gin"
+)
+
+// Replaces the consumer credential with a backend-specific token for the given audience,
+// so backends never see the original API key or admin JWT
+func TokenExchange(exchanger tokenexchange.Exchanger, authService *service.AuthService, audience, header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var subject *tokenexchange.Subject
+
+		if apiKeyInterface, exists := c.Get("api_key"); exists && apiKeyInterface != nil {
+			apiKey := apiKeyInterface.(*models.APIKey)
+			subject = &tokenexchange.Subject{
+				ID:   apiKey.ID.String(),
+				Type: "api_key",
+				Tier: apiKey.Tier,
+			}
+		}
+
+		// Gateway issued JWTs are swapped as well and never forwarded
+		if tokenString, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); found {
+			if claims, err := authService.ValidateToken(tokenString); err == nil {
+				c.Request.Header.Del("Authorization")
+				if subject == nil {
+					subject = &tokenexchange.Subject{
+						ID:    claimString(claims["user_id"]),
+						Type:  "user",
+						Email: claimString(claims["email"]),
+						Role:  claimString(claims["role"]),
+					}
+				}
+			}
+		}
+
+		c.Request.Header.Del("X-API-Key")
+
+		if subject == nil {
+			c.Next()
+			return
+		}
+
+		token, err := exchanger.Exchange(c.Request.Context(), *subject, audience)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("Token exchange failed", "audience", audience, "error", err)
+			c.JSON(http.StatusBadGateway, gin.H{
+				"error": "Token exchange failed",
+			})
+			c.Abort()
+			return
+		}
+
+		if header == "Authorization" {
+			c.Request.Header.Set(header, "Bearer "+token.Value)
+		} else {
+			c.Request.Header.Set(header, token.Value)
+		}
+
+		c.Next()
+	}
+}
+
+func claimString(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
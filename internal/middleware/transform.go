@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header changes for one direction of a proxied exchange
+type HeaderRewrite struct {
+	Set    map[string]string
+	Remove []string
+	Add    map[string]string // Appended unless the value is already present
+	Rename map[string]string // Old name to new name
+}
+
+func (h HeaderRewrite) empty() bool {
+	return len(h.Set) == 0 && len(h.Remove) == 0 && len(h.Add) == 0 && len(h.Rename) == 0
+}
+
+func (h HeaderRewrite) apply(header http.Header) {
+	for from, to := range h.Rename {
+		if values := header.Values(from); len(values) > 0 {
+			values = slices.Clone(values)
+			header.Del(from)
+			header[http.CanonicalHeaderKey(to)] = values
+		}
+	}
+	for _, name := range h.Remove {
+		header.Del(name)
+	}
+	for name, value := range h.Set {
+		header.Set(name, value)
+	}
+	for name, value := range h.Add {
+		if !slices.Contains(header.Values(name), value) {
+			header.Add(name, value)
+		}
+	}
+}
+
+// Rewrites request headers before proxying and response headers before they are sent
+func HeaderTransform(request, response HeaderRewrite) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		request.apply(c.Request.Header)
+
+		if !response.empty() {
+			c.Writer = &headerRewriteWriter{ResponseWriter: c.Writer, rewrite: response}
+		}
+
+		c.Next()
+	}
+}
+
+// Applies the response rewrite just before the headers are written
+type headerRewriteWriter struct {
+	gin.ResponseWriter
+	rewrite HeaderRewrite
+	applied bool
+}
+
+func (w *headerRewriteWriter) WriteHeader(statusCode int) {
+	if !w.applied {
+		w.applied = true
+		w.rewrite.apply(w.ResponseWriter.Header())
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *headerRewriteWriter) Write(data []byte) (int, error) {
+	if !w.applied {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Lets http.ResponseController reach the underlying writer
+func (w *headerRewriteWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *headerRewriteWriter) WriteString(s string) (int, error) {
+	if !w.applied {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Path and query changes for requests sent to a backend
+type URLRewrite struct {
+	Paths       []PathRewrite // The first matching rule applies
+	AddQuery    map[string]string
+	RemoveQuery []string
+
+	// Leading segments replaced when no rule matches, e.g. "/users" to "/v1"
+	// or, stripped, to ""
+	Prefix        string
+	ReplacePrefix string
+}
+
+// Replaces the parts of the path Match matches; Replace may use $1 or ${name}
+type PathRewrite struct {
+	Match   *regexp.Regexp
+	Replace string
+}
+
+// Returns the path and query to send to the backend
+func (u URLRewrite) Rewrite(path, rawQuery string) (string, string) {
+	matched := false
+	for _, rule := range u.Paths {
+		if rule.Match.MatchString(path) {
+			path = rule.Match.ReplaceAllString(path, rule.Replace)
+			matched = true
+			break
+		}
+	}
+	if !matched && u.Prefix != "" {
+		prefix := strings.TrimSuffix(u.Prefix, "/")
+		if rest, ok := strings.CutPrefix(path, prefix); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+			path = strings.TrimSuffix(u.ReplacePrefix, "/") + rest
+			if path == "" {
+				path = "/"
+			}
+		}
+	}
+
+	if len(u.AddQuery) > 0 || len(u.RemoveQuery) > 0 {
+		query, _ := url.ParseQuery(rawQuery)
+		for _, name := range u.RemoveQuery {
+			query.Del(name)
+		}
+		for name, value := range u.AddQuery {
+			query.Set(name, value)
+		}
+		rawQuery = query.Encode()
+	}
+
+	return path, rawQuery
+}
+
+// Rewrites the request URL for the proxy. It belongs last in the chain, and
+// the gateway URL is restored afterwards so logs and metrics keep showing it.
+func RewriteURL(rewrite URLRewrite) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		original := c.Request.URL
+		rewritten := *original
+		rewritten.Path, rewritten.RawQuery = rewrite.Rewrite(original.Path, original.RawQuery)
+		if rewritten.Path != original.Path {
+			rewritten.RawPath = ""
+		}
+
+		c.Request.URL = &rewritten
+		c.Next()
+		c.Request.URL = original
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/messages"
+	"github.com/aman-churiwal/api-gateway/internal/proxy"
+	"github.com/gin-gonic/gin"
+)
+
+// One version of a service's API
+type APIVersion struct {
+	Name       string
+	OwnTargets bool // Served by the proxy target group named by VersionGroup
+	Deprecated bool
+	Sunset     time.Time // Zero when no date is set
+	Link       string
+}
+
+// Name of the proxy target group of a version
+func VersionGroup(name string) string {
+	return "version:" + name
+}
+
+// Picks the API version from the path segment after the service path or, when
+// header is set, from that header, and routes the request to its targets.
+// Records "api_version" as "<service>@<version>" for the request log, and
+// marks responses of deprecated versions. Unknown versions in the header are
+// rejected; in the path they fall back to the default like unversioned paths.
+func Versions(servicePath, header, defaultVersion string, versions []APIVersion) gin.HandlerFunc {
+	byName := make(map[string]*APIVersion, len(versions))
+	for i := range versions {
+		byName[versions[i].Name] = &versions[i]
+	}
+	prefix := strings.TrimSuffix(servicePath, "/") + "/"
+
+	return func(c *gin.Context) {
+		name := ""
+		if header != "" {
+			name = c.GetHeader(header)
+			if _, ok := byName[name]; name != "" && !ok {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error":    messages.Localize(c, messages.UnsupportedVersion, map[string]interface{}{"Version": name}),
+					"versions": versionNames(versions),
+				})
+				return
+			}
+		} else if rest, ok := strings.CutPrefix(c.Request.URL.Path, prefix); ok {
+			name, _, _ = strings.Cut(rest, "/")
+		}
+
+		version, ok := byName[name]
+		if !ok {
+			version, ok = byName[defaultVersion]
+		}
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if version.OwnTargets {
+			proxy.RouteToGroup(c, VersionGroup(version.Name))
+		}
+		c.Set("api_version", servicePath+"@"+version.Name)
+
+		if version.Deprecated {
+			c.Header("Deprecation", "true")
+			if !version.Sunset.IsZero() {
+				c.Header("Sunset", version.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if version.Link != "" {
+				c.Header("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, version.Link))
+			}
+		}
+		c.Next()
+	}
+}
+
+func versionNames(versions []APIVersion) []string {
+	names := make([]string, 0, len(versions))
+	for _, version := range versions {
+		names = append(names, version.Name)
+	}
+	return names
+}
@@ -8,14 +8,29 @@ import (
 )
 
 type APIKey struct {
-	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
-	KeyHash    string     `gorm:"uniqueIndex;not null" json:"-"`
-	Name       string     `gorm:"not null" json:"name"`
-	CreatedBy  string     `json:"created_by"`
-	Tier       string     `gorm:"default:'basic'" json:"tier"`
-	IsActive   bool       `gorm:"default:true" json:"is_active"`
-	CreatedAt  time.Time  `json:"created_at"`
-	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	KeyHash     string     `gorm:"uniqueIndex;not null" json:"-"`
+	KeyPrefix   string     `gorm:"index" json:"key_prefix"` // First characters of the plain key, for identification
+	Name        string     `gorm:"not null" json:"name"`
+	CreatedBy   string     `json:"created_by"`
+	Tier        string     `gorm:"default:'basic'" json:"tier"`
+	IsActive    bool       `gorm:"default:true" json:"is_active"`
+	Tags        StringList `gorm:"type:jsonb;default:'[]'" json:"tags"`
+	Metadata    StringMap  `gorm:"type:jsonb;default:'{}'" json:"metadata"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	StaleSince  *time.Time `gorm:"index" json:"stale_since,omitempty"` // Set when flagged as unused, cleared on next use
+	OrgID       *uuid.UUID `gorm:"type:uuid;index" json:"org_id,omitempty"`
+	DeveloperID *uuid.UUID `gorm:"type:uuid;index" json:"developer_id,omitempty"` // Set on keys developers created through the portal
+	KeyOwner
+	Notes string `gorm:"type:text" json:"notes"` // Free-form; ownership transfers are appended
+}
+
+// Who is responsible for a key
+type KeyOwner struct {
+	Owner        string `gorm:"index" json:"owner"`
+	Team         string `gorm:"index" json:"team"`
+	ContactEmail string `json:"contact_email"`
 }
 
 func (a *APIKey) BeforeCreate(tx *gorm.DB) error {
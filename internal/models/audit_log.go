@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Record of a mutating admin operation
+type AuditLog struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	ActorID      string    `gorm:"index" json:"actor_id"`
+	Actor        string    `gorm:"index" json:"actor"` // Email of the admin
+	IP           string    `json:"ip"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	Status       int       `json:"status"`
+	Action       string    `gorm:"index;not null" json:"action"` // e.g. "api_key.update"
+	ResourceType string    `gorm:"index" json:"resource_type,omitempty"`
+	ResourceID   string    `gorm:"index" json:"resource_id,omitempty"`
+	Changes      JSONMap   `gorm:"type:jsonb;default:'{}'" json:"changes"` // Field name to {"before", "after"}
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Represents a proxied request that ultimately failed
+type DeadLetter struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Service       string     `gorm:"index;not null" json:"service"`
+	Method        string     `gorm:"not null" json:"method"`
+	Path          string     `gorm:"not null" json:"path"`
+	RawQuery      string     `json:"raw_query,omitempty"`
+	Headers       StringMap  `gorm:"type:jsonb" json:"headers"`
+	Body          []byte     `gorm:"type:bytea" json:"body,omitempty"`
+	BodyTruncated bool       `json:"body_truncated"`
+	StatusCode    int        `json:"status_code"`
+	Reason        string     `json:"reason"` // "backend_error", "circuit_open", "no_healthy_targets"
+	APIKeyID      *uuid.UUID `gorm:"type:uuid;index" json:"api_key_id,omitempty"`
+	RequestID     string     `json:"request_id,omitempty"`
+	Attempts      int        `gorm:"default:0" json:"redrive_attempts"`
+	RedriveStatus int        `json:"redrive_status,omitempty"`
+	RedrivenAt    *time.Time `gorm:"index" json:"redriven_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"index" json:"created_at"`
+}
+
+func (d *DeadLetter) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+func (DeadLetter) TableName() string {
+	return "dead_letters"
+}
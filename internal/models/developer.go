@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// A consumer of the gateway's APIs who manages their own API keys through the
+// developer portal. Developers are a separate realm from admin users: their
+// tokens never reach the admin API.
+type Developer struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Email        string     `gorm:"uniqueIndex;not null" json:"email"`
+	PasswordHash string     `gorm:"not null" json:"-"`
+	Name         string     `json:"name"`
+	IsActive     bool       `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+}
+
+func (d *Developer) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+func (Developer) TableName() string {
+	return "developers"
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Admin-issued token that allows one user to register
+type Invitation struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Email      string     `gorm:"index" json:"email,omitempty"` // Restricts the invitation to one address when set
+	TokenHash  string     `gorm:"uniqueIndex;not null" json:"-"`
+	CreatedBy  uuid.UUID  `gorm:"type:uuid" json:"created_by"`
+	OrgID      *uuid.UUID `gorm:"type:uuid;index" json:"org_id,omitempty"` // The organization the invitee joins
+	ExpiresAt  time.Time  `gorm:"index" json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	AcceptedBy *uuid.UUID `gorm:"type:uuid" json:"accepted_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (i *Invitation) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+func (Invitation) TableName() string {
+	return "invitations"
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Login attempt outcomes
+const (
+	LoginSucceeded = "succeeded"
+	LoginFailed    = "failed"
+	LoginThrottled = "throttled" // Rejected before the password was checked
+	LoginLocked    = "locked"    // Failure that locked the account
+)
+
+// Audit record of a password login attempt
+type LoginAttempt struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Email     string    `gorm:"index;not null" json:"email"`
+	IP        string    `gorm:"index" json:"ip"`
+	Outcome   string    `gorm:"not null" json:"outcome"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (l *LoginAttempt) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
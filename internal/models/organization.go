@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// A tenant of the gateway. Users and API keys that belong to one only see
+// and manage their organization's keys and usage; those without one operate
+// the whole gateway.
+type Organization struct {
+	ID                uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Name              string    `gorm:"uniqueIndex;not null" json:"name"`
+	RequestsPerMinute int       `gorm:"not null;default:0" json:"requests_per_minute"` // All keys together, on top of each key's tier; 0 for no limit
+	CreatedBy         string    `json:"created_by"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
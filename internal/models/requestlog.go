@@ -11,13 +11,40 @@ type RequestLog struct {
 	ID             uint       `gorm:"primaryKey" json:"id"`
 	Timestamp      time.Time  `gorm:"index" json:"timestamp"`
 	APIKeyID       *uuid.UUID `gorm:"index" json:"api_key_id,omitempty"`
+	ConsumerID     string     `gorm:"index" json:"consumer_id,omitempty"` // Token subject on services with token introspection
 	Method         string     `json:"method"`
 	Path           string     `gorm:"index" json:"path"`
 	StatusCode     int        `gorm:"index" json:"status_code"`
 	ResponseTimeMs int        `json:"response_time_ms"`
+	BytesIn        int64      `gorm:"not null;default:0" json:"bytes_in"`  // Request body size
+	BytesOut       int64      `gorm:"not null;default:0" json:"bytes_out"` // Response body size
 	IPAddress      string     `json:"ip_address"`
 	UserAgent      string     `json:"user_agent"`
 	BackendServer  string     `json:"backend_server,omitempty"`
+	IsPreflight    bool       `gorm:"index;default:false" json:"is_preflight"`
+	ErrorType      string     `gorm:"index" json:"error_type,omitempty"` // Set by the proxy, e.g. "dial_timeout" or "circuit_open"
+	ErrorMessage   string     `json:"error_message,omitempty"`
+	SampleRate     float64    `gorm:"not null;default:1" json:"sample_rate"` // Share of similar requests logged; see Weight
+	Variant        string     `gorm:"index" json:"variant,omitempty"`        // A/B experiment and variant, e.g. "checkout/b"
+	Operation      string     `gorm:"index" json:"operation,omitempty"`      // GraphQL operation, e.g. "query GetUser"
+	Country        string     `gorm:"size:2;index" json:"country,omitempty"` // ISO code of the client's country, when geoip is configured
+	BotVerdict     string     `gorm:"index" json:"bot_verdict,omitempty"`    // "human" or "bot", on services with bot detection
+	BotSignals     string     `json:"bot_signals,omitempty"`                 // Why a bot was suspected, e.g. "user_agent,honeypot"
+	Route          string     `gorm:"index" json:"route,omitempty"`          // Matched route template, e.g. "/api/users/{id}/orders"
+	APIVersion     string     `gorm:"index" json:"api_version,omitempty"`    // Service and API version, e.g. "/api/users@v2"
+
+	// Filled only while body capture is on for the service, already redacted
+	RequestHeaders string `gorm:"type:text" json:"request_headers,omitempty"`
+	RequestBody    string `gorm:"type:text" json:"request_body,omitempty"`
+	ResponseBody   string `gorm:"type:text" json:"response_body,omitempty"`
+}
+
+// Number of requests this entry stands for when traffic is sampled
+func (l *RequestLog) Weight() float64 {
+	if l.SampleRate <= 0 {
+		return 1
+	}
+	return 1 / l.SampleRate
 }
 
 func (RequestLog) TableName() string {
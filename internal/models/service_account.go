@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Non-human principal for automation. Authenticates with ServiceAccountTokens
+// instead of admin user JWTs.
+type ServiceAccount struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Name        string     `gorm:"uniqueIndex;not null" json:"name"`
+	Description string     `json:"description"`
+	Scopes      StringList `gorm:"type:jsonb;default:'[]'" json:"scopes"` // Upper bound for its tokens' scopes
+	IsActive    bool       `gorm:"default:true" json:"is_active"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (a *ServiceAccount) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (ServiceAccount) TableName() string {
+	return "service_accounts"
+}
+
+// Long-lived bearer token issued to a service account
+type ServiceAccountToken struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	ServiceAccountID uuid.UUID  `gorm:"type:uuid;index;not null" json:"service_account_id"`
+	Name             string     `json:"name"`
+	TokenHash        string     `gorm:"uniqueIndex;not null" json:"-"`
+	TokenPrefix      string     `json:"token_prefix"` // First characters of the plain token, for identification
+	Scopes           StringList `gorm:"type:jsonb;default:'[]'" json:"scopes"`
+	CreatedBy        string     `json:"created_by"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"` // Never expires when nil
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt        *time.Time `gorm:"index" json:"revoked_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+func (t *ServiceAccountToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+func (ServiceAccountToken) TableName() string {
+	return "service_account_tokens"
+}
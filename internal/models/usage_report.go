@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Usage of one API key over a calendar month (UTC), rolled up from request logs
+// for invoicing. Counts are extrapolated when request logging is sampled.
+type UsageReport struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	APIKeyID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_usage_reports_key_month" json:"api_key_id"`
+	Month        time.Time `gorm:"type:date;not null;uniqueIndex:idx_usage_reports_key_month;index" json:"month"` // First day of the month
+	Requests     int64     `gorm:"not null" json:"requests"`
+	ClientErrors int64     `gorm:"not null" json:"client_errors"`
+	ServerErrors int64     `gorm:"not null" json:"server_errors"`
+	BytesIn      int64     `gorm:"not null" json:"bytes_in"`
+	BytesOut     int64     `gorm:"not null" json:"bytes_out"`
+	Final        bool      `gorm:"not null;default:false" json:"final"` // Month over and rolled up after the last requests
+	GeneratedAt  time.Time `gorm:"not null" json:"generated_at"`
+}
+
+func (u *UsageReport) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	return nil
+}
+
+func (UsageReport) TableName() string {
+	return "usage_reports"
+}
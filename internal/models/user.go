@@ -7,13 +7,26 @@ import (
 	"gorm.io/gorm"
 )
 
+// Gateway roles
+const (
+	RoleAdmin  = "admin"
+	RoleViewer = "viewer" // Read-only access to the admin API
+
+	// Set on requests authenticated with a service account token, whose scopes bound access
+	RoleServiceAccount = "service_account"
+)
+
 type User struct {
-	ID           uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
-	Email        string    `gorm:"uniqueIndex;not null" json:"email"`
-	PasswordHash string    `gorm:"not null"`
-	Name         string    `json:"name"`
-	Role         string    `gorm:"default:'admin'" json:"role"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Email        string     `gorm:"uniqueIndex;not null" json:"email"`
+	PasswordHash string     `gorm:"not null"`
+	Name         string     `json:"name"`
+	Role         string     `gorm:"default:'admin'" json:"role"`             // "admin" or "viewer"
+	AuthProvider string     `gorm:"default:'local'" json:"auth_provider"`    // "local" or "oidc"
+	ExternalID   string     `gorm:"index" json:"-"`                          // The provider's subject for external accounts
+	OrgID        *uuid.UUID `gorm:"type:uuid;index" json:"org_id,omitempty"` // Nil for operators of the whole gateway
+	CreatedAt    time.Time  `json:"created_at"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) error {
@@ -0,0 +1,129 @@
+package proxy
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Names of the two target sets of a blue-green service
+const (
+	SetBlue  = "blue"
+	SetGreen = "green"
+)
+
+// Holds a service's two target sets, of which only the active one is routed to
+type BlueGreenConfig struct {
+	Blue   []string
+	Green  []string
+	Active string // Default: blue
+}
+
+// Snapshot of a blue-green service
+type BlueGreenStatus struct {
+	Active     string     `json:"active"`
+	Blue       []string   `json:"blue"`
+	Green      []string   `json:"green"`
+	SwitchedAt *time.Time `json:"switched_at,omitempty"`
+}
+
+// Routes to one target set while the other stays health-checked for switching back
+type blueGreen struct {
+	cfg BlueGreenConfig
+
+	mu         sync.RWMutex
+	active     string
+	switchedAt *time.Time
+}
+
+func newBlueGreen(cfg BlueGreenConfig) *blueGreen {
+	if cfg.Active == "" {
+		cfg.Active = SetBlue
+	}
+	return &blueGreen{cfg: cfg, active: cfg.Active}
+}
+
+func (b *blueGreen) enabled() bool {
+	return len(b.cfg.Blue) > 0 || len(b.cfg.Green) > 0
+}
+
+func (b *blueGreen) targets(set string) []string {
+	if set == SetGreen {
+		return b.cfg.Green
+	}
+	return b.cfg.Blue
+}
+
+// Drops the targets of the inactive set. They never get traffic, even when
+// the active set has no healthy targets.
+func (b *blueGreen) live(healthy []string) []string {
+	if !b.enabled() {
+		return healthy
+	}
+
+	b.mu.RLock()
+	inactive := b.targets(SetBlue)
+	if b.active == SetBlue {
+		inactive = b.targets(SetGreen)
+	}
+	b.mu.RUnlock()
+
+	return slices.DeleteFunc(slices.Clone(healthy), func(target string) bool {
+		return slices.Contains(inactive, target)
+	})
+}
+
+// Makes set the active one. An empty set flips to the inactive one.
+func (b *blueGreen) switchTo(set string) (string, error) {
+	if !b.enabled() {
+		return "", fmt.Errorf("service has no blue-green target sets")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if set == "" {
+		set = SetGreen
+		if b.active == SetGreen {
+			set = SetBlue
+		}
+	}
+	if set != SetBlue && set != SetGreen {
+		return "", fmt.Errorf("unknown target set: %s", set)
+	}
+
+	previous := b.active
+	if set != previous {
+		now := time.Now()
+		b.active = set
+		b.switchedAt = &now
+	}
+	return previous, nil
+}
+
+func (b *blueGreen) status() BlueGreenStatus {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return BlueGreenStatus{
+		Active:     b.active,
+		Blue:       b.cfg.Blue,
+		Green:      b.cfg.Green,
+		SwitchedAt: b.switchedAt,
+	}
+}
+
+// Returns the blue-green sets, or false when the service doesn't have them
+func (p *Proxy) BlueGreenStatus() (BlueGreenStatus, bool) {
+	if !p.blueGreen.enabled() {
+		return BlueGreenStatus{}, false
+	}
+	return p.blueGreen.status(), true
+}
+
+// Routes traffic to the named set, or the inactive one when set is empty, and
+// returns the previously active set. Requests in flight finish where they started.
+func (p *Proxy) SwitchTargets(set string) (string, error) {
+	return p.blueGreen.switchTo(set)
+}
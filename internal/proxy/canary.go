@@ -0,0 +1,207 @@
+package proxy
+
+import (
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Holds settings for sending a share of traffic to a canary group of targets
+type CanaryConfig struct {
+	Targets  []string
+	Percent  float64 // Share of requests sent to the canary, 0-100
+	Rollback CanaryRollbackConfig
+}
+
+// Sets the canary's share to zero when it fails noticeably more than the stable group
+type CanaryRollbackConfig struct {
+	Enabled     bool
+	Threshold   float64       // Percentage points the canary's 5xx rate may exceed the stable group's. Default: 5
+	Window      time.Duration // Error rates are compared over this window. Default: 1m
+	MinRequests int           // Canary requests needed in a window before comparing. Default: 20
+}
+
+// Snapshot of a proxy's canary split
+type CanaryStatus struct {
+	Targets         []string   `json:"targets"`
+	Percent         float64    `json:"percent"`
+	AutoRollback    bool       `json:"auto_rollback"`
+	RolledBackAt    *time.Time `json:"rolled_back_at,omitempty"`
+	RollbackReason  string     `json:"rollback_reason,omitempty"`
+	StableRequests  int64      `json:"stable_requests"` // In the current window
+	StableErrors    int64      `json:"stable_errors"`
+	CanaryRequests  int64      `json:"canary_requests"`
+	CanaryErrors    int64      `json:"canary_errors"`
+	WindowStartedAt time.Time  `json:"window_started_at"`
+}
+
+// Splits traffic between the stable targets and the canary group
+type canary struct {
+	cfg CanaryConfig
+
+	mu             sync.Mutex
+	percent        float64
+	rolledBackAt   *time.Time
+	rollbackReason string
+	windowStart    time.Time
+	stable         canaryCounts
+	canary         canaryCounts
+}
+
+type canaryCounts struct {
+	requests int64
+	errors   int64
+}
+
+func (c canaryCounts) errorRate() float64 {
+	if c.requests == 0 {
+		return 0
+	}
+	return float64(c.errors) / float64(c.requests) * 100
+}
+
+func newCanary(cfg CanaryConfig) *canary {
+	if cfg.Rollback.Threshold <= 0 {
+		cfg.Rollback.Threshold = 5
+	}
+	if cfg.Rollback.Window <= 0 {
+		cfg.Rollback.Window = time.Minute
+	}
+	if cfg.Rollback.MinRequests <= 0 {
+		cfg.Rollback.MinRequests = 20
+	}
+	return &canary{cfg: cfg, percent: cfg.Percent, windowStart: time.Now()}
+}
+
+func (c *canary) enabled() bool {
+	return len(c.cfg.Targets) > 0
+}
+
+func (c *canary) isCanary(target string) bool {
+	return slices.Contains(c.cfg.Targets, target)
+}
+
+// Returns the healthy targets of the group this request goes to, and whether
+// it is the canary. Falls back to the other group when one has no healthy targets.
+func (c *canary) choose(healthy []string) ([]string, bool) {
+	if !c.enabled() {
+		return healthy, false
+	}
+
+	var stable, canary []string
+	for _, target := range healthy {
+		if c.isCanary(target) {
+			canary = append(canary, target)
+		} else {
+			stable = append(stable, target)
+		}
+	}
+
+	c.mu.Lock()
+	percent := c.percent
+	c.mu.Unlock()
+
+	toCanary := percent > 0 && rand.Float64()*100 < percent
+	if (toCanary && len(canary) > 0) || len(stable) == 0 {
+		return canary, len(canary) > 0
+	}
+	return stable, false
+}
+
+// Counts a response from either group and rolls the canary back when its
+// error rate exceeds the stable group's by more than the threshold
+func (c *canary) record(isCanary, failed bool) {
+	if !c.enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.windowStart) > c.cfg.Rollback.Window {
+		c.windowStart = now
+		c.stable = canaryCounts{}
+		c.canary = canaryCounts{}
+	}
+
+	counts := &c.stable
+	if isCanary {
+		counts = &c.canary
+	}
+	counts.requests++
+	if failed {
+		counts.errors++
+	}
+
+	if !isCanary || !c.cfg.Rollback.Enabled || c.percent == 0 || c.canary.requests < int64(c.cfg.Rollback.MinRequests) {
+		return
+	}
+
+	canaryRate, stableRate := c.canary.errorRate(), c.stable.errorRate()
+	if canaryRate-stableRate > c.cfg.Rollback.Threshold {
+		c.percent = 0
+		c.rolledBackAt = &now
+		c.rollbackReason = "canary error rate exceeded the stable group's by more than the threshold"
+		slog.Warn("Canary rolled back",
+			"canary_targets", c.cfg.Targets,
+			"canary_error_rate", canaryRate,
+			"stable_error_rate", stableRate,
+			"threshold", c.cfg.Rollback.Threshold,
+		)
+	}
+}
+
+func (c *canary) setPercent(percent float64) error {
+	if !c.enabled() {
+		return errors.New("service has no canary targets")
+	}
+	if percent < 0 || percent > 100 {
+		return errors.New("percent must be between 0 and 100")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.percent = percent
+	c.rolledBackAt = nil
+	c.rollbackReason = ""
+	c.windowStart = time.Now()
+	c.stable = canaryCounts{}
+	c.canary = canaryCounts{}
+	return nil
+}
+
+func (c *canary) status() CanaryStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CanaryStatus{
+		Targets:         c.cfg.Targets,
+		Percent:         c.percent,
+		AutoRollback:    c.cfg.Rollback.Enabled,
+		RolledBackAt:    c.rolledBackAt,
+		RollbackReason:  c.rollbackReason,
+		StableRequests:  c.stable.requests,
+		StableErrors:    c.stable.errors,
+		CanaryRequests:  c.canary.requests,
+		CanaryErrors:    c.canary.errors,
+		WindowStartedAt: c.windowStart,
+	}
+}
+
+// Returns the canary split, or false when the service has no canary
+func (p *Proxy) CanaryStatus() (CanaryStatus, bool) {
+	if !p.canary.enabled() {
+		return CanaryStatus{}, false
+	}
+	return p.canary.status(), true
+}
+
+// Changes the share of traffic sent to the canary. Setting it clears a rollback.
+func (p *Proxy) SetCanaryPercent(percent float64) error {
+	return p.canary.setPercent(percent)
+}
@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Largest response body read by Do
+const maxClientBodyBytes = 10 << 20
+
+// Sends a gateway-originated request to a healthy target, protected by the circuit breaker
+func (p *Proxy) Do(ctx context.Context, method, path, rawQuery string, header http.Header, body []byte) (int, http.Header, []byte, error) {
+	healthyTargets := p.routableTargets("")
+	if len(healthyTargets) == 0 {
+		return 0, nil, nil, errors.New("no healthy backend servers available")
+	}
+
+	groupTargets, toCanary := p.canary.choose(healthyTargets)
+	selectedTarget := p.loadBalancer.Next(groupTargets)
+	target, err := url.Parse(selectedTarget)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	target.Path = strings.TrimRight(target.Path, "/") + path
+	target.RawQuery = rawQuery
+
+	// Callers with their own deadline, like async workers, may wait longer
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if header != nil {
+		req.Header = header.Clone()
+	}
+
+	var statusCode int
+	var respHeader http.Header
+	var respBody []byte
+	err = p.circuitBreaker.Call(func() error {
+		sent := time.Now()
+		resp, err := (&http.Client{Transport: p.transport}).Do(req)
+		if err != nil {
+			p.outliers.record(selectedTarget, http.StatusBadGateway, time.Since(sent))
+			return err
+		}
+		defer resp.Body.Close()
+
+		statusCode = resp.StatusCode
+		respHeader = resp.Header
+		p.canary.record(toCanary, statusCode >= 500)
+		p.outliers.record(selectedTarget, statusCode, time.Since(sent))
+		respBody, err = io.ReadAll(io.LimitReader(resp.Body, maxClientBodyBytes))
+		if err != nil {
+			return err
+		}
+
+		if statusCode >= 500 {
+			return errors.New("backend error")
+		}
+		return nil
+	})
+
+	return statusCode, respHeader, respBody, err
+}
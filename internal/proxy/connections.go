@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Holds long-lived connection settings for a service
+type LongLivedConfig struct {
+	MaxPerKey     int      // Max concurrent long-lived connections per API key (0: unlimited)
+	LongPollPaths []string // Path prefixes treated as long-polls
+}
+
+// Snapshot of connection counts for a proxy
+type ConnectionStats struct {
+	ActiveRequests     int64                      `json:"active_requests"`
+	LongLived          int64                      `json:"long_lived"`
+	LongLivedByKind    map[string]int64           `json:"long_lived_by_kind"`
+	LongLivedRejected  int64                      `json:"long_lived_rejected"`
+	MaxLongLivedPerKey int                        `json:"max_long_lived_per_key"`
+	Targets            map[string]TargetConnStats `json:"targets"`
+}
+
+// Per-target connection counts
+type TargetConnStats struct {
+	Active    int `json:"active"`
+	LongLived int `json:"long_lived"`
+}
+
+// Tracks normal and long-lived connections for a proxy
+type connectionTracker struct {
+	mu            sync.Mutex
+	active        atomic.Int64
+	rejected      atomic.Int64
+	byKind        map[string]int64
+	perKey        map[string]int
+	targets       map[string]*TargetConnStats
+	maxPerKey     int
+	longPollPaths []string
+}
+
+func newConnectionTracker(cfg LongLivedConfig) *connectionTracker {
+	return &connectionTracker{
+		byKind:        make(map[string]int64),
+		perKey:        make(map[string]int),
+		targets:       make(map[string]*TargetConnStats),
+		maxPerKey:     cfg.MaxPerKey,
+		longPollPaths: cfg.LongPollPaths,
+	}
+}
+
+// Returns the kind of long-lived connection ("websocket", "sse", "long_poll") or "" for normal requests
+func (t *connectionTracker) classify(req *http.Request) string {
+	if strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		return "websocket"
+	}
+	if strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+		return "sse"
+	}
+	for _, prefix := range t.longPollPaths {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			return "long_poll"
+		}
+	}
+	return ""
+}
+
+// Reserves a long-lived slot for a consumer, returns false if the per-key cap is reached
+func (t *connectionTracker) acquireLongLived(consumer, kind string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.maxPerKey > 0 && t.perKey[consumer] >= t.maxPerKey {
+		t.rejected.Add(1)
+		return false
+	}
+
+	t.perKey[consumer]++
+	t.byKind[kind]++
+	return true
+}
+
+// Releases a long-lived slot for a consumer
+func (t *connectionTracker) releaseLongLived(consumer, kind string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.perKey[consumer] <= 1 {
+		delete(t.perKey, consumer)
+	} else {
+		t.perKey[consumer]--
+	}
+	if t.byKind[kind] > 0 {
+		t.byKind[kind]--
+	}
+}
+
+// Records the start of a request against a target
+func (t *connectionTracker) start(target string, longLived bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, exists := t.targets[target]
+	if !exists {
+		stats = &TargetConnStats{}
+		t.targets[target] = stats
+	}
+	if longLived {
+		stats.LongLived++
+	} else {
+		stats.Active++
+		t.active.Add(1)
+	}
+}
+
+// Records the end of a request against a target
+func (t *connectionTracker) done(target string, longLived bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, exists := t.targets[target]
+	if !exists {
+		return
+	}
+	if longLived {
+		if stats.LongLived > 0 {
+			stats.LongLived--
+		}
+	} else {
+		if stats.Active > 0 {
+			stats.Active--
+		}
+		t.active.Add(-1)
+	}
+}
+
+// Returns a snapshot of the tracked connections
+func (t *connectionTracker) stats() ConnectionStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := ConnectionStats{
+		ActiveRequests:     t.active.Load(),
+		LongLivedByKind:    make(map[string]int64),
+		LongLivedRejected:  t.rejected.Load(),
+		MaxLongLivedPerKey: t.maxPerKey,
+		Targets:            make(map[string]TargetConnStats),
+	}
+	for kind, count := range t.byKind {
+		stats.LongLivedByKind[kind] = count
+		stats.LongLived += count
+	}
+	for target, targetStats := range t.targets {
+		stats.Targets[target] = *targetStats
+	}
+
+	return stats
+}
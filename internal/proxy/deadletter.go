@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Holds dead-letter capture settings for a service
+type DeadLetterConfig struct {
+	Enabled      bool
+	MaxBodyBytes int64                    // Default: 64KB
+	Headers      []string                 // Headers kept on capture (default: Content-Type, User-Agent)
+	Sink         func(*models.DeadLetter) // Receives captured requests
+}
+
+// Credentials are never captured, even if configured
+var redactedHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"X-Api-Key":     true,
+}
+
+// Request body buffered for capture while still being forwarded
+type capturedBody struct {
+	data      []byte
+	truncated bool
+}
+
+// Buffers up to maxBytes of the request body and restores it for forwarding
+func captureBody(req *http.Request, maxBytes int64) *capturedBody {
+	if req.Body == nil || req.Body == http.NoBody {
+		return &capturedBody{}
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(req.Body, maxBytes+1))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), req.Body), req.Body}
+
+	if int64(len(data)) > maxBytes {
+		return &capturedBody{data: data[:maxBytes], truncated: true}
+	}
+	return &capturedBody{data: data}
+}
+
+// Hands a redacted copy of a failed request to the dead-letter sink
+func (p *Proxy) captureDeadLetter(c *gin.Context, body *capturedBody, reason string) {
+	headers := make(models.StringMap)
+	for _, name := range p.deadLetter.Headers {
+		name = http.CanonicalHeaderKey(name)
+		if redactedHeaders[name] {
+			continue
+		}
+		if value := c.Request.Header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+
+	deadLetter := &models.DeadLetter{
+		Method:        c.Request.Method,
+		Path:          c.Request.URL.Path,
+		RawQuery:      c.Request.URL.RawQuery,
+		Headers:       headers,
+		Body:          body.data,
+		BodyTruncated: body.truncated,
+		StatusCode:    c.Writer.Status(),
+		Reason:        reason,
+		RequestID:     c.GetString("request_id"),
+	}
+	if apiKeyID, exists := c.Get("api_key_id"); exists {
+		if id, ok := apiKeyID.(uuid.UUID); ok {
+			deadLetter.APIKeyID = &id
+		}
+	}
+
+	p.deadLetter.Sink(deadLetter)
+}
+
+// Re-sends a captured request to a healthy target
+func (p *Proxy) Redrive(ctx context.Context, method, path, rawQuery string, header http.Header, body []byte) (int, error) {
+	statusCode, _, _, err := p.Do(ctx, method, path, rawQuery, header, body)
+	return statusCode, err
+}
@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// Error types recorded on failed requests. Gateway-side failures reuse the
+// dead letter reasons.
+const (
+	ErrorDialTimeout       = "dial_timeout"
+	ErrorConnectionRefused = "connection_refused"
+	ErrorDNS               = "dns_error"
+	ErrorTLS               = "tls_error"
+	ErrorUpstreamTimeout   = "upstream_timeout"
+	ErrorConnectionReset   = "connection_reset"
+	ErrorClientCanceled    = "client_canceled"
+	ErrorUpstream          = "upstream_error"
+)
+
+// Longest upstream error message kept on a request log
+const maxErrorMessageLength = 500
+
+// Implemented by response writers that keep the transport error for the request
+type upstreamErrorRecorder interface {
+	recordUpstreamError(errorType, message string)
+}
+
+// Replaces the reverse proxy's default handler so the cause of a transport
+// failure reaches the request log instead of only a 502
+func handleUpstreamError(w http.ResponseWriter, r *http.Request, err error) {
+	errorType := classifyUpstreamError(err)
+	message := err.Error()
+	if len(message) > maxErrorMessageLength {
+		message = message[:maxErrorMessageLength]
+	}
+
+	if recorder, ok := w.(upstreamErrorRecorder); ok {
+		recorder.recordUpstreamError(errorType, message)
+	}
+
+	logging.FromContext(r.Context()).Warn("Upstream request failed", "error_type", errorType, "error", message)
+
+	// Nothing useful can be written to a client that went away
+	if errorType == ErrorClientCanceled {
+		return
+	}
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+// Maps a transport error from the reverse proxy to an error type
+func classifyUpstreamError(err error) string {
+	var (
+		dnsErr       *net.DNSError
+		opErr        *net.OpError
+		netErr       net.Error
+		certErr      *tls.CertificateVerificationError
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrorClientCanceled
+	case errors.As(err, &dnsErr):
+		return ErrorDNS
+	case errors.As(err, &certErr), errors.As(err, &recordErr), errors.As(err, &alertErr),
+		errors.As(err, &authorityErr), errors.As(err, &hostnameErr), errors.As(err, &invalidErr),
+		strings.Contains(err.Error(), "TLS handshake"), strings.Contains(err.Error(), "HTTPS client"):
+		return ErrorTLS
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		if opErr.Timeout() {
+			return ErrorDialTimeout
+		}
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return ErrorConnectionRefused
+		}
+		return ErrorUpstream
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorUpstreamTimeout
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorConnectionReset
+	default:
+		return ErrorUpstream
+	}
+}
+
+// Records why the gateway failed the request, for the request logs
+func setError(c *gin.Context, errorType, message string) {
+	c.Set("error_type", errorType)
+	c.Set("error_message", message)
+}
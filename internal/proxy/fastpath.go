@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/circuitbreaker"
+	"github.com/aman-churiwal/api-gateway/internal/loadbalancer"
+)
+
+// Serves a fast-path request without gin. Only load balancing over healthy
+// targets and the circuit breaker apply; dead letters, upstream backoff,
+// connection accounting and localized messages are skipped. Panics are
+// recovered and only failed requests are logged.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	sw := &statusWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+	defer func() {
+		if err := recover(); err != nil {
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			slog.Error("Panic recovered on fast path", "method", r.Method, "path", r.URL.Path, "panic", fmt.Sprint(err), "stack", string(debug.Stack()))
+			if !sw.wroteHeader {
+				writeError(sw, http.StatusInternalServerError, "Internal Server Error")
+			}
+		}
+		if sw.statusCode >= 500 {
+			slog.Error("request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", sw.statusCode,
+				"latency_ms", float64(time.Since(start).Microseconds())/1000,
+				"client_ip", r.RemoteAddr,
+				"backend_target", sw.target,
+				"error_type", sw.errorType,
+				"error_message", sw.errorMessage,
+				"fast_path", true,
+			)
+		}
+	}()
+
+	healthyTargets := p.routableTargets("")
+	if len(healthyTargets) == 0 {
+		sw.recordUpstreamError("no_healthy_targets", "no healthy backend targets")
+		writeError(sw, http.StatusServiceUnavailable, "No healthy backend servers available")
+		return
+	}
+
+	groupTargets, toCanary := p.canary.choose(healthyTargets)
+	selectedTarget := p.loadBalancer.Next(groupTargets)
+	sw.target = selectedTarget
+	targetProxy, exists := p.reverseProxy(selectedTarget)
+	if !exists {
+		sw.recordUpstreamError("no_target_selected", "load balancer returned no target")
+		writeError(sw, http.StatusServiceUnavailable, "Failed to select backend server")
+		return
+	}
+
+	if lc, ok := p.loadBalancer.(*loadbalancer.LeastConnections); ok {
+		lc.Increment(selectedTarget)
+		defer lc.Decrement(selectedTarget)
+	}
+
+	target, _ := url.Parse(selectedTarget)
+
+	err := p.circuitBreaker.Call(func() error {
+		r.Header.Set("X-Forwarded-Host", r.Host)
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			r.Header.Set("X-Forwarded-For", host)
+		}
+		r.URL.Host = target.Host
+		r.URL.Scheme = target.Scheme
+		r.Host = target.Host
+
+		sent := time.Now()
+		targetProxy.ServeHTTP(sw, r)
+		p.canary.record(toCanary, sw.statusCode >= 500)
+		p.outliers.record(selectedTarget, sw.statusCode, time.Since(sent))
+
+		if sw.statusCode >= 500 {
+			return errors.New("backend error")
+		}
+		return nil
+	})
+
+	if err == circuitbreaker.ErrCircuitOpen {
+		sw.recordUpstreamError("circuit_open", "circuit breaker open")
+		writeError(sw, http.StatusServiceUnavailable, "Service temporarily unavailable")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// Records the status written through a plain http.ResponseWriter
+type statusWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	target      string // Backend chosen for the request, for logging
+
+	errorType    string
+	errorMessage string
+}
+
+func (w *statusWriter) recordUpstreamError(errorType, message string) {
+	w.errorType = errorType
+	w.errorMessage = message
+}
+
+func (w *statusWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.statusCode = statusCode
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusWriter) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(data)
+}
+
+// Lets http.ResponseController reach the underlying writer for flushing
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
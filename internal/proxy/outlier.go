@@ -0,0 +1,208 @@
+package proxy
+
+import (
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Holds settings for ejecting targets that misbehave under live traffic,
+// independently of health checks
+type OutlierConfig struct {
+	Enabled           bool
+	ConsecutiveErrors int           // 5xx responses in a row that eject a target. Default: 5
+	LatencyFactor     float64       // Eject targets slower on average than this multiple of the pool median. Default: 0 (off)
+	MinRequests       int           // Requests a target needs in an interval to be compared on latency. Default: 20
+	Interval          time.Duration // Latency is compared over this window. Default: 10s
+	BaseEjection      time.Duration // Doubles with each ejection in a row. Default: 30s
+	MaxEjection       time.Duration // Default: 5m
+	MaxEjectedPercent float64       // Share of targets that may be out at once, 0-100. Default: 50
+}
+
+// A target currently kept out of rotation
+type EjectedTarget struct {
+	Target    string    `json:"target"`
+	Reason    string    `json:"reason"` // "consecutive_errors" or "latency"
+	Until     time.Time `json:"until"`
+	Ejections int       `json:"ejections"` // In a row, which sets the ejection time
+}
+
+type outlierStats struct {
+	consecutiveErrors int
+	requests          int
+	latency           time.Duration // Sum over the current interval
+	ejections         int
+	ejectedUntil      time.Time
+	reason            string
+}
+
+// Tracks responses per target and ejects outliers
+type outlierDetector struct {
+	cfg OutlierConfig
+
+	mu          sync.Mutex
+	targets     map[string]*outlierStats
+	windowStart time.Time
+}
+
+func newOutlierDetector(cfg OutlierConfig) *outlierDetector {
+	if cfg.ConsecutiveErrors <= 0 {
+		cfg.ConsecutiveErrors = 5
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 20
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	if cfg.BaseEjection <= 0 {
+		cfg.BaseEjection = 30 * time.Second
+	}
+	if cfg.MaxEjection <= 0 {
+		cfg.MaxEjection = 5 * time.Minute
+	}
+	if cfg.MaxEjectedPercent <= 0 {
+		cfg.MaxEjectedPercent = 50
+	}
+	return &outlierDetector{cfg: cfg, targets: make(map[string]*outlierStats), windowStart: time.Now()}
+}
+
+// Drops ejected targets from the candidates
+func (d *outlierDetector) filter(targets []string) []string {
+	if !d.cfg.Enabled {
+		return targets
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	return slices.DeleteFunc(targets, func(target string) bool {
+		stats := d.targets[target]
+		return stats != nil && now.Before(stats.ejectedUntil)
+	})
+}
+
+// Records the outcome of a request to a target
+func (d *outlierDetector) record(target string, statusCode int, latency time.Duration) {
+	if !d.cfg.Enabled {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	stats := d.stats(target)
+	stats.requests++
+	stats.latency += latency
+	if statusCode >= 500 {
+		stats.consecutiveErrors++
+		if stats.consecutiveErrors >= d.cfg.ConsecutiveErrors && !now.Before(stats.ejectedUntil) {
+			d.eject(target, stats, "consecutive_errors", now)
+		}
+	} else {
+		stats.consecutiveErrors = 0
+	}
+
+	if now.Sub(d.windowStart) >= d.cfg.Interval {
+		d.compareLatency(now)
+	}
+}
+
+func (d *outlierDetector) stats(target string) *outlierStats {
+	stats, ok := d.targets[target]
+	if !ok {
+		stats = &outlierStats{}
+		d.targets[target] = stats
+	}
+	return stats
+}
+
+// Ejects targets far slower than the median and starts a new interval.
+// Targets that got through the interval without an ejection earn back one.
+func (d *outlierDetector) compareLatency(now time.Time) {
+	if d.cfg.LatencyFactor > 0 {
+		averages := make(map[string]time.Duration)
+		for target, stats := range d.targets {
+			if stats.requests >= d.cfg.MinRequests && !now.Before(stats.ejectedUntil) {
+				averages[target] = stats.latency / time.Duration(stats.requests)
+			}
+		}
+
+		// A median of fewer than three targets is mostly the outlier itself
+		if len(averages) >= 3 {
+			sorted := make([]time.Duration, 0, len(averages))
+			for _, average := range averages {
+				sorted = append(sorted, average)
+			}
+			sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+			median := sorted[len(sorted)/2]
+
+			for target, average := range averages {
+				if float64(average) > float64(median)*d.cfg.LatencyFactor {
+					d.eject(target, d.targets[target], "latency", now)
+				}
+			}
+		}
+	}
+
+	for _, stats := range d.targets {
+		if stats.ejections > 0 && now.Sub(stats.ejectedUntil) >= d.cfg.Interval {
+			stats.ejections--
+		}
+		stats.requests = 0
+		stats.latency = 0
+	}
+	d.windowStart = now
+}
+
+// Takes a target out of rotation unless too many already are
+func (d *outlierDetector) eject(target string, stats *outlierStats, reason string, now time.Time) {
+	ejected := 0
+	for _, other := range d.targets {
+		if now.Before(other.ejectedUntil) {
+			ejected++
+		}
+	}
+	if float64(ejected+1) > float64(len(d.targets))*d.cfg.MaxEjectedPercent/100 {
+		return
+	}
+
+	duration := d.cfg.BaseEjection << min(stats.ejections, 16)
+	if duration > d.cfg.MaxEjection || duration <= 0 {
+		duration = d.cfg.MaxEjection
+	}
+	stats.ejections++
+	stats.ejectedUntil = now.Add(duration)
+	stats.reason = reason
+	stats.consecutiveErrors = 0
+	slog.Warn("Target ejected as an outlier", "backend_target", target, "reason", reason, "duration", duration.String(), "ejections", stats.ejections)
+}
+
+func (d *outlierDetector) ejected() []EjectedTarget {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	ejected := make([]EjectedTarget, 0)
+	for target, stats := range d.targets {
+		if now.Before(stats.ejectedUntil) {
+			ejected = append(ejected, EjectedTarget{
+				Target:    target,
+				Reason:    stats.reason,
+				Until:     stats.ejectedUntil,
+				Ejections: stats.ejections,
+			})
+		}
+	}
+	sort.Slice(ejected, func(i, j int) bool { return ejected[i].Target < ejected[j].Target })
+	return ejected
+}
+
+// Returns the targets outlier detection currently keeps out of rotation
+func (p *Proxy) EjectedTargets() []EjectedTarget {
+	return p.outliers.ejected()
+}
@@ -2,24 +2,41 @@ package proxy
 
 import (
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/aman-churiwal/api-gateway/internal/circuitbreaker"
 	"github.com/aman-churiwal/api-gateway/internal/healthcheck"
 	"github.com/aman-churiwal/api-gateway/internal/loadbalancer"
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/aman-churiwal/api-gateway/internal/messages"
 	"github.com/gin-gonic/gin"
 )
 
 type Proxy struct {
+	mu             sync.RWMutex // Guards targets and proxies, which a target source replaces
 	targets        []string
 	proxies        map[string]*httputil.ReverseProxy
+	targetSource   TargetSource
+	transport      http.RoundTripper
 	circuitBreaker *circuitbreaker.CircuitBreaker
 	loadBalancer   loadbalancer.Strategy
 	healthChecker  *healthcheck.Checker
+	connections    *connectionTracker
+	deadLetter     DeadLetterConfig
+	upstreamLimit  *upstreamLimiter
+	canary         *canary
+	blueGreen      *blueGreen
+	outliers       *outlierDetector
+	groups         map[string][]string // Target groups requests are sent to by name
+	pinned         []string            // Canary and group targets, which a target source doesn't replace
 }
 
 type Config struct {
@@ -27,6 +44,22 @@ type Config struct {
 	LoadBalancerStrategy string
 	CircuitBreaker       circuitbreaker.Config
 	HealthCheck          healthcheck.Config
+	LongLived            LongLivedConfig
+	DeadLetter           DeadLetterConfig
+	UpstreamLimit        UpstreamLimitConfig
+	Transport            TransportConfig
+	TargetSource         TargetSource // Replaces Targets at runtime; Targets may then start empty
+	Canary               CanaryConfig
+	BlueGreen            BlueGreenConfig     // Targets must list both sets
+	TargetGroups         map[string][]string // Only get requests routed to them by name; see RouteToGroup
+	Outliers             OutlierConfig
+}
+
+// Supplies a proxy's targets at runtime, e.g. from service discovery
+type TargetSource interface {
+	// Starts calling update with the full target list whenever it changes
+	Watch(update func(targets []string))
+	Stop()
 }
 
 func New(targetURL string) (*Proxy, error) {
@@ -43,7 +76,7 @@ func New(targetURL string) (*Proxy, error) {
 
 // Creates a new Proxy with custom circuit breaker config
 func NewWithConfig(cfg Config) (*Proxy, error) {
-	if len(cfg.Targets) == 0 {
+	if len(cfg.Targets) == 0 && cfg.TargetSource == nil {
 		return nil, errors.New("at least one target is required")
 	}
 
@@ -56,21 +89,40 @@ func NewWithConfig(cfg Config) (*Proxy, error) {
 		return nil, err
 	}
 
-	// Create reverse proxies for each target
+	// Create reverse proxies for each target, sharing one connection pool
+	transport := newTransport(cfg.Transport)
 	proxies := make(map[string]*httputil.ReverseProxy)
-	for _, targetURL := range cfg.Targets {
-		target, err := url.Parse(targetURL)
+	pinned := slices.Clone(cfg.Canary.Targets)
+	for _, targets := range cfg.TargetGroups {
+		pinned = append(pinned, targets...)
+	}
+	for _, targetURL := range slices.Concat(cfg.Targets, pinned) {
+		reverseProxy, err := newReverseProxy(targetURL, transport)
 		if err != nil {
 			return nil, err
 		}
+		proxies[targetURL] = reverseProxy
+	}
 
-		proxies[targetURL] = httputil.NewSingleHostReverseProxy(target)
+	// Setup dead-letter capture defaults
+	if cfg.DeadLetter.Enabled {
+		if cfg.DeadLetter.Sink == nil {
+			return nil, errors.New("dead letter capture requires a sink")
+		}
+		if cfg.DeadLetter.MaxBodyBytes <= 0 {
+			cfg.DeadLetter.MaxBodyBytes = 64 * 1024
+		}
+		if len(cfg.DeadLetter.Headers) == 0 {
+			cfg.DeadLetter.Headers = []string{"Content-Type", "User-Agent"}
+		}
 	}
 
 	// Setup health check configurations
 	if cfg.HealthCheck.Targets == nil {
 		cfg.HealthCheck.Targets = cfg.Targets
 	}
+	cfg.HealthCheck.Targets = slices.Concat(cfg.HealthCheck.Targets, pinned)
+	cfg.HealthCheck.Transport = transport
 
 	// Create health checker
 	hc := healthcheck.NewChecker(&cfg.HealthCheck)
@@ -82,51 +134,215 @@ func NewWithConfig(cfg Config) (*Proxy, error) {
 		circuitBreaker: cb,
 		loadBalancer:   lb,
 		healthChecker:  hc,
+		connections:    newConnectionTracker(cfg.LongLived),
+		deadLetter:     cfg.DeadLetter,
+		upstreamLimit:  newUpstreamLimiter(cfg.UpstreamLimit),
+		canary:         newCanary(cfg.Canary),
+		blueGreen:      newBlueGreen(cfg.BlueGreen),
+		outliers:       newOutlierDetector(cfg.Outliers),
+		groups:         cfg.TargetGroups,
+		pinned:         pinned,
+		targetSource:   cfg.TargetSource,
+		transport:      transport,
 	}
 
-	log.Printf("Proxy initialized with %d targets, strategy: %s", len(cfg.Targets), lb.Name())
+	if p.targetSource != nil {
+		p.targetSource.Watch(p.SetTargets)
+	}
+
+	slog.Info("Proxy initialized", "targets", len(cfg.Targets), "strategy", lb.Name())
 
 	return p, nil
 }
 
+func newReverseProxy(targetURL string, transport http.RoundTripper) (*httputil.ReverseProxy, error) {
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	reverseProxy := httputil.NewSingleHostReverseProxy(target)
+	reverseProxy.Transport = transport
+	reverseProxy.ErrorHandler = handleUpstreamError
+	return reverseProxy, nil
+}
+
+// Replaces the stable targets, keeping the health state of those that remain.
+// Canary and group targets are kept as they are.
+func (p *Proxy) SetTargets(targets []string) {
+	targets = slices.Concat(targets, p.pinned)
+	proxies := make(map[string]*httputil.ReverseProxy, len(targets))
+	valid := make([]string, 0, len(targets))
+
+	p.mu.RLock()
+	for _, targetURL := range targets {
+		if reverseProxy, exists := p.proxies[targetURL]; exists {
+			proxies[targetURL] = reverseProxy
+			valid = append(valid, targetURL)
+			continue
+		}
+		reverseProxy, err := newReverseProxy(targetURL, p.transport)
+		if err != nil {
+			slog.Warn("Ignoring invalid target", "backend_target", targetURL, "error", err)
+			continue
+		}
+		proxies[targetURL] = reverseProxy
+		valid = append(valid, targetURL)
+	}
+	p.mu.RUnlock()
+
+	// Proxies go in first so a newly healthy target always has one
+	p.mu.Lock()
+	p.targets = valid
+	p.proxies = proxies
+	p.mu.Unlock()
+
+	p.healthChecker.SetTargets(valid)
+	slog.Info("Proxy targets updated", "targets", len(valid))
+}
+
+// Returns the healthy targets a request may go to: those of the target group
+// it was routed to, or else the service's own minus the inactive blue-green
+// set. Ejected outliers are left out either way.
+func (p *Proxy) routableTargets(group string) []string {
+	healthy := p.outliers.filter(p.healthChecker.GetHealthyTargets())
+	if group != "" {
+		members := p.groups[group]
+		return slices.DeleteFunc(healthy, func(target string) bool {
+			return !slices.Contains(members, target)
+		})
+	}
+
+	for _, members := range p.groups {
+		healthy = slices.DeleteFunc(healthy, func(target string) bool {
+			return slices.Contains(members, target)
+		})
+	}
+	return p.blueGreen.live(healthy)
+}
+
+// Sends the request to the named target group instead of the service's own
+// targets. Unknown groups have no targets, so the request fails with 503.
+func RouteToGroup(c *gin.Context, group string) {
+	c.Set(targetGroupKey, group)
+}
+
+const targetGroupKey = "target_group"
+
+// Returns the reverse proxy for a target
+func (p *Proxy) reverseProxy(target string) (*httputil.ReverseProxy, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	reverseProxy, exists := p.proxies[target]
+	return reverseProxy, exists
+}
+
 // Forwards the request to the backend
 func (p *Proxy) Handle(c *gin.Context) {
+	if !p.deadLetter.Enabled {
+		p.forward(c)
+		return
+	}
+
+	body := captureBody(c.Request, p.deadLetter.MaxBodyBytes)
+	if reason := p.forward(c); reason != "" {
+		p.captureDeadLetter(c, body, reason)
+	}
+}
+
+// Proxies the request and returns the failure reason, if any
+func (p *Proxy) forward(c *gin.Context) string {
+	// Hold off while the backend has asked us to back off
+	if remaining, ok := p.upstreamLimit.wait(c.Request.Context()); !ok {
+		retryAfter := int(remaining.Round(time.Second).Seconds())
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		setError(c, "upstream_throttled", fmt.Sprintf("backend asked to back off for %ds", retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": messages.Localize(c, messages.UpstreamThrottled, map[string]interface{}{
+				"RetryAfter": retryAfter,
+			}),
+			"retry_after": retryAfter,
+		})
+		return "upstream_throttled"
+	}
+
+	logger := logging.FromContext(c.Request.Context())
+
 	// Get healthy targets only
-	healthyTargets := p.healthChecker.GetHealthyTargets()
+	healthyTargets := p.routableTargets(c.GetString(targetGroupKey))
 
 	if len(healthyTargets) == 0 {
-		log.Println("No healthy targets available")
+		logger.Warn("No healthy targets available")
+		setError(c, "no_healthy_targets", "no healthy backend targets")
 		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "No healthy backend servers available",
+			"error": messages.Localize(c, messages.NoHealthyBackends, nil),
 		})
-		return
+		return "no_healthy_targets"
 	}
 
-	// Select target using load balancer
-	selectedTarget := p.loadBalancer.Next(healthyTargets)
+	// Pick the stable or canary group, then a target in it. Requests routed
+	// to a target group bypass the canary split.
+	groupTargets, toCanary := healthyTargets, false
+	if c.GetString(targetGroupKey) == "" {
+		groupTargets, toCanary = p.canary.choose(healthyTargets)
+	}
+	selectedTarget := p.loadBalancer.Next(groupTargets)
 
 	if selectedTarget == "" {
-		log.Println("Load balancer returned empty target")
+		logger.Error("Load balancer returned empty target")
+		setError(c, "no_target_selected", "load balancer returned no target")
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"error": "Failed to select backend server",
 		})
-		return
+		return "no_target_selected"
 	}
 
+	c.Set("backend_target", selectedTarget)
+
 	// Get the proxy for this target
-	targetProxy, exists := p.proxies[selectedTarget]
+	targetProxy, exists := p.reverseProxy(selectedTarget)
 	if !exists {
-		log.Printf("Proxy not found for target: %s", selectedTarget)
+		logger.Error("Proxy not found for target", "backend_target", selectedTarget)
+		setError(c, "proxy_not_found", "no proxy for target "+selectedTarget)
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"error": "Internal server error",
 		})
-		return
+		return "proxy_not_found"
+	}
+
+	// Long-lived connections are capped per consumer and accounted separately
+	kind := p.connections.classify(c.Request)
+	longLived := kind != ""
+	if longLived {
+		consumer := consumerKey(c)
+		if !p.connections.acquireLongLived(consumer, kind) {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": "Too many long-lived connections for this API key",
+			})
+			return ""
+		}
+		defer p.connections.releaseLongLived(consumer, kind)
+
+		// Long-lived connections must outlive the server write timeout
+		http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
 	}
 
+	p.connections.start(selectedTarget, longLived)
+	defer p.connections.done(selectedTarget, longLived)
+
 	// Track connections for least-connections strategy
 	if lc, ok := p.loadBalancer.(*loadbalancer.LeastConnections); ok {
-		lc.Increment(selectedTarget)
-		defer lc.Decrement(selectedTarget)
+		if longLived {
+			lc.IncrementLongLived(selectedTarget)
+			defer lc.DecrementLongLived(selectedTarget)
+		} else {
+			lc.Increment(selectedTarget)
+			defer lc.Decrement(selectedTarget)
+		}
 	}
 
 	// Parse target URL
@@ -161,8 +377,20 @@ func (p *Proxy) Handle(c *gin.Context) {
 		c.Writer = recorder
 
 		// Forward the request
+		sent := time.Now()
 		targetProxy.ServeHTTP(c.Writer, req)
 
+		// Honor backoff the backend announces in its rate limit headers
+		p.upstreamLimit.observe(recorder.statusCode, recorder.Header())
+		p.canary.record(toCanary, recorder.statusCode >= 500)
+		if !longLived {
+			p.outliers.record(selectedTarget, recorder.statusCode, time.Since(sent))
+		}
+
+		if recorder.errorType != "" {
+			setError(c, recorder.errorType, recorder.errorMessage)
+		}
+
 		// Check if backend returned 5xx error
 		if recorder.statusCode >= 500 {
 			return errors.New("backend error")
@@ -173,15 +401,19 @@ func (p *Proxy) Handle(c *gin.Context) {
 
 	if err != nil {
 		if err == circuitbreaker.ErrCircuitOpen {
-			log.Printf("Circuit breaker open for %s", selectedTarget)
+			logger.Warn("Circuit breaker open", "backend_target", selectedTarget)
+			setError(c, "circuit_open", "circuit breaker open")
 			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"error": "Service temporarily unavailable",
+				"error": messages.Localize(c, messages.ServiceUnavailable, nil),
 			})
-			return
+			return "circuit_open"
 		}
 
 		// Other errors are already handled by proxy
+		return "backend_error"
 	}
+
+	return ""
 }
 
 // Returns the current circuit breaker state
@@ -219,17 +451,46 @@ func (p *Proxy) OverallHealth() healthcheck.HealthStatus {
 	return p.healthChecker.OverallHealth()
 }
 
-// Stops the health checker
+// Returns normal and long-lived connection counts
+func (p *Proxy) ConnectionStats() ConnectionStats {
+	return p.connections.stats()
+}
+
+// Returns whether traffic is paused by backend rate limit headers
+func (p *Proxy) UpstreamLimitStats() UpstreamLimitStats {
+	return p.upstreamLimit.stats()
+}
+
+// Stops the health checker and target source
 func (p *Proxy) Stop() {
+	if p.targetSource != nil {
+		p.targetSource.Stop()
+	}
 	if p.healthChecker != nil {
 		p.healthChecker.Stop()
 	}
+	closeIdleConnections(p.transport)
+}
+
+// Identifies the consumer of a request for per-key accounting
+func consumerKey(c *gin.Context) string {
+	if apiKeyID, exists := c.Get("api_key_id"); exists {
+		return fmt.Sprintf("%v", apiKeyID)
+	}
+	return c.ClientIP()
 }
 
-// Captures the response status code
+// Captures the response status code and any transport error
 type responseRecorder struct {
 	gin.ResponseWriter
-	statusCode int
+	statusCode   int
+	errorType    string
+	errorMessage string
+}
+
+func (r *responseRecorder) recordUpstreamError(errorType, message string) {
+	r.errorType = errorType
+	r.errorMessage = message
 }
 
 func (r *responseRecorder) WriteHeader(statusCode int) {
@@ -240,3 +501,8 @@ func (r *responseRecorder) WriteHeader(statusCode int) {
 func (r *responseRecorder) Write(data []byte) (int, error) {
 	return r.ResponseWriter.Write(data)
 }
+
+// Lets http.ResponseController reach the underlying writer
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
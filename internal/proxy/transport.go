@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Holds settings for connections to a service's backends
+type TransportConfig struct {
+	Protocol            string        // "auto" (default), "http1", "http2" or "h2c"
+	MaxIdleConnsPerHost int           // Default: 32
+	MaxConnsPerHost     int           // Default: 0 (unlimited)
+	IdleConnTimeout     time.Duration // Default: 90s
+	PingInterval        time.Duration // HTTP/2 health pings on idle connections. Default: 0 (off)
+	TLS                 *tls.Config   // Default: system roots and the target host as server name
+
+	DialTimeout           time.Duration // Default: 30s
+	TLSHandshakeTimeout   time.Duration // Default: 10s
+	ResponseHeaderTimeout time.Duration // Default: 0 (none)
+	DisableKeepAlives     bool
+}
+
+// Builds the round tripper for a service. Each service gets its own pool;
+// Go's default transport keeps only 2 idle connections per host, which
+// throttles busy backends. HTTP/2 is negotiated with TLS backends that offer
+// it unless the protocol says otherwise.
+func newTransport(cfg TransportConfig) http.RoundTripper {
+	if cfg.MaxIdleConnsPerHost <= 0 {
+		cfg.MaxIdleConnsPerHost = 32
+	}
+	if cfg.IdleConnTimeout <= 0 {
+		cfg.IdleConnTimeout = 90 * time.Second
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 30 * time.Second
+	}
+	if cfg.TLSHandshakeTimeout <= 0 {
+		cfg.TLSHandshakeTimeout = 10 * time.Second
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = cfg.MaxConnsPerHost
+	transport.IdleConnTimeout = cfg.IdleConnTimeout
+	transport.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
+	transport.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+	transport.DisableKeepAlives = cfg.DisableKeepAlives
+	if cfg.TLS != nil {
+		transport.TLSClientConfig = cfg.TLS
+	}
+
+	// HTTP/2 multiplexes requests over one connection per backend, so
+	// MaxConnsPerHost rarely applies to it
+	protocols := new(http.Protocols)
+	switch cfg.Protocol {
+	case "http1":
+		protocols.SetHTTP1(true)
+		transport.ForceAttemptHTTP2 = false
+	case "http2":
+		protocols.SetHTTP2(true)
+	case "h2c":
+		// Prior knowledge: plaintext backends are spoken to in HTTP/2 directly
+		protocols.SetUnencryptedHTTP2(true)
+	default:
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+	}
+	transport.Protocols = protocols
+
+	if cfg.PingInterval > 0 {
+		transport.HTTP2 = &http.HTTP2Config{
+			SendPingTimeout: cfg.PingInterval,
+			PingTimeout:     15 * time.Second,
+		}
+	}
+
+	return transport
+}
+
+// Drops pooled connections of a transport the proxy owns
+func closeIdleConnections(transport http.RoundTripper) {
+	if transport, ok := transport.(*http.Transport); ok && transport != http.DefaultTransport {
+		transport.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,197 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Holds settings for honoring backend rate limit headers
+type UpstreamLimitConfig struct {
+	Enabled    bool
+	Queue      bool          // Hold requests while throttled instead of rejecting them
+	MaxWait    time.Duration // Longest a queued request waits. Default: 5s
+	MaxBackoff time.Duration // Caps backoffs announced by the backend. Default: 5m
+}
+
+// Snapshot of upstream throttling for a proxy
+type UpstreamLimitStats struct {
+	Throttled      bool       `json:"throttled"`
+	ThrottledUntil *time.Time `json:"throttled_until,omitempty"`
+	Rejected       int64      `json:"rejected"`
+	Queued         int64      `json:"queued"`
+}
+
+// Pauses traffic to a service after its backend signals that quota is exhausted
+type upstreamLimiter struct {
+	cfg      UpstreamLimitConfig
+	mu       sync.Mutex
+	until    time.Time
+	rejected atomic.Int64
+	queued   atomic.Int64
+}
+
+func newUpstreamLimiter(cfg UpstreamLimitConfig) *upstreamLimiter {
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = 5 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 5 * time.Minute
+	}
+	return &upstreamLimiter{cfg: cfg}
+}
+
+// Waits out an active throttle when queueing allows it. Returns the remaining
+// backoff when the request should be rejected instead.
+func (l *upstreamLimiter) wait(ctx context.Context) (time.Duration, bool) {
+	if !l.cfg.Enabled {
+		return 0, true
+	}
+
+	l.mu.Lock()
+	remaining := time.Until(l.until)
+	l.mu.Unlock()
+
+	if remaining <= 0 {
+		return 0, true
+	}
+
+	if !l.cfg.Queue || remaining > l.cfg.MaxWait {
+		l.rejected.Add(1)
+		return remaining, false
+	}
+
+	l.queued.Add(1)
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return 0, true
+	case <-ctx.Done():
+		return remaining, false
+	}
+}
+
+// Records the backoff a backend response asks for, if any
+func (l *upstreamLimiter) observe(status int, header http.Header) {
+	if !l.cfg.Enabled {
+		return
+	}
+
+	delay := upstreamBackoff(status, header, time.Now())
+	if delay <= 0 {
+		return
+	}
+	if delay > l.cfg.MaxBackoff {
+		delay = l.cfg.MaxBackoff
+	}
+
+	until := time.Now().Add(delay)
+
+	l.mu.Lock()
+	if until.After(l.until) {
+		l.until = until
+	}
+	l.mu.Unlock()
+}
+
+func (l *upstreamLimiter) stats() UpstreamLimitStats {
+	l.mu.Lock()
+	until := l.until
+	l.mu.Unlock()
+
+	stats := UpstreamLimitStats{
+		Rejected: l.rejected.Load(),
+		Queued:   l.queued.Load(),
+	}
+	if time.Now().Before(until) {
+		stats.Throttled = true
+		stats.ThrottledUntil = &until
+	}
+	return stats
+}
+
+// Returns how long to hold off based on Retry-After and RateLimit headers
+func upstreamBackoff(status int, header http.Header, now time.Time) time.Duration {
+	// Retry-After is only meaningful on throttling and unavailability responses
+	if status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable {
+		if delay, ok := parseRetryAfter(header.Get("Retry-After"), now); ok {
+			return delay
+		}
+	}
+
+	remaining, reset, ok := parseRateLimit(header, now)
+	if ok && remaining <= 0 {
+		return reset
+	}
+
+	// A 429 without usable headers still warrants a short pause
+	if status == http.StatusTooManyRequests {
+		return time.Second
+	}
+
+	return 0
+}
+
+// Parses Retry-After as delay-seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, seconds >= 0
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return at.Sub(now), true
+	}
+
+	return 0, false
+}
+
+// Reads remaining quota and reset time from the IETF RateLimit header, the
+// RateLimit-* fields or the common X-RateLimit-* fields
+func parseRateLimit(header http.Header, now time.Time) (int, time.Duration, bool) {
+	if combined := header.Get("RateLimit"); combined != "" {
+		params := make(map[string]string)
+		for _, part := range strings.FieldsFunc(combined, func(r rune) bool { return r == ',' || r == ';' }) {
+			key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+			if found {
+				params[strings.ToLower(key)] = strings.Trim(value, `"`)
+			}
+		}
+
+		remaining, errRemaining := strconv.Atoi(params["remaining"])
+		reset, errReset := strconv.Atoi(params["reset"])
+		if errRemaining == nil && errReset == nil {
+			return remaining, time.Duration(reset) * time.Second, true
+		}
+	}
+
+	for _, prefix := range []string{"RateLimit-", "X-RateLimit-"} {
+		remaining, err := strconv.Atoi(header.Get(prefix + "Remaining"))
+		if err != nil {
+			continue
+		}
+
+		reset, err := strconv.ParseInt(header.Get(prefix+"Reset"), 10, 64)
+		if err != nil {
+			return remaining, time.Second, true
+		}
+
+		// Some APIs send an epoch timestamp rather than seconds until reset
+		if reset > 1_000_000_000 {
+			return remaining, time.Unix(reset, 0).Sub(now), true
+		}
+		return remaining, time.Duration(reset) * time.Second, true
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,210 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+)
+
+// Fixed window limiter on the embedded store
+type EmbeddedFixedWindow struct {
+	kv     *storage.EmbeddedKV
+	limit  int
+	window time.Duration
+}
+
+func NewEmbeddedFixedWindow(kv *storage.EmbeddedKV, limit int, window time.Duration) *EmbeddedFixedWindow {
+	return &EmbeddedFixedWindow{kv: kv, limit: limit, window: window}
+}
+
+func (f *EmbeddedFixedWindow) key(key string) string {
+	currentWindow := time.Now().Unix() / int64(f.window.Seconds())
+	return fmt.Sprintf("ratelimit:fixed:%s:%d", key, currentWindow)
+}
+
+func (f *EmbeddedFixedWindow) Allow(ctx context.Context, key string) (bool, error) {
+	var count int
+	err := f.kv.Update(ctx, f.key(key), func(current []byte) ([]byte, time.Duration, error) {
+		count, _ = strconv.Atoi(string(current))
+		count++
+		return []byte(strconv.Itoa(count)), f.window, nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return count <= f.limit, nil
+}
+
+func (f *EmbeddedFixedWindow) Remaining(ctx context.Context, key string) (int, error) {
+	value, err := f.kv.Get(ctx, f.key(key))
+	if err != nil {
+		return 0, err
+	}
+
+	count, _ := strconv.Atoi(string(value))
+	return max(f.limit-count, 0), nil
+}
+
+func (f *EmbeddedFixedWindow) Limit() int {
+	return f.limit
+}
+
+func (f *EmbeddedFixedWindow) Window() time.Duration {
+	return f.window
+}
+
+func (f *EmbeddedFixedWindow) Reset(ctx context.Context, key string) (time.Time, error) {
+	currentWindow := time.Now().Unix() / int64(f.window.Seconds())
+	nextWindow := (currentWindow + 1) * int64(f.window.Seconds())
+	return time.Unix(nextWindow, 0), nil
+}
+
+// Sliding window limiter on the embedded store, keeping request timestamps per key
+type EmbeddedSlidingWindow struct {
+	kv     *storage.EmbeddedKV
+	limit  int
+	window time.Duration
+}
+
+func NewEmbeddedSlidingWindow(kv *storage.EmbeddedKV, limit int, window time.Duration) *EmbeddedSlidingWindow {
+	return &EmbeddedSlidingWindow{kv: kv, limit: limit, window: window}
+}
+
+// Returns the timestamps still inside the window
+func (s *EmbeddedSlidingWindow) inWindow(data []byte, now time.Time) []int64 {
+	var timestamps []int64
+	json.Unmarshal(data, &timestamps)
+
+	windowStart := now.Add(-s.window).UnixNano()
+	kept := timestamps[:0]
+	for _, ts := range timestamps {
+		if ts > windowStart {
+			kept = append(kept, ts)
+		}
+	}
+	return kept
+}
+
+func (s *EmbeddedSlidingWindow) Allow(ctx context.Context, key string) (bool, error) {
+	allowed := false
+	err := s.kv.Update(ctx, "ratelimit:sliding:"+key, func(current []byte) ([]byte, time.Duration, error) {
+		now := time.Now()
+		timestamps := s.inWindow(current, now)
+
+		if len(timestamps) < s.limit {
+			timestamps = append(timestamps, now.UnixNano())
+			allowed = true
+		}
+
+		data, err := json.Marshal(timestamps)
+		return data, s.window, err
+	})
+
+	return allowed, err
+}
+
+func (s *EmbeddedSlidingWindow) Remaining(ctx context.Context, key string) (int, error) {
+	data, err := s.kv.Get(ctx, "ratelimit:sliding:"+key)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(s.limit-len(s.inWindow(data, time.Now())), 0), nil
+}
+
+func (s *EmbeddedSlidingWindow) Limit() int {
+	return s.limit
+}
+
+func (s *EmbeddedSlidingWindow) Window() time.Duration {
+	return s.window
+}
+
+func (s *EmbeddedSlidingWindow) Reset(ctx context.Context, key string) (time.Time, error) {
+	data, err := s.kv.Get(ctx, "ratelimit:sliding:"+key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	timestamps := s.inWindow(data, time.Now())
+	if len(timestamps) == 0 {
+		return time.Now(), nil
+	}
+
+	// Reset time is when the oldest entry leaves the window
+	return time.Unix(0, timestamps[0]).Add(s.window), nil
+}
+
+// Token bucket limiter on the embedded store
+type EmbeddedTokenBucket struct {
+	kv         *storage.EmbeddedKV
+	capacity   int
+	refillRate int // Tokens per second
+}
+
+func NewEmbeddedTokenBucket(kv *storage.EmbeddedKV, capacity int, refillRate int) *EmbeddedTokenBucket {
+	return &EmbeddedTokenBucket{kv: kv, capacity: capacity, refillRate: refillRate}
+}
+
+// Returns the bucket state refilled up to now
+func (t *EmbeddedTokenBucket) refilled(data []byte, now time.Time) bucketState {
+	state := bucketState{Tokens: float64(t.capacity), LastRefill: now}
+	if data != nil {
+		json.Unmarshal(data, &state)
+	}
+
+	elapsed := now.Sub(state.LastRefill)
+	state.Tokens = math.Min(state.Tokens+elapsed.Seconds()*float64(t.refillRate), float64(t.capacity))
+	state.LastRefill = now
+	return state
+}
+
+func (t *EmbeddedTokenBucket) Allow(ctx context.Context, key string) (bool, error) {
+	allowed := false
+	err := t.kv.Update(ctx, "ratelimit:bucket:"+key, func(current []byte) ([]byte, time.Duration, error) {
+		state := t.refilled(current, time.Now())
+		if state.Tokens >= 1 {
+			state.Tokens -= 1
+			allowed = true
+		}
+
+		data, err := json.Marshal(state)
+		return data, time.Hour, err
+	})
+
+	return allowed, err
+}
+
+func (t *EmbeddedTokenBucket) Remaining(ctx context.Context, key string) (int, error) {
+	data, err := t.kv.Get(ctx, "ratelimit:bucket:"+key)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(t.refilled(data, time.Now()).Tokens), nil
+}
+
+func (t *EmbeddedTokenBucket) Limit() int {
+	return t.capacity
+}
+
+func (t *EmbeddedTokenBucket) Window() time.Duration {
+	return time.Duration(t.capacity/t.refillRate) * time.Second
+}
+
+func (t *EmbeddedTokenBucket) Reset(ctx context.Context, key string) (time.Time, error) {
+	data, err := t.kv.Get(ctx, "ratelimit:bucket:"+key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	state := t.refilled(data, time.Now())
+	secondsToFull := (float64(t.capacity) - state.Tokens) / float64(t.refillRate)
+	return time.Now().Add(time.Duration(secondsToFull * float64(time.Second))), nil
+}
@@ -6,19 +6,52 @@ import (
 	"github.com/aman-churiwal/api-gateway/internal/storage"
 )
 
+// Creates limiters on the configured storage backend
+type Factory func(algorithm string, limit int, window time.Duration) Limiter
+
 func NewLimiter(redis *storage.RedisClient, algorithm string, limit int, window time.Duration) Limiter {
 	switch algorithm {
 	case "sliding_window":
 		return NewSlidingWindowLimiter(redis, limit, window)
 	case "token_bucket":
-		refillRate := limit / int(window.Seconds())
-		if refillRate == 0 {
-			refillRate = 1
-		}
-		return NewTokenBucket(redis, limit, refillRate)
+		return NewTokenBucket(redis, limit, refillRate(limit, window))
 	case "fixed_window":
 		return NewFixedWindow(redis, limit, window)
 	default:
 		return NewFixedWindow(redis, limit, window)
 	}
 }
+
+// Creates limiters on the embedded single-node store
+func NewEmbeddedLimiter(kv *storage.EmbeddedKV, algorithm string, limit int, window time.Duration) Limiter {
+	switch algorithm {
+	case "sliding_window":
+		return NewEmbeddedSlidingWindow(kv, limit, window)
+	case "token_bucket":
+		return NewEmbeddedTokenBucket(kv, limit, refillRate(limit, window))
+	default:
+		return NewEmbeddedFixedWindow(kv, limit, window)
+	}
+}
+
+// Returns a factory for Redis-backed limiters
+func RedisFactory(redis *storage.RedisClient) Factory {
+	return func(algorithm string, limit int, window time.Duration) Limiter {
+		return NewLimiter(redis, algorithm, limit, window)
+	}
+}
+
+// Returns a factory for limiters on the embedded store
+func EmbeddedFactory(kv *storage.EmbeddedKV) Factory {
+	return func(algorithm string, limit int, window time.Duration) Limiter {
+		return NewEmbeddedLimiter(kv, algorithm, limit, window)
+	}
+}
+
+func refillRate(limit int, window time.Duration) int {
+	rate := limit / int(window.Seconds())
+	if rate == 0 {
+		rate = 1
+	}
+	return rate
+}
@@ -0,0 +1,209 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Bounds that keep a simulation cheap enough to run inline
+const (
+	MaxSimulatedDuration = 24 * time.Hour
+	MaxSimulatedRequests = 1_000_000
+)
+
+// Algorithms a simulation runs when none is requested
+var Algorithms = []string{"fixed_window", "sliding_window", "token_bucket"}
+
+// A stretch of synthetic traffic. Rate ramps linearly from RPS to RampToRPS when set.
+type TrafficSegment struct {
+	DurationSeconds int     `json:"duration_seconds"`
+	RPS             float64 `json:"rps"`
+	RampToRPS       float64 `json:"ramp_to_rps,omitempty"`
+}
+
+// Outcome of one window of a simulation
+type WindowResult struct {
+	StartSeconds int `json:"start_seconds"`
+	Offered      int `json:"offered"`
+	Allowed      int `json:"allowed"`
+	Denied       int `json:"denied"`
+}
+
+// Outcome of simulating one algorithm
+type SimulationResult struct {
+	Algorithm string         `json:"algorithm"`
+	Allowed   int            `json:"allowed"`
+	Denied    int            `json:"denied"`
+	AllowRate float64        `json:"allow_rate"` // Share of requests allowed, 0-1
+	Windows   []WindowResult `json:"windows"`
+}
+
+// Replays synthetic traffic for a single consumer against each algorithm with
+// the given limit and window, reproducing the live limiters' behavior
+func Simulate(algorithms []string, limit int, window time.Duration, traffic []TrafficSegment) ([]SimulationResult, error) {
+	if limit <= 0 {
+		return nil, errors.New("limit must be positive")
+	}
+	if window < time.Second {
+		return nil, errors.New("window must be at least one second")
+	}
+
+	arrivals, err := arrivalTimes(traffic)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(algorithms) == 0 {
+		algorithms = Algorithms
+	}
+
+	results := make([]SimulationResult, 0, len(algorithms))
+	for _, algorithm := range algorithms {
+		var allow func(now time.Duration) bool
+		switch algorithm {
+		case "fixed_window":
+			allow = simulatedFixedWindow(limit, window)
+		case "sliding_window":
+			allow = simulatedSlidingWindow(limit, window)
+		case "token_bucket":
+			allow = simulatedTokenBucket(limit, refillRate(limit, window))
+		default:
+			return nil, fmt.Errorf("unknown algorithm: %s", algorithm)
+		}
+
+		result := SimulationResult{Algorithm: algorithm, Windows: make([]WindowResult, 0)}
+		for _, at := range arrivals {
+			index := int(at / window)
+			for len(result.Windows) <= index {
+				result.Windows = append(result.Windows, WindowResult{
+					StartSeconds: len(result.Windows) * int(window.Seconds()),
+				})
+			}
+
+			w := &result.Windows[index]
+			w.Offered++
+			if allow(at) {
+				w.Allowed++
+				result.Allowed++
+			} else {
+				w.Denied++
+				result.Denied++
+			}
+		}
+		if len(arrivals) > 0 {
+			result.AllowRate = float64(result.Allowed) / float64(len(arrivals))
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// Spreads each second's requests evenly across the second, carrying fractional
+// requests over so that e.g. 0.5 RPS yields one request every two seconds
+func arrivalTimes(traffic []TrafficSegment) ([]time.Duration, error) {
+	if len(traffic) == 0 {
+		return nil, errors.New("traffic profile is empty")
+	}
+
+	total := 0
+	for _, segment := range traffic {
+		if segment.DurationSeconds <= 0 || segment.RPS < 0 || segment.RampToRPS < 0 {
+			return nil, errors.New("traffic segments need a positive duration and non-negative rates")
+		}
+		total += segment.DurationSeconds
+	}
+	if time.Duration(total)*time.Second > MaxSimulatedDuration {
+		return nil, fmt.Errorf("traffic profile may not exceed %s", MaxSimulatedDuration)
+	}
+
+	var arrivals []time.Duration
+	var carry float64
+	second := 0
+
+	for _, segment := range traffic {
+		end := segment.RPS
+		if segment.RampToRPS > 0 {
+			end = segment.RampToRPS
+		}
+
+		for i := 0; i < segment.DurationSeconds; i++ {
+			rate := segment.RPS
+			if segment.DurationSeconds > 1 {
+				rate += (end - segment.RPS) * float64(i) / float64(segment.DurationSeconds-1)
+			}
+
+			carry += rate
+			n := int(math.Floor(carry))
+			carry -= float64(n)
+
+			if len(arrivals)+n > MaxSimulatedRequests {
+				return nil, fmt.Errorf("traffic profile may not exceed %d requests", MaxSimulatedRequests)
+			}
+
+			start := time.Duration(second) * time.Second
+			for j := 0; j < n; j++ {
+				arrivals = append(arrivals, start+time.Duration(j)*time.Second/time.Duration(n))
+			}
+			second++
+		}
+	}
+
+	return arrivals, nil
+}
+
+// Counts every request, allowed or not, against its clock-aligned window
+func simulatedFixedWindow(limit int, window time.Duration) func(time.Duration) bool {
+	current := int64(-1)
+	count := 0
+
+	return func(now time.Duration) bool {
+		index := int64(now / window)
+		if index != current {
+			current = index
+			count = 0
+		}
+		count++
+		return count <= limit
+	}
+}
+
+// Allows a request while fewer than limit requests were allowed in the last window
+func simulatedSlidingWindow(limit int, window time.Duration) func(time.Duration) bool {
+	var allowed []time.Duration
+
+	return func(now time.Duration) bool {
+		windowStart := now - window
+		drop := 0
+		for drop < len(allowed) && allowed[drop] <= windowStart {
+			drop++
+		}
+		allowed = allowed[drop:]
+
+		if len(allowed) < limit {
+			allowed = append(allowed, now)
+			return true
+		}
+		return false
+	}
+}
+
+// Starts full and refills rate tokens per second up to capacity
+func simulatedTokenBucket(capacity, rate int) func(time.Duration) bool {
+	tokens := float64(capacity)
+	var lastRefill time.Duration
+
+	return func(now time.Duration) bool {
+		tokens = math.Min(tokens+(now-lastRefill).Seconds()*float64(rate), float64(capacity))
+		lastRefill = now
+
+		if tokens >= 1 {
+			tokens--
+			return true
+		}
+		return false
+	}
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/aman-churiwal/api-gateway/internal/models"
@@ -10,6 +12,33 @@ import (
 	"gorm.io/gorm"
 )
 
+// Filters, sorting and pagination for listing API keys
+type APIKeyFilter struct {
+	Tier           string
+	IsActive       *bool
+	Tag            string
+	CreatedBy      string
+	Owner          string
+	Team           string
+	Prefix         string
+	LastUsedBefore *time.Time
+	LastUsedAfter  *time.Time
+	OrgID          *uuid.UUID // Only keys of this organization
+	DeveloperID    *uuid.UUID // Only keys this developer created through the portal
+	SortBy         string     // "created_at", "last_used_at", "name" or "tier"
+	SortDesc       bool
+	Limit          int
+	Offset         int
+}
+
+// Columns API keys can be sorted by
+var apiKeySortColumns = map[string]bool{
+	"created_at":   true,
+	"last_used_at": true,
+	"name":         true,
+	"tier":         true,
+}
+
 type APIKeyRepository struct {
 	db *storage.Postgres
 }
@@ -57,6 +86,87 @@ func (r *APIKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
 	return keys, err
 }
 
+// Retrieves all active keys
+func (r *APIKeyRepository) ListActive(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.DB.WithContext(ctx).
+		Where("is_active = ?", true).
+		Order("created_at ASC").
+		Find(&keys).Error
+
+	return keys, err
+}
+
+// Retrieves a page of keys matching the filter along with the total match count
+func (r *APIKeyRepository) ListFiltered(ctx context.Context, filter APIKeyFilter) ([]models.APIKey, int64, error) {
+	query := r.db.DB.WithContext(ctx).Model(&models.APIKey{})
+
+	if filter.Tier != "" {
+		query = query.Where("tier = ?", filter.Tier)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+	if filter.Tag != "" {
+		tag, _ := json.Marshal([]string{filter.Tag})
+		switch r.db.Dialect {
+		case storage.DialectMySQL:
+			query = query.Where("JSON_CONTAINS(tags, ?)", string(tag))
+		case storage.DialectSQLite:
+			query = query.Where("EXISTS (SELECT 1 FROM json_each(api_keys.tags) WHERE json_each.value = ?)", filter.Tag)
+		default:
+			query = query.Where("tags @> ?::jsonb", string(tag))
+		}
+	}
+	if filter.CreatedBy != "" {
+		query = query.Where("created_by = ?", filter.CreatedBy)
+	}
+	if filter.Owner != "" {
+		query = query.Where("owner = ?", filter.Owner)
+	}
+	if filter.Team != "" {
+		query = query.Where("team = ?", filter.Team)
+	}
+	if filter.Prefix != "" {
+		query = query.Where("key_prefix LIKE ? ESCAPE '!'", escapeLike(filter.Prefix)+"%")
+	}
+	if filter.LastUsedBefore != nil {
+		query = query.Where("last_used_at < ?", *filter.LastUsedBefore)
+	}
+	if filter.LastUsedAfter != nil {
+		query = query.Where("last_used_at > ?", *filter.LastUsedAfter)
+	}
+	if filter.OrgID != nil {
+		query = query.Where("org_id = ?", *filter.OrgID)
+	}
+	if filter.DeveloperID != nil {
+		query = query.Where("developer_id = ?", *filter.DeveloperID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	sortBy := filter.SortBy
+	if !apiKeySortColumns[sortBy] {
+		sortBy = "created_at"
+	}
+	direction := "ASC"
+	if filter.SortDesc {
+		direction = "DESC"
+	}
+
+	var keys []models.APIKey
+	err := query.
+		Order(sortBy + " " + direction).
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&keys).Error
+
+	return keys, total, err
+}
+
 func (r *APIKeyRepository) Update(ctx context.Context, id string, updates map[string]interface{}) error {
 	return r.db.DB.WithContext(ctx).
 		Model(&models.APIKey{}).
@@ -68,7 +178,54 @@ func (r *APIKeyRepository) UpdateLastUsed(ctx context.Context, id uuid.UUID) err
 	return r.db.DB.WithContext(ctx).
 		Model(&models.APIKey{}).
 		Where("id = ?", id).
-		Update("last_used_at", time.Now()).Error
+		Updates(map[string]interface{}{
+			"last_used_at": time.Now(),
+			"stale_since":  nil,
+		}).Error
+}
+
+// Retrieves active keys not used since the cutoff, counting never-used keys from creation
+func (r *APIKeyRepository) ListUnusedSince(ctx context.Context, cutoff time.Time) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.DB.WithContext(ctx).
+		Where("is_active = ?", true).
+		Where("(last_used_at IS NULL AND created_at < ?) OR last_used_at < ?", cutoff, cutoff).
+		Order("COALESCE(last_used_at, created_at) ASC").
+		Find(&keys).Error
+
+	return keys, err
+}
+
+// Retrieves active keys missing an owner or a contact email
+func (r *APIKeyRepository) ListUnowned(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.DB.WithContext(ctx).
+		Where("is_active = ?", true).
+		Where("COALESCE(owner, '') = '' OR COALESCE(contact_email, '') = ''").
+		Order("created_at ASC").
+		Find(&keys).Error
+
+	return keys, err
+}
+
+// Flags a key as stale, reporting whether this call flagged it
+func (r *APIKeyRepository) MarkStale(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.DB.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ? AND stale_since IS NULL", id).
+		Update("stale_since", at)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// Deactivates a key, reporting whether it was active
+func (r *APIKeyRepository) Deactivate(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.DB.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ? AND is_active = ?", id, true).
+		Update("is_active", false)
+
+	return result.RowsAffected > 0, result.Error
 }
 
 func (r *APIKeyRepository) Delete(ctx context.Context, id string) error {
@@ -86,3 +243,9 @@ func (r *APIKeyRepository) CountByTier(ctx context.Context, tier string) (int64,
 
 	return count, err
 }
+
+// Escapes LIKE wildcards in user supplied input, for use with ESCAPE '!'.
+// A backslash escape isn't portable, as MySQL reads it inside string literals.
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
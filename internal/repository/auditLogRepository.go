@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+)
+
+// Filters and pagination for querying the audit log
+type AuditLogFilter struct {
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Since        *time.Time
+	Until        *time.Time
+	Limit        int
+	Offset       int
+}
+
+type AuditLogRepository struct {
+	db *storage.Postgres
+}
+
+func NewAuditLogRepository(db *storage.Postgres) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// Inserts a new audit record
+func (r *AuditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	return r.db.DB.WithContext(ctx).Create(entry).Error
+}
+
+// Retrieves audit records newest first, with the total matching the filter
+func (r *AuditLogRepository) List(ctx context.Context, filter AuditLogFilter) ([]models.AuditLog, int64, error) {
+	query := r.db.DB.WithContext(ctx).Model(&models.AuditLog{})
+
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ResourceType != "" {
+		query = query.Where("resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceID != "" {
+		query = query.Where("resource_id = ?", filter.ResourceID)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.AuditLog
+	err := query.
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&entries).Error
+
+	return entries, total, err
+}
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"github.com/aman-churiwal/api-gateway/internal/models"
 	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AuthRepository struct {
@@ -21,6 +24,43 @@ func (r *AuthRepository) Create(ctx context.Context, user *models.User) error {
 	return r.db.DB.WithContext(ctx).Create(user).Error
 }
 
+// Returned by CreateFirst when a user already exists
+var ErrUsersExist = errors.New("users already exist")
+
+// Inserts the user only if the table is empty, serializing concurrent bootstraps
+func (r *AuthRepository) CreateFirst(ctx context.Context, user *models.User) error {
+	return r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&models.User{})
+		switch r.db.Dialect {
+		case storage.DialectPostgres:
+			if err := tx.Exec("LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+				return err
+			}
+		case storage.DialectMySQL:
+			// Locks the scanned range, so a concurrent insert waits
+			query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		// SQLite allows one writer at a time, so a racing bootstrap fails to insert
+
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrUsersExist
+		}
+
+		return tx.Create(user).Error
+	})
+}
+
+// Returns the number of users
+func (r *AuthRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.DB.WithContext(ctx).Model(&models.User{}).Count(&count).Error
+	return count, err
+}
+
 // Retrieves user by email
 func (r *AuthRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
@@ -35,6 +75,20 @@ func (r *AuthRepository) FindByEmail(ctx context.Context, email string) (*models
 	return &user, err
 }
 
+// Retrieves the user an identity provider knows by the given subject
+func (r *AuthRepository) FindByExternalID(ctx context.Context, provider, subject string) (*models.User, error) {
+	var user models.User
+	err := r.db.DB.WithContext(ctx).
+		Where("auth_provider = ? AND external_id = ?", provider, subject).
+		First(&user).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	return &user, err
+}
+
 // Retrieves user by id
 func (r *AuthRepository) FindById(ctx context.Context, id string) (*models.User, error) {
 	var user models.User
@@ -49,6 +103,22 @@ func (r *AuthRepository) FindById(ctx context.Context, id string) (*models.User,
 	return &user, err
 }
 
+// Updates the given user fields
+func (r *AuthRepository) Update(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	return r.db.DB.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+// Replaces a user's password hash
+func (r *AuthRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return r.db.DB.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", id).
+		Update("password_hash", passwordHash).Error
+}
+
 // Retrieves all users
 func (r *AuthRepository) List(ctx context.Context) ([]models.User, error) {
 	var users []models.User
@@ -0,0 +1,543 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Table layout for request logs in ClickHouse, created on startup
+const clickHouseRequestLogsTable = `
+CREATE TABLE IF NOT EXISTS request_logs (
+	id               UInt64,
+	timestamp        DateTime64(3, 'UTC'),
+	api_key_id       Nullable(UUID),
+	consumer_id      String DEFAULT '',
+	method           LowCardinality(String),
+	path             String,
+	status_code      UInt16,
+	response_time_ms UInt32,
+	bytes_in         UInt64 DEFAULT 0,
+	bytes_out        UInt64 DEFAULT 0,
+	ip_address       String,
+	user_agent       String,
+	backend_server   LowCardinality(String),
+	is_preflight     Bool,
+	error_type       LowCardinality(String),
+	error_message    String,
+	request_headers  String,
+	request_body     String,
+	response_body    String,
+	sample_rate      Float64 DEFAULT 1,
+	variant          LowCardinality(String) DEFAULT '',
+	operation        LowCardinality(String) DEFAULT '',
+	country          LowCardinality(String) DEFAULT '',
+	bot_verdict      LowCardinality(String) DEFAULT '',
+	bot_signals      LowCardinality(String) DEFAULT '',
+	route            LowCardinality(String) DEFAULT '',
+	api_version      LowCardinality(String) DEFAULT ''
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (timestamp, path)`
+
+// Columns added since the table layout was first released
+var clickHouseRequestLogsMigrations = []string{
+	"ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS sample_rate Float64 DEFAULT 1",
+	"ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS bytes_in UInt64 DEFAULT 0",
+	"ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS bytes_out UInt64 DEFAULT 0",
+	"ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS variant LowCardinality(String) DEFAULT ''",
+	"ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS operation LowCardinality(String) DEFAULT ''",
+	"ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS country LowCardinality(String) DEFAULT ''",
+	"ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS bot_verdict LowCardinality(String) DEFAULT ''",
+	"ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS bot_signals LowCardinality(String) DEFAULT ''",
+	"ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS route LowCardinality(String) DEFAULT ''",
+	"ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS api_version LowCardinality(String) DEFAULT ''",
+	"ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS consumer_id String DEFAULT ''",
+}
+
+// Filter shared by the time range queries below
+const clickHouseTimeRange = "timestamp BETWEEN {from:DateTime64(3)} AND {to:DateTime64(3)}"
+
+// Rows are weighted by the requests they stand for, as in RequestLogRepository
+const (
+	clickHouseCount = "toInt64(round(sum(1 / sample_rate)))"
+	clickHouseAvg   = "ifNotFinite(sum(response_time_ms / sample_rate) / sum(1 / sample_rate), 0)"
+)
+
+// Weighted t-digest estimate of a response time percentile
+func clickHouseQuantile(percentile float64) string {
+	return "quantileTDigestWeighted(" + strconv.FormatFloat(percentile, 'f', -1, 64) + ")(response_time_ms, toUInt32(round(1 / sample_rate)))"
+}
+
+// Request logs in ClickHouse, for volumes where PostgreSQL percentile
+// queries get too slow. Percentiles are t-digest estimates. API key names
+// for top keys still come from PostgreSQL.
+type ClickHouseRequestLogRepository struct {
+	ch *storage.ClickHouse
+	db *storage.Postgres
+}
+
+// Creates the request_logs table when missing
+func NewClickHouseRequestLogRepository(ctx context.Context, ch *storage.ClickHouse, db *storage.Postgres) (*ClickHouseRequestLogRepository, error) {
+	for _, statement := range append([]string{clickHouseRequestLogsTable}, clickHouseRequestLogsMigrations...) {
+		if err := ch.Exec(ctx, statement, nil); err != nil {
+			return nil, err
+		}
+	}
+	return &ClickHouseRequestLogRepository{ch: ch, db: db}, nil
+}
+
+func timeRange(from, to time.Time) map[string]string {
+	return map[string]string{
+		"from": from.UTC().Format(storage.ClickHouseTimeFormat),
+		"to":   to.UTC().Format(storage.ClickHouseTimeFormat),
+	}
+}
+
+func (r *ClickHouseRequestLogRepository) Create(ctx context.Context, log *models.RequestLog) error {
+	return r.CreateBatch(ctx, []*models.RequestLog{log})
+}
+
+// ClickHouse has no sequences, so IDs are random 63-bit values
+func (r *ClickHouseRequestLogRepository) CreateBatch(ctx context.Context, logs []*models.RequestLog) error {
+	rows := make([]any, len(logs))
+	for i, log := range logs {
+		if log.ID == 0 {
+			log.ID = uint(rand.Uint64() >> 1)
+		}
+		if log.SampleRate <= 0 {
+			log.SampleRate = 1
+		}
+		rows[i] = log
+	}
+	return r.ch.Insert(ctx, "request_logs", rows)
+}
+
+// Runs a query returning whole log rows
+func (r *ClickHouseRequestLogRepository) findLogs(ctx context.Context, where string, params map[string]string, limit, offset int) ([]models.RequestLog, error) {
+	logs := make([]models.RequestLog, 0)
+	query := "SELECT * FROM request_logs WHERE " + where + " ORDER BY timestamp DESC"
+	if limit > 0 {
+		query += " LIMIT " + strconv.Itoa(limit)
+	}
+	if offset > 0 {
+		query += " OFFSET " + strconv.Itoa(offset)
+	}
+
+	err := r.ch.Query(ctx, query, params, func(row []byte) error {
+		var log models.RequestLog
+		if err := json.Unmarshal(row, &log); err != nil {
+			return err
+		}
+		logs = append(logs, log)
+		return nil
+	})
+	return logs, err
+}
+
+func (r *ClickHouseRequestLogRepository) FindByTimeRange(ctx context.Context, from, to time.Time, limit, offset int) ([]models.RequestLog, error) {
+	return r.findLogs(ctx, clickHouseTimeRange, timeRange(from, to), limit, offset)
+}
+
+func (r *ClickHouseRequestLogRepository) FindByAPIKey(ctx context.Context, apiKeyID uuid.UUID, from, to time.Time, limit, offset int) ([]models.RequestLog, error) {
+	params := timeRange(from, to)
+	params["key"] = apiKeyID.String()
+	return r.findLogs(ctx, "api_key_id = {key:UUID} AND "+clickHouseTimeRange, params, limit, offset)
+}
+
+func (r *ClickHouseRequestLogRepository) FindByStatusCode(ctx context.Context, statusCode int, from, to time.Time, limit, offset int) ([]models.RequestLog, error) {
+	params := timeRange(from, to)
+	params["status"] = strconv.Itoa(statusCode)
+	return r.findLogs(ctx, "status_code = {status:UInt16} AND "+clickHouseTimeRange, params, limit, offset)
+}
+
+// Calls fn for each log in a time range, newest first, as rows arrive
+func (r *ClickHouseRequestLogRepository) Stream(ctx context.Context, from, to time.Time, statusCode *int, limit, offset int, fn func(models.RequestLog) error) error {
+	params := timeRange(from, to)
+	query := "SELECT * FROM request_logs WHERE " + clickHouseTimeRange
+	if statusCode != nil {
+		params["status"] = strconv.Itoa(*statusCode)
+		query += " AND status_code = {status:UInt16}"
+	}
+	query += " ORDER BY timestamp DESC"
+	if limit > 0 {
+		query += " LIMIT " + strconv.Itoa(limit)
+	}
+	if offset > 0 {
+		query += " OFFSET " + strconv.Itoa(offset)
+	}
+
+	return r.ch.Query(ctx, query, params, func(row []byte) error {
+		var log models.RequestLog
+		if err := json.Unmarshal(row, &log); err != nil {
+			return err
+		}
+		return fn(log)
+	})
+}
+
+// Runs a query returning a single value in the column "v"
+func (r *ClickHouseRequestLogRepository) scalar(ctx context.Context, query string, params map[string]string, dest any) error {
+	return r.ch.Query(ctx, query, params, func(row []byte) error {
+		var result struct {
+			V json.RawMessage `json:"v"`
+		}
+		if err := json.Unmarshal(row, &result); err != nil {
+			return err
+		}
+		return json.Unmarshal(result.V, dest)
+	})
+}
+
+func (r *ClickHouseRequestLogRepository) CountByTimeRange(ctx context.Context, from, to time.Time) (int64, error) {
+	var count int64
+	err := r.scalar(ctx, "SELECT "+clickHouseCount+" AS v FROM request_logs WHERE "+clickHouseTimeRange, timeRange(from, to), &count)
+	return count, err
+}
+
+func (r *ClickHouseRequestLogRepository) CountPreflightByTimeRange(ctx context.Context, from, to time.Time) (int64, error) {
+	var count int64
+	err := r.scalar(ctx, "SELECT "+clickHouseCount+" AS v FROM request_logs WHERE is_preflight AND "+clickHouseTimeRange, timeRange(from, to), &count)
+	return count, err
+}
+
+// Runs a query returning path and count rows, grouped by the path expression
+func (r *ClickHouseRequestLogRepository) pathCounts(ctx context.Context, path, where string, from, to time.Time, limit int) ([]map[string]interface{}, error) {
+	var results []map[string]interface{}
+	query := "SELECT " + path + " AS endpoint, " + clickHouseCount + " AS count FROM request_logs WHERE " + where +
+		" GROUP BY endpoint ORDER BY count DESC LIMIT " + strconv.Itoa(limit)
+
+	err := r.ch.Query(ctx, query, timeRange(from, to), func(row []byte) error {
+		var result struct {
+			Path  string `json:"endpoint"`
+			Count int64  `json:"count"`
+		}
+		if err := json.Unmarshal(row, &result); err != nil {
+			return err
+		}
+		results = append(results, map[string]interface{}{
+			"path":  result.Path,
+			"count": result.Count,
+		})
+		return nil
+	})
+	return results, err
+}
+
+func (r *ClickHouseRequestLogRepository) GetPreflightByPath(ctx context.Context, from, to time.Time, limit int) ([]map[string]interface{}, error) {
+	return r.pathCounts(ctx, "path", "is_preflight AND "+clickHouseTimeRange, from, to, limit)
+}
+
+func (r *ClickHouseRequestLogRepository) GetTopEndpoints(ctx context.Context, from, to time.Time, limit int) ([]map[string]interface{}, error) {
+	// Paths of a route count together under its template
+	return r.pathCounts(ctx, "if(route != '', route, path)", clickHouseTimeRange, from, to, limit)
+}
+
+func (r *ClickHouseRequestLogRepository) GetAverageResponseTime(ctx context.Context, from, to time.Time) (float64, error) {
+	var avg float64
+	err := r.scalar(ctx, "SELECT "+clickHouseAvg+" AS v FROM request_logs WHERE "+clickHouseTimeRange, timeRange(from, to), &avg)
+	return avg, err
+}
+
+func (r *ClickHouseRequestLogRepository) GetPercentile(ctx context.Context, from, to time.Time, percentile float64) (int, error) {
+	var value float64
+	query := "SELECT ifNotFinite(" + clickHouseQuantile(percentile) + ", 0) AS v" +
+		" FROM request_logs WHERE " + clickHouseTimeRange
+	err := r.scalar(ctx, query, timeRange(from, to), &value)
+	return int(value), err
+}
+
+func (r *ClickHouseRequestLogRepository) CountByStatusCodeRange(ctx context.Context, minStatusCode, maxStatusCode int, from, to time.Time) (int64, error) {
+	params := timeRange(from, to)
+	params["min"] = strconv.Itoa(minStatusCode)
+	params["max"] = strconv.Itoa(maxStatusCode)
+
+	var count int64
+	err := r.scalar(ctx, "SELECT "+clickHouseCount+" AS v FROM request_logs WHERE status_code BETWEEN {min:UInt16} AND {max:UInt16} AND "+clickHouseTimeRange, params, &count)
+	return count, err
+}
+
+func (r *ClickHouseRequestLogRepository) GetStatusCodeCounts(ctx context.Context, from, to time.Time) (map[int]int64, error) {
+	counts := make(map[int]int64)
+	query := "SELECT status_code, " + clickHouseCount + " AS count FROM request_logs WHERE " + clickHouseTimeRange + " GROUP BY status_code"
+
+	err := r.ch.Query(ctx, query, timeRange(from, to), func(row []byte) error {
+		var result struct {
+			StatusCode int   `json:"status_code"`
+			Count      int64 `json:"count"`
+		}
+		if err := json.Unmarshal(row, &result); err != nil {
+			return err
+		}
+		counts[result.StatusCode] = result.Count
+		return nil
+	})
+	return counts, err
+}
+
+// Buckets match RequestLogRepository.GetLatencyHistogram: a response time
+// falls in bucket i when i bounds are at or below it
+func (r *ClickHouseRequestLogRepository) GetLatencyHistogram(ctx context.Context, from, to time.Time, bounds []int) ([]int64, error) {
+	thresholds := make([]string, len(bounds))
+	for i, bound := range bounds {
+		thresholds[i] = strconv.Itoa(bound)
+	}
+	params := timeRange(from, to)
+	params["bounds"] = "[" + strings.Join(thresholds, ",") + "]"
+
+	counts := make([]int64, len(bounds)+1)
+	query := "SELECT length(arrayFilter(b -> b <= response_time_ms, {bounds:Array(UInt32)})) AS bucket, " + clickHouseCount + " AS count" +
+		" FROM request_logs WHERE " + clickHouseTimeRange + " GROUP BY bucket"
+
+	err := r.ch.Query(ctx, query, params, func(row []byte) error {
+		var result struct {
+			Bucket int   `json:"bucket"`
+			Count  int64 `json:"count"`
+		}
+		if err := json.Unmarshal(row, &result); err != nil {
+			return err
+		}
+		if result.Bucket >= 0 && result.Bucket < len(counts) {
+			counts[result.Bucket] = result.Count
+		}
+		return nil
+	})
+	return counts, err
+}
+
+func (r *ClickHouseRequestLogRepository) GetTopCountries(ctx context.Context, from, to time.Time, limit int) ([]CountryTraffic, error) {
+	results := make([]CountryTraffic, 0)
+	query := `SELECT country,
+			` + clickHouseCount + ` AS requests,
+			toInt64(round(sumIf(1 / sample_rate, status_code BETWEEN 400 AND 499))) AS client_errors,
+			toInt64(round(sumIf(1 / sample_rate, status_code >= 500))) AS server_errors,
+			` + clickHouseAvg + ` AS avg_latency_ms
+		FROM request_logs
+		WHERE ` + clickHouseTimeRange + `
+		GROUP BY country
+		ORDER BY requests DESC, country
+		LIMIT ` + strconv.Itoa(limit)
+
+	err := r.ch.Query(ctx, query, timeRange(from, to), func(row []byte) error {
+		var result struct {
+			Country      string  `json:"country"`
+			Requests     int64   `json:"requests"`
+			ClientErrors int64   `json:"client_errors"`
+			ServerErrors int64   `json:"server_errors"`
+			AvgLatencyMs float64 `json:"avg_latency_ms"`
+		}
+		if err := json.Unmarshal(row, &result); err != nil {
+			return err
+		}
+		results = append(results, CountryTraffic(result))
+		return nil
+	})
+	return results, err
+}
+
+func (r *ClickHouseRequestLogRepository) GetVersionTraffic(ctx context.Context, from, to time.Time) ([]VersionTraffic, error) {
+	results := make([]VersionTraffic, 0)
+	query := `SELECT api_version AS version,
+			` + clickHouseCount + ` AS requests,
+			toInt64(round(sumIf(1 / sample_rate, status_code BETWEEN 400 AND 499))) AS client_errors,
+			toInt64(round(sumIf(1 / sample_rate, status_code >= 500))) AS server_errors,
+			` + clickHouseAvg + ` AS avg_latency_ms
+		FROM request_logs
+		WHERE ` + clickHouseTimeRange + ` AND api_version != ''
+		GROUP BY api_version
+		ORDER BY api_version`
+
+	err := r.ch.Query(ctx, query, timeRange(from, to), func(row []byte) error {
+		var result struct {
+			Version      string  `json:"version"`
+			Requests     int64   `json:"requests"`
+			ClientErrors int64   `json:"client_errors"`
+			ServerErrors int64   `json:"server_errors"`
+			AvgLatencyMs float64 `json:"avg_latency_ms"`
+		}
+		if err := json.Unmarshal(row, &result); err != nil {
+			return err
+		}
+		results = append(results, VersionTraffic(result))
+		return nil
+	})
+	return results, err
+}
+
+func (r *ClickHouseRequestLogRepository) GetTopOperations(ctx context.Context, from, to time.Time, limit int) ([]OperationTraffic, error) {
+	results := make([]OperationTraffic, 0)
+	query := `SELECT path, operation,
+			` + clickHouseCount + ` AS requests,
+			toInt64(round(sumIf(1 / sample_rate, status_code BETWEEN 400 AND 499))) AS client_errors,
+			toInt64(round(sumIf(1 / sample_rate, status_code >= 500))) AS server_errors,
+			` + clickHouseAvg + ` AS avg_latency_ms
+		FROM request_logs
+		WHERE operation != '' AND ` + clickHouseTimeRange + `
+		GROUP BY path, operation
+		ORDER BY requests DESC, path, operation
+		LIMIT ` + strconv.Itoa(limit)
+
+	err := r.ch.Query(ctx, query, timeRange(from, to), func(row []byte) error {
+		var result struct {
+			Path         string  `json:"path"`
+			Operation    string  `json:"operation"`
+			Requests     int64   `json:"requests"`
+			ClientErrors int64   `json:"client_errors"`
+			ServerErrors int64   `json:"server_errors"`
+			AvgLatencyMs float64 `json:"avg_latency_ms"`
+		}
+		if err := json.Unmarshal(row, &result); err != nil {
+			return err
+		}
+		results = append(results, OperationTraffic(result))
+		return nil
+	})
+	return results, err
+}
+
+// Aggregates in ClickHouse, then fills key names, tiers and owners from PostgreSQL
+func (r *ClickHouseRequestLogRepository) GetTopKeys(ctx context.Context, from, to time.Time, orderBy string, limit int) ([]KeyTraffic, error) {
+	order, ok := topKeyOrders[orderBy]
+	if !ok {
+		order = topKeyOrders["requests"]
+	}
+
+	results := make([]KeyTraffic, 0)
+	query := `SELECT api_key_id,
+			` + clickHouseCount + ` AS requests,
+			toInt64(round(sumIf(1 / sample_rate, status_code BETWEEN 400 AND 499))) AS client_errors,
+			toInt64(round(sumIf(1 / sample_rate, status_code >= 500))) AS server_errors,
+			` + clickHouseAvg + ` AS avg_latency_ms,
+			` + clickHouseQuantile(0.95) + ` AS p95_latency_ms
+		FROM request_logs
+		WHERE api_key_id IS NOT NULL AND ` + clickHouseTimeRange + `
+		GROUP BY api_key_id
+		ORDER BY ` + order + `, api_key_id
+		LIMIT ` + strconv.Itoa(limit)
+
+	err := r.ch.Query(ctx, query, timeRange(from, to), func(row []byte) error {
+		var result struct {
+			APIKeyID     uuid.UUID `json:"api_key_id"`
+			Requests     int64     `json:"requests"`
+			ClientErrors int64     `json:"client_errors"`
+			ServerErrors int64     `json:"server_errors"`
+			AvgLatencyMs float64   `json:"avg_latency_ms"`
+			P95LatencyMs float64   `json:"p95_latency_ms"`
+		}
+		if err := json.Unmarshal(row, &result); err != nil {
+			return err
+		}
+		results = append(results, KeyTraffic{
+			APIKeyID:     result.APIKeyID,
+			Requests:     result.Requests,
+			ClientErrors: result.ClientErrors,
+			ServerErrors: result.ServerErrors,
+			AvgLatencyMs: result.AvgLatencyMs,
+			P95LatencyMs: result.P95LatencyMs,
+		})
+		return nil
+	})
+	if err != nil || len(results) == 0 {
+		return results, err
+	}
+
+	ids := make([]uuid.UUID, len(results))
+	for i, result := range results {
+		ids[i] = result.APIKeyID
+	}
+
+	var keys []models.APIKey
+	if err := r.db.DB.WithContext(ctx).Select("id, name, tier, owner").Where("id IN ?", ids).Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.APIKey, len(keys))
+	for _, key := range keys {
+		byID[key.ID] = key
+	}
+	for i := range results {
+		if key, ok := byID[results[i].APIKeyID]; ok {
+			results[i].Name = key.Name
+			results[i].Tier = key.Tier
+			results[i].Owner = key.Owner
+		}
+	}
+
+	return results, nil
+}
+
+func (r *ClickHouseRequestLogRepository) GetKeyUsage(ctx context.Context, from, to time.Time) ([]KeyUsage, error) {
+	results := make([]KeyUsage, 0)
+	query := `SELECT api_key_id,
+			` + clickHouseCount + ` AS requests,
+			toInt64(round(sumIf(1 / sample_rate, status_code BETWEEN 400 AND 499))) AS client_errors,
+			toInt64(round(sumIf(1 / sample_rate, status_code >= 500))) AS server_errors,
+			toInt64(round(sum(bytes_in / sample_rate))) AS bytes_in,
+			toInt64(round(sum(bytes_out / sample_rate))) AS bytes_out
+		FROM request_logs
+		WHERE api_key_id IS NOT NULL AND timestamp >= {from:DateTime64(3)} AND timestamp < {to:DateTime64(3)}
+		GROUP BY api_key_id`
+
+	err := r.ch.Query(ctx, query, timeRange(from, to), func(row []byte) error {
+		var result struct {
+			APIKeyID     uuid.UUID `json:"api_key_id"`
+			Requests     int64     `json:"requests"`
+			ClientErrors int64     `json:"client_errors"`
+			ServerErrors int64     `json:"server_errors"`
+			BytesIn      int64     `json:"bytes_in"`
+			BytesOut     int64     `json:"bytes_out"`
+		}
+		if err := json.Unmarshal(row, &result); err != nil {
+			return err
+		}
+		results = append(results, KeyUsage(result))
+		return nil
+	})
+	return results, err
+}
+
+func (r *ClickHouseRequestLogRepository) GetHourlyStatus(ctx context.Context, from, to time.Time) ([]map[string]interface{}, error) {
+	var results []map[string]interface{}
+	query := "SELECT toStartOfHour(timestamp) AS hour, " + clickHouseCount + " AS count, " + clickHouseAvg + " AS avg_response_time" +
+		" FROM request_logs WHERE " + clickHouseTimeRange + " GROUP BY hour ORDER BY hour ASC"
+
+	err := r.ch.Query(ctx, query, timeRange(from, to), func(row []byte) error {
+		var result struct {
+			Hour            time.Time `json:"hour"`
+			Count           int64     `json:"count"`
+			AvgResponseTime float64   `json:"avg_response_time"`
+		}
+		if err := json.Unmarshal(row, &result); err != nil {
+			return err
+		}
+		results = append(results, map[string]interface{}{
+			"hour":              result.Hour,
+			"count":             result.Count,
+			"avg_response_time": result.AvgResponseTime,
+		})
+		return nil
+	})
+	return results, err
+}
+
+// Deletes with a mutation, which ClickHouse applies in the background
+func (r *ClickHouseRequestLogRepository) DeleteOldLogs(ctx context.Context, before time.Time) (int64, error) {
+	params := map[string]string{"before": before.UTC().Format(storage.ClickHouseTimeFormat)}
+
+	var count int64
+	if err := r.scalar(ctx, "SELECT count() AS v FROM request_logs WHERE timestamp < {before:DateTime64(3)}", params, &count); err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	err := r.ch.Exec(ctx, "ALTER TABLE request_logs DELETE WHERE timestamp < {before:DateTime64(3)}", params)
+	return count, err
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"gorm.io/gorm"
+)
+
+type DeadLetterRepository struct {
+	db *storage.Postgres
+}
+
+func NewDeadLetterRepository(db *storage.Postgres) *DeadLetterRepository {
+	return &DeadLetterRepository{db: db}
+}
+
+// Inserts a new dead letter
+func (r *DeadLetterRepository) Create(ctx context.Context, deadLetter *models.DeadLetter) error {
+	return r.db.DB.WithContext(ctx).Create(deadLetter).Error
+}
+
+// Retrieves a dead letter by id
+func (r *DeadLetterRepository) FindByID(ctx context.Context, id string) (*models.DeadLetter, error) {
+	var deadLetter models.DeadLetter
+	err := r.db.DB.WithContext(ctx).
+		Where("id = ?", id).
+		First(&deadLetter).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	return &deadLetter, err
+}
+
+// Retrieves dead letters, optionally filtered by service and redrive state
+func (r *DeadLetterRepository) List(ctx context.Context, service string, pendingOnly bool, limit, offset int) ([]models.DeadLetter, error) {
+	var deadLetters []models.DeadLetter
+
+	query := r.db.DB.WithContext(ctx)
+	if service != "" {
+		query = query.Where("service = ?", service)
+	}
+	if pendingOnly {
+		query = query.Where("redriven_at IS NULL")
+	}
+
+	err := query.
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&deadLetters).Error
+
+	return deadLetters, err
+}
+
+// Updates fields of a dead letter
+func (r *DeadLetterRepository) Update(ctx context.Context, id string, updates map[string]interface{}) error {
+	return r.db.DB.WithContext(ctx).
+		Model(&models.DeadLetter{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+// Deletes a dead letter
+func (r *DeadLetterRepository) Delete(ctx context.Context, id string) error {
+	return r.db.DB.WithContext(ctx).
+		Where("id = ?", id).
+		Delete(&models.DeadLetter{}).Error
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DeveloperRepository struct {
+	db *storage.Postgres
+}
+
+func NewDeveloperRepository(db *storage.Postgres) *DeveloperRepository {
+	return &DeveloperRepository{db: db}
+}
+
+// Inserts a new developer
+func (r *DeveloperRepository) Create(ctx context.Context, developer *models.Developer) error {
+	return r.db.DB.WithContext(ctx).Create(developer).Error
+}
+
+// Retrieves a developer by email
+func (r *DeveloperRepository) FindByEmail(ctx context.Context, email string) (*models.Developer, error) {
+	var developer models.Developer
+	err := r.db.DB.WithContext(ctx).
+		Where("email = ?", email).
+		First(&developer).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	return &developer, err
+}
+
+// Retrieves a developer by id
+func (r *DeveloperRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Developer, error) {
+	var developer models.Developer
+	err := r.db.DB.WithContext(ctx).
+		Where("id = ?", id).
+		First(&developer).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	return &developer, err
+}
+
+// Records a successful login
+func (r *DeveloperRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).
+		Model(&models.Developer{}).
+		Where("id = ?", id).
+		Update("last_login_at", time.Now()).Error
+}
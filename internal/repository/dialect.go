@@ -0,0 +1,22 @@
+package repository
+
+import "github.com/aman-churiwal/api-gateway/internal/storage"
+
+// Casts a numeric expression to a 64-bit integer
+func castInteger(db *storage.Postgres, expr string) string {
+	if db.Dialect == storage.DialectMySQL {
+		return "CAST(" + expr + " AS SIGNED)"
+	}
+	return "CAST(" + expr + " AS BIGINT)"
+}
+
+// Truncates a timestamp column to the hour, as Unix seconds
+func hourEpoch(db *storage.Postgres, column string) string {
+	switch db.Dialect {
+	case storage.DialectMySQL:
+		return "CAST(FLOOR(UNIX_TIMESTAMP(" + column + ") / 3600) * 3600 AS SIGNED)"
+	case storage.DialectSQLite:
+		return "CAST(strftime('%s', " + column + ") AS INTEGER) / 3600 * 3600"
+	}
+	return "CAST(FLOOR(EXTRACT(EPOCH FROM " + column + ") / 3600) * 3600 AS BIGINT)"
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Returned when an invitation was redeemed by a concurrent registration
+var ErrInvitationUsed = errors.New("invitation already used")
+
+type InvitationRepository struct {
+	db *storage.Postgres
+}
+
+func NewInvitationRepository(db *storage.Postgres) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+// Inserts a new invitation
+func (r *InvitationRepository) Create(ctx context.Context, invitation *models.Invitation) error {
+	return r.db.DB.WithContext(ctx).Create(invitation).Error
+}
+
+// Retrieves an invitation by its token hash
+func (r *InvitationRepository) FindByHash(ctx context.Context, hash string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := r.db.DB.WithContext(ctx).
+		Where("token_hash = ?", hash).
+		First(&invitation).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	return &invitation, err
+}
+
+// Retrieves invitations, newest first
+func (r *InvitationRepository) List(ctx context.Context, pendingOnly bool, orgID *uuid.UUID) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	query := r.db.DB.WithContext(ctx)
+	if pendingOnly {
+		query = query.Where("accepted_at IS NULL AND expires_at > ?", time.Now())
+	}
+	if orgID != nil {
+		query = query.Where("org_id = ?", *orgID)
+	}
+
+	err := query.Order("created_at DESC").Find(&invitations).Error
+	return invitations, err
+}
+
+// Deletes an invitation, only if it belongs to orgID when that's set
+func (r *InvitationRepository) Delete(ctx context.Context, id string, orgID *uuid.UUID) error {
+	query := r.db.DB.WithContext(ctx).Where("id = ?", id)
+	if orgID != nil {
+		query = query.Where("org_id = ?", *orgID)
+	}
+	return query.Delete(&models.Invitation{}).Error
+}
+
+// Marks the invitation accepted and creates the user in one transaction
+func (r *InvitationRepository) Redeem(ctx context.Context, invitation *models.Invitation, user *models.User) error {
+	return r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL", invitation.ID).
+			Updates(map[string]interface{}{
+				"accepted_at": time.Now(),
+				"accepted_by": user.ID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvitationUsed
+		}
+
+		return nil
+	})
+}
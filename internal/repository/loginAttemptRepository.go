@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+)
+
+type LoginAttemptRepository struct {
+	db *storage.Postgres
+}
+
+func NewLoginAttemptRepository(db *storage.Postgres) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+// Inserts a new audit record
+func (r *LoginAttemptRepository) Create(ctx context.Context, attempt *models.LoginAttempt) error {
+	return r.db.DB.WithContext(ctx).Create(attempt).Error
+}
+
+// Retrieves the most recent attempts, optionally filtered by email and IP
+func (r *LoginAttemptRepository) List(ctx context.Context, email, ip string, limit int) ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	query := r.db.DB.WithContext(ctx).Order("created_at DESC").Limit(limit)
+	if email != "" {
+		query = query.Where("email = ?", email)
+	}
+	if ip != "" {
+		query = query.Where("ip = ?", ip)
+	}
+
+	err := query.Find(&attempts).Error
+	return attempts, err
+}
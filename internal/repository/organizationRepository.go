@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OrganizationRepository struct {
+	db *storage.Postgres
+}
+
+func NewOrganizationRepository(db *storage.Postgres) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+// An organization with how many users and API keys belong to it
+type OrganizationEntry struct {
+	models.Organization
+	Users   int64 `json:"users"`
+	APIKeys int64 `json:"api_keys"`
+}
+
+// Inserts a new organization
+func (r *OrganizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	return r.db.DB.WithContext(ctx).Create(org).Error
+}
+
+// Retrieves an organization by id
+func (r *OrganizationRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	var org models.Organization
+	err := r.db.DB.WithContext(ctx).
+		Where("id = ?", id).
+		First(&org).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	return &org, err
+}
+
+// Retrieves organizations with their member and key counts, by name. When
+// id is set only that organization is returned.
+func (r *OrganizationRepository) List(ctx context.Context, id *uuid.UUID) ([]OrganizationEntry, error) {
+	query := r.db.DB.WithContext(ctx).
+		Table("organizations AS o").
+		Select(`o.*,
+			(SELECT COUNT(*) FROM users AS u WHERE u.org_id = o.id) AS users,
+			(SELECT COUNT(*) FROM api_keys AS k WHERE k.org_id = o.id) AS api_keys`)
+	if id != nil {
+		query = query.Where("o.id = ?", *id)
+	}
+
+	entries := make([]OrganizationEntry, 0)
+	err := query.Order("o.name").Scan(&entries).Error
+	return entries, err
+}
+
+// Moves a user into an organization, or out of any when orgID is nil.
+// Returns false when the user doesn't exist.
+func (r *OrganizationRepository) AssignUser(ctx context.Context, userID uuid.UUID, orgID *uuid.UUID) (bool, error) {
+	result := r.db.DB.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("org_id", orgID)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Moves an API key into an organization, or out of any when orgID is nil.
+// Returns false when the key doesn't exist.
+func (r *OrganizationRepository) AssignAPIKey(ctx context.Context, keyID uuid.UUID, orgID *uuid.UUID) (bool, error) {
+	result := r.db.DB.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ?", keyID).
+		Update("org_id", orgID)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Sets the aggregate request limit of an organization. Returns false when the
+// organization doesn't exist.
+func (r *OrganizationRepository) SetRequestLimit(ctx context.Context, id uuid.UUID, requestsPerMinute int) (bool, error) {
+	result := r.db.DB.WithContext(ctx).
+		Model(&models.Organization{}).
+		Where("id = ?", id).
+		Update("requests_per_minute", requestsPerMinute)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Maps each organization with an aggregate request limit to the limit
+func (r *OrganizationRepository) RequestLimits(ctx context.Context) (map[uuid.UUID]int, error) {
+	var orgs []models.Organization
+	err := r.db.DB.WithContext(ctx).
+		Select("id, requests_per_minute").
+		Where("requests_per_minute > 0").
+		Find(&orgs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	limits := make(map[uuid.UUID]int, len(orgs))
+	for _, org := range orgs {
+		limits[org.ID] = org.RequestsPerMinute
+	}
+	return limits, nil
+}
+
+// Maps every API key that belongs to an organization to it
+func (r *OrganizationRepository) KeyOrganizations(ctx context.Context) (map[uuid.UUID]uuid.UUID, error) {
+	var rows []struct {
+		ID    uuid.UUID
+		OrgID uuid.UUID
+	}
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Select("id, org_id").
+		Where("org_id IS NOT NULL").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	orgs := make(map[uuid.UUID]uuid.UUID, len(rows))
+	for _, row := range rows {
+		orgs[row.ID] = row.OrgID
+	}
+	return orgs, nil
+}
+
+// Reports whether an API key belongs to the organization
+func (r *OrganizationRepository) OwnsAPIKey(ctx context.Context, orgID, keyID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ? AND org_id = ?", keyID, orgID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Maps each of an organization's API keys to its name
+func (r *OrganizationRepository) KeyNames(ctx context.Context, orgID uuid.UUID) (map[uuid.UUID]string, error) {
+	var keys []models.APIKey
+	err := r.db.DB.WithContext(ctx).
+		Select("id, name").
+		Where("org_id = ?", orgID).
+		Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[uuid.UUID]string, len(keys))
+	for _, key := range keys {
+		names[key.ID] = key.Name
+	}
+	return names, nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PasswordResetRepository struct {
+	db *storage.Postgres
+}
+
+func NewPasswordResetRepository(db *storage.Postgres) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+// Inserts a new reset token
+func (r *PasswordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	return r.db.DB.WithContext(ctx).Create(token).Error
+}
+
+// Retrieves a reset token by its hash
+func (r *PasswordResetRepository) FindByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.db.DB.WithContext(ctx).
+		Where("token_hash = ?", hash).
+		First(&token).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	return &token, err
+}
+
+// Marks a token used, reporting whether this call consumed it
+func (r *PasswordResetRepository) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.DB.WithContext(ctx).
+		Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// Consumes every outstanding token of a user
+func (r *PasswordResetRepository) InvalidateForUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).
+		Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
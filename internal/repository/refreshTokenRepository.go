@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository struct {
+	db *storage.Postgres
+}
+
+func NewRefreshTokenRepository(db *storage.Postgres) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// Inserts a new refresh token
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.DB.WithContext(ctx).Create(token).Error
+}
+
+// Retrieves a refresh token by its hash
+func (r *RefreshTokenRepository) FindByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.DB.WithContext(ctx).
+		Where("token_hash = ?", hash).
+		First(&token).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	return &token, err
+}
+
+// Marks a token as revoked and records its replacement. Returns false when
+// the token was already revoked, e.g. by a concurrent rotation.
+func (r *RefreshTokenRepository) Revoke(ctx context.Context, id uuid.UUID, replacedBy *uuid.UUID) (bool, error) {
+	result := r.db.DB.WithContext(ctx).
+		Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at":  time.Now(),
+			"replaced_by": replacedBy,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Revokes every active token of a user
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).
+		Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Deletes tokens that expired before the given time
+func (r *RefreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.DB.WithContext(ctx).
+		Where("expires_at < ?", before).
+		Delete(&models.RefreshToken{})
+
+	return result.RowsAffected, result.Error
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aman-churiwal/api-gateway/internal/models"
@@ -9,6 +11,10 @@ import (
 	"github.com/google/uuid"
 )
 
+// Rows stand for 1/sample_rate requests when logging is sampled, so counts
+// and averages are weighted. Rows logged without sampling have a rate of 1.
+const weightedAvg = "COALESCE(SUM(response_time_ms / sample_rate) / NULLIF(SUM(1.0 / sample_rate), 0), 0)"
+
 type RequestLogRepository struct {
 	db *storage.Postgres
 }
@@ -17,6 +23,21 @@ func NewRequestLogRepository(db *storage.Postgres) *RequestLogRepository {
 	return &RequestLogRepository{db: db}
 }
 
+// Weighted sum of value over the rows matching condition, rounded to an integer.
+// An empty condition matches every row.
+func (r *RequestLogRepository) weightedSum(value, condition string) string {
+	sum := "SUM(" + value + " / sample_rate)"
+	if condition != "" {
+		sum = "SUM(CASE WHEN " + condition + " THEN " + value + " / sample_rate ELSE 0 END)"
+	}
+	return castInteger(r.db, "COALESCE(ROUND("+sum+"), 0)")
+}
+
+// Weighted request count
+func (r *RequestLogRepository) weightedCount() string {
+	return r.weightedSum("1.0", "")
+}
+
 // Inserts a new request log
 func (r *RequestLogRepository) Create(ctx context.Context, log *models.RequestLog) error {
 	return r.db.DB.WithContext(ctx).Create(log).Error
@@ -71,18 +92,99 @@ func (r *RequestLogRepository) FindByStatusCode(ctx context.Context, statusCode
 	return logs, err
 }
 
+// Calls fn for each log in a time range, newest first, reading rows as they
+// arrive instead of loading the page. A limit of 0 returns every match.
+func (r *RequestLogRepository) Stream(ctx context.Context, from, to time.Time, statusCode *int, limit, offset int, fn func(models.RequestLog) error) error {
+	query := r.db.DB.WithContext(ctx).
+		Model(&models.RequestLog{}).
+		Where("timestamp BETWEEN ? AND ?", from, to)
+	if statusCode != nil {
+		query = query.Where("status_code = ?", *statusCode)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	rows, err := query.Order("timestamp DESC").Offset(offset).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log models.RequestLog
+		if err := r.db.DB.ScanRows(rows, &log); err != nil {
+			return err
+		}
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
 // Counts logs in a time range
 func (r *RequestLogRepository) CountByTimeRange(ctx context.Context, from, to time.Time) (int64, error) {
 	var count int64
 
 	err := r.db.DB.WithContext(ctx).
 		Model(&models.RequestLog{}).
+		Select(r.weightedCount()).
 		Where("timestamp BETWEEN ? AND ?", from, to).
-		Count(&count).Error
+		Scan(&count).Error
+
+	return count, err
+}
+
+// Counts CORS preflight requests in a time range
+func (r *RequestLogRepository) CountPreflightByTimeRange(ctx context.Context, from, to time.Time) (int64, error) {
+	var count int64
+
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.RequestLog{}).
+		Select(r.weightedCount()).
+		Where("is_preflight = ? AND timestamp BETWEEN ? AND ?", true, from, to).
+		Scan(&count).Error
 
 	return count, err
 }
 
+// Returns preflight counts grouped by path
+func (r *RequestLogRepository) GetPreflightByPath(ctx context.Context, from, to time.Time, limit int) ([]map[string]interface{}, error) {
+	var results []map[string]interface{}
+
+	rows, err := r.db.DB.WithContext(ctx).
+		Model(&models.RequestLog{}).
+		Select("path, "+r.weightedCount()+" as count").
+		Where("is_preflight = ? AND timestamp BETWEEN ? AND ?", true, from, to).
+		Group("path").
+		Order("count DESC").
+		Limit(limit).
+		Rows()
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var path string
+		var count int64
+
+		if err := rows.Scan(&path, &count); err != nil {
+			return nil, err
+		}
+
+		results = append(results, map[string]interface{}{
+			"path":  path,
+			"count": count,
+		})
+	}
+
+	return results, nil
+}
+
 // Calculates average response time
 func (r *RequestLogRepository) GetAverageResponseTime(ctx context.Context, from, to time.Time) (float64, error) {
 	var avg float64
@@ -90,23 +192,28 @@ func (r *RequestLogRepository) GetAverageResponseTime(ctx context.Context, from,
 	err := r.db.DB.WithContext(ctx).
 		Model(&models.RequestLog{}).
 		Where("timestamp BETWEEN ? AND ?", from, to).
-		Select("AVG(response_time_ms)").
+		Select(weightedAvg).
 		Scan(&avg).Error
 
 	return avg, err
 }
 
-// Calculates response time percentile
+// Calculates response time percentile, weighting rows by the requests they stand for
 func (r *RequestLogRepository) GetPercentile(ctx context.Context, from, to time.Time, percentile float64) (int, error) {
-	// Calculate percentile using SQL
 	var result int
 	query := `
-		SELECT PERCENTILE_CONT(?) WITHIN GROUP (ORDER BY response_time_ms)
-		FROM request_logs
-		WHERE timestamp BETWEEN ? AND ?
+		SELECT COALESCE(MIN(response_time_ms), 0)
+		FROM (
+			SELECT response_time_ms,
+				SUM(1.0 / sample_rate) OVER (ORDER BY response_time_ms ROWS UNBOUNDED PRECEDING) AS cumulative,
+				SUM(1.0 / sample_rate) OVER () AS total
+			FROM request_logs
+			WHERE timestamp BETWEEN ? AND ?
+		) weighted
+		WHERE cumulative >= ? * total
 	`
 
-	err := r.db.DB.WithContext(ctx).Raw(query, percentile, from, to).Scan(&result).Error
+	err := r.db.DB.WithContext(ctx).Raw(query, from, to, percentile).Scan(&result).Error
 	return result, err
 }
 
@@ -116,21 +223,273 @@ func (r *RequestLogRepository) CountByStatusCodeRange(ctx context.Context, minSt
 
 	err := r.db.DB.WithContext(ctx).
 		Model(&models.RequestLog{}).
+		Select(r.weightedCount()).
 		Where("status_code BETWEEN ? AND ? AND timestamp BETWEEN ? AND ?", minStatusCode, maxStatusCode, from, to).
-		Count(&count).Error
+		Scan(&count).Error
 
 	return count, err
 }
 
+// Returns request counts keyed by status code
+func (r *RequestLogRepository) GetStatusCodeCounts(ctx context.Context, from, to time.Time) (map[int]int64, error) {
+	rows, err := r.db.DB.WithContext(ctx).
+		Model(&models.RequestLog{}).
+		Select("status_code, "+r.weightedCount()+" as count").
+		Where("timestamp BETWEEN ? AND ?", from, to).
+		Group("status_code").
+		Rows()
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int]int64)
+	for rows.Next() {
+		var statusCode int
+		var count int64
+		if err := rows.Scan(&statusCode, &count); err != nil {
+			return nil, err
+		}
+		counts[statusCode] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// Counts requests per response time bucket. bounds must be ascending; the
+// result has len(bounds)+1 entries, where entry i counts response times in
+// [bounds[i-1], bounds[i]) and the first and last buckets are open-ended.
+func (r *RequestLogRepository) GetLatencyHistogram(ctx context.Context, from, to time.Time, bounds []int) ([]int64, error) {
+	bucket := "0"
+	if len(bounds) > 0 {
+		cases := make([]string, len(bounds))
+		for i, bound := range bounds {
+			cases[i] = "WHEN response_time_ms < " + strconv.Itoa(bound) + " THEN " + strconv.Itoa(i)
+		}
+		bucket = "CASE " + strings.Join(cases, " ") + " ELSE " + strconv.Itoa(len(bounds)) + " END"
+	}
+
+	rows, err := r.db.DB.WithContext(ctx).
+		Model(&models.RequestLog{}).
+		Select(bucket+" as bucket, "+r.weightedCount()+" as count").
+		Where("timestamp BETWEEN ? AND ?", from, to).
+		Group("bucket").
+		Rows()
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]int64, len(bounds)+1)
+	for rows.Next() {
+		var bucket int
+		var count int64
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+		if bucket >= 0 && bucket < len(counts) {
+			counts[bucket] = count
+		}
+	}
+
+	return counts, rows.Err()
+}
+
+// Aggregated traffic of one API key
+type KeyTraffic struct {
+	APIKeyID     uuid.UUID
+	Name         string
+	Tier         string
+	Owner        string
+	Requests     int64
+	ClientErrors int64
+	ServerErrors int64
+	AvgLatencyMs float64
+	P95LatencyMs float64
+}
+
+// Orderings accepted by GetTopKeys
+var topKeyOrders = map[string]string{
+	"requests": "requests DESC",
+	"errors":   "client_errors + server_errors DESC",
+	"p95":      "p95_latency_ms DESC",
+}
+
+// Returns the API keys with the most traffic, or the most errors or highest
+// p95 latency when orderBy is "errors" or "p95". The p95 is taken over logged
+// rows, so sampling that keeps slow requests pushes it up.
+func (r *RequestLogRepository) GetTopKeys(ctx context.Context, from, to time.Time, orderBy string, limit int) ([]KeyTraffic, error) {
+	order, ok := topKeyOrders[orderBy]
+	if !ok {
+		order = topKeyOrders["requests"]
+	}
+
+	// Databases without ordered-set aggregates take the nearest-rank p95
+	// from each key's rows ordered by latency
+	p95 := "PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY rl.response_time_ms)"
+	query := r.db.DB.WithContext(ctx).Table("request_logs AS rl")
+	if r.db.Dialect != storage.DialectPostgres {
+		p95 = "MAX(p.response_time_ms)"
+		query = query.Joins(`LEFT JOIN (
+			SELECT api_key_id, MIN(response_time_ms) AS response_time_ms
+			FROM (
+				SELECT api_key_id, response_time_ms,
+					ROW_NUMBER() OVER (PARTITION BY api_key_id ORDER BY response_time_ms) AS position,
+					COUNT(*) OVER (PARTITION BY api_key_id) AS total
+				FROM request_logs
+				WHERE api_key_id IS NOT NULL AND timestamp BETWEEN ? AND ?
+			) ranked
+			WHERE position >= 0.95 * total
+			GROUP BY api_key_id
+		) AS p ON p.api_key_id = rl.api_key_id`, from, to)
+	}
+
+	var results []KeyTraffic
+	err := query.
+		Select(`rl.api_key_id,
+			COALESCE(k.name, '') AS name,
+			COALESCE(k.tier, '') AS tier,
+			COALESCE(k.owner, '') AS owner,
+			`+castInteger(r.db, "ROUND(SUM(1.0 / rl.sample_rate))")+` AS requests,
+			`+castInteger(r.db, "ROUND(SUM(CASE WHEN rl.status_code BETWEEN 400 AND 499 THEN 1.0 / rl.sample_rate ELSE 0 END))")+` AS client_errors,
+			`+castInteger(r.db, "ROUND(SUM(CASE WHEN rl.status_code >= 500 THEN 1.0 / rl.sample_rate ELSE 0 END))")+` AS server_errors,
+			SUM(rl.response_time_ms / rl.sample_rate) / SUM(1.0 / rl.sample_rate) AS avg_latency_ms,
+			`+p95+` AS p95_latency_ms`).
+		Joins("LEFT JOIN api_keys AS k ON k.id = rl.api_key_id").
+		Where("rl.api_key_id IS NOT NULL AND rl.timestamp BETWEEN ? AND ?", from, to).
+		Group("rl.api_key_id, k.name, k.tier, k.owner").
+		Order(order + ", rl.api_key_id").
+		Limit(limit).
+		Scan(&results).Error
+
+	return results, err
+}
+
+// Billable traffic of one API key
+type KeyUsage struct {
+	APIKeyID     uuid.UUID
+	Requests     int64
+	ClientErrors int64
+	ServerErrors int64
+	BytesIn      int64
+	BytesOut     int64
+}
+
+// Totals requests, errors and bandwidth per API key in [from, to)
+func (r *RequestLogRepository) GetKeyUsage(ctx context.Context, from, to time.Time) ([]KeyUsage, error) {
+	var results []KeyUsage
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.RequestLog{}).
+		Select(`api_key_id,
+			`+r.weightedCount()+` AS requests,
+			`+r.weightedSum("1.0", "status_code BETWEEN 400 AND 499")+` AS client_errors,
+			`+r.weightedSum("1.0", "status_code >= 500")+` AS server_errors,
+			`+r.weightedSum("bytes_in", "")+` AS bytes_in,
+			`+r.weightedSum("bytes_out", "")+` AS bytes_out`).
+		Where("api_key_id IS NOT NULL AND timestamp >= ? AND timestamp < ?", from, to).
+		Group("api_key_id").
+		Scan(&results).Error
+
+	return results, err
+}
+
+// Aggregated traffic from one country. Country is empty for addresses the
+// GeoIP database has no country for, and for logs written without geoip.
+type CountryTraffic struct {
+	Country      string
+	Requests     int64
+	ClientErrors int64
+	ServerErrors int64
+	AvgLatencyMs float64
+}
+
+// Returns the countries sending the most requests
+func (r *RequestLogRepository) GetTopCountries(ctx context.Context, from, to time.Time, limit int) ([]CountryTraffic, error) {
+	var results []CountryTraffic
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.RequestLog{}).
+		Select(`country,
+			`+r.weightedCount()+` AS requests,
+			`+r.weightedSum("1.0", "status_code BETWEEN 400 AND 499")+` AS client_errors,
+			`+r.weightedSum("1.0", "status_code >= 500")+` AS server_errors,
+			SUM(response_time_ms / sample_rate) / SUM(1.0 / sample_rate) AS avg_latency_ms`).
+		Where("timestamp BETWEEN ? AND ?", from, to).
+		Group("country").
+		Order("requests DESC, country").
+		Limit(limit).
+		Scan(&results).Error
+
+	return results, err
+}
+
+// Aggregated traffic of one API version, as "<service>@<version>"
+type VersionTraffic struct {
+	Version      string
+	Requests     int64
+	ClientErrors int64
+	ServerErrors int64
+	AvgLatencyMs float64
+}
+
+// Returns the traffic of each API version of versioned services
+func (r *RequestLogRepository) GetVersionTraffic(ctx context.Context, from, to time.Time) ([]VersionTraffic, error) {
+	var results []VersionTraffic
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.RequestLog{}).
+		Select(`api_version AS version,
+			`+r.weightedCount()+` AS requests,
+			`+r.weightedSum("1.0", "status_code BETWEEN 400 AND 499")+` AS client_errors,
+			`+r.weightedSum("1.0", "status_code >= 500")+` AS server_errors,
+			SUM(response_time_ms / sample_rate) / SUM(1.0 / sample_rate) AS avg_latency_ms`).
+		Where("timestamp BETWEEN ? AND ? AND api_version <> ''", from, to).
+		Group("api_version").
+		Order("api_version").
+		Scan(&results).Error
+
+	return results, err
+}
+
+// Aggregated traffic of one GraphQL operation on one endpoint
+type OperationTraffic struct {
+	Path         string
+	Operation    string
+	Requests     int64
+	ClientErrors int64
+	ServerErrors int64
+	AvgLatencyMs float64
+}
+
+// Returns the busiest GraphQL operations
+func (r *RequestLogRepository) GetTopOperations(ctx context.Context, from, to time.Time, limit int) ([]OperationTraffic, error) {
+	var results []OperationTraffic
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.RequestLog{}).
+		Select(`path, operation,
+			`+r.weightedCount()+` AS requests,
+			`+r.weightedSum("1.0", "status_code BETWEEN 400 AND 499")+` AS client_errors,
+			`+r.weightedSum("1.0", "status_code >= 500")+` AS server_errors,
+			SUM(response_time_ms / sample_rate) / SUM(1.0 / sample_rate) AS avg_latency_ms`).
+		Where("operation <> '' AND timestamp BETWEEN ? AND ?", from, to).
+		Group("path, operation").
+		Order("requests DESC, path, operation").
+		Limit(limit).
+		Scan(&results).Error
+
+	return results, err
+}
+
 // Returns most frequently accessed endpoints
 func (r *RequestLogRepository) GetTopEndpoints(ctx context.Context, from, to time.Time, limit int) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
 
 	rows, err := r.db.DB.WithContext(ctx).
 		Model(&models.RequestLog{}).
-		Select("path, COUNT(*) as count").
+		// Paths of a route count together under its template
+		Select("COALESCE(NULLIF(route, ''), path) as path, "+r.weightedCount()+" as count").
 		Where("timestamp BETWEEN ? AND ?", from, to).
-		Group("path").
+		Group("COALESCE(NULLIF(route, ''), path)").
 		Order("count DESC").
 		Limit(limit).
 		Rows()
@@ -164,7 +523,7 @@ func (r *RequestLogRepository) GetHourlyStatus(ctx context.Context, from, to tim
 
 	rows, err := r.db.DB.WithContext(ctx).
 		Model(&models.RequestLog{}).
-		Select("DATE_TRUNC('hour', timestamp) as hour, COUNT(*) as count, AVG(response_time_ms) as avg_response_time").
+		Select(hourEpoch(r.db, "timestamp")+" as hour, "+r.weightedCount()+" as count, "+weightedAvg+" as avg_response_time").
 		Where("timestamp BETWEEN ? AND ?", from, to).
 		Group("hour").
 		Order("hour ASC").
@@ -176,14 +535,13 @@ func (r *RequestLogRepository) GetHourlyStatus(ctx context.Context, from, to tim
 	defer rows.Close()
 
 	for rows.Next() {
-		var hour time.Time
-		var count int64
+		var hour, count int64
 		var avgResponseTime float64
 		if err := rows.Scan(&hour, &count, &avgResponseTime); err != nil {
 			return nil, err
 		}
 		results = append(results, map[string]interface{}{
-			"hour":              hour,
+			"hour":              time.Unix(hour, 0).UTC(),
 			"count":             count,
 			"avg_response_time": avgResponseTime,
 		})
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/google/uuid"
+)
+
+// Storage behind the analytics API, selected by analytics.backend: PostgreSQL
+// (RequestLogRepository), TimescaleDB or ClickHouse
+type RequestLogStore interface {
+	Create(ctx context.Context, log *models.RequestLog) error
+	CreateBatch(ctx context.Context, logs []*models.RequestLog) error
+
+	FindByTimeRange(ctx context.Context, from, to time.Time, limit, offset int) ([]models.RequestLog, error)
+	FindByAPIKey(ctx context.Context, apiKeyID uuid.UUID, from, to time.Time, limit, offset int) ([]models.RequestLog, error)
+	FindByStatusCode(ctx context.Context, statusCode int, from, to time.Time, limit, offset int) ([]models.RequestLog, error)
+	Stream(ctx context.Context, from, to time.Time, statusCode *int, limit, offset int, fn func(models.RequestLog) error) error
+
+	CountByTimeRange(ctx context.Context, from, to time.Time) (int64, error)
+	CountPreflightByTimeRange(ctx context.Context, from, to time.Time) (int64, error)
+	GetPreflightByPath(ctx context.Context, from, to time.Time, limit int) ([]map[string]interface{}, error)
+	GetAverageResponseTime(ctx context.Context, from, to time.Time) (float64, error)
+	GetPercentile(ctx context.Context, from, to time.Time, percentile float64) (int, error)
+	CountByStatusCodeRange(ctx context.Context, minStatusCode, maxStatusCode int, from, to time.Time) (int64, error)
+	GetStatusCodeCounts(ctx context.Context, from, to time.Time) (map[int]int64, error)
+	GetLatencyHistogram(ctx context.Context, from, to time.Time, bounds []int) ([]int64, error)
+	GetTopKeys(ctx context.Context, from, to time.Time, orderBy string, limit int) ([]KeyTraffic, error)
+	GetTopEndpoints(ctx context.Context, from, to time.Time, limit int) ([]map[string]interface{}, error)
+	GetTopCountries(ctx context.Context, from, to time.Time, limit int) ([]CountryTraffic, error)
+	GetTopOperations(ctx context.Context, from, to time.Time, limit int) ([]OperationTraffic, error)
+	GetVersionTraffic(ctx context.Context, from, to time.Time) ([]VersionTraffic, error)
+	GetHourlyStatus(ctx context.Context, from, to time.Time) ([]map[string]interface{}, error)
+	GetKeyUsage(ctx context.Context, from, to time.Time) ([]KeyUsage, error)
+
+	DeleteOldLogs(ctx context.Context, before time.Time) (int64, error)
+}
+
+var (
+	_ RequestLogStore = (*RequestLogRepository)(nil)
+	_ RequestLogStore = (*TimescaleRequestLogRepository)(nil)
+	_ RequestLogStore = (*ClickHouseRequestLogRepository)(nil)
+)
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"gorm.io/gorm"
+)
+
+type ServiceAccountRepository struct {
+	db *storage.Postgres
+}
+
+func NewServiceAccountRepository(db *storage.Postgres) *ServiceAccountRepository {
+	return &ServiceAccountRepository{db: db}
+}
+
+// Inserts a new service account
+func (r *ServiceAccountRepository) Create(ctx context.Context, account *models.ServiceAccount) error {
+	return r.db.DB.WithContext(ctx).Create(account).Error
+}
+
+// Retrieves a service account by id
+func (r *ServiceAccountRepository) FindByID(ctx context.Context, id string) (*models.ServiceAccount, error) {
+	var account models.ServiceAccount
+	err := r.db.DB.WithContext(ctx).
+		Where("id = ?", id).
+		First(&account).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	return &account, err
+}
+
+// Retrieves all service accounts, newest first
+func (r *ServiceAccountRepository) List(ctx context.Context) ([]models.ServiceAccount, error) {
+	var accounts []models.ServiceAccount
+	err := r.db.DB.WithContext(ctx).
+		Order("created_at DESC").
+		Find(&accounts).Error
+
+	return accounts, err
+}
+
+// Deactivates a service account and revokes its outstanding tokens
+func (r *ServiceAccountRepository) Deactivate(ctx context.Context, id string) error {
+	return r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ServiceAccount{}).
+			Where("id = ?", id).
+			Update("is_active", false).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.ServiceAccountToken{}).
+			Where("service_account_id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", time.Now()).Error
+	})
+}
+
+// Inserts a new token
+func (r *ServiceAccountRepository) CreateToken(ctx context.Context, token *models.ServiceAccountToken) error {
+	return r.db.DB.WithContext(ctx).Create(token).Error
+}
+
+// Retrieves a token by its hash
+func (r *ServiceAccountRepository) FindTokenByHash(ctx context.Context, hash string) (*models.ServiceAccountToken, error) {
+	var token models.ServiceAccountToken
+	err := r.db.DB.WithContext(ctx).
+		Where("token_hash = ?", hash).
+		First(&token).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	return &token, err
+}
+
+// Retrieves a service account's tokens, newest first
+func (r *ServiceAccountRepository) ListTokens(ctx context.Context, accountID string) ([]models.ServiceAccountToken, error) {
+	var tokens []models.ServiceAccountToken
+	err := r.db.DB.WithContext(ctx).
+		Where("service_account_id = ?", accountID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+
+	return tokens, err
+}
+
+// Revokes one of a service account's tokens, reporting whether it was outstanding
+func (r *ServiceAccountRepository) RevokeToken(ctx context.Context, accountID, tokenID string) (bool, error) {
+	result := r.db.DB.WithContext(ctx).
+		Model(&models.ServiceAccountToken{}).
+		Where("id = ? AND service_account_id = ? AND revoked_at IS NULL", tokenID, accountID).
+		Update("revoked_at", time.Now())
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// Records that a token was used
+func (r *ServiceAccountRepository) TouchToken(ctx context.Context, id string, at time.Time) error {
+	return r.db.DB.WithContext(ctx).
+		Model(&models.ServiceAccountToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
+
+// Retrieves a service account by name
+func (r *ServiceAccountRepository) FindByName(ctx context.Context, name string) (*models.ServiceAccount, error) {
+	var account models.ServiceAccount
+	err := r.db.DB.WithContext(ctx).
+		Where("name = ?", name).
+		First(&account).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	return &account, err
+}
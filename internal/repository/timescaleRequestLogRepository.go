@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"gorm.io/gorm"
+)
+
+// Request logs in a TimescaleDB hypertable. Queries are plain PostgreSQL and
+// gain from chunk exclusion on timestamp; retention drops whole chunks.
+type TimescaleRequestLogRepository struct {
+	*RequestLogRepository
+}
+
+// Converts request_logs into a hypertable on first use. Existing rows are migrated.
+func NewTimescaleRequestLogRepository(ctx context.Context, db *storage.Postgres) (*TimescaleRequestLogRepository, error) {
+	err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("CREATE EXTENSION IF NOT EXISTS timescaledb").Error; err != nil {
+			return fmt.Errorf("timescaledb extension unavailable: %w", err)
+		}
+
+		var exists bool
+		err := tx.Raw("SELECT EXISTS (SELECT 1 FROM timescaledb_information.hypertables WHERE hypertable_name = 'request_logs')").
+			Scan(&exists).Error
+		if err != nil || exists {
+			return err
+		}
+
+		// Unique indexes on a hypertable must include the partitioning column
+		statements := []string{
+			"ALTER TABLE request_logs DROP CONSTRAINT IF EXISTS request_logs_pkey",
+			"ALTER TABLE request_logs ADD PRIMARY KEY (id, timestamp)",
+			"SELECT create_hypertable('request_logs', 'timestamp', chunk_time_interval => INTERVAL '1 day', migrate_data => TRUE)",
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("failed to create request_logs hypertable: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TimescaleRequestLogRepository{RequestLogRepository: NewRequestLogRepository(db)}, nil
+}
+
+// Drops chunks entirely older than before, then deletes the rest row by row
+func (r *TimescaleRequestLogRepository) DeleteOldLogs(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// drop_chunks doesn't report rows, so count them while they still exist
+		if err := tx.Model(&models.RequestLog{}).Where("timestamp < ?", before).Count(&deleted).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("SELECT drop_chunks('request_logs', older_than => ?::timestamptz)", before).Error; err != nil {
+			return err
+		}
+		return tx.Where("timestamp < ?", before).Delete(&models.RequestLog{}).Error
+	})
+
+	return deleted, err
+}
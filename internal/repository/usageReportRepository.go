@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+type UsageReportRepository struct {
+	db *storage.Postgres
+}
+
+func NewUsageReportRepository(db *storage.Postgres) *UsageReportRepository {
+	return &UsageReportRepository{db: db}
+}
+
+// A usage report with the key's details for invoicing
+type UsageReportEntry struct {
+	models.UsageReport
+	Name  string     `json:"name"`
+	Tier  string     `json:"tier"`
+	Owner string     `json:"owner"`
+	OrgID *uuid.UUID `json:"org_id,omitempty"`
+}
+
+// Inserts reports, replacing the figures of any already stored for the same key and month
+func (r *UsageReportRepository) Upsert(ctx context.Context, reports []models.UsageReport) error {
+	if len(reports) == 0 {
+		return nil
+	}
+
+	return r.db.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "api_key_id"}, {Name: "month"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"requests", "client_errors", "server_errors", "bytes_in", "bytes_out", "final", "generated_at",
+			}),
+		}).
+		Create(&reports).Error
+}
+
+// Reports whether the month has been rolled up for good
+func (r *UsageReportRepository) IsFinal(ctx context.Context, month time.Time) (bool, error) {
+	var count int64
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.UsageReport{}).
+		Where("month = ? AND final = ?", month, true).
+		Count(&count).Error
+
+	return count > 0, err
+}
+
+// Retrieves a month's reports, optionally for one key or one organization's
+// keys, busiest keys first
+func (r *UsageReportRepository) ListByMonth(ctx context.Context, month time.Time, apiKeyID, orgID *uuid.UUID) ([]UsageReportEntry, error) {
+	query := r.db.DB.WithContext(ctx).
+		Table("usage_reports AS u").
+		Select("u.*, COALESCE(k.name, '') AS name, COALESCE(k.tier, '') AS tier, COALESCE(k.owner, '') AS owner, k.org_id").
+		Joins("LEFT JOIN api_keys AS k ON k.id = u.api_key_id").
+		Where("u.month = ?", month)
+	if apiKeyID != nil {
+		query = query.Where("u.api_key_id = ?", *apiKeyID)
+	}
+	if orgID != nil {
+		query = query.Where("k.org_id = ?", *orgID)
+	}
+
+	entries := make([]UsageReportEntry, 0)
+	err := query.Order("u.requests DESC, u.api_key_id").Scan(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,148 @@
+package routing
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// A path pattern requests are matched against. Name is what logs and
+// metrics record: the template, or the regex unless one is given.
+type Route struct {
+	Name     string
+	Priority int
+	Methods  []string // Empty matches every method
+	pattern  *regexp.Regexp
+}
+
+// Compiles a template such as /users/{id}/orders. {name} matches one path
+// segment, {name:regex} a segment matching regex and {name...} the rest of
+// the path, including slashes.
+func Template(template string) (*regexp.Regexp, error) {
+	if !strings.HasPrefix(template, "/") {
+		return nil, fmt.Errorf("template %q must start with /", template)
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	seen := make(map[string]bool)
+	segments := strings.Split(template[1:], "/")
+	for i, segment := range segments {
+		expr.WriteString("/")
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			if strings.ContainsAny(segment, "{}") {
+				return nil, fmt.Errorf("template %q: parameters must span a whole segment", template)
+			}
+			expr.WriteString(regexp.QuoteMeta(segment))
+			continue
+		}
+
+		name, constraint, _ := strings.Cut(segment[1:len(segment)-1], ":")
+		rest := false
+		if trimmed, found := strings.CutSuffix(name, "..."); found && constraint == "" {
+			if i != len(segments)-1 {
+				return nil, fmt.Errorf("template %q: {%s} must be the last segment", template, name)
+			}
+			name, rest = trimmed, true
+		}
+		if !validName(name) {
+			return nil, fmt.Errorf("template %q: invalid parameter name %q", template, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("template %q: duplicate parameter %q", template, name)
+		}
+		seen[name] = true
+
+		switch {
+		case rest:
+			expr.WriteString("(?P<" + name + ">.*)")
+		case constraint != "":
+			if _, err := regexp.Compile(constraint); err != nil {
+				return nil, fmt.Errorf("template %q: parameter %q: %w", template, name, err)
+			}
+			expr.WriteString("(?P<" + name + ">(?:" + constraint + "))")
+		default:
+			expr.WriteString("(?P<" + name + ">[^/]+)")
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Builds a route from a template, or from a regex matched against the whole
+// path. Named groups in the regex become parameters.
+func NewRoute(template, regex, name string, priority int, methods []string) (Route, error) {
+	route := Route{Name: name, Priority: priority}
+	for _, method := range methods {
+		route.Methods = append(route.Methods, strings.ToUpper(method))
+	}
+
+	var err error
+	switch {
+	case template != "" && regex != "":
+		return Route{}, fmt.Errorf("route sets both template and regex")
+	case template != "":
+		route.pattern, err = Template(template)
+		if route.Name == "" {
+			route.Name = template
+		}
+	case regex != "":
+		route.pattern, err = regexp.Compile("^(?:" + regex + ")$")
+		if route.Name == "" {
+			route.Name = regex
+		}
+	default:
+		return Route{}, fmt.Errorf("route needs a template or a regex")
+	}
+	return route, err
+}
+
+// Routes of one service, tried from the highest priority down and, within a
+// priority, in the order they were given
+type Table struct {
+	routes []Route
+}
+
+func NewTable(routes []Route) *Table {
+	routes = slices.Clone(routes)
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Priority > routes[j].Priority
+	})
+	return &Table{routes: routes}
+}
+
+// Returns the first route matching the request and its path parameters
+func (t *Table) Match(method, path string) (Route, map[string]string, bool) {
+	for _, route := range t.routes {
+		if len(route.Methods) > 0 && !slices.Contains(route.Methods, method) {
+			continue
+		}
+		match := route.pattern.FindStringSubmatch(path)
+		if match == nil {
+			continue
+		}
+
+		params := make(map[string]string)
+		for i, name := range route.pattern.SubexpNames() {
+			if name != "" {
+				params[name] = match[i]
+			}
+		}
+		return route, params, true
+	}
+	return Route{}, nil, false
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/aman-churiwal/api-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// One service in the API catalog
+type catalogEntry struct {
+	Path        string            `json:"path"`
+	Routes      []string          `json:"routes"`
+	Description string            `json:"description,omitempty"`
+	Auth        catalogAuth       `json:"auth"`
+	RateLimits  []catalogRateTier `json:"rate_limits"`
+	OpenAPI     string            `json:"openapi,omitempty"`
+	Internal    bool              `json:"internal,omitempty"`
+}
+
+type catalogAuth struct {
+	APIKey    string `json:"api_key"`              // "required", "optional" or "none"
+	JWTIssuer string `json:"jwt_issuer,omitempty"` // End-user tokens checked by forward auth
+	JWT       string `json:"jwt,omitempty"`        // "required" or "optional"
+	Token     string `json:"token,omitempty"`      // Opaque tokens checked by introspection: "required" or "optional"
+}
+
+// Limits a tier gets on one service
+type catalogRateTier struct {
+	Tier              string `json:"tier"` // Empty for the service's own per-consumer limit
+	RequestsPerMinute int    `json:"requests_per_minute"`
+	RequestsPerHour   int    `json:"requests_per_hour,omitempty"`
+	Bucket            string `json:"bucket,omitempty"`
+}
+
+// Handles GET /admin/catalog
+func (s *Server) adminCatalog(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"services": s.apiCatalog("/admin/catalog/openapi", false)})
+}
+
+// Handles GET /catalog
+func (s *Server) publicCatalog(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"services": s.apiCatalog("/catalog/openapi", true)})
+}
+
+// Lists the services the gateway fronts, sorted by path. Spec files are
+// linked under specBase; public leaves out internal services.
+func (s *Server) apiCatalog(specBase string, public bool) []catalogEntry {
+	s.routesMu.RLock()
+	defer s.routesMu.RUnlock()
+
+	entries := make([]catalogEntry, 0, len(s.config.Services))
+	for i := range s.config.Services {
+		svc := &s.config.Services[i]
+		if public && svc.Internal {
+			continue
+		}
+
+		entry := catalogEntry{
+			Path:        svc.Path,
+			Routes:      []string{svc.Path, svc.Path + "/*"},
+			Description: svc.Description,
+			Auth:        catalogAuthFor(svc),
+			RateLimits:  s.catalogRateLimits(svc),
+			OpenAPI:     svc.OpenAPI,
+			Internal:    svc.Internal,
+		}
+		if svc.OpenAPI != "" && !isURL(svc.OpenAPI) {
+			entry.OpenAPI = specBase + svc.Path
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+func catalogAuthFor(svc *config.ServiceConfig) catalogAuth {
+	var auth catalogAuth
+	switch {
+	case svc.FastPath:
+		auth.APIKey = "none"
+	case svc.Auth == "api_key":
+		auth.APIKey = "required"
+	default:
+		auth.APIKey = "optional"
+	}
+
+	if fa := svc.ForwardAuth; fa != nil && fa.Enabled {
+		auth.JWTIssuer = fa.Issuer
+		auth.JWT = "required"
+		if fa.Optional {
+			auth.JWT = "optional"
+		}
+	}
+
+	if in := svc.Introspection; in != nil && in.Enabled {
+		auth.Token = "required"
+		if in.Optional {
+			auth.Token = "optional"
+		}
+	}
+	return auth
+}
+
+// Returns each tier's limits on the service, then the service's own
+func (s *Server) catalogRateLimits(svc *config.ServiceConfig) []catalogRateTier {
+	limits := make([]catalogRateTier, 0, len(s.config.RateLimitTiers)+1)
+	if svc.FastPath {
+		return limits
+	}
+
+	for i := range s.config.RateLimitTiers {
+		tier := &s.config.RateLimitTiers[i]
+		limit := catalogRateTier{
+			Tier:              tier.Name,
+			RequestsPerMinute: tier.RequestsPerMinute,
+			RequestsPerHour:   tier.RequestsPerHour,
+		}
+		if bucket := tier.BucketFor(svc.Path); bucket != nil {
+			limit.Bucket = bucket.Name
+			if bucket.RequestsPerMinute > 0 {
+				limit.RequestsPerMinute = bucket.RequestsPerMinute
+			}
+		}
+		limits = append(limits, limit)
+	}
+
+	if rl := svc.RateLimit; rl != nil {
+		limits = append(limits, catalogRateTier{RequestsPerMinute: rl.RequestsPerMinute})
+	}
+	return limits
+}
+
+// Handles GET /admin/catalog/openapi/*service and GET /catalog/openapi/*service.
+// Serves specs configured as file paths; URL specs are linked directly.
+func (s *Server) catalogSpec(public bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Param("service")
+
+		s.routesMu.RLock()
+		svc := s.findServiceConfig(path)
+		s.routesMu.RUnlock()
+
+		if svc == nil || svc.OpenAPI == "" || isURL(svc.OpenAPI) || (public && svc.Internal) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No OpenAPI spec for this service"})
+			return
+		}
+
+		contentType := "application/json"
+		if strings.HasSuffix(svc.OpenAPI, ".yaml") || strings.HasSuffix(svc.OpenAPI, ".yml") {
+			contentType = "application/yaml"
+		}
+		c.Header("Content-Type", contentType)
+		c.File(svc.OpenAPI)
+	}
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aman-churiwal/api-gateway/internal/asyncjobs"
+	"github.com/gin-gonic/gin"
+)
+
+// Sends a queued request to its service's backend, as the proxy would have
+func (s *Server) forwardAsyncJob(ctx context.Context, job *asyncjobs.Job, req *asyncjobs.Request) (int, http.Header, []byte, error) {
+	s.routesMu.RLock()
+	p := s.proxies[job.Service]
+	svc := s.findServiceConfig(job.Service)
+	s.routesMu.RUnlock()
+
+	if p == nil || svc == nil {
+		return 0, nil, nil, fmt.Errorf("service %s is no longer served", job.Service)
+	}
+
+	path, rawQuery := urlRewrite(svc).Rewrite(job.Path, req.RawQuery)
+	header := req.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("X-Async-Job-ID", job.ID)
+	return p.Do(ctx, job.Method, path, rawQuery, header, req.Body)
+}
+
+// Handles GET /async/jobs/:id
+// Returns a queued request's status, and the backend's response once it has
+// one. Jobs submitted with an API key are only shown to that key.
+func (s *Server) asyncJobStatus(c *gin.Context) {
+	job, err := s.asyncJobs.Get(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, asyncjobs.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Other consumers can't tell the job exists
+	if job.APIKeyID != "" {
+		if apiKeyID, exists := c.Get("api_key_id"); !exists || fmt.Sprint(apiKeyID) != job.APIKeyID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, job)
+}
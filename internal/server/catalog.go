@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/catalog"
+	"github.com/aman-churiwal/api-gateway/internal/config"
+	"github.com/aman-churiwal/api-gateway/internal/handler"
+	"github.com/aman-churiwal/api-gateway/internal/proxy"
+	"github.com/aman-churiwal/api-gateway/internal/service"
+)
+
+// Reads the catalog into cfg, returning the source to watch and the version read
+func loadCatalog(cfg *config.Config) (catalog.Source, uint64) {
+	source, err := catalog.NewSource(cfg.Catalog)
+	if err != nil {
+		log.Fatalf("Failed to set up service catalog: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	snapshot, version, err := source.Load(ctx)
+	if err != nil {
+		log.Fatalf("Failed to read %s catalog: %v", source.Name(), err)
+	}
+
+	next, err := cfg.WithCatalog(snapshot.Services, snapshot.Tiers)
+	if err != nil {
+		log.Fatalf("Invalid %s catalog: %v", source.Name(), err)
+	}
+	*cfg = *next
+
+	log.Printf("Loaded %d services and %d tiers from %s catalog at %s (version %d)",
+		len(snapshot.Services), len(snapshot.Tiers), source.Name(), cfg.Catalog.Address, version)
+	return source, version
+}
+
+// Applies catalog changes made after version as they are written
+func (s *Server) watchCatalog(source catalog.Source, version uint64, fileConfig *config.Config) {
+	s.catalog = catalog.NewWatcher(source, version, func(snapshot *catalog.Snapshot) {
+		s.applyCatalog(fileConfig, snapshot)
+	})
+	s.catalog.Start()
+}
+
+// Swaps in a routing table built from a catalog snapshot, layered over the
+// file config
+func (s *Server) applyCatalog(fileConfig *config.Config, snapshot *catalog.Snapshot) {
+	next, err := fileConfig.WithCatalog(snapshot.Services, snapshot.Tiers)
+	if err != nil {
+		log.Printf("Ignoring invalid catalog update: %v", err)
+		return
+	}
+
+	if s.swapServices(next.Services, next.RateLimitTiers) {
+		log.Printf("Applied catalog update: %d services, %d tiers", len(next.Services), len(next.RateLimitTiers))
+	}
+}
+
+// Rebuilds the routing table for new services and tiers, reporting whether
+// anything changed. Requests in flight finish on the table they started on,
+// and proxies for unchanged services are carried over with their health state.
+func (s *Server) swapServices(services []config.ServiceConfig, tiers []config.RateLimiterTier) bool {
+	s.routesMu.Lock()
+
+	if reflect.DeepEqual(services, s.config.Services) && reflect.DeepEqual(tiers, s.config.RateLimitTiers) {
+		s.routesMu.Unlock()
+		return false
+	}
+
+	previous := s.proxies
+	proxies := make(map[string]*proxy.Proxy, len(services))
+	for _, svc := range services {
+		if current := s.findServiceConfig(svc.Path); current != nil && previous[svc.Path] != nil && reflect.DeepEqual(*current, svc) {
+			proxies[svc.Path] = previous[svc.Path]
+			continue
+		}
+		if p := s.newServiceProxy(svc); p != nil {
+			proxies[svc.Path] = p
+		}
+	}
+
+	s.config.Services = services
+	s.config.RateLimitTiers = tiers
+	s.proxies = proxies
+	s.fastPaths = nil
+
+	s.systemHandler = handler.NewSystemHandler(s.proxies)
+	s.bodyCaptureHandler = handler.NewBodyCaptureHandler(s.bodyCapture, captureServices(s.config.Services))
+	s.deadLetterService.SetRedrivers(redriversFor(s.proxies))
+
+	s.newRouters()
+	s.setupRoutes()
+
+	s.routesMu.Unlock()
+
+	// Stop health checks for proxies no longer routed to
+	for path, p := range previous {
+		if proxies[path] != p {
+			p.Stop()
+		}
+	}
+	return true
+}
+
+// Returns the proxies as dead letter redrivers
+func redriversFor(proxies map[string]*proxy.Proxy) map[string]service.Redriver {
+	redrivers := make(map[string]service.Redriver, len(proxies))
+	for path, p := range proxies {
+		redrivers[path] = p
+	}
+	return redrivers
+}
+
+// Returns the services that can capture bodies; fast-path services bypass gin
+func captureServices(services []config.ServiceConfig) []string {
+	var paths []string
+	for _, svc := range services {
+		if !svc.FastPath {
+			paths = append(paths, svc.Path)
+		}
+	}
+	return paths
+}
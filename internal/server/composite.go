@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/config"
+	"github.com/aman-churiwal/api-gateway/internal/logging"
+	"github.com/aman-churiwal/api-gateway/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// Result of one part of a composite request
+type compositeResult struct {
+	value interface{}
+	err   error
+}
+
+// Registers the composite routes on the proxy listener
+func (s *Server) setupCompositeRoutes() {
+	for i := range s.config.Composites {
+		comp := s.config.Composites[i]
+
+		handlers := []gin.HandlerFunc{func(c *gin.Context) {
+			c.Set("service", comp.Path)
+		}}
+		if comp.Auth == "api_key" {
+			handlers = append(handlers, middleware.RequireAPIKey())
+		}
+		handlers = append(handlers, middleware.ConsumerHeaders(), s.composite(comp))
+
+		s.router.GET(comp.Path, handlers...)
+		log.Printf("Registered composite route: %s (%d parts)", comp.Path, len(comp.Parts))
+	}
+}
+
+// Handles GET on a composite route: calls every part in parallel and merges
+// the responses under their keys. A failed optional part becomes null and is
+// listed in X-Gateway-Partial; any other failure fails the whole request.
+func (s *Server) composite(comp config.CompositeConfig) gin.HandlerFunc {
+	timeout := time.Duration(comp.TimeoutMs) * time.Millisecond
+
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		header := c.Request.Header.Clone()
+		for _, name := range []string{"Accept-Encoding", "Connection", "Content-Length"} {
+			header.Del(name)
+		}
+		header.Set("X-Forwarded-For", c.ClientIP())
+
+		results := make([]compositeResult, len(comp.Parts))
+		var wg sync.WaitGroup
+		for i, part := range comp.Parts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := s.compositePart(ctx, part, c.Params, c.Request.URL.RawQuery, header)
+				results[i] = compositeResult{value: value, err: err}
+			}()
+		}
+		wg.Wait()
+
+		merged := make(map[string]interface{}, len(comp.Parts))
+		var partial []string
+		for i, part := range comp.Parts {
+			result := results[i]
+			if result.err == nil {
+				merged[part.Key] = result.value
+				continue
+			}
+
+			logging.FromContext(c.Request.Context()).Warn("Composite part failed", "route", comp.Path, "part", part.Key, "error", result.err)
+			if !part.Optional {
+				status := http.StatusBadGateway
+				if ctx.Err() == context.DeadlineExceeded {
+					status = http.StatusGatewayTimeout
+				}
+				c.JSON(status, gin.H{"error": fmt.Sprintf("Composite part %s failed", part.Key)})
+				return
+			}
+			merged[part.Key] = nil
+			partial = append(partial, part.Key)
+		}
+
+		if len(partial) > 0 {
+			c.Header("X-Gateway-Partial", strings.Join(partial, ","))
+		}
+		c.JSON(http.StatusOK, merged)
+	}
+}
+
+// Fetches one part through its service's proxy. JSON bodies are decoded so
+// they nest in the merged response; anything else is kept as a string.
+func (s *Server) compositePart(ctx context.Context, part config.CompositePart, params gin.Params, rawQuery string, header http.Header) (interface{}, error) {
+	path := part.Path
+	// Longest names first, so :id doesn't replace the start of :idx
+	sorted := append(gin.Params(nil), params...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i].Key) > len(sorted[j].Key) })
+	for _, param := range sorted {
+		path = strings.ReplaceAll(path, ":"+param.Key, param.Value)
+	}
+	if !part.ForwardQuery {
+		rawQuery = ""
+	}
+
+	s.routesMu.RLock()
+	servicePath := ""
+	for candidate := range s.proxies {
+		if (path == candidate || strings.HasPrefix(path, candidate+"/")) && len(candidate) > len(servicePath) {
+			servicePath = candidate
+		}
+	}
+	p := s.proxies[servicePath]
+	svc := s.findServiceConfig(servicePath)
+	s.routesMu.RUnlock()
+
+	if p == nil {
+		return nil, fmt.Errorf("no service matches %s", path)
+	}
+	if svc != nil {
+		path, rawQuery = urlRewrite(svc).Rewrite(path, rawQuery)
+	}
+
+	statusCode, _, body, err := p.Do(ctx, http.MethodGet, path, rawQuery, header, nil)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode < 200 || statusCode > 299 {
+		return nil, fmt.Errorf("backend returned %d", statusCode)
+	}
+
+	var value interface{}
+	if json.Unmarshal(body, &value) == nil {
+		return value, nil
+	}
+	return string(body), nil
+}
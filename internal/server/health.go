@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/circuitbreaker"
+	"github.com/aman-churiwal/api-gateway/internal/healthcheck"
+	"github.com/gin-gonic/gin"
+)
+
+// Dependency checks slower than this are reported as a degradation
+const slowDependency = 500 * time.Millisecond
+
+// Bounds each dependency check so one hung store can't stall the endpoint
+const dependencyCheckTimeout = 2 * time.Second
+
+// Reasons /health/detail reports the gateway as degraded or unhealthy
+const (
+	reasonDraining           = "draining"
+	reasonDependencyDown     = "dependency_unavailable"
+	reasonDependencySlow     = "dependency_slow"
+	reasonServiceUnavailable = "service_unavailable" // No healthy targets
+	reasonServiceDegraded    = "service_degraded"    // Some targets unhealthy
+	reasonCircuitOpen        = "circuit_open"
+	reasonCircuitHalfOpen    = "circuit_half_open"
+	reasonTargetsEjected     = "targets_ejected"
+	reasonNoServices         = "no_services"
+)
+
+type dependencyHealth struct {
+	Name        string     `json:"name"`
+	Healthy     bool       `json:"healthy"`
+	LatencyMs   float64    `json:"latency_ms"`
+	Error       string     `json:"error,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+}
+
+type targetHealth struct {
+	Target       string     `json:"target"`
+	Healthy      bool       `json:"healthy"`
+	Ejected      bool       `json:"ejected"`
+	FailureCount int        `json:"failure_count"`
+	LastCheck    *time.Time `json:"last_check,omitempty"`
+	LastSuccess  *time.Time `json:"last_success,omitempty"`
+}
+
+type serviceHealth struct {
+	Status         string         `json:"status"`
+	CircuitBreaker string         `json:"circuit_breaker"`
+	HealthyTargets int            `json:"healthy_targets"`
+	TotalTargets   int            `json:"total_targets"`
+	LastSuccess    *time.Time     `json:"last_success,omitempty"` // Latest successful check of any target
+	Targets        []targetHealth `json:"targets"`
+}
+
+// A machine-readable reason the gateway isn't fully healthy
+type degradation struct {
+	Code      string `json:"code"`
+	Component string `json:"component"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// Checks a dependency, recording when it last succeeded
+func (s *Server) checkDependency(ctx context.Context, name string, ping func(context.Context) error) dependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, dependencyCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ping(ctx)
+	result := dependencyHealth{
+		Name:      name,
+		Healthy:   err == nil,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		s.dependencySuccess.Store(name, start.UTC())
+	}
+	if last, ok := s.dependencySuccess.Load(name); ok {
+		t := last.(time.Time)
+		result.LastSuccess = &t
+	}
+	return result
+}
+
+// Handles GET /health/detail: dependency latencies, backend health from the
+// checkers and the reasons for any degradation. Answers 503 only when the
+// gateway can't serve traffic, so monitors can alert on reasons separately.
+func (s *Server) healthDetail(c *gin.Context) {
+	checks := []struct {
+		name string
+		ping func(context.Context) error
+	}{
+		{"redis", s.redis.Ping},
+		{"database", s.postgres.Ping},
+	}
+
+	dependencies := make([]dependencyHealth, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dependencies[i] = s.checkDependency(c.Request.Context(), check.name, check.ping)
+		}()
+	}
+	wg.Wait()
+
+	reasons := make([]degradation, 0)
+	unavailable := false
+	if s.draining.Load() {
+		unavailable = true
+		reasons = append(reasons, degradation{Code: reasonDraining, Component: "gateway"})
+	}
+	for _, dep := range dependencies {
+		switch {
+		case !dep.Healthy:
+			unavailable = true
+			reasons = append(reasons, degradation{Code: reasonDependencyDown, Component: dep.Name, Detail: dep.Error})
+		case dep.LatencyMs > float64(slowDependency.Milliseconds()):
+			reasons = append(reasons, degradation{Code: reasonDependencySlow, Component: dep.Name,
+				Detail: fmt.Sprintf("%.1fms", dep.LatencyMs)})
+		}
+	}
+
+	services, serviceReasons := s.servicesHealth()
+	reasons = append(reasons, serviceReasons...)
+
+	status := "healthy"
+	statusCode := http.StatusOK
+	switch {
+	case unavailable:
+		status = "unhealthy"
+		statusCode = http.StatusServiceUnavailable
+	case len(reasons) > 0:
+		status = "degraded"
+	}
+
+	c.JSON(statusCode, gin.H{
+		"status":       status,
+		"timestamp":    time.Now().Unix(),
+		"uptime":       time.Since(startTime).Seconds(),
+		"dependencies": dependencies,
+		"services":     services,
+		"reasons":      reasons,
+	})
+}
+
+// Summarizes each service's targets as its health checker and outlier
+// detection see them
+func (s *Server) servicesHealth() (map[string]serviceHealth, []degradation) {
+	s.routesMu.RLock()
+	proxies := s.proxies
+	s.routesMu.RUnlock()
+
+	services := make(map[string]serviceHealth, len(proxies))
+	reasons := make([]degradation, 0)
+	if len(proxies) == 0 {
+		reasons = append(reasons, degradation{Code: reasonNoServices, Component: "gateway"})
+	}
+
+	paths := make([]string, 0, len(proxies))
+	for path := range proxies {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		p := proxies[path]
+		ejected := make(map[string]bool)
+		for _, target := range p.EjectedTargets() {
+			ejected[target.Target] = true
+		}
+
+		health, breaker := p.OverallHealth(), p.CircuitBreakerState()
+		statuses := p.GetHealthStatus()
+		svc := serviceHealth{
+			Status:         health.String(),
+			CircuitBreaker: breaker.String(),
+			HealthyTargets: len(p.GetHealthyTargets()),
+			TotalTargets:   len(p.GetAllTargets()),
+			Targets:        make([]targetHealth, 0, len(statuses)),
+		}
+		for _, status := range statuses {
+			target := targetHealth{
+				Target:       status.Target,
+				Healthy:      status.IsHealthy,
+				Ejected:      ejected[status.Target],
+				FailureCount: status.FailureCount,
+				LastCheck:    optionalTime(status.LastCheck),
+				LastSuccess:  optionalTime(status.LastSuccess),
+			}
+			if target.LastSuccess != nil && (svc.LastSuccess == nil || target.LastSuccess.After(*svc.LastSuccess)) {
+				svc.LastSuccess = target.LastSuccess
+			}
+			svc.Targets = append(svc.Targets, target)
+		}
+		sort.Slice(svc.Targets, func(i, j int) bool { return svc.Targets[i].Target < svc.Targets[j].Target })
+		services[path] = svc
+
+		switch health {
+		case healthcheck.Unhealthy:
+			reasons = append(reasons, degradation{Code: reasonServiceUnavailable, Component: path})
+		case healthcheck.Degraded:
+			reasons = append(reasons, degradation{Code: reasonServiceDegraded, Component: path,
+				Detail: fmt.Sprintf("%d of %d targets healthy", svc.HealthyTargets, svc.TotalTargets)})
+		}
+		switch breaker {
+		case circuitbreaker.StateOpen:
+			reasons = append(reasons, degradation{Code: reasonCircuitOpen, Component: path})
+		case circuitbreaker.StateHalfOpen:
+			reasons = append(reasons, degradation{Code: reasonCircuitHalfOpen, Component: path})
+		}
+		if len(ejected) > 0 {
+			reasons = append(reasons, degradation{Code: reasonTargetsEjected, Component: path,
+				Detail: fmt.Sprintf("%d targets ejected", len(ejected))})
+		}
+	}
+	return services, reasons
+}
+
+// Omits times that were never set
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/aman-churiwal/api-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// Lets POST /admin/config/reload re-read the configuration the gateway was
+// started with. Only services and rate limit tiers are applied; other
+// settings still take a restart.
+func (s *Server) SetConfigLoader(load func() (*config.Config, error)) {
+	s.routesMu.Lock()
+	defer s.routesMu.Unlock()
+
+	s.loadConfig = load
+}
+
+// Handles POST /admin/config/reload
+func (s *Server) reloadConfig(c *gin.Context) {
+	s.routesMu.RLock()
+	load := s.loadConfig
+	s.routesMu.RUnlock()
+
+	if load == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Config reload is not available"})
+		return
+	}
+	if s.catalog != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Services and tiers are managed by the catalog and reload as it changes"})
+		return
+	}
+
+	next, err := load()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changed := s.swapServices(next.Services, next.RateLimitTiers)
+	if changed {
+		log.Printf("Reloaded config: %d services, %d tiers", len(next.Services), len(next.RateLimitTiers))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"changed":  changed,
+		"services": len(next.Services),
+		"tiers":    len(next.RateLimitTiers),
+	})
+}
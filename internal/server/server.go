@@ -2,194 +2,1013 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/netip"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/aman-churiwal/api-gateway/internal/accesslog"
+	"github.com/aman-churiwal/api-gateway/internal/alerting"
+	"github.com/aman-churiwal/api-gateway/internal/asyncjobs"
+	"github.com/aman-churiwal/api-gateway/internal/bodyscan"
+	"github.com/aman-churiwal/api-gateway/internal/bots"
+	"github.com/aman-churiwal/api-gateway/internal/cache"
+	"github.com/aman-churiwal/api-gateway/internal/capture"
+	"github.com/aman-churiwal/api-gateway/internal/catalog"
 	"github.com/aman-churiwal/api-gateway/internal/circuitbreaker"
 	"github.com/aman-churiwal/api-gateway/internal/config"
+	"github.com/aman-churiwal/api-gateway/internal/cors"
+	"github.com/aman-churiwal/api-gateway/internal/darklaunch"
+	"github.com/aman-churiwal/api-gateway/internal/discovery"
+	"github.com/aman-churiwal/api-gateway/internal/faults"
+	"github.com/aman-churiwal/api-gateway/internal/forwardauth"
+	"github.com/aman-churiwal/api-gateway/internal/geoip"
 	"github.com/aman-churiwal/api-gateway/internal/handler"
 	"github.com/aman-churiwal/api-gateway/internal/healthcheck"
+	"github.com/aman-churiwal/api-gateway/internal/introspection"
+	"github.com/aman-churiwal/api-gateway/internal/ipfilter"
+	"github.com/aman-churiwal/api-gateway/internal/jwtkeys"
+	"github.com/aman-churiwal/api-gateway/internal/livemetrics"
+	"github.com/aman-churiwal/api-gateway/internal/maintenance"
+	"github.com/aman-churiwal/api-gateway/internal/messages"
 	"github.com/aman-churiwal/api-gateway/internal/middleware"
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/oidc"
 	"github.com/aman-churiwal/api-gateway/internal/proxy"
+	"github.com/aman-churiwal/api-gateway/internal/ratelimit"
 	"github.com/aman-churiwal/api-gateway/internal/repository"
+	"github.com/aman-churiwal/api-gateway/internal/routing"
 	"github.com/aman-churiwal/api-gateway/internal/service"
 	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/aman-churiwal/api-gateway/internal/stubs"
+	"github.com/aman-churiwal/api-gateway/internal/toggles"
+	"github.com/aman-churiwal/api-gateway/internal/tokenexchange"
+	"github.com/aman-churiwal/api-gateway/internal/vault"
+	"github.com/aman-churiwal/api-gateway/internal/webhook"
 	"github.com/gin-gonic/gin"
 )
 
 type Server struct {
-	router           *gin.Engine
-	config           *config.Config
-	redis            *storage.RedisClient
-	postgres         *storage.Postgres
-	proxies          map[string]*proxy.Proxy
-	apiKeyService    *service.APIKeyService
-	apiKeyHandler    *handler.APIKeyHandler
-	authService      *service.AuthService
-	authHandler      *handler.AuthHandler
-	systemHandler    *handler.SystemHandler
-	analyticsService *service.AnalyticsService
-	analyticsHandler *handler.AnalyticsHandler
-	httpServer       *http.Server
-}
-
-func New(cfg *config.Config, redis *storage.RedisClient, postgres *storage.Postgres) *Server {
+	routesMu              sync.RWMutex // Guards the router, proxies, fast paths, services and tiers
+	router                *gin.Engine
+	adminRouter           *gin.Engine // Management routes when they have their own listener
+	config                *config.Config
+	redis                 *storage.RedisClient
+	postgres              *storage.Postgres
+	proxies               map[string]*proxy.Proxy
+	catalog               *catalog.Watcher // Rebuilds the routing table on catalog changes
+	kubernetes            *discovery.Kubernetes
+	apiKeyService         *service.APIKeyService
+	apiKeyHandler         *handler.APIKeyHandler
+	authService           *service.AuthService
+	authHandler           *handler.AuthHandler
+	systemHandler         *handler.SystemHandler
+	analyticsService      *service.AnalyticsService
+	analyticsHandler      *handler.AnalyticsHandler
+	organizationService   *service.OrganizationService
+	organizationHandler   *handler.OrganizationHandler
+	deadLetterService     *service.DeadLetterService
+	deadLetterHandler     *handler.DeadLetterHandler
+	httpServer            *http.Server
+	adminServers          []*http.Server
+	accessLog             *accesslog.Dispatcher
+	liveMetrics           *livemetrics.Collector
+	alerts                *alerting.Monitor
+	webhooks              *webhook.Dispatcher
+	fastPaths             map[string]bool // Services served outside gin
+	draining              atomic.Bool
+	dependencySuccess     sync.Map      // Dependency name -> time of its last successful health check
+	shuttingDown          chan struct{} // Closed by Shutdown to end streaming responses
+	tokenExchangers       map[string]tokenexchange.Exchanger
+	messages              *messages.Catalog
+	toggles               *toggles.Registry
+	toggleHandler         *handler.ToggleHandler
+	bodyCapture           *capture.Registry
+	bodyCaptureHandler    *handler.BodyCaptureHandler
+	stubs                 *stubs.Registry
+	auditLogRepo          *repository.AuditLogRepository
+	auditHandler          *handler.AuditHandler
+	complianceHandler     *handler.ComplianceHandler
+	serviceAccounts       *service.ServiceAccountService
+	serviceAccountHandler *handler.ServiceAccountHandler
+	stubHandler           *handler.StubHandler
+	cacheStore            *cache.Store
+	cacheWarmer           *cache.Warmer
+	asyncJobs             *asyncjobs.Queue
+	cacheHandler          *handler.CacheHandler
+	staleKeyService       *service.StaleKeyService
+	usageReports          *service.UsageReportService
+	usageReportHandler    *handler.UsageReportHandler
+	staleKeyHandler       *handler.StaleKeyHandler
+	loginThrottleHandler  *handler.LoginThrottleHandler
+	developers            *service.DeveloperService // Nil unless the portal is enabled
+	portalHandler         *handler.PortalHandler
+	oauth                 *service.OAuthService // Nil unless the client-credentials grant is enabled
+	oauthHandler          *handler.OAuthHandler
+	debugHandler          *handler.DebugHandler
+	oidcHandler           *handler.OIDCHandler
+	limiters              ratelimit.Factory
+	loadConfig            func() (*config.Config, error) // Set by SetConfigLoader
+
+	maintenance        *maintenance.Registry
+	maintenanceHandler *handler.MaintenanceHandler
+	faults             *faults.Registry
+	ipFilter           *ipfilter.Registry
+	globalIPs          *ipfilter.List // Configured global lists, which the fast path checks itself
+	trustedProxies     []netip.Prefix // Parsed server.trusted_proxies, for the fast path
+	geoip              *geoip.Reader  // Nil without geoip.database
+	corsOverrides      *cors.Registry
+	corsHandler        *handler.CORSHandler
+	ipFilterHandler    *handler.IPFilterHandler
+	faultHandler       *handler.FaultHandler
+}
+
+// How long Shutdown waits for buffered request logs to reach their sinks
+const logFlushTimeout = 10 * time.Second
+
+// Paths served regardless of proxy load
+var defaultPriorityPaths = []string{"/health", "/readyz", "/admin"}
+
+// Admin routes organization members may use; their handlers limit results to
+// the caller's organization. Everything else manages the whole gateway.
+var orgScopedRoutes = []string{
+	"/admin/keys",
+	"/admin/keys/:id",
+	"/admin/keys/:id/rotate",
+	"/admin/keys/:id/transfer",
+	"/admin/invitations",
+	"/admin/invitations/:id",
+	"/admin/organizations/:id",
+	"/admin/organizations/:id/usage",
+	"/admin/analytics/organizations",
+	"/admin/analytics/keys/:id",
+	"/admin/reports/usage",
+}
+
+// kv is only set when the embedded storage backend is configured
+func New(cfg *config.Config, redis *storage.RedisClient, postgres *storage.Postgres, kv *storage.EmbeddedKV) *Server {
 	if cfg.Server.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	router := gin.New()
+	// Services and tiers shared through the catalog replace the file's
+	fileConfig := *cfg
+	var catalogSource catalog.Source
+	var catalogVersion uint64
+	if cfg.Catalog != nil {
+		catalogSource, catalogVersion = loadCatalog(cfg)
+	}
 
 	// Initialize repositories
 	apiKeyRepo := repository.NewAPIKeyRepository(postgres)
 	authRepo := repository.NewUserRepository(postgres)
-	requestLogRepo := repository.NewRequestLogRepository(postgres)
+	requestLogRepo, err := newRequestLogStore(cfg, postgres)
+	if err != nil {
+		log.Fatalf("Failed to set up %s analytics storage: %v", cfg.Analytics.Backend, err)
+	}
+	refreshTokenRepo := repository.NewRefreshTokenRepository(postgres)
+	invitationRepo := repository.NewInvitationRepository(postgres)
+	passwordResetRepo := repository.NewPasswordResetRepository(postgres)
+	organizationRepo := repository.NewOrganizationRepository(postgres)
+
+	// Initialize webhook notifications
+	endpoints := make([]webhook.Endpoint, 0, len(cfg.Webhooks))
+	for _, hook := range cfg.Webhooks {
+		endpoints = append(endpoints, webhook.Endpoint{
+			URL:    hook.URL,
+			Secret: hook.Secret,
+			Events: hook.Events,
+		})
+	}
+	webhooks := webhook.NewDispatcher(endpoints, cfg.Resources.WebhookBufferSize)
+
+	// Asymmetric JWT signing keys
+	var jwtKeys *jwtkeys.KeySet
+	if len(cfg.JWT.Keys) > 0 {
+		keyConfigs := make([]jwtkeys.KeyConfig, 0, len(cfg.JWT.Keys))
+		for _, key := range cfg.JWT.Keys {
+			keyConfigs = append(keyConfigs, jwtkeys.KeyConfig{
+				ID:             key.ID,
+				Algorithm:      key.Algorithm,
+				PrivateKeyFile: key.PrivateKeyFile,
+			})
+		}
+
+		var err error
+		jwtKeys, err = jwtkeys.Load(keyConfigs, cfg.JWT.SigningKeyID)
+		if err != nil {
+			log.Fatalf("Failed to load JWT signing keys: %v", err)
+		}
+		log.Printf("Signing JWTs with %d key(s) (%s)", len(keyConfigs), strings.Join(jwtKeys.Algorithms(), ", "))
+	}
 
 	// Initialize services
-	apiKeyService := service.NewAPIKeyService(postgres, apiKeyRepo, redis)
-	authService := service.NewAuthService(authRepo, cfg.JWT.Secret, cfg.JWT.ExpiryHours)
-	analyticsService := service.NewAnalyticsService(postgres, requestLogRepo)
+	apiKeyService := service.NewAPIKeyService(postgres, apiKeyRepo, redis, webhooks)
+	apiKeyService.SetDegradationPolicy(service.DegradationPolicy{
+		Mode:         cfg.KeyValidation.Degradation,
+		StaleFor:     time.Duration(cfg.KeyValidation.StaleCacheMinutes) * time.Minute,
+		RecentlySeen: time.Duration(cfg.KeyValidation.RecentlySeenMinutes) * time.Minute,
+	})
+	apiKeyService.SetSigningSecret(cfg.KeyValidation.SigningSecret)
+	// Failed login backoff and lockout
+	var loginThrottle *service.LoginThrottle
+	if lt := cfg.Auth.LoginThrottle; !lt.Disabled {
+		loginThrottle = service.NewLoginThrottle(redis, repository.NewLoginAttemptRepository(postgres), webhooks, service.LoginThrottlePolicy{
+			MaxAccountFailures: lt.MaxAccountFailures,
+			MaxIPFailures:      lt.MaxIPFailures,
+			Window:             time.Duration(lt.WindowMinutes) * time.Minute,
+			BaseDelay:          time.Duration(lt.BaseDelaySeconds) * time.Second,
+			MaxDelay:           time.Duration(lt.MaxDelaySeconds) * time.Second,
+			Lockout:            time.Duration(lt.LockoutMinutes) * time.Minute,
+		})
+	}
+
+	authService := service.NewAuthService(authRepo, refreshTokenRepo, invitationRepo, passwordResetRepo, redis, webhooks, service.AuthSettings{
+		JWTSecret:           cfg.JWT.Secret,
+		Keys:                jwtKeys,
+		AccessExpiry:        cfg.JWT.AccessExpiry(),
+		RefreshExpiry:       cfg.JWT.RefreshExpiry(),
+		Registration:        cfg.Auth.Registration,
+		InvitationExpiry:    cfg.Auth.InvitationExpiry(),
+		PasswordResetExpiry: cfg.Auth.PasswordResetExpiry(),
+		LoginThrottle:       loginThrottle,
+		ExchangeIssuer:      cfg.TokenExchange.Issuer,
+	})
+	analyticsService := service.NewAnalyticsService(postgres, requestLogRepo, organizationRepo)
+
+	// Rate limit counters live in Redis unless the embedded store is configured
+	limiters := ratelimit.RedisFactory(redis)
+	if kv != nil {
+		limiters = ratelimit.EmbeddedFactory(kv)
+		log.Printf("Using embedded store for rate limiting and caching")
+	}
+	organizationService := service.NewOrganizationService(organizationRepo, authService, limiters)
 
 	// Initialize handlers
-	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
-	authHandler := handler.NewAuthHandler(authService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, organizationService)
+	authHandler := handler.NewAuthHandler(authService, organizationService)
 	analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
 
 	s := &Server{
-		router:           router,
-		config:           cfg,
-		redis:            redis,
-		postgres:         postgres,
-		proxies:          make(map[string]*proxy.Proxy),
-		apiKeyService:    apiKeyService,
-		apiKeyHandler:    apiKeyHandler,
-		authService:      authService,
-		authHandler:      authHandler,
-		analyticsService: analyticsService,
-		analyticsHandler: analyticsHandler,
+		config:              cfg,
+		redis:               redis,
+		postgres:            postgres,
+		proxies:             make(map[string]*proxy.Proxy),
+		shuttingDown:        make(chan struct{}),
+		apiKeyService:       apiKeyService,
+		apiKeyHandler:       apiKeyHandler,
+		authService:         authService,
+		authHandler:         authHandler,
+		analyticsService:    analyticsService,
+		analyticsHandler:    analyticsHandler,
+		organizationService: organizationService,
+		organizationHandler: handler.NewOrganizationHandler(organizationService, analyticsService),
+		debugHandler:        handler.NewDebugHandler(),
+		webhooks:            webhooks,
+		limiters:            limiters,
+	}
+	if loginThrottle != nil {
+		s.loginThrottleHandler = handler.NewLoginThrottleHandler(loginThrottle)
 	}
 
+	// Aggregate request limits of organizations
+	s.organizationService.Start()
+
 	// Initialize proxies for each configured service
 	s.initializeProxies()
 
 	// Initialize system handler after proxies are created
 	s.systemHandler = handler.NewSystemHandler(s.proxies)
 
+	// Dead letters are redriven through the proxies they were captured on
+	s.deadLetterService = service.NewDeadLetterService(repository.NewDeadLetterRepository(postgres), redriversFor(s.proxies))
+	s.deadLetterHandler = handler.NewDeadLetterHandler(s.deadLetterService)
+
+	// Response cache and warming
+	s.cacheStore = cache.NewStore(redis, cfg.Resources.CacheMaxEntryBytes)
+	if kv != nil {
+		s.cacheStore = cache.NewEmbeddedStore(kv, cfg.Resources.CacheMaxEntryBytes)
+	}
+	s.cacheWarmer = cache.NewWarmer(s.cacheStore, s.fetchForCache, cfg.Resources.CacheWarmWorkers)
+	s.cacheHandler = handler.NewCacheHandler(s.cacheStore, s.cacheWarmer)
+
+	// Queued async requests; workers start with the first service using them
+	s.asyncJobs = asyncjobs.NewQueue(redis, asyncjobs.Config{
+		Workers:        cfg.AsyncQueue.Workers,
+		ResultTTL:      time.Duration(cfg.AsyncQueue.ResultTTLSeconds) * time.Second,
+		Timeout:        time.Duration(cfg.AsyncQueue.TimeoutSeconds) * time.Second,
+		CallbackHosts:  cfg.AsyncQueue.CallbackHosts,
+		CallbackSecret: cfg.AsyncQueue.CallbackSecret,
+	}, s.forwardAsyncJob)
+
+	// Admin single sign-on
+	if o := cfg.OIDC; o != nil && o.Enabled {
+		provider := oidc.NewProvider(oidc.Config{
+			Issuer:       o.Issuer,
+			ClientID:     o.ClientID,
+			ClientSecret: o.ClientSecret,
+			RedirectURL:  o.RedirectURL,
+			Scopes:       o.Scopes,
+			GroupsClaim:  o.GroupsClaim,
+			RoleMapping:  o.RoleMapping,
+			DefaultRole:  o.DefaultRole,
+		})
+		s.oidcHandler = handler.NewOIDCHandler(service.NewOIDCService(provider, authService, redis))
+		log.Printf("OIDC login enabled (issuer: %s)", o.Issuer)
+	}
+
+	// Stale API key detection
+	s.staleKeyService = service.NewStaleKeyService(apiKeyService, apiKeyRepo, webhooks, service.StaleKeyPolicy{
+		UnusedFor:      time.Duration(cfg.StaleKeys.UnusedDays) * 24 * time.Hour,
+		GracePeriod:    time.Duration(cfg.StaleKeys.GraceDays) * 24 * time.Hour,
+		AutoDeactivate: cfg.StaleKeys.AutoDeactivate,
+		CheckInterval:  time.Duration(cfg.StaleKeys.CheckIntervalMinutes) * time.Minute,
+	})
+	s.staleKeyHandler = handler.NewStaleKeyHandler(s.staleKeyService)
+	if cfg.StaleKeys.Enabled {
+		s.staleKeyService.Start()
+	}
+
+	// Monthly usage per API key
+	s.usageReports = service.NewUsageReportService(requestLogRepo, repository.NewUsageReportRepository(postgres),
+		time.Duration(cfg.UsageReports.IntervalMinutes)*time.Minute)
+	s.usageReportHandler = handler.NewUsageReportHandler(s.usageReports)
+	if cfg.UsageReports.Enabled {
+		s.usageReports.Start()
+	}
+
+	// Developer self-service portal
+	if cfg.Portal.Enabled {
+		// Developers share the login policy, but not failure counts with users of the same email
+		var portalThrottle *service.LoginThrottle
+		if loginThrottle != nil {
+			portalThrottle = loginThrottle.WithNamespace("portal")
+		}
+		s.developers = service.NewDeveloperService(repository.NewDeveloperRepository(postgres), apiKeyService, apiKeyRepo,
+			requestLogRepo, analyticsService, authService, portalThrottle, limiters, cfg.RateLimitTiers, cfg.Portal)
+		s.portalHandler = handler.NewPortalHandler(s.developers, authService)
+	}
+
+	// API keys exchanged for JWTs backends verify on their own
+	if cfg.OAuth.Enabled {
+		s.oauth = service.NewOAuthService(apiKeyService, jwtKeys, cfg.OAuth)
+		s.oauthHandler = handler.NewOAuthHandler(s.oauth)
+	}
+
 	// Initialize request logger
-	middleware.InitRequestLogger(postgres, 1000)
+	accessLog, err := newAccessLog(cfg, requestLogRepo)
+	if err != nil {
+		log.Fatalf("Failed to set up access log sinks: %v", err)
+	}
+	s.accessLog = accessLog
+	s.liveMetrics = livemetrics.NewCollector()
+	var sampler *accesslog.Sampler
+	if sampling := cfg.AccessLog.Sampling; sampling != nil {
+		sampler = &accesslog.Sampler{
+			Rate:            sampling.Rate,
+			ClientErrorRate: sampling.ClientErrorRate,
+			ServerErrorRate: sampling.ServerErrorRate,
+			Slow:            time.Duration(sampling.SlowMs) * time.Millisecond,
+		}
+		log.Printf("Request log sampling enabled (rate: %g, slow: %dms)", sampling.Rate, sampling.SlowMs)
+	}
+	middleware.InitRequestLogger(accessLog, sampler)
+
+	// Traffic anomaly alerts, evaluated over the live metrics
+	s.alerts = newAlertMonitor(cfg.Alerts, s.liveMetrics, webhooks)
+	if cfg.Alerts.Enabled {
+		s.alerts.Start()
+	}
+
+	// User-facing messages, localized by Accept-Language
+	catalog, err := messages.NewCatalog(cfg.Messages.DefaultLocale, cfg.Messages.Templates)
+	if err != nil {
+		log.Printf("Invalid message templates, using built-in messages: %v", err)
+		catalog, _ = messages.NewCatalog(cfg.Messages.DefaultLocale, nil)
+	}
+	s.messages = catalog
+
+	// Runtime middleware toggles, shared with other replicas through Redis
+	s.toggles = toggles.NewRegistry(redis, 5*time.Second)
+	s.toggleHandler = handler.NewToggleHandler(s.toggles)
+
+	// Audit log of mutating admin operations
+	s.auditLogRepo = repository.NewAuditLogRepository(postgres)
+	s.auditHandler = handler.NewAuditHandler(service.NewAuditService(s.auditLogRepo))
+	s.complianceHandler = handler.NewComplianceHandler(service.NewComplianceService(authRepo, apiKeyRepo, s.auditLogRepo))
+	s.serviceAccounts = service.NewServiceAccountService(repository.NewServiceAccountRepository(postgres))
+	s.serviceAccountHandler = handler.NewServiceAccountHandler(s.serviceAccounts)
+
+	// Admin-managed response stubs, shared with other replicas through Redis
+	s.stubs = stubs.NewRegistry(redis, 5*time.Second)
+	s.stubHandler = handler.NewStubHandler(s.stubs)
+	s.stubs.Start()
+
+	// Maintenance windows, shared with other replicas through Redis
+	s.maintenance = maintenance.NewRegistry(redis, 5*time.Second)
+	s.maintenanceHandler = handler.NewMaintenanceHandler(s.maintenance)
+	s.maintenance.Start()
+
+	// Fault injection rules for resilience tests, shared with other replicas through Redis
+	s.faults = faults.NewRegistry(redis, 5*time.Second)
+	s.faultHandler = handler.NewFaultHandler(s.faults)
+	s.faults.Start()
+
+	// IP allow and deny rules added at runtime, shared with other replicas through Redis
+	s.ipFilter = ipfilter.NewRegistry(redis, 5*time.Second)
+	s.ipFilterHandler = handler.NewIPFilterHandler(s.ipFilter)
+	s.ipFilter.Start()
+
+	// CORS policies set per service at runtime, shared with other replicas through Redis
+	s.corsOverrides = cors.NewRegistry(redis, 5*time.Second)
+	s.corsHandler = handler.NewCORSHandler(s.corsOverrides)
+	s.corsOverrides.Start()
+
+	// Country lookups for geo rules and analytics
+	if cfg.GeoIP != nil && cfg.GeoIP.Database != "" {
+		reader, err := geoip.Open(cfg.GeoIP.Database)
+		if err != nil {
+			log.Fatalf("Failed to load GeoIP database: %v", err)
+		}
+		s.geoip = reader
+		log.Printf("Loaded GeoIP database %s", cfg.GeoIP.Database)
+	}
+
+	// Debug body capture, switched on per service at runtime
+	s.bodyCapture = capture.NewRegistry(redis, 5*time.Second)
+	s.bodyCaptureHandler = handler.NewBodyCaptureHandler(s.bodyCapture, captureServices(cfg.Services))
+	s.bodyCapture.Start()
 
 	// Setup middleware
-	s.setupMiddleware()
+	s.newRouters()
+	s.toggles.Start()
 
 	// Setup routes
 	s.setupRoutes()
 
+	if catalogSource != nil {
+		s.watchCatalog(catalogSource, catalogVersion, &fileConfig)
+	}
+
 	return s
 }
 
 // Creates proxy instances for each configured backend service
 func (s *Server) initializeProxies() {
 	for _, svc := range s.config.Services {
-		if len(svc.Targets) == 0 {
+		if p := s.newServiceProxy(svc); p != nil {
+			s.proxies[svc.Path] = p
+		}
+	}
+}
+
+// Creates the proxy for a backend service, or returns nil if it can't be served
+func (s *Server) newServiceProxy(svc config.ServiceConfig) *proxy.Proxy {
+	if bg := svc.BlueGreen; bg != nil {
+		svc.Targets = slices.Concat(bg.Blue, bg.Green)
+	}
+	if len(svc.Targets) == 0 && svc.Kubernetes == nil {
+		if len(svc.Mocks) == 0 {
 			log.Printf("Warning: Service %s has no targets configured", svc.Path)
-			continue
 		}
+		return nil
+	}
+
+	// Build proxy config
+	proxyCfg := proxy.Config{
+		Targets:              svc.Targets,
+		LoadBalancerStrategy: svc.LoadBalancer,
+	}
+
+	// Circuit breaker config
+	if svc.CircuitBreaker != nil {
+		proxyCfg.CircuitBreaker = circuitbreaker.Config{
+			MaxFailures:     svc.CircuitBreaker.MaxFailures,
+			Timeout:         time.Duration(svc.CircuitBreaker.TimeoutSeconds) * time.Second,
+			HalfOpenSuccess: svc.CircuitBreaker.HalfOpenSuccess,
+		}
+	} else {
+		proxyCfg.CircuitBreaker = circuitbreaker.Config{
+			MaxFailures:     5,
+			Timeout:         30 * time.Second,
+			HalfOpenSuccess: 1,
+		}
+	}
+
+	// Health check config
+	if svc.HealthCheck != nil {
+		proxyCfg.HealthCheck = healthcheck.Config{
+			Targets:     svc.Targets,
+			Endpoint:    svc.HealthCheck.Endpoint,
+			Interval:    time.Duration(svc.HealthCheck.IntervalSeconds) * time.Second,
+			Timeout:     time.Duration(svc.HealthCheck.TimeoutSeconds) * time.Second,
+			MaxFailures: svc.HealthCheck.MaxFailures,
+			Concurrency: s.config.Resources.HealthCheckConcurrency,
+		}
+	} else {
+		proxyCfg.HealthCheck = healthcheck.Config{
+			Targets:     svc.Targets,
+			Endpoint:    "/health",
+			Interval:    10 * time.Second,
+			Timeout:     5 * time.Second,
+			MaxFailures: 3,
+			Concurrency: s.config.Resources.HealthCheckConcurrency,
+		}
+	}
+
+	// Breaker transitions and health changes are published as webhook events
+	proxyCfg.CircuitBreaker.OnStateChange = func(from, to circuitbreaker.State) {
+		s.webhooks.Dispatch(webhook.EventCircuitBreakerStateChanged, map[string]interface{}{
+			"service": svc.Path,
+			"from":    from.String(),
+			"to":      to.String(),
+		})
+	}
+	proxyCfg.HealthCheck.OnChange = func(target string, healthy bool) {
+		event := webhook.EventTargetUnhealthy
+		if healthy {
+			event = webhook.EventTargetHealthy
+		}
+		s.webhooks.Dispatch(event, map[string]interface{}{
+			"service": svc.Path,
+			"target":  target,
+		})
+	}
+
+	// Passive ejection of misbehaving targets
+	if od := svc.Outliers; od != nil && od.Enabled {
+		proxyCfg.Outliers = proxy.OutlierConfig{
+			Enabled:           true,
+			ConsecutiveErrors: od.ConsecutiveErrors,
+			LatencyFactor:     od.LatencyFactor,
+			MinRequests:       od.MinRequests,
+			Interval:          time.Duration(od.IntervalSeconds) * time.Second,
+			BaseEjection:      time.Duration(od.BaseEjectionSeconds) * time.Second,
+			MaxEjection:       time.Duration(od.MaxEjectionSeconds) * time.Second,
+			MaxEjectedPercent: od.MaxEjectionPercent,
+		}
+	}
+
+	// Long-lived connection config
+	if svc.LongLived != nil {
+		proxyCfg.LongLived = proxy.LongLivedConfig{
+			MaxPerKey:     svc.LongLived.MaxPerKey,
+			LongPollPaths: svc.LongLived.LongPollPaths,
+		}
+	}
 
-		// Build proxy config
-		proxyCfg := proxy.Config{
-			Targets:              svc.Targets,
-			LoadBalancerStrategy: svc.LoadBalancer,
+	// Backend rate limit header config
+	if ul := svc.UpstreamLimit; ul != nil && ul.Enabled {
+		proxyCfg.UpstreamLimit = proxy.UpstreamLimitConfig{
+			Enabled:    true,
+			Queue:      ul.Mode == "queue",
+			MaxWait:    time.Duration(ul.MaxWaitMs) * time.Millisecond,
+			MaxBackoff: time.Duration(ul.MaxBackoffSeconds) * time.Second,
 		}
+	}
 
-		// Circuit breaker config
-		if svc.CircuitBreaker != nil {
-			proxyCfg.CircuitBreaker = circuitbreaker.Config{
-				MaxFailures:     svc.CircuitBreaker.MaxFailures,
-				Timeout:         time.Duration(svc.CircuitBreaker.TimeoutSeconds) * time.Second,
-				HalfOpenSuccess: svc.CircuitBreaker.HalfOpenSuccess,
+	// Backend protocol and connection pool config
+	if t := svc.Transport; t != nil {
+		proxyCfg.Transport = proxy.TransportConfig{
+			Protocol:            t.Protocol,
+			MaxIdleConnsPerHost: t.MaxIdleConnsPerHost,
+			MaxConnsPerHost:     t.MaxConnsPerHost,
+			IdleConnTimeout:     time.Duration(t.IdleConnTimeoutSeconds) * time.Second,
+			PingInterval:        time.Duration(t.PingIntervalSeconds) * time.Second,
+
+			DialTimeout:           time.Duration(t.DialTimeoutSeconds) * time.Second,
+			TLSHandshakeTimeout:   time.Duration(t.TLSHandshakeTimeoutSeconds) * time.Second,
+			ResponseHeaderTimeout: time.Duration(t.ResponseHeaderTimeoutSeconds) * time.Second,
+			DisableKeepAlives:     t.DisableKeepAlives,
+		}
+		if t.TLS != nil {
+			tlsConfig, err := newUpstreamTLSConfig(t.TLS)
+			if err != nil {
+				log.Printf("Failed to create proxy for %s: %v", svc.Path, err)
+				return nil
 			}
-		} else {
-			proxyCfg.CircuitBreaker = circuitbreaker.Config{
-				MaxFailures:     5,
-				Timeout:         30 * time.Second,
-				HalfOpenSuccess: 1,
+			proxyCfg.Transport.TLS = tlsConfig
+			if t.TLS.InsecureSkipVerify {
+				log.Printf("Warning: TLS verification of %s backends is disabled", svc.Path)
+			}
+			if t.TLS.CertFile != "" {
+				log.Printf("Mutual TLS to %s backends with %s", svc.Path, t.TLS.CertFile)
 			}
 		}
+	}
+
+	// Blue-green sets, all of which are health-checked
+	if bg := svc.BlueGreen; bg != nil {
+		proxyCfg.BlueGreen = proxy.BlueGreenConfig{
+			Blue:   bg.Blue,
+			Green:  bg.Green,
+			Active: bg.Active,
+		}
+		log.Printf("Blue-green targets for %s (active: %s)", svc.Path, bg.Active)
+	}
+
+	// Each experiment gets a target group named after it
+	if len(svc.Experiments) > 0 {
+		proxyCfg.TargetGroups = make(map[string][]string, len(svc.Experiments))
+		for _, exp := range svc.Experiments {
+			proxyCfg.TargetGroups[exp.Name] = exp.Targets
+		}
+	}
 
-		// Health check config
-		if svc.HealthCheck != nil {
-			proxyCfg.HealthCheck = healthcheck.Config{
-				Targets:     svc.Targets,
-				Endpoint:    svc.HealthCheck.Endpoint,
-				Interval:    time.Duration(svc.HealthCheck.IntervalSeconds) * time.Second,
-				Timeout:     time.Duration(svc.HealthCheck.TimeoutSeconds) * time.Second,
-				MaxFailures: svc.HealthCheck.MaxFailures,
+	// And so does each API version with its own targets
+	if v := svc.Versions; v != nil {
+		for _, version := range v.Versions {
+			if len(version.Targets) == 0 {
+				continue
 			}
-		} else {
-			proxyCfg.HealthCheck = healthcheck.Config{
-				Targets:     svc.Targets,
-				Endpoint:    "/health",
-				Interval:    10 * time.Second,
-				Timeout:     5 * time.Second,
-				MaxFailures: 3,
+			if proxyCfg.TargetGroups == nil {
+				proxyCfg.TargetGroups = make(map[string][]string)
 			}
+			proxyCfg.TargetGroups[middleware.VersionGroup(version.Name)] = version.Targets
 		}
+	}
 
-		// Create proxy
-		p, err := proxy.NewWithConfig(proxyCfg)
+	// Canary split config
+	if cn := svc.Canary; cn != nil {
+		proxyCfg.Canary = proxy.CanaryConfig{
+			Targets: cn.Targets,
+			Percent: cn.Percent,
+		}
+		if ar := cn.AutoRollback; ar != nil {
+			proxyCfg.Canary.Rollback = proxy.CanaryRollbackConfig{
+				Enabled:     ar.Enabled,
+				Threshold:   ar.ErrorRateThreshold,
+				Window:      time.Duration(ar.WindowSeconds) * time.Second,
+				MinRequests: ar.MinRequests,
+			}
+		}
+		log.Printf("Canary for %s: %d targets at %.1f%%", svc.Path, len(cn.Targets), cn.Percent)
+	}
+
+	// Dead-letter capture config
+	if svc.DeadLetter != nil && svc.DeadLetter.Enabled {
+		servicePath := svc.Path
+		proxyCfg.DeadLetter = proxy.DeadLetterConfig{
+			Enabled:      true,
+			MaxBodyBytes: svc.DeadLetter.MaxBodyBytes,
+			Headers:      svc.DeadLetter.Headers,
+			Sink: func(deadLetter *models.DeadLetter) {
+				deadLetter.Service = servicePath
+				go s.deadLetterService.Capture(context.Background(), deadLetter)
+			},
+		}
+	}
+
+	// Targets discovered from Kubernetes replace any listed ones
+	if k := svc.Kubernetes; k != nil {
+		client, err := s.kubernetesClient()
 		if err != nil {
-			log.Printf("Failed to create proxy for %s: %v", svc.Path, err)
-			continue
+			log.Printf("Failed to set up Kubernetes discovery for %s: %v", svc.Path, err)
+			return nil
 		}
+		proxyCfg.Targets = nil
+		proxyCfg.HealthCheck.Targets = nil
+		proxyCfg.TargetSource = discovery.NewEndpointSliceSource(client, k.Namespace, k.Service, k.LabelSelector, k.Port, k.Scheme)
+		log.Printf("Discovering targets for %s from Kubernetes (namespace: %s, service: %s, selector: %s)", svc.Path, k.Namespace, k.Service, k.LabelSelector)
+	}
+
+	// srv:// targets are resolved on an interval alongside the listed ones
+	if proxyCfg.TargetSource == nil && slices.ContainsFunc(svc.Targets, discovery.IsSRVTarget) {
+		refresh, resolver := 30*time.Second, ""
+		if srv := svc.SRV; srv != nil {
+			refresh, resolver = time.Duration(srv.RefreshSeconds)*time.Second, srv.Resolver
+		}
+		source := discovery.NewSRVSource(svc.Targets, refresh, resolver)
+		proxyCfg.Targets = source.StaticTargets()
+		proxyCfg.HealthCheck.Targets = proxyCfg.Targets
+		proxyCfg.TargetSource = source
+		log.Printf("Resolving SRV targets for %s every %s", svc.Path, refresh)
+	}
+
+	// Create proxy
+	p, err := proxy.NewWithConfig(proxyCfg)
+	if err != nil {
+		log.Printf("Failed to create proxy for %s: %v", svc.Path, err)
+		return nil
+	}
+
+	log.Printf("Initialized proxy for %s with %d targets (strategy: %s)", svc.Path, len(svc.Targets), svc.LoadBalancer)
+	return p
+}
+
+// Returns the Kubernetes client shared by discovered services, connecting on first use
+func (s *Server) kubernetesClient() (*discovery.Kubernetes, error) {
+	if s.kubernetes != nil {
+		return s.kubernetes, nil
+	}
+
+	var apiServer, tokenFile, caFile string
+	var insecure bool
+	if k := s.config.Kubernetes; k != nil {
+		apiServer, tokenFile, caFile, insecure = k.APIServer, k.TokenFile, k.CAFile, k.InsecureSkipVerify
+	}
+
+	client, err := discovery.NewKubernetes(apiServer, tokenFile, caFile, insecure)
+	if err != nil {
+		return nil, err
+	}
+	s.kubernetes = client
+	return client, nil
+}
+
+// Creates the routers with their middleware, giving the management routes
+// their own when the admin listener is configured
+func (s *Server) newRouters() {
+	s.globalIPs = s.ipFilterList(s.config.IPFilter)
+	s.trustedProxies = nil
+	for _, entry := range s.config.Server.TrustedProxies {
+		// Validated with the config
+		prefix, _ := ipfilter.ParsePrefix(entry)
+		s.trustedProxies = append(s.trustedProxies, prefix)
+	}
 
-		s.proxies[svc.Path] = p
-		log.Printf("Initialized proxy for %s with %d targets (strategy: %s)", svc.Path, len(svc.Targets), svc.LoadBalancer)
+	s.router = gin.New()
+	s.setupMiddleware(s.router)
+
+	s.adminRouter = nil
+	if s.config.Server.Admin != nil {
+		s.adminRouter = gin.New()
+		s.setupMiddleware(s.adminRouter)
 	}
 }
 
 // Configures the middleware chain
-func (s *Server) setupMiddleware() {
-	s.router.Use(middleware.Recovery())
+func (s *Server) setupMiddleware(router *gin.Engine) {
+	// Client addresses drive IP filters, GeoIP rules and login lockouts, so
+	// forwarded headers are only believed from configured proxies
+	if err := router.SetTrustedProxies(s.config.Server.TrustedProxies); err != nil {
+		log.Printf("Invalid trusted proxies, trusting none: %v", err)
+		router.SetTrustedProxies(nil)
+	}
+
+	router.Use(middleware.Recovery())
+
+	router.Use(messages.Middleware(s.messages))
+
+	router.Use(middleware.RequestID(s.requestIDOptions()))
+
+	router.Use(middleware.IPFilter(s.ipFilter, ipfilter.GlobalScope, s.globalIPs))
+
+	if s.geoip != nil {
+		router.Use(middleware.GeoIP(s.geoip))
+	}
 
-	s.router.Use(middleware.RequestID())
+	if s.config.Server.MaxConcurrentRequests > 0 {
+		queueTimeout := time.Duration(s.config.Server.QueueTimeoutMs) * time.Millisecond
+		if queueTimeout <= 0 {
+			queueTimeout = 100 * time.Millisecond
+		}
+		priorityPaths := append(append([]string{}, defaultPriorityPaths...), s.config.Server.PriorityPaths...)
+		router.Use(middleware.Toggleable("concurrency_limit", s.toggles, middleware.ConcurrencyLimit(s.config.Server.MaxConcurrentRequests, queueTimeout, priorityPaths)))
+	}
+
+	router.Use(middleware.Toggleable("logger", s.toggles, middleware.Logger()))
+	router.Use(middleware.Toggleable("live_metrics", s.toggles, middleware.LiveMetrics(s.liveMetrics)))
+
+	if s.accessLog.Enabled() {
+		router.Use(middleware.Toggleable("request_logger", s.toggles, middleware.RequestLogger()))
+	}
+
+	router.Use(middleware.Toggleable("cors", s.toggles, s.newCORS()))
 
-	s.router.Use(middleware.Logger())
+	router.Use(middleware.SignatureValidator(s.apiKeyService, s.redis, 5*time.Minute))
 
-	s.router.Use(middleware.RequestLogger())
+	router.Use(middleware.APIKeyValidator(s.apiKeyService, s.oauth))
+
+	// Verified client certificates stand in for API keys
+	if t := s.config.Server.TLS; t != nil && t.ClientAuth != nil {
+		identities := make([]middleware.CertIdentity, 0, len(t.ClientAuth.Identities))
+		for _, identity := range t.ClientAuth.Identities {
+			identities = append(identities, middleware.CertIdentity(identity))
+		}
+		router.Use(middleware.ClientCertificate(identities, t.ClientAuth.DefaultTier))
+	}
 
-	s.router.Use(middleware.CORS())
+	// Tiers are read per request, so each router keeps the ones it was built with
+	tiers := &config.Config{RateLimitTiers: s.config.RateLimitTiers}
+	router.Use(middleware.Toggleable("rate_limit", s.toggles, middleware.RateLimitWithTier(s.limiters, tiers, s.webhooks)))
+	router.Use(middleware.Toggleable("rate_limit", s.toggles, middleware.OrgRateLimit(s.organizationService, s.webhooks)))
 
-	s.router.Use(middleware.APIKeyValidator(s.apiKeyService))
+	if len(s.config.DarkLaunch) > 0 {
+		router.Use(middleware.Toggleable("dark_launch", s.toggles, middleware.DarkLaunch(s.newDarkLaunchEngine())))
+	}
+}
 
-	s.router.Use(middleware.RateLimitWithTier(s.redis, s.config))
+// Parses configured allow and deny entries, which validation has already checked
+func (s *Server) ipFilterList(cfg *config.IPFilterConfig) *ipfilter.List {
+	if cfg == nil {
+		return nil
+	}
+	list, err := ipfilter.ParseList(cfg.Allow, cfg.Deny)
+	if err != nil {
+		log.Printf("Ignoring ip_filter: %v", err)
+		return nil
+	}
+	return list
+}
+
+// Builds the CORS middleware from the global policy and its route overrides
+func (s *Server) newCORS() gin.HandlerFunc {
+	defaultPolicy := middleware.DefaultCORSPolicy()
+	var routes []middleware.CORSRoute
+	if s.config.CORS != nil {
+		defaultPolicy = defaultPolicy.Merge(corsPolicy(s.config.CORS.CORSPolicyConfig))
+	}
+
+	// Service policies come first so they win over a route with the same prefix
+	for _, svc := range s.config.Services {
+		if svc.CORS != nil {
+			routes = append(routes, middleware.CORSRoute{
+				PathPrefix: svc.Path,
+				Policy:     defaultPolicy.Merge(corsPolicy(*svc.CORS)),
+			})
+		}
+	}
+	if s.config.CORS != nil {
+		for _, route := range s.config.CORS.Routes {
+			routes = append(routes, middleware.CORSRoute{
+				PathPrefix: route.PathPrefix,
+				Policy:     defaultPolicy.Merge(corsPolicy(route.CORSPolicyConfig)),
+			})
+		}
+	}
+
+	return middleware.CORSWithRoutes(defaultPolicy, routes, s.corsOverrides)
+}
+
+func (s *Server) requestIDOptions() middleware.RequestIDOptions {
+	opts := middleware.DefaultRequestIDOptions()
+	cfg := s.config.RequestID
+	if cfg == nil {
+		return opts
+	}
+
+	opts.Header = cfg.Header
+	opts.MaxLength = cfg.MaxLength
+	opts.RejectUntrusted = cfg.RejectUntrusted
+	if len(cfg.TrustedNetworks) > 0 {
+		opts.TrustAll = false
+		for _, entry := range cfg.TrustedNetworks {
+			// Validated with the config
+			prefix, _ := ipfilter.ParsePrefix(entry)
+			opts.TrustedNetworks = append(opts.TrustedNetworks, prefix)
+		}
+	}
+	return opts
+}
+
+func corsPolicy(cfg config.CORSPolicyConfig) cors.Policy {
+	return cors.Policy{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposeHeaders:    cfg.ExposeHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAgeSeconds:    cfg.MaxAgeSeconds,
+	}
+}
+
+// Builds the dark-launch rule engine from configuration
+func (s *Server) newDarkLaunchEngine() *darklaunch.Engine {
+	rules := make([]darklaunch.Rule, 0, len(s.config.DarkLaunch))
+	for _, rule := range s.config.DarkLaunch {
+		rules = append(rules, darklaunch.Rule{
+			Header:     rule.Header,
+			Value:      rule.Value,
+			Tiers:      rule.Tiers,
+			Services:   rule.Services,
+			Orgs:       rule.Orgs,
+			Percentage: rule.Percentage,
+		})
+	}
+
+	engine := darklaunch.NewEngine(rules)
+	log.Printf("Dark launch enabled with %d header rules", engine.Len())
+
+	return engine
 }
 
 // Configures all application routes
 func (s *Server) setupRoutes() {
+	management := s.router
+	if s.adminRouter != nil {
+		management = s.adminRouter
+	}
+
 	// Public routes
-	s.router.GET("/health", s.healthCheck)
+	management.GET("/health", s.healthCheck)
+	management.GET("/health/detail", s.healthDetail)
+	management.GET("/readyz", s.readinessCheck)
+
+	// Public keys for verifying gateway-issued tokens, which backends behind the proxy listener need
+	s.router.GET("/.well-known/jwks.json", s.authHandler.JWKS)
+
+	// Consumers poll requests queued by async services
+	s.router.GET("/async/jobs/:id", s.asyncJobStatus)
+
+	// Consumers discover services on the proxy listener
+	if s.config.APICatalog.Public {
+		s.router.GET("/catalog", s.publicCatalog)
+		s.router.GET("/catalog/openapi/*service", s.catalogSpec(true))
+	}
 
 	// Auth routes
-	auth := s.router.Group("/auth")
+	auth := management.Group("/auth")
 	{
 		auth.POST("/register", s.authHandler.Register)
 		auth.POST("/login", s.authHandler.Login)
+		auth.POST("/refresh", s.authHandler.Refresh)
+		auth.POST("/logout", middleware.RequireAuth(s.authService, nil), s.authHandler.Logout)
 		auth.GET("/me", s.authHandler.Me)
+
+		if s.oidcHandler != nil {
+			auth.GET("/oidc/login", s.oidcHandler.Login)
+			auth.GET("/oidc/callback", s.oidcHandler.Callback)
+		}
+		auth.POST("/password/forgot", s.authHandler.ForgotPassword)
+		auth.POST("/password/reset", s.authHandler.ResetPassword)
+		auth.POST("/password/change", middleware.RequireAuth(s.authService, nil), s.authHandler.ChangePassword)
+	}
+
+	// Consumers exchange API keys for access tokens on the proxy listener
+	if s.oauthHandler != nil {
+		s.router.POST("/oauth/token", s.oauthHandler.Token)
+	}
+
+	// Developer portal on the proxy listener, where API consumers reach it.
+	// Its sessions are a separate realm from /auth.
+	if s.portalHandler != nil {
+		portal := s.router.Group("/portal")
+		portal.POST("/register", s.portalHandler.Register)
+		portal.POST("/login", s.portalHandler.Login)
+
+		account := portal.Group("", middleware.RequirePortalAuth(s.authService, s.developers))
+		account.POST("/logout", s.portalHandler.Logout)
+		account.GET("/me", s.portalHandler.Me)
+		account.GET("/tiers", s.portalHandler.Tiers)
+		account.GET("/keys", s.portalHandler.ListKeys)
+		account.POST("/keys", s.portalHandler.CreateKey)
+		account.POST("/keys/:id/rotate", s.portalHandler.RotateKey)
+		account.GET("/keys/:id/usage", s.portalHandler.KeyUsage)
+		account.GET("/keys/:id/logs", s.portalHandler.KeyLogs)
 	}
 
 	// Admin routes - Protected with JWT Authentication
-	admin := s.router.Group("/admin")
-	admin.Use(middleware.RequireAuth(s.authService))
+	admin := management.Group("/admin")
+	admin.Use(middleware.RequireAuth(s.authService, s.serviceAccounts))
+	admin.Use(middleware.Audit(s.auditLogRepo))
+	admin.Use(middleware.AdminAccess())
+	admin.Use(middleware.OrgScope(orgScopedRoutes...))
 	{
 		admin.POST("/keys", s.apiKeyHandler.Create)
 		admin.GET("/keys", s.apiKeyHandler.List)
+		admin.GET("/keys/unowned", s.apiKeyHandler.Unowned)
+		admin.GET("/keys/degradation", s.apiKeyHandler.Degradation)
+		admin.GET("/keys/stale", s.staleKeyHandler.Report)
+		admin.POST("/keys/stale/sweep", s.staleKeyHandler.Sweep)
+
+		// Login audit and lockouts
+		if s.loginThrottleHandler != nil {
+			admin.GET("/login-attempts", s.loginThrottleHandler.Attempts)
+			admin.DELETE("/login-lockouts/:email", s.loginThrottleHandler.Unlock)
+		}
 		admin.GET("/keys/:id", s.apiKeyHandler.Get)
 		admin.PUT("/keys/:id", s.apiKeyHandler.Update)
+		admin.POST("/keys/:id/rotate", s.apiKeyHandler.Rotate)
+		admin.POST("/keys/:id/transfer", s.apiKeyHandler.Transfer)
 		admin.DELETE("/keys/:id", s.apiKeyHandler.Delete)
 
+		// Admin audit log
+		admin.GET("/audit", s.auditHandler.List)
+		admin.GET("/compliance/access-review", s.complianceHandler.AccessReview)
+
 		// System status
 		admin.GET("/status", s.adminStatus)
+		admin.GET("/policies", s.listPolicyBundles)
+		admin.POST("/config/reload", s.reloadConfig)
+		admin.GET("/catalog", s.adminCatalog)
+		admin.GET("/catalog/openapi/*service", s.catalogSpec(false))
+
+		// Rate limit capacity planning
+		admin.POST("/ratelimit/simulate", s.simulateRateLimit)
 
 		// Circuit Breaker management (NEW)
 		admin.GET("/circuit-breakers", s.systemHandler.CircuitBreakerStatus)
@@ -198,15 +1017,118 @@ func (s *Server) setupRoutes() {
 		// Health Checker
 		admin.GET("/services/health", s.systemHandler.ServiceHealthStatus)
 
+		// Canary releases
+		admin.GET("/canaries", s.systemHandler.CanaryStatus)
+		admin.PUT("/canaries/*service", s.systemHandler.SetCanary)
+
+		// Blue-green deployments
+		admin.GET("/deployments", s.systemHandler.DeploymentStatus)
+		admin.POST("/services/*service", s.systemHandler.SwitchDeployment)
+
+		// Registration invitations
+		admin.POST("/invitations", s.authHandler.CreateInvitation)
+		admin.GET("/invitations", s.authHandler.ListInvitations)
+		admin.DELETE("/invitations/:id", s.authHandler.RevokeInvitation)
+
+		// Organizations owning users and API keys
+		admin.POST("/organizations", s.organizationHandler.Create)
+		admin.GET("/organizations", s.organizationHandler.List)
+		admin.GET("/organizations/:id", s.organizationHandler.Get)
+		admin.POST("/organizations/:id/members", s.organizationHandler.AddUser)
+		admin.POST("/organizations/:id/keys", s.organizationHandler.AddAPIKey)
+		admin.PUT("/organizations/:id/limits", s.organizationHandler.SetLimits)
+		admin.GET("/organizations/:id/usage", s.organizationHandler.Usage)
+
+		// Service accounts for automation; their own tokens can never reach these routes
+		admin.POST("/service-accounts", s.serviceAccountHandler.Create)
+		admin.GET("/service-accounts", s.serviceAccountHandler.List)
+		admin.DELETE("/service-accounts/:id", s.serviceAccountHandler.Deactivate)
+		admin.POST("/service-accounts/:id/tokens", s.serviceAccountHandler.IssueToken)
+		admin.GET("/service-accounts/:id/tokens", s.serviceAccountHandler.ListTokens)
+		admin.DELETE("/service-accounts/:id/tokens/:tokenId", s.serviceAccountHandler.RevokeToken)
+
+		// Connection accounting
+		admin.GET("/connections", s.systemHandler.ConnectionStatus)
+		admin.GET("/upstream-limits", s.systemHandler.UpstreamLimitStatus)
+
 		// Analytics routes
 		admin.GET("/analytics", s.analyticsHandler.GetSummary)
 		admin.GET("/analytics/timeseries", s.analyticsHandler.GetTimeSeries)
+		admin.GET("/analytics/status-codes", s.analyticsHandler.GetStatusCodes)
+		admin.GET("/analytics/latency-histogram", s.analyticsHandler.GetLatencyHistogram)
+		admin.GET("/analytics/live", s.streamLiveMetrics)
+		admin.GET("/analytics/top-keys", s.analyticsHandler.GetTopKeys)
+		admin.GET("/analytics/operations", s.analyticsHandler.GetTopOperations)
+		admin.GET("/analytics/countries", s.analyticsHandler.GetTopCountries)
+		admin.GET("/analytics/versions", s.analyticsHandler.GetVersionTraffic)
 		admin.GET("/analytics/keys/:id", s.analyticsHandler.GetAPIKeyStats)
+		admin.GET("/analytics/organizations", s.analyticsHandler.GetOrganizationUsage)
 		admin.GET("/logs", s.analyticsHandler.GetLogs)
+
+		// Monthly usage per API key for invoicing
+		admin.GET("/reports/usage", s.usageReportHandler.Report)
+		admin.POST("/reports/usage/rollup", s.usageReportHandler.Rollup)
+		admin.GET("/access-log/sinks", s.accessLogSinks)
+		admin.GET("/alerts", s.listAlerts)
+		admin.GET("/webhooks/deliveries", s.listWebhookDeliveries)
+
+		// Runtime middleware toggles
+		admin.GET("/middleware", s.toggleHandler.List)
+		admin.PUT("/middleware/:name/:action", s.toggleHandler.Set)
+
+		// Debug capture of redacted request and response bodies
+		admin.GET("/body-capture", s.bodyCaptureHandler.List)
+		admin.PUT("/body-capture/*service", s.bodyCaptureHandler.Enable)
+		admin.DELETE("/body-capture/*service", s.bodyCaptureHandler.Disable)
+
+		// Response stubs for contract tests
+		admin.GET("/stubs", s.stubHandler.List)
+		admin.POST("/stubs", s.stubHandler.Create)
+		admin.DELETE("/stubs/:id", s.stubHandler.Delete)
+
+		// Maintenance windows
+		admin.GET("/maintenance", s.maintenanceHandler.List)
+		admin.POST("/maintenance", s.maintenanceHandler.Create)
+		admin.DELETE("/maintenance/:id", s.maintenanceHandler.Delete)
+
+		// Fault injection for resilience tests
+		admin.GET("/faults", s.faultHandler.List)
+		admin.POST("/faults", s.faultHandler.Create)
+		admin.PUT("/faults/:id", s.faultHandler.Toggle)
+		admin.DELETE("/faults/:id", s.faultHandler.Delete)
+
+		// IP allow and deny rules
+		admin.GET("/ip-filter", s.ipFilterHandler.List)
+		admin.POST("/ip-filter", s.ipFilterHandler.Create)
+		admin.DELETE("/ip-filter/:id", s.ipFilterHandler.Delete)
+
+		// CORS policy overrides
+		admin.GET("/cors", s.corsHandler.List)
+		admin.PUT("/cors/*service", s.corsHandler.Set)
+		admin.DELETE("/cors/*service", s.corsHandler.Delete)
+
+		// Response cache warming
+		admin.POST("/cache/warm", s.cacheHandler.Warm)
+		admin.GET("/cache/warm", s.cacheHandler.ListJobs)
+		admin.GET("/cache/warm/:id", s.cacheHandler.GetJob)
+		admin.POST("/cache/purge", s.cacheHandler.Purge)
+
+		// Dead letters
+		admin.GET("/dead-letters", s.deadLetterHandler.List)
+		admin.GET("/dead-letters/:id", s.deadLetterHandler.Get)
+		admin.POST("/dead-letters/:id/redrive", s.deadLetterHandler.Redrive)
+		admin.DELETE("/dead-letters/:id", s.deadLetterHandler.Delete)
+
+		// Profiling and runtime stats
+		admin.GET("/debug/runtime", s.debugHandler.Runtime)
+		admin.GET("/debug/pprof/", s.debugHandler.Index)
+		admin.GET("/debug/pprof/:profile", s.debugHandler.Profile)
+		admin.POST("/debug/pprof/symbol", s.debugHandler.Profile)
 	}
 
 	// Proxy routes
 	s.setupProxyRoutes()
+	s.setupCompositeRoutes()
 }
 
 // Configures routes that proxy to backend services
@@ -215,16 +1137,495 @@ func (s *Server) setupProxyRoutes() {
 		proxyPath := path
 		p := proxyInstance
 
-		s.router.Any(proxyPath+"/*proxyPath", func(c *gin.Context) {
-			p.Handle(c)
-		})
+		if svc := s.findServiceConfig(proxyPath); svc != nil && svc.FastPath {
+			if s.fastPaths == nil {
+				s.fastPaths = make(map[string]bool)
+			}
+			s.fastPaths[proxyPath] = true
+			log.Printf("Registered fast path route: %s", proxyPath)
+			continue
+		}
 
-		s.router.Any(proxyPath, func(c *gin.Context) {
+		// Tags the request for logging before any service middleware can end it
+		handlers := []gin.HandlerFunc{func(c *gin.Context) {
+			c.Set("service", proxyPath)
+		}}
+		handlers = append(handlers, s.serviceMiddleware(proxyPath)...)
+		handlers = append(handlers, func(c *gin.Context) {
 			p.Handle(c)
 		})
 
+		s.router.Any(proxyPath+"/*proxyPath", handlers...)
+
+		s.router.Any(proxyPath, handlers...)
+
 		log.Printf("Registered proxy route: %s", proxyPath)
 	}
+
+	// Services without a backend yet are answered entirely by their mocks
+	for _, svc := range s.config.Services {
+		if _, exists := s.proxies[svc.Path]; exists || len(svc.Mocks) == 0 {
+			continue
+		}
+
+		mockPath := svc.Path
+		handlers := []gin.HandlerFunc{func(c *gin.Context) {
+			c.Set("service", mockPath)
+		}}
+		handlers = append(handlers, s.serviceMiddleware(mockPath)...)
+
+		s.router.Any(mockPath+"/*proxyPath", handlers...)
+		s.router.Any(mockPath, handlers...)
+
+		log.Printf("Registered mock route: %s", mockPath)
+	}
+}
+
+// Sends fast-path requests straight to their proxy and everything else through gin
+func (s *Server) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Catalog updates and config reloads swap the routing table; the request
+		// stays on the one it started on
+		s.routesMu.RLock()
+		router := s.router
+		var p *proxy.Proxy
+		if len(s.fastPaths) > 0 {
+			p = s.fastPathProxy(r.URL.Path)
+		}
+		globalIPs, trustedProxies := s.globalIPs, s.trustedProxies
+		s.routesMu.RUnlock()
+
+		if p != nil {
+			// Global IP lists apply to every route, fast paths included
+			addr := ipfilter.ClientAddr(r, trustedProxies)
+			if decision := s.ipFilter.Decide(ipfilter.GlobalScope, globalIPs, addr); !decision.Allowed {
+				message, locale := s.messages.Render(r.Header.Get("Accept-Language"), messages.IPDenied, nil)
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.Header().Set("Content-Language", locale)
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(gin.H{
+					"error":     message,
+					"reason":    decision.Reason,
+					"scope":     ipfilter.GlobalScope,
+					"client_ip": addr.String(),
+				})
+				return
+			}
+
+			// Fast-path services authenticate no one, but must not pass on a claimed identity
+			middleware.StripConsumerHeaders(r.Header)
+			p.ServeHTTP(w, r)
+			return
+		}
+		router.ServeHTTP(w, r)
+	})
+}
+
+// Returns the fast-path proxy for path, unless a longer gin-served service owns it
+func (s *Server) fastPathProxy(path string) *proxy.Proxy {
+	longest := ""
+	for candidate := range s.proxies {
+		if (path == candidate || strings.HasPrefix(path, candidate+"/")) && len(candidate) > len(longest) {
+			longest = candidate
+		}
+	}
+	if !s.fastPaths[longest] {
+		return nil
+	}
+	return s.proxies[longest]
+}
+
+// Returns the configuration of the service mounted at path
+func (s *Server) findServiceConfig(path string) *config.ServiceConfig {
+	for i := range s.config.Services {
+		if s.config.Services[i].Path == path {
+			return &s.config.Services[i]
+		}
+	}
+	return nil
+}
+
+// Builds the middleware that only applies to a single service's routes
+func (s *Server) serviceMiddleware(path string) []gin.HandlerFunc {
+	handlers := make([]gin.HandlerFunc, 0)
+
+	svc := s.findServiceConfig(path)
+	if svc == nil {
+		return append(handlers, middleware.ConsumerHeaders())
+	}
+
+	// Routing comes first so everything below, and the logs, see the route
+	if len(svc.Routes) > 0 {
+		routes := make([]routing.Route, 0, len(svc.Routes))
+		for _, rc := range svc.Routes {
+			// Validated with the config
+			route, _ := routing.NewRoute(rc.Template, rc.Regex, rc.Name, rc.Priority, rc.Methods)
+			routes = append(routes, route)
+		}
+		handlers = append(handlers, middleware.Routes(routing.NewTable(routes)))
+	}
+
+	// Outermost, so the capture and cache below see uncompressed bodies
+	if comp := svc.Compression; comp != nil && comp.Enabled {
+		handlers = append(handlers, middleware.Toggleable("compression", s.toggles, middleware.Compression(middleware.CompressionOptions{
+			Encodings:    comp.Algorithms,
+			MinBytes:     comp.MinBytes,
+			ContentTypes: comp.ContentTypes,
+		})))
+	}
+
+	// Captures rejected requests too, so it goes before any policy
+	captureMaxBytes := 4096
+	var redactHeaders, redactFields []string
+	if bc := svc.BodyCapture; bc != nil {
+		if bc.MaxBytes > 0 {
+			captureMaxBytes = bc.MaxBytes
+		}
+		redactHeaders, redactFields = bc.RedactHeaders, bc.RedactFields
+	}
+	handlers = append(handlers, middleware.BodyCapture(s.bodyCapture, path, capture.NewRedactor(redactHeaders, redactFields), captureMaxBytes))
+
+	handlers = append(handlers, middleware.IPFilter(s.ipFilter, path, s.ipFilterList(svc.IPFilter)))
+	if geo := svc.Geo; geo != nil && s.geoip != nil {
+		handlers = append(handlers, middleware.GeoFilter(geo.AllowCountries, geo.DenyCountries, geo.AllowUnknown))
+	}
+
+	if b := svc.Bots; b != nil && b.Enabled {
+		opts := bots.Options{
+			RequiredHeaders: b.RequiredHeaders,
+			HoneypotPaths:   b.HoneypotPaths,
+			MaxPerSecond:    b.MaxRequestsPerSecond,
+			FlagDuration:    time.Duration(b.FlagSeconds) * time.Second,
+		}
+		for _, pattern := range b.UserAgentPatterns {
+			opts.UserAgents = append(opts.UserAgents, regexp.MustCompile(pattern))
+		}
+		for _, pattern := range b.AllowUserAgents {
+			opts.AllowUserAgents = append(opts.AllowUserAgents, regexp.MustCompile(pattern))
+		}
+		detector := bots.NewDetector(path, opts, s.redis, s.limiters)
+		handlers = append(handlers, middleware.Toggleable("bot_detection", s.toggles, middleware.BotDetection(detector, path, b.Action, s.limiters, b.ThrottleRPM)))
+		log.Printf("Bot detection enabled for %s (action: %s)", path, b.Action)
+	}
+
+	// Services in maintenance answer before any policy runs or a backend is reached
+	handlers = append(handlers, middleware.Maintenance(s.maintenance, path))
+
+	// Policies, whether set on the service or inherited from a policy bundle
+	if cc := svc.ClientCert; cc != nil && cc.Required {
+		handlers = append(handlers, middleware.RequireClientCert(cc.Allowed))
+	}
+	if svc.Auth == "api_key" {
+		handlers = append(handlers, middleware.RequireAPIKey())
+	}
+
+	if fa := svc.ForwardAuth; fa != nil && fa.Enabled {
+		validator := forwardauth.NewValidator(forwardauth.Config{
+			Issuer:     fa.Issuer,
+			Audience:   fa.Audience,
+			JWKSURL:    fa.JWKSURL,
+			Algorithms: fa.Algorithms,
+			Leeway:     time.Duration(fa.LeewaySeconds) * time.Second,
+		})
+		handlers = append(handlers, middleware.ForwardAuth(validator, middleware.ForwardAuthOptions{
+			Header:       fa.Header,
+			Optional:     fa.Optional,
+			ClaimHeaders: fa.ClaimHeaders,
+		}))
+		log.Printf("Forward auth enabled for %s (issuer: %s)", path, fa.Issuer)
+	}
+
+	if in := svc.Introspection; in != nil && in.Enabled {
+		introspector := introspection.NewIntrospector(introspection.Config{
+			URL:          in.URL,
+			ClientID:     in.ClientID,
+			ClientSecret: in.ClientSecret,
+			CacheTTL:     time.Duration(in.CacheSeconds) * time.Second,
+		}, s.redis)
+		handlers = append(handlers, middleware.Introspection(introspector, middleware.IntrospectionOptions{
+			Header:        in.Header,
+			Optional:      in.Optional,
+			ConsumerClaim: in.ConsumerClaim,
+			ClaimHeaders:  in.ClaimHeaders,
+		}))
+		log.Printf("Token introspection enabled for %s (endpoint: %s)", path, in.URL)
+	}
+
+	// Every authentication step has run, so the backend learns who the caller is
+	handlers = append(handlers, middleware.ConsumerHeaders())
+
+	if rl := svc.RateLimit; rl != nil {
+		handlers = append(handlers, middleware.Toggleable("rate_limit", s.toggles, middleware.ServiceRateLimit(s.limiters, path, rl.RequestsPerMinute, rl.Algorithm)))
+	}
+
+	if svc.TimeoutSeconds > 0 {
+		handlers = append(handlers, middleware.Timeout(time.Duration(svc.TimeoutSeconds)*time.Second))
+	}
+
+	if tr := svc.Transforms; tr != nil {
+		handlers = append(handlers, middleware.HeaderTransform(headerRewrite(tr.Request), headerRewrite(tr.Response)))
+	}
+
+	if len(svc.Policies) > 0 {
+		log.Printf("Policy bundles for %s: %s", path, strings.Join(svc.Policies, ", "))
+	}
+
+	if bs := svc.BodyScan; bs != nil && bs.Enabled {
+		opts := middleware.BodyScanOptions{
+			Paths:          bs.Paths,
+			MaxBytes:       bs.MaxBytes,
+			Timeout:        time.Duration(bs.TimeoutMs) * time.Millisecond,
+			RejectOversize: bs.RejectOversize,
+			FailOpen:       bs.FailOpen,
+		}
+		if opts.MaxBytes <= 0 {
+			opts.MaxBytes = 10 << 20
+		}
+		if opts.Timeout <= 0 {
+			opts.Timeout = 2 * time.Second
+		}
+		handlers = append(handlers, middleware.Toggleable("body_scan", s.toggles, middleware.BodyScan(bodyscan.NewClamAV(bs.Address), opts)))
+		log.Printf("Body scanning enabled for %s (scanner: %s)", path, bs.Scanner)
+	}
+
+	if gql := svc.GraphQL; gql != nil && gql.Enabled {
+		handlers = append(handlers, middleware.Toggleable("graphql", s.toggles, middleware.GraphQL(middleware.GraphQLLimits{
+			MaxDepth:      gql.MaxDepth,
+			MaxComplexity: gql.MaxComplexity,
+			Introspection: *gql.Introspection,
+		})))
+		log.Printf("GraphQL limits enabled for %s (depth %d, complexity %d)", path, gql.MaxDepth, gql.MaxComplexity)
+	}
+
+	// Versions pick the targets before experiments can override them
+	if v := svc.Versions; v != nil {
+		versions := make([]middleware.APIVersion, 0, len(v.Versions))
+		for _, version := range v.Versions {
+			apiVersion := middleware.APIVersion{
+				Name:       version.Name,
+				OwnTargets: len(version.Targets) > 0,
+				Deprecated: version.Deprecated,
+				Link:       version.Link,
+			}
+			// Validated with the config
+			apiVersion.Sunset, _ = time.Parse(time.RFC3339, version.Sunset)
+			versions = append(versions, apiVersion)
+		}
+		handlers = append(handlers, middleware.Versions(path, v.Header, v.Default, versions))
+	}
+
+	// Experiments pick the targets, and cached responses are kept per variant
+	if len(svc.Experiments) > 0 {
+		experiments := make([]middleware.Experiment, 0, len(svc.Experiments))
+		for _, exp := range svc.Experiments {
+			experiments = append(experiments, middleware.Experiment{
+				Name:       exp.Name,
+				Variant:    exp.Variant,
+				Header:     exp.Header,
+				Cookie:     exp.Cookie,
+				Value:      exp.Value,
+				Percentage: exp.Percentage,
+			})
+		}
+		handlers = append(handlers, middleware.Experiments(experiments))
+	}
+
+	// Replayed responses skip faults, stubs, mocks and the backend
+	if id := svc.Idempotency; id != nil && id.Enabled {
+		handlers = append(handlers, middleware.Toggleable("idempotency", s.toggles, middleware.Idempotency(s.redis, path, time.Duration(id.TTLSeconds)*time.Second, id.Required)))
+	}
+
+	// Faults stand in for a slow or failing backend, so they go after every policy
+	handlers = append(handlers, middleware.Toggleable("fault_injection", s.toggles, middleware.FaultInjection(s.faults, path)))
+
+	// Stubs take priority over cached and proxied responses
+	handlers = append(handlers, middleware.Toggleable("stubs", s.toggles, middleware.Stubs(s.stubs)))
+
+	if len(svc.Mocks) > 0 {
+		mocks := make([]middleware.Mock, 0, len(svc.Mocks))
+		for _, m := range svc.Mocks {
+			stub := &stubs.Stub{
+				Method:   m.Method,
+				Path:     m.Path,
+				Response: stubs.Response{Status: m.Status, Headers: m.Headers, Body: m.Body},
+			}
+			if err := stub.Compile(); err != nil {
+				log.Printf("Skipping mock %s %s for %s: %v", m.Method, m.Path, path, err)
+				continue
+			}
+			mocks = append(mocks, middleware.Mock{Stub: stub, Latency: time.Duration(m.LatencyMs) * time.Millisecond})
+		}
+		hasBackend := len(svc.AllTargets()) > 0 || svc.Kubernetes != nil
+		handlers = append(handlers, middleware.Mocks(mocks, hasBackend))
+	}
+
+	if svc.Cache != nil && svc.Cache.Enabled {
+		handlers = append(handlers, middleware.Toggleable("response_cache", s.toggles, middleware.ResponseCache(s.cacheStore, cachePolicy(svc.Cache))))
+	}
+
+	if te := svc.TokenExchange; te != nil && te.Enabled {
+		audience := te.Audience
+		if audience == "" {
+			audience = path
+		}
+		header := te.Header
+		if header == "" {
+			header = "Authorization"
+		}
+		handlers = append(handlers, middleware.TokenExchange(s.tokenExchanger(te.Mode), s.authService, audience, header))
+		log.Printf("Token exchange enabled for %s (audience: %s, mode: %s)", path, audience, te.Mode)
+	}
+
+	// Queued requests have passed every policy; workers apply the URL rewrite
+	if as := svc.Async; as != nil && as.Enabled {
+		if err := s.asyncJobs.Start(); err != nil {
+			log.Printf("Async requests disabled for %s: %v", path, err)
+		} else {
+			handlers = append(handlers, middleware.AsyncRequests(s.asyncJobs, middleware.AsyncOptions{
+				Service:        path,
+				Paths:          as.Paths,
+				Methods:        as.Methods,
+				MaxBodyBytes:   as.MaxBodyBytes,
+				CallbackHeader: as.CallbackHeader,
+				StatusPath:     "/async/jobs",
+			}))
+			log.Printf("Async requests enabled for %s", path)
+		}
+	}
+
+	// Everything before the proxy sees the gateway URL
+	if tr := svc.Transforms; (tr != nil && (len(tr.Paths) > 0 || tr.Query != nil)) || svc.StripPrefix || svc.Rewrite != nil {
+		handlers = append(handlers, middleware.RewriteURL(urlRewrite(svc)))
+	}
+
+	return handlers
+}
+
+func headerRewrite(t config.HeaderTransform) middleware.HeaderRewrite {
+	return middleware.HeaderRewrite{
+		Set:    t.SetHeaders,
+		Remove: t.RemoveHeaders,
+		Add:    t.AddHeaders,
+		Rename: t.RenameHeaders,
+	}
+}
+
+// Builds the path and query rewrite of a service; patterns were checked when the config loaded
+func urlRewrite(svc *config.ServiceConfig) middleware.URLRewrite {
+	var rewrite middleware.URLRewrite
+	switch {
+	case svc.StripPrefix:
+		rewrite.Prefix = svc.Path
+	case svc.Rewrite != nil:
+		rewrite.Prefix, rewrite.ReplacePrefix = svc.Rewrite.From, svc.Rewrite.To
+		if rewrite.Prefix == "" {
+			rewrite.Prefix = svc.Path
+		}
+	}
+
+	tr := svc.Transforms
+	if tr == nil {
+		return rewrite
+	}
+	for _, rule := range tr.Paths {
+		rewrite.Paths = append(rewrite.Paths, middleware.PathRewrite{
+			Match:   regexp.MustCompile(rule.Match),
+			Replace: rule.Replace,
+		})
+	}
+	if tr.Query != nil {
+		rewrite.AddQuery = tr.Query.Add
+		rewrite.RemoveQuery = tr.Query.Remove
+	}
+	return rewrite
+}
+
+// Fetches a gateway path from its service backend for cache warming
+func (s *Server) fetchForCache(ctx context.Context, path, rawQuery string) (*cache.Entry, string, time.Duration, error) {
+	s.routesMu.RLock()
+	servicePath := ""
+	for candidate := range s.proxies {
+		if (path == candidate || strings.HasPrefix(path, candidate+"/")) && len(candidate) > len(servicePath) {
+			servicePath = candidate
+		}
+	}
+	p := s.proxies[servicePath]
+	svc := s.findServiceConfig(servicePath)
+	s.routesMu.RUnlock()
+
+	if servicePath == "" {
+		return nil, "", 0, fmt.Errorf("no service matches %s", path)
+	}
+	if svc == nil || svc.Cache == nil || !svc.Cache.Enabled {
+		return nil, "", 0, fmt.Errorf("caching is not enabled for service %s", servicePath)
+	}
+
+	policy := cachePolicy(svc.Cache)
+	ttl, ok := policy.PathTTL(path)
+	if !ok {
+		return nil, "", 0, fmt.Errorf("caching is disabled for %s", path)
+	}
+
+	backendPath, backendQuery := urlRewrite(svc).Rewrite(path, rawQuery)
+
+	statusCode, header, body, err := p.Do(ctx, http.MethodGet, backendPath, backendQuery, nil, nil)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if !cache.Cacheable(statusCode, header) {
+		return nil, "", 0, fmt.Errorf("response is not cacheable (status %d)", statusCode)
+	}
+	if ttl, ok = policy.ResponseTTL(header, ttl); !ok {
+		return nil, "", 0, fmt.Errorf("response is not cacheable (Cache-Control: %s)", header.Get("Cache-Control"))
+	}
+
+	// Warming sends no request headers, so it fills the variant without them
+	key := policy.Key(path, rawQuery, http.Header{})
+	return &cache.Entry{StatusCode: statusCode, Header: header, Body: body}, key, ttl, nil
+}
+
+// Builds the cache policy of a service
+func cachePolicy(cfg *config.CacheConfig) *cache.Policy {
+	policy := &cache.Policy{
+		TTL:               cfg.TTL(),
+		VaryHeaders:       cfg.VaryHeaders,
+		QueryParams:       cfg.QueryParams,
+		HonorCacheControl: cfg.HonorCacheControl,
+		Coalesce:          cfg.Coalesce,
+	}
+	for _, rule := range cfg.Paths {
+		policy.Paths = append(policy.Paths, cache.PathRule{
+			Prefix:   rule.Prefix,
+			TTL:      time.Duration(rule.TTLSeconds) * time.Second,
+			Disabled: rule.Disabled,
+		})
+	}
+	return policy
+}
+
+// Returns the shared token exchanger for a mode, creating it on first use
+func (s *Server) tokenExchanger(mode string) tokenexchange.Exchanger {
+	if mode != "sts" {
+		mode = "local"
+	}
+	if s.tokenExchangers == nil {
+		s.tokenExchangers = make(map[string]tokenexchange.Exchanger)
+	}
+	if exchanger, exists := s.tokenExchangers[mode]; exists {
+		return exchanger
+	}
+
+	teCfg := s.config.TokenExchange
+	local := tokenexchange.NewLocalIssuer(teCfg.Issuer, teCfg.Secret, time.Duration(teCfg.TTLSeconds)*time.Second)
+
+	var exchanger tokenexchange.Exchanger = local
+	if mode == "sts" {
+		exchanger = tokenexchange.NewSTSExchanger(teCfg.STSURL, teCfg.STSClientID, teCfg.STSClientSecret, local)
+	}
+	exchanger = tokenexchange.NewCachingExchanger(exchanger, 30*time.Second)
+
+	s.tokenExchangers[mode] = exchanger
+	return exchanger
 }
 
 // Handles GET /health
@@ -262,49 +1663,494 @@ func (s *Server) healthCheck(c *gin.Context) {
 	})
 }
 
+// Handles GET /readyz
+func (s *Server) readinessCheck(c *gin.Context) {
+	if s.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":  "draining",
+			"message": messages.Localize(c, messages.Maintenance, nil),
+		})
+		return
+	}
+
+	s.routesMu.RLock()
+	services := len(s.proxies)
+	s.routesMu.RUnlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "ready",
+		"services": services,
+	})
+}
+
 func (s *Server) adminStatus(c *gin.Context) {
 	ctx := c.Request.Context()
 	keys, _ := s.apiKeyService.List(ctx)
+	s.routesMu.RLock()
+	services := len(s.config.Services)
+	s.routesMu.RUnlock()
+
 	c.JSON(http.StatusOK, gin.H{
 		"gateway":   "running",
-		"services":  len(s.config.Services),
+		"services":  services,
 		"api_keys":  len(keys),
 		"uptime":    time.Since(startTime).Seconds(),
 		"timestamp": time.Now().Unix(),
 	})
 }
 
-func (s *Server) Run(addr string) error {
-	s.httpServer = &http.Server{
-		Addr:         addr,
-		Handler:      s.router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  15 * time.Second,
+// Returns the policy bundles and the services that reference them
+func (s *Server) listPolicyBundles(c *gin.Context) {
+	s.routesMu.RLock()
+	defer s.routesMu.RUnlock()
+
+	bundles := make(map[string]interface{}, len(s.config.PolicyBundles))
+	for name, bundle := range s.config.PolicyBundles {
+		services := make([]string, 0)
+		for _, svc := range s.config.Services {
+			for _, policy := range svc.Policies {
+				if policy == name {
+					services = append(services, svc.Path)
+					break
+				}
+			}
+		}
+
+		bundles[name] = gin.H{
+			"policy":   bundle,
+			"services": services,
+		}
 	}
 
-	log.Printf("Starting API Gateway on %s", addr)
+	c.JSON(http.StatusOK, bundles)
+}
+
+// Handles POST /admin/ratelimit/simulate
+// Replays a synthetic traffic profile against a configured tier, or a custom
+// requests_per_minute, and reports allowed and denied requests per minute for
+// each algorithm
+func (s *Server) simulateRateLimit(c *gin.Context) {
+	var req struct {
+		Tier              string                     `json:"tier"`
+		RequestsPerMinute int                        `json:"requests_per_minute"` // Overrides the tier's limit
+		Algorithm         string                     `json:"algorithm"`           // Default: every algorithm
+		Traffic           []ratelimit.TrafficSegment `json:"traffic" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := req.RequestsPerMinute
+	configured := ""
+	if req.Tier != "" {
+		s.routesMu.RLock()
+		tier := middleware.FindTierConfig(s.config, req.Tier)
+		s.routesMu.RUnlock()
+		if tier == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown tier: " + req.Tier})
+			return
+		}
+		if limit <= 0 {
+			limit = tier.RequestsPerMinute
+		}
+		configured = tier.Algorithm
+	}
+	if limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide a tier or requests_per_minute"})
+		return
+	}
+
+	var algorithms []string
+	if req.Algorithm != "" {
+		algorithms = []string{req.Algorithm}
+	}
+
+	results, err := ratelimit.Simulate(algorithms, limit, time.Minute, req.Traffic)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	total := 0
+	if len(results) > 0 {
+		total = results[0].Allowed + results[0].Denied
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tier":                 req.Tier,
+		"configured_algorithm": configured,
+		"requests_per_minute":  limit,
+		"total_requests":       total,
+		"results":              results,
+	})
+}
+
+func (s *Server) Run(addr string) error {
+	s.httpServer = s.newHTTPServer(s.handler())
+	s.httpServer.Addr = addr
+
 	log.Printf("Environment: %s", s.config.Server.Environment)
 
+	var tlsConfig *tls.Config
+	if s.config.Server.TLS != nil {
+		var err error
+		tlsConfig, err = newTLSConfig(s.config.Server.TLS)
+		if err != nil {
+			return err
+		}
+		s.httpServer.TLSConfig = tlsConfig
+	}
+
+	if s.config.Server.Admin != nil {
+		adminTLS := tlsConfig
+		if tlsConfig != nil && tlsConfig.ClientAuth != tls.NoClientCert {
+			// Operators reach the admin API with their own credentials
+			adminTLS = tlsConfig.Clone()
+			adminTLS.ClientAuth = tls.NoClientCert
+		}
+		if err := s.serveAdmin(adminTLS); err != nil {
+			return err
+		}
+	}
+
+	if tlsConfig != nil {
+		log.Printf("Starting API Gateway on %s with TLS (%d certificates)", addr, len(tlsConfig.Certificates))
+		return s.httpServer.ListenAndServeTLS("", "")
+	}
+
+	log.Printf("Starting API Gateway on %s", addr)
 	return s.httpServer.ListenAndServe()
 }
 
+// Creates an HTTP server with the configured timeouts and limits
+func (s *Server) newHTTPServer(handler http.Handler) *http.Server {
+	cfg := s.config.Server
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: serverTimeout(cfg.ReadHeaderTimeoutSeconds),
+		ReadTimeout:       serverTimeout(cfg.ReadTimeoutSeconds),
+		WriteTimeout:      serverTimeout(cfg.WriteTimeoutSeconds),
+		IdleTimeout:       serverTimeout(cfg.IdleTimeoutSeconds),
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// Negative timeouts are disabled, which net/http spells as zero
+func serverTimeout(seconds int) time.Duration {
+	return time.Duration(max(seconds, 0)) * time.Second
+}
+
+// Starts the management listeners. The TCP port uses the proxy listener's TLS
+// settings; the unix socket is left to file permissions.
+func (s *Server) serveAdmin(tlsConfig *tls.Config) error {
+	admin := s.config.Server.Admin
+
+	// Catalog updates swap the admin router along with the proxy one
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.routesMu.RLock()
+		router := s.adminRouter
+		s.routesMu.RUnlock()
+
+		router.ServeHTTP(w, r)
+	})
+
+	var listeners []net.Listener
+	if admin.Port != "" {
+		listener, err := net.Listen("tcp", ":"+admin.Port)
+		if err != nil {
+			return fmt.Errorf("failed to listen on admin port: %w", err)
+		}
+		listeners = append(listeners, listener)
+	}
+	if admin.Socket != "" {
+		// A socket left behind by an unclean exit would block the listen
+		os.Remove(admin.Socket)
+		listener, err := net.Listen("unix", admin.Socket)
+		if err != nil {
+			return fmt.Errorf("failed to listen on admin socket: %w", err)
+		}
+		if err := os.Chmod(admin.Socket, 0660); err != nil {
+			listener.Close()
+			return fmt.Errorf("failed to set admin socket permissions: %w", err)
+		}
+		listeners = append(listeners, listener)
+	}
+
+	for _, listener := range listeners {
+		adminServer := s.newHTTPServer(handler)
+		if listener.Addr().Network() == "tcp" {
+			adminServer.TLSConfig = tlsConfig
+		}
+		s.adminServers = append(s.adminServers, adminServer)
+
+		log.Printf("Serving admin routes on %s", listener.Addr())
+		go func() {
+			serve := adminServer.Serve
+			if adminServer.TLSConfig != nil {
+				serve = func(listener net.Listener) error { return adminServer.ServeTLS(listener, "", "") }
+			}
+			if err := serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Admin listener on %s failed: %v", listener.Addr(), err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// Drains the gateway in order: stop accepting connections, wait for in-flight
+// requests until ctx ends, flush buffered request logs, then stop health
+// checks and background workers. Redis and Postgres are left for the caller
+// to close afterwards.
 func (s *Server) Shutdown(ctx context.Context) error {
 	log.Println("Shutting down server...")
 
+	// Stop advertising readiness so load balancers drain traffic, and end
+	// streaming responses so they don't hold up the drain
+	if !s.draining.Swap(true) {
+		close(s.shuttingDown)
+	}
+
+	var err error
+	if s.httpServer != nil {
+		err = s.httpServer.Shutdown(ctx)
+	}
+	for _, adminServer := range s.adminServers {
+		if adminErr := adminServer.Shutdown(ctx); err == nil {
+			err = adminErr
+		}
+	}
+	if err != nil {
+		log.Printf("In-flight requests did not finish in time: %v", err)
+	} else {
+		log.Println("In-flight requests finished")
+	}
+
+	// Flush request logs buffered for the sinks after the last request finished.
+	// This gets its own deadline so a slow drain doesn't cost the logs.
+	flushCtx, cancel := context.WithTimeout(context.Background(), logFlushTimeout)
+	defer cancel()
+	if closeErr := s.accessLog.Close(flushCtx); closeErr != nil {
+		log.Printf("Failed to flush access logs: %v", closeErr)
+	}
+
 	// Stop health checkers
+	s.routesMu.RLock()
 	for _, p := range s.proxies {
 		p.Stop()
 	}
+	s.routesMu.RUnlock()
 
-	if s.httpServer != nil {
-		return s.httpServer.Shutdown(ctx)
+	s.toggles.Stop()
+	s.bodyCapture.Stop()
+	s.stubs.Stop()
+	s.maintenance.Stop()
+	s.faults.Stop()
+	s.ipFilter.Stop()
+	s.corsOverrides.Stop()
+	s.cacheWarmer.Stop()
+	s.asyncJobs.Stop()
+	s.staleKeyService.Stop()
+	s.usageReports.Stop()
+	s.organizationService.Stop()
+	s.alerts.Stop()
+	if s.catalog != nil {
+		s.catalog.Stop()
 	}
 
-	return nil
+	return err
+}
+
+// Opens the request log storage behind the analytics API
+func newRequestLogStore(cfg *config.Config, postgres *storage.Postgres) (repository.RequestLogStore, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	switch cfg.Analytics.Backend {
+	case "timescale":
+		return repository.NewTimescaleRequestLogRepository(ctx, postgres)
+	case "clickhouse":
+		ch := cfg.Analytics.ClickHouse
+		client, err := storage.NewClickHouse(ch.URL, ch.Database, ch.Username, ch.Password)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.Ping(ctx); err != nil {
+			return nil, err
+		}
+		log.Printf("Request logs stored in ClickHouse at %s", ch.URL)
+		return repository.NewClickHouseRequestLogRepository(ctx, client, postgres)
+	default:
+		return repository.NewRequestLogRepository(postgres), nil
+	}
+}
+
+// Builds the request log sinks: PostgreSQL when analytics is on, plus any configured sinks
+func newAccessLog(cfg *config.Config, requestLogs repository.RequestLogStore) (*accesslog.Dispatcher, error) {
+	dispatcher := accesslog.NewDispatcher()
+
+	if cfg.Analytics.Enabled {
+		dispatcher.Add(accesslog.NewAnalyticsSink(cfg.Analytics.Backend, requestLogs), accesslog.Options{
+			BufferSize:    cfg.Resources.LogBufferSize,
+			BatchSize:     cfg.Analytics.BatchSize,
+			FlushInterval: time.Duration(cfg.Analytics.FlushIntervalSec) * time.Second,
+		})
+	}
+
+	for _, sinkCfg := range cfg.AccessLog.Sinks {
+		var sink accesslog.Sink
+		switch sinkCfg.Type {
+		case "file":
+			fileSink, err := accesslog.NewFileSink(sinkCfg.Name, sinkCfg.Path, int64(sinkCfg.MaxSizeMB)<<20, sinkCfg.MaxBackups)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open access log file %s: %w", sinkCfg.Path, err)
+			}
+			sink = fileSink
+		case "kafka":
+			sink = accesslog.NewKafkaSink(sinkCfg.Name, sinkCfg.RestURL, sinkCfg.Topic)
+		case "elasticsearch":
+			sink = accesslog.NewElasticsearchSink(sinkCfg.Name, sinkCfg.URL, sinkCfg.Index, sinkCfg.Username, sinkCfg.Password, sinkCfg.APIKey)
+		}
+
+		dispatcher.Add(sink, accesslog.Options{
+			BufferSize:    sinkCfg.BufferSize,
+			BatchSize:     sinkCfg.BatchSize,
+			FlushInterval: time.Duration(sinkCfg.FlushIntervalSec) * time.Second,
+			Overflow:      sinkCfg.Overflow,
+			BlockTimeout:  time.Duration(sinkCfg.BlockTimeoutMs) * time.Millisecond,
+		})
+	}
+
+	return dispatcher, nil
+}
+
+// Handles GET /admin/analytics/live
+// Streams in-memory traffic stats and circuit breaker states as server-sent
+// events. Accepts interval=<seconds> (default 1) and window=<seconds> (default 10).
+func (s *Server) streamLiveMetrics(c *gin.Context) {
+	interval, err := strconv.Atoi(c.DefaultQuery("interval", "1"))
+	if err != nil || interval < 1 || interval > 60 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be between 1 and 60 seconds"})
+		return
+	}
+	window, err := strconv.Atoi(c.DefaultQuery("window", "10"))
+	if err != nil || window < 1 || window >= livemetrics.MaxWindow {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("window must be between 1 and %d seconds", livemetrics.MaxWindow-1)})
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		total, services := s.liveMetrics.Snapshot(window)
+
+		s.routesMu.RLock()
+		breakers := make(map[string]string, len(s.proxies))
+		for path, p := range s.proxies {
+			breakers[path] = p.CircuitBreakerState().String()
+		}
+		s.routesMu.RUnlock()
+
+		c.SSEvent("metrics", gin.H{
+			"timestamp":        time.Now().UTC(),
+			"window_seconds":   window,
+			"total":            total,
+			"services":         services,
+			"circuit_breakers": breakers,
+		})
+
+		select {
+		case <-ticker.C:
+			return true
+		case <-s.shuttingDown:
+			// Dashboards reconnect elsewhere rather than holding up shutdown
+			return false
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// Handles GET /admin/access-log/sinks
+func (s *Server) accessLogSinks(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"sinks": s.accessLog.Stats()})
+}
+
+// Builds the alert monitor and the notifiers configured for it
+func newAlertMonitor(cfg config.AlertsConfig, collector *livemetrics.Collector, webhooks *webhook.Dispatcher) *alerting.Monitor {
+	var notifiers []alerting.Notifier
+	if cfg.Webhooks {
+		notifiers = append(notifiers, alerting.NewWebhookNotifier(webhooks))
+	}
+	if cfg.SlackWebhookURL != "" {
+		notifiers = append(notifiers, alerting.NewSlackNotifier(cfg.SlackWebhookURL))
+	}
+	if e := cfg.Email; e != nil {
+		notifiers = append(notifiers, alerting.NewEmailNotifier(e.SMTPHost, e.SMTPPort, e.From, e.To, e.Username, e.Password))
+	}
+
+	return alerting.NewMonitor(collector, alerting.Rules{
+		Interval:           time.Duration(cfg.IntervalSeconds) * time.Second,
+		Window:             cfg.WindowSeconds,
+		MinRequests:        int64(cfg.MinRequests),
+		WarmupSamples:      cfg.WarmupIntervals,
+		BaselineAlpha:      cfg.BaselineAlpha,
+		DeviationMultiple:  cfg.DeviationMultiple,
+		ErrorRateThreshold: cfg.ErrorRateThreshold,
+		MinErrorRate:       cfg.MinErrorRate,
+		P95ThresholdMs:     cfg.P95ThresholdMs,
+	}, notifiers)
+}
+
+// Handles GET /admin/alerts
+func (s *Server) listAlerts(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":   s.config.Alerts.Enabled,
+		"active":    s.alerts.Active(),
+		"recent":    s.alerts.History(),
+		"baselines": s.alerts.Baselines(),
+	})
+}
+
+// Handles GET /admin/webhooks/deliveries
+// Accepts status=pending|delivered|failed to filter
+func (s *Server) listWebhookDeliveries(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", webhook.DeliveryPending, webhook.DeliveryDelivered, webhook.DeliveryFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, delivered or failed"})
+		return
+	}
+
+	deliveries := s.webhooks.Deliveries(status)
+	queued, dropped := s.webhooks.Backlog()
+	c.JSON(http.StatusOK, gin.H{
+		"endpoints":  len(s.config.Webhooks),
+		"queued":     queued,
+		"dropped":    dropped,
+		"deliveries": deliveries,
+		"total":      len(deliveries),
+	})
+}
+
+// Signs tokens with the JWT secret as Vault rotates it
+func (s *Server) WatchSecrets(secrets *vault.Secrets) {
+	secrets.OnChange(vault.JWTSecret, s.authService.RotateJWTSecret)
 }
 
 func (s *Server) GetRouter() *gin.Engine {
+	s.routesMu.RLock()
+	defer s.routesMu.RUnlock()
+
 	return s.router
 }
 
@@ -2,8 +2,13 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/aman-churiwal/api-gateway/internal/models"
 	"github.com/aman-churiwal/api-gateway/internal/repository"
 	"github.com/aman-churiwal/api-gateway/internal/storage"
 	"github.com/google/uuid"
@@ -11,13 +16,15 @@ import (
 
 type AnalyticsService struct {
 	db         *storage.Postgres
-	repository *repository.RequestLogRepository
+	repository repository.RequestLogStore
+	orgs       *repository.OrganizationRepository
 }
 
-func NewAnalyticsService(db *storage.Postgres, repo *repository.RequestLogRepository) *AnalyticsService {
+func NewAnalyticsService(db *storage.Postgres, repo repository.RequestLogStore, orgs *repository.OrganizationRepository) *AnalyticsService {
 	return &AnalyticsService{
 		db:         db,
 		repository: repo,
+		orgs:       orgs,
 	}
 }
 
@@ -33,6 +40,13 @@ type AnalyticsSummary struct {
 	ClientErrorRate float64                  `json:"client_error_rate"`
 	ServerErrorRate float64                  `json:"server_error_rate"`
 	TopEndpoints    []map[string]interface{} `json:"top_endpoints"`
+	Preflight       *PreflightStats          `json:"preflight,omitempty"`
+}
+
+// Holds CORS preflight traffic, counted separately from regular requests
+type PreflightStats struct {
+	TotalRequests int64                    `json:"total_requests"`
+	TopPaths      []map[string]interface{} `json:"top_paths"`
 }
 
 // Holds time-series analytics data
@@ -99,6 +113,20 @@ func (s *AnalyticsService) GetSummary(ctx context.Context, from, to time.Time) (
 	}
 	summary.TopEndpoints = topEndpoints
 
+	// Preflight traffic
+	preflightCount, err := s.repository.CountPreflightByTimeRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	preflightPaths, err := s.repository.GetPreflightByPath(ctx, from, to, 10)
+	if err != nil {
+		return nil, err
+	}
+	summary.Preflight = &PreflightStats{
+		TotalRequests: preflightCount,
+		TopPaths:      preflightPaths,
+	}
+
 	return summary, nil
 }
 
@@ -121,6 +149,377 @@ func (s *AnalyticsService) GetTimeSeriesData(ctx context.Context, from, to time.
 	return timeSeries, nil
 }
 
+// Request counts per status code over a time range
+type StatusCodeDistribution struct {
+	From          time.Time         `json:"from"`
+	To            time.Time         `json:"to"`
+	TotalRequests int64             `json:"total_requests"`
+	Classes       map[string]int64  `json:"classes"` // "2xx", "4xx", ...
+	Codes         []StatusCodeCount `json:"codes"`
+}
+
+type StatusCodeCount struct {
+	StatusCode int     `json:"status_code"`
+	Count      int64   `json:"count"`
+	Percent    float64 `json:"percent"`
+}
+
+// Response time distribution over a time range
+type LatencyHistogram struct {
+	From          time.Time       `json:"from"`
+	To            time.Time       `json:"to"`
+	TotalRequests int64           `json:"total_requests"`
+	Buckets       []LatencyBucket `json:"buckets"`
+}
+
+// Requests with lower_ms <= response time < upper_ms. The last bucket has no upper bound.
+type LatencyBucket struct {
+	LowerMs    int   `json:"lower_ms"`
+	UpperMs    *int  `json:"upper_ms"`
+	Count      int64 `json:"count"`
+	Cumulative int64 `json:"cumulative"`
+}
+
+// Default latency histogram bucket bounds in milliseconds
+var DefaultLatencyBuckets = []int{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// Counts requests per status code and status class
+func (s *AnalyticsService) GetStatusCodeDistribution(ctx context.Context, from, to time.Time) (*StatusCodeDistribution, error) {
+	counts, err := s.repository.GetStatusCodeCounts(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	distribution := &StatusCodeDistribution{
+		From:    from,
+		To:      to,
+		Classes: make(map[string]int64),
+		Codes:   make([]StatusCodeCount, 0, len(counts)),
+	}
+	for statusCode, count := range counts {
+		distribution.TotalRequests += count
+		distribution.Classes[fmt.Sprintf("%dxx", statusCode/100)] += count
+		distribution.Codes = append(distribution.Codes, StatusCodeCount{StatusCode: statusCode, Count: count})
+	}
+
+	sort.Slice(distribution.Codes, func(i, j int) bool {
+		return distribution.Codes[i].StatusCode < distribution.Codes[j].StatusCode
+	})
+	for i := range distribution.Codes {
+		distribution.Codes[i].Percent = float64(distribution.Codes[i].Count) / float64(distribution.TotalRequests) * 100
+	}
+
+	return distribution, nil
+}
+
+// Buckets response times by the given ascending bounds in milliseconds
+func (s *AnalyticsService) GetLatencyHistogram(ctx context.Context, from, to time.Time, bounds []int) (*LatencyHistogram, error) {
+	counts, err := s.repository.GetLatencyHistogram(ctx, from, to, bounds)
+	if err != nil {
+		return nil, err
+	}
+
+	histogram := &LatencyHistogram{
+		From:    from,
+		To:      to,
+		Buckets: make([]LatencyBucket, len(counts)),
+	}
+	for i, count := range counts {
+		histogram.TotalRequests += count
+
+		bucket := LatencyBucket{Count: count, Cumulative: histogram.TotalRequests}
+		if i > 0 {
+			bucket.LowerMs = bounds[i-1]
+		}
+		if i < len(bounds) {
+			bucket.UpperMs = &bounds[i]
+		}
+		histogram.Buckets[i] = bucket
+	}
+
+	return histogram, nil
+}
+
+// Traffic of one API key over a time range
+type TopKey struct {
+	APIKeyID        uuid.UUID `json:"api_key_id"`
+	Name            string    `json:"name"`
+	Tier            string    `json:"tier"`
+	Owner           string    `json:"owner"`
+	Requests        int64     `json:"requests"`
+	ErrorRate       float64   `json:"error_rate"`
+	ClientErrorRate float64   `json:"client_error_rate"`
+	ServerErrorRate float64   `json:"server_error_rate"`
+	AvgResponseTime float64   `json:"avg_response_time_ms"`
+	P95ResponseTime float64   `json:"p95_response_time_ms"`
+}
+
+// Returns the highest-traffic API keys, or those with the most errors or
+// slowest p95 when orderBy is "errors" or "p95"
+func (s *AnalyticsService) GetTopKeys(ctx context.Context, from, to time.Time, orderBy string, limit int) ([]TopKey, error) {
+	traffic, err := s.repository.GetTopKeys(ctx, from, to, orderBy, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]TopKey, 0, len(traffic))
+	for _, t := range traffic {
+		key := TopKey{
+			APIKeyID:        t.APIKeyID,
+			Name:            t.Name,
+			Tier:            t.Tier,
+			Owner:           t.Owner,
+			Requests:        t.Requests,
+			AvgResponseTime: t.AvgLatencyMs,
+			P95ResponseTime: t.P95LatencyMs,
+		}
+		if t.Requests > 0 {
+			key.ClientErrorRate = float64(t.ClientErrors) / float64(t.Requests) * 100
+			key.ServerErrorRate = float64(t.ServerErrors) / float64(t.Requests) * 100
+			key.ErrorRate = key.ClientErrorRate + key.ServerErrorRate
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Traffic from one organization's API keys over a time range
+type OrganizationUsage struct {
+	OrgID           uuid.UUID `json:"org_id"`
+	Name            string    `json:"name"`
+	APIKeys         int       `json:"api_keys"` // Keys that sent traffic
+	Requests        int64     `json:"requests"`
+	ErrorRate       float64   `json:"error_rate"`
+	ClientErrorRate float64   `json:"client_error_rate"`
+	ServerErrorRate float64   `json:"server_error_rate"`
+	BytesIn         int64     `json:"bytes_in"`
+	BytesOut        int64     `json:"bytes_out"`
+}
+
+// Rolls per key usage up to the organizations owning the keys, busiest
+// first. Organizations without traffic are included with zero usage. When
+// only is set just that organization is returned.
+func (s *AnalyticsService) GetOrganizationUsage(ctx context.Context, from, to time.Time, only *uuid.UUID) ([]OrganizationUsage, error) {
+	orgs, err := s.orgs.List(ctx, only)
+	if err != nil {
+		return nil, err
+	}
+	keyOrgs, err := s.orgs.KeyOrganizations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.repository.GetKeyUsage(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	type errorCounts struct{ client, server int64 }
+	byOrg := make(map[uuid.UUID]int, len(orgs))
+	results := make([]OrganizationUsage, len(orgs))
+	failures := make([]errorCounts, len(orgs))
+	for i, org := range orgs {
+		results[i] = OrganizationUsage{OrgID: org.ID, Name: org.Name}
+		byOrg[org.ID] = i
+	}
+	for _, u := range usage {
+		i, ok := byOrg[keyOrgs[u.APIKeyID]]
+		if !ok {
+			continue
+		}
+		results[i].APIKeys++
+		results[i].Requests += u.Requests
+		results[i].BytesIn += u.BytesIn
+		results[i].BytesOut += u.BytesOut
+		failures[i].client += u.ClientErrors
+		failures[i].server += u.ServerErrors
+	}
+
+	for i := range results {
+		if requests := float64(results[i].Requests); requests > 0 {
+			results[i].ClientErrorRate = float64(failures[i].client) / requests * 100
+			results[i].ServerErrorRate = float64(failures[i].server) / requests * 100
+			results[i].ErrorRate = results[i].ClientErrorRate + results[i].ServerErrorRate
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Requests > results[j].Requests })
+
+	return results, nil
+}
+
+// One key's part of its organization's traffic
+type OrganizationKeyUsage struct {
+	APIKeyID     uuid.UUID `json:"api_key_id"`
+	Name         string    `json:"name"`
+	Requests     int64     `json:"requests"`
+	Share        float64   `json:"share"` // Percent of the organization's requests
+	ClientErrors int64     `json:"client_errors"`
+	ServerErrors int64     `json:"server_errors"`
+	BytesIn      int64     `json:"bytes_in"`
+	BytesOut     int64     `json:"bytes_out"`
+}
+
+// Breaks an organization's traffic down by key, busiest first, to show which
+// keys use up its aggregate limit
+func (s *AnalyticsService) GetOrganizationKeyUsage(ctx context.Context, from, to time.Time, orgID uuid.UUID) ([]OrganizationKeyUsage, error) {
+	names, err := s.orgs.KeyNames(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.repository.GetKeyUsage(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]OrganizationKeyUsage, 0)
+	var total int64
+	for _, u := range usage {
+		name, ok := names[u.APIKeyID]
+		if !ok {
+			continue
+		}
+		keys = append(keys, OrganizationKeyUsage{
+			APIKeyID:     u.APIKeyID,
+			Name:         name,
+			Requests:     u.Requests,
+			ClientErrors: u.ClientErrors,
+			ServerErrors: u.ServerErrors,
+			BytesIn:      u.BytesIn,
+			BytesOut:     u.BytesOut,
+		})
+		total += u.Requests
+	}
+
+	for i := range keys {
+		if total > 0 {
+			keys[i].Share = float64(keys[i].Requests) / float64(total) * 100
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Requests != keys[j].Requests {
+			return keys[i].Requests > keys[j].Requests
+		}
+		return keys[i].Name < keys[j].Name
+	})
+
+	return keys, nil
+}
+
+// Reports whether an API key belongs to the organization
+func (s *AnalyticsService) OrganizationOwnsKey(ctx context.Context, orgID, apiKeyID uuid.UUID) (bool, error) {
+	return s.orgs.OwnsAPIKey(ctx, orgID, apiKeyID)
+}
+
+// Traffic from one country over a time range
+type TopCountry struct {
+	Country         string  `json:"country"` // Empty for unknown
+	Requests        int64   `json:"requests"`
+	ErrorRate       float64 `json:"error_rate"`
+	ClientErrorRate float64 `json:"client_error_rate"`
+	ServerErrorRate float64 `json:"server_error_rate"`
+	AvgResponseTime float64 `json:"avg_response_time_ms"`
+}
+
+// Returns the countries sending the most requests
+func (s *AnalyticsService) GetTopCountries(ctx context.Context, from, to time.Time, limit int) ([]TopCountry, error) {
+	traffic, err := s.repository.GetTopCountries(ctx, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	countries := make([]TopCountry, 0, len(traffic))
+	for _, t := range traffic {
+		country := TopCountry{
+			Country:         t.Country,
+			Requests:        t.Requests,
+			AvgResponseTime: t.AvgLatencyMs,
+		}
+		if t.Requests > 0 {
+			country.ClientErrorRate = float64(t.ClientErrors) / float64(t.Requests) * 100
+			country.ServerErrorRate = float64(t.ServerErrors) / float64(t.Requests) * 100
+			country.ErrorRate = country.ClientErrorRate + country.ServerErrorRate
+		}
+		countries = append(countries, country)
+	}
+
+	return countries, nil
+}
+
+// Traffic of one API version over a time range
+type VersionStats struct {
+	Service         string  `json:"service"`
+	Version         string  `json:"version"`
+	Requests        int64   `json:"requests"`
+	ErrorRate       float64 `json:"error_rate"`
+	ClientErrorRate float64 `json:"client_error_rate"`
+	ServerErrorRate float64 `json:"server_error_rate"`
+	AvgResponseTime float64 `json:"avg_response_time_ms"`
+}
+
+// Returns the traffic of each version of versioned services
+func (s *AnalyticsService) GetVersionTraffic(ctx context.Context, from, to time.Time) ([]VersionStats, error) {
+	traffic, err := s.repository.GetVersionTraffic(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]VersionStats, 0, len(traffic))
+	for _, t := range traffic {
+		service, version, _ := strings.Cut(t.Version, "@")
+		stats := VersionStats{
+			Service:         service,
+			Version:         version,
+			Requests:        t.Requests,
+			AvgResponseTime: t.AvgLatencyMs,
+		}
+		if t.Requests > 0 {
+			stats.ClientErrorRate = float64(t.ClientErrors) / float64(t.Requests) * 100
+			stats.ServerErrorRate = float64(t.ServerErrors) / float64(t.Requests) * 100
+			stats.ErrorRate = stats.ClientErrorRate + stats.ServerErrorRate
+		}
+		versions = append(versions, stats)
+	}
+
+	return versions, nil
+}
+
+// Traffic of one GraphQL operation over a time range
+type TopOperation struct {
+	Path            string  `json:"path"`
+	Operation       string  `json:"operation"`
+	Requests        int64   `json:"requests"`
+	ErrorRate       float64 `json:"error_rate"`
+	ClientErrorRate float64 `json:"client_error_rate"`
+	ServerErrorRate float64 `json:"server_error_rate"`
+	AvgResponseTime float64 `json:"avg_response_time_ms"`
+}
+
+// Returns the busiest GraphQL operations
+func (s *AnalyticsService) GetTopOperations(ctx context.Context, from, to time.Time, limit int) ([]TopOperation, error) {
+	traffic, err := s.repository.GetTopOperations(ctx, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	operations := make([]TopOperation, 0, len(traffic))
+	for _, t := range traffic {
+		op := TopOperation{
+			Path:            t.Path,
+			Operation:       t.Operation,
+			Requests:        t.Requests,
+			AvgResponseTime: t.AvgLatencyMs,
+		}
+		if t.Requests > 0 {
+			op.ClientErrorRate = float64(t.ClientErrors) / float64(t.Requests) * 100
+			op.ServerErrorRate = float64(t.ServerErrors) / float64(t.Requests) * 100
+			op.ErrorRate = op.ClientErrorRate + op.ServerErrorRate
+		}
+		operations = append(operations, op)
+	}
+
+	return operations, nil
+}
+
 // Retrieves analytics for a specific API key
 func (s *AnalyticsService) GetAPIKeyStats(ctx context.Context, apiKeyID uuid.UUID, from, to time.Time) (*AnalyticsSummary, error) {
 	// Similar to GetSummary but filtered by API key
@@ -133,32 +532,34 @@ func (s *AnalyticsService) GetAPIKeyStats(ctx context.Context, apiKeyID uuid.UUI
 		return &AnalyticsSummary{}, nil
 	}
 
-	// Calculate metrics from logs
-	summary := &AnalyticsSummary{
-		TotalRequests: int64(len(logs)),
-	}
-
-	var totalResponseTime int64
-	var clientErrors, serverErrors int64
+	// Calculate metrics from logs, each standing for the requests sampling skipped
+	var totalRequests, totalResponseTime float64
+	var clientErrors, serverErrors float64
 
 	for _, log := range logs {
-		totalResponseTime += int64(log.ResponseTimeMs)
+		weight := log.Weight()
+		totalRequests += weight
+		totalResponseTime += float64(log.ResponseTimeMs) * weight
 
 		if log.StatusCode >= 400 && log.StatusCode <= 499 {
-			clientErrors++
+			clientErrors += weight
 		}
 		if log.StatusCode >= 500 && log.StatusCode <= 599 {
-			serverErrors++
+			serverErrors += weight
 		}
 
 	}
-	summary.AvgResponseTime = float64(totalResponseTime) / float64(summary.TotalRequests)
+
+	summary := &AnalyticsSummary{
+		TotalRequests: int64(math.Round(totalRequests)),
+	}
+	summary.AvgResponseTime = totalResponseTime / totalRequests
 
 	totalErrors := clientErrors + serverErrors
-	summary.ErrorRate = (float64(totalErrors) / float64(summary.TotalRequests)) * 100
+	summary.ErrorRate = (totalErrors / totalRequests) * 100
 	summary.SuccessRate = 100 - summary.ErrorRate
-	summary.ClientErrorRate = (float64(clientErrors) / float64(summary.TotalRequests)) * 100
-	summary.ServerErrorRate = (float64(serverErrors) / float64(summary.TotalRequests)) * 100
+	summary.ClientErrorRate = (clientErrors / totalRequests) * 100
+	summary.ServerErrorRate = (serverErrors / totalRequests) * 100
 
 	return summary, nil
 }
@@ -188,6 +589,11 @@ func (s *AnalyticsService) GetLogs(ctx context.Context, from, to time.Time, stat
 	return logs, nil
 }
 
+// Calls fn for each matching request log without buffering the result set
+func (s *AnalyticsService) StreamLogs(ctx context.Context, from, to time.Time, statusCode *int, limit, offset int, fn func(models.RequestLog) error) error {
+	return s.repository.Stream(ctx, from, to, statusCode, limit, offset, fn)
+}
+
 // Deletes logs older than specified retention period
 func (s *AnalyticsService) CleanupOldLogs(ctx context.Context, retentionDays int) (int64, error) {
 	cutOffDate := time.Now().AddDate(0, 0, -retentionDays)
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -10,31 +11,50 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/aman-churiwal/api-gateway/internal/audit"
 	"github.com/aman-churiwal/api-gateway/internal/models"
 	"github.com/aman-churiwal/api-gateway/internal/repository"
 	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/aman-churiwal/api-gateway/internal/webhook"
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Number of leading key characters stored for identification
+const KeyPrefixLength = 8
+
+// How long and how many of a key's client IPs are remembered. An IP not seen
+// within the window, or pushed out by newer ones, counts as new again.
+const (
+	keyIPWindow = 30 * 24 * time.Hour
+	keyIPLimit  = 1000
 )
 
 type APIKeyService struct {
 	db         *storage.Postgres
 	repository *repository.APIKeyRepository
 	redis      *storage.RedisClient
+	webhooks   *webhook.Dispatcher
+
+	degradation   degradation
+	signingSecret []byte // Derives per-key request signing secrets; nil disables signed requests
 }
 
-func NewAPIKeyService(db *storage.Postgres, repo *repository.APIKeyRepository, redis *storage.RedisClient) *APIKeyService {
+func NewAPIKeyService(db *storage.Postgres, repo *repository.APIKeyRepository, redis *storage.RedisClient, webhooks *webhook.Dispatcher) *APIKeyService {
 	return &APIKeyService{
 		db:         db,
 		repository: repo,
 		redis:      redis,
+		webhooks:   webhooks,
 	}
 }
 
-func (s *APIKeyService) Create(ctx context.Context, name, createdBy, tier string) (string, error) {
+// Generates a new plain key and its storage hash
+func generateKey() (string, string, error) {
 	// Generate random key
 	keyBytes := make([]byte, 32)
 	if _, err := rand.Read(keyBytes); err != nil {
-		return "", fmt.Errorf("failed to generate random key: %w", err)
+		return "", "", fmt.Errorf("failed to generate random key: %w", err)
 	}
 
 	// Creating key with prefix
@@ -44,19 +64,60 @@ func (s *APIKeyService) Create(ctx context.Context, name, createdBy, tier string
 	hash := sha256.Sum256([]byte(key))
 	keyHash := hex.EncodeToString(hash[:])
 
-	// Save to database
-	apiKey := models.APIKey{
-		KeyHash:   keyHash,
+	return key, keyHash, nil
+}
+
+// Creates a key, in an organization when orgID is set
+func (s *APIKeyService) Create(ctx context.Context, name, createdBy, tier string, owner models.KeyOwner, orgID *uuid.UUID, notes string, tags []string, metadata map[string]string) (string, error) {
+	return s.create(ctx, &models.APIKey{
 		Name:      name,
 		CreatedBy: createdBy,
 		Tier:      tier,
-		IsActive:  true,
+		Tags:      tags,
+		Metadata:  metadata,
+		KeyOwner:  owner,
+		OrgID:     orgID,
+		Notes:     notes,
+	})
+}
+
+// Creates a key a developer requested through the portal, owned by them
+func (s *APIKeyService) CreateForDeveloper(ctx context.Context, developer *models.Developer, name, tier string) (*models.APIKey, string, error) {
+	apiKey := &models.APIKey{
+		Name:        name,
+		CreatedBy:   "developer:" + developer.Email,
+		Tier:        tier,
+		Tags:        models.StringList{},
+		Metadata:    models.StringMap{},
+		KeyOwner:    models.KeyOwner{Owner: developer.Name, ContactEmail: developer.Email},
+		DeveloperID: &developer.ID,
+	}
+	key, err := s.create(ctx, apiKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return apiKey, key, nil
+}
+
+// Generates the secret for a new key and stores it, returning the plain key
+func (s *APIKeyService) create(ctx context.Context, apiKey *models.APIKey) (string, error) {
+	key, keyHash, err := generateKey()
+	if err != nil {
+		return "", err
 	}
 
-	if err := s.repository.Create(ctx, &apiKey); err != nil {
+	apiKey.KeyHash = keyHash
+	apiKey.KeyPrefix = key[:KeyPrefixLength]
+	apiKey.IsActive = true
+
+	// Save to database
+	if err := s.repository.Create(ctx, apiKey); err != nil {
 		return "", fmt.Errorf("failed to create API key: %w", err)
 	}
 
+	s.webhooks.Dispatch(webhook.EventAPIKeyCreated, keyEventData(apiKey))
+	audit.Record(ctx, "api_key.create", "api_key", apiKey.ID.String(), nil, apiKey)
+
 	// Return plain key (only time it's visible)
 	return key, nil
 }
@@ -73,6 +134,7 @@ func (s *APIKeyService) Validate(ctx context.Context, key string) (*models.APIKe
 		// Cache hit
 		var apiKey models.APIKey
 		if err := json.Unmarshal([]byte(cached), &apiKey); err == nil {
+			s.markSeen(keyHash, &apiKey)
 			return &apiKey, nil
 		}
 	}
@@ -80,7 +142,7 @@ func (s *APIKeyService) Validate(ctx context.Context, key string) (*models.APIKe
 	// Cache miss - query database
 	apiKey, err := s.repository.FindByHash(ctx, keyHash)
 	if err != nil {
-		return nil, err
+		return s.degrade(ctx, keyHash, err)
 	}
 
 	if apiKey == nil {
@@ -90,6 +152,7 @@ func (s *APIKeyService) Validate(ctx context.Context, key string) (*models.APIKe
 	// Cache the result
 	apiKeyJSON, _ := json.Marshal(apiKey)
 	s.redis.Set(ctx, cacheKey, apiKeyJSON, 5*time.Minute)
+	s.remember(ctx, keyHash, apiKey, apiKeyJSON)
 
 	return apiKey, nil
 }
@@ -102,6 +165,10 @@ func (s *APIKeyService) List(ctx context.Context) ([]models.APIKey, error) {
 	return s.repository.List(ctx)
 }
 
+func (s *APIKeyService) ListFiltered(ctx context.Context, filter repository.APIKeyFilter) ([]models.APIKey, int64, error) {
+	return s.repository.ListFiltered(ctx, filter)
+}
+
 func (s *APIKeyService) Update(ctx context.Context, id string, updates map[string]interface{}) error {
 	// Invalidate cache if tier or is_active is updated
 	if _, hasTier := updates["tier"]; hasTier {
@@ -111,14 +178,137 @@ func (s *APIKeyService) Update(ctx context.Context, id string, updates map[strin
 		s.invalidateCache(ctx, id)
 	}
 
-	return s.repository.Update(ctx, id, updates)
+	before, _ := s.repository.FindByID(ctx, id)
+
+	if err := s.repository.Update(ctx, id, updates); err != nil {
+		return err
+	}
+
+	after, _ := s.repository.FindByID(ctx, id)
+	audit.Record(ctx, "api_key.update", "api_key", id, before, after)
+
+	if active, ok := updates["is_active"].(bool); ok && !active && after != nil {
+		s.webhooks.Dispatch(webhook.EventAPIKeyDeactivated, keyEventData(after))
+	}
+
+	return nil
+}
+
+// Replaces the secret of an existing key, returning the new plain key
+func (s *APIKeyService) Rotate(ctx context.Context, id string) (string, error) {
+	apiKey, err := s.repository.FindByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if apiKey == nil {
+		return "", nil
+	}
+
+	key, keyHash, err := generateKey()
+	if err != nil {
+		return "", err
+	}
+
+	updates := map[string]interface{}{
+		"key_hash":   keyHash,
+		"key_prefix": key[:KeyPrefixLength],
+	}
+	if err := s.repository.Update(ctx, id, updates); err != nil {
+		return "", fmt.Errorf("failed to rotate API key: %w", err)
+	}
+
+	// Only once the hash is replaced, so a request in between can't cache the old key again
+	s.invalidateHash(ctx, apiKey.KeyHash)
+
+	audit.Record(ctx, "api_key.rotate", "api_key", id,
+		map[string]string{"key_prefix": apiKey.KeyPrefix},
+		map[string]string{"key_prefix": key[:KeyPrefixLength]})
+
+	apiKey.KeyPrefix = key[:KeyPrefixLength]
+	s.webhooks.Dispatch(webhook.EventAPIKeyRotated, keyEventData(apiKey))
+
+	return key, nil
+}
+
+// Returns active keys nobody can be contacted about
+func (s *APIKeyService) ListUnowned(ctx context.Context) ([]models.APIKey, error) {
+	return s.repository.ListUnowned(ctx)
+}
+
+// Hands a key over to a new owner and records the transfer in its notes.
+// Returns nil if the key does not exist.
+func (s *APIKeyService) Transfer(ctx context.Context, id string, to models.KeyOwner, by, reason string) (*models.APIKey, error) {
+	apiKey, err := s.repository.FindByID(ctx, id)
+	if err != nil || apiKey == nil {
+		return nil, err
+	}
+
+	before := *apiKey
+	previous := apiKey.KeyOwner
+	entry := fmt.Sprintf("%s: ownership transferred from %s to %s by %s",
+		time.Now().UTC().Format(time.RFC3339), ownerLabel(previous), ownerLabel(to), by)
+	if reason != "" {
+		entry += " (" + reason + ")"
+	}
+	notes := entry
+	if apiKey.Notes != "" {
+		notes = apiKey.Notes + "\n" + entry
+	}
+
+	updates := map[string]interface{}{
+		"owner":         to.Owner,
+		"team":          to.Team,
+		"contact_email": to.ContactEmail,
+		"notes":         notes,
+	}
+	if err := s.repository.Update(ctx, id, updates); err != nil {
+		return nil, fmt.Errorf("failed to transfer API key: %w", err)
+	}
+
+	apiKey.KeyOwner = to
+	apiKey.Notes = notes
+	audit.Record(ctx, "api_key.transfer", "api_key", id, &before, apiKey)
+
+	data := keyEventData(apiKey)
+	data["previous_owner"] = previous.Owner
+	data["previous_team"] = previous.Team
+	data["transferred_by"] = by
+	s.webhooks.Dispatch(webhook.EventAPIKeyTransferred, data)
+
+	return apiKey, nil
+}
+
+// Formats an owner for the notes trail, e.g. "alice <alice@example.com> (payments)"
+func ownerLabel(owner models.KeyOwner) string {
+	if owner.Owner == "" {
+		return "nobody"
+	}
+	label := owner.Owner
+	if owner.ContactEmail != "" {
+		label += " <" + owner.ContactEmail + ">"
+	}
+	if owner.Team != "" {
+		label += " (" + owner.Team + ")"
+	}
+	return label
 }
 
 func (s *APIKeyService) Delete(ctx context.Context, id string) error {
+	apiKey, _ := s.repository.FindByID(ctx, id)
+
 	// Invalidate cache
 	s.invalidateCache(ctx, id)
 
-	return s.repository.Delete(ctx, id)
+	if err := s.repository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	if apiKey != nil {
+		s.webhooks.Dispatch(webhook.EventAPIKeyDeleted, keyEventData(apiKey))
+		audit.Record(ctx, "api_key.delete", "api_key", id, apiKey, nil)
+	}
+
+	return nil
 }
 
 func (s *APIKeyService) UpdateLastUsed(ctx context.Context, id uuid.UUID) {
@@ -126,6 +316,68 @@ func (s *APIKeyService) UpdateLastUsed(ctx context.Context, id uuid.UUID) {
 	s.repository.UpdateLastUsed(ctx, id)
 }
 
+// Enables HMAC-signed requests. Each key signs with a secret derived from this
+// one and the key's hash, so the stored hash alone can't sign requests.
+func (s *APIKeyService) SetSigningSecret(secret string) {
+	if secret != "" {
+		s.signingSecret = []byte(secret)
+	}
+}
+
+// Returns the request signing secret of the key with the given hash, or ""
+// when signed requests are off
+func (s *APIKeyService) SigningSecret(keyHash string) string {
+	if s.signingSecret == nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, s.signingSecret)
+	mac.Write([]byte(keyHash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Returns the request signing secret of a plain key, shown alongside it when
+// it is created or rotated
+func (s *APIKeyService) SigningSecretForKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return s.SigningSecret(hex.EncodeToString(hash[:]))
+}
+
+// Records key usage and notifies when a key is used from a new IP
+func (s *APIKeyService) RecordUsage(ctx context.Context, apiKey *models.APIKey, ip string) {
+	s.repository.UpdateLastUsed(ctx, apiKey.ID)
+
+	// Sorted by when each IP was last seen, so old and excess IPs can be trimmed
+	key := fmt.Sprintf("apikey:ips:%s", apiKey.ID)
+	now := time.Now()
+	pipe := s.redis.Pipeline()
+	pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("%d", now.Add(-keyIPWindow).Unix()))
+	added := pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.Unix()), Member: ip})
+	pipe.ZRemRangeByRank(ctx, key, 0, -keyIPLimit-1)
+	pipe.Expire(ctx, key, keyIPWindow)
+	if _, err := pipe.Exec(ctx); err != nil || added.Val() == 0 {
+		return
+	}
+
+	data := keyEventData(apiKey)
+	data["ip_address"] = ip
+	s.webhooks.Dispatch(webhook.EventAPIKeyNewIP, data)
+}
+
+// Returns the non-sensitive key fields included in webhook events
+func keyEventData(apiKey *models.APIKey) map[string]interface{} {
+	return map[string]interface{}{
+		"id":            apiKey.ID.String(),
+		"name":          apiKey.Name,
+		"key_prefix":    apiKey.KeyPrefix,
+		"tier":          apiKey.Tier,
+		"created_by":    apiKey.CreatedBy,
+		"owner":         apiKey.Owner,
+		"team":          apiKey.Team,
+		"contact_email": apiKey.ContactEmail,
+		"org_id":        apiKey.OrgID,
+	}
+}
+
 func (s *APIKeyService) invalidateCache(ctx context.Context, id string) {
 	// Get the key to find its hash
 	apiKey, err := s.repository.FindByID(ctx, id)
@@ -133,6 +385,11 @@ func (s *APIKeyService) invalidateCache(ctx context.Context, id string) {
 		return
 	}
 
-	cacheKey := fmt.Sprintf("apikey:cache:%s", apiKey.KeyHash)
+	s.invalidateHash(ctx, apiKey.KeyHash)
+}
+
+func (s *APIKeyService) invalidateHash(ctx context.Context, keyHash string) {
+	cacheKey := fmt.Sprintf("apikey:cache:%s", keyHash)
 	s.redis.Set(ctx, cacheKey, "", 0) // Delete by setting empty with no TTL
+	s.forget(ctx, keyHash)
 }
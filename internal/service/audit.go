@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/repository"
+)
+
+// Queries the admin audit log. Records are written by middleware.Audit.
+type AuditService struct {
+	repository *repository.AuditLogRepository
+}
+
+func NewAuditService(repo *repository.AuditLogRepository) *AuditService {
+	return &AuditService{repository: repo}
+}
+
+// Returns matching audit records newest first, with the total count
+func (s *AuditService) List(ctx context.Context, filter repository.AuditLogFilter) ([]models.AuditLog, int64, error) {
+	return s.repository.List(ctx, filter)
+}
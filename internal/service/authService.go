@@ -2,32 +2,105 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/aman-churiwal/api-gateway/internal/audit"
+	"github.com/aman-churiwal/api-gateway/internal/jwtkeys"
 	"github.com/aman-churiwal/api-gateway/internal/models"
 	"github.com/aman-churiwal/api-gateway/internal/repository"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/aman-churiwal/api-gateway/internal/webhook"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService struct {
-	repo      *repository.AuthRepository
-	jwtSecret []byte // Stored in env (JWT_SECRET)
-	jwtExpiry time.Duration
+	repo           *repository.AuthRepository
+	refreshRepo    *repository.RefreshTokenRepository
+	inviteRepo     *repository.InvitationRepository
+	resetRepo      *repository.PasswordResetRepository
+	redis          *storage.RedisClient // Holds revoked access token IDs
+	webhooks       *webhook.Dispatcher  // Delivers password reset tokens
+	jwtSecret      []byte               // Stored in env (JWT_SECRET) or Vault, used when no key set is configured
+	keys           *jwtkeys.KeySet      // Asymmetric signing keys
+	jwtExpiry      time.Duration
+	refreshExpiry  time.Duration
+	registration   string // "open" or "invite"
+	inviteExpiry   time.Duration
+	resetExpiry    time.Duration
+	throttle       *LoginThrottle
+	exchangeIssuer string
+
+	secretMu     sync.RWMutex // Guards the secrets, which Vault rotations replace
+	oldJWTSecret []byte       // Still accepted after a rotation until oldSecretEnd
+	oldSecretEnd time.Time    // When the last token signed with oldJWTSecret expires
+}
+
+// Token lifetimes and registration policy
+type AuthSettings struct {
+	JWTSecret           string
+	Keys                *jwtkeys.KeySet // Signs with RS256/EdDSA instead of the HS256 secret when set
+	AccessExpiry        time.Duration
+	RefreshExpiry       time.Duration
+	Registration        string // "open" or "invite"
+	InvitationExpiry    time.Duration
+	PasswordResetExpiry time.Duration
+	LoginThrottle       *LoginThrottle // Backs off and locks out repeated failed logins when set
+	ExchangeIssuer      string         // Issuer of backend tokens from token exchange, which are never admin tokens
+}
+
+// Access and refresh tokens issued on login or refresh
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // Access token lifetime in seconds
 }
 
-func NewAuthService(repo *repository.AuthRepository, secret string, expiryHours int) *AuthService {
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRegistrationClosed  = errors.New("registration requires an invitation")
+	ErrInvalidInvitation   = errors.New("invalid or expired invitation")
+	ErrInvalidResetToken   = errors.New("invalid or expired reset token")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrExternalAccount     = errors.New("account is managed by an external identity provider")
+)
+
+func NewAuthService(repo *repository.AuthRepository, refreshRepo *repository.RefreshTokenRepository, inviteRepo *repository.InvitationRepository, resetRepo *repository.PasswordResetRepository, redis *storage.RedisClient, webhooks *webhook.Dispatcher, settings AuthSettings) *AuthService {
 	return &AuthService{
-		repo:      repo,
-		jwtSecret: []byte(secret),
-		jwtExpiry: time.Duration(expiryHours) * time.Hour,
+		repo:           repo,
+		refreshRepo:    refreshRepo,
+		inviteRepo:     inviteRepo,
+		resetRepo:      resetRepo,
+		redis:          redis,
+		webhooks:       webhooks,
+		jwtSecret:      []byte(settings.JWTSecret),
+		keys:           settings.Keys,
+		jwtExpiry:      settings.AccessExpiry,
+		refreshExpiry:  settings.RefreshExpiry,
+		registration:   settings.Registration,
+		inviteExpiry:   settings.InvitationExpiry,
+		resetExpiry:    settings.PasswordResetExpiry,
+		throttle:       settings.LoginThrottle,
+		exchangeIssuer: settings.ExchangeIssuer,
 	}
 }
 
-// Creates a new admin user
-func (s *AuthService) Register(ctx context.Context, email, password, name string) error {
+// Creates a new admin user. In invite mode only the first user may register
+// without an invitation token.
+func (s *AuthService) Register(ctx context.Context, email, password, name, invitationToken string) error {
 	existingUser, err := s.repo.FindByEmail(ctx, email)
 	if err != nil {
 		return err
@@ -36,62 +109,587 @@ func (s *AuthService) Register(ctx context.Context, email, password, name string
 		return errors.New("user with this email already exists")
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(password)
 	if err != nil {
-		return fmt.Errorf("failed to hash password: %w", err)
+		return err
 	}
 
 	user := &models.User{
 		Email:        email,
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
 		Name:         name,
 		Role:         "admin",
 	}
 
-	return s.repo.Create(ctx, user)
+	if s.registration != "invite" {
+		return s.repo.Create(ctx, user)
+	}
+
+	if invitationToken == "" {
+		err := s.repo.CreateFirst(ctx, user)
+		if errors.Is(err, repository.ErrUsersExist) {
+			return ErrRegistrationClosed
+		}
+		return err
+	}
+
+	invitation, err := s.inviteRepo.FindByHash(ctx, hashToken(invitationToken))
+	if err != nil {
+		return err
+	}
+	if invitation == nil || invitation.AcceptedAt != nil || time.Now().After(invitation.ExpiresAt) {
+		return ErrInvalidInvitation
+	}
+	if invitation.Email != "" && !strings.EqualFold(invitation.Email, email) {
+		return ErrInvalidInvitation
+	}
+	user.OrgID = invitation.OrgID
+
+	err = s.inviteRepo.Redeem(ctx, invitation, user)
+	if errors.Is(err, repository.ErrInvitationUsed) {
+		return ErrInvalidInvitation
+	}
+	return err
+}
+
+// Issues an invitation token, optionally bound to an email address. Invitees
+// join orgID when it's set. The token is only returned here; just its hash is
+// stored.
+func (s *AuthService) CreateInvitation(ctx context.Context, createdBy, email string, orgID *uuid.UUID) (*models.Invitation, string, error) {
+	creatorID, err := uuid.Parse(createdBy)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid user id: %w", err)
+	}
+
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+
+	invitation := &models.Invitation{
+		Email:     strings.ToLower(email),
+		TokenHash: hashToken(token),
+		CreatedBy: creatorID,
+		OrgID:     orgID,
+		ExpiresAt: time.Now().Add(s.inviteExpiry),
+	}
+	if err := s.inviteRepo.Create(ctx, invitation); err != nil {
+		return nil, "", fmt.Errorf("failed to store invitation: %w", err)
+	}
+	audit.Record(ctx, "invitation.create", "invitation", invitation.ID.String(), nil, invitation)
+
+	return invitation, token, nil
+}
+
+// Lists invitations, optionally only the ones that can still be redeemed or
+// that invite into orgID
+func (s *AuthService) ListInvitations(ctx context.Context, pendingOnly bool, orgID *uuid.UUID) ([]models.Invitation, error) {
+	return s.inviteRepo.List(ctx, pendingOnly, orgID)
 }
 
-// Authenticates a user and returns a JWT token
-func (s *AuthService) Login(ctx context.Context, email, password string) (string, error) {
+// Deletes an invitation so its token can no longer be redeemed. When orgID is
+// set only that organization's invitations can be deleted.
+func (s *AuthService) RevokeInvitation(ctx context.Context, id string, orgID *uuid.UUID) error {
+	if err := s.inviteRepo.Delete(ctx, id, orgID); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, "invitation.revoke", "invitation", id, nil, nil)
+	return nil
+}
+
+// Authenticates a user and returns an access and refresh token. Returns a
+// *LoginThrottledError while the account or client IP is backing off.
+func (s *AuthService) Login(ctx context.Context, email, password, ip string) (*TokenPair, error) {
+	if s.throttle != nil {
+		if err := s.throttle.Check(ctx, email, ip); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := s.authenticate(ctx, email, password)
+	if err == ErrInvalidCredentials && s.throttle != nil {
+		s.throttle.Failed(ctx, email, ip)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.throttle != nil {
+		s.throttle.Succeeded(ctx, email, ip)
+	}
+
+	s.recordLogin(ctx, user)
+	return s.issueTokens(ctx, user, nil)
+}
+
+// Returns the local user matching email and password
+func (s *AuthService) authenticate(ctx context.Context, email, password string) (*models.User, error) {
 	// Find user by email
 	user, err := s.repo.FindByEmail(ctx, email)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if user == nil {
-		return "", errors.New("invalid credentials")
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.AuthProvider != "" && user.AuthProvider != "local" {
+		return nil, ErrExternalAccount
 	}
 
 	// verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", errors.New("invalid credentials")
+		return nil, ErrInvalidCredentials
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	return user, nil
+}
+
+// Signs in a user authenticated by an external identity provider, creating
+// the account on first login and keeping its role and email in sync with the
+// provider. Accounts are matched on the provider's subject, since the email
+// an IdP reports can be changed or reassigned.
+func (s *AuthService) LoginExternal(ctx context.Context, provider, subject, email, name, role string) (*TokenPair, error) {
+	user, err := s.repo.FindByExternalID(ctx, provider, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		existing, err := s.repo.FindByEmail(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			// Local accounts and other subjects are not taken over by an IdP login for the same address
+			if existing.AuthProvider != provider || existing.ExternalID != "" {
+				return nil, ErrExternalAccount
+			}
+
+			// Accounts created before subjects were recorded are linked on their next login
+			if err := s.repo.Update(ctx, existing.ID, map[string]interface{}{"external_id": subject}); err != nil {
+				return nil, fmt.Errorf("failed to update user: %w", err)
+			}
+			existing.ExternalID = subject
+			user = existing
+		}
+	} else if user.Email != email {
+		// The address moved at the provider; another account keeping it blocks the login
+		taken, err := s.repo.FindByEmail(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+		if taken != nil {
+			return nil, ErrExternalAccount
+		}
+	}
+
+	if user == nil {
+		// External accounts get a random password hash so local login can never succeed
+		unusable, err := generateOpaqueToken()
+		if err != nil {
+			return nil, err
+		}
+		passwordHash, err := hashPassword(unusable)
+		if err != nil {
+			return nil, err
+		}
+
+		user = &models.User{
+			Email:        email,
+			PasswordHash: passwordHash,
+			Name:         name,
+			Role:         role,
+			AuthProvider: provider,
+			ExternalID:   subject,
+		}
+		if err := s.repo.Create(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+
+		s.recordLogin(ctx, user)
+		return s.issueTokens(ctx, user, nil)
+	}
+
+	// A role change ends sessions carrying the old role claim
+	if user.Role != role {
+		if err := s.invalidateSessions(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.Role != role || user.Email != email || (name != "" && user.Name != name) {
+		updates := map[string]interface{}{"role": role, "email": email}
+		if name != "" {
+			updates["name"] = name
+		}
+		if err := s.repo.Update(ctx, user.ID, updates); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+		user.Role = role
+		user.Email = email
+	}
+
+	s.recordLogin(ctx, user)
+	return s.issueTokens(ctx, user, nil)
+}
+
+// Stamps the user's last login time for access reviews. Failures don't block the login.
+func (s *AuthService) recordLogin(ctx context.Context, user *models.User) {
+	now := time.Now()
+	if err := s.repo.Update(ctx, user.ID, map[string]interface{}{"last_login_at": now}); err != nil {
+		log.Printf("Failed to record login for %s: %v", user.Email, err)
+		return
+	}
+	user.LastLoginAt = &now
+}
+
+// Exchanges a refresh token for a new token pair, rotating the refresh token
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	stored, err := s.refreshRepo.FindByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// A rotated token being presented again means it leaked, so end every session of the user
+	if stored.RevokedAt != nil {
+		if err := s.refreshRepo.RevokeAllForUser(ctx, stored.UserID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.repo.FindById(ctx, stored.UserID.String())
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return s.issueTokens(ctx, user, stored)
+}
+
+// Signs an access token and stores a new refresh token, revoking the one it replaces
+func (s *AuthService) issueTokens(ctx context.Context, user *models.User, replaces *models.RefreshToken) (*TokenPair, error) {
+	claims := jwt.MapClaims{
 		"user_id": user.ID.String(),
 		"email":   user.Email,
 		"role":    user.Role,
 		"exp":     time.Now().Add(s.jwtExpiry).Unix(),
 		"iat":     time.Now().Unix(),
+		"jti":     uuid.New().String(),
+	}
+	// Scopes the user's admin access to their organization
+	if user.OrgID != nil {
+		claims["org_id"] = user.OrgID.String()
+	}
+
+	tokenString, err := s.sign(claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	refreshToken, err := generateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	stored := &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.refreshExpiry),
+	}
+
+	// Revoking first makes rotation atomic: of two refreshes racing with the
+	// same token only one revokes it, and the other is treated as reuse
+	if replaces != nil {
+		revoked, err := s.refreshRepo.Revoke(ctx, replaces.ID, &stored.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !revoked {
+			if err := s.refreshRepo.RevokeAllForUser(ctx, user.ID); err != nil {
+				return nil, err
+			}
+			return nil, ErrInvalidRefreshToken
+		}
+	}
+
+	if err := s.refreshRepo.Create(ctx, stored); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:  tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.jwtExpiry.Seconds()),
+	}, nil
+}
+
+// Issues a password reset token and hands it to the notification webhooks.
+// Unknown emails are ignored so the endpoint does not reveal which accounts exist.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user == nil || (user.AuthProvider != "" && user.AuthProvider != "local") {
+		return nil
+	}
+
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	reset := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.resetExpiry),
+	}
+	if err := s.resetRepo.Create(ctx, reset); err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	s.webhooks.Dispatch(webhook.EventPasswordResetRequested, map[string]interface{}{
+		"user_id":    user.ID.String(),
+		"email":      user.Email,
+		"name":       user.Name,
+		"token":      token,
+		"expires_at": reset.ExpiresAt,
+	})
+
+	return nil
+}
+
+// Sets a new password using a reset token and ends every session of the user
+func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	reset, err := s.resetRepo.FindByHash(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+	if reset == nil || reset.UsedAt != nil || time.Now().After(reset.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	consumed, err := s.resetRepo.MarkUsed(ctx, reset.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.repo.FindById(ctx, reset.UserID.String())
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidResetToken
+	}
+
+	return s.setPassword(ctx, user, newPassword)
+}
+
+// Changes the password of an authenticated user after verifying the current one.
+// Other sessions are ended; the returned token pair keeps the caller signed in.
+func (s *AuthService) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) (*TokenPair, error) {
+	user, err := s.repo.FindById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+	if user.AuthProvider != "" && user.AuthProvider != "local" {
+		return nil, ErrExternalAccount
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := s.setPassword(ctx, user, newPassword); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, user, nil)
+}
+
+// Stores a new password hash and invalidates existing sessions and reset tokens
+func (s *AuthService) setPassword(ctx context.Context, user *models.User, password string) error {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if err := s.invalidateSessions(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := s.resetRepo.InvalidateForUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	s.webhooks.Dispatch(webhook.EventPasswordChanged, map[string]interface{}{
+		"user_id": user.ID.String(),
+		"email":   user.Email,
 	})
 
-	tokenString, err := token.SignedString(s.jwtSecret)
+	return nil
+}
+
+// Revokes all refresh tokens of a user and rejects access tokens issued before now
+func (s *AuthService) invalidateSessions(ctx context.Context, userID uuid.UUID) error {
+	if err := s.refreshRepo.RevokeAllForUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	// Kept for as long as any access token issued before now can still be valid
+	cutoff := strconv.FormatInt(time.Now().Unix(), 10)
+	if err := s.redis.Set(ctx, sessionCutoffKey(userID.String()), cutoff, s.jwtExpiry); err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	return nil
+}
+
+func sessionCutoffKey(userID string) string {
+	return "auth:sessions_valid_after:" + userID
+}
+
+func hashPassword(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate token: %w", err)
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashedPassword), nil
+}
+
+// Returns a random URL-safe token
+func generateOpaqueToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(tokenBytes), nil
+}
+
+// Returns the storage hash of an opaque token
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// Signs claims with the key set, or the shared secret when none is configured
+func (s *AuthService) sign(claims jwt.MapClaims) (string, error) {
+	if s.keys != nil {
+		return s.keys.Sign(claims)
 	}
+	s.secretMu.RLock()
+	defer s.secretMu.RUnlock()
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+}
 
-	return tokenString, nil
+// Signs new tokens with secret. Tokens signed with the previous secret stay
+// valid until they expire.
+func (s *AuthService) RotateJWTSecret(secret string) {
+	s.secretMu.Lock()
+	defer s.secretMu.Unlock()
+
+	s.oldJWTSecret = s.jwtSecret
+	s.oldSecretEnd = time.Now().Add(s.jwtExpiry)
+	s.jwtSecret = []byte(secret)
 }
 
-// Validates a JWT token and return the claims
+// Returns the public signing keys for the JWKS endpoint
+func (s *AuthService) JWKs() []jwtkeys.JWK {
+	if s.keys == nil {
+		return []jwtkeys.JWK{}
+	}
+	return s.keys.JWKs()
+}
+
+// Audience of developer portal tokens. They are signed like admin tokens, so
+// each realm checks it to keep the other's tokens out.
+const PortalAudience = "developer-portal"
+
+// Validates an admin JWT token and returns the claims. Admin tokens carry no
+// audience, which keeps portal, OAuth client and backend tokens out.
 func (s *AuthService) ValidateToken(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Verifying signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.jwtSecret, nil
+	claims, err := s.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if audience, _ := claims.GetAudience(); len(audience) > 0 {
+		return nil, errors.New("invalid token audience")
+	}
+	if issuer, _ := claims.GetIssuer(); s.exchangeIssuer != "" && issuer == s.exchangeIssuer {
+		return nil, errors.New("invalid token issuer")
+	}
+	return claims, nil
+}
+
+// Signs an access token for a developer portal session
+func (s *AuthService) IssuePortalToken(developer *models.Developer) (string, int64, error) {
+	token, err := s.sign(jwt.MapClaims{
+		"developer_id": developer.ID.String(),
+		"email":        developer.Email,
+		"aud":          PortalAudience,
+		"exp":          time.Now().Add(s.jwtExpiry).Unix(),
+		"iat":          time.Now().Unix(),
+		"jti":          uuid.New().String(),
 	})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return token, int64(s.jwtExpiry.Seconds()), nil
+}
+
+// Validates a developer portal token and returns the claims
+func (s *AuthService) ValidatePortalToken(tokenString string) (jwt.MapClaims, error) {
+	claims, err := s.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if !inPortalRealm(claims) {
+		return nil, errors.New("invalid token audience")
+	}
+	return claims, nil
+}
+
+func inPortalRealm(claims jwt.MapClaims) bool {
+	audience, _ := claims.GetAudience()
+	return slices.Contains(audience, PortalAudience)
+}
+
+// Checks a token's signature and expiry
+func (s *AuthService) parseToken(tokenString string) (jwt.MapClaims, error) {
+	var token *jwt.Token
+	var err error
+	if s.keys != nil {
+		token, err = jwt.Parse(tokenString, s.keys.Keyfunc, jwt.WithValidMethods(s.keys.Algorithms()))
+	} else {
+		s.secretMu.RLock()
+		secret, oldSecret, oldSecretEnd := s.jwtSecret, s.oldJWTSecret, s.oldSecretEnd
+		s.secretMu.RUnlock()
+
+		token, err = jwt.Parse(tokenString, hmacKeyfunc(secret))
+
+		// Fall back to the secret in use before the last rotation
+		if errors.Is(err, jwt.ErrTokenSignatureInvalid) && oldSecret != nil && time.Now().Before(oldSecretEnd) {
+			token, err = jwt.Parse(tokenString, hmacKeyfunc(oldSecret))
+		}
+	}
 
 	if err != nil {
 		return nil, err
@@ -109,6 +707,80 @@ func (s *AuthService) ValidateToken(tokenString string) (jwt.MapClaims, error) {
 	return claims, nil
 }
 
+// Returns a keyfunc verifying HS256 tokens against secret
+func hmacKeyfunc(secret []byte) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		// Verifying signing method
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return secret, nil
+	}
+}
+
+// Revokes an access token until it expires, and the refresh token if given
+func (s *AuthService) Logout(ctx context.Context, claims jwt.MapClaims, refreshToken string) error {
+	jti, _ := claims["jti"].(string)
+	if jti != "" {
+		ttl := time.Minute
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			ttl = time.Until(exp.Time)
+		}
+		if ttl > 0 {
+			if err := s.redis.Set(ctx, revokedTokenKey(jti), 1, ttl); err != nil {
+				return fmt.Errorf("failed to revoke token: %w", err)
+			}
+		}
+	}
+
+	if refreshToken != "" {
+		stored, err := s.refreshRepo.FindByHash(ctx, hashToken(refreshToken))
+		if err != nil {
+			return err
+		}
+		if stored != nil && stored.UserID.String() == claims["user_id"] {
+			_, err := s.refreshRepo.Revoke(ctx, stored.ID, nil)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reports whether an access token has been revoked by logout or a password change
+func (s *AuthService) IsRevoked(ctx context.Context, claims jwt.MapClaims) (bool, error) {
+	if jti, _ := claims["jti"].(string); jti != "" {
+		_, err := s.redis.Get(ctx, revokedTokenKey(jti))
+		if err == nil {
+			return true, nil
+		}
+		if err != redis.Nil {
+			return false, err
+		}
+	}
+
+	userID, _ := claims["user_id"].(string)
+	cutoff, err := s.redis.Get(ctx, sessionCutoffKey(userID))
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	validAfter, _ := strconv.ParseInt(cutoff, 10, 64)
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return true, nil
+	}
+
+	return issuedAt.Unix() < validAfter, nil
+}
+
+func revokedTokenKey(jti string) string {
+	return "auth:revoked:" + jti
+}
+
 // Retrieves a user by ID
 func (s *AuthService) GetUserByID(ctx context.Context, id string) (*models.User, error) {
 	return s.repo.FindById(ctx, id)
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/repository"
+)
+
+// Caps the privileged actions included in one access review
+const MaxReviewActions = 10000
+
+// Point-in-time report of who can administer the gateway and what they did
+type AccessReview struct {
+	GeneratedAt       time.Time        `json:"generated_at"`
+	ActionsSince      time.Time        `json:"actions_since"`
+	Summary           AccessSummary    `json:"summary"`
+	Users             []ReviewedUser   `json:"users"`
+	APIKeys           []ReviewedAPIKey `json:"api_keys"`
+	PrivilegedActions []ReviewedAction `json:"privileged_actions"`
+	ActionsTruncated  bool             `json:"actions_truncated"` // More than MaxReviewActions matched
+}
+
+type AccessSummary struct {
+	Admins             int   `json:"admins"`
+	Viewers            int   `json:"viewers"`
+	UsersNeverLoggedIn int   `json:"users_never_logged_in"`
+	ActiveAPIKeys      int   `json:"active_api_keys"`
+	UnownedAPIKeys     int   `json:"unowned_api_keys"`
+	PrivilegedActions  int64 `json:"privileged_actions"`
+}
+
+type ReviewedUser struct {
+	ID           string     `json:"id"`
+	Email        string     `json:"email"`
+	Name         string     `json:"name"`
+	Role         string     `json:"role"`
+	AuthProvider string     `json:"auth_provider"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastLoginAt  *time.Time `json:"last_login_at"`
+}
+
+// Tier and tags are what an API key is entitled to; keys carry no finer-grained scopes
+type ReviewedAPIKey struct {
+	ID           string     `json:"id"`
+	KeyPrefix    string     `json:"key_prefix"`
+	Name         string     `json:"name"`
+	Tier         string     `json:"tier"`
+	Tags         []string   `json:"tags"`
+	Owner        string     `json:"owner"`
+	Team         string     `json:"team"`
+	ContactEmail string     `json:"contact_email"`
+	CreatedBy    string     `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+}
+
+type ReviewedAction struct {
+	At           time.Time `json:"at"`
+	Actor        string    `json:"actor"`
+	IP           string    `json:"ip"`
+	Action       string    `json:"action"`
+	ResourceType string    `json:"resource_type"`
+	ResourceID   string    `json:"resource_id"`
+	Status       int       `json:"status"`
+}
+
+// Assembles access reviews for compliance audits
+type ComplianceService struct {
+	users    *repository.AuthRepository
+	keys     *repository.APIKeyRepository
+	auditLog *repository.AuditLogRepository
+}
+
+func NewComplianceService(users *repository.AuthRepository, keys *repository.APIKeyRepository, auditLog *repository.AuditLogRepository) *ComplianceService {
+	return &ComplianceService{users: users, keys: keys, auditLog: auditLog}
+}
+
+// Builds an access review covering privileged actions since the given time
+func (s *ComplianceService) AccessReview(ctx context.Context, since time.Time) (*AccessReview, error) {
+	users, err := s.users.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.keys.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every audit record is a mutating admin operation, so all of them count as privileged
+	entries, total, err := s.auditLog.List(ctx, repository.AuditLogFilter{Since: &since, Limit: MaxReviewActions})
+	if err != nil {
+		return nil, err
+	}
+
+	review := &AccessReview{
+		GeneratedAt:       time.Now().UTC(),
+		ActionsSince:      since.UTC(),
+		Users:             make([]ReviewedUser, 0, len(users)),
+		APIKeys:           make([]ReviewedAPIKey, 0, len(keys)),
+		PrivilegedActions: make([]ReviewedAction, 0, len(entries)),
+		ActionsTruncated:  total > int64(len(entries)),
+	}
+
+	for _, user := range users {
+		switch user.Role {
+		case models.RoleAdmin:
+			review.Summary.Admins++
+		case models.RoleViewer:
+			review.Summary.Viewers++
+		}
+		if user.LastLoginAt == nil {
+			review.Summary.UsersNeverLoggedIn++
+		}
+
+		review.Users = append(review.Users, ReviewedUser{
+			ID:           user.ID.String(),
+			Email:        user.Email,
+			Name:         user.Name,
+			Role:         user.Role,
+			AuthProvider: user.AuthProvider,
+			CreatedAt:    user.CreatedAt,
+			LastLoginAt:  user.LastLoginAt,
+		})
+	}
+
+	for _, key := range keys {
+		if key.Owner == "" || key.ContactEmail == "" {
+			review.Summary.UnownedAPIKeys++
+		}
+
+		tags := []string(key.Tags)
+		if tags == nil {
+			tags = []string{}
+		}
+		review.APIKeys = append(review.APIKeys, ReviewedAPIKey{
+			ID:           key.ID.String(),
+			KeyPrefix:    key.KeyPrefix,
+			Name:         key.Name,
+			Tier:         key.Tier,
+			Tags:         tags,
+			Owner:        key.Owner,
+			Team:         key.Team,
+			ContactEmail: key.ContactEmail,
+			CreatedBy:    key.CreatedBy,
+			CreatedAt:    key.CreatedAt,
+			LastUsedAt:   key.LastUsedAt,
+		})
+	}
+	review.Summary.ActiveAPIKeys = len(keys)
+
+	for _, entry := range entries {
+		review.PrivilegedActions = append(review.PrivilegedActions, ReviewedAction{
+			At:           entry.CreatedAt,
+			Actor:        entry.Actor,
+			IP:           entry.IP,
+			Action:       entry.Action,
+			ResourceType: entry.ResourceType,
+			ResourceID:   entry.ResourceID,
+			Status:       entry.Status,
+		})
+	}
+	review.Summary.PrivilegedActions = total
+
+	return review, nil
+}
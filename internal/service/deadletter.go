@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/audit"
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/repository"
+)
+
+// Returned when a dead letter can't be replayed
+var ErrDeadLetterNotRedrivable = errors.New("dead letter cannot be redriven")
+
+// Re-sends a captured request to a backend service
+type Redriver interface {
+	Redrive(ctx context.Context, method, path, rawQuery string, header http.Header, body []byte) (int, error)
+}
+
+type DeadLetterService struct {
+	repository *repository.DeadLetterRepository
+	mu         sync.RWMutex
+	redrivers  map[string]Redriver
+}
+
+func NewDeadLetterService(repo *repository.DeadLetterRepository, redrivers map[string]Redriver) *DeadLetterService {
+	return &DeadLetterService{
+		repository: repo,
+		redrivers:  redrivers,
+	}
+}
+
+// Replaces the services dead letters can be redriven through
+func (s *DeadLetterService) SetRedrivers(redrivers map[string]Redriver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.redrivers = redrivers
+}
+
+// Persists a failed request
+func (s *DeadLetterService) Capture(ctx context.Context, deadLetter *models.DeadLetter) {
+	if err := s.repository.Create(ctx, deadLetter); err != nil {
+		log.Printf("Failed to store dead letter for %s %s: %v", deadLetter.Method, deadLetter.Path, err)
+	}
+}
+
+func (s *DeadLetterService) Get(ctx context.Context, id string) (*models.DeadLetter, error) {
+	return s.repository.FindByID(ctx, id)
+}
+
+func (s *DeadLetterService) List(ctx context.Context, service string, pendingOnly bool, limit, offset int) ([]models.DeadLetter, error) {
+	return s.repository.List(ctx, service, pendingOnly, limit, offset)
+}
+
+func (s *DeadLetterService) Delete(ctx context.Context, id string) error {
+	before, _ := s.repository.FindByID(ctx, id)
+	if err := s.repository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, "dead_letter.delete", "dead_letter", id, before, nil)
+	return nil
+}
+
+// Re-sends a dead letter to its service and records the outcome
+func (s *DeadLetterService) Redrive(ctx context.Context, id string) (*models.DeadLetter, error) {
+	deadLetter, err := s.repository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if deadLetter == nil {
+		return nil, nil
+	}
+
+	if deadLetter.BodyTruncated {
+		return nil, fmt.Errorf("%w: body was truncated on capture", ErrDeadLetterNotRedrivable)
+	}
+
+	s.mu.RLock()
+	redriver, exists := s.redrivers[deadLetter.Service]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: service %s is no longer configured", ErrDeadLetterNotRedrivable, deadLetter.Service)
+	}
+
+	header := make(http.Header)
+	for name, value := range deadLetter.Headers {
+		header.Set(name, value)
+	}
+
+	statusCode, redriveErr := redriver.Redrive(ctx, deadLetter.Method, deadLetter.Path, deadLetter.RawQuery, header, deadLetter.Body)
+
+	updates := map[string]interface{}{
+		"attempts":       deadLetter.Attempts + 1,
+		"redrive_status": statusCode,
+	}
+	if redriveErr == nil && statusCode < 500 {
+		now := time.Now()
+		updates["redriven_at"] = now
+		deadLetter.RedrivenAt = &now
+	}
+	if err := s.repository.Update(ctx, id, updates); err != nil {
+		return nil, err
+	}
+
+	deadLetter.Attempts++
+	deadLetter.RedriveStatus = statusCode
+
+	if redriveErr != nil {
+		return deadLetter, redriveErr
+	}
+
+	return deadLetter, nil
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/config"
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/ratelimit"
+	"github.com/aman-churiwal/api-gateway/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrDeveloperExists      = errors.New("developer with this email already exists")
+	ErrTierNotOffered       = errors.New("tier is not available to developers")
+	ErrDeveloperKeyLimit    = errors.New("developer key limit reached")
+	ErrDeveloperKeyNotFound = errors.New("API key not found")
+)
+
+// Serves the developer portal: developers register, sign in and manage their
+// own API keys within the tiers and key count operators allow
+type DeveloperService struct {
+	repository  *repository.DeveloperRepository
+	apiKeys     *APIKeyService
+	apiKeyRepo  *repository.APIKeyRepository
+	requestLogs repository.RequestLogStore
+	analytics   *AnalyticsService
+	auth        *AuthService
+	throttle    *LoginThrottle
+	limiters    ratelimit.Factory
+	tiers       []config.RateLimiterTier
+	settings    config.PortalConfig
+}
+
+func NewDeveloperService(repo *repository.DeveloperRepository, apiKeys *APIKeyService, apiKeyRepo *repository.APIKeyRepository,
+	requestLogs repository.RequestLogStore, analytics *AnalyticsService, auth *AuthService, throttle *LoginThrottle, limiters ratelimit.Factory,
+	tiers []config.RateLimiterTier, settings config.PortalConfig) *DeveloperService {
+	return &DeveloperService{
+		repository:  repo,
+		apiKeys:     apiKeys,
+		apiKeyRepo:  apiKeyRepo,
+		requestLogs: requestLogs,
+		analytics:   analytics,
+		auth:        auth,
+		throttle:    throttle,
+		limiters:    limiters,
+		tiers:       tiers,
+		settings:    settings,
+	}
+}
+
+// A tier developers may pick for their keys
+type PortalTier struct {
+	Name              string `json:"name"`
+	RequestsPerMinute int    `json:"requests_per_minute"`
+	Default           bool   `json:"default"`
+}
+
+// The state of a key's tier limit in the current window
+type KeyQuota struct {
+	Tier      string    `json:"tier"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// A request log as developers see it, without backend details or captured bodies
+type PortalRequestLog struct {
+	Timestamp      time.Time `json:"timestamp"`
+	Method         string    `json:"method"`
+	Path           string    `json:"path"`
+	StatusCode     int       `json:"status_code"`
+	ResponseTimeMs int       `json:"response_time_ms"`
+	BytesIn        int64     `json:"bytes_in"`
+	BytesOut       int64     `json:"bytes_out"`
+	IPAddress      string    `json:"ip_address"`
+	ErrorType      string    `json:"error_type,omitempty"`
+}
+
+// Creates a developer account
+func (s *DeveloperService) Register(ctx context.Context, email, password, name string) (*models.Developer, error) {
+	email = strings.ToLower(email)
+	existing, err := s.repository.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrDeveloperExists
+	}
+
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	developer := &models.Developer{
+		Email:        email,
+		PasswordHash: hashedPassword,
+		Name:         name,
+		IsActive:     true,
+	}
+	if err := s.repository.Create(ctx, developer); err != nil {
+		return nil, fmt.Errorf("failed to create developer: %w", err)
+	}
+	return developer, nil
+}
+
+// Authenticates a developer and issues a portal token, returning it with its
+// lifetime in seconds. Returns a *LoginThrottledError while the account or
+// client IP is backing off.
+func (s *DeveloperService) Login(ctx context.Context, email, password, ip string) (string, int64, error) {
+	if s.throttle != nil {
+		if err := s.throttle.Check(ctx, email, ip); err != nil {
+			return "", 0, err
+		}
+	}
+
+	developer, err := s.authenticate(ctx, email, password)
+	if err == ErrInvalidCredentials && s.throttle != nil {
+		s.throttle.Failed(ctx, email, ip)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	if s.throttle != nil {
+		s.throttle.Succeeded(ctx, email, ip)
+	}
+
+	token, expiresIn, err := s.auth.IssuePortalToken(developer)
+	if err != nil {
+		return "", 0, err
+	}
+	s.repository.UpdateLastLogin(ctx, developer.ID)
+
+	return token, expiresIn, nil
+}
+
+func (s *DeveloperService) authenticate(ctx context.Context, email, password string) (*models.Developer, error) {
+	developer, err := s.repository.FindByEmail(ctx, strings.ToLower(email))
+	if err != nil {
+		return nil, err
+	}
+	if developer == nil || !developer.IsActive {
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(developer.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return developer, nil
+}
+
+// Returns an active developer, or nil when the account is gone or disabled
+func (s *DeveloperService) Get(ctx context.Context, id uuid.UUID) (*models.Developer, error) {
+	developer, err := s.repository.FindByID(ctx, id)
+	if err != nil || developer == nil || !developer.IsActive {
+		return nil, err
+	}
+	return developer, nil
+}
+
+// Returns the tiers developers may pick, the default first
+func (s *DeveloperService) Tiers() []PortalTier {
+	tiers := make([]PortalTier, 0, len(s.settings.Tiers))
+	for i, name := range s.settings.Tiers {
+		tier := PortalTier{Name: name, Default: i == 0}
+		if cfg := s.tierConfig(name); cfg != nil {
+			tier.RequestsPerMinute = cfg.RequestsPerMinute
+		}
+		tiers = append(tiers, tier)
+	}
+	return tiers
+}
+
+// Lists a developer's keys, newest first
+func (s *DeveloperService) ListKeys(ctx context.Context, developerID uuid.UUID) ([]models.APIKey, error) {
+	keys, _, err := s.apiKeyRepo.ListFiltered(ctx, repository.APIKeyFilter{
+		DeveloperID: &developerID,
+		SortBy:      "created_at",
+		SortDesc:    true,
+		Limit:       s.settings.MaxKeys,
+	})
+	return keys, err
+}
+
+// Creates a key for the developer in an offered tier, the default when tier
+// is empty. Returns the key and its plain secret.
+func (s *DeveloperService) CreateKey(ctx context.Context, developer *models.Developer, name, tier string) (*models.APIKey, string, error) {
+	if tier == "" {
+		tier = s.settings.Tiers[0]
+	}
+	if !slices.Contains(s.settings.Tiers, tier) {
+		return nil, "", ErrTierNotOffered
+	}
+
+	_, count, err := s.apiKeyRepo.ListFiltered(ctx, repository.APIKeyFilter{DeveloperID: &developer.ID, Limit: 1})
+	if err != nil {
+		return nil, "", err
+	}
+	if count >= int64(s.settings.MaxKeys) {
+		return nil, "", ErrDeveloperKeyLimit
+	}
+
+	return s.apiKeys.CreateForDeveloper(ctx, developer, name, tier)
+}
+
+// Replaces the secret of one of the developer's keys
+func (s *DeveloperService) RotateKey(ctx context.Context, developerID uuid.UUID, keyID string) (string, error) {
+	if _, err := s.ownedKey(ctx, developerID, keyID); err != nil {
+		return "", err
+	}
+	return s.apiKeys.Rotate(ctx, keyID)
+}
+
+// Returns the request signing secret of a plain key, or "" when signed
+// requests are off
+func (s *DeveloperService) SigningSecret(key string) string {
+	return s.apiKeys.SigningSecretForKey(key)
+}
+
+// Returns a key's traffic over the time range and its tier limit right now
+func (s *DeveloperService) KeyUsage(ctx context.Context, developerID uuid.UUID, keyID string, from, to time.Time) (*AnalyticsSummary, *KeyQuota, error) {
+	apiKey, err := s.ownedKey(ctx, developerID, keyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	summary, err := s.analytics.GetAPIKeyStats(ctx, apiKey.ID, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	return summary, s.quota(ctx, apiKey), nil
+}
+
+// Returns a key's recent requests, newest first, no further back than the
+// portal's log window
+func (s *DeveloperService) KeyLogs(ctx context.Context, developerID uuid.UUID, keyID string, limit, offset int) ([]PortalRequestLog, error) {
+	apiKey, err := s.ownedKey(ctx, developerID, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -s.settings.LogDays)
+	logs, err := s.requestLogs.FindByAPIKey(ctx, apiKey.ID, from, to, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]PortalRequestLog, 0, len(logs))
+	for _, log := range logs {
+		entries = append(entries, PortalRequestLog{
+			Timestamp:      log.Timestamp,
+			Method:         log.Method,
+			Path:           log.Path,
+			StatusCode:     log.StatusCode,
+			ResponseTimeMs: log.ResponseTimeMs,
+			BytesIn:        log.BytesIn,
+			BytesOut:       log.BytesOut,
+			IPAddress:      log.IPAddress,
+			ErrorType:      log.ErrorType,
+		})
+	}
+	return entries, nil
+}
+
+// Returns a key the developer owns, or ErrDeveloperKeyNotFound
+func (s *DeveloperService) ownedKey(ctx context.Context, developerID uuid.UUID, keyID string) (*models.APIKey, error) {
+	if _, err := uuid.Parse(keyID); err != nil {
+		return nil, ErrDeveloperKeyNotFound
+	}
+
+	apiKey, err := s.apiKeys.Get(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey == nil || apiKey.DeveloperID == nil || *apiKey.DeveloperID != developerID {
+		return nil, ErrDeveloperKeyNotFound
+	}
+	return apiKey, nil
+}
+
+// Reads the key's shared tier allowance the way the rate limit middleware
+// counts it, without using any of it
+func (s *DeveloperService) quota(ctx context.Context, apiKey *models.APIKey) *KeyQuota {
+	limit, algorithm := 60, "fixed_window"
+	if tier := s.tierConfig(apiKey.Tier); tier != nil {
+		limit, algorithm = tier.RequestsPerMinute, tier.Algorithm
+	}
+
+	limiter := s.limiters(algorithm, limit, time.Minute)
+	key := apiKey.ID.String()
+	remaining, _ := limiter.Remaining(ctx, key)
+	reset, _ := limiter.Reset(ctx, key)
+
+	return &KeyQuota{
+		Tier:      apiKey.Tier,
+		Limit:     limiter.Limit(),
+		Remaining: remaining,
+		ResetAt:   reset,
+	}
+}
+
+func (s *DeveloperService) tierConfig(name string) *config.RateLimiterTier {
+	for i := range s.tiers {
+		if s.tiers[i].Name == name {
+			return &s.tiers[i]
+		}
+	}
+	return nil
+}
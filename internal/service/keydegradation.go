@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+)
+
+// What Validate does when the database lookup fails and the cache missed
+const (
+	DegradeFailClosed = "fail_closed" // Reject the request
+	DegradeStaleCache = "stale_cache" // Use the key's last cached copy
+	DegradeFailOpen   = "fail_open"   // Also admit keys this instance validated recently
+)
+
+type DegradationPolicy struct {
+	Mode         string
+	StaleFor     time.Duration // How long a key's last cached copy stays usable
+	RecentlySeen time.Duration // fail_open: how long a validated key is remembered in memory
+}
+
+func (p DegradationPolicy) keepsStaleCopies() bool {
+	return p.Mode == DegradeStaleCache || p.Mode == DegradeFailOpen
+}
+
+// How often key validation fell back on the degradation policy
+type DegradationStats struct {
+	Mode             string `json:"mode"`
+	DependencyErrors int64  `json:"dependency_errors"` // Database lookups that failed
+	ServedStale      int64  `json:"served_stale"`
+	FailedOpen       int64  `json:"failed_open"`
+	Rejected         int64  `json:"rejected"`
+}
+
+type degradation struct {
+	policy DegradationPolicy
+
+	dependencyErrors atomic.Int64
+	servedStale      atomic.Int64
+	failedOpen       atomic.Int64
+	rejected         atomic.Int64
+
+	mu     sync.Mutex
+	recent map[string]recentKey // Key hash to the key validated under it
+	pruned time.Time
+}
+
+type recentKey struct {
+	apiKey *models.APIKey
+	seen   time.Time
+}
+
+func staleCacheKey(keyHash string) string {
+	return "apikey:stale:" + keyHash
+}
+
+// Sets what Validate does when the database can't be reached; the default is fail_closed
+func (s *APIKeyService) SetDegradationPolicy(policy DegradationPolicy) {
+	s.degradation.policy = policy
+	s.degradation.recent = make(map[string]recentKey)
+}
+
+func (s *APIKeyService) DegradationStats() DegradationStats {
+	d := &s.degradation
+	mode := d.policy.Mode
+	if mode == "" {
+		mode = DegradeFailClosed
+	}
+
+	return DegradationStats{
+		Mode:             mode,
+		DependencyErrors: d.dependencyErrors.Load(),
+		ServedStale:      d.servedStale.Load(),
+		FailedOpen:       d.failedOpen.Load(),
+		Rejected:         d.rejected.Load(),
+	}
+}
+
+// Keeps what the policy needs to validate the key during an outage
+func (s *APIKeyService) remember(ctx context.Context, keyHash string, apiKey *models.APIKey, apiKeyJSON []byte) {
+	if !s.degradation.policy.keepsStaleCopies() {
+		return
+	}
+
+	s.redis.Set(ctx, staleCacheKey(keyHash), apiKeyJSON, s.degradation.policy.StaleFor)
+	s.markSeen(keyHash, apiKey)
+}
+
+// Records that the key was valid just now, for fail_open
+func (s *APIKeyService) markSeen(keyHash string, apiKey *models.APIKey) {
+	d := &s.degradation
+	if d.policy.Mode != DegradeFailOpen {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	d.recent[keyHash] = recentKey{apiKey: apiKey, seen: now}
+
+	if now.Sub(d.pruned) > d.policy.RecentlySeen {
+		for hash, recent := range d.recent {
+			if now.Sub(recent.seen) > d.policy.RecentlySeen {
+				delete(d.recent, hash)
+			}
+		}
+		d.pruned = now
+	}
+}
+
+// Drops everything remembered for the key, so a revoked key isn't admitted during an outage
+func (s *APIKeyService) forget(ctx context.Context, keyHash string) {
+	d := &s.degradation
+	if !d.policy.keepsStaleCopies() {
+		return
+	}
+
+	s.redis.Set(ctx, staleCacheKey(keyHash), "", 0)
+
+	d.mu.Lock()
+	delete(d.recent, keyHash)
+	d.mu.Unlock()
+}
+
+// Applies the policy after the database lookup failed with err
+func (s *APIKeyService) degrade(ctx context.Context, keyHash string, err error) (*models.APIKey, error) {
+	d := &s.degradation
+	d.dependencyErrors.Add(1)
+
+	if d.policy.keepsStaleCopies() {
+		cached, cacheErr := s.redis.Get(ctx, staleCacheKey(keyHash))
+		var apiKey models.APIKey
+		if cacheErr == nil && cached != "" && json.Unmarshal([]byte(cached), &apiKey) == nil {
+			d.servedStale.Add(1)
+			return &apiKey, nil
+		}
+	}
+
+	if d.policy.Mode == DegradeFailOpen {
+		d.mu.Lock()
+		recent, ok := d.recent[keyHash]
+		d.mu.Unlock()
+		if ok && time.Since(recent.seen) <= d.policy.RecentlySeen {
+			d.failedOpen.Add(1)
+			return recent.apiKey, nil
+		}
+	}
+
+	d.rejected.Add(1)
+	return nil, err
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/oidc"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/redis/go-redis/v9"
+)
+
+// How long a login may take between redirect and callback
+const oidcStateTTL = 10 * time.Minute
+
+var (
+	ErrInvalidOIDCState = errors.New("invalid or expired login state")
+	ErrNoMappedRole     = errors.New("user is not in any group allowed to access the gateway")
+)
+
+// Signs admins in through an OIDC identity provider
+type OIDCService struct {
+	provider *oidc.Provider
+	auth     *AuthService
+	redis    *storage.RedisClient
+}
+
+func NewOIDCService(provider *oidc.Provider, auth *AuthService, redis *storage.RedisClient) *OIDCService {
+	return &OIDCService{
+		provider: provider,
+		auth:     auth,
+		redis:    redis,
+	}
+}
+
+// Starts a login, returning the provider URL to redirect the user to
+func (s *OIDCService) BeginLogin(ctx context.Context) (string, error) {
+	state, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.redis.Set(ctx, oidcStateKey(state), nonce, oidcStateTTL); err != nil {
+		return "", fmt.Errorf("failed to store login state: %w", err)
+	}
+
+	return s.provider.AuthCodeURL(ctx, state, nonce)
+}
+
+// Completes a login from the provider callback and issues gateway tokens
+func (s *OIDCService) CompleteLogin(ctx context.Context, state, code string) (*TokenPair, error) {
+	// States are single use
+	nonce, err := s.redis.GetDel(ctx, oidcStateKey(state))
+	if err == redis.Nil {
+		return nil, ErrInvalidOIDCState
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := s.provider.Exchange(ctx, code, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	role := s.provider.RoleFor(identity.Groups)
+	if role == "" {
+		return nil, ErrNoMappedRole
+	}
+
+	return s.auth.LoginExternal(ctx, "oidc", identity.Subject, identity.Email, identity.Name, role)
+}
+
+func oidcStateKey(state string) string {
+	return "auth:oidc:state:" + state
+}
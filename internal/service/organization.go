@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/audit"
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/ratelimit"
+	"github.com/aman-churiwal/api-gateway/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrOrganizationExists   = errors.New("organization with this name already exists")
+	ErrInvalidOrgLimit      = errors.New("requests_per_minute must not be negative")
+)
+
+// How often aggregate limits set on other replicas are picked up
+const orgLimitRefreshInterval = 30 * time.Second
+
+// Manages the organizations users and API keys belong to, and enforces their
+// aggregate request limits
+type OrganizationService struct {
+	repository *repository.OrganizationRepository
+	auth       *AuthService
+	limiters   ratelimit.Factory
+	limits     atomic.Pointer[map[uuid.UUID]int] // Organizations with an aggregate limit
+
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+}
+
+func NewOrganizationService(repository *repository.OrganizationRepository, auth *AuthService, limiters ratelimit.Factory) *OrganizationService {
+	s := &OrganizationService{
+		repository: repository,
+		auth:       auth,
+		limiters:   limiters,
+		stopChan:   make(chan struct{}),
+	}
+	s.limits.Store(&map[uuid.UUID]int{})
+	return s
+}
+
+// The state of an organization's aggregate request limit
+type OrgQuota struct {
+	Limit     int       `json:"limit"` // Requests per minute; 0 for no limit
+	Remaining int       `json:"remaining"`
+	Used      int       `json:"used"`
+	ResetAt   time.Time `json:"reset_at,omitempty"`
+}
+
+// Counts a request against the organization's aggregate limit. Organizations
+// without one always allow; the quota is then zero.
+func (s *OrganizationService) AllowRequest(ctx context.Context, orgID uuid.UUID) (bool, OrgQuota, error) {
+	limit := (*s.limits.Load())[orgID]
+	if limit <= 0 {
+		return true, OrgQuota{}, nil
+	}
+
+	limiter, key := s.limiter(orgID, limit)
+	allowed, err := limiter.Allow(ctx, key)
+	if err != nil {
+		return false, OrgQuota{}, err
+	}
+	return allowed, s.quota(ctx, limiter, key), nil
+}
+
+// Returns how much of the organization's aggregate limit is used without
+// counting a request
+func (s *OrganizationService) Quota(ctx context.Context, orgID uuid.UUID) OrgQuota {
+	limit := (*s.limits.Load())[orgID]
+	if limit <= 0 {
+		return OrgQuota{}
+	}
+
+	limiter, key := s.limiter(orgID, limit)
+	return s.quota(ctx, limiter, key)
+}
+
+func (s *OrganizationService) limiter(orgID uuid.UUID, limit int) (ratelimit.Limiter, string) {
+	return s.limiters("sliding_window", limit, time.Minute), "org:" + orgID.String()
+}
+
+func (s *OrganizationService) quota(ctx context.Context, limiter ratelimit.Limiter, key string) OrgQuota {
+	remaining, _ := limiter.Remaining(ctx, key)
+	reset, _ := limiter.Reset(ctx, key)
+	return OrgQuota{
+		Limit:     limiter.Limit(),
+		Remaining: remaining,
+		Used:      limiter.Limit() - remaining,
+		ResetAt:   reset,
+	}
+}
+
+// Sets an organization's aggregate request limit, 0 to remove it. Takes
+// effect on this replica right away and on others within the refresh interval.
+func (s *OrganizationService) SetRequestLimit(ctx context.Context, id uuid.UUID, requestsPerMinute int) error {
+	if requestsPerMinute < 0 {
+		return ErrInvalidOrgLimit
+	}
+
+	before, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.repository.SetRequestLimit(ctx, id, requestsPerMinute); err != nil {
+		return err
+	}
+	audit.Record(ctx, "organization.set_limit", "organization", id.String(),
+		map[string]int{"requests_per_minute": before.RequestsPerMinute},
+		map[string]int{"requests_per_minute": requestsPerMinute})
+
+	return s.refreshLimits(ctx)
+}
+
+// Loads aggregate limits now and then periodically, so limits set on other
+// replicas apply here too
+func (s *OrganizationService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	if err := s.refreshLimits(context.Background()); err != nil {
+		log.Printf("Failed to load organization limits: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(orgLimitRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.refreshLimits(context.Background()); err != nil {
+					log.Printf("Failed to refresh organization limits: %v", err)
+				}
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stops refreshing aggregate limits
+func (s *OrganizationService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		close(s.stopChan)
+		s.running = false
+	}
+}
+
+// Replaces the cached limits; the previous ones stay in force on failure
+func (s *OrganizationService) refreshLimits(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	limits, err := s.repository.RequestLimits(ctx)
+	if err != nil {
+		return err
+	}
+	s.limits.Store(&limits)
+	return nil
+}
+
+func (s *OrganizationService) Create(ctx context.Context, name, createdBy string) (*models.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	existing, err := s.repository.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, org := range existing {
+		if strings.EqualFold(org.Name, name) {
+			return nil, ErrOrganizationExists
+		}
+	}
+
+	org := &models.Organization{Name: name, CreatedBy: createdBy}
+	if err := s.repository.Create(ctx, org); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+	audit.Record(ctx, "organization.create", "organization", org.ID.String(), nil, org)
+
+	return org, nil
+}
+
+// Lists organizations, or only the one given
+func (s *OrganizationService) List(ctx context.Context, only *uuid.UUID) ([]repository.OrganizationEntry, error) {
+	return s.repository.List(ctx, only)
+}
+
+// Returns an organization or ErrOrganizationNotFound
+func (s *OrganizationService) Get(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	org, err := s.repository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return org, nil
+}
+
+// Moves an existing user into an organization, e.g. when adopting
+// organizations on a gateway whose users were all operators
+func (s *OrganizationService) AddUser(ctx context.Context, orgID, userID uuid.UUID) (bool, error) {
+	if _, err := s.Get(ctx, orgID); err != nil {
+		return false, err
+	}
+
+	found, err := s.repository.AssignUser(ctx, userID, &orgID)
+	if err != nil || !found {
+		return found, err
+	}
+
+	audit.Record(ctx, "organization.add_user", "user", userID.String(), nil, map[string]string{"org_id": orgID.String()})
+
+	// Tokens issued before the move carry no org_id and would still act platform-wide
+	return true, s.auth.invalidateSessions(ctx, userID)
+}
+
+// Moves an existing API key into an organization
+func (s *OrganizationService) AddAPIKey(ctx context.Context, orgID, keyID uuid.UUID) (bool, error) {
+	if _, err := s.Get(ctx, orgID); err != nil {
+		return false, err
+	}
+
+	found, err := s.repository.AssignAPIKey(ctx, keyID, &orgID)
+	if err == nil && found {
+		audit.Record(ctx, "organization.add_api_key", "api_key", keyID.String(), nil, map[string]string{"org_id": orgID.String()})
+	}
+	return found, err
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/audit"
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/repository"
+)
+
+// Marks service account tokens so RequireAuth can tell them from user JWTs
+const ServiceTokenPrefix = "gwsa_"
+
+// Admin API resource that service accounts can never be granted, so a token
+// cannot mint more powerful tokens
+const serviceAccountResource = "service-accounts"
+
+// Admin API resources that create or empower principals. Like service
+// accounts, no scope covers them, "*" included: an invitation registers an
+// unscoped admin, and organization membership changes what a user can reach.
+var principalResources = map[string]bool{
+	serviceAccountResource: true,
+	"invitations":          true,
+}
+
+var (
+	ErrServiceAccountExists   = errors.New("service account with this name already exists")
+	ErrServiceAccountNotFound = errors.New("service account not found")
+	ErrServiceAccountInactive = errors.New("service account is deactivated")
+	ErrInvalidScope           = errors.New("scopes must look like <resource>:read or <resource>:write")
+	ErrScopeNotGranted        = errors.New("token scopes must be granted to the service account")
+	ErrInvalidServiceToken    = errors.New("invalid, expired or revoked service account token")
+)
+
+var scopePattern = regexp.MustCompile(`^(\*|[a-z][a-z0-9-]*):(read|write)$`)
+
+// Authenticated service account and the token it presented
+type ServicePrincipal struct {
+	Account *models.ServiceAccount
+	Token   *models.ServiceAccountToken
+}
+
+// Manages service accounts and validates their tokens. Scopes are
+// "<resource>:<read|write>", where resource is the first path segment under
+// /admin (e.g. "keys:write", "analytics:read") or "*" for all of them but
+// those managing principals. Write implies read.
+type ServiceAccountService struct {
+	repository *repository.ServiceAccountRepository
+}
+
+func NewServiceAccountService(repo *repository.ServiceAccountRepository) *ServiceAccountService {
+	return &ServiceAccountService{repository: repo}
+}
+
+// Creates a service account allowed to hold tokens with the given scopes
+func (s *ServiceAccountService) Create(ctx context.Context, name, description, createdBy string, scopes []string) (*models.ServiceAccount, error) {
+	if err := validateScopes(scopes); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repository.FindByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrServiceAccountExists
+	}
+
+	account := &models.ServiceAccount{
+		Name:        name,
+		Description: description,
+		Scopes:      scopes,
+		IsActive:    true,
+		CreatedBy:   createdBy,
+	}
+	if err := s.repository.Create(ctx, account); err != nil {
+		return nil, fmt.Errorf("failed to create service account: %w", err)
+	}
+
+	audit.Record(ctx, "service_account.create", "service_account", account.ID.String(), nil, account)
+	return account, nil
+}
+
+// Returns all service accounts
+func (s *ServiceAccountService) List(ctx context.Context) ([]models.ServiceAccount, error) {
+	return s.repository.List(ctx)
+}
+
+// Deactivates a service account, revoking all of its tokens
+func (s *ServiceAccountService) Deactivate(ctx context.Context, id string) error {
+	account, err := s.repository.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return ErrServiceAccountNotFound
+	}
+
+	if err := s.repository.Deactivate(ctx, id); err != nil {
+		return err
+	}
+
+	after := *account
+	after.IsActive = false
+	audit.Record(ctx, "service_account.deactivate", "service_account", id, account, &after)
+	return nil
+}
+
+// Issues a token limited to scopes, which must be granted to the account.
+// A zero ttl issues a token that never expires. Returns the plain token,
+// which is not stored.
+func (s *ServiceAccountService) IssueToken(ctx context.Context, accountID, name, createdBy string, scopes []string, ttl time.Duration) (*models.ServiceAccountToken, string, error) {
+	account, err := s.repository.FindByID(ctx, accountID)
+	if err != nil {
+		return nil, "", err
+	}
+	if account == nil {
+		return nil, "", ErrServiceAccountNotFound
+	}
+	if !account.IsActive {
+		return nil, "", ErrServiceAccountInactive
+	}
+
+	if len(scopes) == 0 {
+		scopes = account.Scopes
+	}
+	if err := validateScopes(scopes); err != nil {
+		return nil, "", err
+	}
+	for _, scope := range scopes {
+		if !scopeCovered(account.Scopes, scope) {
+			return nil, "", ErrScopeNotGranted
+		}
+	}
+
+	secret, err := generateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	plain := ServiceTokenPrefix + secret
+
+	token := &models.ServiceAccountToken{
+		ServiceAccountID: account.ID,
+		Name:             name,
+		TokenHash:        hashToken(plain),
+		TokenPrefix:      plain[:len(ServiceTokenPrefix)+8],
+		Scopes:           scopes,
+		CreatedBy:        createdBy,
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.repository.CreateToken(ctx, token); err != nil {
+		return nil, "", fmt.Errorf("failed to create token: %w", err)
+	}
+
+	audit.Record(ctx, "service_account.token_issue", "service_account_token", token.ID.String(), nil, token)
+	return token, plain, nil
+}
+
+// Returns a service account's tokens
+func (s *ServiceAccountService) ListTokens(ctx context.Context, accountID string) ([]models.ServiceAccountToken, error) {
+	return s.repository.ListTokens(ctx, accountID)
+}
+
+// Revokes one of a service account's tokens
+func (s *ServiceAccountService) RevokeToken(ctx context.Context, accountID, tokenID string) error {
+	revoked, err := s.repository.RevokeToken(ctx, accountID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrInvalidServiceToken
+	}
+
+	audit.Record(ctx, "service_account.token_revoke", "service_account_token", tokenID, nil, nil)
+	return nil
+}
+
+// Resolves a plain token to its service account. Fails for unknown, expired
+// and revoked tokens and for deactivated accounts.
+func (s *ServiceAccountService) Authenticate(ctx context.Context, plain string) (*ServicePrincipal, error) {
+	token, err := s.repository.FindTokenByHash(ctx, hashToken(plain))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if token == nil || token.RevokedAt != nil || (token.ExpiresAt != nil && now.After(*token.ExpiresAt)) {
+		return nil, ErrInvalidServiceToken
+	}
+
+	account, err := s.repository.FindByID(ctx, token.ServiceAccountID.String())
+	if err != nil {
+		return nil, err
+	}
+	if account == nil || !account.IsActive {
+		return nil, ErrInvalidServiceToken
+	}
+
+	// Coarse usage tracking keeps busy automation from writing on every request
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > time.Minute {
+		if err := s.repository.TouchToken(ctx, token.ID.String(), now); err != nil {
+			log.Printf("Failed to record use of service account token %s: %v", token.ID, err)
+		}
+	}
+
+	return &ServicePrincipal{Account: account, Token: token}, nil
+}
+
+// Reports whether the principal's token scopes allow a request to the admin route
+func (p *ServicePrincipal) Allows(method, path string) bool {
+	rest, ok := strings.CutPrefix(path, "/admin/")
+	if !ok {
+		return false
+	}
+	resource, _, _ := strings.Cut(rest, "/")
+	if resource == "" || principalResources[resource] {
+		return false
+	}
+
+	access := "write"
+	if method == http.MethodGet || method == http.MethodHead {
+		access = "read"
+	}
+	if access == "write" && membershipPath(rest) {
+		return false
+	}
+
+	return scopeCovered(p.Token.Scopes, resource+":"+access)
+}
+
+// Reports whether an admin path below /admin/ manages organization members
+func membershipPath(rest string) bool {
+	parts := strings.Split(rest, "/")
+	return len(parts) >= 3 && parts[0] == "organizations" && parts[2] == "members"
+}
+
+// Reports whether granted covers scope, with wildcards and write implying read
+func scopeCovered(granted []string, scope string) bool {
+	resource, access, _ := strings.Cut(scope, ":")
+	for _, g := range granted {
+		gResource, gAccess, _ := strings.Cut(g, ":")
+		if gResource != "*" && gResource != resource {
+			continue
+		}
+		if gAccess == access || gAccess == "write" {
+			return true
+		}
+	}
+	return false
+}
+
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		resource, _, _ := strings.Cut(scope, ":")
+		if !scopePattern.MatchString(scope) || principalResources[resource] {
+			return ErrInvalidScope
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/audit"
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/repository"
+	"github.com/aman-churiwal/api-gateway/internal/webhook"
+)
+
+// When a key counts as stale and what happens to it afterwards
+type StaleKeyPolicy struct {
+	UnusedFor      time.Duration // Keys unused this long are flagged
+	GracePeriod    time.Duration // Time between flagging and auto-deactivation
+	AutoDeactivate bool
+	CheckInterval  time.Duration
+}
+
+// A key flagged by the stale key policy
+type StaleKey struct {
+	models.APIKey
+	UnusedDays   int        `json:"unused_days"`
+	DeactivateAt *time.Time `json:"deactivate_at,omitempty"` // Set when auto-deactivation is enabled
+}
+
+// Flags unused keys, notifies their owners and deactivates them after a grace period
+type StaleKeyService struct {
+	keys       *APIKeyService
+	repository *repository.APIKeyRepository
+	webhooks   *webhook.Dispatcher
+	policy     StaleKeyPolicy
+
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+}
+
+func NewStaleKeyService(keys *APIKeyService, repo *repository.APIKeyRepository, webhooks *webhook.Dispatcher, policy StaleKeyPolicy) *StaleKeyService {
+	if policy.CheckInterval <= 0 {
+		policy.CheckInterval = time.Hour
+	}
+
+	return &StaleKeyService{
+		keys:       keys,
+		repository: repo,
+		webhooks:   webhooks,
+		policy:     policy,
+		stopChan:   make(chan struct{}),
+	}
+}
+
+// Returns the active keys unused for the given duration, or the policy's when zero
+func (s *StaleKeyService) Report(ctx context.Context, unusedFor time.Duration) ([]StaleKey, error) {
+	if unusedFor <= 0 {
+		unusedFor = s.policy.UnusedFor
+	}
+
+	now := time.Now()
+	keys, err := s.repository.ListUnusedSince(ctx, now.Add(-unusedFor))
+	if err != nil {
+		return nil, err
+	}
+
+	report := make([]StaleKey, 0, len(keys))
+	for _, key := range keys {
+		report = append(report, s.describe(key, now))
+	}
+
+	return report, nil
+}
+
+// Flags newly stale keys and deactivates the ones whose grace period has passed
+func (s *StaleKeyService) Sweep(ctx context.Context) (flagged, deactivated int, err error) {
+	now := time.Now()
+	keys, err := s.repository.ListUnusedSince(ctx, now.Add(-s.policy.UnusedFor))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, key := range keys {
+		if key.StaleSince == nil {
+			// Only the replica that flags the key notifies its owner
+			marked, err := s.repository.MarkStale(ctx, key.ID, now)
+			if err != nil {
+				return flagged, deactivated, err
+			}
+			if marked {
+				key.StaleSince = &now
+				flagged++
+
+				stale := s.describe(key, now)
+				data := keyEventData(&key)
+				data["unused_days"] = stale.UnusedDays
+				if stale.DeactivateAt != nil {
+					data["deactivate_at"] = stale.DeactivateAt
+				}
+				s.webhooks.Dispatch(webhook.EventAPIKeyStale, data)
+			}
+			continue
+		}
+
+		if !s.policy.AutoDeactivate || now.Before(key.StaleSince.Add(s.policy.GracePeriod)) {
+			continue
+		}
+
+		s.keys.invalidateCache(ctx, key.ID.String())
+		ok, err := s.repository.Deactivate(ctx, key.ID)
+		if err != nil {
+			return flagged, deactivated, err
+		}
+		if ok {
+			deactivated++
+
+			data := keyEventData(&key)
+			data["reason"] = "stale"
+			s.webhooks.Dispatch(webhook.EventAPIKeyDeactivated, data)
+			audit.Record(ctx, "api_key.deactivate", "api_key", key.ID.String(),
+				map[string]bool{"is_active": true}, map[string]bool{"is_active": false})
+		}
+	}
+
+	return flagged, deactivated, nil
+}
+
+// Builds the report entry for a stale key
+func (s *StaleKeyService) describe(key models.APIKey, now time.Time) StaleKey {
+	lastSeen := key.CreatedAt
+	if key.LastUsedAt != nil {
+		lastSeen = *key.LastUsedAt
+	}
+
+	stale := StaleKey{
+		APIKey:     key,
+		UnusedDays: int(now.Sub(lastSeen).Hours() / 24),
+	}
+
+	if s.policy.AutoDeactivate {
+		flaggedAt := now
+		if key.StaleSince != nil {
+			flaggedAt = *key.StaleSince
+		}
+		deactivateAt := flaggedAt.Add(s.policy.GracePeriod)
+		stale.DeactivateAt = &deactivateAt
+	}
+
+	return stale
+}
+
+// Begins periodic sweeps
+func (s *StaleKeyService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	log.Printf("Starting stale key sweeps (unused for: %v, interval: %v)", s.policy.UnusedFor, s.policy.CheckInterval)
+
+	go func() {
+		ticker := time.NewTicker(s.policy.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.sweep()
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stops periodic sweeps
+func (s *StaleKeyService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		close(s.stopChan)
+		s.running = false
+	}
+}
+
+func (s *StaleKeyService) sweep() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	flagged, deactivated, err := s.Sweep(ctx)
+	if err != nil {
+		log.Printf("Stale key sweep failed: %v", err)
+		return
+	}
+	if flagged > 0 || deactivated > 0 {
+		log.Printf("Stale key sweep: %d flagged, %d deactivated", flagged, deactivated)
+	}
+}
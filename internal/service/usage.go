@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/aman-churiwal/api-gateway/internal/repository"
+	"github.com/google/uuid"
+)
+
+// How long after a month ends its report is still rolled up, for logs that
+// were buffered when it ended. The report is final afterwards.
+const usageSettlePeriod = 24 * time.Hour
+
+// Rolls request logs up into monthly usage reports per API key
+type UsageReportService struct {
+	requestLogs repository.RequestLogStore
+	reports     *repository.UsageReportRepository
+	interval    time.Duration
+
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+}
+
+func NewUsageReportService(requestLogs repository.RequestLogStore, reports *repository.UsageReportRepository, interval time.Duration) *UsageReportService {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return &UsageReportService{
+		requestLogs: requestLogs,
+		reports:     reports,
+		interval:    interval,
+		stopChan:    make(chan struct{}),
+	}
+}
+
+// Returns the first instant of the calendar month containing t, in UTC
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Recomputes the usage reports of the month containing the given time.
+// Returns the number of keys with traffic that month.
+func (s *UsageReportService) Rollup(ctx context.Context, month time.Time) (int, error) {
+	from := MonthStart(month)
+	to := from.AddDate(0, 1, 0)
+	now := time.Now()
+
+	usage, err := s.requestLogs.GetKeyUsage(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	final := now.After(to.Add(usageSettlePeriod))
+	reports := make([]models.UsageReport, 0, len(usage))
+	for _, u := range usage {
+		reports = append(reports, models.UsageReport{
+			APIKeyID:     u.APIKeyID,
+			Month:        from,
+			Requests:     u.Requests,
+			ClientErrors: u.ClientErrors,
+			ServerErrors: u.ServerErrors,
+			BytesIn:      u.BytesIn,
+			BytesOut:     u.BytesOut,
+			Final:        final,
+			GeneratedAt:  now,
+		})
+	}
+
+	if err := s.reports.Upsert(ctx, reports); err != nil {
+		return 0, err
+	}
+	return len(reports), nil
+}
+
+// Returns a month's usage reports, optionally for one key or one
+// organization's keys
+func (s *UsageReportService) Report(ctx context.Context, month time.Time, apiKeyID, orgID *uuid.UUID) ([]repository.UsageReportEntry, error) {
+	return s.reports.ListByMonth(ctx, MonthStart(month), apiKeyID, orgID)
+}
+
+// A month's usage summed over an organization's keys. OrgID is nil for keys
+// outside any organization.
+type OrganizationUsageReport struct {
+	OrgID        *uuid.UUID `json:"org_id"`
+	APIKeys      int        `json:"api_keys"`
+	Requests     int64      `json:"requests"`
+	ClientErrors int64      `json:"client_errors"`
+	ServerErrors int64      `json:"server_errors"`
+	BytesIn      int64      `json:"bytes_in"`
+	BytesOut     int64      `json:"bytes_out"`
+	Final        bool       `json:"final"`
+}
+
+// Returns a month's usage summed per organization, busiest first, optionally
+// only for one organization
+func (s *UsageReportService) ReportByOrganization(ctx context.Context, month time.Time, orgID *uuid.UUID) ([]OrganizationUsageReport, error) {
+	reports, err := s.Report(ctx, month, nil, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	byOrg := make(map[uuid.UUID]int)
+	rollups := make([]OrganizationUsageReport, 0)
+	for _, report := range reports {
+		var orgKey uuid.UUID // uuid.Nil groups keys without an organization
+		if report.OrgID != nil {
+			orgKey = *report.OrgID
+		}
+
+		i, ok := byOrg[orgKey]
+		if !ok {
+			i = len(rollups)
+			byOrg[orgKey] = i
+			rollups = append(rollups, OrganizationUsageReport{OrgID: report.OrgID, Final: true})
+		}
+
+		rollup := &rollups[i]
+		rollup.APIKeys++
+		rollup.Requests += report.Requests
+		rollup.ClientErrors += report.ClientErrors
+		rollup.ServerErrors += report.ServerErrors
+		rollup.BytesIn += report.BytesIn
+		rollup.BytesOut += report.BytesOut
+		rollup.Final = rollup.Final && report.Final
+	}
+
+	sort.SliceStable(rollups, func(i, j int) bool { return rollups[i].Requests > rollups[j].Requests })
+	return rollups, nil
+}
+
+// Begins periodic rollups of the current month, and of the previous one until it is final
+func (s *UsageReportService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	log.Printf("Starting usage report rollups (interval: %v)", s.interval)
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.rollup()
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stops periodic rollups
+func (s *UsageReportService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		close(s.stopChan)
+		s.running = false
+	}
+}
+
+func (s *UsageReportService) rollup() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	now := time.Now()
+	previous := MonthStart(now).AddDate(0, -1, 0)
+
+	final, err := s.reports.IsFinal(ctx, previous)
+	if err != nil {
+		log.Printf("Usage report rollup failed: %v", err)
+		return
+	}
+	months := []time.Time{now}
+	if !final {
+		months = append([]time.Time{previous}, months...)
+	}
+
+	for _, month := range months {
+		keys, err := s.Rollup(ctx, month)
+		if err != nil {
+			log.Printf("Usage report rollup for %s failed: %v", MonthStart(month).Format("2006-01"), err)
+			continue
+		}
+		if keys > 0 {
+			log.Printf("Usage reports for %s updated (%d keys)", MonthStart(month).Format("2006-01"), keys)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Format for DateTime64 query parameters
+const ClickHouseTimeFormat = "2006-01-02 15:04:05.000"
+
+// ClickHouse client over its HTTP interface. Rows are exchanged as
+// JSONEachRow and values are bound with {name:Type} query parameters.
+type ClickHouse struct {
+	url      string
+	database string
+	username string
+	password string
+	client   *http.Client
+}
+
+func NewClickHouse(baseURL, database, username, password string) (*ClickHouse, error) {
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid clickhouse url: %w", err)
+	}
+	if database == "" {
+		database = "default"
+	}
+
+	return &ClickHouse{
+		url:      strings.TrimRight(baseURL, "/"),
+		database: database,
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+func (c *ClickHouse) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/ping", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach clickhouse: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("clickhouse ping returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Runs a statement that returns no rows
+func (c *ClickHouse) Exec(ctx context.Context, query string, params map[string]string) error {
+	resp, err := c.do(ctx, query, params, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Runs a SELECT and calls fn with each row as it is read
+func (c *ClickHouse) Query(ctx context.Context, query string, params map[string]string, fn func(row []byte) error) error {
+	resp, err := c.do(ctx, query+" FORMAT JSONEachRow", params, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Inserts rows, marshalled with their JSON tags, into a table
+func (c *ClickHouse) Insert(ctx context.Context, table string, rows []any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.do(ctx, "INSERT INTO "+table+" FORMAT JSONEachRow", nil, &body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Sends the query in the URL when a body carries insert data, otherwise as the body
+func (c *ClickHouse) do(ctx context.Context, query string, params map[string]string, data io.Reader) (*http.Response, error) {
+	values := url.Values{}
+	values.Set("database", c.database)
+	values.Set("date_time_input_format", "best_effort")
+	values.Set("date_time_output_format", "iso")
+	values.Set("output_format_json_quote_64bit_integers", "0")
+	values.Set("input_format_skip_unknown_fields", "1")
+	for name, value := range params {
+		values.Set("param_"+name, value)
+	}
+
+	body := data
+	if body == nil {
+		body = strings.NewReader(query)
+	} else {
+		values.Set("query", query)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/?"+values.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	if c.username != "" {
+		req.Header.Set("X-ClickHouse-User", c.username)
+		req.Header.Set("X-ClickHouse-Key", c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("clickhouse request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("clickhouse returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var embeddedBucket = []byte("kv")
+
+// Key-value store in a local bbolt file, used in place of Redis for rate
+// limiting and response caching on single-node installs
+type EmbeddedKV struct {
+	db       *bolt.DB
+	stopChan chan struct{}
+}
+
+func NewEmbeddedKV(path string) (*EmbeddedKV, error) {
+	// Rate limit and cache writes come with every request and are cheap to
+	// lose, so commits skip fsync; embedded Redis data is synced when saved
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second, NoSync: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded store: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(embeddedBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize embedded store: %w", err)
+	}
+
+	kv := &EmbeddedKV{
+		db:       db,
+		stopChan: make(chan struct{}),
+	}
+	go kv.sweep(time.Minute)
+
+	return kv, nil
+}
+
+// Returns the value of a key, or nil when it is missing or expired
+func (e *EmbeddedKV) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	err := e.db.View(func(tx *bolt.Tx) error {
+		value = decodeEntry(tx.Bucket(embeddedBucket).Get([]byte(key)), time.Now())
+		return nil
+	})
+	return value, err
+}
+
+// Stores a value, expiring after ttl unless ttl is zero
+func (e *EmbeddedKV) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return e.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(embeddedBucket).Put([]byte(key), encodeEntry(value, ttl))
+	})
+}
+
+// Atomically replaces a value with the result of fn. A nil result deletes the key.
+func (e *EmbeddedKV) Update(ctx context.Context, key string, fn func(current []byte) ([]byte, time.Duration, error)) error {
+	return e.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(embeddedBucket)
+
+		next, ttl, err := fn(decodeEntry(bucket.Get([]byte(key)), time.Now()))
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			return bucket.Delete([]byte(key))
+		}
+		return bucket.Put([]byte(key), encodeEntry(next, ttl))
+	})
+}
+
+// Deletes the keys matching a Redis-style glob pattern and returns how many were deleted
+func (e *EmbeddedKV) DeleteMatching(ctx context.Context, pattern string) (int, error) {
+	re, err := globRegexp(pattern)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	err = e.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(embeddedBucket)
+
+		var matched [][]byte
+		bucket.ForEach(func(k, v []byte) error {
+			if re.Match(k) {
+				matched = append(matched, append([]byte(nil), k...))
+			}
+			return nil
+		})
+
+		for _, k := range matched {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		deleted = len(matched)
+		return nil
+	})
+	return deleted, err
+}
+
+// Translates a glob with *, ?, [classes] and \ escapes, as Redis matches them
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [ in pattern %q", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "^") {
+				class = "^" + regexp.QuoteMeta(class[1:])
+			} else {
+				class = regexp.QuoteMeta(class)
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+func (e *EmbeddedKV) Close() error {
+	close(e.stopChan)
+	return e.db.Close()
+}
+
+// Periodically deletes expired entries
+func (e *EmbeddedKV) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			err := e.db.Update(func(tx *bolt.Tx) error {
+				bucket := tx.Bucket(embeddedBucket)
+
+				// Deleting while iterating skips keys, so collect first
+				var expired [][]byte
+				bucket.ForEach(func(k, v []byte) error {
+					if decodeEntry(v, now) == nil {
+						expired = append(expired, append([]byte(nil), k...))
+					}
+					return nil
+				})
+
+				for _, k := range expired {
+					if err := bucket.Delete(k); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				log.Printf("Embedded store sweep failed: %v", err)
+			}
+		case <-e.stopChan:
+			return
+		}
+	}
+}
+
+// Entries are an 8-byte expiry in unix nanoseconds (0: never) followed by the value
+func encodeEntry(value []byte, ttl time.Duration) []byte {
+	entry := make([]byte, 8+len(value))
+	if ttl > 0 {
+		binary.BigEndian.PutUint64(entry, uint64(time.Now().Add(ttl).UnixNano()))
+	}
+	copy(entry[8:], value)
+	return entry
+}
+
+func decodeEntry(entry []byte, now time.Time) []byte {
+	if len(entry) < 8 {
+		return nil
+	}
+
+	if expiresAt := int64(binary.BigEndian.Uint64(entry)); expiresAt != 0 && now.UnixNano() >= expiresAt {
+		return nil
+	}
+
+	// Bolt memory is only valid inside the transaction
+	value := make([]byte, len(entry)-8)
+	copy(value, entry[8:])
+	return value
+}
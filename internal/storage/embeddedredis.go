@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	bolt "go.etcd.io/bbolt"
+)
+
+var redisSnapshotBucket = []byte("redis")
+
+// How often the in-process Redis data is written to the embedded store
+const redisSnapshotInterval = 10 * time.Second
+
+// A Redis key as kept in the embedded store
+type redisSnapshotEntry struct {
+	Type      string             `json:"type"`
+	Value     string             `json:"value,omitempty"`
+	Hash      map[string]string  `json:"hash,omitempty"`
+	Members   []string           `json:"members,omitempty"` // Set members or list items
+	Scores    map[string]float64 `json:"scores,omitempty"`
+	ExpiresAt int64              `json:"expires_at,omitempty"` // Unix nanoseconds, whole seconds so unchanged keys compare equal
+}
+
+// Starts a Redis-compatible server inside the process like NewMemoryRedis,
+// keeping its data in the embedded store so single-node installs need no Redis.
+// Data is restored on start, and keys that changed are written back every few
+// seconds and on close; streams, which only carry async job notifications,
+// are not kept.
+func NewEmbeddedRedis(kv *EmbeddedKV) (*RedisClient, error) {
+	server, password, err := startMiniredis()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start embedded Redis: %w", err)
+	}
+
+	saved, err := restoreRedis(kv, server)
+	if err != nil {
+		server.Close()
+		return nil, err
+	}
+
+	client, err := connectRedis(&redis.Options{Addr: server.Addr(), Password: password})
+	if err != nil {
+		server.Close()
+		return nil, err
+	}
+
+	client.memory = &memoryRedis{
+		server:   server,
+		kv:       kv,
+		saved:    saved,
+		stopChan: make(chan struct{}),
+	}
+	go client.memory.tick(time.Second)
+
+	return client, nil
+}
+
+// Loads the stored keys into the server, returning the hashes of the stored entries
+func restoreRedis(kv *EmbeddedKV, server *miniredis.Miniredis) (map[string][32]byte, error) {
+	now := time.Now()
+	saved := make(map[string][32]byte)
+	err := kv.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(redisSnapshotBucket)
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var entry redisSnapshotEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return fmt.Errorf("key %s: %w", k, err)
+			}
+			saved[string(k)] = sha256.Sum256(v)
+			if entry.ExpiresAt != 0 && now.UnixNano() >= entry.ExpiresAt {
+				return nil
+			}
+
+			key := string(k)
+			switch entry.Type {
+			case "string":
+				server.Set(key, entry.Value)
+			case "hash":
+				for field, value := range entry.Hash {
+					server.HSet(key, field, value)
+				}
+			case "set":
+				server.SAdd(key, entry.Members...)
+			case "list":
+				server.Push(key, entry.Members...)
+			case "zset":
+				for member, score := range entry.Scores {
+					server.ZAdd(key, score, member)
+				}
+			}
+			if entry.ExpiresAt != 0 {
+				server.SetTTL(key, time.Duration(entry.ExpiresAt-now.UnixNano()))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore embedded Redis data: %w", err)
+	}
+	return saved, nil
+}
+
+// Writes the keys that changed since the last snapshot and deletes those gone
+func (m *memoryRedis) snapshot() error {
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
+
+	now := time.Now()
+	current := make(map[string][32]byte)
+	changed := make(map[string][]byte)
+	for _, key := range m.server.Keys() {
+		entry := redisSnapshotEntry{Type: m.server.Type(key)}
+		switch entry.Type {
+		case "string":
+			entry.Value, _ = m.server.Get(key)
+		case "hash":
+			fields, _ := m.server.HKeys(key)
+			entry.Hash = make(map[string]string, len(fields))
+			for _, field := range fields {
+				entry.Hash[field] = m.server.HGet(key, field)
+			}
+		case "set":
+			entry.Members, _ = m.server.Members(key)
+		case "list":
+			entry.Members, _ = m.server.List(key)
+		case "zset":
+			members, _ := m.server.ZMembers(key)
+			entry.Scores = make(map[string]float64, len(members))
+			for _, member := range members {
+				entry.Scores[member], _ = m.server.ZScore(key, member)
+			}
+		default:
+			continue
+		}
+		if ttl := m.server.TTL(key); ttl > 0 {
+			entry.ExpiresAt = now.Add(ttl).Round(time.Second).UnixNano()
+		}
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		current[key] = sha256.Sum256(data)
+		if m.saved[key] != current[key] {
+			changed[key] = data
+		}
+	}
+
+	var deleted []string
+	for key := range m.saved {
+		if _, exists := current[key]; !exists {
+			deleted = append(deleted, key)
+		}
+	}
+	if len(changed) == 0 && len(deleted) == 0 {
+		return nil
+	}
+
+	err := m.kv.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(redisSnapshotBucket)
+		if err != nil {
+			return err
+		}
+		for key, data := range changed {
+			if err := bucket.Put([]byte(key), data); err != nil {
+				return err
+			}
+		}
+		for _, key := range deleted {
+			if err := bucket.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.saved = current
+	// The store skips fsync for limiter and cache writes; sessions are flushed here
+	return m.kv.db.Sync()
+}
+
+func (m *memoryRedis) saveSnapshot() {
+	if err := m.snapshot(); err != nil {
+		log.Printf("Failed to save embedded Redis data: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Keeps in-memory state in step with a Redis hash that every replica writes
+// to. The hash is read on Start and on every interval after, and its fields
+// are handed to apply, which returns fields to delete, such as expired entries.
+// A failed read keeps the state apply last built.
+type HashSync struct {
+	redis    *RedisClient
+	key      string
+	name     string // What the hash holds, for logs
+	interval time.Duration
+	apply    func(fields map[string]string) (stale []string)
+
+	mu       sync.Mutex
+	running  bool
+	stopChan chan struct{}
+}
+
+func NewHashSync(redis *RedisClient, key, name string, interval time.Duration, apply func(fields map[string]string) []string) *HashSync {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	return &HashSync{
+		redis:    redis,
+		key:      key,
+		name:     name,
+		interval: interval,
+		apply:    apply,
+	}
+}
+
+// Reads the hash, then keeps reading it in the background until stopped
+func (s *HashSync) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	stopChan := make(chan struct{})
+	s.stopChan = stopChan
+	s.mu.Unlock()
+
+	s.refresh()
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.refresh()
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+}
+
+func (s *HashSync) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		close(s.stopChan)
+		s.running = false
+	}
+}
+
+func (s *HashSync) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	fields, err := s.redis.HGetAll(ctx, s.key)
+	if err != nil {
+		log.Printf("Failed to refresh %s: %v", s.name, err)
+		return
+	}
+
+	if stale := s.apply(fields); len(stale) > 0 {
+		if err := s.redis.HDel(ctx, s.key, stale...); err != nil {
+			log.Printf("Failed to delete stale %s: %v", s.name, err)
+		}
+	}
+}
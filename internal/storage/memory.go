@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// In-process Redis server. miniredis only expires keys when its clock is
+// advanced, so it is moved along with the wall clock.
+type memoryRedis struct {
+	server   *miniredis.Miniredis
+	kv       *EmbeddedKV         // Keeps the data across restarts when set
+	saved    map[string][32]byte // Hashes of the entries last written to kv
+	saveMu   sync.Mutex          // Held while snapshotting, which close does too
+	stopChan chan struct{}
+}
+
+// Starts a Redis-compatible server inside the process and connects to it, for
+// development and tests without a Redis instance. Data is lost on exit.
+func NewMemoryRedis() (*RedisClient, error) {
+	server, password, err := startMiniredis()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start in-memory Redis: %w", err)
+	}
+
+	client, err := connectRedis(&redis.Options{Addr: server.Addr(), Password: password})
+	if err != nil {
+		server.Close()
+		return nil, err
+	}
+
+	client.memory = &memoryRedis{
+		server:   server,
+		stopChan: make(chan struct{}),
+	}
+	go client.memory.tick(time.Second)
+
+	return client, nil
+}
+
+// Starts miniredis on a loopback port behind a random password, so other
+// local users can't reach the gateway's data
+func startMiniredis() (*miniredis.Miniredis, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	password := hex.EncodeToString(secret)
+
+	server := miniredis.NewMiniRedis()
+	server.RequireAuth(password)
+	if err := server.Start(); err != nil {
+		return nil, "", err
+	}
+	return server, password, nil
+}
+
+func (m *memoryRedis) tick(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	lastSnapshot := last
+	for {
+		select {
+		case now := <-ticker.C:
+			m.server.FastForward(now.Sub(last))
+			last = now
+
+			if m.kv != nil && now.Sub(lastSnapshot) >= redisSnapshotInterval {
+				m.saveSnapshot()
+				lastSnapshot = now
+			}
+		case <-m.stopChan:
+			return
+		}
+	}
+}
+
+func (m *memoryRedis) close() {
+	close(m.stopChan)
+	if m.kv != nil {
+		m.saveSnapshot()
+	}
+	m.server.Close()
+}
@@ -5,19 +5,44 @@ import (
 	"time"
 
 	"github.com/aman-churiwal/api-gateway/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"golang.org/x/net/context"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// The SQL database. Postgres is the reference driver; MySQL and SQLite are
+// also supported, and Dialect tells repositories which SQL to write.
 type Postgres struct {
-	DB *gorm.DB
+	DB      *gorm.DB
+	Dialect string
 }
 
 // dsn - Data Source Name
 func NewPostgres(dsn string) (*Postgres, error) {
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	return openDatabase(DialectPostgres, postgres.Open(dsn))
+}
+
+// Asks password for the current password whenever a connection is opened,
+// so a rotated password applies as pooled connections are recycled
+func NewPostgresWithRotatingPassword(dsn string, password func() string) (*Postgres, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database settings: %w", err)
+	}
+
+	sqlDB := stdlib.OpenDB(*connConfig, stdlib.OptionBeforeConnect(func(ctx context.Context, cfg *pgx.ConnConfig) error {
+		cfg.Password = password()
+		return nil
+	}))
+
+	return openDatabase(DialectPostgres, postgres.New(postgres.Config{Conn: sqlDB}))
+}
+
+func openDatabase(dialect string, dialector gorm.Dialector) (*Postgres, error) {
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
@@ -37,7 +62,7 @@ func NewPostgres(dsn string) (*Postgres, error) {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	return &Postgres{DB: db}, nil
+	return &Postgres{DB: db, Dialect: dialect}, nil
 }
 
 func (p *Postgres) Ping(ctx context.Context) error {
@@ -49,13 +74,30 @@ func (p *Postgres) Ping(ctx context.Context) error {
 	return sqlDB.PingContext(ctx)
 }
 
+// Tables created by AutoMigrate
+var migratedModels = []interface{}{
+	&models.APIKey{},
+	&models.RateLimitTier{},
+	&models.User{},
+	&models.RequestLog{},
+	&models.DeadLetter{},
+	&models.RefreshToken{},
+	&models.Invitation{},
+	&models.PasswordResetToken{},
+	&models.LoginAttempt{},
+	&models.AuditLog{},
+	&models.ServiceAccount{},
+	&models.ServiceAccountToken{},
+	&models.UsageReport{},
+	&models.Organization{},
+	&models.Developer{},
+}
+
 func (p *Postgres) AutoMigrate() error {
-	return p.DB.AutoMigrate(
-		&models.APIKey{},
-		&models.RateLimitTier{},
-		&models.User{},
-		&models.RequestLog{},
-	)
+	if err := p.portColumnTypes(); err != nil {
+		return err
+	}
+	return p.DB.AutoMigrate(migratedModels...)
 }
 
 func (p *Postgres) Close() error {
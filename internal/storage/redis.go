@@ -2,7 +2,9 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -10,19 +12,36 @@ import (
 
 type RedisClient struct {
 	client *redis.Client
+	memory *memoryRedis // Set when the server runs in the process
 }
 
 func NewRedis(addr, password string, db int) (*RedisClient, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:         addr,
-		Password:     password,
-		DB:           db,
-		DialTimeout:  5 * time.Second,
-		ReadTimeout:  3 * time.Second,
-		WriteTimeout: 3 * time.Second,
-		PoolSize:     10,
-		MinIdleConns: 5,
+	return connectRedis(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
 	})
+}
+
+// Asks password for the current password whenever a connection is opened,
+// so a rotated password applies without restarting
+func NewRedisWithRotatingPassword(addr string, password func() string, db int) (*RedisClient, error) {
+	return connectRedis(&redis.Options{
+		Addr: addr,
+		CredentialsProvider: func() (string, string) {
+			return "", password()
+		},
+		DB: db,
+	})
+}
+
+func connectRedis(opts *redis.Options) (*RedisClient, error) {
+	opts.DialTimeout = 5 * time.Second
+	opts.ReadTimeout = 3 * time.Second
+	opts.WriteTimeout = 3 * time.Second
+	opts.PoolSize = 10
+	opts.MinIdleConns = 5
+	client := redis.NewClient(opts)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -46,6 +65,10 @@ func (r *RedisClient) Set(ctx context.Context, key string, value interface{}, ex
 	return r.client.Set(ctx, key, value, expiration).Err()
 }
 
+func (r *RedisClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, expiration).Result()
+}
+
 func (r *RedisClient) Incr(ctx context.Context, key string) (int64, error) {
 	return r.client.Incr(ctx, key).Result()
 }
@@ -54,6 +77,15 @@ func (r *RedisClient) Expire(ctx context.Context, key string, expiration time.Du
 	return r.client.Expire(ctx, key, expiration).Err()
 }
 
+// Returns a key's remaining time to live, or a negative duration when it has none
+func (r *RedisClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return r.client.TTL(ctx, key).Result()
+}
+
+func (r *RedisClient) Del(ctx context.Context, keys ...string) error {
+	return r.client.Del(ctx, keys...).Err()
+}
+
 func (r *RedisClient) Pipeline() redis.Pipeliner {
 	return r.client.Pipeline()
 }
@@ -70,6 +102,94 @@ func (r *RedisClient) ZRange(ctx context.Context, key string, start, stop int64)
 	return r.client.ZRange(ctx, key, start, stop).Result()
 }
 
+func (r *RedisClient) HSet(ctx context.Context, key string, values ...interface{}) error {
+	return r.client.HSet(ctx, key, values...).Err()
+}
+
+func (r *RedisClient) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return r.client.HGetAll(ctx, key).Result()
+}
+
+func (r *RedisClient) HDel(ctx context.Context, key string, fields ...string) error {
+	return r.client.HDel(ctx, key, fields...).Err()
+}
+
+// Deletes the keys matching a glob pattern and returns how many were deleted.
+// Keys are found with SCAN, so keys written meanwhile may be missed.
+func (r *RedisClient) DelMatching(ctx context.Context, pattern string) (int, error) {
+	deleted := 0
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, pattern, 500).Result()
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			n, err := r.client.Del(ctx, keys...).Result()
+			deleted += int(n)
+			if err != nil {
+				return deleted, err
+			}
+		}
+		if next == 0 {
+			return deleted, nil
+		}
+		cursor = next
+	}
+}
+
+// Appends an entry to a stream and returns its ID
+func (r *RedisClient) XAdd(ctx context.Context, stream string, values map[string]interface{}) (string, error) {
+	return r.client.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: values}).Result()
+}
+
+// Creates a consumer group reading new entries, and the stream if missing.
+// A group that already exists is not an error.
+func (r *RedisClient) XGroupCreate(ctx context.Context, stream, group string) error {
+	err := r.client.XGroupCreateMkStream(ctx, stream, group, "$").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// Reads up to count new entries for a consumer of a group, waiting up to
+// block for one to arrive. Returns no entries when none did.
+func (r *RedisClient) XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]redis.XMessage, error) {
+	streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil || len(streams) == 0 {
+		return nil, err
+	}
+	return streams[0].Messages, nil
+}
+
+// Acknowledges entries for a group and removes them from the stream
+func (r *RedisClient) XAckDel(ctx context.Context, stream, group string, ids ...string) error {
+	pipe := r.client.TxPipeline()
+	pipe.XAck(ctx, stream, group, ids...)
+	pipe.XDel(ctx, stream, ids...)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Returns a key's value and deletes it atomically
+func (r *RedisClient) GetDel(ctx context.Context, key string) (string, error) {
+	return r.client.GetDel(ctx, key).Result()
+}
+
 func (r *RedisClient) Close() error {
-	return r.client.Close()
+	err := r.client.Close()
+	if r.memory != nil {
+		r.memory.close()
+	}
+	return err
 }
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// SQL dialects the database can be opened with
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+	DialectSQLite   = "sqlite"
+)
+
+// Postgres column types the models declare, and what other dialects store them as
+var columnTypes = map[string]map[string]string{
+	DialectMySQL: {
+		"uuid":  "char(36)",
+		"jsonb": "json",
+		"bytea": "longblob",
+	},
+	DialectSQLite: {
+		"uuid":  "text",
+		"jsonb": "text",
+		"bytea": "blob",
+	},
+}
+
+// dsn in go-sql-driver form; it must set parseTime=true
+func NewMySQL(dsn string) (*Postgres, error) {
+	return openDatabase(DialectMySQL, mysql.New(mysql.Config{
+		DSN:               dsn,
+		DefaultStringSize: 255,
+	}))
+}
+
+// Opens the database file at path, creating it if missing. Meant for
+// single-node installs and development. A path of ":memory:" keeps the
+// database in the process.
+func NewSQLite(path string) (*Postgres, error) {
+	if path == ":memory:" {
+		return openSQLiteMemory()
+	}
+	return openDatabase(DialectSQLite, sqlite.Open(path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"))
+}
+
+// Every connection to :memory: gets its own empty database, so the pool is
+// held to one connection that is never recycled
+func openSQLiteMemory() (*Postgres, error) {
+	db, err := openDatabase(DialectSQLite, sqlite.Open(":memory:?_pragma=foreign_keys(1)"))
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database instance: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+
+	return db, nil
+}
+
+// Rewrites the models' Postgres column types for the dialect before migrating
+func (p *Postgres) portColumnTypes() error {
+	types, ok := columnTypes[p.Dialect]
+	if !ok {
+		return nil
+	}
+
+	for _, model := range migratedModels {
+		stmt := &gorm.Statement{DB: p.DB}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+
+		for _, field := range stmt.Schema.Fields {
+			ported, ok := types[string(field.DataType)]
+			if !ok {
+				continue
+			}
+			// MySQL JSON columns can't have literal defaults; the JSON types
+			// write an empty value for nil instead
+			if p.Dialect == DialectMySQL && field.DataType == "jsonb" {
+				field.HasDefaultValue = false
+				field.DefaultValue = ""
+				field.DefaultValueInterface = nil
+			}
+			field.DataType = schema.DataType(ported)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package stubs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/audit"
+	"github.com/aman-churiwal/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Redis hash holding stubs shared by all replicas
+const redisKey = "gateway:stubs"
+
+const (
+	DefaultTTL = time.Hour
+	MaxTTL     = 7 * 24 * time.Hour
+)
+
+var (
+	ErrNotFound    = errors.New("stub not found")
+	ErrInvalidStub = errors.New("invalid stub")
+)
+
+// Conditions a request must meet, in addition to method and path, to be stubbed
+type Matcher struct {
+	Query        map[string]string `json:"query,omitempty"`         // Exact query parameter values
+	Headers      map[string]string `json:"headers,omitempty"`       // Exact header values
+	BodyContains string            `json:"body_contains,omitempty"` // Substring of the request body
+}
+
+// Response returned in place of the backend's. Body is a text/template.
+type Response struct {
+	Status  int               `json:"status"`            // Default: 200
+	Headers map[string]string `json:"headers,omitempty"` // Values are templates too
+	Body    string            `json:"body"`
+}
+
+// A stubbed route
+type Stub struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description,omitempty"`
+	Method      string    `json:"method,omitempty"` // Empty matches any method
+	Path        string    `json:"path"`             // Exact path, or a prefix when it ends in "*"
+	Match       Matcher   `json:"match"`
+	Response    Response  `json:"response"`
+	Priority    int       `json:"priority"` // Higher wins when several stubs match
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+
+	body    *template.Template
+	headers map[string]*template.Template
+}
+
+// Reports whether the stub has passed its expiry
+func (s *Stub) Expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+// Reports whether the stub needs the request body to match or render
+func (s *Stub) NeedsBody() bool {
+	return s.Match.BodyContains != "" || strings.Contains(s.Response.Body, ".Body") || strings.Contains(s.Response.Body, ".JSON")
+}
+
+// Reports whether a request matches everything but the body
+func (s *Stub) Matches(r *http.Request) bool {
+	if s.Method != "" && !strings.EqualFold(s.Method, r.Method) {
+		return false
+	}
+
+	if prefix, ok := strings.CutSuffix(s.Path, "*"); ok {
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			return false
+		}
+	} else if r.URL.Path != s.Path {
+		return false
+	}
+
+	query := r.URL.Query()
+	for name, value := range s.Match.Query {
+		if query.Get(name) != value {
+			return false
+		}
+	}
+	for name, value := range s.Match.Headers {
+		if r.Header.Get(name) != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Parses the response templates and fills defaults
+func (s *Stub) Compile() error {
+	if s.Path == "" || !strings.HasPrefix(s.Path, "/") {
+		return fmt.Errorf("path must start with /")
+	}
+	if s.Response.Status == 0 {
+		s.Response.Status = http.StatusOK
+	}
+	if s.Response.Status < 100 || s.Response.Status > 599 {
+		return fmt.Errorf("invalid response status: %d", s.Response.Status)
+	}
+	s.Method = strings.ToUpper(s.Method)
+
+	body, err := template.New("body").Funcs(funcs).Option("missingkey=zero").Parse(s.Response.Body)
+	if err != nil {
+		return fmt.Errorf("invalid body template: %w", err)
+	}
+	s.body = body
+
+	s.headers = make(map[string]*template.Template, len(s.Response.Headers))
+	for name, value := range s.Response.Headers {
+		tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(value)
+		if err != nil {
+			return fmt.Errorf("invalid template for header %s: %w", name, err)
+		}
+		s.headers[name] = tmpl
+	}
+
+	return nil
+}
+
+// Holds stubs and keeps them in sync with other replicas
+type Registry struct {
+	mu       sync.RWMutex
+	stubs    map[string]*Stub
+	redis    *storage.RedisClient
+	hashSync *storage.HashSync
+}
+
+func NewRegistry(redis *storage.RedisClient, interval time.Duration) *Registry {
+	r := &Registry{
+		stubs: make(map[string]*Stub),
+		redis: redis,
+	}
+	r.hashSync = storage.NewHashSync(redis, redisKey, "stubs", interval, r.apply)
+	return r
+}
+
+// Validates and stores a stub that expires after ttl (DefaultTTL when zero)
+func (r *Registry) Add(ctx context.Context, stub Stub, ttl time.Duration) (*Stub, error) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if ttl > MaxTTL {
+		return nil, fmt.Errorf("%w: ttl may not exceed %s", ErrInvalidStub, MaxTTL)
+	}
+	if err := stub.Compile(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStub, err)
+	}
+
+	stub.ID = uuid.New().String()
+	stub.CreatedAt = time.Now()
+	stub.ExpiresAt = stub.CreatedAt.Add(ttl)
+
+	data, err := json.Marshal(&stub)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.redis.HSet(ctx, redisKey, stub.ID, data); err != nil {
+		return nil, fmt.Errorf("failed to persist stub: %w", err)
+	}
+
+	r.mu.Lock()
+	r.stubs[stub.ID] = &stub
+	r.mu.Unlock()
+
+	audit.Record(ctx, "stub.create", "stub", stub.ID, nil, &stub)
+
+	log.Printf("Stub %s added for %s %s (expires %s)", stub.ID, stub.Method, stub.Path, stub.ExpiresAt.Format(time.RFC3339))
+	return &stub, nil
+}
+
+// Deletes a stub before it expires
+func (r *Registry) Remove(ctx context.Context, id string) error {
+	r.mu.Lock()
+	stub, exists := r.stubs[id]
+	delete(r.stubs, id)
+	r.mu.Unlock()
+
+	if !exists {
+		return ErrNotFound
+	}
+	if err := r.redis.HDel(ctx, redisKey, id); err != nil {
+		return err
+	}
+
+	audit.Record(ctx, "stub.delete", "stub", id, stub, nil)
+	return nil
+}
+
+// Returns the live stubs, highest priority first
+func (r *Registry) List() []*Stub {
+	now := time.Now()
+
+	r.mu.RLock()
+	stubs := make([]*Stub, 0, len(r.stubs))
+	for _, stub := range r.stubs {
+		if !stub.Expired(now) {
+			stubs = append(stubs, stub)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(stubs, func(i, j int) bool {
+		if stubs[i].Priority != stubs[j].Priority {
+			return stubs[i].Priority > stubs[j].Priority
+		}
+		return stubs[i].CreatedAt.After(stubs[j].CreatedAt)
+	})
+	return stubs
+}
+
+// Returns the live stubs matching a request's method, path, query and
+// headers, in the order they should be tried
+func (r *Registry) Candidates(req *http.Request) []*Stub {
+	var candidates []*Stub
+	for _, stub := range r.List() {
+		if stub.Matches(req) {
+			candidates = append(candidates, stub)
+		}
+	}
+	return candidates
+}
+
+// Loads persisted stubs and keeps them in sync with other replicas
+func (r *Registry) Start() {
+	r.hashSync.Start()
+}
+
+// Stops syncing stubs
+func (r *Registry) Stop() {
+	r.hashSync.Stop()
+}
+
+// Replaces the stubs with those persisted in Redis, returning expired ones to delete
+func (r *Registry) apply(persisted map[string]string) []string {
+	now := time.Now()
+	stubs := make(map[string]*Stub, len(persisted))
+	var expired []string
+
+	for id, data := range persisted {
+		var stub Stub
+		if err := json.Unmarshal([]byte(data), &stub); err != nil {
+			log.Printf("Skipping malformed stub %s: %v", id, err)
+			continue
+		}
+		if stub.Expired(now) {
+			expired = append(expired, id)
+			continue
+		}
+		if err := stub.Compile(); err != nil {
+			log.Printf("Skipping invalid stub %s: %v", id, err)
+			continue
+		}
+		stubs[id] = &stub
+	}
+
+	r.mu.Lock()
+	r.stubs = stubs
+	r.mu.Unlock()
+
+	return expired
+}
@@ -0,0 +1,86 @@
+package stubs
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Helpers available to response templates
+var funcs = template.FuncMap{
+	"uuid": func() string { return uuid.New().String() },
+	"now":  func() string { return time.Now().UTC().Format(time.RFC3339) },
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"default": func(fallback, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return fallback
+		}
+		return v
+	},
+}
+
+// Request values exposed to response templates, e.g. {{.Query.id}} or {{.JSON.name}}
+type requestData struct {
+	Method   string
+	Path     string
+	Wildcard string // Part of the path matched by a trailing "*"
+	Query    map[string]string
+	Headers  map[string]string
+	Body     string
+	JSON     interface{} // Request body decoded as JSON, nil when it is not JSON
+}
+
+// Reports whether the request body satisfies the stub's body matcher
+func (s *Stub) MatchesBody(body []byte) bool {
+	return s.Match.BodyContains == "" || bytes.Contains(body, []byte(s.Match.BodyContains))
+}
+
+// Renders the stub's response for a request
+func (s *Stub) Render(r *http.Request, body []byte) (int, http.Header, []byte, error) {
+	data := requestData{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Query:   make(map[string]string),
+		Headers: make(map[string]string),
+		Body:    string(body),
+	}
+	if prefix, ok := strings.CutSuffix(s.Path, "*"); ok {
+		data.Wildcard = strings.TrimPrefix(r.URL.Path, prefix)
+	}
+	for name, values := range r.URL.Query() {
+		data.Query[name] = values[0]
+	}
+	for name, values := range r.Header {
+		data.Headers[name] = values[0]
+	}
+	if len(body) > 0 {
+		var decoded interface{}
+		if json.Unmarshal(body, &decoded) == nil {
+			data.JSON = decoded
+		}
+	}
+
+	header := make(http.Header, len(s.headers))
+	for name, tmpl := range s.headers {
+		var value strings.Builder
+		if err := tmpl.Execute(&value, data); err != nil {
+			return 0, nil, nil, err
+		}
+		header.Set(name, value.String())
+	}
+
+	var out bytes.Buffer
+	if err := s.body.Execute(&out, data); err != nil {
+		return 0, nil, nil, err
+	}
+
+	return s.Response.Status, header, out.Bytes(), nil
+}
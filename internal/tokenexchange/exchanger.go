@@ -0,0 +1,72 @@
+package tokenexchange
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Identifies the consumer a backend token is issued for
+type Subject struct {
+	ID    string // API key ID or user ID
+	Type  string // "api_key" or "user"
+	Tier  string
+	Email string
+	Role  string
+}
+
+// Swaps a validated consumer identity for a backend-specific token
+type Exchanger interface {
+	Exchange(ctx context.Context, subject Subject, audience string) (*Token, error)
+}
+
+// Token issued for a backend audience
+type Token struct {
+	Value     string
+	ExpiresAt time.Time
+}
+
+// Reuses issued tokens until shortly before they expire
+type CachingExchanger struct {
+	mu     sync.Mutex
+	next   Exchanger
+	tokens map[string]*Token
+	skew   time.Duration
+}
+
+func NewCachingExchanger(next Exchanger, skew time.Duration) *CachingExchanger {
+	return &CachingExchanger{
+		next:   next,
+		tokens: make(map[string]*Token),
+		skew:   skew,
+	}
+}
+
+func (c *CachingExchanger) Exchange(ctx context.Context, subject Subject, audience string) (*Token, error) {
+	cacheKey := subject.Type + ":" + subject.ID + ":" + audience
+
+	c.mu.Lock()
+	if token, exists := c.tokens[cacheKey]; exists && time.Until(token.ExpiresAt) > c.skew {
+		c.mu.Unlock()
+		return token, nil
+	}
+	c.mu.Unlock()
+
+	token, err := c.next.Exchange(ctx, subject, audience)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired entries so the cache stays bounded by active consumers
+	for key, cached := range c.tokens {
+		if time.Now().After(cached.ExpiresAt) {
+			delete(c.tokens, key)
+		}
+	}
+	c.tokens[cacheKey] = token
+
+	return token, nil
+}
@@ -0,0 +1,61 @@
+package tokenexchange
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Mints short-lived backend tokens signed by the gateway
+type LocalIssuer struct {
+	issuer string
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewLocalIssuer(issuer, secret string, ttl time.Duration) *LocalIssuer {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+
+	return &LocalIssuer{
+		issuer: issuer,
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+func (l *LocalIssuer) Exchange(ctx context.Context, subject Subject, audience string) (*Token, error) {
+	now := time.Now()
+	expiresAt := now.Add(l.ttl)
+
+	claims := jwt.MapClaims{
+		"iss":          l.issuer,
+		"sub":          subject.ID,
+		"aud":          audience,
+		"jti":          uuid.New().String(),
+		"iat":          now.Unix(),
+		"exp":          expiresAt.Unix(),
+		"subject_type": subject.Type,
+	}
+	if subject.Tier != "" {
+		claims["tier"] = subject.Tier
+	}
+	if subject.Email != "" {
+		claims["email"] = subject.Email
+	}
+	if subject.Role != "" {
+		claims["role"] = subject.Role
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString(l.secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign backend token: %w", err)
+	}
+
+	return &Token{Value: tokenString, ExpiresAt: expiresAt}, nil
+}
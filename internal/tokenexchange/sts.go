@@ -0,0 +1,89 @@
+package tokenexchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeJWT           = "urn:ietf:params:oauth:token-type:jwt"
+	tokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// Exchanges gateway-issued assertions at an external RFC 8693 security token service
+type STSExchanger struct {
+	endpoint     string
+	clientID     string
+	clientSecret string
+	assertions   *LocalIssuer // Mints the subject_token presented to the STS
+	client       *http.Client
+}
+
+func NewSTSExchanger(endpoint, clientID, clientSecret string, assertions *LocalIssuer) *STSExchanger {
+	return &STSExchanger{
+		endpoint:     endpoint,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		assertions:   assertions,
+		client:       &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// RFC 8693 token exchange response
+type stsResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	ExpiresIn       int    `json:"expires_in"`
+	Error           string `json:"error"`
+}
+
+func (s *STSExchanger) Exchange(ctx context.Context, subject Subject, audience string) (*Token, error) {
+	assertion, err := s.assertions.Exchange(ctx, subject, s.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", grantTypeTokenExchange)
+	form.Set("subject_token", assertion.Value)
+	form.Set("subject_token_type", tokenTypeJWT)
+	form.Set("requested_token_type", tokenTypeAccessToken)
+	form.Set("audience", audience)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if s.clientID != "" {
+		req.SetBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body stsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode token exchange response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		return nil, fmt.Errorf("token exchange rejected (status %d): %s", resp.StatusCode, body.Error)
+	}
+
+	expiresIn := time.Duration(body.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = time.Minute
+	}
+
+	return &Token{Value: body.AccessToken, ExpiresAt: time.Now().Add(expiresIn)}, nil
+}
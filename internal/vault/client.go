@@ -0,0 +1,219 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Service account token presented by the kubernetes auth method
+const defaultServiceAccountToken = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// How to log in to Vault
+type Auth struct {
+	Method    string // token, kubernetes or approle
+	Mount     string // Default: the method name
+	Token     string // token
+	Role      string // kubernetes
+	TokenFile string // kubernetes; default: the pod's service account token
+	RoleID    string // approle
+	SecretID  string // approle
+}
+
+// Reads secrets over the Vault HTTP API, logging in again when the token expires
+type Client struct {
+	address   string
+	namespace string
+	auth      Auth
+	client    *http.Client
+
+	mu      sync.Mutex
+	token   string
+	renewAt time.Time // Zero for tokens that don't expire
+}
+
+func NewClient(address, namespace string, auth Auth) *Client {
+	if auth.Mount == "" {
+		auth.Mount = auth.Method
+	}
+	if auth.Method == "kubernetes" && auth.TokenFile == "" {
+		auth.TokenFile = defaultServiceAccountToken
+	}
+
+	return &Client{
+		address:   strings.TrimRight(address, "/"),
+		namespace: namespace,
+		auth:      auth,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Returns a secret field from a reference of the form path#field. KV version 2
+// paths include data/, e.g. secret/data/gateway#jwt_secret.
+func (c *Client) Read(ctx context.Context, ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("invalid vault secret reference %q: expected path#field", ref)
+	}
+
+	resp, err := c.get(ctx, "/v1/"+strings.TrimLeft(path, "/"))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode vault response: %w", err)
+	}
+
+	// KV version 2 nests the secret under data with its metadata alongside
+	data := result.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, versioned := data["metadata"]; versioned {
+			data = nested
+		}
+	}
+
+	value, ok := data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no string field %s", path, field)
+	}
+	return value, nil
+}
+
+// Sends a GET with the client token, logging in again once if it was rejected
+func (c *Client) get(ctx context.Context, path string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		token, err := c.clientToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.send(ctx, http.MethodGet, path, nil, token)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+
+		err = responseError(resp)
+		if resp.StatusCode == http.StatusForbidden && c.auth.Method != "token" && attempt == 0 {
+			c.mu.Lock()
+			c.token = ""
+			c.mu.Unlock()
+			continue
+		}
+		return nil, err
+	}
+}
+
+// Returns the current token, logging in if there is none or it is due for renewal
+func (c *Client) clientToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.auth.Method == "token" {
+		return c.auth.Token, nil
+	}
+	if c.token != "" && (c.renewAt.IsZero() || time.Now().Before(c.renewAt)) {
+		return c.token, nil
+	}
+
+	var body map[string]string
+	switch c.auth.Method {
+	case "kubernetes":
+		jwt, err := os.ReadFile(c.auth.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read service account token: %w", err)
+		}
+		body = map[string]string{"role": c.auth.Role, "jwt": strings.TrimSpace(string(jwt))}
+	case "approle":
+		body = map[string]string{"role_id": c.auth.RoleID, "secret_id": c.auth.SecretID}
+	default:
+		return "", fmt.Errorf("unknown vault auth method: %s", c.auth.Method)
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.send(ctx, http.MethodPost, "/v1/auth/"+c.auth.Mount+"/login", payload, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault %s login failed: %w", c.auth.Method, responseError(resp))
+	}
+
+	var result struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"` // Seconds; 0 never expires
+		} `json:"auth"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode vault login response: %w", err)
+	}
+	if result.Auth.ClientToken == "" {
+		return "", errors.New("vault login returned no client token")
+	}
+
+	c.token = result.Auth.ClientToken
+	c.renewAt = time.Time{}
+	if lease := time.Duration(result.Auth.LeaseDuration) * time.Second; lease > 0 {
+		// Log in again well before the token expires
+		c.renewAt = time.Now().Add(lease * 2 / 3)
+	}
+
+	return c.token, nil
+}
+
+func (c *Client) send(ctx context.Context, method, path string, payload []byte, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.address+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach vault: %w", err)
+	}
+	return resp, nil
+}
+
+// Reads Vault's error list from a failed response and closes it
+func responseError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	var result struct {
+		Errors []string `json:"errors"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if json.Unmarshal(body, &result) == nil && len(result.Errors) > 0 {
+		return fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.Join(result.Errors, "; "))
+	}
+	return fmt.Errorf("vault returned status %d", resp.StatusCode)
+}
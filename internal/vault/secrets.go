@@ -0,0 +1,157 @@
+package vault
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aman-churiwal/api-gateway/internal/config"
+)
+
+// Names of the secrets the gateway can read from Vault
+const (
+	JWTSecret        = "jwt_secret"
+	DatabasePassword = "database_password"
+	RedisPassword    = "redis_password"
+)
+
+// Secrets read from Vault and re-read on an interval, so rotations reach the
+// gateway without a restart
+type Secrets struct {
+	client   *Client
+	refs     map[string]string // Secret name to path#field
+	interval time.Duration
+
+	mu       sync.RWMutex
+	values   map[string]string
+	watchers map[string][]func(value string)
+	running  bool
+	stopChan chan struct{}
+}
+
+func NewSecrets(cfg *config.VaultConfig) *Secrets {
+	refs := make(map[string]string)
+	for name, ref := range map[string]string{
+		JWTSecret:        cfg.JWTSecret,
+		DatabasePassword: cfg.DatabasePassword,
+		RedisPassword:    cfg.RedisPassword,
+	} {
+		if ref != "" {
+			refs[name] = ref
+		}
+	}
+
+	client := NewClient(cfg.Address, cfg.Namespace, Auth{
+		Method:    cfg.Auth.Method,
+		Mount:     cfg.Auth.Mount,
+		Token:     cfg.Auth.Token,
+		Role:      cfg.Auth.Role,
+		TokenFile: cfg.Auth.TokenFile,
+		RoleID:    cfg.Auth.RoleID,
+		SecretID:  cfg.Auth.SecretID,
+	})
+
+	return &Secrets{
+		client:   client,
+		refs:     refs,
+		interval: time.Duration(cfg.RefreshMinutes) * time.Minute,
+		values:   make(map[string]string),
+		watchers: make(map[string][]func(string)),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Reads every configured secret, failing if any can't be read
+func (s *Secrets) Load(ctx context.Context) error {
+	for name, ref := range s.refs {
+		value, err := s.client.Read(ctx, ref)
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		s.values[name] = value
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// Returns a secret's current value and whether it is read from Vault
+func (s *Secrets) Get(name string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, exists := s.values[name]
+	return value, exists
+}
+
+// Calls fn with the new value whenever a refresh finds the secret rotated
+func (s *Secrets) OnChange(name string, fn func(value string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.watchers[name] = append(s.watchers[name], fn)
+}
+
+func (s *Secrets) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return
+	}
+	s.running = true
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.refresh()
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+func (s *Secrets) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		close(s.stopChan)
+		s.running = false
+	}
+}
+
+// Re-reads every secret; one that fails keeps its current value
+func (s *Secrets) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for name, ref := range s.refs {
+		value, err := s.client.Read(ctx, ref)
+		if err != nil {
+			log.Printf("Failed to refresh %s from Vault, keeping current value: %v", name, err)
+			continue
+		}
+
+		s.mu.Lock()
+		changed := s.values[name] != value
+		s.values[name] = value
+		watchers := s.watchers[name]
+		s.mu.Unlock()
+
+		if !changed {
+			continue
+		}
+		log.Printf("Rotated %s from Vault", name)
+		for _, fn := range watchers {
+			fn(value)
+		}
+	}
+}
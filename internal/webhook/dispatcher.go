@@ -0,0 +1,322 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A webhook receiver and the events it subscribes to
+type Endpoint struct {
+	URL    string
+	Secret string   // Signs payloads with HMAC-SHA256 when set
+	Events []string // Empty subscribes to all events
+}
+
+// Payload delivered to webhook receivers
+type Event struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// Delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed" // Every attempt failed
+)
+
+// How many deliveries are kept for the admin API
+const deliveryHistory = 500
+
+// The outcome of sending one event to one endpoint
+type Delivery struct {
+	EventID     string     `json:"event_id"`
+	EventType   string     `json:"event_type"`
+	URL         string     `json:"url"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	StatusCode  int        `json:"status_code,omitempty"` // Of the last attempt
+	LastError   string     `json:"last_error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Delivers events to webhook endpoints in the background. Each endpoint has
+// its own queue and worker, so a slow or failing receiver only delays itself,
+// and retries are scheduled rather than waited for.
+type Dispatcher struct {
+	endpoints  []Endpoint
+	workers    []*endpointWorker
+	queue      chan Event
+	client     *http.Client
+	maxRetries int
+
+	mu         sync.Mutex
+	deliveries []*Delivery // Oldest first, capped at deliveryHistory
+	dropped    int64       // Events lost to a full queue
+}
+
+// Delivers to one endpoint, one attempt at a time
+type endpointWorker struct {
+	endpoint Endpoint
+	queue    chan attempt
+}
+
+// One try at sending an event to an endpoint
+type attempt struct {
+	event    Event
+	payload  []byte
+	delivery *Delivery
+	number   int
+}
+
+func NewDispatcher(endpoints []Endpoint, bufferSize int) *Dispatcher {
+	if bufferSize <= 0 {
+		bufferSize = 1000
+	}
+
+	d := &Dispatcher{
+		endpoints:  endpoints,
+		queue:      make(chan Event, bufferSize),
+		client:     &http.Client{Timeout: 10 * time.Second},
+		maxRetries: 3,
+	}
+
+	for _, endpoint := range endpoints {
+		w := &endpointWorker{endpoint: endpoint, queue: make(chan attempt, bufferSize)}
+		d.workers = append(d.workers, w)
+		go d.work(w)
+	}
+	go d.run()
+
+	return d
+}
+
+// Queues an event for delivery without blocking the caller
+func (d *Dispatcher) Dispatch(eventType string, data map[string]interface{}) {
+	if d == nil || len(d.endpoints) == 0 {
+		return
+	}
+
+	event := Event{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+
+	select {
+	case d.queue <- event:
+	default:
+		d.mu.Lock()
+		d.dropped++
+		d.mu.Unlock()
+		log.Printf("Webhook queue full, dropping %s event", eventType)
+	}
+}
+
+func (d *Dispatcher) run() {
+	for event := range d.queue {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("Failed to encode webhook event %s: %v", event.Type, err)
+			continue
+		}
+
+		for _, w := range d.workers {
+			if !subscribed(w.endpoint, event.Type) {
+				continue
+			}
+			d.enqueue(w, attempt{event: event, payload: payload, delivery: d.track(w.endpoint, event), number: 1})
+		}
+	}
+}
+
+func (d *Dispatcher) work(w *endpointWorker) {
+	for a := range w.queue {
+		d.deliver(w, a)
+	}
+}
+
+// Hands an attempt to an endpoint's worker, failing the delivery when its queue is full
+func (d *Dispatcher) enqueue(w *endpointWorker, a attempt) {
+	select {
+	case w.queue <- a:
+	default:
+		d.mu.Lock()
+		d.dropped++
+		d.mu.Unlock()
+		d.abandon(a.delivery, "delivery queue is full")
+		log.Printf("Webhook queue for %s full, dropping %s event", w.endpoint.URL, a.event.Type)
+	}
+}
+
+// Starts recording a delivery, evicting the oldest past the history size
+func (d *Dispatcher) track(endpoint Endpoint, event Event) *Delivery {
+	delivery := &Delivery{
+		EventID:   event.ID,
+		EventType: event.Type,
+		URL:       endpoint.URL,
+		Status:    DeliveryPending,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.deliveries) >= deliveryHistory {
+		d.deliveries = d.deliveries[1:]
+	}
+	d.deliveries = append(d.deliveries, delivery)
+	return delivery
+}
+
+// Sends a payload to an endpoint. Failures are queued again after a backoff
+// doubling from a second, without holding up the endpoint's other deliveries.
+func (d *Dispatcher) deliver(w *endpointWorker, a attempt) {
+	statusCode, err := d.send(w.endpoint, a.event, a.payload)
+	d.record(a.delivery, a.number, statusCode, err)
+	if err == nil {
+		return
+	}
+
+	log.Printf("Webhook delivery of %s to %s failed (attempt %d/%d): %v", a.event.Type, w.endpoint.URL, a.number, d.maxRetries, err)
+	if a.number < d.maxRetries {
+		backoff := time.Second << (a.number - 1)
+		a.number++
+		time.AfterFunc(backoff, func() { d.enqueue(w, a) })
+	}
+}
+
+func (d *Dispatcher) record(delivery *Delivery, attempt, statusCode int, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delivery.Attempts = attempt
+	delivery.StatusCode = statusCode
+	switch {
+	case err == nil:
+		delivery.Status = DeliveryDelivered
+		delivery.LastError = ""
+	case attempt >= d.maxRetries:
+		delivery.Status = DeliveryFailed
+		delivery.LastError = err.Error()
+	default:
+		delivery.LastError = err.Error()
+		return
+	}
+	completedAt := time.Now().UTC()
+	delivery.CompletedAt = &completedAt
+}
+
+// Fails a delivery without another attempt
+func (d *Dispatcher) abandon(delivery *Delivery, reason string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	completedAt := time.Now().UTC()
+	delivery.Status = DeliveryFailed
+	delivery.LastError = reason
+	delivery.CompletedAt = &completedAt
+}
+
+func (d *Dispatcher) send(endpoint Endpoint, event Event, payload []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gateway-Event", event.Type)
+	req.Header.Set("X-Gateway-Delivery", event.ID)
+	if endpoint.Secret != "" {
+		req.Header.Set("X-Gateway-Signature", "sha256="+Sign(endpoint.Secret, payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Returns recent deliveries newest first, optionally only those in a state
+func (d *Dispatcher) Deliveries(status string) []Delivery {
+	deliveries := make([]Delivery, 0)
+	if d == nil {
+		return deliveries
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i := len(d.deliveries) - 1; i >= 0; i-- {
+		if status == "" || d.deliveries[i].Status == status {
+			deliveries = append(deliveries, *d.deliveries[i])
+		}
+	}
+	return deliveries
+}
+
+// Returns how many events and delivery attempts are waiting, not counting
+// scheduled retries, and how many were dropped
+func (d *Dispatcher) Backlog() (queued int, dropped int64) {
+	if d == nil {
+		return 0, 0
+	}
+
+	queued = len(d.queue)
+	for _, w := range d.workers {
+		queued += len(w.queue)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return queued, d.dropped
+}
+
+// Returns the hex encoded HMAC-SHA256 of a payload
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Subscriptions name an event type or a whole category, e.g. "circuit_breaker.*".
+// Sensitive events must be named.
+func subscribed(endpoint Endpoint, eventType string) bool {
+	sensitive := sensitiveEvents[eventType]
+	if len(endpoint.Events) == 0 {
+		return !sensitive
+	}
+	for _, e := range endpoint.Events {
+		if e == eventType {
+			return true
+		}
+		if category, ok := strings.CutSuffix(e, "*"); ok && !sensitive && strings.HasPrefix(eventType, category) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package webhook
+
+// API key lifecycle events
+const (
+	EventAPIKeyCreated     = "api_key.created"
+	EventAPIKeyRotated     = "api_key.rotated"
+	EventAPIKeyDeactivated = "api_key.deactivated"
+	EventAPIKeyDeleted     = "api_key.deleted"
+	EventAPIKeyNewIP       = "api_key.new_ip"
+	EventAPIKeyStale       = "api_key.stale"
+	EventAPIKeyTransferred = "api_key.transferred"
+)
+
+// User account events. Password reset events carry the reset token, so only
+// subscribe endpoints that deliver it to the user (e.g. a mailer).
+const (
+	EventPasswordResetRequested = "user.password_reset_requested"
+	EventPasswordChanged        = "user.password_changed"
+	EventAccountLocked          = "user.locked_out"
+)
+
+// Events carrying secrets. They reach only endpoints that list them by name,
+// never through an empty subscription or a category.
+var sensitiveEvents = map[string]bool{
+	EventPasswordResetRequested: true,
+}
+
+// Traffic anomaly alerts
+const (
+	EventAlertFired    = "alert.fired"
+	EventAlertResolved = "alert.resolved"
+)
+
+// Backend events, dispatched per service
+const (
+	EventCircuitBreakerStateChanged = "circuit_breaker.state_changed"
+	EventTargetUnhealthy            = "target.unhealthy"
+	EventTargetHealthy              = "target.healthy"
+)
+
+// Sent the first time a consumer is rejected in a rate limit window
+const EventQuotaExceeded = "quota.exceeded"
@@ -15,15 +15,20 @@ var (
 	requestCount atomic.Int64
 	// Port the backend is running on
 	port string
+	// Skip per-request logs, which slow down load tests
+	quiet bool
 )
 
 func main() {
 	flag.StringVar(&port, "port", "3001", "Port to listen on")
+	flag.BoolVar(&quiet, "quiet", false, "Don't log every request")
 	flag.Parse()
 
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		count := requestCount.Add(1)
-		log.Printf("[%d] Received request: %s %s", count, r.Method, r.URL.Path)
+		if !quiet {
+			log.Printf("[%d] Received request: %s %s", count, r.Method, r.URL.Path)
+		}
 
 		// Check for control endpoints
 		switch r.URL.Path {
@@ -65,7 +70,9 @@ func main() {
 
 		// Check if in fail mode
 		if failMode.Load() {
-			log.Printf("[%d] Responding with 500 (fail mode)", count)
+			if !quiet {
+				log.Printf("[%d] Responding with 500 (fail mode)", count)
+			}
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Fprintf(w, `{"error": "Simulated backend failure", "request": %d, "port": "%s"}`, count, port)
@@ -0,0 +1,304 @@
+// loadgen drives a steady request rate through the gateway and reports
+// latency percentiles. Scenarios check rate limiting and circuit breaking
+// hold up under load; run the dummy backend and gateway first:
+//
+//	go run test/dummy-backend.go -quiet
+//	go run ./test/loadgen -rps 200 -duration 30s -api-key <key>
+//	go run ./test/loadgen -scenario ratelimit -rps 50 -api-key <key>
+//	go run ./test/loadgen -scenario breaker -backend http://localhost:3001
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type options struct {
+	url         string
+	method      string
+	apiKey      string
+	token       string
+	rps         int
+	duration    time.Duration
+	concurrency int
+	timeout     time.Duration
+	scenario    string
+	backend     string
+	recoverWait time.Duration
+}
+
+// The outcome of one request; status is 0 when it never got a response
+type result struct {
+	status  int
+	latency time.Duration
+	limit   int // X-RateLimit-Limit, when the gateway sent it
+}
+
+// Everything a run observed
+type report struct {
+	results  []result
+	missed   int // Ticks skipped because every worker was busy
+	elapsed  time.Duration
+	statuses map[int]int
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.url, "url", "http://localhost:8080/api/test", "gateway URL to request")
+	flag.StringVar(&opts.method, "method", http.MethodGet, "request method")
+	flag.StringVar(&opts.apiKey, "api-key", "", "sent as X-API-Key")
+	flag.StringVar(&opts.token, "token", "", "sent as a bearer token")
+	flag.IntVar(&opts.rps, "rps", 100, "requests per second")
+	flag.DurationVar(&opts.duration, "duration", 10*time.Second, "how long to send load")
+	flag.IntVar(&opts.concurrency, "concurrency", 50, "maximum requests in flight")
+	flag.DurationVar(&opts.timeout, "timeout", 10*time.Second, "per request timeout")
+	flag.StringVar(&opts.scenario, "scenario", "steady", "steady, ratelimit or breaker")
+	flag.StringVar(&opts.backend, "backend", "http://localhost:3001", "dummy backend, for the breaker scenario")
+	flag.DurationVar(&opts.recoverWait, "recover-wait", 35*time.Second, "breaker timeout to wait out before checking recovery")
+	flag.Parse()
+
+	if opts.rps <= 0 || opts.concurrency <= 0 {
+		fmt.Fprintln(os.Stderr, "-rps and -concurrency must be positive")
+		os.Exit(2)
+	}
+
+	var err error
+	switch opts.scenario {
+	case "steady":
+		printReport(run(opts))
+	case "ratelimit":
+		err = verifyRateLimit(opts)
+	case "breaker":
+		err = verifyBreaker(opts)
+	default:
+		err = fmt.Errorf("unknown scenario %q", opts.scenario)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "FAIL: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Sends requests at a fixed rate for the duration. Load is open-loop: a slow
+// gateway doesn't lower the offered rate, it shows up as missed ticks.
+func run(opts options) *report {
+	client := &http.Client{
+		Timeout: opts.timeout,
+		Transport: &http.Transport{
+			MaxIdleConns:        opts.concurrency,
+			MaxIdleConnsPerHost: opts.concurrency,
+		},
+	}
+
+	work := make(chan struct{})
+	results := make(chan result, opts.concurrency)
+	var wg sync.WaitGroup
+	for range opts.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range work {
+				results <- send(client, opts)
+			}
+		}()
+	}
+
+	rep := &report{statuses: make(map[int]int)}
+	collected := make(chan struct{})
+	go func() {
+		for r := range results {
+			rep.results = append(rep.results, r)
+			rep.statuses[r.status]++
+		}
+		close(collected)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.duration)
+	defer cancel()
+
+	start := time.Now()
+	ticker := time.NewTicker(time.Second / time.Duration(opts.rps))
+	defer ticker.Stop()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+			select {
+			case work <- struct{}{}:
+			default:
+				rep.missed++
+			}
+		}
+	}
+
+	close(work)
+	wg.Wait()
+	close(results)
+	<-collected
+	rep.elapsed = time.Since(start)
+	return rep
+}
+
+func send(client *http.Client, opts options) result {
+	req, err := http.NewRequest(opts.method, opts.url, nil)
+	if err != nil {
+		return result{}
+	}
+	if opts.apiKey != "" {
+		req.Header.Set("X-API-Key", opts.apiKey)
+	}
+	if opts.token != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.token)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return result{latency: time.Since(start)}
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	r := result{status: resp.StatusCode, latency: time.Since(start)}
+	r.limit, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	return r
+}
+
+// Returns the latency at each percentile, of answered requests only
+func percentiles(results []result, ps ...float64) []time.Duration {
+	latencies := make([]time.Duration, 0, len(results))
+	for _, r := range results {
+		if r.status != 0 {
+			latencies = append(latencies, r.latency)
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	values := make([]time.Duration, len(ps))
+	if len(latencies) == 0 {
+		return values
+	}
+	for i, p := range ps {
+		index := int(float64(len(latencies))*p/100+0.5) - 1
+		values[i] = latencies[min(max(index, 0), len(latencies)-1)]
+	}
+	return values
+}
+
+func printReport(rep *report) {
+	sent := len(rep.results)
+	fmt.Printf("Requests:   %d in %s (%.1f/s), %d missed\n", sent, rep.elapsed.Round(time.Millisecond),
+		float64(sent)/rep.elapsed.Seconds(), rep.missed)
+
+	codes := make([]int, 0, len(rep.statuses))
+	for code := range rep.statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	fmt.Print("Status:    ")
+	for _, code := range codes {
+		name := strconv.Itoa(code)
+		if code == 0 {
+			name = "error"
+		}
+		fmt.Printf(" %s=%d", name, rep.statuses[code])
+	}
+	fmt.Println()
+
+	p := percentiles(rep.results, 50, 90, 95, 99, 100)
+	fmt.Printf("Latency:    p50=%s p90=%s p95=%s p99=%s max=%s\n",
+		p[0].Round(time.Microsecond), p[1].Round(time.Microsecond), p[2].Round(time.Microsecond),
+		p[3].Round(time.Microsecond), p[4].Round(time.Microsecond))
+}
+
+// Checks that a consumer sending faster than its tier allows is held to the
+// limit: requests beyond it get 429 and nothing else fails
+func verifyRateLimit(opts options) error {
+	if opts.apiKey == "" && opts.token == "" {
+		fmt.Println("No -api-key given; testing the anonymous per-IP limit")
+	}
+
+	rep := run(opts)
+	printReport(rep)
+
+	limit := 0
+	for _, r := range rep.results {
+		limit = max(limit, r.limit)
+	}
+	if limit == 0 {
+		return fmt.Errorf("responses carried no X-RateLimit-Limit header")
+	}
+
+	allowed, limited := rep.statuses[http.StatusOK], rep.statuses[http.StatusTooManyRequests]
+	other := len(rep.results) - allowed - limited
+	fmt.Printf("Limit:      %d/min, %d allowed, %d limited, %d other\n", limit, allowed, limited, other)
+
+	// Windows can roll over during the run, so allow one window per started minute plus one
+	windows := int(rep.elapsed/time.Minute) + 2
+	switch {
+	case other > 0:
+		return fmt.Errorf("%d requests failed with something other than 200 or 429", other)
+	case len(rep.results) > limit && limited == 0:
+		return fmt.Errorf("sent %d requests against a limit of %d but none were rate limited", len(rep.results), limit)
+	case allowed > limit*windows:
+		return fmt.Errorf("%d requests allowed, more than %d windows of %d", allowed, windows, limit)
+	}
+	fmt.Println("PASS: rate limit held under load")
+	return nil
+}
+
+// Switches the dummy backend to failing and checks the breaker opens and
+// answers quickly, then recovers once the backend does
+func verifyBreaker(opts options) error {
+	control := func(action string) error {
+		resp, err := http.Get(opts.backend + "/control/" + action)
+		if err != nil {
+			return fmt.Errorf("dummy backend unreachable: %w", err)
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	fmt.Println("Phase 1: backend failing")
+	if err := control("fail"); err != nil {
+		return err
+	}
+	defer control("recover")
+
+	failing := run(opts)
+	printReport(failing)
+
+	backendErrors, open := failing.statuses[http.StatusInternalServerError], failing.statuses[http.StatusServiceUnavailable]
+	if open == 0 {
+		return fmt.Errorf("breaker never opened: %d backend errors, no 503s", backendErrors)
+	}
+	if backendErrors >= len(failing.results)/2 {
+		return fmt.Errorf("%d of %d requests still reached the failing backend", backendErrors, len(failing.results))
+	}
+
+	fmt.Printf("\nPhase 2: backend recovered, waiting %s for the breaker to half-open\n", opts.recoverWait)
+	if err := control("recover"); err != nil {
+		return err
+	}
+	time.Sleep(opts.recoverWait)
+
+	recovered := run(opts)
+	printReport(recovered)
+	if ok := recovered.statuses[http.StatusOK]; ok < len(recovered.results)*9/10 {
+		return fmt.Errorf("only %d of %d requests succeeded after recovery", ok, len(recovered.results))
+	}
+
+	fmt.Println("PASS: breaker opened under failure and closed after recovery")
+	return nil
+}
@@ -63,6 +63,8 @@ $keyName = "analytics-test-key-$random"
 $apiKeyBody = @{
     name = $keyName
     tier = "basic"
+    owner = "test-user"
+    contact_email = "test-user@example.com"
 } | ConvertTo-Json
 
 $API_KEY = ""
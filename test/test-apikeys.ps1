@@ -61,6 +61,8 @@ $body = @{
     name       = $keyName
     created_by = "test-user"
     tier       = "basic"
+    owner      = "test-user"
+    contact_email = "test-user@example.com"
 }
 
 try {
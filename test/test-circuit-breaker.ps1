@@ -86,6 +86,8 @@ try {
         name       = "test-key-$apiKey"
         created_by = "test-user"
         tier       = "basic"
+        owner      = "test-user"
+        contact_email = "test-user@example.com"
     }
     $keyResponse = Invoke-RestMethod -Uri "$baseUrl/admin/keys" -Method POST `
         -ContentType "application/json" `
@@ -66,7 +66,7 @@ $headers = @{ Authorization = "Bearer $authToken" }
 
 $apiKey = "routing-test-$(Get-Random)"
 try {
-    $keyBody = @{ name = "routing-test-key"; created_by = "test-user"; tier = "enterprise" }
+    $keyBody = @{ name = "routing-test-key"; created_by = "test-user"; tier = "enterprise"; owner = "test-user"; contact_email = "test-user@example.com" }
     $keyResponse = Invoke-RestMethod -Uri "$baseUrl/admin/keys" -Method POST -ContentType "application/json" -Body ($keyBody | ConvertTo-Json -Compress) -Headers $headers
     $apiKey = $keyResponse.key
     Write-Host "  Created API key" -ForegroundColor Cyan
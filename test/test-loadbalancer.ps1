@@ -94,6 +94,8 @@ try {
         name       = "test-key-lb"
         created_by = "test-user"
         tier       = "enterprise"
+        owner      = "test-user"
+        contact_email = "test-user@example.com"
     }
     $keyResponse = Invoke-RestMethod -Uri "$baseUrl/admin/keys" -Method POST `
         -ContentType "application/json" `